@@ -29,7 +29,9 @@ import (
 	"github.com/srikesh3005/summer/pkg/heartbeat"
 	"github.com/srikesh3005/summer/pkg/logger"
 	"github.com/srikesh3005/summer/pkg/migrate"
+	"github.com/srikesh3005/summer/pkg/persona"
 	"github.com/srikesh3005/summer/pkg/providers"
+	"github.com/srikesh3005/summer/pkg/research"
 	"github.com/srikesh3005/summer/pkg/skills"
 	"github.com/srikesh3005/summer/pkg/state"
 	"github.com/srikesh3005/summer/pkg/tools"
@@ -134,6 +136,10 @@ func main() {
 		authCmd()
 	case "cron":
 		cronCmd()
+	case "research":
+		researchCmd()
+	case "persona":
+		personaCmd()
 	case "skills":
 		if len(os.Args) < 3 {
 			skillsHelp()
@@ -203,6 +209,8 @@ func printHelp() {
 	fmt.Println("  gateway     Start summer gateway")
 	fmt.Println("  status      Show summer status")
 	fmt.Println("  cron        Manage scheduled tasks")
+	fmt.Println("  research    Manage research alerts (new-paper digests)")
+	fmt.Println("  persona     List personas and lint persona files")
 	fmt.Println("  migrate     Migrate from OpenClaw to Summer")
 	fmt.Println("  skills      Manage skills (install, list, remove)")
 	fmt.Println("  version     Show version information")
@@ -671,7 +679,7 @@ func gatewayCmd() {
 		})
 
 	// Setup cron tool and service
-	cronService := setupCronTool(agentLoop, msgBus, cfg.WorkspacePath())
+	cronService := setupCronTool(agentLoop, msgBus, provider, cfg)
 
 	heartbeatService := heartbeat.NewHeartbeatService(
 		cfg.WorkspacePath(),
@@ -689,7 +697,7 @@ func gatewayCmd() {
 		if err != nil {
 			return tools.ErrorResult(fmt.Sprintf("Heartbeat error: %v", err))
 		}
-		if response == "HEARTBEAT_OK" {
+		if response == agent.HeartbeatOK {
 			return tools.SilentResult("Heartbeat OK")
 		}
 		// For heartbeat, always return silent - the subagent result will be
@@ -844,6 +852,8 @@ func statusCmd() {
 			fmt.Println("vLLM/Local: not set")
 		}
 
+		printExecStatus(cfg)
+
 		store, _ := auth.LoadStore()
 		if store != nil && len(store.Credentials) > 0 {
 			fmt.Println("\nOAuth/Token Auth:")
@@ -860,6 +870,68 @@ func statusCmd() {
 	}
 }
 
+// printExecStatus reports the effective exec tool settings.
+func printExecStatus(cfg *config.Config) {
+	execCfg := cfg.Tools.Exec
+	workspace := cfg.WorkspacePath()
+	restrict := cfg.Agents.Defaults.RestrictToWorkspace
+
+	fmt.Println("\nExec tool:")
+	timeout := execCfg.Timeout
+	if timeout <= 0 {
+		timeout = 60
+	}
+	fmt.Printf("  Timeout: %ds\n", timeout)
+	if execCfg.MaxOutputChars > 0 {
+		fmt.Printf("  Max output: %d chars\n", execCfg.MaxOutputChars)
+	} else {
+		fmt.Println("  Max output: 10000 chars")
+	}
+
+	workDir := workspace
+	if execCfg.WorkingDir != "" {
+		if dir, err := agent.ExecWorkingDir(workspace, restrict, execCfg.WorkingDir, agent.PathRoots(cfg.Tools.Filesystem)...); err != nil {
+			workDir = fmt.Sprintf("%s (ignored %q: %v)", workspace, execCfg.WorkingDir, err)
+		} else {
+			workDir = dir
+		}
+	}
+	fmt.Println("  Working dir:", workDir)
+	fmt.Println("  Restricted to workspace:", restrict)
+	if fsCfg := cfg.Tools.Filesystem; restrict && len(fsCfg.ReadWritePaths)+len(fsCfg.ReadOnlyPaths) > 0 {
+		if len(fsCfg.ReadWritePaths) > 0 {
+			fmt.Printf("  Extra read-write paths: %s\n", strings.Join(fsCfg.ReadWritePaths, ", "))
+		}
+		if len(fsCfg.ReadOnlyPaths) > 0 {
+			fmt.Printf("  Extra read-only paths: %s\n", strings.Join(fsCfg.ReadOnlyPaths, ", "))
+		}
+	}
+
+	if len(execCfg.AllowPatterns) > 0 {
+		fmt.Printf("  Allow patterns: %s\n", strings.Join(execCfg.AllowPatterns, ", "))
+	} else {
+		fmt.Println("  Allow patterns: any command")
+	}
+	if len(execCfg.DenyPatterns) > 0 {
+		fmt.Printf("  Deny patterns: built-in + %s\n", strings.Join(execCfg.DenyPatterns, ", "))
+	} else {
+		fmt.Println("  Deny patterns: built-in")
+	}
+	if len(execCfg.EnvPassthrough) > 0 {
+		fmt.Printf("  Environment: only %s (plus PATH, HOME)\n", strings.Join(execCfg.EnvPassthrough, ", "))
+	} else {
+		fmt.Println("  Environment: inherited")
+	}
+	if len(execCfg.EnvRedact) > 0 {
+		fmt.Printf("  Redacted: %s\n", strings.Join(execCfg.EnvRedact, ", "))
+	}
+	sandboxMode := cfg.Agents.Defaults.Sandbox.Mode
+	if sandboxMode == "" {
+		sandboxMode = "off"
+	}
+	fmt.Println("  Sandbox:", sandboxMode)
+}
+
 func authCmd() {
 	if len(os.Args) < 3 {
 		authHelp()
@@ -1084,7 +1156,8 @@ func getConfigPath() string {
 	return filepath.Join(home, ".summer", "config.json")
 }
 
-func setupCronTool(agentLoop *agent.AgentLoop, msgBus *bus.MessageBus, workspace string) *cron.CronService {
+func setupCronTool(agentLoop *agent.AgentLoop, msgBus *bus.MessageBus, provider providers.LLMProvider, cfg *config.Config) *cron.CronService {
+	workspace := cfg.WorkspacePath()
 	cronStorePath := filepath.Join(workspace, "cron", "jobs.json")
 
 	// Create cron service
@@ -1094,8 +1167,21 @@ func setupCronTool(agentLoop *agent.AgentLoop, msgBus *bus.MessageBus, workspace
 	cronTool := tools.NewCronTool(cronService, agentLoop, msgBus, workspace)
 	agentLoop.RegisterTool(cronTool)
 
+	// Research watches run on cron and send paper digests.
+	watchStore := research.NewStore(workspace)
+	httpClient := tools.NewOutboundClient(tools.OutboundPolicy{
+		AllowHosts:       cfg.Tools.Network.AllowHosts,
+		MaxResponseBytes: cfg.Tools.Network.MaxResponseBytes,
+	}, 60*time.Second)
+	watcher := tools.NewResearchWatcher(watchStore, tools.NewArXivTool(httpClient), tools.NewCrossrefTool(httpClient),
+		provider, cfg.Agents.Defaults.Model, msgBus)
+	agentLoop.RegisterTool(tools.NewResearchWatchTool(watchStore, cronService))
+
 	// Execute due jobs and deliver results back to the original channel/chat.
 	cronService.SetOnJob(func(job *cron.CronJob) (string, error) {
+		if job.Payload.Kind == research.JobKind {
+			return watcher.RunJob(context.Background(), job)
+		}
 		result := cronTool.ExecuteJob(context.Background(), job)
 		return result, nil
 	})
@@ -1321,6 +1407,145 @@ func cronEnableCmd(storePath string, disable bool) {
 	}
 }
 
+func researchCmd() {
+	if len(os.Args) < 4 || os.Args[2] != "watch" {
+		researchHelp()
+		return
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+
+	workspace := cfg.WorkspacePath()
+	store := research.NewStore(workspace)
+	cs := cron.NewCronService(filepath.Join(workspace, "cron", "jobs.json"), nil)
+
+	switch os.Args[3] {
+	case "list":
+		researchWatchListCmd(store)
+	case "add":
+		researchWatchAddCmd(store, cs)
+	case "remove":
+		if len(os.Args) < 5 {
+			fmt.Println("Usage: summer research watch remove <id>")
+			return
+		}
+		w, err := research.Unsubscribe(store, cs, os.Args[4])
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+		fmt.Printf("✓ Stopped watching %s (%s)\n", w.Label(), w.ID)
+	default:
+		fmt.Printf("Unknown research watch command: %s\n", os.Args[3])
+		researchHelp()
+	}
+}
+
+func researchHelp() {
+	fmt.Println("\nResearch commands:")
+	fmt.Println("  watch list              List research watches")
+	fmt.Println("  watch add <query>       Send a digest of new papers matching query")
+	fmt.Println("  watch remove <id>       Remove a watch by ID")
+	fmt.Println()
+	fmt.Println("Add options:")
+	fmt.Println("  --author         Query is an author name")
+	fmt.Println("  --category       Query is an arXiv category (e.g. cs.SD)")
+	fmt.Println("  --sources        Comma-separated sources: arxiv, crossref (default: both)")
+	fmt.Println("  -c, --cron       When to send the digest (default: '0 8 * * *')")
+	fmt.Println("  --channel        Channel for delivery (e.g. telegram, slack)")
+	fmt.Println("  --to             Chat ID for delivery")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  summer research watch add \"diffusion models for audio\" --channel telegram --to 123456")
+	fmt.Println("  summer research watch add cs.SD --category --cron '0 9 * * 1'")
+}
+
+func researchWatchListCmd(store *research.Store) {
+	watches, err := store.List()
+	if err != nil {
+		fmt.Printf("Error listing watches: %v\n", err)
+		return
+	}
+	if len(watches) == 0 {
+		fmt.Println("No research watches.")
+		return
+	}
+
+	fmt.Println("\nResearch Watches:")
+	fmt.Println("-----------------")
+	for _, w := range watches {
+		lastRun := "never"
+		if !w.LastRunAt.IsZero() {
+			lastRun = w.LastRunAt.Format("2006-01-02 15:04")
+		}
+		deliver := "cli"
+		if w.Channel != "" {
+			deliver = w.Channel + ":" + w.ChatID
+		}
+		fmt.Printf("  %s (%s)\n", w.Label(), w.ID)
+		fmt.Printf("    Sources: %s\n", strings.Join(w.Sources, ", "))
+		fmt.Printf("    Schedule: %s\n", w.Schedule)
+		fmt.Printf("    Deliver to: %s\n", deliver)
+		fmt.Printf("    Last run: %s (%d papers seen)\n", lastRun, len(w.Seen))
+	}
+}
+
+func researchWatchAddCmd(store *research.Store, cs *cron.CronService) {
+	w := &research.Watch{}
+	args := os.Args[4:]
+	var query []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--author":
+			w.Kind = research.KindAuthor
+		case "--category":
+			w.Kind = research.KindCategory
+		case "--sources":
+			if i+1 < len(args) {
+				w.Sources = strings.Split(args[i+1], ",")
+				i++
+			}
+		case "-c", "--cron":
+			if i+1 < len(args) {
+				w.Schedule = args[i+1]
+				i++
+			}
+		case "--channel":
+			if i+1 < len(args) {
+				w.Channel = args[i+1]
+				i++
+			}
+		case "--to":
+			if i+1 < len(args) {
+				w.ChatID = args[i+1]
+				i++
+			}
+		default:
+			query = append(query, args[i])
+		}
+	}
+	w.Query = strings.Join(query, " ")
+
+	if w.Query == "" {
+		fmt.Println("Usage: summer research watch add <query> [options]")
+		return
+	}
+	if (w.Channel == "") != (w.ChatID == "") {
+		fmt.Println("Error: --channel and --to must be given together")
+		return
+	}
+
+	if err := research.Subscribe(store, cs, w); err != nil {
+		fmt.Printf("Error adding watch: %v\n", err)
+		return
+	}
+	fmt.Printf("✓ Watching %s on %s (%s, schedule %s)\n", w.Label(), strings.Join(w.Sources, ", "), w.ID, w.Schedule)
+}
+
 func skillsHelp() {
 	fmt.Println("\nSkills commands:")
 	fmt.Println("  list                    List installed skills")
@@ -1521,3 +1746,70 @@ func skillsShowCmd(loader *skills.SkillsLoader, skillName string) {
 	fmt.Println("----------------------")
 	fmt.Println(content)
 }
+
+func personaCmd() {
+	if len(os.Args) < 3 || (os.Args[2] != "list" && os.Args[2] != "lint") {
+		personaHelp()
+		return
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+	defaults := cfg.Agents.Defaults
+	personas := persona.NewManager(cfg.WorkspacePath(), defaults.Persona, defaults.Personas)
+
+	if os.Args[2] == "list" {
+		list, err := personas.List()
+		if err != nil {
+			fmt.Printf("Error listing personas: %v\n", err)
+			return
+		}
+		fmt.Printf("Default persona: %s\n\n", personas.Resolve("", ""))
+		for _, p := range list {
+			source := p.Path
+			if p.Builtin() {
+				source = "built-in"
+			}
+			fmt.Printf("  %-12s ~%5d tokens  %s (%s)\n", p.Name, persona.EstimateTokens(p.Content), p.Description, source)
+		}
+		return
+	}
+
+	var names []string
+	if len(os.Args) > 3 {
+		names = os.Args[3:]
+	} else {
+		list, err := personas.List()
+		if err != nil {
+			fmt.Printf("Error listing personas: %v\n", err)
+			return
+		}
+		for _, p := range list {
+			names = append(names, p.Name)
+		}
+	}
+	for i, name := range names {
+		if i > 0 {
+			fmt.Println()
+		}
+		p, err := personas.Load(name)
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			continue
+		}
+		fmt.Println(persona.Lint(p))
+	}
+}
+
+func personaHelp() {
+	fmt.Println("\nPersona commands:")
+	fmt.Println("  list              List available personas")
+	fmt.Println("  lint [name...]    Report prompt size and problems of personas (default: all)")
+	fmt.Println()
+	fmt.Println("Personas are Markdown files in <workspace>/personas/<name>.md. Choose one with")
+	fmt.Println("agents.defaults.persona, per channel or chat with agents.defaults.personas,")
+	fmt.Println("or with /persona <name> in a chat.")
+}
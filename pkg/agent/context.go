@@ -9,9 +9,11 @@ import (
 	"time"
 
 	"github.com/srikesh3005/summer/pkg/logger"
+	"github.com/srikesh3005/summer/pkg/persona"
 	"github.com/srikesh3005/summer/pkg/providers"
 	"github.com/srikesh3005/summer/pkg/skills"
 	"github.com/srikesh3005/summer/pkg/tools"
+	"github.com/srikesh3005/summer/pkg/utils"
 )
 
 type ContextBuilder struct {
@@ -19,6 +21,7 @@ type ContextBuilder struct {
 	skillsLoader *skills.SkillsLoader
 	memory       *MemoryStore
 	tools        *tools.ToolRegistry // Direct reference to tool registry
+	personas     *persona.Manager
 }
 
 func getGlobalConfigDir() string {
@@ -48,8 +51,31 @@ func (cb *ContextBuilder) SetToolsRegistry(registry *tools.ToolRegistry) {
 	cb.tools = registry
 }
 
-func (cb *ContextBuilder) getIdentity() string {
-	now := time.Now().Format("2006-01-02 15:04 (Monday)")
+// SetPersonas sets where the persona of each conversation comes from.
+func (cb *ContextBuilder) SetPersonas(m *persona.Manager) {
+	cb.personas = m
+}
+
+// personaText returns the persona specification of a conversation. Without
+// a persona manager, or if the persona is empty, the built-in default is
+// used.
+func (cb *ContextBuilder) personaText(channel, chatID string) string {
+	const fallback = "You are summer, a helpful AI assistant."
+	if cb.personas == nil {
+		return fallback
+	}
+	p, err := cb.personas.Active(channel, chatID)
+	if err != nil {
+		logger.WarnCF("agent", "Failed to load persona, using default",
+			map[string]interface{}{"error": err.Error()})
+	}
+	if p == nil || p.Content == "" {
+		return fallback
+	}
+	return p.Content
+}
+
+func (cb *ContextBuilder) getIdentity(channel, chatID string) string {
 	workspacePath, _ := filepath.Abs(filepath.Join(cb.workspace))
 	runtime := fmt.Sprintf("%s %s, Go %s", runtime.GOOS, runtime.GOARCH, runtime.Version())
 
@@ -58,9 +84,6 @@ func (cb *ContextBuilder) getIdentity() string {
 
 	return fmt.Sprintf(`# summer 🦞
 
-You are summer, a helpful AI assistant.
-
-## Current Time
 %s
 
 ## Runtime
@@ -83,7 +106,7 @@ Your workspace is at: %s
 3. **Be helpful and accurate** - When using tools, briefly explain what you're doing.
 
 4. **Memory** - When remembering something, write to %s/memory/MEMORY.md`,
-		now, runtime, workspacePath, workspacePath, workspacePath, workspacePath, toolsSection, workspacePath)
+		cb.personaText(channel, chatID), runtime, workspacePath, workspacePath, workspacePath, workspacePath, toolsSection, workspacePath)
 }
 
 func (cb *ContextBuilder) buildToolsSection() string {
@@ -107,11 +130,15 @@ func (cb *ContextBuilder) buildToolsSection() string {
 	return sb.String()
 }
 
-func (cb *ContextBuilder) BuildSystemPrompt() string {
+// BuildSystemPrompt returns the stable part of the system prompt for a
+// conversation. It must not contain anything that changes from turn to turn
+// (time, summary) so that providers can cache it as a prompt prefix; see
+// buildTurnContext. The conversation only selects the persona.
+func (cb *ContextBuilder) BuildSystemPrompt(channel, chatID string) string {
 	parts := []string{}
 
 	// Core identity section
-	parts = append(parts, cb.getIdentity())
+	parts = append(parts, cb.getIdentity(channel, chatID))
 
 	// Bootstrap files
 	bootstrapContent := cb.LoadBootstrapFiles()
@@ -161,12 +188,7 @@ func (cb *ContextBuilder) LoadBootstrapFiles() string {
 func (cb *ContextBuilder) BuildMessages(history []providers.Message, summary string, currentMessage string, media []string, channel, chatID string) []providers.Message {
 	messages := []providers.Message{}
 
-	systemPrompt := cb.BuildSystemPrompt()
-
-	// Add Current Session info if provided
-	if channel != "" && chatID != "" {
-		systemPrompt += fmt.Sprintf("\n\n## Current Session\nChannel: %s\nChat ID: %s", channel, chatID)
-	}
+	systemPrompt := cb.BuildSystemPrompt(channel, chatID)
 
 	// Log system prompt summary for debugging (debug mode only)
 	logger.DebugCF("agent", "System prompt built",
@@ -186,10 +208,6 @@ func (cb *ContextBuilder) BuildMessages(history []providers.Message, summary str
 			"preview": preview,
 		})
 
-	if summary != "" {
-		systemPrompt += "\n\n## Summary of Previous Conversation\n\n" + summary
-	}
-
 	//This fix prevents the session memory from LLM failure due to elimination of toolu_IDs required from LLM
 	// --- INICIO DEL FIX ---
 	//Diegox-17
@@ -201,10 +219,16 @@ func (cb *ContextBuilder) BuildMessages(history []providers.Message, summary str
 	//Diegox-17
 	// --- FIN DEL FIX ---
 
+	// The stable prompt and the per-turn context go out as two system
+	// messages so the first one can be cached by the provider.
 	messages = append(messages, providers.Message{
 		Role:    "system",
 		Content: systemPrompt,
 	})
+	messages = append(messages, providers.Message{
+		Role:    "system",
+		Content: cb.buildTurnContext(summary, channel, chatID, media),
+	})
 
 	messages = append(messages, history...)
 
@@ -216,6 +240,57 @@ func (cb *ContextBuilder) BuildMessages(history []providers.Message, summary str
 	return messages
 }
 
+// buildTurnContext returns the parts of the system context that change between
+// turns: the current time, the active chat, the attachments of the current
+// message and the conversation summary.
+func (cb *ContextBuilder) buildTurnContext(summary, channel, chatID string, media []string) string {
+	var sb strings.Builder
+
+	sb.WriteString("## Current Time\n")
+	sb.WriteString(time.Now().Format("2006-01-02 15:04 (Monday)"))
+
+	if channel != "" && chatID != "" {
+		sb.WriteString(fmt.Sprintf("\n\n## Current Session\nChannel: %s\nChat ID: %s", channel, chatID))
+	}
+
+	if hint := cb.attachmentsHint(media); hint != "" {
+		sb.WriteString("\n\n## Attachments\n" + hint)
+	}
+
+	if summary != "" {
+		sb.WriteString("\n\n## Summary of Previous Conversation\n\n" + summary)
+	}
+
+	return sb.String()
+}
+
+// attachmentsHint lists the document attachments of the current message and
+// asks the model to read them with read_document. It is empty when there are
+// none or the tool is not available.
+func (cb *ContextBuilder) attachmentsHint(media []string) string {
+	if cb.tools == nil {
+		return ""
+	}
+	if _, ok := cb.tools.Get("read_document"); !ok {
+		return ""
+	}
+	var docs []string
+	for _, path := range media {
+		if !utils.IsDocumentFile(path) || strings.Contains(path, "://") {
+			continue
+		}
+		if rel, err := filepath.Rel(cb.workspace, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+		docs = append(docs, "- "+path)
+	}
+	if len(docs) == 0 {
+		return ""
+	}
+	return "The user attached the following document(s). Call read_document on them before answering " +
+		"questions about their content; use pages or chunk for long files.\n" + strings.Join(docs, "\n")
+}
+
 func (cb *ContextBuilder) AddToolResult(messages []providers.Message, toolCallID, toolName, result string) []providers.Message {
 	messages = append(messages, providers.Message{
 		Role:       "tool",
@@ -20,7 +20,9 @@ import (
 	"github.com/srikesh3005/summer/pkg/bus"
 	"github.com/srikesh3005/summer/pkg/config"
 	"github.com/srikesh3005/summer/pkg/constants"
+	"github.com/srikesh3005/summer/pkg/library"
 	"github.com/srikesh3005/summer/pkg/logger"
+	"github.com/srikesh3005/summer/pkg/persona"
 	"github.com/srikesh3005/summer/pkg/providers"
 	"github.com/srikesh3005/summer/pkg/session"
 	"github.com/srikesh3005/summer/pkg/state"
@@ -41,36 +43,83 @@ type AgentLoop struct {
 	tools          *tools.ToolRegistry
 	running        atomic.Bool
 	summarizing    sync.Map // Tracks which sessions are currently being summarized
+	thinking       *config.ThinkingConfig
+	showReasoning  bool
+	lastReasoning  sync.Map // Session key -> reasoning behind the last final answer
+	citationMode   string   // tools.research.verify_citations
+	personas       *persona.Manager
 }
 
 // processOptions configures how a message is processed
 type processOptions struct {
-	SessionKey      string // Session identifier for history/context
-	Channel         string // Target channel for tool execution
-	ChatID          string // Target chat ID for tool execution
-	UserMessage     string // User message content (may include prefix)
-	DefaultResponse string // Response when LLM returns empty
-	EnableSummary   bool   // Whether to trigger summarization
-	SendResponse    bool   // Whether to send response via bus
-	NoHistory       bool   // If true, don't load session history (for heartbeat)
+	SessionKey      string   // Session identifier for history/context
+	Channel         string   // Target channel for tool execution
+	ChatID          string   // Target chat ID for tool execution
+	UserMessage     string   // User message content (may include prefix)
+	DefaultResponse string   // Response when LLM returns empty
+	EnableSummary   bool     // Whether to trigger summarization
+	SendResponse    bool     // Whether to send response via bus
+	NoHistory       bool     // If true, don't load session history (for heartbeat)
+	Media           []string // Local paths of attachments to the user message
 }
 
 // createToolRegistry creates a tool registry with common tools.
 // This is shared between main agent and subagents.
-func createToolRegistry(workspace string, restrict bool, cfg *config.Config, msgBus *bus.MessageBus) *tools.ToolRegistry {
+func createToolRegistry(workspace string, restrict bool, cfg *config.Config, msgBus *bus.MessageBus, provider providers.LLMProvider) *tools.ToolRegistry {
 	registry := tools.NewToolRegistry()
+	roots := PathRoots(cfg.Tools.Filesystem)
 
 	// File system tools
-	registry.Register(tools.NewReadFileTool(workspace, restrict))
-	registry.Register(tools.NewWriteFileTool(workspace, restrict))
-	registry.Register(tools.NewListDirTool(workspace, restrict))
-	registry.Register(tools.NewEditFileTool(workspace, restrict))
-	registry.Register(tools.NewAppendFileTool(workspace, restrict))
+	registry.Register(tools.NewReadFileTool(workspace, restrict, roots...))
+	registry.Register(tools.NewWriteFileTool(workspace, restrict, roots...))
+	registry.Register(tools.NewListDirTool(workspace, restrict, roots...))
+	registry.Register(tools.NewEditFileTool(workspace, restrict, roots...))
+	registry.Register(tools.NewAppendFileTool(workspace, restrict, roots...))
+	registry.Register(tools.NewApplyPatchTool(workspace, restrict, roots...))
+	registry.Register(tools.NewGrepTool(workspace, restrict, roots...))
+	registry.Register(tools.NewGlobTool(workspace, restrict, roots...))
+	registry.Register(tools.NewReadDocumentTool(workspace, restrict, roots...))
+
+	// Math and data analysis
+	registry.Register(tools.NewCalculateTool(workspace, restrict, roots...))
+	registry.Register(tools.NewQueryCSVTool(workspace, restrict, roots...))
 
 	// Shell execution
-	registry.Register(tools.NewExecTool(workspace, restrict))
+	execTool := tools.NewExecTool(workspace, restrict, roots...)
+	configureExecTool(execTool, workspace, restrict, cfg.Tools.Exec, roots)
+	sandboxCfg := cfg.Agents.Defaults.Sandbox
+	var writable []string
+	for _, root := range roots {
+		if !root.ReadOnly {
+			writable = append(writable, root.Path)
+		}
+	}
+	sandbox, err := tools.NewSandbox(tools.SandboxOptions{
+		Mode:          sandboxCfg.Mode,
+		Workspace:     workspace,
+		WritablePaths: writable,
+		AllowNetwork:  sandboxCfg.AllowNetwork,
+		MemoryMB:      sandboxCfg.MemoryMB,
+		CPUSeconds:    sandboxCfg.CPUSeconds,
+		MaxProcesses:  sandboxCfg.MaxProcesses,
+	})
+	if err != nil {
+		logger.WarnCF("agent", "Exec sandbox unavailable, falling back to command guard",
+			map[string]interface{}{"mode": sandboxCfg.Mode, "error": err.Error()})
+	} else if sandbox != nil {
+		execTool.SetSandbox(sandbox)
+		logger.InfoCF("agent", "Exec sandbox enabled", map[string]interface{}{"sandbox": sandbox.Name()})
+	}
+	registry.Register(execTool)
+	registry.Register(tools.NewShellSessionTool(execTool))
 
-	if searchTool := tools.NewWebSearchTool(tools.WebSearchToolOptions{
+	// Outbound HTTP for tools that fetch model-chosen URLs
+	httpClient := tools.NewOutboundClient(tools.OutboundPolicy{
+		AllowHosts:       cfg.Tools.Network.AllowHosts,
+		MaxResponseBytes: cfg.Tools.Network.MaxResponseBytes,
+	}, 60*time.Second)
+
+	searchTool := tools.NewWebSearchTool(tools.WebSearchToolOptions{
 		TavilyAPIKey:         cfg.Tools.Web.Tavily.APIKey,
 		TavilyMaxResults:     cfg.Tools.Web.Tavily.MaxResults,
 		TavilyEnabled:        cfg.Tools.Web.Tavily.Enabled,
@@ -79,15 +128,50 @@ func createToolRegistry(workspace string, restrict bool, cfg *config.Config, msg
 		BraveEnabled:         cfg.Tools.Web.Brave.Enabled,
 		DuckDuckGoMaxResults: cfg.Tools.Web.DuckDuckGo.MaxResults,
 		DuckDuckGoEnabled:    cfg.Tools.Web.DuckDuckGo.Enabled,
-	}); searchTool != nil {
+		HTTPClient:           httpClient,
+	})
+	if searchTool != nil {
 		registry.Register(searchTool)
 	}
-	registry.Register(tools.NewWebFetchTool(50000))
+	registry.Register(tools.NewWebFetchTool(50000, httpClient))
+	if browserCfg := cfg.Tools.Browser; browserCfg.Enabled {
+		if chromePath := tools.FindChrome(browserCfg.ChromePath); chromePath != "" {
+			registry.Register(tools.NewBrowserTool(workspace, restrict, tools.BrowserToolOptions{
+				ChromePath:  chromePath,
+				IdleTimeout: time.Duration(browserCfg.IdleTimeout) * time.Second,
+				Policy: tools.OutboundPolicy{
+					AllowHosts: cfg.Tools.Network.AllowHosts,
+				},
+			}))
+		} else {
+			logger.DebugCF("agent", "Browser tool disabled: no Chromium-based browser found", nil)
+		}
+	}
 
 	// Research tools - free APIs for academic research
-	registry.Register(tools.NewDuckDuckGoInstantAnswerTool())
-	registry.Register(tools.NewArXivTool())
-	registry.Register(tools.NewCrossrefTool())
+	registry.Register(tools.NewDuckDuckGoInstantAnswerTool(httpClient))
+	arxivTool := tools.NewArXivTool(httpClient)
+	crossrefTool := tools.NewCrossrefTool(httpClient)
+	registry.Register(arxivTool)
+	registry.Register(crossrefTool)
+	researchCfg := cfg.Tools.Research
+	semanticScholar := tools.NewSemanticScholarTool(httpClient, researchCfg.SemanticScholarAPIKey, researchCfg.MaxDepth)
+	openAlex := tools.NewOpenAlexTool(httpClient, researchCfg.OpenAlexEmail, researchCfg.MaxDepth)
+	registry.Register(semanticScholar)
+	registry.Register(openAlex)
+	registry.Register(tools.NewPaperLookupTool(semanticScholar, openAlex))
+	registry.Register(tools.NewPubMedTool(httpClient, researchCfg.NCBIAPIKey))
+	registry.Register(tools.NewWikipediaTool(httpClient, researchCfg.WikipediaLanguage))
+
+	// Paper library - persistent collection in workspace/library
+	paperLibrary := library.New(filepath.Join(workspace, "library"))
+	resolver := &library.Resolver{Client: httpClient}
+	registry.Register(tools.NewLibraryAddTool(paperLibrary, resolver))
+	registry.Register(tools.NewLibraryTagTool(paperLibrary))
+	registry.Register(tools.NewLibraryNoteTool(paperLibrary))
+	registry.Register(tools.NewLibrarySearchTool(paperLibrary))
+	registry.Register(tools.NewLibraryExportTool(paperLibrary, workspace, restrict, roots...))
+	registry.Register(tools.NewVerifyCitationsTool(resolver))
 
 	// Hardware tools (I2C, SPI) - Linux only, returns error on other platforms
 	registry.Register(tools.NewI2CTool())
@@ -105,11 +189,96 @@ func createToolRegistry(workspace string, restrict bool, cfg *config.Config, msg
 		return nil
 	})
 	registry.Register(messageTool)
-	registry.Register(tools.NewMarkdownFileTool(workspace, restrict, msgBus))
+	markdownTool := tools.NewMarkdownFileTool(workspace, restrict, msgBus, roots...)
+	registry.Register(markdownTool)
+	registry.Register(tools.NewPlotTool(workspace, restrict, msgBus, roots...))
+
+	// Literature review pipeline built on the research, web and report tools
+	registry.Register(tools.NewLiteratureReviewTool(tools.LiteratureReviewToolOptions{
+		Provider:   provider,
+		Model:      cfg.Agents.Defaults.Model,
+		ArXiv:      arxivTool,
+		Crossref:   crossrefTool,
+		WebSearch:  searchTool,
+		Markdown:   markdownTool,
+		Message:    messageTool,
+		HTTPClient: httpClient,
+	}))
 
 	return registry
 }
 
+// PathRoots turns the tools.filesystem config into the extra directories
+// restricted tools may use besides the workspace.
+func PathRoots(fsCfg config.FilesystemToolsConfig) []tools.PathRoot {
+	var roots []tools.PathRoot
+	add := func(paths []string, readOnly bool) {
+		for _, p := range paths {
+			if p = strings.TrimSpace(p); p == "" {
+				continue
+			}
+			if strings.HasPrefix(p, "~") {
+				if home, err := os.UserHomeDir(); err == nil {
+					p = filepath.Join(home, p[1:])
+				}
+			}
+			roots = append(roots, tools.PathRoot{Path: filepath.Clean(p), ReadOnly: readOnly})
+		}
+	}
+	add(fsCfg.ReadWritePaths, false)
+	add(fsCfg.ReadOnlyPaths, true)
+	return roots
+}
+
+// configureExecTool applies the tools.exec config. Invalid settings are
+// logged and skipped so a typo does not disable the tool.
+func configureExecTool(execTool *tools.ExecTool, workspace string, restrict bool, execCfg config.ExecToolsConfig, roots []tools.PathRoot) {
+	if execCfg.Timeout > 0 {
+		execTool.SetTimeout(time.Duration(execCfg.Timeout) * time.Second)
+	}
+	execTool.SetMaxOutput(execCfg.MaxOutputChars)
+	if err := execTool.SetAllowPatterns(execCfg.AllowPatterns); err != nil {
+		logger.WarnCF("agent", "Ignoring exec allow patterns", map[string]interface{}{"error": err.Error()})
+	}
+	if err := execTool.SetDenyPatterns(execCfg.DenyPatterns); err != nil {
+		logger.WarnCF("agent", "Ignoring exec deny patterns", map[string]interface{}{"error": err.Error()})
+	}
+	if err := execTool.SetEnvPolicy(execCfg.EnvPassthrough, execCfg.EnvRedact); err != nil {
+		logger.WarnCF("agent", "Ignoring exec environment policy", map[string]interface{}{"error": err.Error()})
+	}
+	if execCfg.WorkingDir != "" {
+		dir, err := ExecWorkingDir(workspace, restrict, execCfg.WorkingDir, roots...)
+		if err != nil {
+			logger.WarnCF("agent", "Ignoring exec working_dir", map[string]interface{}{"working_dir": execCfg.WorkingDir, "error": err.Error()})
+		} else {
+			execTool.SetWorkingDir(dir)
+		}
+	}
+}
+
+// ExecWorkingDir resolves the configured exec working directory against the
+// workspace. It must exist and, when restricted, lie inside the workspace or
+// one of roots.
+func ExecWorkingDir(workspace string, restrict bool, dir string, roots ...tools.PathRoot) (string, error) {
+	if strings.HasPrefix(dir, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, dir[1:])
+		}
+	}
+	dir, err := tools.NewPathPolicy(workspace, restrict, roots...).Resolve(dir, tools.PathRead)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	return dir, nil
+}
+
 func NewAgentLoop(cfg *config.Config, msgBus *bus.MessageBus, provider providers.LLMProvider) *AgentLoop {
 	workspace := cfg.WorkspacePath()
 	os.MkdirAll(workspace, 0755)
@@ -117,12 +286,12 @@ func NewAgentLoop(cfg *config.Config, msgBus *bus.MessageBus, provider providers
 	restrict := cfg.Agents.Defaults.RestrictToWorkspace
 
 	// Create tool registry for main agent
-	toolsRegistry := createToolRegistry(workspace, restrict, cfg, msgBus)
+	toolsRegistry := createToolRegistry(workspace, restrict, cfg, msgBus, provider)
 
 	// Create subagent manager with its own tool registry
 	// TEMPORARILY DISABLED: Groq model has issues with subagent tool format
 	// subagentManager := tools.NewSubagentManager(provider, cfg.Agents.Defaults.Model, workspace, msgBus)
-	// subagentTools := createToolRegistry(workspace, restrict, cfg, msgBus)
+	// subagentTools := createToolRegistry(workspace, restrict, cfg, msgBus, provider)
 	// subagentManager.SetTools(subagentTools)
 	// spawnTool := tools.NewSpawnTool(subagentManager)
 	// toolsRegistry.Register(spawnTool)
@@ -137,8 +306,10 @@ func NewAgentLoop(cfg *config.Config, msgBus *bus.MessageBus, provider providers
 	// Create context builder and set tools registry
 	contextBuilder := NewContextBuilder(workspace)
 	contextBuilder.SetToolsRegistry(toolsRegistry)
+	personas := persona.NewManager(workspace, cfg.Agents.Defaults.Persona, cfg.Agents.Defaults.Personas)
+	contextBuilder.SetPersonas(personas)
 
-	return &AgentLoop{
+	al := &AgentLoop{
 		bus:            msgBus,
 		provider:       provider,
 		workspace:      workspace,
@@ -150,7 +321,21 @@ func NewAgentLoop(cfg *config.Config, msgBus *bus.MessageBus, provider providers
 		contextBuilder: contextBuilder,
 		tools:          toolsRegistry,
 		summarizing:    sync.Map{},
+		showReasoning:  cfg.Agents.Defaults.ShowReasoning,
+		citationMode:   cfg.Tools.Research.VerifyCitations,
+		personas:       personas,
+	}
+	if tc, ok := cfg.Agents.Defaults.ThinkingFor(al.model); ok {
+		al.thinking = &tc
+	}
+	// Replies sent through the message tool skip the final-answer path, so
+	// check their references there too.
+	if tool, ok := toolsRegistry.Get("message"); ok {
+		if mt, ok := tool.(*tools.MessageTool); ok {
+			mt.SetContentFilter(al.checkCitations)
+		}
 	}
+	return al
 }
 
 func (al *AgentLoop) Run(ctx context.Context) error {
@@ -181,12 +366,17 @@ func (al *AgentLoop) Run(ctx context.Context) error {
 					}
 				}
 
+				reasoning, hasReasoning := al.lastReasoning.LoadAndDelete(msg.SessionKey)
 				if !alreadySent {
-					al.bus.PublishOutbound(bus.OutboundMessage{
+					out := bus.OutboundMessage{
 						Channel: msg.Channel,
 						ChatID:  msg.ChatID,
 						Content: response,
-					})
+					}
+					if hasReasoning && al.showReasoning {
+						out.Reasoning = reasoning.(string)
+					}
+					al.bus.PublishOutbound(out)
 				}
 			}
 		}
@@ -197,6 +387,9 @@ func (al *AgentLoop) Run(ctx context.Context) error {
 
 func (al *AgentLoop) Stop() {
 	al.running.Store(false)
+
+	// Shut down tools that own processes, such as the browser.
+	al.tools.Close()
 }
 
 func (al *AgentLoop) RegisterTool(tool tools.Tool) {
@@ -231,10 +424,85 @@ func (al *AgentLoop) ProcessDirectWithChannel(ctx context.Context, content, sess
 	return al.processMessage(ctx, msg)
 }
 
+// resetSession clears the history and summary of a session and drops the
+// state tools and the provider keep for the conversation, such as shell
+// sessions, browser tabs and a resumable CLI session.
+func (al *AgentLoop) resetSession(sessionKey, channel, chatID string) string {
+	al.sessions.TruncateHistory(sessionKey, 0)
+	al.sessions.SetSummary(sessionKey, "")
+	if err := al.sessions.Save(sessionKey); err != nil {
+		logger.WarnCF("agent", "Failed to save reset session", map[string]interface{}{"session_key": sessionKey, "error": err.Error()})
+	}
+	al.tools.ResetSession(channel, chatID)
+	if resetter, ok := al.provider.(providers.SessionResetter); ok {
+		resetter.ResetSession(sessionKey)
+	}
+
+	logger.InfoCF("agent", "Session reset", map[string]interface{}{"session_key": sessionKey})
+	return "Conversation reset. History, background processes and browser tabs were cleared."
+}
+
+// personaCommand lists, switches or lints the personas of a chat.
+func (al *AgentLoop) personaCommand(channel, chatID string, args []string) string {
+	current := al.personas.Resolve(channel, chatID)
+	if len(args) == 0 || args[0] == "list" {
+		list, err := al.personas.List()
+		if err != nil {
+			return fmt.Sprintf("Error listing personas: %v", err)
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Current persona: %s\n\nAvailable:\n", current)
+		for _, p := range list {
+			fmt.Fprintf(&sb, "- %s", p.Name)
+			if p.Description != "" {
+				fmt.Fprintf(&sb, ": %s", p.Description)
+			}
+			if p.Builtin() {
+				sb.WriteString(" (built-in)")
+			}
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "\nAdd your own as %s/<name>.md. Use /persona <name> to switch, /persona reset to go back to the configured persona, /persona lint [name] to check a persona's size.",
+			al.personas.Dir())
+		return sb.String()
+	}
+
+	switch args[0] {
+	case "reset":
+		if err := al.personas.Select(channel, chatID, ""); err != nil {
+			return fmt.Sprintf("Error resetting persona: %v", err)
+		}
+		return fmt.Sprintf("Persona reset to %s.", al.personas.Resolve(channel, chatID))
+	case "lint":
+		name := current
+		if len(args) > 1 {
+			name = args[1]
+		}
+		p, err := al.personas.Load(name)
+		if err != nil {
+			return fmt.Sprintf("Error: %v", err)
+		}
+		report := persona.Lint(p)
+		// The prompt with the linted persona in place of the current one.
+		prompt := persona.EstimateTokens(al.contextBuilder.BuildSystemPrompt(channel, chatID))
+		if active, err := al.personas.Load(current); err == nil {
+			prompt += report.Tokens - persona.EstimateTokens(active.Content)
+		}
+		report.PromptTokens = prompt
+		return report.String()
+	default:
+		if err := al.personas.Select(channel, chatID, args[0]); err != nil {
+			return fmt.Sprintf("Error: %v", err)
+		}
+		logger.InfoCF("agent", "Persona selected", map[string]interface{}{"channel": channel, "chat_id": chatID, "persona": args[0]})
+		return fmt.Sprintf("Switched to persona %s for this chat.", strings.ToLower(args[0]))
+	}
+}
+
 // ProcessHeartbeat processes a heartbeat request without session history.
 // Each heartbeat is independent and doesn't accumulate context.
 func (al *AgentLoop) ProcessHeartbeat(ctx context.Context, content, channel, chatID string) (string, error) {
-	return al.runAgentLoop(ctx, processOptions{
+	response, err := al.runAgentLoop(ctx, processOptions{
 		SessionKey:      "heartbeat",
 		Channel:         channel,
 		ChatID:          chatID,
@@ -244,6 +512,58 @@ func (al *AgentLoop) ProcessHeartbeat(ctx context.Context, content, channel, cha
 		SendResponse:    false,
 		NoHistory:       true, // Don't load session history for heartbeat
 	})
+	if err != nil || response == HeartbeatOK {
+		return response, err
+	}
+	return al.heartbeatVerdict(ctx, content, response), nil
+}
+
+// HeartbeatOK is what ProcessHeartbeat returns when nothing needs the
+// user's attention.
+const HeartbeatOK = "HEARTBEAT_OK"
+
+// heartbeatVerdictFormat is the structured reply heartbeatVerdict asks for.
+var heartbeatVerdictFormat = &providers.ResponseFormat{
+	Name:        "heartbeat_verdict",
+	Description: "Whether the heartbeat result needs the user's attention, and the message for them if so.",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"needs_attention": map[string]interface{}{"type": "boolean"},
+			"message":         map[string]interface{}{"type": "string"},
+		},
+		"required":             []interface{}{"needs_attention", "message"},
+		"additionalProperties": false,
+	},
+	Strict: true,
+}
+
+// heartbeatVerdict decides whether a heartbeat reply that is not exactly
+// HEARTBEAT_OK (say "Nothing to do. HEARTBEAT_OK") has anything to report.
+// If the check fails the reply is passed on unchanged.
+func (al *AgentLoop) heartbeatVerdict(ctx context.Context, prompt, response string) string {
+	var verdict struct {
+		NeedsAttention bool   `json:"needs_attention"`
+		Message        string `json:"message"`
+	}
+	check := fmt.Sprintf("A scheduled heartbeat check ran with these instructions:\n\n%s\n\nIt produced this result:\n\n%s\n\n"+
+		"Does the result need the user's attention? If so, give the message to send them, otherwise leave message empty.", prompt, response)
+	_, err := providers.ChatStructured(ctx, al.provider, []providers.Message{{Role: "user", Content: check}}, al.model,
+		heartbeatVerdictFormat, map[string]interface{}{
+			"max_tokens":  1024,
+			"temperature": 0,
+		}, &verdict)
+	if err != nil {
+		logger.WarnCF("agent", "Heartbeat verdict failed", map[string]interface{}{"error": err.Error()})
+		return response
+	}
+	if !verdict.NeedsAttention {
+		return HeartbeatOK
+	}
+	if msg := strings.TrimSpace(verdict.Message); msg != "" {
+		return msg
+	}
+	return response
 }
 
 func (al *AgentLoop) processMessage(ctx context.Context, msg bus.InboundMessage) (string, error) {
@@ -267,6 +587,16 @@ func (al *AgentLoop) processMessage(ctx context.Context, msg bus.InboundMessage)
 		return al.processSystemMessage(ctx, msg)
 	}
 
+	// "/reset" starts the conversation over
+	if strings.TrimSpace(msg.Content) == "/reset" {
+		return al.resetSession(msg.SessionKey, msg.Channel, msg.ChatID), nil
+	}
+
+	// "/persona [name|reset|lint [name]]" shows or switches the chat's persona
+	if args, ok := strings.CutPrefix(strings.TrimSpace(msg.Content), "/persona"); ok && (args == "" || args[0] == ' ') {
+		return al.personaCommand(msg.Channel, msg.ChatID, strings.Fields(args)), nil
+	}
+
 	// Process as user message
 	return al.runAgentLoop(ctx, processOptions{
 		SessionKey:      msg.SessionKey,
@@ -276,9 +606,55 @@ func (al *AgentLoop) processMessage(ctx context.Context, msg bus.InboundMessage)
 		DefaultResponse: "I've completed processing but have no response to give.",
 		EnableSummary:   true,
 		SendResponse:    false,
+		Media:           al.importAttachments(msg.Media),
 	})
 }
 
+// importAttachments moves document attachments into the workspace's
+// attachments directory so that read_document can open them even when tools
+// are restricted to the workspace. Other media is returned unchanged.
+func (al *AgentLoop) importAttachments(media []string) []string {
+	out := make([]string, 0, len(media))
+	for _, path := range media {
+		if !utils.IsDocumentFile(path) || strings.Contains(path, "://") {
+			out = append(out, path)
+			continue
+		}
+		dir := filepath.Join(al.workspace, "attachments")
+		dst := filepath.Join(dir, filepath.Base(path))
+		err := os.MkdirAll(dir, 0755)
+		if err == nil {
+			err = moveFile(path, dst)
+		}
+		if err != nil {
+			logger.WarnCF("agent", "Failed to import attachment", map[string]interface{}{
+				"path":  path,
+				"error": err.Error(),
+			})
+			out = append(out, path)
+			continue
+		}
+		out = append(out, dst)
+	}
+	return out
+}
+
+// moveFile renames src to dst, copying when they are on different devices.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return err
+	}
+	os.Remove(src)
+	return nil
+}
+
 func (al *AgentLoop) processSystemMessage(ctx context.Context, msg bus.InboundMessage) (string, error) {
 	// Verify this is a system message
 	if msg.Channel != "system" {
@@ -334,6 +710,10 @@ func (al *AgentLoop) processSystemMessage(ctx context.Context, msg bus.InboundMe
 // runAgentLoop is the core message processing logic.
 // It handles context building, LLM calls, tool execution, and response handling.
 func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (string, error) {
+	// Reasoning left by an earlier turn that was never published must not be
+	// attached to this turn's answer.
+	al.lastReasoning.Delete(opts.SessionKey)
+
 	// 0. Record last channel for heartbeat notifications (skip internal channels)
 	if opts.Channel != "" && opts.ChatID != "" {
 		// Don't record internal channels (cli, system, subagent)
@@ -370,7 +750,7 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 		history,
 		summary,
 		opts.UserMessage,
-		nil,
+		opts.Media,
 		opts.Channel,
 		opts.ChatID,
 	)
@@ -391,6 +771,8 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 	if finalContent == "" {
 		finalContent = opts.DefaultResponse
 	}
+	// Check references before the answer is saved to the session or sent
+	finalContent = al.checkCitations(ctx, finalContent)
 
 	// 6. Save final assistant message to session
 	al.sessions.AddMessage(opts.SessionKey, "assistant", finalContent)
@@ -466,10 +848,24 @@ func (al *AgentLoop) runLLMIteration(ctx context.Context, messages []providers.M
 		if callMaxTokens <= 0 || callMaxTokens > 4096 {
 			callMaxTokens = 4096
 		}
-		response, err := al.provider.Chat(ctx, messages, providerToolDefs, al.model, map[string]interface{}{
-			"max_tokens":  callMaxTokens,
-			"temperature": 0.7,
-		})
+		llmOpts := map[string]interface{}{
+			"max_tokens":       callMaxTokens,
+			"temperature":      0.7,
+			"prompt_cache_key": opts.SessionKey,
+		}
+		if !opts.NoHistory {
+			// Lets stateful providers (claude-cli) resume their own session.
+			llmOpts["session_key"] = opts.SessionKey
+		}
+		if al.thinking != nil {
+			if al.thinking.BudgetTokens > 0 {
+				llmOpts["thinking_budget"] = al.thinking.BudgetTokens
+			}
+			if al.thinking.Effort != "" {
+				llmOpts["reasoning_effort"] = al.thinking.Effort
+			}
+		}
+		response, err := al.provider.Chat(ctx, messages, providerToolDefs, al.model, llmOpts)
 
 		if err != nil {
 			logger.ErrorCF("agent", "LLM call failed",
@@ -480,6 +876,18 @@ func (al *AgentLoop) runLLMIteration(ctx context.Context, messages []providers.M
 			return "", iteration, fmt.Errorf("LLM call failed: %w", err)
 		}
 
+		if response.Usage != nil {
+			logger.DebugCF("agent", "LLM usage",
+				map[string]interface{}{
+					"iteration":             iteration,
+					"prompt_tokens":         response.Usage.PromptTokens,
+					"completion_tokens":     response.Usage.CompletionTokens,
+					"cache_read_tokens":     response.Usage.CacheReadTokens,
+					"cache_creation_tokens": response.Usage.CacheCreationTokens,
+					"cache_miss_tokens":     response.Usage.CacheMissTokens(),
+				})
+		}
+
 		// Some providers/models emit tool calls as inline tagged text instead of
 		// structured tool_calls. Recover them so they execute normally.
 		if len(response.ToolCalls) == 0 {
@@ -492,6 +900,9 @@ func (al *AgentLoop) runLLMIteration(ctx context.Context, messages []providers.M
 		// Check if no tool calls - we're done
 		if len(response.ToolCalls) == 0 {
 			finalContent = response.Content
+			if response.Reasoning != "" {
+				al.lastReasoning.Store(opts.SessionKey, response.Reasoning)
+			}
 			logger.InfoCF("agent", "LLM response without tool calls (direct answer)",
 				map[string]interface{}{
 					"iteration":     iteration,
@@ -512,10 +923,12 @@ func (al *AgentLoop) runLLMIteration(ctx context.Context, messages []providers.M
 				"iteration": iteration,
 			})
 
-		// Build assistant message with tool calls
+		// Build assistant message with tool calls. Reasoning goes back to the
+		// provider for the rest of this turn but is not kept in the session.
 		assistantMsg := providers.Message{
-			Role:    "assistant",
-			Content: response.Content,
+			Role:            "assistant",
+			Content:         response.Content,
+			ReasoningBlocks: response.ReasoningBlocks,
 		}
 		for _, tc := range response.ToolCalls {
 			argumentsJSON, _ := json.Marshal(tc.Arguments)
@@ -531,7 +944,9 @@ func (al *AgentLoop) runLLMIteration(ctx context.Context, messages []providers.M
 		messages = append(messages, assistantMsg)
 
 		// Save assistant message with tool calls to session
-		al.sessions.AddFullMessage(opts.SessionKey, assistantMsg)
+		sessionMsg := assistantMsg
+		sessionMsg.ReasoningBlocks = nil
+		al.sessions.AddFullMessage(opts.SessionKey, sessionMsg)
 
 		// Execute tool calls
 		for _, tc := range response.ToolCalls {
@@ -597,6 +1012,28 @@ func (al *AgentLoop) runLLMIteration(ctx context.Context, messages []providers.M
 	return finalContent, iteration, nil
 }
 
+// checkCitations flags or removes references in a response that do not
+// resolve in Crossref or arXiv, before it is saved and sent.
+func (al *AgentLoop) checkCitations(ctx context.Context, content string) string {
+	mode := al.citationMode
+	if mode != library.CheckFlag && mode != library.CheckRemove {
+		return content
+	}
+	// Most answers cite nothing; don't look anything up for them.
+	if !library.HasCitations(content) {
+		return content
+	}
+	tool, ok := al.tools.Get("verify_citations")
+	if !ok {
+		return content
+	}
+	vt, ok := tool.(*tools.VerifyCitationsTool)
+	if !ok {
+		return content
+	}
+	return vt.CheckResponse(ctx, content, mode)
+}
+
 // updateToolContexts updates the context for tools that need channel/chatID info.
 func (al *AgentLoop) updateToolContexts(channel, chatID string) {
 	// Use ContextualTool interface instead of type assertions
@@ -752,12 +1189,8 @@ func (al *AgentLoop) summarizeSession(sessionKey string) {
 
 		// Merge them
 		mergePrompt := fmt.Sprintf("Merge these two conversation summaries into one cohesive summary:\n\n1: %s\n\n2: %s", s1, s2)
-		resp, err := al.provider.Chat(ctx, []providers.Message{{Role: "user", Content: mergePrompt}}, nil, al.model, map[string]interface{}{
-			"max_tokens":  1024,
-			"temperature": 0.3,
-		})
-		if err == nil {
-			finalSummary = resp.Content
+		if merged, err := al.chatSummary(ctx, mergePrompt); err == nil {
+			finalSummary = merged
 		} else {
 			finalSummary = s1 + " " + s2
 		}
@@ -787,14 +1220,39 @@ func (al *AgentLoop) summarizeBatch(ctx context.Context, batch []providers.Messa
 		prompt += fmt.Sprintf("%s: %s\n", m.Role, m.Content)
 	}
 
-	response, err := al.provider.Chat(ctx, []providers.Message{{Role: "user", Content: prompt}}, nil, al.model, map[string]interface{}{
-		"max_tokens":  1024,
-		"temperature": 0.3,
-	})
+	return al.chatSummary(ctx, prompt)
+}
+
+// summaryFormat is the structured reply the summarizer asks the LLM for, so
+// that preambles like "Here is the summary:" stay out of the session.
+var summaryFormat = &providers.ResponseFormat{
+	Name:        "conversation_summary",
+	Description: "The summary of the conversation.",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"summary": map[string]interface{}{"type": "string"},
+		},
+		"required":             []interface{}{"summary"},
+		"additionalProperties": false,
+	},
+	Strict: true,
+}
+
+// chatSummary sends a summarization prompt and returns the summary text.
+func (al *AgentLoop) chatSummary(ctx context.Context, prompt string) (string, error) {
+	var reply struct {
+		Summary string `json:"summary"`
+	}
+	_, err := providers.ChatStructured(ctx, al.provider, []providers.Message{{Role: "user", Content: prompt}}, al.model,
+		summaryFormat, map[string]interface{}{
+			"max_tokens":  1024,
+			"temperature": 0.3,
+		}, &reply)
 	if err != nil {
 		return "", err
 	}
-	return response.Content, nil
+	return strings.TrimSpace(reply.Summary), nil
 }
 
 // estimateTokens estimates the number of tokens in a message list.
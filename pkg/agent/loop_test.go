@@ -2,8 +2,10 @@ package agent
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/srikesh3005/summer/pkg/config"
 	"github.com/srikesh3005/summer/pkg/providers"
 	"github.com/srikesh3005/summer/pkg/tools"
+	"github.com/srikesh3005/summer/pkg/utils"
 )
 
 // mockProvider is a simple mock LLM provider for testing
@@ -527,3 +530,430 @@ func TestToolResult_UserFacingToolDoesSendMessage(t *testing.T) {
 		t.Errorf("Expected 'Command output: hello world', got: %s", response)
 	}
 }
+
+// mockResettableTool records session resets
+type mockResettableTool struct {
+	mockCustomTool
+	resets []string
+}
+
+func (m *mockResettableTool) Name() string {
+	return "mock_resettable"
+}
+
+func (m *mockResettableTool) ResetSession(channel, chatID string) {
+	m.resets = append(m.resets, channel+":"+chatID)
+}
+
+// TestAgentLoop_ResetSession verifies /reset clears history and tool state
+func TestAgentLoop_ResetSession(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         tmpDir,
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &simpleMockProvider{response: "hi"})
+	defer al.Stop()
+	resettable := &mockResettableTool{}
+	al.RegisterTool(resettable)
+	helper := testHelper{al: al}
+
+	msg := bus.InboundMessage{
+		Channel:    "test",
+		SenderID:   "user1",
+		ChatID:     "chat1",
+		Content:    "hello",
+		SessionKey: "test-session",
+	}
+	helper.executeAndGetResponse(t, context.Background(), msg)
+	al.sessions.SetSummary("test-session", "earlier talk")
+	if len(al.sessions.GetHistory("test-session")) == 0 {
+		t.Fatal("Expected history after the first message")
+	}
+
+	msg.Content = " /reset "
+	response := helper.executeAndGetResponse(t, context.Background(), msg)
+	if response == "hi" {
+		t.Fatal("Expected /reset to be handled without the LLM")
+	}
+	if n := len(al.sessions.GetHistory("test-session")); n != 0 {
+		t.Errorf("Expected empty history after reset, got %d messages", n)
+	}
+	if summary := al.sessions.GetSummary("test-session"); summary != "" {
+		t.Errorf("Expected summary to be cleared, got %q", summary)
+	}
+	if len(resettable.resets) != 1 || resettable.resets[0] != "test:chat1" {
+		t.Errorf("Expected tool state for test:chat1 to be reset, got %v", resettable.resets)
+	}
+}
+
+// resettableProvider records provider session resets
+type resettableProvider struct {
+	simpleMockProvider
+	resets []string
+}
+
+func (m *resettableProvider) ResetSession(sessionKey string) {
+	m.resets = append(m.resets, sessionKey)
+}
+
+// TestAgentLoop_ResetSessionResetsProvider verifies /reset also drops the
+// conversation a provider keeps itself, such as a resumable CLI session
+func TestAgentLoop_ResetSessionResetsProvider(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	provider := &resettableProvider{simpleMockProvider: simpleMockProvider{response: "hi"}}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	defer al.Stop()
+	helper := testHelper{al: al}
+
+	msg := bus.InboundMessage{
+		Channel:    "test",
+		SenderID:   "user1",
+		ChatID:     "chat1",
+		Content:    "/reset",
+		SessionKey: "test-session",
+	}
+	helper.executeAndGetResponse(t, context.Background(), msg)
+	if len(provider.resets) != 1 || provider.resets[0] != "test-session" {
+		t.Errorf("Expected the provider session test-session to be reset, got %v", provider.resets)
+	}
+}
+
+// reasoningProvider returns its responses in turn
+type reasoningProvider struct {
+	responses []providers.LLMResponse
+}
+
+func (m *reasoningProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (*providers.LLMResponse, error) {
+	resp := m.responses[0]
+	m.responses = m.responses[1:]
+	return &resp, nil
+}
+
+func (m *reasoningProvider) GetDefaultModel() string {
+	return "mock-model"
+}
+
+// TestAgentLoop_ReasoningDoesNotLeak verifies reasoning that was never
+// published is not attached to a later answer without reasoning
+func TestAgentLoop_ReasoningDoesNotLeak(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+				ShowReasoning:     true,
+			},
+		},
+	}
+	provider := &reasoningProvider{responses: []providers.LLMResponse{
+		{Content: "first", Reasoning: "thought about the first"},
+		{Content: "second"},
+	}}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	defer al.Stop()
+
+	// A direct call (cron, heartbeat) leaves its reasoning unpublished.
+	if _, err := al.ProcessDirect(context.Background(), "one", "test-session"); err != nil {
+		t.Fatalf("ProcessDirect: %v", err)
+	}
+	if _, ok := al.lastReasoning.Load("test-session"); !ok {
+		t.Fatal("Expected the first answer's reasoning to be stored")
+	}
+	if _, err := al.ProcessDirect(context.Background(), "two", "test-session"); err != nil {
+		t.Fatalf("ProcessDirect: %v", err)
+	}
+	if reasoning, ok := al.lastReasoning.Load("test-session"); ok {
+		t.Errorf("Expected no reasoning for the second answer, got %q", reasoning)
+	}
+}
+
+func newStructuredTestLoop(t *testing.T, responses ...providers.LLMResponse) *AgentLoop {
+	t.Helper()
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &reasoningProvider{responses: responses})
+	t.Cleanup(al.Stop)
+	return al
+}
+
+// TestAgentLoop_SummarizeUsesStructuredOutput verifies the session summary
+// is taken from the structured reply, without the model's preamble
+func TestAgentLoop_SummarizeUsesStructuredOutput(t *testing.T) {
+	al := newStructuredTestLoop(t, providers.LLMResponse{Content: `Here is the summary: {"summary": " The user planned a trip to Kyoto. "}`})
+	for i := 0; i < 4; i++ {
+		al.sessions.AddMessage("s", "user", fmt.Sprintf("question %d", i))
+		al.sessions.AddMessage("s", "assistant", fmt.Sprintf("answer %d", i))
+	}
+
+	al.summarizeSession("s")
+
+	if got := al.sessions.GetSummary("s"); got != "The user planned a trip to Kyoto." {
+		t.Errorf("Expected the structured summary, got %q", got)
+	}
+	if got := len(al.sessions.GetHistory("s")); got != 4 {
+		t.Errorf("Expected history truncated to 4 messages, got %d", got)
+	}
+}
+
+// TestAgentLoop_HeartbeatVerdict verifies heartbeat replies that are not
+// exactly HEARTBEAT_OK are classified through structured output
+func TestAgentLoop_HeartbeatVerdict(t *testing.T) {
+	al := newStructuredTestLoop(t,
+		providers.LLMResponse{Content: "Nothing needs doing right now. HEARTBEAT_OK"},
+		providers.LLMResponse{Content: `{"needs_attention": false, "message": ""}`},
+	)
+	if got, err := al.ProcessHeartbeat(context.Background(), "check the disk", "cli", "direct"); err != nil || got != HeartbeatOK {
+		t.Errorf("Expected %s, got %q, %v", HeartbeatOK, got, err)
+	}
+
+	al = newStructuredTestLoop(t,
+		providers.LLMResponse{Content: "I checked the disk. It is 95% full."},
+		providers.LLMResponse{Content: `{"needs_attention": true, "message": "The disk is 95% full."}`},
+	)
+	if got, err := al.ProcessHeartbeat(context.Background(), "check the disk", "cli", "direct"); err != nil || got != "The disk is 95% full." {
+		t.Errorf("Expected the verdict message, got %q, %v", got, err)
+	}
+
+	// An exact HEARTBEAT_OK needs no second call.
+	al = newStructuredTestLoop(t, providers.LLMResponse{Content: HeartbeatOK})
+	if got, err := al.ProcessHeartbeat(context.Background(), "check the disk", "cli", "direct"); err != nil || got != HeartbeatOK {
+		t.Errorf("Expected %s, got %q, %v", HeartbeatOK, got, err)
+	}
+}
+
+// capturingProvider records the messages of the last request
+type capturingProvider struct {
+	messages []providers.Message
+}
+
+func (m *capturingProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (*providers.LLMResponse, error) {
+	m.messages = messages
+	return &providers.LLMResponse{Content: "ok"}, nil
+}
+
+func (m *capturingProvider) GetDefaultModel() string {
+	return "mock-model"
+}
+
+// TestAgentLoop_DocumentAttachments verifies documents are moved into the
+// workspace and announced in the turn context
+func TestAgentLoop_DocumentAttachments(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:           tmpDir,
+				Model:               "test-model",
+				MaxTokens:           4096,
+				MaxToolIterations:   10,
+				RestrictToWorkspace: true,
+			},
+		},
+	}
+	provider := &capturingProvider{}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	defer al.Stop()
+
+	download := filepath.Join(t.TempDir(), "1a2b3c4d_report.pdf")
+	if err := os.WriteFile(download, []byte("%PDF-1.4"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	photo := filepath.Join(t.TempDir(), "photo.jpg")
+
+	helper := testHelper{al: al}
+	helper.executeAndGetResponse(t, context.Background(), bus.InboundMessage{
+		Channel:    "telegram",
+		SenderID:   "user1",
+		ChatID:     "chat1",
+		Content:    "[file: report.pdf]\nsummarize this",
+		Media:      []string{download, photo},
+		SessionKey: "test-session",
+	})
+
+	imported := filepath.Join(tmpDir, "attachments", "1a2b3c4d_report.pdf")
+	if _, err := os.Stat(imported); err != nil {
+		t.Fatalf("Expected attachment in the workspace: %v", err)
+	}
+	if _, err := os.Stat(download); !os.IsNotExist(err) {
+		t.Errorf("Expected the downloaded file to be moved, stat error: %v", err)
+	}
+
+	var turnContext string
+	for _, m := range provider.messages {
+		if m.Role == "system" && strings.Contains(m.Content, "## Current Time") {
+			turnContext = m.Content
+		}
+	}
+	if !strings.Contains(turnContext, "## Attachments") || !strings.Contains(turnContext, "read_document") ||
+		!strings.Contains(turnContext, "- "+filepath.Join("attachments", "1a2b3c4d_report.pdf")) {
+		t.Errorf("Expected an attachments hint in the turn context, got:\n%s", turnContext)
+	}
+	if strings.Contains(turnContext, "photo.jpg") {
+		t.Errorf("Non-document media should not be listed:\n%s", turnContext)
+	}
+}
+
+// TestAgentLoop_PersonaCommand verifies /persona switches the persona that
+// opens the system prompt, per chat and per channel
+func TestAgentLoop_PersonaCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         tmpDir,
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+				Personas:          map[string]string{"slack": "research"},
+			},
+		},
+	}
+	provider := &capturingProvider{}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	defer al.Stop()
+	helper := testHelper{al: al}
+
+	systemPrompt := func(channel string) string {
+		helper.executeAndGetResponse(t, context.Background(), bus.InboundMessage{
+			Channel: channel, SenderID: "user1", ChatID: "chat1", Content: "hello", SessionKey: channel + "-session",
+		})
+		return provider.messages[0].Content
+	}
+	command := func(content string) string {
+		provider.messages = nil
+		response := helper.executeAndGetResponse(t, context.Background(), bus.InboundMessage{
+			Channel: "telegram", SenderID: "user1", ChatID: "chat1", Content: content, SessionKey: "telegram-session",
+		})
+		if provider.messages != nil {
+			t.Fatalf("Expected %s to be handled without the LLM", content)
+		}
+		return response
+	}
+
+	if prompt := systemPrompt("telegram"); !strings.Contains(prompt, "You are summer, a helpful AI assistant.") {
+		t.Errorf("Expected the default persona, got:\n%s", utils.Truncate(prompt, 300))
+	}
+	if prompt := systemPrompt("slack"); !strings.Contains(prompt, "You are SUMMER, a calm") {
+		t.Errorf("Expected the channel's research persona, got:\n%s", utils.Truncate(prompt, 300))
+	}
+
+	if list := command("/persona"); !strings.Contains(list, "Current persona: default") || !strings.Contains(list, "- research: ") {
+		t.Errorf("Unexpected persona list:\n%s", list)
+	}
+	if response := command("/persona nonexistent"); !strings.Contains(response, "not found") {
+		t.Errorf("Expected an unknown persona to be rejected, got %q", response)
+	}
+	command("/persona research")
+	if prompt := systemPrompt("telegram"); !strings.Contains(prompt, "You are SUMMER, a calm") || strings.Contains(prompt, "a helpful AI assistant") {
+		t.Errorf("Expected the selected persona, got:\n%s", utils.Truncate(prompt, 300))
+	}
+	if report := command("/persona lint default"); !strings.Contains(report, "Persona default:") || !strings.Contains(report, "Share of system prompt") {
+		t.Errorf("Unexpected lint report:\n%s", report)
+	}
+	if response := command("/persona reset"); response != "Persona reset to default." {
+		t.Errorf("Unexpected reset response: %q", response)
+	}
+}
+
+// TestExecWorkingDir verifies resolution of the configured exec directory
+func TestExecWorkingDir(t *testing.T) {
+	workspace := t.TempDir()
+	if err := os.Mkdir(filepath.Join(workspace, "projects"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	outside := t.TempDir()
+
+	if dir, err := ExecWorkingDir(workspace, true, "projects"); err != nil || dir != filepath.Join(workspace, "projects") {
+		t.Errorf("Expected relative dir inside the workspace, got %q, %v", dir, err)
+	}
+	if _, err := ExecWorkingDir(workspace, true, "missing"); err == nil {
+		t.Error("Expected error for a missing directory")
+	}
+	if _, err := ExecWorkingDir(workspace, true, outside); err == nil {
+		t.Error("Expected error for a directory outside the restricted workspace")
+	}
+	if dir, err := ExecWorkingDir(workspace, false, outside); err != nil || dir != outside {
+		t.Errorf("Expected absolute dir when unrestricted, got %q, %v", dir, err)
+	}
+}
+
+// TestPathRoots verifies tools.filesystem paths become extra tool roots
+func TestPathRoots(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	roots := PathRoots(config.FilesystemToolsConfig{
+		ReadOnlyPaths:  config.FlexibleStringSlice{"~/papers", " "},
+		ReadWritePaths: config.FlexibleStringSlice{"/data/shared/"},
+	})
+	want := []tools.PathRoot{
+		{Path: "/data/shared"},
+		{Path: filepath.Join(home, "papers"), ReadOnly: true},
+	}
+	if len(roots) != len(want) {
+		t.Fatalf("Expected %d roots, got %v", len(want), roots)
+	}
+	for i := range want {
+		if roots[i] != want[i] {
+			t.Errorf("Root %d: expected %+v, got %+v", i, want[i], roots[i])
+		}
+	}
+
+	workspace, outside := t.TempDir(), t.TempDir()
+	roots = []tools.PathRoot{{Path: outside, ReadOnly: true}}
+	if dir, err := ExecWorkingDir(workspace, true, outside, roots...); err != nil || dir != outside {
+		t.Errorf("Expected an extra root to be a valid exec directory, got %q, %v", dir, err)
+	}
+}
+
+// TestCreateToolRegistry_ExecConfig verifies tools.exec settings reach the exec tool
+func TestCreateToolRegistry_ExecConfig(t *testing.T) {
+	workspace := t.TempDir()
+	if err := os.Mkdir(filepath.Join(workspace, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.Tools.Browser.Enabled = false
+	cfg.Tools.Exec.WorkingDir = "sub"
+	cfg.Tools.Exec.DenyPatterns = config.FlexibleStringSlice{`\bwget\b`}
+
+	registry := createToolRegistry(workspace, true, cfg, bus.NewMessageBus(), nil)
+	defer registry.Close()
+
+	result := registry.Execute(context.Background(), "exec", map[string]interface{}{"command": "pwd"})
+	if result.IsError || filepath.Base(strings.TrimSpace(result.ForLLM)) != "sub" {
+		t.Errorf("Expected commands to run in the configured working dir, got: %s", result.ForLLM)
+	}
+	result = registry.Execute(context.Background(), "exec", map[string]interface{}{"command": "wget example.com"})
+	if !result.IsError {
+		t.Errorf("Expected configured deny pattern to apply, got: %s", result.ForLLM)
+	}
+}
@@ -17,6 +17,8 @@ type OutboundMessage struct {
 	// Optional file delivery fields (channel-specific support).
 	FilePath string `json:"file_path,omitempty"`
 	FileName string `json:"file_name,omitempty"`
+	// Optional model reasoning, shown collapsed by channels that support it.
+	Reasoning string `json:"reasoning,omitempty"`
 }
 
 type MessageHandler func(InboundMessage) error
@@ -0,0 +1,669 @@
+// Package calc evaluates arithmetic expressions with physical units,
+// statistics over lists of numbers and filters over table rows, so that the
+// agent computes numbers instead of guessing them. It needs no interpreter
+// and runs on any board summer runs on.
+//
+// Expressions support + - * / % ^, comparisons, and/or/not, string and
+// list literals, functions such as sqrt, round, mean and percentile, unit
+// suffixes ("3 ft + 2 in") and a final conversion ("to cm", "in mph").
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind is the type of a Value.
+type Kind int
+
+const (
+	KindNumber Kind = iota
+	KindBool
+	KindString
+	KindList
+)
+
+// Value is the result of an expression. Numbers are kept in SI base units
+// with their dimension; Unit is the unit they are preferably shown in.
+type Value struct {
+	Kind Kind
+	Num  float64
+	Dim  Dim
+	Unit string
+	Str  string
+	List []float64
+}
+
+// Number returns a dimensionless number.
+func Number(f float64) Value {
+	return Value{Kind: KindNumber, Num: f}
+}
+
+// Text returns a string value.
+func Text(s string) Value {
+	return Value{Kind: KindString, Str: s}
+}
+
+// List returns a list of numbers.
+func List(xs []float64) Value {
+	return Value{Kind: KindList, List: xs}
+}
+
+func boolValue(b bool) Value {
+	if b {
+		return Value{Kind: KindBool, Num: 1}
+	}
+	return Value{Kind: KindBool}
+}
+
+// Truthy reports whether v counts as true in a condition.
+func (v Value) Truthy() bool {
+	switch v.Kind {
+	case KindString:
+		return v.Str != ""
+	case KindList:
+		return len(v.List) > 0
+	}
+	return v.Num != 0 && !math.IsNaN(v.Num)
+}
+
+// Magnitude returns a number in its display unit.
+func (v Value) Magnitude() float64 {
+	if v.Unit != "" {
+		if u, err := parseUnitString(v.Unit); err == nil && u.dim == v.Dim {
+			return (v.Num - u.offset) / u.factor
+		}
+	}
+	return v.Num
+}
+
+func (v Value) String() string {
+	switch v.Kind {
+	case KindBool:
+		return strconv.FormatBool(v.Num != 0)
+	case KindString:
+		return v.Str
+	case KindList:
+		parts := make([]string, 0, min(len(v.List), 20))
+		for i, x := range v.List {
+			if i == 20 {
+				parts = append(parts, fmt.Sprintf("… (%d values)", len(v.List)))
+				break
+			}
+			parts = append(parts, FormatNumber(x))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	if v.Unit != "" {
+		if u, err := parseUnitString(v.Unit); err == nil && u.dim == v.Dim {
+			return FormatNumber((v.Num-u.offset)/u.factor) + " " + v.Unit
+		}
+	}
+	if v.Dim.IsZero() {
+		return FormatNumber(v.Num)
+	}
+	return FormatNumber(v.Num) + " " + v.Dim.String()
+}
+
+// FormatNumber formats f with up to 10 significant digits, which hides
+// floating-point noise such as 0.1+0.2 = 0.30000000000000004.
+func FormatNumber(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case f == math.Trunc(f) && math.Abs(f) < 1e15:
+		return strconv.FormatFloat(f, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(f, 'g', 10, 64)
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokNum
+	tokIdent
+	tokStr
+	tokOp
+)
+
+type token struct {
+	kind   tokKind
+	text   string
+	num    float64
+	pos    int
+	quoted bool // a `quoted` identifier, never a keyword or unit
+}
+
+var twoCharOps = []string{"**", "==", "!=", "<=", ">=", "&&", "||", "<>"}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isDigit(c) || c == '.' && i+1 < len(src) && isDigit(src[i+1]):
+			j := i
+			for j < len(src) && (isDigit(src[j]) || src[j] == '.' || src[j] == '_') {
+				j++
+			}
+			if j < len(src) && (src[j] == 'e' || src[j] == 'E') {
+				k := j + 1
+				if k < len(src) && (src[k] == '+' || src[k] == '-') {
+					k++
+				}
+				if k < len(src) && isDigit(src[k]) {
+					for j = k; j < len(src) && isDigit(src[j]); j++ {
+					}
+				}
+			}
+			f, err := strconv.ParseFloat(strings.ReplaceAll(src[i:j], "_", ""), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", src[i:j])
+			}
+			toks = append(toks, token{kind: tokNum, text: src[i:j], num: f, pos: i})
+			i = j
+		case c == '"' || c == '\'' || c == '`':
+			j := strings.IndexByte(src[i+1:], c)
+			if j < 0 {
+				return nil, fmt.Errorf("unterminated %c at position %d", c, i+1)
+			}
+			t := token{kind: tokStr, text: src[i+1 : i+1+j], pos: i}
+			if c == '`' {
+				t.kind, t.quoted = tokIdent, true
+			}
+			toks = append(toks, t)
+			i += j + 2
+		default:
+			r, size := utf8.DecodeRuneInString(src[i:])
+			if unicode.IsLetter(r) || r == '_' || r == '°' {
+				j := i + size
+				for j < len(src) {
+					r, size := utf8.DecodeRuneInString(src[j:])
+					if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+						break
+					}
+					j += size
+				}
+				toks = append(toks, token{kind: tokIdent, text: src[i:j], pos: i})
+				i = j
+				continue
+			}
+			switch r {
+			case '−':
+				toks = append(toks, token{kind: tokOp, text: "-", pos: i})
+				i += size
+				continue
+			case '×':
+				toks = append(toks, token{kind: tokOp, text: "*", pos: i})
+				i += size
+				continue
+			case '÷':
+				toks = append(toks, token{kind: tokOp, text: "/", pos: i})
+				i += size
+				continue
+			}
+			op := ""
+			for _, o := range twoCharOps {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" && strings.ContainsRune("+-*/%^()[],<>!=", r) {
+				op = string(r)
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, i+1)
+			}
+			start := i
+			i += len(op)
+			switch op {
+			case "**":
+				op = "^"
+			case "=":
+				op = "=="
+			case "<>":
+				op = "!="
+			}
+			toks = append(toks, token{kind: tokOp, text: op, pos: start})
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Expr is a parsed expression that can be evaluated repeatedly, e.g. once
+// per table row.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses an expression.
+func Parse(src string) (*Expr, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 1 {
+		return nil, errors.New("empty expression")
+	}
+	p := &parser{toks: toks}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.isWord("to", "in", "as") {
+		kw := p.next()
+		target, name, err := p.parseUnit()
+		if err != nil {
+			return nil, fmt.Errorf("expected a unit after %q: %w", kw.text, err)
+		}
+		root = &convertNode{x: root, unit: target, name: name}
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos+1)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// Eval evaluates the expression with the given variables.
+func (e *Expr) Eval(vars map[string]Value) (Value, error) {
+	return e.root.eval(vars)
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval parses and evaluates an expression.
+func Eval(src string, vars map[string]Value) (Value, error) {
+	e, err := Parse(src)
+	if err != nil {
+		return Value{}, err
+	}
+	return e.Eval(vars)
+}
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) at(i int) token {
+	if i >= len(p.toks) {
+		return p.toks[len(p.toks)-1]
+	}
+	return p.toks[i]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isOp(ops ...string) bool {
+	t := p.peek()
+	if t.kind != tokOp {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) isWord(words ...string) bool {
+	t := p.peek()
+	if t.kind != tokIdent || t.quoted {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(t.text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.isOp(op) {
+		t := p.peek()
+		if t.kind == tokEOF {
+			return fmt.Errorf("expected %q at end of expression", op)
+		}
+		return fmt.Errorf("expected %q at position %d, found %q", op, t.pos+1, t.text)
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") || p.isWord("or") {
+		p.next()
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = &logicNode{and: false, x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	x, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") || p.isWord("and") {
+		p.next()
+		y, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		x = &logicNode{and: true, x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.isOp("!") || p.isWord("not") {
+		p.next()
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{x: x}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (node, error) {
+	x, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+	if p.isOp("==", "!=", "<", "<=", ">", ">=") {
+		op := p.next().text
+		y, err := p.parseAdd()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: op, x: x, y: y}, nil
+	}
+	return x, nil
+}
+
+func (p *parser) parseAdd() (node, error) {
+	x, err := p.parseMul()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+", "-") {
+		op := p.next().text
+		y, err := p.parseMul()
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryNode{op: op, x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseMul() (node, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*", "/", "%") {
+		op := p.next().text
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryNode{op: op, x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isOp("-", "+") {
+		op := p.next().text
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if op == "+" {
+			return x, nil
+		}
+		return &negNode{x: x}, nil
+	}
+	return p.parsePow()
+}
+
+func (p *parser) parsePow() (node, error) {
+	x, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	if p.isOp("^") {
+		p.next()
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: "^", x: x, y: y}, nil
+	}
+	return x, nil
+}
+
+// parsePostfix parses a primary expression and the unit that may follow a
+// number or parenthesized expression, as in "3 km" or "(1+2) h".
+func (p *parser) parsePostfix() (node, error) {
+	first := p.peek()
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if (first.kind == tokNum || first.kind == tokOp && first.text == "(") && p.unitFollows() {
+		u, name, err := p.parseUnit()
+		if err != nil {
+			return nil, err
+		}
+		return &unitNode{x: x, unit: u, name: name}, nil
+	}
+	return x, nil
+}
+
+// unitFollows reports whether the next token starts a unit suffix. "in"
+// followed by a unit that ends the expression is a conversion
+// ("2 ft in cm"), not inches.
+func (p *parser) unitFollows() bool {
+	t := p.peek()
+	if t.kind != tokIdent || t.quoted {
+		return false
+	}
+	switch strings.ToLower(t.text) {
+	case "and", "or", "not", "to", "as":
+		return false
+	case "in":
+		rest := &parser{toks: p.toks, pos: p.pos + 1}
+		if _, _, err := rest.parseUnit(); err == nil && rest.peek().kind == tokEOF {
+			return false
+		}
+	}
+	if next := p.at(p.pos + 1); next.kind == tokOp && next.text == "(" {
+		return false
+	}
+	_, ok := lookupUnit(t.text)
+	return ok
+}
+
+// parseUnit parses a unit expression such as "km/h", "m^2" or "kg*m/s^2".
+func (p *parser) parseUnit() (unit, string, error) {
+	u, name, err := p.parseUnitTerm()
+	if err != nil {
+		return unit{}, "", err
+	}
+	for p.isOp("*", "/") {
+		t := p.at(p.pos + 1)
+		if t.kind != tokIdent || t.quoted {
+			break
+		}
+		if _, ok := lookupUnit(t.text); !ok {
+			break
+		}
+		op := p.next().text
+		v, vname, err := p.parseUnitTerm()
+		if err != nil {
+			return unit{}, "", err
+		}
+		sign := 1
+		if op == "/" {
+			sign = -1
+		}
+		if u, err = u.combine(v, sign); err != nil {
+			return unit{}, "", err
+		}
+		name += op + vname
+	}
+	return u, name, nil
+}
+
+func (p *parser) parseUnitTerm() (unit, string, error) {
+	t := p.peek()
+	if t.kind != tokIdent || t.quoted {
+		if t.kind == tokEOF {
+			return unit{}, "", errors.New("missing unit")
+		}
+		return unit{}, "", fmt.Errorf("unknown unit %q", t.text)
+	}
+	u, ok := lookupUnit(t.text)
+	if !ok {
+		return unit{}, "", fmt.Errorf("unknown unit %q", t.text)
+	}
+	p.next()
+	name := t.text
+	if p.isOp("^") {
+		sign, n := 1, p.at(p.pos+1)
+		if n.kind == tokOp && n.text == "-" {
+			sign, n = -1, p.at(p.pos+2)
+		}
+		if n.kind == tokNum && n.num == math.Trunc(n.num) && n.num <= 9 {
+			p.next()
+			if sign < 0 {
+				p.next()
+			}
+			p.next()
+			exp := sign * int(n.num)
+			var err error
+			if u, err = u.pow(exp); err != nil {
+				return unit{}, "", err
+			}
+			name += fmt.Sprintf("^%d", exp)
+		}
+	}
+	return u, name, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNum:
+		return &constNode{v: Number(t.num)}, nil
+	case tokStr:
+		return &constNode{v: Text(t.text)}, nil
+	case tokIdent:
+		if !t.quoted {
+			switch strings.ToLower(t.text) {
+			case "true":
+				return &constNode{v: boolValue(true)}, nil
+			case "false":
+				return &constNode{v: boolValue(false)}, nil
+			}
+		}
+		if !t.quoted && p.isOp("(") {
+			p.next()
+			var args []node
+			for !p.isOp(")") {
+				arg, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if !p.isOp(",") {
+					break
+				}
+				p.next()
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			name := strings.ToLower(t.text)
+			if _, ok := functions[name]; !ok {
+				return nil, fmt.Errorf("unknown function %q", t.text)
+			}
+			return &callNode{name: name, args: args}, nil
+		}
+		return &nameNode{name: t.text, quoted: t.quoted}, nil
+	case tokOp:
+		switch t.text {
+		case "(":
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		case "[":
+			var items []node
+			for !p.isOp("]") {
+				item, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+				if !p.isOp(",") {
+					break
+				}
+				p.next()
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			return &listNode{items: items}, nil
+		}
+		return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos+1)
+	}
+	return nil, errors.New("unexpected end of expression")
+}
@@ -0,0 +1,157 @@
+package calc
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"0.1 + 0.2", "0.3"},
+		{"2 ^ 10 - 24", "1000"},
+		{"-2 ^ 2", "-4"},
+		{"7 % 3 + 2 * 3", "7"},
+		{"(4.2 - 3.9) / 3.9 * 100", "7.692307692"},
+		{"sqrt(2)", "1.414213562"},
+		{"log(8, 2) + ln(e) + log10(1000)", "7"},
+		{"round(2.71828, 2)", "2.72"},
+		{"sin(30 deg)", "0.5"},
+		{"pi / 2 to deg", "90 deg"},
+		{"3 ft + 2 in to cm", "96.52 cm"},
+		{"12 in in cm", "30.48 cm"},
+		{"5 in", "5 in"},
+		{"60 km / 2 h", "30 km/h"},
+		{"60 km / 45 min to mph", "49.70969538 mph"},
+		{"100 km/h to m/s", "27.77777778 m/s"},
+		{"3 m * 4 m", "12 m^2"},
+		{"sqrt(16 m^2)", "4 m"},
+		{"10 m / 2 s", "5 m/s"},
+		{"2 kg * 9.81 m/s^2 to N", "19.62 N"},
+		{"1 kWh to J", "3600000 J"},
+		{"1.5 GiB to MB", "1610.612736 MB"},
+		{"hour to min", "60 min"},
+		{"100 degF to degC", "37.77777778 degC"},
+		{"-40 degC to degF", "-40 degF"},
+		{"30 degC - 20 degC", "10 K"},
+		{"20 degC + 5 K", "25 degC"},
+		{"max(3 m, 2 ft)", "3 m"},
+		{"mean([1, 2, 3, 4])", "2.5"},
+		{"median(5, 1, 3)", "3"},
+		{"std(2, 4, 4, 4, 5, 5, 7, 9)", "2.138089935"},
+		{"percentile([1, 2, 3, 4, 5], 90)", "4.6"},
+		{"count([1, 2, 3])", "3"},
+		{"[1, 2, 3] * 2", "[2, 4, 6]"},
+		{"slope([1, 2, 3], [2, 4, 6])", "2"},
+		{"1 < 2 and not false", "true"},
+		{"'abc' == \"abc\" or 1 / 0", "true"},
+		{"contains('Deep Learning', 'learn')", "true"},
+		{"if(3 > 2, 'yes', 'no')", "yes"},
+		{"round(1.5, 400 - 100)", "1.5"},
+		{"round(1234, -2)", "1200"},
+		{"sum([])", "0"},
+		{"(m^9)^9 / (m^9)^9", "1"},
+	}
+	for _, tt := range tests {
+		v, err := Eval(tt.expr, nil)
+		if err != nil {
+			t.Errorf("Eval(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := v.String(); got != tt.want {
+			t.Errorf("Eval(%q) = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestEval_Errors(t *testing.T) {
+	tests := map[string]string{
+		"1 / 0":                "division by zero",
+		"2 km + 3 kg":          "incompatible units",
+		"5 km to kg":           "incompatible units",
+		"20 degC + 20 degC":    "cannot add two temperatures",
+		"2 * 20 degC":          "degC or degF",
+		"foo + 1":              `unknown name "foo"`,
+		"frobnicate(2)":        `unknown function "frobnicate"`,
+		"(1 + 2":               `expected ")"`,
+		"3 m to parsnips":      `unknown unit "parsnips"`,
+		"sin(2 m)":             "without units",
+		"percentile([1], 120)": "between 0 and 100",
+		"((m^9)^9)^9":          "unit exponent out of range",
+		"(m^9)^9 * (m^9)^9":    "unit exponent out of range",
+		"round(1.5, 400)":      "digits must be between",
+		"mean([])":             "no values",
+		"":                     "empty expression",
+		"1 $ 2":                "unexpected character",
+	}
+	for expr, want := range tests {
+		_, err := Eval(expr, nil)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Eval(%q) error = %v, want it to contain %q", expr, err, want)
+		}
+	}
+}
+
+func TestEval_Vars(t *testing.T) {
+	vars := map[string]Value{
+		"height": List([]float64{1.6, 1.7, math.NaN(), 1.8}),
+		"weight": List([]float64{60, 65, 70, 80}),
+		"name":   Text("Ada"),
+	}
+	tests := map[string]string{
+		"mean(height)":                   "1.7",
+		"count(height)":                  "3",
+		"round(corr(height, weight), 3)": "0.961",
+		"Height * 100":                   "[160, 170, NaN, 180]",
+		"upper(name)":                    "ADA",
+	}
+	for expr, want := range tests {
+		v, err := Eval(expr, vars)
+		if err != nil {
+			t.Errorf("Eval(%q): %v", expr, err)
+			continue
+		}
+		if got := v.String(); got != want {
+			t.Errorf("Eval(%q) = %s, want %s", expr, got, want)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	xs := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	s := Describe(xs)
+	if s.Count != 8 || s.Mean != 5 || s.Median != 4.5 || s.Min != 2 || s.Max != 9 || s.Q1 != 4 || s.Q3 != 5.5 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	if !strings.Contains(s.String(), "quartiles: 4, 4.5, 5.5") {
+		t.Errorf("unexpected summary text:\n%s", s)
+	}
+
+	slope, intercept, r2, err := LinearFit([]float64{1, 2, 3, 4}, []float64{3, 5, 7, 9})
+	if err != nil || slope != 2 || intercept != 1 || math.Abs(r2-1) > 1e-12 {
+		t.Errorf("LinearFit = %v, %v, %v, %v", slope, intercept, r2, err)
+	}
+	if _, err := Correlation([]float64{1, 2}, []float64{1}); err == nil {
+		t.Error("expected an error for lists of different lengths")
+	}
+	if !math.IsNaN(Mean(nil)) {
+		t.Error("mean of no values should be NaN")
+	}
+}
+
+func TestIdent(t *testing.T) {
+	tests := map[string]string{
+		"Body Mass (kg)": "body_mass_kg",
+		"  year ":        "year",
+		"CO2-ppm":        "co2_ppm",
+		"größe":          "größe",
+	}
+	for in, want := range tests {
+		if got := Ident(in); got != want {
+			t.Errorf("Ident(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
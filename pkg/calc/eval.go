@@ -0,0 +1,420 @@
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type node interface {
+	eval(vars map[string]Value) (Value, error)
+}
+
+type constNode struct{ v Value }
+
+func (n *constNode) eval(map[string]Value) (Value, error) {
+	return n.v, nil
+}
+
+var constants = map[string]float64{
+	"pi":  math.Pi,
+	"π":   math.Pi,
+	"tau": 2 * math.Pi,
+	"e":   math.E,
+	"phi": math.Phi,
+	"inf": math.Inf(1),
+}
+
+type nameNode struct {
+	name   string
+	quoted bool
+}
+
+// eval resolves a name as a variable (exactly, in lower case or as a
+// column identifier), then as a constant and finally as one of a unit.
+func (n *nameNode) eval(vars map[string]Value) (Value, error) {
+	for _, key := range []string{n.name, strings.ToLower(n.name), Ident(n.name)} {
+		if v, ok := vars[key]; ok {
+			return v, nil
+		}
+	}
+	if !n.quoted {
+		if c, ok := constants[strings.ToLower(n.name)]; ok {
+			return Number(c), nil
+		}
+		if u, ok := lookupUnit(n.name); ok {
+			return Value{Kind: KindNumber, Num: u.factor + u.offset, Dim: u.dim, Unit: n.name}, nil
+		}
+	}
+	return Value{}, fmt.Errorf("unknown name %q", n.name)
+}
+
+type unitNode struct {
+	x    node
+	unit unit
+	name string
+}
+
+func (n *unitNode) eval(vars map[string]Value) (Value, error) {
+	v, err := n.x.eval(vars)
+	if err != nil {
+		return Value{}, err
+	}
+	if v.Kind != KindNumber || !v.Dim.IsZero() || v.Unit != "" {
+		return Value{}, fmt.Errorf("cannot apply unit %s to %s", n.name, v)
+	}
+	return Value{Kind: KindNumber, Num: v.Num*n.unit.factor + n.unit.offset, Dim: n.unit.dim, Unit: n.name}, nil
+}
+
+type convertNode struct {
+	x    node
+	unit unit
+	name string
+}
+
+func (n *convertNode) eval(vars map[string]Value) (Value, error) {
+	v, err := n.x.eval(vars)
+	if err != nil {
+		return Value{}, err
+	}
+	if v.Kind != KindNumber {
+		return Value{}, fmt.Errorf("cannot convert %s to %s", v, n.name)
+	}
+	if v.Dim != n.unit.dim {
+		return Value{}, fmt.Errorf("cannot convert %s to %s: incompatible units", v, n.name)
+	}
+	v.Unit = n.name
+	return v, nil
+}
+
+type negNode struct{ x node }
+
+func (n *negNode) eval(vars map[string]Value) (Value, error) {
+	v, err := n.x.eval(vars)
+	if err != nil {
+		return Value{}, err
+	}
+	switch v.Kind {
+	case KindList:
+		out := make([]float64, len(v.List))
+		for i, x := range v.List {
+			out[i] = -x
+		}
+		return List(out), nil
+	case KindString:
+		return Value{}, fmt.Errorf("cannot negate text %q", v.Str)
+	}
+	return withMagnitude(v, -v.Magnitude()), nil
+}
+
+// withMagnitude returns v with its magnitude in the display unit replaced,
+// so that e.g. negating -5 degC keeps the offset right.
+func withMagnitude(v Value, m float64) Value {
+	v.Kind = KindNumber
+	if v.Unit != "" {
+		if u, err := parseUnitString(v.Unit); err == nil && u.dim == v.Dim {
+			v.Num = m*u.factor + u.offset
+			return v
+		}
+	}
+	v.Num = m
+	return v
+}
+
+type notNode struct{ x node }
+
+func (n *notNode) eval(vars map[string]Value) (Value, error) {
+	v, err := n.x.eval(vars)
+	if err != nil {
+		return Value{}, err
+	}
+	return boolValue(!v.Truthy()), nil
+}
+
+type logicNode struct {
+	and  bool
+	x, y node
+}
+
+func (n *logicNode) eval(vars map[string]Value) (Value, error) {
+	x, err := n.x.eval(vars)
+	if err != nil {
+		return Value{}, err
+	}
+	if x.Truthy() != n.and {
+		return boolValue(x.Truthy()), nil
+	}
+	y, err := n.y.eval(vars)
+	if err != nil {
+		return Value{}, err
+	}
+	return boolValue(y.Truthy()), nil
+}
+
+type listNode struct{ items []node }
+
+func (n *listNode) eval(vars map[string]Value) (Value, error) {
+	var out []float64
+	for _, item := range n.items {
+		v, err := item.eval(vars)
+		if err != nil {
+			return Value{}, err
+		}
+		switch {
+		case v.Kind == KindList:
+			out = append(out, v.List...)
+		case v.Kind == KindNumber && v.Dim.IsZero():
+			out = append(out, v.Num)
+		default:
+			return Value{}, fmt.Errorf("lists can only hold plain numbers, not %s", v)
+		}
+	}
+	return List(out), nil
+}
+
+type callNode struct {
+	name string
+	args []node
+}
+
+func (n *callNode) eval(vars map[string]Value) (Value, error) {
+	args := make([]Value, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(vars)
+		if err != nil {
+			return Value{}, err
+		}
+		args[i] = v
+	}
+	v, err := functions[n.name](args)
+	if err != nil {
+		return Value{}, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return v, nil
+}
+
+type binaryNode struct {
+	op   string
+	x, y node
+}
+
+func (n *binaryNode) eval(vars map[string]Value) (Value, error) {
+	x, err := n.x.eval(vars)
+	if err != nil {
+		return Value{}, err
+	}
+	y, err := n.y.eval(vars)
+	if err != nil {
+		return Value{}, err
+	}
+	switch n.op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return compare(n.op, x, y)
+	}
+	return arith(n.op, x, y)
+}
+
+// compare compares two numbers or two strings. Values of different kinds,
+// such as an empty cell and a number, are never equal or ordered.
+func compare(op string, x, y Value) (Value, error) {
+	if x.Kind == KindList || y.Kind == KindList {
+		return Value{}, fmt.Errorf("cannot compare lists with %s", op)
+	}
+	var c int
+	switch {
+	case x.Kind == KindString && y.Kind == KindString:
+		c = strings.Compare(x.Str, y.Str)
+	case x.Kind == KindString || y.Kind == KindString:
+		return boolValue(op == "!="), nil
+	case x.Dim != y.Dim:
+		return Value{}, fmt.Errorf("cannot compare %s and %s: incompatible units", x, y)
+	case math.IsNaN(x.Num) || math.IsNaN(y.Num):
+		return boolValue(op == "!="), nil
+	case x.Num < y.Num:
+		c = -1
+	case x.Num > y.Num:
+		c = 1
+	}
+	switch op {
+	case "==":
+		return boolValue(c == 0), nil
+	case "!=":
+		return boolValue(c != 0), nil
+	case "<":
+		return boolValue(c < 0), nil
+	case "<=":
+		return boolValue(c <= 0), nil
+	case ">":
+		return boolValue(c > 0), nil
+	}
+	return boolValue(c >= 0), nil
+}
+
+func hasOffset(v Value) bool {
+	if v.Unit == "" {
+		return false
+	}
+	u, err := parseUnitString(v.Unit)
+	return err == nil && u.offset != 0
+}
+
+func arith(op string, x, y Value) (Value, error) {
+	// An empty cell is a missing value, which propagates.
+	if x.Kind == KindString && x.Str == "" && y.Kind != KindString || y.Kind == KindString && y.Str == "" && x.Kind != KindString {
+		return Number(math.NaN()), nil
+	}
+	if x.Kind == KindString || y.Kind == KindString {
+		return Value{}, fmt.Errorf("cannot apply %s to text", op)
+	}
+	if x.Kind == KindList || y.Kind == KindList {
+		return listArith(op, x, y)
+	}
+	x.Kind, y.Kind = KindNumber, KindNumber
+
+	switch op {
+	case "+", "-":
+		if x.Dim != y.Dim {
+			return Value{}, fmt.Errorf("cannot %s %s and %s: incompatible units", map[string]string{"+": "add", "-": "subtract"}[op], x, y)
+		}
+		r := Value{Kind: KindNumber, Dim: x.Dim, Unit: x.Unit}
+		if r.Unit == "" {
+			r.Unit = y.Unit
+		}
+		if hasOffset(y) {
+			if hasOffset(x) && op == "+" {
+				return Value{}, errors.New("cannot add two temperatures in degC or degF; add a difference in K instead")
+			}
+			r.Unit = "K"
+		}
+		if op == "+" {
+			r.Num = x.Num + y.Num
+		} else {
+			r.Num = x.Num - y.Num
+		}
+		return r, nil
+	case "%":
+		if x.Dim != y.Dim {
+			return Value{}, fmt.Errorf("cannot take %s modulo %s: incompatible units", x, y)
+		}
+		if y.Num == 0 {
+			return Value{}, errors.New("modulo by zero")
+		}
+		return Value{Kind: KindNumber, Num: math.Mod(x.Num, y.Num), Dim: x.Dim, Unit: x.Unit}, nil
+	}
+
+	if hasOffset(x) || hasOffset(y) {
+		return Value{}, errors.New("temperatures in degC or degF can only be added to, subtracted or converted; use K")
+	}
+	switch op {
+	case "*":
+		dim, err := x.Dim.add(y.Dim, 1)
+		if err != nil {
+			return Value{}, err
+		}
+		return Value{Kind: KindNumber, Num: x.Num * y.Num, Dim: dim, Unit: combineUnits(op, x, y, dim)}, nil
+	case "/":
+		if y.Num == 0 {
+			return Value{}, errors.New("division by zero")
+		}
+		dim, err := x.Dim.add(y.Dim, -1)
+		if err != nil {
+			return Value{}, err
+		}
+		return Value{Kind: KindNumber, Num: x.Num / y.Num, Dim: dim, Unit: combineUnits(op, x, y, dim)}, nil
+	}
+
+	// op == "^"
+	if !y.Dim.IsZero() {
+		return Value{}, fmt.Errorf("exponent %s must not have units", y)
+	}
+	if x.Dim.IsZero() {
+		return Number(math.Pow(x.Num, y.Num)), nil
+	}
+	if y.Num != math.Trunc(y.Num) || math.Abs(y.Num) > 9 {
+		return Value{}, fmt.Errorf("a value with units can only be raised to a small whole power, not %s", y)
+	}
+	n := int(y.Num)
+	dim, err := x.Dim.scale(n)
+	if err != nil {
+		return Value{}, err
+	}
+	r := Value{Kind: KindNumber, Num: math.Pow(x.Num, y.Num), Dim: dim}
+	if x.Unit != "" && !strings.ContainsAny(x.Unit, "*/^") {
+		r.Unit = fmt.Sprintf("%s^%d", x.Unit, n)
+	}
+	return r, nil
+}
+
+// combineUnits derives the display unit of a product or quotient, e.g.
+// "km/h" for a distance in km divided by a time in h. It returns "" when
+// there is no sensible unit, and the result is shown in SI units.
+func combineUnits(op string, x, y Value, dim Dim) string {
+	var unit string
+	switch {
+	case x.Unit != "" && y.Unit == "" && y.Dim.IsZero():
+		unit = x.Unit
+	case y.Unit != "" && x.Unit == "" && x.Dim.IsZero() && op == "*":
+		unit = y.Unit
+	case x.Unit == "" || y.Unit == "" || dim.IsZero():
+		return ""
+	case op == "*" && x.Unit == y.Unit && !strings.ContainsAny(x.Unit, "*/^"):
+		unit = x.Unit + "^2"
+	case op == "/" && strings.ContainsAny(y.Unit, "*/"):
+		return ""
+	default:
+		unit = x.Unit + op + y.Unit
+	}
+	if u, err := parseUnitString(unit); err != nil || u.dim != dim {
+		return ""
+	}
+	return unit
+}
+
+// listArith applies op element-wise to lists, or to a list and a number.
+func listArith(op string, x, y Value) (Value, error) {
+	if x.Kind != KindList && !x.Dim.IsZero() || y.Kind != KindList && !y.Dim.IsZero() {
+		return Value{}, errors.New("lists can only be combined with plain numbers")
+	}
+	n := len(x.List)
+	if x.Kind != KindList {
+		n = len(y.List)
+	} else if y.Kind == KindList && len(y.List) != n {
+		return Value{}, fmt.Errorf("lists differ in length (%d and %d)", len(x.List), len(y.List))
+	}
+	item := func(v Value, i int) Value {
+		if v.Kind == KindList {
+			return Number(v.List[i])
+		}
+		return Number(v.Num)
+	}
+	out := make([]float64, n)
+	for i := range out {
+		r, err := arith(op, item(x, i), item(y, i))
+		if err != nil {
+			return Value{}, err
+		}
+		out[i] = r.Num
+	}
+	return List(out), nil
+}
+
+// Ident turns a column name into the identifier it is known by in
+// expressions: lower case with runs of other characters replaced by "_",
+// e.g. "Body Mass (kg)" becomes "body_mass_kg".
+func Ident(name string) string {
+	var sb strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			sb.WriteRune(r)
+			underscore = false
+		} else if !underscore && sb.Len() > 0 {
+			sb.WriteByte('_')
+			underscore = true
+		}
+	}
+	return strings.TrimRight(sb.String(), "_")
+}
@@ -0,0 +1,369 @@
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+var functions map[string]func(args []Value) (Value, error)
+
+func init() {
+	functions = map[string]func(args []Value) (Value, error){
+		"sqrt": fnSqrt,
+		"abs": func(args []Value) (Value, error) {
+			v, err := oneNumber(args)
+			if err != nil {
+				return Value{}, err
+			}
+			return withMagnitude(v, math.Abs(v.Magnitude())), nil
+		},
+		"round": fnRound,
+		"floor": rounding(math.Floor),
+		"ceil":  rounding(math.Ceil),
+		"trunc": rounding(math.Trunc),
+		"exp":   plain(math.Exp),
+		"ln":    plain(math.Log),
+		"log": func(args []Value) (Value, error) {
+			if len(args) == 2 {
+				x, err := plainArgs(args)
+				if err != nil {
+					return Value{}, err
+				}
+				return Number(math.Log(x[0]) / math.Log(x[1])), nil
+			}
+			return plain(math.Log)(args)
+		},
+		"log10": plain(math.Log10),
+		"log2":  plain(math.Log2),
+		"sin":   plain(math.Sin),
+		"cos":   plain(math.Cos),
+		"tan":   plain(math.Tan),
+		"asin":  plain(math.Asin),
+		"acos":  plain(math.Acos),
+		"atan":  plain(math.Atan),
+		"sinh":  plain(math.Sinh),
+		"cosh":  plain(math.Cosh),
+		"tanh":  plain(math.Tanh),
+		"atan2": plain2(math.Atan2),
+		"pow": func(args []Value) (Value, error) {
+			if len(args) != 2 {
+				return Value{}, errors.New("expects 2 arguments")
+			}
+			return arith("^", args[0], args[1])
+		},
+		"hypot": func(args []Value) (Value, error) {
+			if len(args) != 2 {
+				return Value{}, errors.New("expects 2 arguments")
+			}
+			if args[0].Dim != args[1].Dim {
+				return Value{}, errors.New("arguments have incompatible units")
+			}
+			v := args[0]
+			v.Num = math.Hypot(args[0].Num, args[1].Num)
+			return v, nil
+		},
+
+		"min":      aggregate(func(xs []float64) float64 { return Quantile(xs, 0) }),
+		"max":      aggregate(func(xs []float64) float64 { return Quantile(xs, 1) }),
+		"sum":      fnSum,
+		"mean":     aggregate(Mean),
+		"avg":      aggregate(Mean),
+		"average":  aggregate(Mean),
+		"median":   aggregate(Median),
+		"std":      aggregate(StdDev),
+		"stdev":    aggregate(StdDev),
+		"var":      fnVariance,
+		"variance": fnVariance,
+		"count": func(args []Value) (Value, error) {
+			xs, _, _, err := numbers(args)
+			if err != nil {
+				return Value{}, err
+			}
+			return Number(float64(len(xs))), nil
+		},
+		"percentile": func(args []Value) (Value, error) { return fnQuantile(args, 100) },
+		"quantile":   func(args []Value) (Value, error) { return fnQuantile(args, 1) },
+		"corr":       paired(Correlation),
+		"cov":        paired(Covariance),
+		"slope": paired(func(x, y []float64) (float64, error) {
+			slope, _, _, err := LinearFit(x, y)
+			return slope, err
+		}),
+		"intercept": paired(func(x, y []float64) (float64, error) {
+			_, intercept, _, err := LinearFit(x, y)
+			return intercept, err
+		}),
+
+		"if": func(args []Value) (Value, error) {
+			if len(args) != 3 {
+				return Value{}, errors.New("expects a condition and two values")
+			}
+			if args[0].Truthy() {
+				return args[1], nil
+			}
+			return args[2], nil
+		},
+		"len": func(args []Value) (Value, error) {
+			if len(args) == 1 && args[0].Kind == KindString {
+				return Number(float64(utf8.RuneCountInString(args[0].Str))), nil
+			}
+			return functions["count"](args)
+		},
+		"lower": text(strings.ToLower),
+		"upper": text(strings.ToUpper),
+		"contains": textTest(func(s, sub string) bool {
+			return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+		}),
+		"startswith": textTest(func(s, prefix string) bool {
+			return strings.HasPrefix(strings.ToLower(s), strings.ToLower(prefix))
+		}),
+		"endswith": textTest(func(s, suffix string) bool {
+			return strings.HasSuffix(strings.ToLower(s), strings.ToLower(suffix))
+		}),
+	}
+}
+
+func oneNumber(args []Value) (Value, error) {
+	if len(args) != 1 {
+		return Value{}, fmt.Errorf("expects 1 argument, got %d", len(args))
+	}
+	if args[0].Kind != KindNumber && args[0].Kind != KindBool {
+		return Value{}, fmt.Errorf("expects a number, got %s", args[0])
+	}
+	return args[0], nil
+}
+
+// plainArgs returns the values of arguments that must be numbers without
+// units.
+func plainArgs(args []Value) ([]float64, error) {
+	out := make([]float64, len(args))
+	for i, a := range args {
+		if a.Kind != KindNumber && a.Kind != KindBool {
+			return nil, fmt.Errorf("expects a number, got %s", a)
+		}
+		if !a.Dim.IsZero() {
+			return nil, fmt.Errorf("expects a number without units, got %s", a)
+		}
+		out[i] = a.Num
+	}
+	return out, nil
+}
+
+func plain(f func(float64) float64) func([]Value) (Value, error) {
+	return func(args []Value) (Value, error) {
+		if len(args) != 1 {
+			return Value{}, fmt.Errorf("expects 1 argument, got %d", len(args))
+		}
+		x, err := plainArgs(args)
+		if err != nil {
+			return Value{}, err
+		}
+		return Number(f(x[0])), nil
+	}
+}
+
+func plain2(f func(float64, float64) float64) func([]Value) (Value, error) {
+	return func(args []Value) (Value, error) {
+		if len(args) != 2 {
+			return Value{}, fmt.Errorf("expects 2 arguments, got %d", len(args))
+		}
+		x, err := plainArgs(args)
+		if err != nil {
+			return Value{}, err
+		}
+		return Number(f(x[0], x[1])), nil
+	}
+}
+
+func fnSqrt(args []Value) (Value, error) {
+	v, err := oneNumber(args)
+	if err != nil {
+		return Value{}, err
+	}
+	r := Value{Kind: KindNumber, Num: math.Sqrt(v.Num)}
+	for i, e := range v.Dim {
+		if e%2 != 0 {
+			return Value{}, fmt.Errorf("cannot take the square root of %s", v)
+		}
+		r.Dim[i] = e / 2
+	}
+	if base, ok := strings.CutSuffix(v.Unit, "^2"); ok && !strings.ContainsAny(base, "*/^") {
+		r.Unit = base
+	}
+	return r, nil
+}
+
+// fnRound rounds in the display unit, optionally to a number of decimals.
+func fnRound(args []Value) (Value, error) {
+	if len(args) == 2 {
+		d, err := plainArgs(args[1:])
+		if err != nil {
+			return Value{}, err
+		}
+		v, err := oneNumber(args[:1])
+		if err != nil {
+			return Value{}, err
+		}
+		digits := math.Trunc(d[0])
+		if math.IsNaN(digits) || math.Abs(digits) > 308 {
+			return Value{}, errors.New("digits must be between -308 and 308")
+		}
+		scale := math.Pow(10, digits)
+		scaled := v.Magnitude() * scale
+		if math.IsInf(scaled, 0) {
+			// Finer than float64 can represent: nothing to round.
+			return v, nil
+		}
+		return withMagnitude(v, math.Round(scaled)/scale), nil
+	}
+	return rounding(math.Round)(args)
+}
+
+func rounding(f func(float64) float64) func([]Value) (Value, error) {
+	return func(args []Value) (Value, error) {
+		v, err := oneNumber(args)
+		if err != nil {
+			return Value{}, err
+		}
+		return withMagnitude(v, f(v.Magnitude())), nil
+	}
+}
+
+// numbers flattens numbers and lists into one list, skipping the NaNs that
+// stand for missing values. Numbers must all have the same dimension; the
+// display unit of the first one is returned.
+func numbers(args []Value) ([]float64, Dim, string, error) {
+	var (
+		xs   []float64
+		dim  Dim
+		unit string
+		seen bool
+	)
+	for _, a := range args {
+		switch a.Kind {
+		case KindList:
+			if seen && !dim.IsZero() {
+				return nil, dim, "", errors.New("cannot mix a list with values that have units")
+			}
+			for _, x := range a.List {
+				if !math.IsNaN(x) {
+					xs = append(xs, x)
+				}
+			}
+			seen = true
+		case KindNumber, KindBool:
+			if seen && a.Dim != dim {
+				return nil, dim, "", fmt.Errorf("incompatible units: %s", a)
+			}
+			if !seen || unit == "" {
+				unit = a.Unit
+			}
+			dim, seen = a.Dim, true
+			xs = append(xs, a.Num)
+		default:
+			return nil, dim, "", fmt.Errorf("expects numbers, got %q", a.Str)
+		}
+	}
+	if hasOffset(Value{Unit: unit, Dim: dim}) {
+		return nil, dim, "", errors.New("temperatures in degC or degF cannot be aggregated; convert them to K")
+	}
+	return xs, dim, unit, nil
+}
+
+func aggregate(f func([]float64) float64) func([]Value) (Value, error) {
+	return func(args []Value) (Value, error) {
+		xs, dim, unit, err := numbers(args)
+		if err != nil {
+			return Value{}, err
+		}
+		if len(xs) == 0 {
+			return Value{}, errors.New("no values")
+		}
+		return Value{Kind: KindNumber, Num: f(xs), Dim: dim, Unit: unit}, nil
+	}
+}
+
+// fnSum is aggregate(Sum), except that the sum of no values is 0.
+func fnSum(args []Value) (Value, error) {
+	xs, dim, unit, err := numbers(args)
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{Kind: KindNumber, Num: Sum(xs), Dim: dim, Unit: unit}, nil
+}
+
+func fnVariance(args []Value) (Value, error) {
+	xs, dim, _, err := numbers(args)
+	if err != nil {
+		return Value{}, err
+	}
+	if len(xs) < 2 {
+		return Value{}, errors.New("needs at least two values")
+	}
+	dim, err = dim.scale(2)
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{Kind: KindNumber, Num: Variance(xs), Dim: dim}, nil
+}
+
+// fnQuantile implements percentile(values, p) with scale 100 and
+// quantile(values, q) with scale 1.
+func fnQuantile(args []Value, scale float64) (Value, error) {
+	if len(args) < 2 {
+		return Value{}, errors.New("expects values and a rank")
+	}
+	q, err := plainArgs(args[len(args)-1:])
+	if err != nil {
+		return Value{}, err
+	}
+	if q[0] < 0 || q[0] > scale {
+		return Value{}, fmt.Errorf("rank must be between 0 and %s", FormatNumber(scale))
+	}
+	return aggregate(func(xs []float64) float64 { return Quantile(xs, q[0]/scale) })(args[:len(args)-1])
+}
+
+func paired(f func(x, y []float64) (float64, error)) func([]Value) (Value, error) {
+	return func(args []Value) (Value, error) {
+		if len(args) != 2 || args[0].Kind != KindList || args[1].Kind != KindList {
+			return Value{}, errors.New("expects two lists of numbers")
+		}
+		x, y := args[0].List, args[1].List
+		if len(x) == len(y) {
+			// Drop pairs with a missing value, e.g. an empty CSV cell.
+			var px, py []float64
+			for i := range x {
+				if !math.IsNaN(x[i]) && !math.IsNaN(y[i]) {
+					px, py = append(px, x[i]), append(py, y[i])
+				}
+			}
+			x, y = px, py
+		}
+		r, err := f(x, y)
+		if err != nil {
+			return Value{}, err
+		}
+		return Number(r), nil
+	}
+}
+
+func text(f func(string) string) func([]Value) (Value, error) {
+	return func(args []Value) (Value, error) {
+		if len(args) != 1 {
+			return Value{}, fmt.Errorf("expects 1 argument, got %d", len(args))
+		}
+		return Text(f(args[0].String())), nil
+	}
+}
+
+func textTest(f func(s, sub string) bool) func([]Value) (Value, error) {
+	return func(args []Value) (Value, error) {
+		if len(args) != 2 {
+			return Value{}, fmt.Errorf("expects 2 arguments, got %d", len(args))
+		}
+		return boolValue(f(args[0].String(), args[1].String())), nil
+	}
+}
@@ -0,0 +1,156 @@
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Sum returns the sum of xs.
+func Sum(xs []float64) float64 {
+	var s float64
+	for _, x := range xs {
+		s += x
+	}
+	return s
+}
+
+// Mean returns the arithmetic mean of xs, or NaN if xs is empty.
+func Mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return math.NaN()
+	}
+	return Sum(xs) / float64(len(xs))
+}
+
+// Quantile returns the q-quantile (0 <= q <= 1) of xs, interpolating
+// linearly between order statistics like R's default and NumPy.
+func Quantile(xs []float64, q float64) float64 {
+	if len(xs) == 0 || q < 0 || q > 1 {
+		return math.NaN()
+	}
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+	h := q * float64(len(sorted)-1)
+	lo := int(math.Floor(h))
+	if lo+1 >= len(sorted) {
+		return sorted[lo]
+	}
+	return sorted[lo] + (h-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
+
+// Median returns the median of xs.
+func Median(xs []float64) float64 {
+	return Quantile(xs, 0.5)
+}
+
+// Variance returns the sample variance of xs (divided by n-1).
+func Variance(xs []float64) float64 {
+	if len(xs) < 2 {
+		return math.NaN()
+	}
+	m := Mean(xs)
+	var ss float64
+	for _, x := range xs {
+		ss += (x - m) * (x - m)
+	}
+	return ss / float64(len(xs)-1)
+}
+
+// StdDev returns the sample standard deviation of xs.
+func StdDev(xs []float64) float64 {
+	return math.Sqrt(Variance(xs))
+}
+
+// Covariance returns the sample covariance of x and y.
+func Covariance(x, y []float64) (float64, error) {
+	if err := checkPairs(x, y); err != nil {
+		return 0, err
+	}
+	mx, my := Mean(x), Mean(y)
+	var s float64
+	for i := range x {
+		s += (x[i] - mx) * (y[i] - my)
+	}
+	return s / float64(len(x)-1), nil
+}
+
+// Correlation returns Pearson's correlation coefficient of x and y.
+func Correlation(x, y []float64) (float64, error) {
+	cov, err := Covariance(x, y)
+	if err != nil {
+		return 0, err
+	}
+	return cov / (StdDev(x) * StdDev(y)), nil
+}
+
+// LinearFit fits y = slope*x + intercept by least squares and returns the
+// coefficient of determination.
+func LinearFit(x, y []float64) (slope, intercept, r2 float64, err error) {
+	cov, err := Covariance(x, y)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	vx := Variance(x)
+	if vx == 0 {
+		return 0, 0, 0, errors.New("x values are all equal")
+	}
+	slope = cov / vx
+	intercept = Mean(y) - slope*Mean(x)
+	r := cov / math.Sqrt(vx*Variance(y))
+	return slope, intercept, r * r, nil
+}
+
+func checkPairs(x, y []float64) error {
+	if len(x) != len(y) {
+		return fmt.Errorf("lists differ in length (%d and %d)", len(x), len(y))
+	}
+	if len(x) < 2 {
+		return errors.New("need at least two pairs of values")
+	}
+	return nil
+}
+
+// Summary holds descriptive statistics of a list of numbers.
+type Summary struct {
+	Count  int
+	Sum    float64
+	Mean   float64
+	StdDev float64
+	Min    float64
+	Q1     float64
+	Median float64
+	Q3     float64
+	Max    float64
+}
+
+// Describe computes descriptive statistics of xs.
+func Describe(xs []float64) Summary {
+	return Summary{
+		Count:  len(xs),
+		Sum:    Sum(xs),
+		Mean:   Mean(xs),
+		StdDev: StdDev(xs),
+		Min:    Quantile(xs, 0),
+		Q1:     Quantile(xs, 0.25),
+		Median: Median(xs),
+		Q3:     Quantile(xs, 0.75),
+		Max:    Quantile(xs, 1),
+	}
+}
+
+func (s Summary) String() string {
+	if s.Count == 0 {
+		return "count: 0"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "count: %d\nsum: %s\nmean: %s\n", s.Count, FormatNumber(s.Sum), FormatNumber(s.Mean))
+	if s.Count > 1 {
+		fmt.Fprintf(&sb, "std dev: %s\n", FormatNumber(s.StdDev))
+	}
+	fmt.Fprintf(&sb, "min: %s\nquartiles: %s, %s, %s\nmax: %s",
+		FormatNumber(s.Min), FormatNumber(s.Q1), FormatNumber(s.Median), FormatNumber(s.Q3), FormatNumber(s.Max))
+	return sb.String()
+}
@@ -0,0 +1,406 @@
+package calc
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Table is a header row and data rows of text cells, e.g. a CSV file.
+type Table struct {
+	Columns []string
+	Rows    [][]string
+}
+
+// NewTable makes a table from rows whose first row is the header. Blank
+// header cells are named column_N and short rows are padded.
+func NewTable(rows [][]string) *Table {
+	t := &Table{}
+	if len(rows) == 0 {
+		return t
+	}
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	for i := 0; i < width; i++ {
+		name := ""
+		if i < len(rows[0]) {
+			name = strings.TrimSpace(rows[0][i])
+		}
+		if name == "" {
+			name = fmt.Sprintf("column_%d", i+1)
+		}
+		t.Columns = append(t.Columns, name)
+	}
+	for _, row := range rows[1:] {
+		if len(row) < width {
+			row = append(row, make([]string, width-len(row))...)
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
+
+// Column finds a column by its name, ignoring case, or by its identifier
+// (see Ident).
+func (t *Table) Column(name string) (int, error) {
+	name = strings.TrimSpace(strings.Trim(strings.TrimSpace(name), "`"))
+	for i, c := range t.Columns {
+		if c == name {
+			return i, nil
+		}
+	}
+	for i, c := range t.Columns {
+		if strings.EqualFold(c, name) || Ident(c) == Ident(name) {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("no column %q; columns are %s", name, strings.Join(t.Columns, ", "))
+}
+
+var thousandsRe = regexp.MustCompile(`^[-+]?\d{1,3}(,\d{3})+(\.\d+)?$`)
+
+// ParseCell returns a cell as a number if it is one, else as text.
+func ParseCell(s string) Value {
+	s = strings.TrimSpace(s)
+	if thousandsRe.MatchString(s) {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && s != "" {
+		return Number(f)
+	}
+	return Text(s)
+}
+
+// Numbers returns a column as numbers, with NaN for cells that are not.
+// It reports how many cells are numeric.
+func (t *Table) Numbers(col int) ([]float64, int) {
+	xs := make([]float64, len(t.Rows))
+	n := 0
+	for i, row := range t.Rows {
+		if v := ParseCell(row[col]); v.Kind == KindNumber {
+			xs[i] = v.Num
+			n++
+		} else {
+			xs[i] = math.NaN()
+		}
+	}
+	return xs, n
+}
+
+// Vars binds every column with numeric cells to a list, by the column's
+// identifier, for use in expressions such as "mean(price)".
+func (t *Table) Vars() map[string]Value {
+	vars := make(map[string]Value)
+	for i, c := range t.Columns {
+		if xs, n := t.Numbers(i); n > 0 {
+			vars[Ident(c)] = List(xs)
+		}
+	}
+	return vars
+}
+
+func (t *Table) rowVars(row []string) map[string]Value {
+	vars := make(map[string]Value, len(t.Columns))
+	for i, c := range t.Columns {
+		v := ParseCell(row[i])
+		vars[c] = v
+		vars[Ident(c)] = v
+	}
+	return vars
+}
+
+// Query selects, filters, groups and sorts the rows of a table.
+type Query struct {
+	Where     string   // expression a row must satisfy, e.g. "year >= 2020 and contains(title, 'llm')"
+	GroupBy   []string // columns to group by
+	Aggregate []string // e.g. "count", "mean(price)", "sum(price * qty) as revenue"
+	Select    []string // columns or expressions to return when not aggregating
+	Sort      string   // output column to sort by, "-" prefix for descending
+	Limit     int
+}
+
+var (
+	aliasRe = regexp.MustCompile(`(?i)^(.+?)\s+as\s+([\w ]+)$`)
+	aggRe   = regexp.MustCompile(`^(\w+)\s*(?:\((.*)\))?$`)
+)
+
+var aggregates = map[string]func(xs []float64) float64{
+	"sum":    Sum,
+	"mean":   Mean,
+	"avg":    Mean,
+	"median": Median,
+	"min":    func(xs []float64) float64 { return Quantile(xs, 0) },
+	"max":    func(xs []float64) float64 { return Quantile(xs, 1) },
+	"std":    StdDev,
+	"var":    Variance,
+}
+
+// column is an output column of a query: a source column or an expression
+// evaluated per row.
+type column struct {
+	label string
+	index int   // source column, or -1
+	expr  *Expr // when index is -1
+}
+
+func (t *Table) column(spec string) (column, error) {
+	spec = strings.TrimSpace(spec)
+	label := spec
+	if m := aliasRe.FindStringSubmatch(spec); m != nil {
+		spec, label = strings.TrimSpace(m[1]), strings.TrimSpace(m[2])
+	}
+	if i, err := t.Column(spec); err == nil {
+		if label == spec {
+			label = t.Columns[i]
+		}
+		return column{label: label, index: i}, nil
+	}
+	e, err := Parse(spec)
+	if err != nil {
+		return column{}, fmt.Errorf("%q is neither a column nor an expression: %w", spec, err)
+	}
+	return column{label: label, index: -1, expr: e}, nil
+}
+
+func (c column) value(t *Table, row []string) (Value, error) {
+	if c.index >= 0 {
+		return ParseCell(row[c.index]), nil
+	}
+	return c.expr.Eval(t.rowVars(row))
+}
+
+type aggregation struct {
+	label string
+	fn    string
+	arg   *column // nil for count
+}
+
+func (t *Table) aggregation(spec string) (aggregation, error) {
+	spec = strings.TrimSpace(spec)
+	label := spec
+	if m := aliasRe.FindStringSubmatch(spec); m != nil {
+		spec, label = strings.TrimSpace(m[1]), strings.TrimSpace(m[2])
+	}
+	m := aggRe.FindStringSubmatch(spec)
+	if m == nil {
+		return aggregation{}, fmt.Errorf("invalid aggregate %q (use e.g. count, mean(price) or sum(qty) as total)", spec)
+	}
+	a := aggregation{label: label, fn: strings.ToLower(m[1])}
+	if _, ok := aggregates[a.fn]; !ok && a.fn != "count" && a.fn != "distinct" {
+		return aggregation{}, fmt.Errorf("unknown aggregate %q (use count, distinct, sum, mean, median, min, max, std or var)", m[1])
+	}
+	if arg := strings.TrimSpace(m[2]); arg == "" || arg == "*" {
+		if a.fn != "count" {
+			return aggregation{}, fmt.Errorf("%s needs a column, e.g. %s(price)", a.fn, a.fn)
+		}
+		return a, nil
+	}
+	c, err := t.column(m[2])
+	if err != nil {
+		return aggregation{}, err
+	}
+	a.arg = &c
+	return a, nil
+}
+
+// apply computes the aggregation over some rows.
+func (a aggregation) apply(t *Table, rows [][]string) (string, error) {
+	if a.arg == nil {
+		return strconv.Itoa(len(rows)), nil
+	}
+	var xs []float64
+	distinct := make(map[string]bool)
+	count := 0
+	for _, row := range rows {
+		v, err := a.arg.value(t, row)
+		if err != nil {
+			return "", err
+		}
+		if v.Kind == KindString && v.Str == "" {
+			continue
+		}
+		count++
+		distinct[v.String()] = true
+		if v.Kind == KindNumber && !math.IsNaN(v.Num) {
+			xs = append(xs, v.Magnitude())
+		}
+	}
+	switch a.fn {
+	case "count":
+		return strconv.Itoa(count), nil
+	case "distinct":
+		return strconv.Itoa(len(distinct)), nil
+	}
+	if len(xs) == 0 {
+		return "", nil
+	}
+	return FormatNumber(aggregates[a.fn](xs)), nil
+}
+
+// Query runs q and returns the result as a new table.
+func (t *Table) Query(q Query) (*Table, error) {
+	rows := t.Rows
+	if strings.TrimSpace(q.Where) != "" {
+		where, err := Parse(q.Where)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %w", err)
+		}
+		rows = nil
+		for i, row := range t.Rows {
+			v, err := where.Eval(t.rowVars(row))
+			if err != nil {
+				return nil, fmt.Errorf("filter failed on row %d: %w", i+1, err)
+			}
+			if v.Truthy() {
+				rows = append(rows, row)
+			}
+		}
+	}
+
+	var out *Table
+	var err error
+	if len(q.GroupBy) > 0 || len(q.Aggregate) > 0 {
+		out, err = t.group(rows, q.GroupBy, q.Aggregate)
+	} else {
+		out, err = t.project(rows, q.Select)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if sortBy := strings.TrimSpace(q.Sort); sortBy != "" {
+		desc := strings.HasPrefix(sortBy, "-")
+		col, err := out.Column(strings.TrimPrefix(sortBy, "-"))
+		if err != nil {
+			return nil, fmt.Errorf("cannot sort: %w", err)
+		}
+		sort.SliceStable(out.Rows, func(i, j int) bool {
+			// Empty cells go last in either direction.
+			a, b := strings.TrimSpace(out.Rows[i][col]), strings.TrimSpace(out.Rows[j][col])
+			if a == "" || b == "" {
+				return a != "" && b == ""
+			}
+			if desc {
+				return lessCell(out.Rows[j][col], out.Rows[i][col])
+			}
+			return lessCell(out.Rows[i][col], out.Rows[j][col])
+		})
+	}
+	if q.Limit > 0 && len(out.Rows) > q.Limit {
+		out.Rows = out.Rows[:q.Limit]
+	}
+	return out, nil
+}
+
+func (t *Table) project(rows [][]string, specs []string) (*Table, error) {
+	if len(specs) == 0 {
+		return &Table{Columns: t.Columns, Rows: rows}, nil
+	}
+	var cols []column
+	out := &Table{}
+	for _, spec := range specs {
+		c, err := t.column(spec)
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, c)
+		out.Columns = append(out.Columns, c.label)
+	}
+	for _, row := range rows {
+		cells := make([]string, len(cols))
+		for i, c := range cols {
+			if c.index >= 0 {
+				cells[i] = row[c.index]
+				continue
+			}
+			v, err := c.value(t, row)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", c.label, err)
+			}
+			if v.Kind == KindNumber && math.IsNaN(v.Num) {
+				continue
+			}
+			cells[i] = v.String()
+		}
+		out.Rows = append(out.Rows, cells)
+	}
+	return out, nil
+}
+
+func (t *Table) group(rows [][]string, groupBy, specs []string) (*Table, error) {
+	if len(specs) == 0 {
+		specs = []string{"count"}
+	}
+	out := &Table{}
+	var keys []int
+	for _, name := range groupBy {
+		i, err := t.Column(name)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, i)
+		out.Columns = append(out.Columns, t.Columns[i])
+	}
+	var aggs []aggregation
+	for _, spec := range specs {
+		a, err := t.aggregation(spec)
+		if err != nil {
+			return nil, err
+		}
+		aggs = append(aggs, a)
+		out.Columns = append(out.Columns, a.label)
+	}
+
+	// Groups keep the order in which their first row appears.
+	var order []string
+	groups := make(map[string][][]string)
+	for _, row := range rows {
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = strings.TrimSpace(row[k])
+		}
+		key := strings.Join(parts, "\x00")
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], row)
+	}
+	if len(keys) == 0 && len(order) == 0 {
+		order, groups[""] = []string{""}, nil
+	}
+
+	for _, key := range order {
+		var cells []string
+		if len(keys) > 0 {
+			cells = strings.Split(key, "\x00")
+		}
+		for _, a := range aggs {
+			cell, err := a.apply(t, groups[key])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", a.label, err)
+			}
+			cells = append(cells, cell)
+		}
+		out.Rows = append(out.Rows, cells)
+	}
+	return out, nil
+}
+
+// lessCell orders numbers numerically before text, and text by case.
+func lessCell(a, b string) bool {
+	va, vb := ParseCell(a), ParseCell(b)
+	switch {
+	case va.Kind == KindNumber && vb.Kind == KindNumber:
+		return va.Num < vb.Num
+	case va.Kind == KindNumber:
+		return true
+	case vb.Kind == KindNumber:
+		return false
+	}
+	return strings.ToLower(va.Str) < strings.ToLower(vb.Str)
+}
@@ -0,0 +1,89 @@
+package calc
+
+import (
+	"strings"
+	"testing"
+)
+
+func testTable() *Table {
+	return NewTable([][]string{
+		{"Title", "Year", "Venue", "Citations"},
+		{"Attention Is All You Need", "2017", "NeurIPS", "120,000"},
+		{"BERT", "2019", "NAACL", "95000"},
+		{"GPT-3", "2020", "NeurIPS", "30000"},
+		{"LoRA", "2021", "ICLR", ""},
+		{"Scaling Laws", "2020", "arXiv"},
+	})
+}
+
+func TestTable_Query(t *testing.T) {
+	table := testTable()
+	if len(table.Rows) != 5 || len(table.Rows[4]) != 4 {
+		t.Fatalf("short rows were not padded: %v", table.Rows)
+	}
+
+	tests := []struct {
+		name string
+		q    Query
+		want string
+	}{
+		{
+			"filter and select",
+			Query{Where: "year >= 2020 and not contains(venue, 'arxiv')", Select: []string{"title", "citations / 1000 as kcites"}},
+			"Title,kcites|GPT-3,30|LoRA,",
+		},
+		{
+			"group and aggregate",
+			Query{GroupBy: []string{"venue"}, Aggregate: []string{"count", "sum(citations) as total", "distinct(year)"}, Sort: "-total"},
+			"Venue,count,total,distinct(year)|NeurIPS,2,150000,2|NAACL,1,95000,1|ICLR,1,,1|arXiv,1,,1",
+		},
+		{
+			"aggregate without groups",
+			Query{Where: "`Venue` == 'NeurIPS'", Aggregate: []string{"mean(citations)", "count(*)"}},
+			"mean(citations),count(*)|75000,2",
+		},
+		{
+			"sort and limit",
+			Query{Select: []string{"Title", "Year"}, Sort: "-year", Limit: 2},
+			"Title,Year|LoRA,2021|GPT-3,2020",
+		},
+	}
+	for _, tt := range tests {
+		out, err := table.Query(tt.q)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		got := []string{strings.Join(out.Columns, ",")}
+		for _, row := range out.Rows {
+			got = append(got, strings.Join(row, ","))
+		}
+		if strings.Join(got, "|") != tt.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", tt.name, strings.Join(got, "|"), tt.want)
+		}
+	}
+
+	for _, q := range []Query{
+		{Where: "year >="},
+		{GroupBy: []string{"country"}},
+		{Aggregate: []string{"mode(year)"}},
+		{Aggregate: []string{"sum"}},
+		{Sort: "rank"},
+	} {
+		if _, err := table.Query(q); err == nil {
+			t.Errorf("expected an error for %+v", q)
+		}
+	}
+}
+
+func TestTable_Vars(t *testing.T) {
+	table := testTable()
+	vars := table.Vars()
+	if _, ok := vars["title"]; ok {
+		t.Error("text columns should not become lists")
+	}
+	v, err := Eval("sum(citations) / count(citations)", vars)
+	if err != nil || v.String() != "81666.66667" {
+		t.Errorf("Eval = %v, %v", v, err)
+	}
+}
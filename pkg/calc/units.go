@@ -0,0 +1,314 @@
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Dim is a physical dimension as exponents of the base quantities length,
+// mass, time, current, temperature, amount of substance and information.
+type Dim [7]int8
+
+const (
+	dimLength = iota
+	dimMass
+	dimTime
+	dimCurrent
+	dimTemperature
+	dimAmount
+	dimData
+)
+
+var baseUnitNames = [7]string{"m", "kg", "s", "A", "K", "mol", "B"}
+
+// IsZero reports whether d is dimensionless.
+func (d Dim) IsZero() bool {
+	return d == Dim{}
+}
+
+// errDimRange is returned when a unit exponent does not fit in a Dim.
+var errDimRange = errors.New("unit exponent out of range")
+
+func (d Dim) add(o Dim, sign int) (Dim, error) {
+	for i := range d {
+		e := int(d[i]) + sign*int(o[i])
+		if e < math.MinInt8 || e > math.MaxInt8 {
+			return Dim{}, errDimRange
+		}
+		d[i] = int8(e)
+	}
+	return d, nil
+}
+
+func (d Dim) scale(n int) (Dim, error) {
+	for i := range d {
+		e := int(d[i]) * n
+		if e < math.MinInt8 || e > math.MaxInt8 {
+			return Dim{}, errDimRange
+		}
+		d[i] = int8(e)
+	}
+	return d, nil
+}
+
+// derivedUnits name common dimensions when a result has no preferred unit.
+var derivedUnits = map[Dim]string{
+	{1, 1, -2}:             "N",
+	{2, 1, -2}:             "J",
+	{2, 1, -3}:             "W",
+	{-1, 1, -2}:            "Pa",
+	{0, 0, -1}:             "Hz",
+	{2, 1, -3, -1}:         "V",
+	{2, 1, -3, -2}:         "ohm",
+	{1, 0, -1}:             "m/s",
+	{1, 0, -2}:             "m/s^2",
+	{-3, 1}:                "kg/m^3",
+	{0, 0, -1, 0, 0, 0, 1}: "B/s",
+}
+
+// String formats d in SI base units, e.g. "kg*m/s^2".
+func (d Dim) String() string {
+	if name, ok := derivedUnits[d]; ok {
+		return name
+	}
+	var num, den []string
+	for i, e := range d {
+		switch {
+		case e == 1:
+			num = append(num, baseUnitNames[i])
+		case e > 1:
+			num = append(num, fmt.Sprintf("%s^%d", baseUnitNames[i], e))
+		case e == -1:
+			den = append(den, baseUnitNames[i])
+		case e < -1:
+			den = append(den, fmt.Sprintf("%s^%d", baseUnitNames[i], -e))
+		}
+	}
+	s := strings.Join(num, "*")
+	if s == "" {
+		s = "1"
+	}
+	for _, u := range den {
+		s += "/" + u
+	}
+	return s
+}
+
+// unit converts between a unit and SI base units: si = x*factor + offset.
+// Only absolute temperatures have an offset.
+type unit struct {
+	factor float64
+	offset float64
+	dim    Dim
+}
+
+func (u unit) combine(o unit, sign int) (unit, error) {
+	if u.offset != 0 || o.offset != 0 {
+		return unit{}, fmt.Errorf("temperatures in degC or degF cannot be combined with other units; use K")
+	}
+	dim, err := u.dim.add(o.dim, sign)
+	if err != nil {
+		return unit{}, err
+	}
+	return unit{factor: u.factor * math.Pow(o.factor, float64(sign)), dim: dim}, nil
+}
+
+func (u unit) pow(n int) (unit, error) {
+	if u.offset != 0 {
+		return unit{}, fmt.Errorf("temperatures in degC or degF cannot be raised to a power; use K")
+	}
+	dim, err := u.dim.scale(n)
+	if err != nil {
+		return unit{}, err
+	}
+	return unit{factor: math.Pow(u.factor, float64(n)), dim: dim}, nil
+}
+
+var units = map[string]unit{}
+
+// defineUnit registers a unit under each of the space-separated names.
+func defineUnit(names string, factor float64, dim Dim) {
+	for _, name := range strings.Fields(names) {
+		units[name] = unit{factor: factor, dim: dim}
+	}
+}
+
+func init() {
+	var (
+		none     = Dim{}
+		length   = Dim{dimLength: 1}
+		area     = Dim{dimLength: 2}
+		volume   = Dim{dimLength: 3}
+		mass     = Dim{dimMass: 1}
+		duration = Dim{dimTime: 1}
+		current  = Dim{dimCurrent: 1}
+		temp     = Dim{dimTemperature: 1}
+		amount   = Dim{dimAmount: 1}
+		data     = Dim{dimData: 1}
+		speed    = Dim{dimLength: 1, dimTime: -1}
+		force    = Dim{dimLength: 1, dimMass: 1, dimTime: -2}
+		energy   = Dim{dimLength: 2, dimMass: 1, dimTime: -2}
+		power    = Dim{dimLength: 2, dimMass: 1, dimTime: -3}
+		pressure = Dim{dimLength: -1, dimMass: 1, dimTime: -2}
+		freq     = Dim{dimTime: -1}
+		voltage  = Dim{dimLength: 2, dimMass: 1, dimTime: -3, dimCurrent: -1}
+		ohm      = Dim{dimLength: 2, dimMass: 1, dimTime: -3, dimCurrent: -2}
+		charge   = Dim{dimTime: 1, dimCurrent: 1}
+	)
+
+	defineUnit("m meter meters metre metres", 1, length)
+	defineUnit("km kilometer kilometers kilometre kilometres", 1e3, length)
+	defineUnit("cm centimeter centimeters", 1e-2, length)
+	defineUnit("mm millimeter millimeters", 1e-3, length)
+	defineUnit("um µm micron microns micrometer micrometers", 1e-6, length)
+	defineUnit("nm nanometer nanometers", 1e-9, length)
+	defineUnit("in inch inches", 0.0254, length)
+	defineUnit("ft foot feet", 0.3048, length)
+	defineUnit("yd yard yards", 0.9144, length)
+	defineUnit("mi mile miles", 1609.344, length)
+	defineUnit("nmi", 1852, length)
+	defineUnit("au AU", 1.495978707e11, length)
+	defineUnit("ly lightyear lightyears", 9.4607304725808e15, length)
+	defineUnit("pc parsec parsecs", 3.0856775814913673e16, length)
+
+	defineUnit("ha hectare hectares", 1e4, area)
+	defineUnit("acre acres", 4046.8564224, area)
+	defineUnit("L l liter liters litre litres", 1e-3, volume)
+	defineUnit("mL ml milliliter milliliters", 1e-6, volume)
+	defineUnit("gal gallon gallons", 3.785411784e-3, volume)
+
+	defineUnit("kg kilogram kilograms", 1, mass)
+	defineUnit("g gram grams", 1e-3, mass)
+	defineUnit("mg milligram milligrams", 1e-6, mass)
+	defineUnit("ug µg mcg microgram micrograms", 1e-9, mass)
+	defineUnit("t tonne tonnes", 1e3, mass)
+	defineUnit("lb lbs pound pounds", 0.45359237, mass)
+	defineUnit("oz ounce ounces", 0.028349523125, mass)
+	defineUnit("st stone", 6.35029318, mass)
+
+	defineUnit("s sec secs second seconds", 1, duration)
+	defineUnit("ms millisecond milliseconds", 1e-3, duration)
+	defineUnit("us µs microsecond microseconds", 1e-6, duration)
+	defineUnit("ns nanosecond nanoseconds", 1e-9, duration)
+	defineUnit("min mins minute minutes", 60, duration)
+	defineUnit("h hr hrs hour hours", 3600, duration)
+	defineUnit("d day days", 86400, duration)
+	defineUnit("wk week weeks", 604800, duration)
+	defineUnit("mo month months", 2629800, duration)
+	defineUnit("yr year years", 31557600, duration)
+
+	defineUnit("A amp amps ampere amperes", 1, current)
+	defineUnit("mA", 1e-3, current)
+	defineUnit("mAh", 3.6, charge)
+	defineUnit("Ah", 3600, charge)
+
+	defineUnit("K kelvin", 1, temp)
+	for _, name := range strings.Fields("degC °C celsius") {
+		units[name] = unit{factor: 1, offset: 273.15, dim: temp}
+	}
+	for _, name := range strings.Fields("degF °F fahrenheit") {
+		units[name] = unit{factor: 5.0 / 9, offset: 459.67 * 5 / 9, dim: temp}
+	}
+
+	defineUnit("mol mole moles", 1, amount)
+	defineUnit("mmol", 1e-3, amount)
+	defineUnit("umol µmol", 1e-6, amount)
+
+	defineUnit("B byte bytes", 1, data)
+	defineUnit("bit bits", 0.125, data)
+	defineUnit("kB KB", 1e3, data)
+	defineUnit("MB", 1e6, data)
+	defineUnit("GB", 1e9, data)
+	defineUnit("TB", 1e12, data)
+	defineUnit("KiB", 1<<10, data)
+	defineUnit("MiB", 1<<20, data)
+	defineUnit("GiB", 1<<30, data)
+	defineUnit("TiB", 1<<40, data)
+	defineUnit("kbit", 125, data)
+	defineUnit("Mbit", 125e3, data)
+	defineUnit("Gbit", 125e6, data)
+
+	defineUnit("rad radian radians", 1, none)
+	defineUnit("deg degree degrees °", math.Pi/180, none)
+
+	defineUnit("mph", 0.44704, speed)
+	defineUnit("kph kmh", 1/3.6, speed)
+	defineUnit("kn knot knots", 1852.0/3600, speed)
+
+	defineUnit("N newton newtons", 1, force)
+	defineUnit("kN", 1e3, force)
+	defineUnit("lbf", 4.4482216152605, force)
+
+	defineUnit("J joule joules", 1, energy)
+	defineUnit("kJ", 1e3, energy)
+	defineUnit("MJ", 1e6, energy)
+	defineUnit("cal calorie calories", 4.184, energy)
+	defineUnit("kcal", 4184, energy)
+	defineUnit("Wh", 3600, energy)
+	defineUnit("kWh", 3.6e6, energy)
+	defineUnit("MWh", 3.6e9, energy)
+	defineUnit("eV", 1.602176634e-19, energy)
+	defineUnit("BTU btu", 1055.05585262, energy)
+
+	defineUnit("W watt watts", 1, power)
+	defineUnit("mW", 1e-3, power)
+	defineUnit("kW", 1e3, power)
+	defineUnit("MW", 1e6, power)
+	defineUnit("GW", 1e9, power)
+	defineUnit("hp", 745.69987158227022, power)
+
+	defineUnit("Pa pascal pascals", 1, pressure)
+	defineUnit("hPa", 100, pressure)
+	defineUnit("kPa", 1e3, pressure)
+	defineUnit("MPa", 1e6, pressure)
+	defineUnit("bar", 1e5, pressure)
+	defineUnit("mbar", 100, pressure)
+	defineUnit("atm", 101325, pressure)
+	defineUnit("psi", 6894.757293168361, pressure)
+	defineUnit("mmHg", 133.322387415, pressure)
+	defineUnit("torr", 101325.0/760, pressure)
+
+	defineUnit("Hz hertz", 1, freq)
+	defineUnit("kHz", 1e3, freq)
+	defineUnit("MHz", 1e6, freq)
+	defineUnit("GHz", 1e9, freq)
+
+	defineUnit("V volt volts", 1, voltage)
+	defineUnit("mV", 1e-3, voltage)
+	defineUnit("kV", 1e3, voltage)
+	defineUnit("ohm ohms Ω", 1, ohm)
+	defineUnit("kohm kΩ", 1e3, ohm)
+	defineUnit("Mohm MΩ", 1e6, ohm)
+}
+
+// lookupUnit finds a unit by name. Symbols are case-sensitive (mW is not
+// MW); longer names such as "Meters" also match in lower case.
+func lookupUnit(name string) (unit, bool) {
+	if u, ok := units[name]; ok {
+		return u, true
+	}
+	if len(name) > 3 {
+		u, ok := units[strings.ToLower(name)]
+		return u, ok
+	}
+	return unit{}, false
+}
+
+// parseUnitString parses a unit expression such as "km/h" or "kg*m/s^2".
+func parseUnitString(s string) (unit, error) {
+	toks, err := lex(s)
+	if err != nil {
+		return unit{}, err
+	}
+	p := &parser{toks: toks}
+	u, _, err := p.parseUnit()
+	if err != nil {
+		return unit{}, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return unit{}, fmt.Errorf("unexpected %q in unit %q", t.text, s)
+	}
+	return u, nil
+}
@@ -3,9 +3,14 @@ package channels
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/srikesh3005/summer/pkg/bus"
+	"github.com/srikesh3005/summer/pkg/logger"
+	"github.com/srikesh3005/summer/pkg/utils"
 )
 
 type Channel interface {
@@ -95,7 +100,7 @@ func (c *BaseChannel) HandleMessage(senderID, chatID, content string, media []st
 		SenderID:   senderID,
 		ChatID:     chatID,
 		Content:    content,
-		Media:      media,
+		Media:      keepDocuments(media),
 		SessionKey: sessionKey,
 		Metadata:   metadata,
 	}
@@ -103,6 +108,77 @@ func (c *BaseChannel) HandleMessage(senderID, chatID, content string, media []st
 	c.bus.PublishInbound(msg)
 }
 
+// keepDocuments preserves local document attachments. Channels delete
+// downloaded media as soon as HandleMessage returns, but the agent reads
+// documents later with read_document, so they are linked (or copied) into
+// a directory of their own first.
+func keepDocuments(media []string) []string {
+	out := make([]string, len(media))
+	copy(out, media)
+	for i, path := range out {
+		if !utils.IsDocumentFile(path) || strings.Contains(path, "://") {
+			continue
+		}
+		kept, err := keepFile(path, filepath.Join(os.TempDir(), "summer_media", "documents"))
+		if err != nil {
+			logger.WarnCF("channels", "Failed to keep document attachment", map[string]interface{}{
+				"path":  path,
+				"error": err.Error(),
+			})
+			continue
+		}
+		out[i] = kept
+	}
+	return out
+}
+
+func keepFile(path, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	dst := filepath.Join(dir, filepath.Base(path))
+	if err := os.Link(path, dst); err == nil {
+		return dst, nil
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return "", err
+	}
+	return dst, out.Close()
+}
+
 func (c *BaseChannel) setRunning(running bool) {
 	c.running = running
 }
+
+// maxReasoningChars caps the reasoning summary attached to outbound messages.
+const maxReasoningChars = 1500
+
+// reasoningSummary flattens model reasoning to a single paragraph suitable
+// for a collapsed/quoted preamble. Returns "" when there is nothing to show.
+func reasoningSummary(reasoning string) string {
+	summary := strings.Join(strings.Fields(reasoning), " ")
+	if len(summary) > maxReasoningChars {
+		summary = strings.TrimSpace(summary[:maxReasoningChars]) + "…"
+	}
+	return summary
+}
+
+// isImageFile reports whether path is an image chat apps can show inline.
+func isImageFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp":
+		return true
+	}
+	return false
+}
@@ -1,6 +1,11 @@
 package channels
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func TestBaseChannelIsAllowed(t *testing.T) {
 	tests := []struct {
@@ -50,3 +55,43 @@ func TestBaseChannelIsAllowed(t *testing.T) {
 		})
 	}
 }
+
+func TestReasoningSummary(t *testing.T) {
+	if got := reasoningSummary("  first line\n\n  second\tline "); got != "first line second line" {
+		t.Errorf("reasoningSummary() = %q", got)
+	}
+	if got := reasoningSummary(" \n "); got != "" {
+		t.Errorf("reasoningSummary(blank) = %q, want empty", got)
+	}
+	long := strings.Repeat("a ", maxReasoningChars)
+	if got := reasoningSummary(long); len(got) > maxReasoningChars+len("…") {
+		t.Errorf("len(reasoningSummary(long)) = %d, want at most %d", len(got), maxReasoningChars+len("…"))
+	}
+}
+
+func TestKeepDocuments(t *testing.T) {
+	dir := t.TempDir()
+	doc := filepath.Join(dir, "5e6f7a8b_keep-test.pdf")
+	photo := filepath.Join(dir, "photo.jpg")
+	for _, p := range []string{doc, photo} {
+		if err := os.WriteFile(p, []byte("data"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	media := []string{doc, photo, "https://example.com/remote.pdf"}
+	kept := keepDocuments(media)
+	defer os.Remove(kept[0])
+
+	if kept[0] == doc {
+		t.Fatalf("expected the document to be preserved elsewhere, got %s", kept[0])
+	}
+	if kept[1] != photo || kept[2] != media[2] {
+		t.Errorf("expected other media unchanged, got %v", kept)
+	}
+	// Channels remove their downloads once the message is handed off.
+	os.Remove(doc)
+	if data, err := os.ReadFile(kept[0]); err != nil || string(data) != "data" {
+		t.Errorf("expected preserved copy to survive, got %q, %v", data, err)
+	}
+}
@@ -3,7 +3,10 @@ package channels
 import (
 	"context"
 	"fmt"
+	"mime"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -101,14 +104,43 @@ func (c *DiscordChannel) Send(ctx context.Context, msg bus.OutboundMessage) erro
 	}
 
 	message := msg.Content
+	if summary := reasoningSummary(msg.Reasoning); summary != "" {
+		message = "💭 ||" + strings.ReplaceAll(summary, "||", "| |") + "||\n\n" + message
+	}
 
 	// 使用传入的 ctx 进行超时控制
 	sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
 	defer cancel()
 
+	// Files are attached to the message; Discord shows images inline.
+	var file *os.File
+	if msg.FilePath != "" {
+		var err error
+		if file, err = os.Open(msg.FilePath); err != nil {
+			return fmt.Errorf("failed to open file %q: %w", msg.FilePath, err)
+		}
+		defer file.Close()
+	}
+
 	done := make(chan error, 1)
 	go func() {
-		_, err := c.session.ChannelMessageSend(channelID, message)
+		if file == nil {
+			_, err := c.session.ChannelMessageSend(channelID, message)
+			done <- err
+			return
+		}
+		fileName := msg.FileName
+		if fileName == "" {
+			fileName = filepath.Base(msg.FilePath)
+		}
+		_, err := c.session.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+			Content: message,
+			Files: []*discordgo.File{{
+				Name:        fileName,
+				ContentType: mime.TypeByExtension(filepath.Ext(fileName)),
+				Reader:      file,
+			}},
+		})
 		done <- err
 	}()
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -117,17 +118,28 @@ func (c *SlackChannel) Send(ctx context.Context, msg bus.OutboundMessage) error
 		return fmt.Errorf("invalid slack chat ID: %s", msg.ChatID)
 	}
 
-	opts := []slack.MsgOption{
-		slack.MsgOptionText(msg.Content, false),
+	text := msg.Content
+	if summary := reasoningSummary(msg.Reasoning); summary != "" {
+		text = "> 💭 _" + summary + "_\n\n" + text
 	}
 
-	if threadTS != "" {
-		opts = append(opts, slack.MsgOptionTS(threadTS))
-	}
+	if msg.FilePath != "" {
+		if err := c.uploadFile(ctx, channelID, threadTS, text, msg); err != nil {
+			return err
+		}
+	} else {
+		opts := []slack.MsgOption{
+			slack.MsgOptionText(text, false),
+		}
 
-	_, _, err := c.api.PostMessageContext(ctx, channelID, opts...)
-	if err != nil {
-		return fmt.Errorf("failed to send slack message: %w", err)
+		if threadTS != "" {
+			opts = append(opts, slack.MsgOptionTS(threadTS))
+		}
+
+		_, _, err := c.api.PostMessageContext(ctx, channelID, opts...)
+		if err != nil {
+			return fmt.Errorf("failed to send slack message: %w", err)
+		}
 	}
 
 	if ref, ok := c.pendingAcks.LoadAndDelete(msg.ChatID); ok {
@@ -146,6 +158,38 @@ func (c *SlackChannel) Send(ctx context.Context, msg bus.OutboundMessage) error
 	return nil
 }
 
+// uploadFile shares a file in the channel (or thread) with text as its
+// comment. Slack previews images inline.
+func (c *SlackChannel) uploadFile(ctx context.Context, channelID, threadTS, text string, msg bus.OutboundMessage) error {
+	file, err := os.Open(msg.FilePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file %q: %w", msg.FilePath, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat file %q: %w", msg.FilePath, err)
+	}
+
+	fileName := msg.FileName
+	if fileName == "" {
+		fileName = filepath.Base(msg.FilePath)
+	}
+	_, err = c.api.UploadFileV2Context(ctx, slack.UploadFileV2Parameters{
+		Reader:          file,
+		FileSize:        int(info.Size()),
+		Filename:        fileName,
+		Title:           fileName,
+		InitialComment:  text,
+		Channel:         channelID,
+		ThreadTimestamp: threadTS,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload slack file: %w", err)
+	}
+	return nil
+}
+
 func (c *SlackChannel) eventLoop() {
 	for {
 		select {
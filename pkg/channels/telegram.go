@@ -3,6 +3,7 @@ package channels
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
@@ -141,7 +142,7 @@ func (c *TelegramChannel) Send(ctx context.Context, msg bus.OutboundMessage) err
 		c.placeholders.Delete(msg.ChatID)
 	}
 
-	// File delivery path (e.g. markdown reports).
+	// File delivery path (e.g. markdown reports, charts).
 	if msg.FilePath != "" {
 		file, openErr := os.Open(msg.FilePath)
 		if openErr != nil {
@@ -154,16 +155,31 @@ func (c *TelegramChannel) Send(ctx context.Context, msg bus.OutboundMessage) err
 			fileName = filepath.Base(msg.FilePath)
 		}
 
-		doc := tu.Document(tu.ID(chatID), tu.FileFromReader(file, fileName))
 		// Keep caption simple and short to avoid parse/limit issues.
-		if msg.Content != "" {
-			caption := msg.Content
-			if len(caption) > 900 {
-				caption = caption[:900] + "..."
+		caption := msg.Content
+		if len(caption) > 900 {
+			caption = caption[:900] + "..."
+		}
+
+		// Images are shown inline; fall back to a document when Telegram
+		// rejects the photo (e.g. too large or extreme dimensions).
+		if isImageFile(fileName) {
+			photo := tu.Photo(tu.ID(chatID), tu.FileFromReader(file, fileName))
+			photo.Caption = caption
+			if _, err = c.bot.SendPhoto(ctx, photo); err == nil {
+				return nil
+			}
+			logger.WarnCF("telegram", "Failed to send photo, sending as document", map[string]interface{}{
+				"error": err.Error(),
+			})
+			if _, err = file.Seek(0, io.SeekStart); err != nil {
+				return fmt.Errorf("failed to rewind file: %w", err)
 			}
-			doc.Caption = caption
 		}
 
+		doc := tu.Document(tu.ID(chatID), tu.FileFromReader(file, fileName))
+		doc.Caption = caption
+
 		if _, err = c.bot.SendDocument(ctx, doc); err != nil {
 			return fmt.Errorf("failed to send document: %w", err)
 		}
@@ -171,6 +187,9 @@ func (c *TelegramChannel) Send(ctx context.Context, msg bus.OutboundMessage) err
 	}
 
 	htmlContent := markdownToTelegramHTML(msg.Content)
+	if summary := reasoningSummary(msg.Reasoning); summary != "" {
+		htmlContent = "<blockquote expandable>💭 " + escapeHTML(summary) + "</blockquote>\n" + htmlContent
+	}
 
 	// Try to edit placeholder
 	if pID, ok := c.placeholders.Load(msg.ChatID); ok {
@@ -323,7 +342,11 @@ func (c *TelegramChannel) handleMessage(ctx context.Context, update telego.Updat
 			if content != "" {
 				content += "\n"
 			}
-			content += fmt.Sprintf("[file]")
+			if message.Document.FileName != "" {
+				content += fmt.Sprintf("[file: %s]", message.Document.FileName)
+			} else {
+				content += "[file]"
+			}
 		}
 	}
 
@@ -0,0 +1,585 @@
+// Package chart renders line, bar, scatter and histogram charts to PNG and
+// SVG using only the standard library.
+package chart
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Kind selects how a chart draws its series.
+type Kind string
+
+const (
+	Line      Kind = "line"
+	Bar       Kind = "bar"
+	Scatter   Kind = "scatter"
+	Histogram Kind = "histogram"
+)
+
+const (
+	defaultWidth  = 800
+	defaultHeight = 500
+	maxSeries     = 20
+	maxPoints     = 200000
+	maxBins       = 200
+)
+
+// Chart describes a chart to render. Series values that are NaN or
+// infinite are skipped; in line charts they break the line.
+type Chart struct {
+	Kind   Kind
+	Title  string
+	XLabel string
+	YLabel string
+	// Categories label the x positions of bar charts, and of line charts
+	// whose series have no X values.
+	Categories []string
+	Series     []Series
+	// Bins is the number of histogram bins; zero picks one from the data.
+	Bins int
+	// Width and Height are the image size in pixels, 800x500 by default.
+	Width  int
+	Height int
+}
+
+// Series is one set of values. X is optional for line and scatter charts
+// (points are numbered from 1) and ignored by bar charts; histograms bin
+// the Y values.
+type Series struct {
+	Name string
+	X    []float64
+	Y    []float64
+}
+
+var palette = []color.NRGBA{
+	{0x1f, 0x77, 0xb4, 0xff},
+	{0xff, 0x7f, 0x0e, 0xff},
+	{0x2c, 0xa0, 0x2c, 0xff},
+	{0xd6, 0x27, 0x28, 0xff},
+	{0x94, 0x67, 0xbd, 0xff},
+	{0x8c, 0x56, 0x4b, 0xff},
+	{0xe3, 0x77, 0xc2, 0xff},
+	{0x7f, 0x7f, 0x7f, 0xff},
+	{0xbc, 0xbd, 0x22, 0xff},
+	{0x17, 0xbe, 0xcf, 0xff},
+}
+
+var (
+	white     = color.NRGBA{0xff, 0xff, 0xff, 0xff}
+	ink       = color.NRGBA{0x33, 0x33, 0x33, 0xff}
+	gridColor = color.NRGBA{0xe6, 0xe6, 0xe6, 0xff}
+)
+
+func seriesColor(i int, alpha uint8) color.NRGBA {
+	c := palette[i%len(palette)]
+	c.A = alpha
+	return c
+}
+
+// Validate reports whether the chart can be rendered.
+func (c *Chart) Validate() error {
+	switch c.Kind {
+	case Line, Bar, Scatter, Histogram:
+	default:
+		return fmt.Errorf("unknown chart type %q (use line, bar, scatter or histogram)", c.Kind)
+	}
+	if len(c.Series) == 0 {
+		return fmt.Errorf("no data series to plot")
+	}
+	if len(c.Series) > maxSeries {
+		return fmt.Errorf("too many series (%d); the limit is %d", len(c.Series), maxSeries)
+	}
+	if c.Bins < 0 || c.Bins > maxBins {
+		return fmt.Errorf("bins must be between 1 and %d", maxBins)
+	}
+	total := 0
+	finite := false
+	for i, s := range c.Series {
+		name := c.seriesName(i)
+		if len(s.Y) == 0 {
+			return fmt.Errorf("series %q has no values", name)
+		}
+		if (c.Kind == Line || c.Kind == Scatter) && len(s.X) > 0 && len(s.X) != len(s.Y) {
+			return fmt.Errorf("series %q has %d x values but %d y values", name, len(s.X), len(s.Y))
+		}
+		if c.Kind == Bar && len(c.Categories) > 0 && len(s.Y) > len(c.Categories) {
+			return fmt.Errorf("series %q has %d values but there are only %d labels", name, len(s.Y), len(c.Categories))
+		}
+		total += len(s.Y)
+		for _, y := range s.Y {
+			finite = finite || isFinite(y)
+		}
+	}
+	if total > maxPoints {
+		return fmt.Errorf("too many points (%d); the limit is %d", total, maxPoints)
+	}
+	if !finite {
+		return fmt.Errorf("no numeric values to plot")
+	}
+	return nil
+}
+
+func (c *Chart) seriesName(i int) string {
+	if c.Series[i].Name != "" {
+		return c.Series[i].Name
+	}
+	return fmt.Sprintf("Series %d", i+1)
+}
+
+func (c *Chart) size() (int, int) {
+	w, h := c.Width, c.Height
+	if w <= 0 {
+		w = defaultWidth
+	}
+	if h <= 0 {
+		h = defaultHeight
+	}
+	return clampInt(w, 200, 4000), clampInt(h, 150, 4000)
+}
+
+// categorical reports whether x positions are labelled slots rather than
+// numbers.
+func (c *Chart) categorical() bool {
+	if c.Kind == Bar {
+		return true
+	}
+	if c.Kind != Line || len(c.Categories) == 0 {
+		return false
+	}
+	for _, s := range c.Series {
+		if len(s.X) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Chart) categoryLabels() []string {
+	n := 0
+	for _, s := range c.Series {
+		n = max(n, len(s.Y))
+	}
+	labels := make([]string, n)
+	for i := range labels {
+		if i < len(c.Categories) {
+			labels[i] = c.Categories[i]
+		} else {
+			labels[i] = strconv.Itoa(i + 1)
+		}
+	}
+	return labels
+}
+
+func (s Series) xAt(i int) float64 {
+	if len(s.X) > 0 {
+		return s.X[i]
+	}
+	return float64(i + 1)
+}
+
+// bins counts each series' values into equal-width bins over the range of
+// all values, using Sturges' rule when c.Bins is zero.
+func (c *Chart) bins() (edges []float64, counts [][]float64) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	n := 0
+	for _, s := range c.Series {
+		for _, v := range s.Y {
+			if isFinite(v) {
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+				n++
+			}
+		}
+	}
+	bins := c.Bins
+	if bins == 0 {
+		bins = clampInt(int(math.Ceil(math.Log2(float64(n))))+1, 1, 50)
+	}
+	if lo == hi {
+		lo, hi = lo-0.5, hi+0.5
+	}
+	width := (hi - lo) / float64(bins)
+	edges = make([]float64, bins+1)
+	for i := range edges {
+		edges[i] = lo + float64(i)*width
+	}
+	edges[bins] = hi
+	counts = make([][]float64, len(c.Series))
+	for i, s := range c.Series {
+		counts[i] = make([]float64, bins)
+		for _, v := range s.Y {
+			if !isFinite(v) {
+				continue
+			}
+			b := min(int((v-lo)/width), bins-1)
+			counts[i][b]++
+		}
+	}
+	return edges, counts
+}
+
+// canvas is the drawing surface shared by the PNG and SVG backends.
+// Coordinates are pixels from the top left; text is vertically centred on
+// y and, when vertical, reads bottom to top.
+type canvas interface {
+	rect(x, y, w, h float64, c color.NRGBA)
+	polyline(xs, ys []float64, width float64, c color.NRGBA)
+	circle(x, y, r float64, c color.NRGBA)
+	text(x, y float64, s string, scale int, anchor anchor, vertical bool, c color.NRGBA)
+}
+
+type anchor int
+
+const (
+	anchorStart anchor = iota
+	anchorMiddle
+	anchorEnd
+)
+
+const (
+	labelScale = 2
+	titleScale = 3
+	pad        = 12
+)
+
+// frame maps data coordinates to the plot area.
+type frame struct {
+	left, top, width, height float64
+	xmin, xmax, ymin, ymax   float64
+}
+
+func (f *frame) px(x float64) float64 {
+	return f.left + (x-f.xmin)/(f.xmax-f.xmin)*f.width
+}
+
+func (f *frame) py(y float64) float64 {
+	return f.top + f.height - (y-f.ymin)/(f.ymax-f.ymin)*f.height
+}
+
+// draw lays out and paints the chart onto cv.
+func (c *Chart) draw(cv canvas) {
+	w, h := c.size()
+	width, height := float64(w), float64(h)
+	categorical := c.categorical()
+
+	var edges []float64
+	var counts [][]float64
+	if c.Kind == Histogram {
+		edges, counts = c.bins()
+	}
+
+	// Data ranges.
+	ylo, yhi := math.Inf(1), math.Inf(-1)
+	xlo, xhi := math.Inf(1), math.Inf(-1)
+	if c.Kind == Histogram {
+		for _, cs := range counts {
+			for _, v := range cs {
+				yhi = math.Max(yhi, v)
+			}
+		}
+		ylo = 0
+		xlo, xhi = edges[0], edges[len(edges)-1]
+	} else {
+		for _, s := range c.Series {
+			for i, y := range s.Y {
+				x := s.xAt(i)
+				if !isFinite(y) || !isFinite(x) {
+					continue
+				}
+				ylo, yhi = math.Min(ylo, y), math.Max(yhi, y)
+				xlo, xhi = math.Min(xlo, x), math.Max(xhi, x)
+			}
+		}
+	}
+	var labels []string
+	if categorical {
+		labels = c.categoryLabels()
+		xlo, xhi = -0.5, float64(len(labels))-0.5
+	} else if c.Kind != Histogram {
+		xlo, xhi = padRange(xlo, xhi, 0.03)
+	}
+	if c.Kind == Bar || c.Kind == Histogram {
+		ylo, yhi = math.Min(ylo, 0), math.Max(yhi, 0)
+		if yhi > 0 {
+			yhi += (yhi - ylo) * 0.05
+		}
+		if ylo < 0 {
+			ylo -= (yhi - ylo) * 0.05
+		}
+		if ylo == yhi {
+			yhi = 1
+		}
+	} else {
+		ylo, yhi = padRange(ylo, yhi, 0.05)
+	}
+
+	// Margins depend on the labels that have to fit around the plot.
+	lh := float64(lineHeight * labelScale)
+	top := float64(pad)
+	if c.Title != "" {
+		top += float64(lineHeight*titleScale) + pad
+	}
+	bottom := float64(pad) + lh + pad/2
+	if c.XLabel != "" {
+		bottom += lh + pad/2
+	}
+	left := float64(pad)
+	if c.YLabel != "" {
+		left += lh + pad/2
+	}
+	plotHeight := height - top - bottom
+	minStep := 0.0
+	if c.Kind == Histogram {
+		minStep = 1 // counts
+	}
+	yticks, ystep := niceTicks(ylo, yhi, clampInt(int(plotHeight/60), 2, 10), minStep)
+	ytickLabels := formatTicks(yticks, ystep)
+	tickWidth := 0.0
+	for _, l := range ytickLabels {
+		tickWidth = math.Max(tickWidth, textWidth(l, labelScale))
+	}
+	left += tickWidth + pad/2
+	right := float64(pad) * 2
+	legend := len(c.Series) > 1
+	if legend {
+		nameWidth := 0.0
+		for i := range c.Series {
+			nameWidth = math.Max(nameWidth, textWidth(truncate(c.seriesName(i), 20), labelScale))
+		}
+		right += lh + pad/2 + nameWidth
+	}
+	f := &frame{left: left, top: top, width: width - left - right, height: plotHeight,
+		xmin: xlo, xmax: xhi, ymin: ylo, ymax: yhi}
+	if f.width < 50 || f.height < 50 {
+		f.width, f.height = math.Max(f.width, 50), math.Max(f.height, 50)
+	}
+
+	cv.rect(0, 0, width, height, white)
+
+	// Grid and y axis labels.
+	for i, t := range yticks {
+		y := f.py(t)
+		cv.rect(f.left, y-0.5, f.width, 1, gridColor)
+		cv.text(f.left-pad/2, y, ytickLabels[i], labelScale, anchorEnd, false, ink)
+	}
+
+	// X axis labels.
+	xlabelY := f.top + f.height + pad/2 + lh/2
+	if categorical {
+		maxWidth := 0.0
+		for i, l := range labels {
+			labels[i] = truncate(l, 14)
+			maxWidth = math.Max(maxWidth, textWidth(labels[i], labelScale))
+		}
+		slot := f.width / float64(len(labels))
+		every := max(1, int(math.Ceil((maxWidth+pad)/slot)))
+		for i := 0; i < len(labels); i += every {
+			x := f.px(float64(i))
+			cv.rect(x-0.5, f.top+f.height, 1, 5, ink)
+			cv.text(x, xlabelY, labels[i], labelScale, anchorMiddle, false, ink)
+		}
+	} else {
+		xticks, xstep := niceTicks(xlo, xhi, clampInt(int(f.width/110), 2, 10), 0)
+		xtickLabels := formatTicks(xticks, xstep)
+		for i, t := range xticks {
+			x := f.px(t)
+			cv.rect(x-0.5, f.top+f.height, 1, 5, ink)
+			cv.text(x, xlabelY, xtickLabels[i], labelScale, anchorMiddle, false, ink)
+		}
+	}
+
+	// Series.
+	switch c.Kind {
+	case Line:
+		for i, s := range c.Series {
+			col := seriesColor(i, 0xff)
+			var xs, ys []float64
+			flush := func() {
+				if len(xs) > 1 {
+					cv.polyline(xs, ys, 2.5, col)
+				}
+				xs, ys = xs[:0], ys[:0]
+			}
+			for j, y := range s.Y {
+				x := float64(j)
+				if !categorical {
+					x = s.xAt(j)
+				}
+				if !isFinite(x) || !isFinite(y) {
+					flush()
+					continue
+				}
+				xs, ys = append(xs, f.px(x)), append(ys, f.py(y))
+			}
+			flush()
+			if len(s.Y) <= 60 {
+				for j, y := range s.Y {
+					x := float64(j)
+					if !categorical {
+						x = s.xAt(j)
+					}
+					if isFinite(x) && isFinite(y) {
+						cv.circle(f.px(x), f.py(y), 3.5, col)
+					}
+				}
+			}
+		}
+	case Scatter:
+		for i, s := range c.Series {
+			col := seriesColor(i, 0xcc)
+			for j, y := range s.Y {
+				x := s.xAt(j)
+				if isFinite(x) && isFinite(y) {
+					cv.circle(f.px(x), f.py(y), 4, col)
+				}
+			}
+		}
+	case Bar:
+		slot := f.width / float64(len(labels))
+		barWidth := slot * 0.8 / float64(len(c.Series))
+		zero := f.py(0)
+		for i, s := range c.Series {
+			col := seriesColor(i, 0xff)
+			for j, y := range s.Y {
+				if !isFinite(y) {
+					continue
+				}
+				x := f.px(float64(j)) - slot*0.4 + float64(i)*barWidth
+				top := math.Min(zero, f.py(y))
+				cv.rect(x, top, math.Max(barWidth-1, 1), math.Abs(f.py(y)-zero), col)
+			}
+		}
+	case Histogram:
+		alpha := uint8(0xe6)
+		if len(c.Series) > 1 {
+			alpha = 0x8c
+		}
+		for i, cs := range counts {
+			col := seriesColor(i, alpha)
+			for b, n := range cs {
+				if n == 0 {
+					continue
+				}
+				x0, x1 := f.px(edges[b]), f.px(edges[b+1])
+				cv.rect(x0, f.py(n), math.Max(x1-x0-1, 1), f.py(0)-f.py(n), col)
+			}
+		}
+	}
+
+	// Axes.
+	cv.rect(f.left-1, f.top, 1, f.height+1, ink)
+	cv.rect(f.left-1, f.top+f.height, f.width+1, 1, ink)
+	if ylo < 0 && yhi > 0 {
+		cv.rect(f.left, f.py(0)-0.5, f.width, 1, ink)
+	}
+
+	// Titles and legend.
+	if c.Title != "" {
+		scale := titleScale
+		if textWidth(c.Title, scale) > width-2*pad {
+			scale = labelScale
+		}
+		title := truncate(c.Title, int((width-2*pad)/float64(glyphAdvance*scale)))
+		cv.text(width/2, pad+float64(lineHeight*titleScale)/2, title, scale, anchorMiddle, false, ink)
+	}
+	if c.XLabel != "" {
+		label := truncate(c.XLabel, int(f.width/float64(glyphAdvance*labelScale)))
+		cv.text(f.left+f.width/2, height-pad-lh/2, label, labelScale, anchorMiddle, false, ink)
+	}
+	if c.YLabel != "" {
+		label := truncate(c.YLabel, int(f.height/float64(glyphAdvance*labelScale)))
+		cv.text(pad+lh/2, f.top+f.height/2, label, labelScale, anchorMiddle, true, ink)
+	}
+	if legend {
+		x := f.left + f.width + pad
+		for i := range c.Series {
+			y := f.top + lh/2 + float64(i)*(lh+pad/2)
+			cv.rect(x, y-lh/2+2, lh-4, lh-4, seriesColor(i, 0xff))
+			cv.text(x+lh+pad/2-4, y, truncate(c.seriesName(i), 20), labelScale, anchorStart, false, ink)
+		}
+	}
+}
+
+// padRange widens [lo, hi] by frac on both sides, or around a single
+// value by a unit-ish amount.
+func padRange(lo, hi, frac float64) (float64, float64) {
+	if lo == hi {
+		d := math.Abs(lo) * 0.1
+		if d == 0 {
+			d = 1
+		}
+		return lo - d, hi + d
+	}
+	d := (hi - lo) * frac
+	return lo - d, hi + d
+}
+
+// niceTicks returns tick positions inside [lo, hi] spaced by 1, 2 or 5
+// times a power of ten and at least minStep, aiming for about n ticks.
+func niceTicks(lo, hi float64, n int, minStep float64) ([]float64, float64) {
+	raw := (hi - lo) / float64(max(n-1, 1))
+	exp := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := 10 * exp
+	switch f := raw / exp; {
+	case f < 1.5:
+		step = exp
+	case f < 3:
+		step = 2 * exp
+	case f < 7:
+		step = 5 * exp
+	}
+	step = math.Max(step, minStep)
+	var ticks []float64
+	for i := math.Ceil(lo/step - 1e-9); i*step <= hi+step*1e-9; i++ {
+		v := i * step
+		if math.Abs(v) < step*1e-9 {
+			v = 0
+		}
+		ticks = append(ticks, v)
+	}
+	return ticks, step
+}
+
+// formatTicks formats tick values with as many decimals as the step
+// needs, abbreviating millions and billions.
+func formatTicks(ticks []float64, step float64) []string {
+	div, suffix := 1.0, ""
+	largest := 0.0
+	for _, t := range ticks {
+		largest = math.Max(largest, math.Abs(t))
+	}
+	switch {
+	case largest >= 1e9:
+		div, suffix = 1e9, "B"
+	case largest >= 1e6:
+		div, suffix = 1e6, "M"
+	}
+	decimals := max(0, int(-math.Floor(math.Log10(step/div)+1e-9)))
+	out := make([]string, len(ticks))
+	for i, t := range ticks {
+		out[i] = strconv.FormatFloat(t/div, 'f', decimals, 64) + suffix
+	}
+	return out
+}
+
+func truncate(s string, n int) string {
+	if n < 3 {
+		n = 3
+	}
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:n-2]) + ".."
+}
+
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+func clampInt(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
@@ -0,0 +1,116 @@
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	charts := []*Chart{
+		{Kind: Line, Title: "Citations per year", XLabel: "Year", YLabel: "Citations",
+			Series: []Series{
+				{Name: "BERT", X: []float64{2019, 2020, 2021, 2022}, Y: []float64{1200, 5400, math.NaN(), 9800}},
+				{Name: "GPT-3", X: []float64{2020, 2021, 2022}, Y: []float64{800, 4100, 7600}},
+			}},
+		{Kind: Line, Categories: []string{"Jan", "Feb", "Mar"}, Series: []Series{{Y: []float64{3, 1, 2}}}},
+		{Kind: Bar, Title: "Venues", Categories: []string{"NeurIPS", "ICLR", "ACL"},
+			Series: []Series{{Name: "2023", Y: []float64{12, 7, -3}}, {Name: "2024", Y: []float64{15, 9}}}},
+		{Kind: Scatter, Width: 400, Height: 300, Series: []Series{{X: []float64{1, 2, 3}, Y: []float64{2, 4, 5}}}},
+		{Kind: Histogram, Bins: 5, Series: []Series{{Y: []float64{1, 2, 2, 3, 3, 3, 4, 4, 5}}}},
+	}
+	for _, c := range charts {
+		var buf bytes.Buffer
+		if err := c.PNG(&buf); err != nil {
+			t.Fatalf("%s PNG: %v", c.Kind, err)
+		}
+		img, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("%s PNG does not decode: %v", c.Kind, err)
+		}
+		w, h := c.size()
+		if b := img.Bounds(); b.Dx() != w || b.Dy() != h {
+			t.Errorf("%s PNG is %v, want %dx%d", c.Kind, b, w, h)
+		}
+
+		buf.Reset()
+		if err := c.SVG(&buf); err != nil {
+			t.Fatalf("%s SVG: %v", c.Kind, err)
+		}
+		svg := buf.String()
+		if !strings.HasPrefix(svg, "<svg ") || !strings.HasSuffix(svg, "</svg>\n") {
+			t.Errorf("%s SVG is not a document:\n%s", c.Kind, svg)
+		}
+		if c.Title != "" && !strings.Contains(svg, ">"+c.Title+"</text>") {
+			t.Errorf("%s SVG is missing the title", c.Kind)
+		}
+	}
+}
+
+func TestRender_Series(t *testing.T) {
+	var buf bytes.Buffer
+	c := &Chart{Kind: Line, Series: []Series{{Name: "a<b", Y: []float64{1, math.NaN(), 2, 3}}, {Y: []float64{2, 2}}}}
+	if err := c.SVG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+	// The NaN splits the first series, leaving a lone point that is drawn
+	// only as a marker.
+	if n := strings.Count(svg, "<polyline"); n != 2 {
+		t.Errorf("got %d polylines, want 2", n)
+	}
+	if !strings.Contains(svg, ">a&lt;b</text>") || !strings.Contains(svg, ">Series 2</text>") {
+		t.Errorf("legend missing or not escaped:\n%s", svg)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, c := range []*Chart{
+		{Kind: "pie", Series: []Series{{Y: []float64{1}}}},
+		{Kind: Line},
+		{Kind: Line, Series: []Series{{X: []float64{1}, Y: []float64{1, 2}}}},
+		{Kind: Bar, Categories: []string{"a"}, Series: []Series{{Y: []float64{1, 2}}}},
+		{Kind: Scatter, Series: []Series{{Y: []float64{math.NaN()}}}},
+		{Kind: Histogram, Bins: 1000, Series: []Series{{Y: []float64{1}}}},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("expected an error for %+v", c)
+		}
+	}
+}
+
+func TestNiceTicks(t *testing.T) {
+	tests := []struct {
+		lo, hi float64
+		n      int
+		want   []string
+	}{
+		{0, 10, 6, []string{"0", "2", "4", "6", "8", "10"}},
+		{-0.13, 0.42, 6, []string{"-0.1", "0.0", "0.1", "0.2", "0.3", "0.4"}},
+		{2017, 2021, 5, []string{"2017", "2018", "2019", "2020", "2021"}},
+		{0, 2.5e6, 3, []string{"0M", "1M", "2M"}},
+	}
+	for _, tt := range tests {
+		ticks, step := niceTicks(tt.lo, tt.hi, tt.n, 0)
+		if got := formatTicks(ticks, step); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ticks(%v, %v) = %q, want %q", tt.lo, tt.hi, got, tt.want)
+		}
+	}
+}
+
+func TestFont(t *testing.T) {
+	for ch := ' '; ch <= '~'; ch++ {
+		if _, ok := glyphs[ch]; !ok {
+			t.Errorf("font has no glyph for %q", ch)
+		}
+	}
+	if glyph('€') != glyphs['?'] {
+		t.Error("unknown characters should render as '?'")
+	}
+	if err := parseFont("A ..... .....\n"); err == nil {
+		t.Error("expected an error for a short glyph")
+	}
+}
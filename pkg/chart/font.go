@@ -0,0 +1,85 @@
+package chart
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	glyphWidth  = 5
+	glyphHeight = 8
+	// glyphAdvance and lineHeight include one pixel of spacing.
+	glyphAdvance = glyphWidth + 1
+	lineHeight   = glyphHeight + 1
+)
+
+//go:embed font5x8.txt
+var fontData string
+
+// glyphs holds one bitmask per row for each character, bit 4 being the
+// leftmost column.
+var glyphs = map[rune][glyphHeight]uint8{}
+
+func init() {
+	if err := parseFont(fontData); err != nil {
+		panic(err)
+	}
+}
+
+func parseFont(data string) error {
+	for i, line := range strings.Split(data, "\n") {
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != glyphHeight+1 {
+			return fmt.Errorf("font line %d: expected a glyph and %d rows", i+1, glyphHeight)
+		}
+		ch, _ := utf8.DecodeRuneInString(fields[0])
+		if fields[0] == "space" {
+			ch = ' '
+		}
+		var rows [glyphHeight]uint8
+		for r, row := range fields[1:] {
+			if len(row) != glyphWidth {
+				return fmt.Errorf("font line %d: row %d is not %d pixels wide", i+1, r+1, glyphWidth)
+			}
+			for c := 0; c < glyphWidth; c++ {
+				if row[c] == '#' {
+					rows[r] |= 1 << (glyphWidth - 1 - c)
+				}
+			}
+		}
+		glyphs[ch] = rows
+	}
+	return nil
+}
+
+// glyph returns the bitmap for ch, substituting '?' for characters the
+// font does not cover.
+func glyph(ch rune) [glyphHeight]uint8 {
+	if g, ok := glyphs[ch]; ok {
+		return g
+	}
+	switch ch {
+	case '–', '—', '−':
+		return glyphs['-']
+	case '‘', '’':
+		return glyphs['\'']
+	case '“', '”':
+		return glyphs['"']
+	}
+	return glyphs['?']
+}
+
+// textWidth is the width in pixels of s drawn at the given scale. The SVG
+// backend uses the same metric for layout so both formats line up.
+func textWidth(s string, scale int) float64 {
+	n := utf8.RuneCountInString(s)
+	if n == 0 {
+		return 0
+	}
+	return float64((n*glyphAdvance - 1) * scale)
+}
@@ -0,0 +1,144 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// PNG renders the chart as a PNG image.
+func (c *Chart) PNG(w io.Writer) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	width, height := c.size()
+	r := &raster{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+	c.draw(r)
+	return png.Encode(w, r.img)
+}
+
+// raster draws onto an RGBA image. Lines and circles are anti-aliased by
+// pixel coverage; text uses the embedded bitmap font.
+type raster struct {
+	img *image.RGBA
+}
+
+// blend composites c over the pixel at (x, y) with the given coverage.
+func (r *raster) blend(x, y int, c color.NRGBA, coverage float64) {
+	if !(image.Point{x, y}.In(r.img.Rect)) || coverage <= 0 {
+		return
+	}
+	a := float64(c.A) / 255 * math.Min(coverage, 1)
+	i := r.img.PixOffset(x, y)
+	p := r.img.Pix[i : i+4 : i+4]
+	p[0] = uint8(float64(c.R)*a + float64(p[0])*(1-a) + 0.5)
+	p[1] = uint8(float64(c.G)*a + float64(p[1])*(1-a) + 0.5)
+	p[2] = uint8(float64(c.B)*a + float64(p[2])*(1-a) + 0.5)
+	p[3] = uint8(255*a + float64(p[3])*(1-a) + 0.5)
+}
+
+func (r *raster) rect(x, y, w, h float64, c color.NRGBA) {
+	x0, y0 := int(math.Round(x)), int(math.Round(y))
+	x1, y1 := int(math.Round(x+w)), int(math.Round(y+h))
+	if x1 == x0 {
+		x1++
+	}
+	if y1 == y0 {
+		y1++
+	}
+	for py := y0; py < y1; py++ {
+		for px := x0; px < x1; px++ {
+			r.blend(px, py, c, 1)
+		}
+	}
+}
+
+func (r *raster) polyline(xs, ys []float64, width float64, c color.NRGBA) {
+	// Each pixel takes the best coverage of any segment so joints are not
+	// painted twice.
+	half := width / 2
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for i := range xs {
+		minX, maxX = math.Min(minX, xs[i]), math.Max(maxX, xs[i])
+		minY, maxY = math.Min(minY, ys[i]), math.Max(maxY, ys[i])
+	}
+	b := r.img.Rect.Intersect(image.Rect(int(minX-half-1), int(minY-half-1), int(maxX+half+2), int(maxY+half+2)))
+	cover := make([]float64, b.Dx()*b.Dy())
+	for i := 1; i < len(xs); i++ {
+		x0, y0, x1, y1 := xs[i-1], ys[i-1], xs[i], ys[i]
+		sb := b.Intersect(image.Rect(int(math.Min(x0, x1)-half-1), int(math.Min(y0, y1)-half-1),
+			int(math.Max(x0, x1)+half+2), int(math.Max(y0, y1)+half+2)))
+		for py := sb.Min.Y; py < sb.Max.Y; py++ {
+			for px := sb.Min.X; px < sb.Max.X; px++ {
+				d := segmentDistance(float64(px)+0.5, float64(py)+0.5, x0, y0, x1, y1)
+				k := (py-b.Min.Y)*b.Dx() + px - b.Min.X
+				cover[k] = math.Max(cover[k], half+0.5-d)
+			}
+		}
+	}
+	for py := b.Min.Y; py < b.Max.Y; py++ {
+		for px := b.Min.X; px < b.Max.X; px++ {
+			r.blend(px, py, c, cover[(py-b.Min.Y)*b.Dx()+px-b.Min.X])
+		}
+	}
+}
+
+func (r *raster) circle(x, y, radius float64, c color.NRGBA) {
+	for py := int(y - radius - 1); py <= int(y+radius+1); py++ {
+		for px := int(x - radius - 1); px <= int(x+radius+1); px++ {
+			d := math.Hypot(float64(px)+0.5-x, float64(py)+0.5-y)
+			r.blend(px, py, c, radius+0.5-d)
+		}
+	}
+}
+
+func (r *raster) text(x, y float64, s string, scale int, a anchor, vertical bool, c color.NRGBA) {
+	w := textWidth(s, scale)
+	u0 := 0.0
+	switch a {
+	case anchorMiddle:
+		u0 = -w / 2
+	case anchorEnd:
+		u0 = -w
+	}
+	// u runs along the text and v across it, centred on the cap height.
+	v0 := -float64((glyphHeight-1)*scale) / 2
+	ox, oy := int(math.Round(x)), int(math.Round(y))
+	i := 0
+	for _, ch := range s {
+		g := glyph(ch)
+		for row := 0; row < glyphHeight; row++ {
+			for col := 0; col < glyphWidth; col++ {
+				if g[row]&(1<<(glyphWidth-1-col)) == 0 {
+					continue
+				}
+				u := int(u0) + (i*glyphAdvance+col)*scale
+				v := int(v0) + row*scale
+				for dy := 0; dy < scale; dy++ {
+					for dx := 0; dx < scale; dx++ {
+						if vertical {
+							r.blend(ox+v+dy, oy-u-dx-1, c, 1)
+						} else {
+							r.blend(ox+u+dx, oy+v+dy, c, 1)
+						}
+					}
+				}
+			}
+		}
+		i++
+	}
+}
+
+// segmentDistance is the distance from (px, py) to the segment from
+// (x0, y0) to (x1, y1).
+func segmentDistance(px, py, x0, y0, x1, y1 float64) float64 {
+	dx, dy := x1-x0, y1-y0
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, ((px-x0)*dx+(py-y0)*dy)/l))
+	}
+	return math.Hypot(px-(x0+t*dx), py-(y0+t*dy))
+}
@@ -0,0 +1,75 @@
+package chart
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SVG renders the chart as a standalone SVG document.
+func (c *Chart) SVG(w io.Writer) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	width, height := c.size()
+	s := &svg{}
+	fmt.Fprintf(&s.buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Helvetica, Arial, sans-serif">`+"\n",
+		width, height, width, height)
+	c.draw(s)
+	s.buf.WriteString("</svg>\n")
+	_, err := w.Write(s.buf.Bytes())
+	return err
+}
+
+type svg struct {
+	buf bytes.Buffer
+}
+
+func (s *svg) rect(x, y, w, h float64, c color.NRGBA) {
+	fmt.Fprintf(&s.buf, `<rect x="%s" y="%s" width="%s" height="%s" %s/>`+"\n", num(x), num(y), num(w), num(h), fill(c))
+}
+
+func (s *svg) polyline(xs, ys []float64, width float64, c color.NRGBA) {
+	points := make([]string, len(xs))
+	for i := range xs {
+		points[i] = num(xs[i]) + "," + num(ys[i])
+	}
+	fmt.Fprintf(&s.buf, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%s" stroke-linejoin="round" stroke-linecap="round"/>`+"\n",
+		strings.Join(points, " "), hex(c), num(width))
+}
+
+func (s *svg) circle(x, y, r float64, c color.NRGBA) {
+	fmt.Fprintf(&s.buf, `<circle cx="%s" cy="%s" r="%s" %s/>`+"\n", num(x), num(y), num(r), fill(c))
+}
+
+func (s *svg) text(x, y float64, str string, scale int, a anchor, vertical bool, c color.NRGBA) {
+	anchors := [...]string{"start", "middle", "end"}
+	transform := ""
+	if vertical {
+		transform = fmt.Sprintf(` transform="rotate(-90 %s %s)"`, num(x), num(y))
+	}
+	fmt.Fprintf(&s.buf, `<text x="%s" y="%s" font-size="%d" text-anchor="%s" dominant-baseline="central" %s%s>`,
+		num(x), num(y), 10*scale, anchors[a], fill(c), transform)
+	xml.EscapeText(&s.buf, []byte(str))
+	s.buf.WriteString("</text>\n")
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+func hex(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func fill(c color.NRGBA) string {
+	if c.A == 0xff {
+		return fmt.Sprintf(`fill="%s"`, hex(c))
+	}
+	return fmt.Sprintf(`fill="%s" fill-opacity="%s"`, hex(c), num(float64(c.A)/255))
+}
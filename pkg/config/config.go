@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/caarlos0/env/v11"
@@ -59,13 +60,81 @@ type AgentsConfig struct {
 }
 
 type AgentDefaults struct {
-	Workspace           string  `json:"workspace" env:"SUMMER_AGENTS_DEFAULTS_WORKSPACE"`
-	RestrictToWorkspace bool    `json:"restrict_to_workspace" env:"SUMMER_AGENTS_DEFAULTS_RESTRICT_TO_WORKSPACE"`
-	Provider            string  `json:"provider" env:"SUMMER_AGENTS_DEFAULTS_PROVIDER"`
-	Model               string  `json:"model" env:"SUMMER_AGENTS_DEFAULTS_MODEL"`
-	MaxTokens           int     `json:"max_tokens" env:"SUMMER_AGENTS_DEFAULTS_MAX_TOKENS"`
-	Temperature         float64 `json:"temperature" env:"SUMMER_AGENTS_DEFAULTS_TEMPERATURE"`
-	MaxToolIterations   int     `json:"max_tool_iterations" env:"SUMMER_AGENTS_DEFAULTS_MAX_TOOL_ITERATIONS"`
+	Workspace           string                    `json:"workspace" env:"SUMMER_AGENTS_DEFAULTS_WORKSPACE"`
+	RestrictToWorkspace bool                      `json:"restrict_to_workspace" env:"SUMMER_AGENTS_DEFAULTS_RESTRICT_TO_WORKSPACE"`
+	Provider            string                    `json:"provider" env:"SUMMER_AGENTS_DEFAULTS_PROVIDER"`
+	Model               string                    `json:"model" env:"SUMMER_AGENTS_DEFAULTS_MODEL"`
+	MaxTokens           int                       `json:"max_tokens" env:"SUMMER_AGENTS_DEFAULTS_MAX_TOKENS"`
+	Temperature         float64                   `json:"temperature" env:"SUMMER_AGENTS_DEFAULTS_TEMPERATURE"`
+	MaxToolIterations   int                       `json:"max_tool_iterations" env:"SUMMER_AGENTS_DEFAULTS_MAX_TOOL_ITERATIONS"`
+	ShowReasoning       bool                      `json:"show_reasoning" env:"SUMMER_AGENTS_DEFAULTS_SHOW_REASONING"`
+	Persona             string                    `json:"persona" env:"SUMMER_AGENTS_DEFAULTS_PERSONA"`
+	Personas            map[string]string         `json:"personas,omitempty"` // keyed by channel or "channel:chat_id"
+	Thinking            map[string]ThinkingConfig `json:"thinking,omitempty"` // keyed by model name; "*" matches only reasoning models, see ThinkingFor
+	ReasoningModels     []string                  `json:"reasoning_models,omitempty" env:"SUMMER_AGENTS_DEFAULTS_REASONING_MODELS"`
+	Sandbox             SandboxConfig             `json:"sandbox"`
+}
+
+// SandboxConfig isolates the exec tool. Mode is "off", "auto", "bwrap" or
+// "landlock"; when the sandbox cannot be set up, exec falls back to the
+// command guard.
+type SandboxConfig struct {
+	Mode         string `json:"mode" env:"SUMMER_AGENTS_DEFAULTS_SANDBOX_MODE"`
+	AllowNetwork bool   `json:"allow_network" env:"SUMMER_AGENTS_DEFAULTS_SANDBOX_ALLOW_NETWORK"`
+	MemoryMB     int    `json:"memory_mb" env:"SUMMER_AGENTS_DEFAULTS_SANDBOX_MEMORY_MB"`
+	CPUSeconds   int    `json:"cpu_seconds" env:"SUMMER_AGENTS_DEFAULTS_SANDBOX_CPU_SECONDS"`
+	MaxProcesses int    `json:"max_processes" env:"SUMMER_AGENTS_DEFAULTS_SANDBOX_MAX_PROCESSES"`
+}
+
+// ThinkingConfig enables extended thinking for a model. BudgetTokens applies
+// to Anthropic models, Effort ("minimal", "low", "medium", "high") to
+// OpenAI-style reasoning models.
+type ThinkingConfig struct {
+	BudgetTokens int    `json:"budget_tokens,omitempty"`
+	Effort       string `json:"effort,omitempty"`
+}
+
+// reasoningModelPrefixes are the model families known to accept thinking
+// settings. AgentDefaults.ReasoningModels adds to them.
+var reasoningModelPrefixes = []string{
+	"o1", "o3", "o4", "gpt-5", "gpt-oss",
+	"claude-3-7", "claude-sonnet-4", "claude-opus-4", "claude-haiku-4",
+	"deepseek-reasoner", "deepseek-r1", "qwq", "qwen3", "grok-3-mini", "grok-4", "gemini-2.5",
+}
+
+// isReasoningModel reports whether model, with any "provider/" prefix
+// removed, belongs to a family in reasoningModelPrefixes or extra.
+func isReasoningModel(model string, extra []string) bool {
+	model = strings.ToLower(model)
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	for _, prefixes := range [][]string{reasoningModelPrefixes, extra} {
+		for _, prefix := range prefixes {
+			prefix = strings.ToLower(strings.TrimSpace(prefix))
+			if prefix != "" && strings.HasPrefix(model, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ThinkingFor returns the thinking settings for model, falling back to the
+// "*" entry for reasoning models: those in a family listed in
+// reasoningModelPrefixes or ReasoningModels. Other models never get the "*"
+// entry, since some (e.g. llama on Groq) reject reasoning parameters; list a
+// new reasoning family in reasoning_models to enable it without a release.
+// ok is false when thinking is not configured for the model.
+func (d AgentDefaults) ThinkingFor(model string) (ThinkingConfig, bool) {
+	if tc, ok := d.Thinking[model]; ok {
+		return tc, true
+	}
+	if !isReasoningModel(model, d.ReasoningModels) {
+		return ThinkingConfig{}, false
+	}
+	tc, ok := d.Thinking["*"]
+	return tc, ok
 }
 
 type ChannelsConfig struct {
@@ -205,8 +274,72 @@ type WebToolsConfig struct {
 	DuckDuckGo DuckDuckGoConfig `json:"duckduckgo"`
 }
 
+// NetworkToolsConfig controls outbound HTTP from tools. Private, loopback
+// and link-local addresses are blocked unless listed in AllowHosts
+// (hostnames, "*.domain" wildcards, IPs or CIDR ranges).
+type NetworkToolsConfig struct {
+	AllowHosts       FlexibleStringSlice `json:"allow_hosts" env:"SUMMER_TOOLS_NETWORK_ALLOW_HOSTS"`
+	MaxResponseBytes int64               `json:"max_response_bytes" env:"SUMMER_TOOLS_NETWORK_MAX_RESPONSE_BYTES"`
+}
+
+// BrowserToolsConfig enables the CDP browser tool. It is only registered when
+// a Chromium-based browser is found at ChromePath or on PATH.
+type BrowserToolsConfig struct {
+	Enabled     bool   `json:"enabled" env:"SUMMER_TOOLS_BROWSER_ENABLED"`
+	ChromePath  string `json:"chrome_path,omitempty" env:"SUMMER_TOOLS_BROWSER_CHROME_PATH"`
+	IdleTimeout int    `json:"idle_timeout" env:"SUMMER_TOOLS_BROWSER_IDLE_TIMEOUT"` // seconds
+}
+
+// ExecToolsConfig configures the exec and shell tools. Patterns are regular
+// expressions matched against the lower-cased command: DenyPatterns are added
+// to the built-in ones, and when AllowPatterns is set a command must match
+// one of them. Environment variables are selected with shell-style globs such
+// as "*_API_KEY": with EnvPassthrough set, commands only see matching
+// variables (plus PATH and HOME); variables matching EnvRedact are never
+// passed and their values are masked in command output.
+type ExecToolsConfig struct {
+	Timeout        int                 `json:"timeout" env:"SUMMER_TOOLS_EXEC_TIMEOUT"` // seconds
+	AllowPatterns  FlexibleStringSlice `json:"allow_patterns" env:"SUMMER_TOOLS_EXEC_ALLOW_PATTERNS"`
+	DenyPatterns   FlexibleStringSlice `json:"deny_patterns" env:"SUMMER_TOOLS_EXEC_DENY_PATTERNS"`
+	EnvPassthrough FlexibleStringSlice `json:"env_passthrough" env:"SUMMER_TOOLS_EXEC_ENV_PASSTHROUGH"`
+	EnvRedact      FlexibleStringSlice `json:"env_redact" env:"SUMMER_TOOLS_EXEC_ENV_REDACT"`
+	MaxOutputChars int                 `json:"max_output_chars" env:"SUMMER_TOOLS_EXEC_MAX_OUTPUT_CHARS"`
+	WorkingDir     string              `json:"working_dir,omitempty" env:"SUMMER_TOOLS_EXEC_WORKING_DIR"` // relative to the workspace
+}
+
+// FilesystemToolsConfig lists directories the file and exec tools may use
+// besides the workspace when restrict_to_workspace is set. Paths may start
+// with "~"; symlinks are resolved before paths are compared.
+type FilesystemToolsConfig struct {
+	ReadOnlyPaths  FlexibleStringSlice `json:"read_only_paths" env:"SUMMER_TOOLS_FILESYSTEM_READ_ONLY_PATHS"`
+	ReadWritePaths FlexibleStringSlice `json:"read_write_paths" env:"SUMMER_TOOLS_FILESYSTEM_READ_WRITE_PATHS"`
+}
+
+// ResearchToolsConfig configures the Semantic Scholar, OpenAlex, PubMed and
+// Wikipedia tools. All APIs work without credentials: an API key raises
+// Semantic Scholar's or NCBI's rate limit and an email address puts OpenAlex
+// requests in its faster "polite pool". WikipediaLanguage is the default
+// Wikipedia edition (e.g. "en", "de"). MaxDepth caps citation graph
+// expansion. VerifyCitations sets what happens to references in agent
+// answers that cannot be found in Crossref or arXiv: "flag" marks them,
+// "remove" drops them from reference lists and "off", the default, skips
+// the check.
+type ResearchToolsConfig struct {
+	SemanticScholarAPIKey string `json:"semantic_scholar_api_key" env:"SUMMER_TOOLS_RESEARCH_SEMANTIC_SCHOLAR_API_KEY"`
+	OpenAlexEmail         string `json:"openalex_email" env:"SUMMER_TOOLS_RESEARCH_OPENALEX_EMAIL"`
+	NCBIAPIKey            string `json:"ncbi_api_key" env:"SUMMER_TOOLS_RESEARCH_NCBI_API_KEY"`
+	WikipediaLanguage     string `json:"wikipedia_language" env:"SUMMER_TOOLS_RESEARCH_WIKIPEDIA_LANGUAGE"`
+	MaxDepth              int    `json:"max_depth" env:"SUMMER_TOOLS_RESEARCH_MAX_DEPTH"`
+	VerifyCitations       string `json:"verify_citations" env:"SUMMER_TOOLS_RESEARCH_VERIFY_CITATIONS"`
+}
+
 type ToolsConfig struct {
-	Web WebToolsConfig `json:"web"`
+	Web        WebToolsConfig        `json:"web"`
+	Network    NetworkToolsConfig    `json:"network"`
+	Browser    BrowserToolsConfig    `json:"browser"`
+	Exec       ExecToolsConfig       `json:"exec"`
+	Filesystem FilesystemToolsConfig `json:"filesystem"`
+	Research   ResearchToolsConfig   `json:"research"`
 }
 
 func DefaultConfig() *Config {
@@ -220,6 +353,13 @@ func DefaultConfig() *Config {
 				MaxTokens:           8192,
 				Temperature:         0.7,
 				MaxToolIterations:   20,
+				Persona:             "default",
+				Sandbox: SandboxConfig{
+					Mode:         "off",
+					MemoryMB:     4096,
+					CPUSeconds:   120,
+					MaxProcesses: 256,
+				},
 			},
 		},
 		Channels: ChannelsConfig{
@@ -313,6 +453,31 @@ func DefaultConfig() *Config {
 					MaxResults: 5,
 				},
 			},
+			Network: NetworkToolsConfig{
+				AllowHosts:       FlexibleStringSlice{},
+				MaxResponseBytes: 10 << 20,
+			},
+			Browser: BrowserToolsConfig{
+				Enabled:     true,
+				IdleTimeout: 600,
+			},
+			Exec: ExecToolsConfig{
+				Timeout:        60,
+				AllowPatterns:  FlexibleStringSlice{},
+				DenyPatterns:   FlexibleStringSlice{},
+				EnvPassthrough: FlexibleStringSlice{},
+				EnvRedact:      FlexibleStringSlice{"*_API_KEY", "*SECRET*", "*TOKEN*", "*PASSWORD*"},
+				MaxOutputChars: 10000,
+			},
+			Filesystem: FilesystemToolsConfig{
+				ReadOnlyPaths:  FlexibleStringSlice{},
+				ReadWritePaths: FlexibleStringSlice{},
+			},
+			Research: ResearchToolsConfig{
+				WikipediaLanguage: "en",
+				MaxDepth:          2,
+				VerifyCitations:   "off",
+			},
 		},
 		Heartbeat: HeartbeatConfig{
 			Enabled:  true,
@@ -153,6 +153,24 @@ func TestDefaultConfig_WebTools(t *testing.T) {
 	}
 }
 
+// TestDefaultConfig_ExecTools verifies exec tool defaults
+func TestDefaultConfig_ExecTools(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.Tools.Exec.Timeout != 60 {
+		t.Error("Expected exec timeout 60, got ", cfg.Tools.Exec.Timeout)
+	}
+	if cfg.Tools.Exec.MaxOutputChars != 10000 {
+		t.Error("Expected exec max output 10000, got ", cfg.Tools.Exec.MaxOutputChars)
+	}
+	if len(cfg.Tools.Exec.EnvPassthrough) != 0 {
+		t.Error("Expected all environment variables to pass through by default")
+	}
+	if len(cfg.Tools.Exec.EnvRedact) == 0 {
+		t.Error("Expected secrets to be redacted by default")
+	}
+}
+
 // TestConfig_Complete verifies all config fields are set
 func TestConfig_Complete(t *testing.T) {
 	cfg := DefaultConfig()
@@ -183,3 +201,38 @@ func TestConfig_Complete(t *testing.T) {
 		t.Error("Heartbeat should be enabled by default")
 	}
 }
+
+func TestAgentDefaults_ThinkingFor(t *testing.T) {
+	d := AgentDefaults{
+		Thinking: map[string]ThinkingConfig{
+			"claude-sonnet-4-5": {BudgetTokens: 4096},
+			"*":                 {Effort: "low"},
+		},
+	}
+	if tc, ok := d.ThinkingFor("claude-sonnet-4-5"); !ok || tc.BudgetTokens != 4096 {
+		t.Errorf("ThinkingFor(claude-sonnet-4-5) = %+v, %v", tc, ok)
+	}
+	if tc, ok := d.ThinkingFor("gpt-5.2"); !ok || tc.Effort != "low" {
+		t.Errorf("ThinkingFor(gpt-5.2) = %+v, %v, want wildcard entry", tc, ok)
+	}
+	if tc, ok := d.ThinkingFor("openai/o4-mini"); !ok || tc.Effort != "low" {
+		t.Errorf("ThinkingFor(openai/o4-mini) = %+v, %v, want wildcard entry", tc, ok)
+	}
+	for _, model := range []string{"llama-3.3-70b-versatile", "groq/llama-3.1-8b-instant", "gpt-4o-mini"} {
+		if tc, ok := d.ThinkingFor(model); ok {
+			t.Errorf("ThinkingFor(%s) = %+v, the wildcard should not apply to non-reasoning models", model, tc)
+		}
+	}
+	if _, ok := (AgentDefaults{}).ThinkingFor("gpt-5.2"); ok {
+		t.Error("ThinkingFor should report false when thinking is not configured")
+	}
+
+	// A family not yet known is enabled through reasoning_models.
+	if _, ok := d.ThinkingFor("kimi-k3-thinking"); ok {
+		t.Error("ThinkingFor(kimi-k3-thinking) should not match before it is listed")
+	}
+	d.ReasoningModels = []string{" Kimi-K3 "}
+	if tc, ok := d.ThinkingFor("moonshot/kimi-k3-thinking"); !ok || tc.Effort != "low" {
+		t.Errorf("ThinkingFor(moonshot/kimi-k3-thinking) = %+v, %v, want wildcard entry", tc, ok)
+	}
+}
@@ -0,0 +1,235 @@
+package library
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Export formats.
+const (
+	FormatBibTeX  = "bibtex"
+	FormatCSLJSON = "csl-json"
+	FormatRIS     = "ris"
+)
+
+// Formats lists the supported export formats.
+var Formats = []string{FormatBibTeX, FormatCSLJSON, FormatRIS}
+
+// Export renders entries in the given format.
+func Export(entries []*Entry, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case FormatBibTeX, "bib":
+		return exportBibTeX(entries), nil
+	case FormatCSLJSON, "csl", "json":
+		return exportCSL(entries)
+	case FormatRIS:
+		return exportRIS(entries), nil
+	default:
+		return "", fmt.Errorf("unknown export format %q (supported: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// venueField names the BibTeX field that holds an entry's venue.
+var venueField = map[string]string{
+	TypeArticle:       "journal",
+	TypeInProceedings: "booktitle",
+	TypeInCollection:  "booktitle",
+	TypePhDThesis:     "school",
+	TypeTechReport:    "institution",
+	TypePreprint:      "howpublished",
+}
+
+var bibtexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+)
+
+func exportBibTeX(entries []*Entry) string {
+	var sb strings.Builder
+	for i, e := range entries {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "@%s{%s,\n", e.Type, e.Key)
+		field := func(name, value string) {
+			if value != "" {
+				fmt.Fprintf(&sb, "  %s = {%s},\n", name, value)
+			}
+		}
+		// Double braces keep the title's capitalization.
+		field("title", "{"+bibtexEscaper.Replace(e.Title)+"}")
+		authors := make([]string, len(e.Authors))
+		for i, a := range e.Authors {
+			authors[i] = a.Family
+			if a.Given != "" {
+				authors[i] += ", " + a.Given
+			}
+			authors[i] = bibtexEscaper.Replace(authors[i])
+		}
+		field("author", strings.Join(authors, " and "))
+		if name := venueField[e.Type]; name != "" {
+			field(name, bibtexEscaper.Replace(e.Venue))
+		}
+		if e.Year > 0 {
+			field("year", strconv.Itoa(e.Year))
+		}
+		field("volume", e.Volume)
+		field("number", e.Issue)
+		field("pages", strings.Replace(e.Pages, "-", "--", 1))
+		field("publisher", bibtexEscaper.Replace(e.Publisher))
+		field("doi", e.DOI)
+		if e.ArXivID != "" {
+			field("eprint", e.ArXivID)
+			field("archivePrefix", "arXiv")
+		}
+		field("url", e.URL)
+		field("keywords", strings.Join(e.Tags, ", "))
+		sb.WriteString("}\n")
+	}
+	return sb.String()
+}
+
+// cslTypes maps entry types to CSL item types.
+var cslTypes = map[string]string{
+	TypeArticle:       "article-journal",
+	TypeInProceedings: "paper-conference",
+	TypeBook:          "book",
+	TypeInCollection:  "chapter",
+	TypePhDThesis:     "thesis",
+	TypeTechReport:    "report",
+	TypePreprint:      "article",
+}
+
+type cslName struct {
+	Family string `json:"family"`
+	Given  string `json:"given,omitempty"`
+}
+
+type cslDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+type cslItem struct {
+	ID             string    `json:"id"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title"`
+	Author         []cslName `json:"author,omitempty"`
+	Issued         *cslDate  `json:"issued,omitempty"`
+	ContainerTitle string    `json:"container-title,omitempty"`
+	Volume         string    `json:"volume,omitempty"`
+	Issue          string    `json:"issue,omitempty"`
+	Page           string    `json:"page,omitempty"`
+	Publisher      string    `json:"publisher,omitempty"`
+	DOI            string    `json:"DOI,omitempty"`
+	URL            string    `json:"URL,omitempty"`
+	Abstract       string    `json:"abstract,omitempty"`
+	Number         string    `json:"number,omitempty"`
+	Keyword        string    `json:"keyword,omitempty"`
+}
+
+func exportCSL(entries []*Entry) (string, error) {
+	items := make([]cslItem, 0, len(entries))
+	for _, e := range entries {
+		item := cslItem{
+			ID:             e.Key,
+			Type:           cslTypes[e.Type],
+			Title:          e.Title,
+			ContainerTitle: e.Venue,
+			Volume:         e.Volume,
+			Issue:          e.Issue,
+			Page:           e.Pages,
+			Publisher:      e.Publisher,
+			DOI:            e.DOI,
+			URL:            e.URL,
+			Abstract:       e.Abstract,
+			Keyword:        strings.Join(e.Tags, ", "),
+		}
+		if item.Type == "" {
+			item.Type = "article"
+		}
+		if e.ArXivID != "" {
+			item.Number = "arXiv:" + e.ArXivID
+		}
+		for _, a := range e.Authors {
+			item.Author = append(item.Author, cslName{Family: a.Family, Given: a.Given})
+		}
+		if e.Year > 0 {
+			parts := []int{e.Year}
+			if e.Month > 0 {
+				parts = append(parts, e.Month)
+			}
+			item.Issued = &cslDate{DateParts: [][]int{parts}}
+		}
+		items = append(items, item)
+	}
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode CSL-JSON: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// risTypes maps entry types to RIS reference types.
+var risTypes = map[string]string{
+	TypeArticle:       "JOUR",
+	TypeInProceedings: "CONF",
+	TypeBook:          "BOOK",
+	TypeInCollection:  "CHAP",
+	TypePhDThesis:     "THES",
+	TypeTechReport:    "RPRT",
+	TypePreprint:      "UNPB",
+}
+
+func exportRIS(entries []*Entry) string {
+	var sb strings.Builder
+	for _, e := range entries {
+		tag := func(name, value string) {
+			if value != "" {
+				fmt.Fprintf(&sb, "%s  - %s\n", name, value)
+			}
+		}
+		ty := risTypes[e.Type]
+		if ty == "" {
+			ty = "GEN"
+		}
+		tag("TY", ty)
+		tag("ID", e.Key)
+		tag("TI", e.Title)
+		for _, a := range e.Authors {
+			name := a.Family
+			if a.Given != "" {
+				name += ", " + a.Given
+			}
+			tag("AU", name)
+		}
+		if e.Year > 0 {
+			tag("PY", strconv.Itoa(e.Year))
+		}
+		tag("T2", e.Venue)
+		tag("VL", e.Volume)
+		tag("IS", e.Issue)
+		if start, end, ok := strings.Cut(e.Pages, "-"); ok {
+			tag("SP", start)
+			tag("EP", end)
+		} else {
+			tag("SP", e.Pages)
+		}
+		tag("PB", e.Publisher)
+		tag("DO", e.DOI)
+		tag("UR", e.URL)
+		tag("AB", e.Abstract)
+		for _, t := range e.Tags {
+			tag("KW", t)
+		}
+		sb.WriteString("ER  - \n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,110 @@
+package library
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func exportFixtures() []*Entry {
+	return []*Entry{
+		{
+			Key:       "lecun2015deep",
+			Type:      TypeArticle,
+			Title:     "Deep learning",
+			Authors:   []Author{{Given: "Yann", Family: "LeCun"}, {Given: "Yoshua", Family: "Bengio"}},
+			Year:      2015,
+			Month:     5,
+			Venue:     "Nature",
+			Volume:    "521",
+			Issue:     "7553",
+			Pages:     "436-444",
+			Publisher: "Springer",
+			DOI:       "10.1038/nature14539",
+			Tags:      []string{"survey"},
+		},
+		{
+			Key:     "vaswani2017attention",
+			Type:    TypePreprint,
+			Title:   "Attention Is All You Need: 100% & {more}",
+			Authors: []Author{{Given: "Ashish", Family: "Vaswani"}},
+			Year:    2017,
+			ArXivID: "1706.03762",
+			URL:     "https://arxiv.org/abs/1706.03762",
+		},
+	}
+}
+
+func TestExport_BibTeX(t *testing.T) {
+	out, err := Export(exportFixtures(), "bibtex")
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	for _, want := range []string{
+		"@article{lecun2015deep,\n",
+		"  title = {{Deep learning}},\n",
+		"  author = {LeCun, Yann and Bengio, Yoshua},\n",
+		"  journal = {Nature},\n",
+		"  pages = {436--444},\n",
+		"  number = {7553},\n",
+		"  keywords = {survey},\n",
+		"@misc{vaswani2017attention,\n",
+		`  title = {{Attention Is All You Need: 100\% \& \{more\}}},`,
+		"  eprint = {1706.03762},\n  archivePrefix = {arXiv},\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("BibTeX output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestExport_CSLJSON(t *testing.T) {
+	out, err := Export(exportFixtures(), "csl-json")
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	var items []map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &items); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("got %d items", len(items))
+	}
+	if items[0]["id"] != "lecun2015deep" || items[0]["type"] != "article-journal" || items[0]["container-title"] != "Nature" {
+		t.Errorf("unexpected first item: %v", items[0])
+	}
+	issued, _ := json.Marshal(items[0]["issued"])
+	if string(issued) != `{"date-parts":[[2015,5]]}` {
+		t.Errorf("issued = %s", issued)
+	}
+	if items[1]["type"] != "article" || items[1]["number"] != "arXiv:1706.03762" {
+		t.Errorf("unexpected preprint item: %v", items[1])
+	}
+}
+
+func TestExport_RIS(t *testing.T) {
+	out, err := Export(exportFixtures(), "ris")
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	for _, want := range []string{
+		"TY  - JOUR\nID  - lecun2015deep\n",
+		"AU  - LeCun, Yann\nAU  - Bengio, Yoshua\n",
+		"SP  - 436\nEP  - 444\n",
+		"KW  - survey\nER  - \n",
+		"TY  - UNPB\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("RIS output missing %q:\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "ER  - "); n != 2 {
+		t.Errorf("got %d records", n)
+	}
+}
+
+func TestExport_UnknownFormat(t *testing.T) {
+	if _, err := Export(exportFixtures(), "endnote"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,566 @@
+// Package library keeps a persistent collection of papers in the workspace,
+// with tags, notes and downloaded PDFs, and exports it as BibTeX, CSL-JSON
+// or RIS.
+package library
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Entry types, named after their BibTeX counterparts.
+const (
+	TypeArticle       = "article"
+	TypeInProceedings = "inproceedings"
+	TypeBook          = "book"
+	TypeInCollection  = "incollection"
+	TypePhDThesis     = "phdthesis"
+	TypeTechReport    = "techreport"
+	TypePreprint      = "misc"
+)
+
+// Author is a person's name split for citation formatting.
+type Author struct {
+	Given  string `json:"given,omitempty"`
+	Family string `json:"family"`
+}
+
+// Note is a dated remark attached to an entry.
+type Note struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
+// Entry is one paper in the library.
+type Entry struct {
+	Key       string    `json:"key"` // citation key, e.g. "vaswani2017attention"
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Authors   []Author  `json:"authors,omitempty"`
+	Year      int       `json:"year,omitempty"`
+	Month     int       `json:"month,omitempty"`
+	Venue     string    `json:"venue,omitempty"` // journal, proceedings or book title
+	Volume    string    `json:"volume,omitempty"`
+	Issue     string    `json:"issue,omitempty"`
+	Pages     string    `json:"pages,omitempty"`
+	Publisher string    `json:"publisher,omitempty"`
+	DOI       string    `json:"doi,omitempty"`
+	ArXivID   string    `json:"arxiv_id,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	Abstract  string    `json:"abstract,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	Notes     []Note    `json:"notes,omitempty"`
+	PDF       string    `json:"pdf,omitempty"` // relative to the library directory
+	Added     time.Time `json:"added"`
+
+	pdfURL string // download link found while resolving metadata
+}
+
+// AuthorNames returns the authors as "Given Family".
+func (e *Entry) AuthorNames() []string {
+	names := make([]string, len(e.Authors))
+	for i, a := range e.Authors {
+		names[i] = strings.TrimSpace(a.Given + " " + a.Family)
+	}
+	return names
+}
+
+// Library is a paper collection stored as library.json in its directory.
+// Every operation re-reads the file, so several Library values (the agent's
+// and its subagents') can share a directory.
+type Library struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// New returns the library in dir. Nothing is created until the first change.
+func New(dir string) *Library {
+	return &Library{dir: dir}
+}
+
+// Dir returns the library directory.
+func (l *Library) Dir() string {
+	return l.dir
+}
+
+func (l *Library) indexPath() string {
+	return filepath.Join(l.dir, "library.json")
+}
+
+type libraryFile struct {
+	Entries []*Entry `json:"entries"`
+}
+
+func (l *Library) load() ([]*Entry, error) {
+	data, err := os.ReadFile(l.indexPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read library: %w", err)
+	}
+	var f libraryFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", l.indexPath(), err)
+	}
+	return f.Entries, nil
+}
+
+// save writes the index atomically via a temp file and rename.
+func (l *Library) save(entries []*Entry) error {
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create library directory: %w", err)
+	}
+	data, err := json.MarshalIndent(libraryFile{Entries: entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal library: %w", err)
+	}
+	tmp := l.indexPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write library: %w", err)
+	}
+	if err := os.Rename(tmp, l.indexPath()); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write library: %w", err)
+	}
+	return nil
+}
+
+// update loads the entries, applies fn and saves the result.
+func (l *Library) update(fn func(entries []*Entry) ([]*Entry, error)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries, err := l.load()
+	if err != nil {
+		return err
+	}
+	entries, err = fn(entries)
+	if err != nil {
+		return err
+	}
+	return l.save(entries)
+}
+
+// Entries returns all entries in the order they were added.
+func (l *Library) Entries() ([]*Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.load()
+}
+
+// Add stores e, or merges it into an existing entry with the same DOI,
+// arXiv ID or title. It returns the stored entry and whether it was new.
+func (l *Library) Add(e *Entry) (*Entry, bool, error) {
+	Normalize(e)
+	if e.Title == "" {
+		return nil, false, fmt.Errorf("entry has no title")
+	}
+	var stored *Entry
+	added := false
+	err := l.update(func(entries []*Entry) ([]*Entry, error) {
+		if existing := findDuplicate(entries, e); existing != nil {
+			mergeEntry(existing, e)
+			stored = existing
+			return entries, nil
+		}
+		if e.Key == "" || findKey(entries, e.Key) != nil {
+			e.Key = uniqueKey(entries, CitationKey(e))
+		}
+		if e.Added.IsZero() {
+			e.Added = time.Now()
+		}
+		stored, added = e, true
+		return append(entries, e), nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return stored, added, nil
+}
+
+// Find returns the entry with the given citation key, DOI or arXiv ID.
+func (l *Library) Find(ref string) (*Entry, error) {
+	entries, err := l.Entries()
+	if err != nil {
+		return nil, err
+	}
+	if e := findRef(entries, ref); e != nil {
+		return e, nil
+	}
+	return nil, fmt.Errorf("no entry %q in the library", ref)
+}
+
+func findRef(entries []*Entry, ref string) *Entry {
+	ref = strings.TrimSpace(ref)
+	if e := findKey(entries, ref); e != nil {
+		return e
+	}
+	doi := NormalizeDOI(ref)
+	arxiv := NormalizeArXivID(ref)
+	for _, e := range entries {
+		if (e.DOI != "" && e.DOI == doi) || (e.ArXivID != "" && e.ArXivID == arxiv) {
+			return e
+		}
+	}
+	return nil
+}
+
+// Modify applies fn to the entry identified by ref and saves the library.
+func (l *Library) Modify(ref string, fn func(e *Entry) error) (*Entry, error) {
+	var found *Entry
+	err := l.update(func(entries []*Entry) ([]*Entry, error) {
+		found = findRef(entries, ref)
+		if found == nil {
+			return nil, fmt.Errorf("no entry %q in the library", ref)
+		}
+		return entries, fn(found)
+	})
+	return found, err
+}
+
+// Tag adds and removes tags on an entry. Tags are lower-cased.
+func (l *Library) Tag(ref string, add, remove []string) (*Entry, error) {
+	return l.Modify(ref, func(e *Entry) error {
+		drop := make(map[string]bool)
+		for _, t := range remove {
+			drop[normalizeTag(t)] = true
+		}
+		var tags []string
+		for _, t := range e.Tags {
+			if !drop[t] {
+				tags = append(tags, t)
+			}
+		}
+		e.Tags = mergeTags(tags, add)
+		return nil
+	})
+}
+
+// AddNote appends a note to an entry.
+func (l *Library) AddNote(ref, text string) (*Entry, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("note is empty")
+	}
+	return l.Modify(ref, func(e *Entry) error {
+		e.Notes = append(e.Notes, Note{Time: time.Now(), Text: text})
+		return nil
+	})
+}
+
+// Search returns entries whose title, authors, venue, abstract, notes,
+// identifiers or key contain every word of query and that carry all of the
+// given tags. An empty query matches everything.
+func (l *Library) Search(query string, tags []string) ([]*Entry, error) {
+	entries, err := l.Entries()
+	if err != nil {
+		return nil, err
+	}
+	words := strings.Fields(foldText(query))
+	var out []*Entry
+	for _, e := range entries {
+		if !hasTags(e, tags) {
+			continue
+		}
+		text := searchText(e)
+		match := true
+		for _, w := range words {
+			if !strings.Contains(text, w) {
+				match = false
+				break
+			}
+		}
+		if match {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func searchText(e *Entry) string {
+	parts := []string{e.Key, e.Title, e.Venue, e.Abstract, e.DOI, e.ArXivID, fmt.Sprint(e.Year)}
+	parts = append(parts, e.AuthorNames()...)
+	parts = append(parts, e.Tags...)
+	for _, n := range e.Notes {
+		parts = append(parts, n.Text)
+	}
+	return foldText(strings.Join(parts, " "))
+}
+
+func hasTags(e *Entry, tags []string) bool {
+	for _, t := range tags {
+		t = normalizeTag(t)
+		if t == "" {
+			continue
+		}
+		found := false
+		for _, have := range e.Tags {
+			found = found || have == t
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func findKey(entries []*Entry, key string) *Entry {
+	for _, e := range entries {
+		if e.Key == key {
+			return e
+		}
+	}
+	return nil
+}
+
+// findDuplicate finds an entry for the same paper: same DOI or arXiv ID,
+// or the same title and year.
+func findDuplicate(entries []*Entry, e *Entry) *Entry {
+	title := foldTitle(e.Title)
+	for _, have := range entries {
+		if (e.DOI != "" && have.DOI == e.DOI) || (e.ArXivID != "" && have.ArXivID == e.ArXivID) {
+			return have
+		}
+	}
+	for _, have := range entries {
+		// A preprint and its published version may be a year apart.
+		if foldTitle(have.Title) == title && (have.Year == 0 || e.Year == 0 || abs(have.Year-e.Year) <= 1) {
+			return have
+		}
+	}
+	return nil
+}
+
+// mergeEntry fills in fields dst lacks from src and unions tags and notes.
+// A published version's venue and type replace those of a preprint.
+func mergeEntry(dst, src *Entry) {
+	if dst.Type == TypePreprint && src.Type != "" && src.Type != TypePreprint {
+		dst.Type = src.Type
+		dst.Venue = src.Venue
+	}
+	fill := func(d *string, s string) {
+		if *d == "" {
+			*d = s
+		}
+	}
+	fill(&dst.Type, src.Type)
+	fill(&dst.Venue, src.Venue)
+	fill(&dst.Volume, src.Volume)
+	fill(&dst.Issue, src.Issue)
+	fill(&dst.Pages, src.Pages)
+	fill(&dst.Publisher, src.Publisher)
+	fill(&dst.DOI, src.DOI)
+	fill(&dst.ArXivID, src.ArXivID)
+	fill(&dst.URL, src.URL)
+	fill(&dst.Abstract, src.Abstract)
+	fill(&dst.PDF, src.PDF)
+	if len(dst.Authors) == 0 {
+		dst.Authors = src.Authors
+	}
+	if dst.Year == 0 {
+		dst.Year, dst.Month = src.Year, src.Month
+	}
+	dst.Tags = mergeTags(dst.Tags, src.Tags)
+	dst.Notes = append(dst.Notes, src.Notes...)
+}
+
+func mergeTags(tags, add []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, t := range append(append([]string{}, tags...), add...) {
+		t = normalizeTag(t)
+		if t != "" && !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func normalizeTag(t string) string {
+	return strings.Join(strings.Fields(strings.ToLower(t)), "-")
+}
+
+// Normalize cleans up an entry's fields: whitespace in text, DOI and arXiv
+// ID forms, a default type and tag spelling.
+func Normalize(e *Entry) {
+	e.Title = strings.TrimSuffix(collapseSpace(e.Title), ".")
+	e.Venue = collapseSpace(e.Venue)
+	e.Abstract = collapseSpace(e.Abstract)
+	e.Publisher = collapseSpace(e.Publisher)
+	e.Pages = strings.ReplaceAll(strings.ReplaceAll(collapseSpace(e.Pages), "--", "-"), "–", "-")
+	e.DOI = NormalizeDOI(e.DOI)
+	e.ArXivID = NormalizeArXivID(e.ArXivID)
+	for i := range e.Authors {
+		e.Authors[i].Given = collapseSpace(e.Authors[i].Given)
+		e.Authors[i].Family = collapseSpace(e.Authors[i].Family)
+	}
+	if e.Type == "" {
+		e.Type = TypeArticle
+		if e.Venue == "" && e.ArXivID != "" {
+			e.Type = TypePreprint
+		}
+	}
+	e.Tags = mergeTags(nil, e.Tags)
+}
+
+var (
+	doiRe   = regexp.MustCompile(`(?i)10\.\d{4,9}/\S+`)
+	arxivRe = regexp.MustCompile(`(\d{4}\.\d{4,5}|[a-z][a-z.-]*/\d{7})(?:v\d+)?`)
+)
+
+// NormalizeDOI extracts a bare, lower-cased DOI from s (which may be a
+// doi.org URL or carry a "doi:" prefix). It returns "" if there is none, and
+// for arXiv's own DOIs, which are represented by the arXiv ID instead.
+func NormalizeDOI(s string) string {
+	m := doiRe.FindString(strings.TrimSpace(s))
+	doi := strings.ToLower(strings.TrimRight(m, ".,;"))
+	if strings.HasPrefix(doi, "10.48550/arxiv.") {
+		return ""
+	}
+	return doi
+}
+
+// NormalizeArXivID extracts an arXiv ID without version from s, which may be
+// a bare ID, an "arXiv:" reference, an abs/pdf URL or an arXiv DOI.
+func NormalizeArXivID(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if i := strings.Index(s, "10.48550/arxiv."); i >= 0 {
+		s = s[i+len("10.48550/arxiv."):]
+	} else if doiRe.MatchString(s) {
+		return ""
+	}
+	m := arxivRe.FindStringSubmatch(s)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// SplitName splits "Given Family" or "Family, Given" into an Author.
+// Lower-case particles such as "van der" stay with the family name.
+func SplitName(name string) Author {
+	name = collapseSpace(name)
+	if family, given, ok := strings.Cut(name, ","); ok {
+		return Author{Given: strings.TrimSpace(given), Family: strings.TrimSpace(family)}
+	}
+	parts := strings.Fields(name)
+	if len(parts) <= 1 {
+		return Author{Family: name}
+	}
+	i := len(parts) - 1
+	for i > 1 && isParticle(parts[i-1]) {
+		i--
+	}
+	return Author{Given: strings.Join(parts[:i], " "), Family: strings.Join(parts[i:], " ")}
+}
+
+func isParticle(s string) bool {
+	switch s {
+	case "van", "von", "der", "den", "de", "del", "della", "di", "da", "du", "la", "le", "dos", "das", "ter", "ten":
+		return true
+	}
+	return false
+}
+
+var keyStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "on": true, "of": true, "for": true, "in": true,
+	"to": true, "and": true, "with": true, "towards": true, "via": true, "is": true, "are": true,
+}
+
+// CitationKey builds a key from the first author's family name, the year and
+// the first significant word of the title, e.g. "vaswani2017attention".
+func CitationKey(e *Entry) string {
+	family := "anon"
+	if len(e.Authors) > 0 {
+		if f := asciiWord(e.Authors[0].Family); f != "" {
+			family = f
+		}
+	}
+	word := ""
+	for _, w := range strings.Fields(e.Title) {
+		if w = asciiWord(w); w != "" && !keyStopWords[w] {
+			word = w
+			break
+		}
+	}
+	year := ""
+	if e.Year > 0 {
+		year = fmt.Sprint(e.Year)
+	}
+	return family + year + word
+}
+
+// uniqueKey appends a, b, c... to key until no entry uses it.
+func uniqueKey(entries []*Entry, key string) string {
+	if findKey(entries, key) == nil {
+		return key
+	}
+	for i := 0; ; i++ {
+		suffix := ""
+		for n := i; ; n = n/26 - 1 {
+			suffix = string(rune('a'+n%26)) + suffix
+			if n < 26 {
+				break
+			}
+		}
+		if findKey(entries, key+suffix) == nil {
+			return key + suffix
+		}
+	}
+}
+
+// asciiWord lower-cases s, strips accents and drops anything that is not a
+// letter or digit.
+func asciiWord(s string) string {
+	var sb strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// foldText lower-cases s and strips accents for matching.
+func foldText(s string) string {
+	var sb strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		if !unicode.Is(unicode.Mn, r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func foldTitle(s string) string {
+	var sb strings.Builder
+	for _, r := range foldText(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
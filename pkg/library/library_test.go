@@ -0,0 +1,157 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeIdentifiers(t *testing.T) {
+	dois := map[string]string{
+		"10.1038/NATURE14539":                     "10.1038/nature14539",
+		"https://doi.org/10.1145/3292500.3330701": "10.1145/3292500.3330701",
+		"doi:10.1000/xyz123.":                     "10.1000/xyz123",
+		"10.48550/arXiv.1706.03762":               "",
+		"1706.03762":                              "",
+	}
+	for in, want := range dois {
+		if got := NormalizeDOI(in); got != want {
+			t.Errorf("NormalizeDOI(%q) = %q, want %q", in, got, want)
+		}
+	}
+	arxiv := map[string]string{
+		"1706.03762":                         "1706.03762",
+		"arXiv:1706.03762v7":                 "1706.03762",
+		"https://arxiv.org/abs/2301.00001":   "2301.00001",
+		"https://arxiv.org/pdf/1706.03762v2": "1706.03762",
+		"hep-th/9901001":                     "hep-th/9901001",
+		"10.48550/arXiv.1706.03762":          "1706.03762",
+		"10.1038/nature14539":                "",
+		"not an id":                          "",
+	}
+	for in, want := range arxiv {
+		if got := NormalizeArXivID(in); got != want {
+			t.Errorf("NormalizeArXivID(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSplitNameAndCitationKey(t *testing.T) {
+	names := map[string]Author{
+		"Ashish Vaswani":           {Given: "Ashish", Family: "Vaswani"},
+		"LeCun, Yann":              {Given: "Yann", Family: "LeCun"},
+		"Ludwig van der Maaten":    {Given: "Ludwig", Family: "van der Maaten"},
+		"Plato":                    {Family: "Plato"},
+		"  Geoffrey   E.  Hinton ": {Given: "Geoffrey E.", Family: "Hinton"},
+	}
+	for in, want := range names {
+		if got := SplitName(in); got != want {
+			t.Errorf("SplitName(%q) = %+v, want %+v", in, got, want)
+		}
+	}
+
+	e := &Entry{Title: "The Élan of Attention", Year: 2017, Authors: []Author{{Given: "Łukasz", Family: "Müller-Kaiser"}}}
+	if got := CitationKey(e); got != "mullerkaiser2017elan" {
+		t.Errorf("CitationKey = %q", got)
+	}
+	if got := CitationKey(&Entry{Title: "On the Origin"}); got != "anonorigin" {
+		t.Errorf("CitationKey without authors or year = %q", got)
+	}
+}
+
+func TestLibrary_AddMergesDuplicates(t *testing.T) {
+	lib := New(filepath.Join(t.TempDir(), "library"))
+
+	preprint := &Entry{
+		Title:   "Attention Is All You Need",
+		Authors: []Author{{Given: "Ashish", Family: "Vaswani"}},
+		Year:    2017,
+		ArXivID: "arXiv:1706.03762v5",
+		Tags:    []string{"Transformers"},
+	}
+	stored, added, err := lib.Add(preprint)
+	if err != nil || !added {
+		t.Fatalf("Add = %v, %v", added, err)
+	}
+	if stored.Key != "vaswani2017attention" || stored.Type != TypePreprint || stored.ArXivID != "1706.03762" {
+		t.Errorf("unexpected stored entry: %+v", stored)
+	}
+
+	// The published version, matched by title, upgrades the preprint.
+	published := &Entry{
+		Title:   "Attention is all you need.",
+		Type:    TypeInProceedings,
+		Venue:   "NeurIPS",
+		Year:    2017,
+		DOI:     "10.5555/3295222.3295349",
+		Tags:    []string{"nlp", "transformers"},
+		Authors: []Author{{Given: "A.", Family: "Vaswani"}},
+	}
+	stored, added, err = lib.Add(published)
+	if err != nil || added {
+		t.Fatalf("Add duplicate = %v, %v", added, err)
+	}
+	if stored.Type != TypeInProceedings || stored.Venue != "NeurIPS" || stored.DOI == "" || stored.Authors[0].Given != "Ashish" {
+		t.Errorf("published version not merged: %+v", stored)
+	}
+	if len(stored.Tags) != 2 || stored.Tags[0] != "nlp" || stored.Tags[1] != "transformers" {
+		t.Errorf("tags = %v", stored.Tags)
+	}
+
+	// A different paper with the same key base gets a suffix.
+	other, added, err := lib.Add(&Entry{Title: "Attention for speech", Year: 2017, Authors: []Author{{Family: "Vaswani"}}})
+	if err != nil || !added || other.Key != "vaswani2017attentiona" {
+		t.Errorf("second paper: key %q added %v err %v", other.Key, added, err)
+	}
+
+	// Everything survives a reload from disk.
+	entries, err := New(lib.Dir()).Entries()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("reload: %d entries, %v", len(entries), err)
+	}
+	if _, err := os.Stat(filepath.Join(lib.Dir(), "library.json.tmp")); !os.IsNotExist(err) {
+		t.Error("temp file left behind")
+	}
+}
+
+func TestLibrary_TagNoteSearch(t *testing.T) {
+	lib := New(filepath.Join(t.TempDir(), "library"))
+	lib.Add(&Entry{Title: "Deep learning", DOI: "10.1038/nature14539", Year: 2015,
+		Authors: []Author{{Given: "Yann", Family: "LeCun"}}, Tags: []string{"survey"}})
+	lib.Add(&Entry{Title: "Attention Is All You Need", ArXivID: "1706.03762", Year: 2017,
+		Authors: []Author{{Given: "Klaus", Family: "Müller"}}})
+
+	e, err := lib.Tag("10.1038/NATURE14539", []string{"Reading List", "classic"}, []string{"survey"})
+	if err != nil {
+		t.Fatalf("Tag: %v", err)
+	}
+	if len(e.Tags) != 2 || e.Tags[0] != "classic" || e.Tags[1] != "reading-list" {
+		t.Errorf("tags = %v", e.Tags)
+	}
+	if _, err := lib.AddNote("arXiv:1706.03762", "Introduces multi-head self-attention."); err != nil {
+		t.Fatalf("AddNote: %v", err)
+	}
+	if _, err := lib.AddNote("missing2020", "x"); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+
+	cases := []struct {
+		query string
+		tags  []string
+		want  string
+	}{
+		{"multi-head", nil, "muller2017attention"},
+		{"muller", nil, "muller2017attention"},
+		{"LECUN 2015", nil, "lecun2015deep"},
+		{"", []string{"reading list"}, "lecun2015deep"},
+	}
+	for _, tc := range cases {
+		got, err := lib.Search(tc.query, tc.tags)
+		if err != nil || len(got) != 1 || got[0].Key != tc.want {
+			t.Errorf("Search(%q, %v) = %v, %v; want %s", tc.query, tc.tags, got, err, tc.want)
+		}
+	}
+	if got, _ := lib.Search("", nil); len(got) != 2 {
+		t.Errorf("empty search returned %d entries", len(got))
+	}
+}
@@ -0,0 +1,277 @@
+package library
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultArXivAPI    = "https://export.arxiv.org/api/query"
+	defaultArXivPDF    = "https://arxiv.org/pdf/"
+	defaultCrossrefAPI = "https://api.crossref.org/works/"
+	userAgent          = "Summer AI Assistant (mailto:research@example.com)"
+)
+
+// ErrNotFound is returned when arXiv or Crossref has no record for an ID.
+var ErrNotFound = errors.New("not found")
+
+// Resolver fetches metadata from arXiv and Crossref and normalizes it into
+// entries. Empty URLs use the public endpoints.
+type Resolver struct {
+	Client       *http.Client
+	ArXivAPI     string
+	ArXivPDF     string
+	CrossrefAPI  string
+	DOIHandleAPI string
+}
+
+func (r *Resolver) endpoint(value, def string) string {
+	if value != "" {
+		return value
+	}
+	return def
+}
+
+// Resolve looks up a DOI or arXiv ID. For arXiv papers with a published
+// version, Crossref's record supplies the venue, type and pages while the
+// arXiv ID and abstract are kept.
+func (r *Resolver) Resolve(ctx context.Context, ref string) (*Entry, error) {
+	if doi := NormalizeDOI(ref); doi != "" {
+		return r.crossref(ctx, doi)
+	}
+	id := NormalizeArXivID(ref)
+	if id == "" {
+		return nil, fmt.Errorf("%q is not a DOI or arXiv ID", ref)
+	}
+	preprint, err := r.arxiv(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if preprint.DOI == "" {
+		return preprint, nil
+	}
+	published, err := r.crossref(ctx, preprint.DOI)
+	if err != nil {
+		// The preprint alone is still useful.
+		return preprint, nil
+	}
+	mergeEntry(published, preprint)
+	return published, nil
+}
+
+// PDFURL returns where the entry's PDF can be downloaded, or "".
+func (r *Resolver) PDFURL(e *Entry) string {
+	if e.ArXivID != "" {
+		return r.endpoint(r.ArXivPDF, defaultArXivPDF) + e.ArXivID
+	}
+	return e.pdfURL
+}
+
+func (r *Resolver) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("HTTP %d from %s", resp.StatusCode, req.URL.Host)
+	}
+	return body, nil
+}
+
+type arxivFeed struct {
+	Entries []struct {
+		ID        string `xml:"id"`
+		Title     string `xml:"title"`
+		Summary   string `xml:"summary"`
+		Published string `xml:"published"`
+		Authors   []struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		DOI        string `xml:"http://arxiv.org/schemas/atom doi"`
+		JournalRef string `xml:"http://arxiv.org/schemas/atom journal_ref"`
+	} `xml:"entry"`
+}
+
+func (r *Resolver) arxiv(ctx context.Context, id string) (*Entry, error) {
+	body, err := r.get(ctx, r.endpoint(r.ArXivAPI, defaultArXivAPI)+"?id_list="+url.QueryEscape(id))
+	if err != nil {
+		return nil, fmt.Errorf("arXiv lookup failed: %w", err)
+	}
+	var feed arxivFeed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, fmt.Errorf("failed to parse arXiv response: %w", err)
+	}
+	// Unknown IDs come back as an entry describing the error.
+	if len(feed.Entries) == 0 || strings.Contains(feed.Entries[0].ID, "/api/errors") {
+		return nil, fmt.Errorf("arXiv: %s %w", id, ErrNotFound)
+	}
+	a := feed.Entries[0]
+	e := &Entry{
+		Type:     TypePreprint,
+		Title:    a.Title,
+		Abstract: a.Summary,
+		DOI:      a.DOI,
+		ArXivID:  id,
+		URL:      "https://arxiv.org/abs/" + id,
+	}
+	for _, author := range a.Authors {
+		e.Authors = append(e.Authors, SplitName(author.Name))
+	}
+	if t, err := time.Parse(time.RFC3339, a.Published); err == nil {
+		e.Year, e.Month = t.Year(), int(t.Month())
+	}
+	if a.JournalRef != "" && a.DOI == "" {
+		// Without a DOI, the journal reference is the best venue we have.
+		e.Venue = a.JournalRef
+		e.Type = TypeArticle
+	}
+	Normalize(e)
+	return e, nil
+}
+
+type crossrefWork struct {
+	Type   string   `json:"type"`
+	Title  []string `json:"title"`
+	Author []struct {
+		Given  string `json:"given"`
+		Family string `json:"family"`
+		Name   string `json:"name"` // organizations
+	} `json:"author"`
+	Issued struct {
+		DateParts [][]int `json:"date-parts"`
+	} `json:"issued"`
+	ContainerTitle []string `json:"container-title"`
+	Volume         string   `json:"volume"`
+	Issue          string   `json:"issue"`
+	Page           string   `json:"page"`
+	Publisher      string   `json:"publisher"`
+	DOI            string   `json:"DOI"`
+	URL            string   `json:"URL"`
+	Abstract       string   `json:"abstract"`
+	Link           []struct {
+		URL         string `json:"URL"`
+		ContentType string `json:"content-type"`
+	} `json:"link"`
+}
+
+// crossrefTypes maps Crossref work types to entry types.
+var crossrefTypes = map[string]string{
+	"journal-article":     TypeArticle,
+	"proceedings-article": TypeInProceedings,
+	"book":                TypeBook,
+	"monograph":           TypeBook,
+	"edited-book":         TypeBook,
+	"book-chapter":        TypeInCollection,
+	"dissertation":        TypePhDThesis,
+	"report":              TypeTechReport,
+	"posted-content":      TypePreprint,
+}
+
+var jatsTagRe = regexp.MustCompile(`<[^>]+>`)
+
+func (r *Resolver) crossref(ctx context.Context, doi string) (*Entry, error) {
+	body, err := r.get(ctx, r.endpoint(r.CrossrefAPI, defaultCrossrefAPI)+url.PathEscape(doi))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("Crossref: %s %w", doi, ErrNotFound)
+		}
+		return nil, fmt.Errorf("Crossref lookup failed: %w", err)
+	}
+	var resp struct {
+		Message crossrefWork `json:"message"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse Crossref response: %w", err)
+	}
+	w := resp.Message
+	e := &Entry{
+		Type:      crossrefTypes[w.Type],
+		Volume:    w.Volume,
+		Issue:     w.Issue,
+		Pages:     w.Page,
+		Publisher: w.Publisher,
+		DOI:       w.DOI,
+		URL:       w.URL,
+		Abstract:  jatsTagRe.ReplaceAllString(w.Abstract, " "),
+	}
+	if e.Type == "" {
+		e.Type = TypeArticle
+	}
+	if len(w.Title) > 0 {
+		e.Title = w.Title[0]
+	}
+	if len(w.ContainerTitle) > 0 {
+		e.Venue = w.ContainerTitle[0]
+	}
+	for _, a := range w.Author {
+		if a.Family == "" && a.Name != "" {
+			e.Authors = append(e.Authors, Author{Family: a.Name})
+			continue
+		}
+		e.Authors = append(e.Authors, Author{Given: a.Given, Family: a.Family})
+	}
+	if dp := w.Issued.DateParts; len(dp) > 0 && len(dp[0]) > 0 {
+		e.Year = dp[0][0]
+		if len(dp[0]) > 1 {
+			e.Month = dp[0][1]
+		}
+	}
+	for _, l := range w.Link {
+		if l.ContentType == "application/pdf" {
+			e.pdfURL = l.URL
+			break
+		}
+	}
+	Normalize(e)
+	return e, nil
+}
+
+// DownloadPDF fetches the PDF at rawURL into the library's pdfs directory
+// as <key>.pdf and returns its path relative to the library.
+func (r *Resolver) DownloadPDF(ctx context.Context, lib *Library, key, rawURL string) (string, error) {
+	body, err := r.get(ctx, rawURL)
+	if err != nil {
+		return "", fmt.Errorf("PDF download failed: %w", err)
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(body[:min(len(body), 1024)]), []byte("%PDF")) {
+		return "", fmt.Errorf("%s did not return a PDF (the publisher may require a login)", rawURL)
+	}
+	rel := filepath.Join("pdfs", key+".pdf")
+	path := filepath.Join(lib.Dir(), rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create PDF directory: %w", err)
+	}
+	if err := os.WriteFile(path, body, 0644); err != nil {
+		return "", fmt.Errorf("failed to save PDF: %w", err)
+	}
+	return rel, nil
+}
@@ -0,0 +1,145 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fixtureResolver serves the files in testdata in place of arXiv, Crossref
+// and PDF downloads.
+func fixtureResolver(t *testing.T) *Resolver {
+	t.Helper()
+	serve := func(w http.ResponseWriter, name string) {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			http.NotFound(w, nil)
+			return
+		}
+		w.Write(data)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/arxiv", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("search_query") != "" {
+			serve(w, "arxiv_search.xml")
+			return
+		}
+		id := r.URL.Query().Get("id_list")
+		if _, err := os.Stat(filepath.Join("testdata", "arxiv_"+id+".xml")); err != nil {
+			serve(w, "arxiv_error.xml")
+			return
+		}
+		serve(w, "arxiv_"+id+".xml")
+	})
+	mux.HandleFunc("/crossref", func(w http.ResponseWriter, r *http.Request) {
+		serve(w, "crossref_search.json")
+	})
+	mux.HandleFunc("/crossref/", func(w http.ResponseWriter, r *http.Request) {
+		doi := strings.TrimPrefix(r.URL.Path, "/crossref/")
+		serve(w, "crossref_"+strings.ReplaceAll(doi, "/", "_")+".json")
+	})
+	mux.HandleFunc("/handles/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/handles/10.5281/zenodo.123" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"responseCode": 1, "handle": "10.5281/zenodo.123"}`))
+	})
+	mux.HandleFunc("/pdf/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("%PDF-1.4\n% fixture\n"))
+	})
+	mux.HandleFunc("/paywall", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body>Sign in</body></html>"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return &Resolver{
+		Client:       srv.Client(),
+		ArXivAPI:     srv.URL + "/arxiv",
+		ArXivPDF:     srv.URL + "/pdf/",
+		CrossrefAPI:  srv.URL + "/crossref/",
+		DOIHandleAPI: srv.URL + "/handles/",
+	}
+}
+
+func TestResolve_Crossref(t *testing.T) {
+	r := fixtureResolver(t)
+	e, err := r.Resolve(context.Background(), "https://doi.org/10.1038/NATURE14539")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if e.Type != TypeArticle || e.Title != "Deep learning" || e.Venue != "Nature" {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e.Year != 2015 || e.Month != 5 || e.Volume != "521" || e.Issue != "7553" || e.Pages != "436-444" {
+		t.Errorf("unexpected publication details: %+v", e)
+	}
+	if len(e.Authors) != 3 || e.Authors[0] != (Author{Given: "Yann", Family: "LeCun"}) {
+		t.Errorf("unexpected authors: %+v", e.Authors)
+	}
+	if strings.Contains(e.Abstract, "<") || !strings.HasPrefix(e.Abstract, "Deep learning allows") {
+		t.Errorf("abstract not cleaned: %q", e.Abstract)
+	}
+	if got := r.PDFURL(e); got != "https://www.nature.com/articles/nature14539.pdf" {
+		t.Errorf("PDFURL = %q", got)
+	}
+}
+
+func TestResolve_ArXivWithPublishedVersion(t *testing.T) {
+	r := fixtureResolver(t)
+	e, err := r.Resolve(context.Background(), "arXiv:1706.03762v7")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	// Crossref's record supplies the venue; arXiv's the ID and abstract.
+	if e.Type != TypeInProceedings || !strings.HasPrefix(e.Venue, "Proceedings of the 31st") {
+		t.Errorf("published version not used: type %q venue %q", e.Type, e.Venue)
+	}
+	if e.ArXivID != "1706.03762" || e.DOI != "10.5555/3295222.3295349" {
+		t.Errorf("unexpected identifiers: arXiv %q DOI %q", e.ArXivID, e.DOI)
+	}
+	if e.Title != "Attention is all you need" || !strings.HasPrefix(e.Abstract, "The dominant sequence") {
+		t.Errorf("unexpected title/abstract: %q / %q", e.Title, e.Abstract)
+	}
+	if !strings.HasSuffix(r.PDFURL(e), "/pdf/1706.03762") {
+		t.Errorf("PDFURL = %q", r.PDFURL(e))
+	}
+}
+
+func TestResolve_NotFound(t *testing.T) {
+	r := fixtureResolver(t)
+	for _, ref := range []string{"9999.99999", "10.1234/missing"} {
+		if _, err := r.Resolve(context.Background(), ref); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Resolve(%q) error = %v, want ErrNotFound", ref, err)
+		}
+	}
+	if _, err := r.Resolve(context.Background(), "attention is all you need"); err == nil {
+		t.Error("expected an error for a reference that is not an identifier")
+	}
+}
+
+func TestDownloadPDF(t *testing.T) {
+	r := fixtureResolver(t)
+	lib := New(filepath.Join(t.TempDir(), "library"))
+
+	rel, err := r.DownloadPDF(context.Background(), lib, "vaswani2017attention", r.ArXivPDF+"1706.03762")
+	if err != nil {
+		t.Fatalf("DownloadPDF: %v", err)
+	}
+	if rel != filepath.Join("pdfs", "vaswani2017attention.pdf") {
+		t.Errorf("rel = %q", rel)
+	}
+	if data, err := os.ReadFile(filepath.Join(lib.Dir(), rel)); err != nil || !strings.HasPrefix(string(data), "%PDF") {
+		t.Errorf("PDF not saved: %v", err)
+	}
+
+	paywall := strings.TrimSuffix(r.ArXivPDF, "/pdf/") + "/paywall"
+	if _, err := r.DownloadPDF(context.Background(), lib, "x", paywall); err == nil {
+		t.Error("expected an error for an HTML page")
+	}
+}
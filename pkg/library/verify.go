@@ -0,0 +1,411 @@
+package library
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const defaultDOIHandleAPI = "https://doi.org/api/handles/"
+
+// Citation kinds.
+const (
+	CitationDOI   = "doi"
+	CitationArXiv = "arxiv"
+	CitationTitle = "title"
+)
+
+// Citation is a reference found in a piece of text.
+type Citation struct {
+	Kind  string // CitationDOI, CitationArXiv or CitationTitle
+	ID    string // normalized DOI or arXiv ID; empty for titles
+	Title string // the claimed title, for titles and IDs cited next to one
+	Text  string // the reference as written
+	Line  int    // zero-based line of the reference
+	Start int    // byte offsets of Text within the line
+	End   int
+}
+
+// key identifies what a citation points to, for deduplicating lookups.
+func (c Citation) key() string {
+	if c.ID != "" {
+		return c.Kind + ":" + c.ID + "|" + foldTitle(c.Title)
+	}
+	return c.Kind + ":" + foldTitle(c.Title)
+}
+
+var (
+	citeArXivRe   = regexp.MustCompile(`(?i)(?:arxiv:\s*|arxiv\.org/(?:abs|pdf)/)(\d{4}\.\d{4,5}|[a-z][a-z.-]*/\d{7})(?:v\d+)?`)
+	quotedTitleRe = regexp.MustCompile(`"([^"\n]{10,300})"|“([^”\n]{10,300})”|\*([^*\n]{10,300})\*`)
+	// Titles are only taken from lines that cite an ID or an author "et
+	// al", or from reference-list entries that give a year.
+	etAlRe = regexp.MustCompile(`\bet al\b`)
+	yearRe = regexp.MustCompile(`\b(?:19|20)\d{2}\b`)
+)
+
+// ExtractCitations finds DOIs, arXiv IDs and quoted or italicized paper
+// titles in text. A title on the same line as an ID is attached to that
+// ID's citation so that the pair can be checked together.
+func ExtractCitations(text string) []Citation {
+	var cites []Citation
+	for n, line := range strings.Split(text, "\n") {
+		var ids []Citation
+		for _, loc := range doiRe.FindAllStringIndex(line, -1) {
+			raw := trimDOIMatch(line[loc[0]:loc[1]])
+			c := Citation{Kind: CitationDOI, ID: NormalizeDOI(raw), Text: raw, Line: n, Start: loc[0], End: loc[0] + len(raw)}
+			if c.ID == "" {
+				c.Kind, c.ID = CitationArXiv, NormalizeArXivID(raw)
+			}
+			if c.ID != "" {
+				ids = append(ids, c)
+			}
+		}
+		for _, loc := range citeArXivRe.FindAllStringSubmatchIndex(line, -1) {
+			ids = append(ids, Citation{
+				Kind:  CitationArXiv,
+				ID:    strings.ToLower(line[loc[2]:loc[3]]),
+				Text:  line[loc[0]:loc[1]],
+				Line:  n,
+				Start: loc[0],
+				End:   loc[1],
+			})
+		}
+
+		var titles []Citation
+		if len(ids) > 0 || etAlRe.MatchString(line) || (referenceLineRe.MatchString(line) && yearRe.MatchString(line)) {
+			for _, loc := range quotedTitleRe.FindAllStringSubmatchIndex(line, -1) {
+				var title string
+				for g := 1; g < len(loc)/2; g++ {
+					if loc[2*g] >= 0 {
+						title = strings.TrimSpace(strings.TrimRight(line[loc[2*g]:loc[2*g+1]], ".,"))
+					}
+				}
+				if len(strings.Fields(title)) < 4 || strings.Contains(title, "://") {
+					continue
+				}
+				titles = append(titles, Citation{Kind: CitationTitle, Title: title, Text: line[loc[0]:loc[1]], Line: n, Start: loc[0], End: loc[1]})
+			}
+		}
+
+		if len(ids) > 0 {
+			sort.Slice(ids, func(i, j int) bool { return ids[i].Start < ids[j].Start })
+			for i := range ids {
+				if len(titles) > 0 {
+					ids[i].Title = titles[0].Title
+				}
+			}
+			cites = append(cites, ids...)
+		} else {
+			cites = append(cites, titles...)
+		}
+	}
+	return cites
+}
+
+// HasCitations reports whether text contains anything ExtractCitations
+// would return: a DOI, an arXiv ID or a quoted title on a line that looks
+// like a reference. It is a cheap test for skipping verification entirely.
+func HasCitations(text string) bool {
+	if doiRe.MatchString(text) || citeArXivRe.MatchString(text) {
+		return true
+	}
+	return quotedTitleRe.MatchString(text) && len(ExtractCitations(text)) > 0
+}
+
+// trimDOIMatch drops trailing punctuation that belongs to the surrounding
+// text, keeping closing parentheses that are part of the DOI.
+func trimDOIMatch(s string) string {
+	for len(s) > 0 {
+		last := s[len(s)-1]
+		switch {
+		case strings.IndexByte(".,;:'\"*>]", last) >= 0:
+			s = s[:len(s)-1]
+		case last == ')' && strings.Count(s, "(") < strings.Count(s, ")"):
+			s = s[:len(s)-1]
+		default:
+			return s
+		}
+	}
+	return s
+}
+
+// Verification statuses.
+const (
+	StatusVerified  = "verified"
+	StatusMismatch  = "mismatch"  // the ID exists but names another paper
+	StatusNotFound  = "not_found" // no record for the ID or title
+	StatusUnchecked = "unchecked" // the lookup failed
+)
+
+// Verdict is the result of checking a citation.
+type Verdict struct {
+	Citation
+	Status string
+	Entry  *Entry // the matching record, for verified and mismatched IDs
+	Error  string // why the citation could not be checked
+}
+
+// Bad reports whether the citation looks hallucinated.
+func (v Verdict) Bad() bool {
+	return v.Status == StatusNotFound || v.Status == StatusMismatch
+}
+
+// Verify checks a citation against Crossref and arXiv. Network failures
+// give StatusUnchecked rather than an error so that they are never mistaken
+// for hallucinations.
+func (r *Resolver) Verify(ctx context.Context, c Citation) Verdict {
+	v := Verdict{Citation: c}
+	var e *Entry
+	var err error
+	switch c.Kind {
+	case CitationDOI:
+		e, err = r.crossref(ctx, c.ID)
+		if errors.Is(err, ErrNotFound) {
+			// DataCite and other agencies register DOIs Crossref does not know.
+			found, herr := r.doiRegistered(ctx, c.ID)
+			if found {
+				v.Status = StatusVerified
+				return v
+			}
+			if herr != nil {
+				err = herr
+			}
+		}
+	case CitationArXiv:
+		e, err = r.arxiv(ctx, c.ID)
+	default:
+		e, err = r.FindTitle(ctx, c.Title)
+	}
+	switch {
+	case errors.Is(err, ErrNotFound):
+		v.Status = StatusNotFound
+	case err != nil:
+		v.Status, v.Error = StatusUnchecked, err.Error()
+	case c.ID != "" && c.Title != "" && !TitlesMatch(c.Title, e.Title):
+		v.Status, v.Entry = StatusMismatch, e
+	default:
+		v.Status, v.Entry = StatusVerified, e
+	}
+	return v
+}
+
+// VerifyAll checks citations concurrently, looking each distinct reference
+// up once. Verdicts are returned in the order of cites.
+func (r *Resolver) VerifyAll(ctx context.Context, cites []Citation) []Verdict {
+	const workers = 4
+	results := make(map[string]Verdict)
+	var mu sync.Mutex
+	jobs := make(chan Citation)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				v := r.Verify(ctx, c)
+				mu.Lock()
+				results[c.key()] = v
+				mu.Unlock()
+			}
+		}()
+	}
+	queued := make(map[string]bool)
+	for _, c := range cites {
+		if !queued[c.key()] {
+			queued[c.key()] = true
+			jobs <- c
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	verdicts := make([]Verdict, len(cites))
+	for i, c := range cites {
+		verdicts[i] = results[c.key()]
+		verdicts[i].Citation = c
+	}
+	return verdicts
+}
+
+// doiRegistered asks the DOI handle service whether doi exists.
+func (r *Resolver) doiRegistered(ctx context.Context, doi string) (bool, error) {
+	body, err := r.get(ctx, r.endpoint(r.DOIHandleAPI, defaultDOIHandleAPI)+url.PathEscape(doi))
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("DOI lookup failed: %w", err)
+	}
+	var resp struct {
+		ResponseCode int `json:"responseCode"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return false, fmt.Errorf("failed to parse DOI response: %w", err)
+	}
+	return resp.ResponseCode == 1, nil
+}
+
+// FindTitle searches Crossref and then arXiv for a paper with the given
+// title, returning ErrNotFound if neither has a close match.
+func (r *Resolver) FindTitle(ctx context.Context, title string) (*Entry, error) {
+	api := strings.TrimSuffix(r.endpoint(r.CrossrefAPI, defaultCrossrefAPI), "/")
+	body, err := r.get(ctx, api+"?rows=5&select=DOI,title&query.bibliographic="+url.QueryEscape(title))
+	if err != nil {
+		return nil, fmt.Errorf("Crossref search failed: %w", err)
+	}
+	var resp struct {
+		Message struct {
+			Items []crossrefWork `json:"items"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse Crossref response: %w", err)
+	}
+	for _, w := range resp.Message.Items {
+		if len(w.Title) > 0 && TitlesMatch(title, w.Title[0]) {
+			return &Entry{Title: w.Title[0], DOI: strings.ToLower(w.DOI)}, nil
+		}
+	}
+
+	query := url.Values{"search_query": {fmt.Sprintf("ti:%q", strings.Join(titleWords(title), " "))}, "max_results": {"5"}}
+	body, err = r.get(ctx, r.endpoint(r.ArXivAPI, defaultArXivAPI)+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("arXiv search failed: %w", err)
+	}
+	var feed arxivFeed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, fmt.Errorf("failed to parse arXiv response: %w", err)
+	}
+	for _, a := range feed.Entries {
+		if TitlesMatch(title, a.Title) {
+			return &Entry{Title: collapseSpace(a.Title), ArXivID: NormalizeArXivID(a.ID)}, nil
+		}
+	}
+	return nil, fmt.Errorf("title %q %w", title, ErrNotFound)
+}
+
+func titleWords(s string) []string {
+	return strings.FieldsFunc(foldText(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// TitlesMatch reports whether a cited title names the same paper as a
+// record's title. Case, accents and punctuation are ignored, and a cited
+// title may leave out the subtitle.
+func TitlesMatch(cited, actual string) bool {
+	a, b := titleWords(cited), titleWords(actual)
+	if len(a) == 0 || len(b) == 0 {
+		return false
+	}
+	inActual := make(map[string]bool, len(b))
+	for _, w := range b {
+		inActual[w] = true
+	}
+	common := 0
+	seen := make(map[string]bool, len(a))
+	for _, w := range a {
+		if !seen[w] {
+			seen[w] = true
+			if inActual[w] {
+				common++
+			}
+		}
+	}
+	if common == len(seen) && len(seen) >= 4 {
+		return true
+	}
+	union := len(seen) + len(inActual) - common
+	return float64(common)/float64(union) >= 0.75
+}
+
+// Citation check modes.
+const (
+	CheckFlag   = "flag"   // mark bad references inline
+	CheckRemove = "remove" // drop reference-list entries, mark inline ones
+)
+
+var referenceLineRe = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)]|\[\d+\])\s+`)
+
+// ApplyVerdicts marks or removes the bad references in text and appends a
+// note saying so. It returns the new text and the number of bad references.
+func ApplyVerdicts(text string, verdicts []Verdict, mode string) (string, int) {
+	lines := strings.Split(text, "\n")
+	drop := make(map[int]bool)
+	marks := make(map[int][]Verdict)
+	bad := 0
+	for _, v := range verdicts {
+		if !v.Bad() {
+			continue
+		}
+		bad++
+		if mode == CheckRemove && referenceLineRe.MatchString(lines[v.Line]) {
+			drop[v.Line] = true
+			continue
+		}
+		marks[v.Line] = append(marks[v.Line], v)
+	}
+	if bad == 0 {
+		return text, 0
+	}
+
+	var out []string
+	flagged := false
+	for n, line := range lines {
+		if drop[n] {
+			continue
+		}
+		vs := marks[n]
+		sort.Slice(vs, func(i, j int) bool { return vs[i].End > vs[j].End })
+		for _, v := range vs {
+			end := afterClosingMarks(line, v.End)
+			line = line[:end] + " " + v.marker() + line[end:]
+			flagged = true
+		}
+		out = append(out, line)
+	}
+	result := strings.TrimRight(strings.Join(out, "\n"), "\n")
+	if len(drop) > 0 {
+		result += fmt.Sprintf("\n\n_Removed %d reference(s) that could not be matched to a record in Crossref or arXiv._", len(drop))
+	}
+	if flagged {
+		result += "\n\n_References marked [unverified] could not be matched to a record in Crossref or arXiv._"
+	}
+	return result, bad
+}
+
+// closingMarks are emphasis and quote delimiters that may close around a
+// reference; a marker goes after them so the markdown stays intact.
+var closingMarks = []string{"*", "_", `"`, "'", "”", "’"}
+
+// afterClosingMarks returns the offset in line past any closing marks that
+// start at i.
+func afterClosingMarks(line string, i int) int {
+	for {
+		found := false
+		for _, m := range closingMarks {
+			if strings.HasPrefix(line[i:], m) {
+				i += len(m)
+				found = true
+			}
+		}
+		if !found {
+			return i
+		}
+	}
+}
+
+func (v Verdict) marker() string {
+	if v.Status == StatusMismatch && v.Entry != nil {
+		return fmt.Sprintf("[unverified: %s is “%s”]", v.Text, collapseSpace(v.Entry.Title))
+	}
+	return "[unverified]"
+}
@@ -0,0 +1,138 @@
+package library
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const draftAnswer = `Transformers replaced recurrence (Vaswani et al., 2017, arXiv:1706.03762v5).
+GANs were introduced in "Generative Adversarial Networks for Speech Synthesis" (2014), arXiv:1706.03762.
+
+References:
+1. LeCun et al. "Deep learning". Nature, 2015. https://doi.org/10.1038/nature14539.
+2. Smith, J. (2021). "Quantum Gradient Boosting for Protein Folding". doi:10.9999/fake.2021.1
+3. Vaswani et al. (2017). *Attention is all you need*.
+4. Doe (2020). "Recurrent Transformers for Lossless Audio Compression".
+5. Survey data (2020), https://doi.org/10.5281/zenodo.123
+He said "this is not a paper title at all" yesterday.`
+
+func TestExtractCitations(t *testing.T) {
+	cites := ExtractCitations(draftAnswer)
+	var got []string
+	for _, c := range cites {
+		got = append(got, c.Kind+"|"+c.ID+"|"+c.Title+"|"+c.Text)
+	}
+	want := []string{
+		"arxiv|1706.03762||arXiv:1706.03762v5",
+		"arxiv|1706.03762|Generative Adversarial Networks for Speech Synthesis|arXiv:1706.03762",
+		"doi|10.1038/nature14539||10.1038/nature14539",
+		"doi|10.9999/fake.2021.1|Quantum Gradient Boosting for Protein Folding|10.9999/fake.2021.1",
+		"title||Attention is all you need|*Attention is all you need*",
+		"title||Recurrent Transformers for Lossless Audio Compression|\"Recurrent Transformers for Lossless Audio Compression\"",
+		"doi|10.5281/zenodo.123||10.5281/zenodo.123",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ExtractCitations:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if c := cites[3]; draftAnswer[strings.Index(draftAnswer, "2. Smith"):][c.Start:c.End] != c.Text {
+		t.Errorf("span %d:%d does not match %q", c.Start, c.End, c.Text)
+	}
+}
+
+func TestHasCitations(t *testing.T) {
+	cases := map[string]bool{
+		draftAnswer: true,
+		"See arxiv.org/abs/2410.01234 for details.":                 true,
+		"Published as doi:10.1038/nature14539.":                     true,
+		`Vaswani et al. wrote "Attention is all you need" in 2017.`: true,
+		`The error said "could not open the configuration file".`:   false,
+		"Paris is the capital of France, as it has been since 987.": false,
+		"**Key changes in the 2024 release**":                       false,
+		`In 2023 the CEO said "we will double the team size"`:       false,
+		"Plan for 2025: *finish the migration before summer*":       false,
+		"- **Ship the new onboarding flow** next sprint":            false,
+		"": false,
+	}
+	for text, want := range cases {
+		if got := HasCitations(text); got != want {
+			t.Errorf("HasCitations(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestVerifyAndApply(t *testing.T) {
+	r := fixtureResolver(t)
+	verdicts := r.VerifyAll(context.Background(), ExtractCitations(draftAnswer))
+	var statuses []string
+	for _, v := range verdicts {
+		statuses = append(statuses, v.Status)
+	}
+	want := []string{StatusVerified, StatusMismatch, StatusVerified, StatusNotFound, StatusVerified, StatusNotFound, StatusVerified}
+	if strings.Join(statuses, ",") != strings.Join(want, ",") {
+		t.Fatalf("statuses = %v, want %v", statuses, want)
+	}
+
+	flagged, bad := ApplyVerdicts(draftAnswer, verdicts, CheckFlag)
+	if bad != 3 {
+		t.Errorf("bad = %d, want 3", bad)
+	}
+	for _, s := range []string{
+		"arXiv:1706.03762 [unverified: arXiv:1706.03762 is “Attention Is All You Need”].",
+		"doi:10.9999/fake.2021.1 [unverified]\n",
+		`Compression" [unverified].`,
+		"_References marked [unverified] could not be matched",
+	} {
+		if !strings.Contains(flagged, s) {
+			t.Errorf("flagged text missing %q:\n%s", s, flagged)
+		}
+	}
+
+	removed, _ := ApplyVerdicts(draftAnswer, verdicts, CheckRemove)
+	if strings.Contains(removed, "Quantum") || strings.Contains(removed, "Lossless") {
+		t.Errorf("bad reference entries not removed:\n%s", removed)
+	}
+	if !strings.Contains(removed, "[unverified: arXiv") || !strings.Contains(removed, "_Removed 2 reference(s)") {
+		t.Errorf("unexpected text after removal:\n%s", removed)
+	}
+
+	if text, bad := ApplyVerdicts("no citations", nil, CheckFlag); text != "no citations" || bad != 0 {
+		t.Errorf("clean text changed: %q", text)
+	}
+}
+
+func TestApplyVerdicts_MarkerAfterEmphasis(t *testing.T) {
+	cases := map[string]string{
+		"1. Doe (2020). **Recurrent Transformers for Lossless Audio Compression**.": "**Recurrent Transformers for Lossless Audio Compression** [unverified].",
+		"1. Doe (2020). *Recurrent Transformers for Lossless Audio Compression*":    "*Recurrent Transformers for Lossless Audio Compression* [unverified]\n",
+		"See **doi:10.9999/nope** for details.":                                     "**doi:10.9999/nope** [unverified] for details.",
+	}
+	for text, want := range cases {
+		var verdicts []Verdict
+		for _, c := range ExtractCitations(text) {
+			verdicts = append(verdicts, Verdict{Citation: c, Status: StatusNotFound})
+		}
+		flagged, bad := ApplyVerdicts(text, verdicts, CheckFlag)
+		if bad != 1 || !strings.Contains(flagged, want) {
+			t.Errorf("ApplyVerdicts(%q) = %q, want it to contain %q", text, flagged, want)
+		}
+	}
+}
+
+func TestTitlesMatch(t *testing.T) {
+	cases := []struct {
+		cited, actual string
+		want          bool
+	}{
+		{"Attention is all you need", "Attention Is All You\n  Need", true},
+		{"BERT: Pre-training of Deep Bidirectional Transformers", "BERT: Pre-training of Deep Bidirectional Transformers for Language Understanding", true},
+		{"Über die Elektrodynamik bewegter Körper", "Uber die Elektrodynamik bewegter Korper", true},
+		{"Deep learning", "Deep learning for audio", false},
+		{"Quantum Gradient Boosting for Protein Folding", "Gradient-based learning applied to document recognition", false},
+	}
+	for _, tc := range cases {
+		if got := TitlesMatch(tc.cited, tc.actual); got != tc.want {
+			t.Errorf("TitlesMatch(%q, %q) = %v", tc.cited, tc.actual, got)
+		}
+	}
+}
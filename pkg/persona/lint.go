@@ -0,0 +1,146 @@
+package persona
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// largePersonaTokens is the size above which lint warns that a persona
+// crowds the context window.
+const largePersonaTokens = 2000
+
+// EstimateTokens approximates the token count of s, at four characters per
+// token like the agent's context accounting.
+func EstimateTokens(s string) int {
+	return len(s) / 4
+}
+
+// Section is a headed part of a persona and its size.
+type Section struct {
+	Title  string
+	Tokens int
+}
+
+// LintReport describes a persona's prompt size and likely problems.
+type LintReport struct {
+	Name          string
+	Chars         int
+	Tokens        int
+	DefaultTokens int // size of the built-in default persona
+	PromptTokens  int // size of the whole system prompt, when known
+	Sections      []Section
+	Warnings      []string
+}
+
+var (
+	headingRe = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*$`)
+	// Lines that talk about the specification instead of to the model.
+	metaLineRe = regexp.MustCompile(`(?i)\b(this (document|specification|spec) (defines|describes)|end of specification|implement(ation)? spec)`)
+)
+
+// Lint measures a persona and checks it for common mistakes.
+func Lint(p *Persona) *LintReport {
+	r := &LintReport{
+		Name:   p.Name,
+		Chars:  len(p.Content),
+		Tokens: EstimateTokens(p.Content),
+	}
+	if def, err := builtinFS.ReadFile("builtin/" + Default + ".md"); err == nil {
+		r.DefaultTokens = EstimateTokens(Parse(Default, string(def)).Content)
+	}
+	if strings.TrimSpace(p.Content) == "" {
+		r.Warnings = append(r.Warnings, "persona is empty; the default persona is used instead")
+		return r
+	}
+	if p.Description == "" {
+		r.Warnings = append(r.Warnings, "no description in frontmatter; /persona shows none")
+	}
+	if r.Tokens > largePersonaTokens {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("~%d tokens are sent with every request; consider trimming examples", r.Tokens))
+	}
+
+	type heading struct {
+		title string
+		line  int
+	}
+	var headings []heading
+	seen := make(map[string]bool)
+	current := -1 // index of the section being measured
+	lines := strings.Split(p.Content, "\n")
+	for n, line := range lines {
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			title := m[2]
+			key := strings.ToLower(title)
+			if seen[key] {
+				r.Warnings = append(r.Warnings, fmt.Sprintf("line %d: duplicate heading %q", n+1, title))
+			}
+			seen[key] = true
+			headings = append(headings, heading{title, n})
+			r.Sections = append(r.Sections, Section{Title: title})
+			current = len(r.Sections) - 1
+			continue
+		}
+		if current >= 0 {
+			r.Sections[current].Tokens += len(line) + 1 // chars until converted below
+		}
+		if metaLineRe.MatchString(line) {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("line %d: addresses the reader of the spec, not the model: %q", n+1, strings.TrimSpace(line)))
+		}
+	}
+	for i := range r.Sections {
+		r.Sections[i].Tokens /= 4
+	}
+	for i, h := range headings {
+		end := len(lines)
+		if i+1 < len(headings) {
+			end = headings[i+1].line
+		}
+		if strings.Trim(strings.Join(lines[h.line+1:end], ""), " \t-") == "" && (i+1 == len(headings) || headingLevel(lines[headings[i+1].line]) <= headingLevel(lines[h.line])) {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("line %d: section %q is empty", h.line+1, h.title))
+		}
+	}
+	return r
+}
+
+func headingLevel(line string) int {
+	if m := headingRe.FindStringSubmatch(line); m != nil {
+		return len(m[1])
+	}
+	return 0
+}
+
+// String formats the report for chat or the terminal.
+func (r *LintReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Persona %s: %d chars, ~%d tokens", r.Name, r.Chars, r.Tokens)
+	if delta := r.Tokens - r.DefaultTokens; r.Name != Default && delta != 0 {
+		fmt.Fprintf(&sb, " (%+d vs default)", delta)
+	}
+	sb.WriteString("\n")
+	if r.PromptTokens > 0 {
+		fmt.Fprintf(&sb, "Share of system prompt: %d%% of ~%d tokens\n", r.Tokens*100/r.PromptTokens, r.PromptTokens)
+	}
+
+	sections := append([]Section(nil), r.Sections...)
+	sort.SliceStable(sections, func(i, j int) bool { return sections[i].Tokens > sections[j].Tokens })
+	if len(sections) > 5 {
+		sections = sections[:5]
+	}
+	if len(sections) > 0 {
+		sb.WriteString("Largest sections:\n")
+		for _, s := range sections {
+			fmt.Fprintf(&sb, "  ~%d tokens  %s\n", s.Tokens, s.Title)
+		}
+	}
+	if len(r.Warnings) == 0 {
+		sb.WriteString("No problems found.")
+	} else {
+		sb.WriteString("Warnings:\n")
+		for _, w := range r.Warnings {
+			fmt.Fprintf(&sb, "  - %s\n", w)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
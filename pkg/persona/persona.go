@@ -0,0 +1,238 @@
+// Package persona loads the persona that opens the system prompt: the
+// assistant's identity, tone rules and behavioural checklists. Personas are
+// Markdown specifications in the style of per.md, stored as
+// personas/<name>.md in the workspace with optional name/description
+// frontmatter. Built-in personas can be overridden by a workspace file of
+// the same name.
+package persona
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Default is the persona used when nothing else is selected.
+const Default = "default"
+
+//go:embed builtin/*.md
+var builtinFS embed.FS
+
+// Persona is a loaded persona specification.
+type Persona struct {
+	Name        string
+	Description string
+	Content     string // the specification without frontmatter
+	Path        string // file path, or "" for built-ins
+}
+
+// Builtin reports whether the persona ships with summer.
+func (p *Persona) Builtin() bool {
+	return p.Path == ""
+}
+
+var (
+	frontmatterRe = regexp.MustCompile(`(?s)^---\n(.*?)\n---\n?`)
+	nameRe        = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+)
+
+// Parse reads a persona file. Frontmatter name and description are
+// optional; the name defaults to the file name.
+func Parse(name, data string) *Persona {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	p := &Persona{Name: name}
+	if m := frontmatterRe.FindStringSubmatch(data); m != nil {
+		for _, line := range strings.Split(m[1], "\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			value = strings.Trim(strings.TrimSpace(value), `"'`)
+			switch strings.TrimSpace(key) {
+			case "name":
+				if value != "" {
+					p.Name = value
+				}
+			case "description":
+				p.Description = value
+			}
+		}
+		data = data[len(m[0]):]
+	}
+	p.Content = strings.TrimSpace(data)
+	return p
+}
+
+// Manager finds personas and tracks which one each conversation uses.
+type Manager struct {
+	dir         string
+	defaultName string
+	channels    map[string]string // channel or "channel:chat_id" -> persona
+	mu          sync.Mutex
+}
+
+// NewManager returns the persona manager of a workspace. defaultName is
+// the configured default persona and channels maps a channel, or
+// "channel:chat_id", to the persona it uses.
+func NewManager(workspace, defaultName string, channels map[string]string) *Manager {
+	if defaultName == "" {
+		defaultName = Default
+	}
+	return &Manager{
+		dir:         filepath.Join(workspace, "personas"),
+		defaultName: defaultName,
+		channels:    channels,
+	}
+}
+
+// Dir returns the directory holding workspace personas.
+func (m *Manager) Dir() string {
+	return m.dir
+}
+
+// Load returns the named persona, preferring a workspace file over a
+// built-in one.
+func (m *Manager) Load(name string) (*Persona, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !nameRe.MatchString(name) {
+		return nil, fmt.Errorf("invalid persona name %q", name)
+	}
+	path := filepath.Join(m.dir, name+".md")
+	data, err := os.ReadFile(path)
+	if err == nil {
+		p := Parse(name, string(data))
+		p.Name, p.Path = name, path
+		return p, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read persona: %w", err)
+	}
+	data, err = builtinFS.ReadFile("builtin/" + name + ".md")
+	if err != nil {
+		return nil, fmt.Errorf("persona %q not found", name)
+	}
+	p := Parse(name, string(data))
+	p.Name = name
+	return p, nil
+}
+
+// List returns all available personas sorted by name.
+func (m *Manager) List() ([]*Persona, error) {
+	names := make(map[string]bool)
+	builtins, _ := builtinFS.ReadDir("builtin")
+	for _, e := range builtins {
+		names[strings.TrimSuffix(e.Name(), ".md")] = true
+	}
+	entries, err := os.ReadDir(m.dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list personas: %w", err)
+	}
+	for _, e := range entries {
+		if name := strings.TrimSuffix(e.Name(), ".md"); !e.IsDir() && name != e.Name() && nameRe.MatchString(name) {
+			names[name] = true
+		}
+	}
+
+	var personas []*Persona
+	for name := range names {
+		p, err := m.Load(name)
+		if err != nil {
+			return nil, err
+		}
+		personas = append(personas, p)
+	}
+	sort.Slice(personas, func(i, j int) bool { return personas[i].Name < personas[j].Name })
+	return personas, nil
+}
+
+func (m *Manager) selectionsPath() string {
+	return filepath.Join(m.dir, "selections.json")
+}
+
+func (m *Manager) loadSelections() (map[string]string, error) {
+	data, err := os.ReadFile(m.selectionsPath())
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read persona selections: %w", err)
+	}
+	selections := map[string]string{}
+	if err := json.Unmarshal(data, &selections); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", m.selectionsPath(), err)
+	}
+	return selections, nil
+}
+
+// Resolve returns the name of the persona a conversation uses: the one
+// selected in the chat with Select, else the configured persona for the
+// chat or its channel, else the default.
+func (m *Manager) Resolve(channel, chatID string) string {
+	key := channel + ":" + chatID
+	m.mu.Lock()
+	selections, err := m.loadSelections()
+	m.mu.Unlock()
+	if err == nil && selections[key] != "" {
+		return selections[key]
+	}
+	if name := m.channels[key]; name != "" {
+		return name
+	}
+	if name := m.channels[channel]; name != "" {
+		return name
+	}
+	return m.defaultName
+}
+
+// Active loads the persona of a conversation, falling back to the built-in
+// default if the configured one cannot be loaded.
+func (m *Manager) Active(channel, chatID string) (*Persona, error) {
+	name := m.Resolve(channel, chatID)
+	p, err := m.Load(name)
+	if err != nil {
+		fallback, _ := m.Load(Default)
+		return fallback, err
+	}
+	return p, nil
+}
+
+// Select sets the persona of one chat. An empty name clears the selection
+// so that the configured persona applies again.
+func (m *Manager) Select(channel, chatID, name string) error {
+	if name != "" {
+		p, err := m.Load(name)
+		if err != nil {
+			return err
+		}
+		name = p.Name
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	selections, err := m.loadSelections()
+	if err != nil {
+		return err
+	}
+	key := channel + ":" + chatID
+	if name == "" {
+		delete(selections, key)
+	} else {
+		selections[key] = name
+	}
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create personas directory: %w", err)
+	}
+	data, err := json.MarshalIndent(selections, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal persona selections: %w", err)
+	}
+	if err := os.WriteFile(m.selectionsPath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to save persona selections: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package persona
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePersona(t *testing.T, workspace, name, content string) {
+	t.Helper()
+	dir := filepath.Join(workspace, "personas")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManager_LoadAndList(t *testing.T) {
+	workspace := t.TempDir()
+	writePersona(t, workspace, "tutor", "---\nname: Tutor\ndescription: \"Patient teacher\"\n---\n\nYou are a patient tutor.\n")
+	writePersona(t, workspace, "research", "You are a terse reviewer.")
+	m := NewManager(workspace, "", nil)
+
+	p, err := m.Load("Tutor")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if p.Name != "tutor" || p.Description != "Patient teacher" || p.Content != "You are a patient tutor." || p.Builtin() {
+		t.Errorf("unexpected persona: %+v", p)
+	}
+
+	// A workspace file overrides the built-in persona of the same name.
+	if p, _ := m.Load("research"); p.Content != "You are a terse reviewer." {
+		t.Errorf("workspace persona did not override the built-in: %q", p.Content)
+	}
+	if _, err := m.Load("../secrets"); err == nil {
+		t.Error("expected an invalid name to be rejected")
+	}
+	if _, err := m.Load("missing"); err == nil {
+		t.Error("expected a missing persona to fail")
+	}
+
+	list, err := m.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var names []string
+	for _, p := range list {
+		names = append(names, p.Name)
+	}
+	if strings.Join(names, ",") != "default,research,tutor" {
+		t.Errorf("List = %v", names)
+	}
+}
+
+func TestManager_ResolveAndSelect(t *testing.T) {
+	workspace := t.TempDir()
+	m := NewManager(workspace, "research", map[string]string{
+		"slack":         "default",
+		"telegram:1234": "default",
+	})
+
+	cases := map[[2]string]string{
+		{"telegram", "1234"}: "default",  // configured for the chat
+		{"slack", "general"}: "default",  // configured for the channel
+		{"telegram", "999"}:  "research", // configured default
+	}
+	for c, want := range cases {
+		if got := m.Resolve(c[0], c[1]); got != want {
+			t.Errorf("Resolve(%s, %s) = %q, want %q", c[0], c[1], got, want)
+		}
+	}
+
+	if err := m.Select("slack", "general", "research"); err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if got := NewManager(workspace, "research", map[string]string{"slack": "default"}).Resolve("slack", "general"); got != "research" {
+		t.Errorf("selection not persisted: %q", got)
+	}
+	if err := m.Select("slack", "general", "nope"); err == nil {
+		t.Error("expected selecting an unknown persona to fail")
+	}
+	if err := m.Select("slack", "general", ""); err != nil {
+		t.Fatalf("Select reset: %v", err)
+	}
+	if got := m.Resolve("slack", "general"); got != "default" {
+		t.Errorf("reset selection still applies: %q", got)
+	}
+
+	// A broken configured persona falls back to the built-in default.
+	p, err := NewManager(workspace, "gone", nil).Active("cli", "direct")
+	if err == nil || p == nil || p.Name != Default {
+		t.Errorf("Active = %+v, %v", p, err)
+	}
+}
+
+func TestLint(t *testing.T) {
+	m := NewManager(t.TempDir(), "", nil)
+	research, err := m.Load("research")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := Lint(research)
+	if len(report.Warnings) != 0 {
+		t.Errorf("built-in research persona has warnings: %v", report.Warnings)
+	}
+	if report.Tokens <= report.DefaultTokens || len(report.Sections) < 5 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	draft := Parse("draft", "# Draft\n\nThis document defines how the bot should behave.\n\n## Tone\n\n## Rules\n\n### Always\n- Cite sources.\n\n## tone\nCalm.\n")
+	report = Lint(draft)
+	report.PromptTokens = 200
+	out := report.String()
+	for _, want := range []string{
+		"Persona draft:",
+		"vs default)",
+		"Share of system prompt:",
+		"no description in frontmatter",
+		`line 3: addresses the reader of the spec`,
+		`line 5: section "Tone" is empty`,
+		`line 12: duplicate heading "tone"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("lint output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `section "Rules" is empty`) {
+		t.Errorf("a section with subsections is not empty:\n%s", out)
+	}
+
+	if report := Lint(Parse("blank", "---\nname: blank\n---\n")); len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "empty") {
+		t.Errorf("unexpected warnings for an empty persona: %v", report.Warnings)
+	}
+}
@@ -0,0 +1,166 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// claudeCliMCPServer is the MCP server name the CLI sees; its tools show up
+// to the model as mcp__summer__<tool>.
+const claudeCliMCPServer = "summer"
+
+// cliMCPBridge exposes summer tools to the claude CLI as an MCP server over
+// streamable HTTP on localhost. Each CLI run gets its own unguessable endpoint
+// so tool lists and pending calls of concurrent runs stay separate. Tool calls
+// are not executed here: they are handed to the run and answered once the
+// agent loop sends the tool results back through Chat.
+type cliMCPBridge struct {
+	listener net.Listener
+	mu       sync.Mutex
+	runs     map[string]*cliRun
+}
+
+func newCLIMCPBridge() (*cliMCPBridge, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start MCP bridge: %w", err)
+	}
+	b := &cliMCPBridge{listener: ln, runs: make(map[string]*cliRun)}
+	go http.Serve(ln, b)
+	return b, nil
+}
+
+// mcpConfig returns the --mcp-config JSON pointing the CLI at run's endpoint.
+func (b *cliMCPBridge) mcpConfig(run *cliRun) string {
+	cfg := map[string]interface{}{
+		"mcpServers": map[string]interface{}{
+			claudeCliMCPServer: map[string]interface{}{
+				"type": "http",
+				"url":  fmt.Sprintf("http://%s/mcp/%s", b.listener.Addr(), run.id),
+			},
+		},
+	}
+	data, _ := json.Marshal(cfg)
+	return string(data)
+}
+
+func (b *cliMCPBridge) register(run *cliRun) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.runs[run.id] = run
+}
+
+func (b *cliMCPBridge) unregister(run *cliRun) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.runs, run.id)
+}
+
+type mcpRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type mcpResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *mcpError       `json:"error,omitempty"`
+}
+
+type mcpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (b *cliMCPBridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	b.mu.Lock()
+	run := b.runs[strings.TrimPrefix(r.URL.Path, "/mcp/")]
+	b.mu.Unlock()
+	if run == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var req mcpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON-RPC message", http.StatusBadRequest)
+		return
+	}
+
+	// Notifications (no id) need no answer.
+	if len(req.ID) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	resp := mcpResponse{JSONRPC: "2.0", ID: req.ID}
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(req.Params, &params)
+		if params.ProtocolVersion == "" {
+			params.ProtocolVersion = "2025-03-26"
+		}
+		resp.Result = map[string]interface{}{
+			"protocolVersion": params.ProtocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]interface{}{"name": claudeCliMCPServer, "version": "1.0.0"},
+		}
+	case "ping":
+		resp.Result = map[string]interface{}{}
+	case "tools/list":
+		resp.Result = map[string]interface{}{"tools": mcpToolList(run.tools)}
+	case "tools/call":
+		var params struct {
+			Name      string                 `json:"name"`
+			Arguments map[string]interface{} `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			resp.Error = &mcpError{Code: -32602, Message: "invalid params"}
+			break
+		}
+		result, ok := run.callTool(r.Context(), params.Name, params.Arguments)
+		resp.Result = map[string]interface{}{
+			"content": []map[string]interface{}{{"type": "text", "text": result}},
+			"isError": !ok,
+		}
+	default:
+		resp.Error = &mcpError{Code: -32601, Message: "method not found: " + req.Method}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func mcpToolList(tools []ToolDefinition) []map[string]interface{} {
+	list := make([]map[string]interface{}, 0, len(tools))
+	for _, t := range tools {
+		if t.Type != "function" {
+			continue
+		}
+		schema := t.Function.Parameters
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object"}
+		}
+		list = append(list, map[string]interface{}{
+			"name":        t.Function.Name,
+			"description": t.Function.Description,
+			"inputSchema": schema,
+		})
+	}
+	return list
+}
@@ -1,18 +1,43 @@
 package providers
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os/exec"
 	"strings"
+	"sync"
+	"time"
 )
 
+// claudeCliIdleTimeout bounds how long a CLI run waits for tool results
+// before it is killed, e.g. when the agent loop hits its iteration limit.
+const claudeCliIdleTimeout = 10 * time.Minute
+
+// claudeCliAnnounceGrace is how long a tool call waits for the assistant
+// message announcing it; see step.
+const claudeCliAnnounceGrace = 2 * time.Second
+
 // ClaudeCliProvider implements LLMProvider using the claude CLI as a subprocess.
+//
+// The CLI is driven in stream-json mode. Summer tools are offered to it via a
+// local MCP bridge: when the CLI calls one, Chat returns it as a regular tool
+// call and the CLI process waits until the next Chat delivers the result.
+// Conversations that pass options["session_key"] resume the CLI's own
+// session on later turns instead of resending the history.
 type ClaudeCliProvider struct {
 	command   string
 	workspace string
+
+	mu       sync.Mutex
+	bridge   *cliMCPBridge
+	sessions map[string]string  // summer session key -> CLI session ID
+	pending  map[string]*cliRun // tool call ID -> run waiting for its result
 }
 
 // NewClaudeCliProvider creates a new Claude CLI provider.
@@ -20,41 +45,456 @@ func NewClaudeCliProvider(workspace string) *ClaudeCliProvider {
 	return &ClaudeCliProvider{
 		command:   "claude",
 		workspace: workspace,
+		sessions:  make(map[string]string),
+		pending:   make(map[string]*cliRun),
 	}
 }
 
 // Chat implements LLMProvider.Chat by executing the claude CLI.
 func (p *ClaudeCliProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
-	systemPrompt := p.buildSystemPrompt(messages, tools)
-	prompt := p.messagesToPrompt(messages)
+	if run, err := p.resumePendingRun(messages); run != nil || err != nil {
+		if err != nil {
+			return nil, err
+		}
+		return p.step(ctx, run)
+	}
 
-	args := []string{"-p", "--output-format", "json", "--dangerously-skip-permissions", "--no-chrome"}
-	if systemPrompt != "" {
+	run, err := p.startRun(messages, tools, model, options)
+	if err != nil {
+		return nil, err
+	}
+	return p.step(ctx, run)
+}
+
+// startRun launches a CLI process for a new user turn.
+func (p *ClaudeCliProvider) startRun(messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*cliRun, error) {
+	run := &cliRun{
+		id:       newCliRunID(),
+		provider: p,
+		tools:    tools,
+		events:   make(chan cliEvent, 64),
+		done:     make(chan struct{}),
+		calls:    make(map[string]*cliToolCall),
+	}
+	run.sessionKey, _ = options["session_key"].(string)
+
+	// The CLI's built-in tools (Bash, Edit, WebFetch, ...) are disabled so
+	// that every action goes through summer's tools and their workspace,
+	// sandbox and network checks; only the bridge's tools remain, and those
+	// run without a permission prompt.
+	args := []string{"-p", "--input-format", "stream-json", "--output-format", "stream-json", "--verbose",
+		"--tools", "", "--allowedTools", "mcp__" + claudeCliMCPServer + "__*",
+		"--dangerously-skip-permissions", "--no-chrome"}
+
+	// Tools go through the MCP bridge; if it cannot start they fall back
+	// to being described in the system prompt.
+	promptTools := tools
+	if len(tools) > 0 {
+		if bridge, err := p.getBridge(); err == nil {
+			bridge.register(run)
+			run.bridge = bridge
+			promptTools = nil
+			args = append(args, "--mcp-config", bridge.mcpConfig(run), "--strict-mcp-config")
+		}
+	}
+
+	if systemPrompt := p.buildSystemPrompt(messages, promptTools); systemPrompt != "" {
 		args = append(args, "--system-prompt", systemPrompt)
 	}
 	if model != "" && model != "claude-code" {
 		args = append(args, "--model", model)
 	}
-	args = append(args, "-") // read from stdin
 
-	cmd := exec.CommandContext(ctx, p.command, args...)
+	prompt := p.messagesToPrompt(messages)
+	if sessionID := p.sessionFor(run.sessionKey); sessionID != "" {
+		// The CLI already holds the earlier turns; only send what is new.
+		args = append(args, "--resume", sessionID)
+		prompt = p.messagesToPrompt(messagesSinceLastAssistant(messages))
+	}
+
+	input, _ := json.Marshal(map[string]interface{}{
+		"type":    "user",
+		"message": map[string]interface{}{"role": "user", "content": prompt},
+	})
+
+	cmd := exec.Command(p.command, args...)
 	if p.workspace != "" {
 		cmd.Dir = p.workspace
 	}
-	cmd.Stdin = bytes.NewReader([]byte(prompt))
+	cmd.Stdin = bytes.NewReader(append(input, '\n'))
+	cmd.Stderr = &run.stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		run.close()
+		return nil, fmt.Errorf("claude cli error: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		run.close()
+		return nil, fmt.Errorf("claude cli error: %w", err)
+	}
+	run.cmd = cmd
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	go run.readOutput(stdout)
+	return run, nil
+}
+
+// resumePendingRun hands trailing tool results to the run that asked for
+// them. It returns nil when the messages do not continue a pending run.
+func (p *ClaudeCliProvider) resumePendingRun(messages []Message) (*cliRun, error) {
+	var results []Message
+	for i := len(messages) - 1; i >= 0 && messages[i].Role == "tool"; i-- {
+		results = append(results, messages[i])
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	if err := cmd.Run(); err != nil {
-		if stderrStr := stderr.String(); stderrStr != "" {
-			return nil, fmt.Errorf("claude cli error: %s", stderrStr)
+	var run *cliRun
+	for _, msg := range results {
+		r, ok := p.pending[msg.ToolCallID]
+		if !ok {
+			continue
+		}
+		run = r
+		delete(p.pending, msg.ToolCallID)
+		if call := r.calls[msg.ToolCallID]; call != nil {
+			call.result <- msg.Content
+			delete(r.calls, msg.ToolCallID)
 		}
-		return nil, fmt.Errorf("claude cli error: %w", err)
 	}
+	if run == nil {
+		return nil, nil
+	}
+
+	if !run.idle.Stop() {
+		select {
+		case <-run.done:
+			return nil, fmt.Errorf("claude cli error: run timed out waiting for tool results")
+		default:
+		}
+	}
+	return run, nil
+}
+
+// step waits for the run's next stopping point: a batch of tool calls or the
+// final result.
+func (p *ClaudeCliProvider) step(ctx context.Context, run *cliRun) (*LLMResponse, error) {
+	var content strings.Builder
+	var usage *UsageInfo
+
+	// A tool call can reach the bridge before the assistant message that
+	// announces it shows up on stdout. Give the message a moment to arrive
+	// so its text is returned with the call.
+	var grace <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			run.close()
+			return nil, ctx.Err()
+		case <-grace:
+			return p.yieldToolCalls(run, content.String(), usage), nil
+		case ev := <-run.events:
+			switch {
+			case ev.err != nil:
+				run.close()
+				if run.sessionKey != "" {
+					p.setSession(run.sessionKey, "")
+				}
+				return nil, ev.err
+			case ev.result != nil:
+				run.close()
+				if run.sessionKey != "" && ev.sessionID != "" {
+					p.setSession(run.sessionKey, ev.sessionID)
+				}
+				return ev.result, nil
+			case ev.call != nil:
+				run.queued = append(run.queued, ev.call)
+			default:
+				content.WriteString(ev.text)
+				run.announced += ev.toolUses
+				if ev.usage != nil {
+					usage = ev.usage
+				}
+			}
+
+			// Return once every announced tool use has reached the bridge.
+			outstanding := run.announced - run.returned
+			if len(run.queued) > 0 && outstanding > 0 && len(run.queued) >= outstanding {
+				return p.yieldToolCalls(run, content.String(), usage), nil
+			}
+			if len(run.queued) > 0 && grace == nil {
+				grace = time.After(claudeCliAnnounceGrace)
+			}
+		}
+	}
+}
 
-	return p.parseClaudeCliResponse(stdout.String())
+func (p *ClaudeCliProvider) yieldToolCalls(run *cliRun, content string, usage *UsageInfo) *LLMResponse {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	resp := &LLMResponse{
+		Content:      strings.TrimSpace(content),
+		FinishReason: "tool_calls",
+		Usage:        usage,
+	}
+	for _, call := range run.queued {
+		argsJSON, _ := json.Marshal(call.args)
+		resp.ToolCalls = append(resp.ToolCalls, ToolCall{
+			ID:        call.id,
+			Type:      "function",
+			Name:      call.name,
+			Arguments: call.args,
+			Function: &FunctionCall{
+				Name:      call.name,
+				Arguments: string(argsJSON),
+			},
+		})
+		run.calls[call.id] = call
+		p.pending[call.id] = run
+	}
+	run.returned += len(run.queued)
+	run.queued = nil
+	run.idle = time.AfterFunc(claudeCliIdleTimeout, run.close)
+	return resp
+}
+
+func (p *ClaudeCliProvider) getBridge() (*cliMCPBridge, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.bridge == nil {
+		bridge, err := newCLIMCPBridge()
+		if err != nil {
+			return nil, err
+		}
+		p.bridge = bridge
+	}
+	return p.bridge, nil
+}
+
+func (p *ClaudeCliProvider) sessionFor(key string) string {
+	if key == "" {
+		return ""
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sessions[key]
+}
+
+// setSession records the CLI session of key; an empty id forgets it so the
+// next turn starts over with the full history.
+func (p *ClaudeCliProvider) setSession(key, id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if id == "" {
+		delete(p.sessions, key)
+		return
+	}
+	p.sessions[key] = id
+}
+
+// ResetSession implements SessionResetter: the next turn of sessionKey starts
+// a new CLI session instead of resuming the old one.
+func (p *ClaudeCliProvider) ResetSession(sessionKey string) {
+	if sessionKey != "" {
+		p.setSession(sessionKey, "")
+	}
+}
+
+// messagesSinceLastAssistant returns the messages after the last assistant
+// message, i.e. the new input of a resumed conversation.
+func messagesSinceLastAssistant(messages []Message) []Message {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "assistant" {
+			return messages[i+1:]
+		}
+	}
+	return messages
+}
+
+func newCliRunID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// cliRun is one claude CLI process, which may span several Chat calls when
+// the model uses tools.
+type cliRun struct {
+	id         string
+	provider   *ClaudeCliProvider
+	bridge     *cliMCPBridge
+	tools      []ToolDefinition
+	sessionKey string
+	cmd        *exec.Cmd
+	stderr     bytes.Buffer
+	events     chan cliEvent
+	done       chan struct{}
+	closeOnce  sync.Once
+	idle       *time.Timer
+
+	// Guarded by provider.mu.
+	calls map[string]*cliToolCall
+
+	// Only touched by the goroutine currently in step.
+	queued    []*cliToolCall
+	announced int
+	returned  int
+
+	callMu sync.Mutex
+	nextID int
+}
+
+// cliEvent is one thing that happened in a run: assistant output, a tool
+// call arriving at the bridge, the final result or a failure.
+type cliEvent struct {
+	text      string
+	toolUses  int
+	usage     *UsageInfo
+	call      *cliToolCall
+	result    *LLMResponse
+	sessionID string
+	err       error
+}
+
+type cliToolCall struct {
+	id     string
+	name   string
+	args   map[string]interface{}
+	result chan string
+}
+
+// callTool is invoked by the MCP bridge and blocks until the agent loop
+// sends the tool result back through Chat.
+func (r *cliRun) callTool(ctx context.Context, name string, args map[string]interface{}) (string, bool) {
+	r.callMu.Lock()
+	r.nextID++
+	call := &cliToolCall{
+		id:     fmt.Sprintf("call_cli_%s_%d", r.id[:8], r.nextID),
+		name:   name,
+		args:   args,
+		result: make(chan string, 1),
+	}
+	r.callMu.Unlock()
+
+	select {
+	case r.events <- cliEvent{call: call}:
+	case <-r.done:
+		return "run finished before the tool call was handled", false
+	}
+
+	select {
+	case result := <-call.result:
+		return result, true
+	case <-r.done:
+		return "run finished before the tool call was handled", false
+	case <-ctx.Done():
+		return ctx.Err().Error(), false
+	}
+}
+
+// readOutput turns the CLI's stream-json output into events.
+func (r *cliRun) readOutput(stdout io.Reader) {
+	reader := bufio.NewReader(stdout)
+	gotResult := false
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 && !gotResult {
+			ev, ok := r.parseEvent(line)
+			if ok {
+				gotResult = ev.result != nil || ev.err != nil
+				r.emit(ev)
+			}
+		}
+		if readErr != nil {
+			break
+		}
+	}
+
+	waitErr := r.cmd.Wait()
+	if gotResult {
+		return
+	}
+	if stderrStr := r.stderr.String(); stderrStr != "" {
+		r.emit(cliEvent{err: fmt.Errorf("claude cli error: %s", stderrStr)})
+	} else if waitErr != nil {
+		r.emit(cliEvent{err: fmt.Errorf("claude cli error: %w", waitErr)})
+	} else {
+		r.emit(cliEvent{err: fmt.Errorf("claude cli error: no result in output")})
+	}
+}
+
+func (r *cliRun) emit(ev cliEvent) {
+	select {
+	case r.events <- ev:
+	case <-r.done:
+	}
+}
+
+// parseEvent parses one stream-json line. ok is false for lines that carry
+// nothing of interest (init, tool results echoed back, and so on).
+func (r *cliRun) parseEvent(line []byte) (cliEvent, bool) {
+	var head struct {
+		Type    string `json:"type"`
+		Message struct {
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+				Name string `json:"name"`
+			} `json:"content"`
+			Usage *claudeCliUsageInfo `json:"usage"`
+		} `json:"message"`
+		SessionID string `json:"session_id"`
+	}
+	if err := json.Unmarshal(line, &head); err != nil {
+		return cliEvent{err: fmt.Errorf("failed to parse claude cli response: %w", err)}, true
+	}
+
+	switch head.Type {
+	case "assistant":
+		var ev cliEvent
+		for _, block := range head.Message.Content {
+			switch block.Type {
+			case "text":
+				ev.text += block.Text
+			case "tool_use":
+				if strings.HasPrefix(block.Name, "mcp__"+claudeCliMCPServer+"__") {
+					ev.toolUses++
+				}
+			}
+		}
+		if head.Message.Usage != nil {
+			ev.usage = head.Message.Usage.toUsageInfo(0)
+		}
+		return ev, true
+	case "result":
+		resp, err := r.provider.parseClaudeCliResponse(string(line))
+		return cliEvent{result: resp, sessionID: head.SessionID, err: err}, true
+	}
+	return cliEvent{}, false
+}
+
+// close ends the run: the process is killed if still running and pending
+// tool calls are released.
+func (r *cliRun) close() {
+	r.closeOnce.Do(func() {
+		close(r.done)
+		if r.idle != nil {
+			r.idle.Stop()
+		}
+		if r.bridge != nil {
+			r.bridge.unregister(r)
+		}
+		if r.cmd != nil && r.cmd.Process != nil {
+			r.cmd.Process.Kill()
+		}
+		r.provider.mu.Lock()
+		for id := range r.calls {
+			delete(r.provider.pending, id)
+		}
+		r.provider.mu.Unlock()
+	})
 }
 
 // GetDefaultModel returns the default model identifier.
@@ -157,11 +597,7 @@ func (p *ClaudeCliProvider) parseClaudeCliResponse(output string) (*LLMResponse,
 
 	var usage *UsageInfo
 	if resp.Usage.InputTokens > 0 || resp.Usage.OutputTokens > 0 {
-		usage = &UsageInfo{
-			PromptTokens:     resp.Usage.InputTokens + resp.Usage.CacheCreationInputTokens + resp.Usage.CacheReadInputTokens,
-			CompletionTokens: resp.Usage.OutputTokens,
-			TotalTokens:      resp.Usage.InputTokens + resp.Usage.CacheCreationInputTokens + resp.Usage.CacheReadInputTokens + resp.Usage.OutputTokens,
-		}
+		usage = resp.Usage.toUsageInfo(resp.TotalCostUSD)
 	}
 
 	return &LLMResponse{
@@ -427,3 +863,15 @@ type claudeCliUsageInfo struct {
 	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
 	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
 }
+
+func (u *claudeCliUsageInfo) toUsageInfo(costUSD float64) *UsageInfo {
+	prompt := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return &UsageInfo{
+		PromptTokens:        prompt,
+		CompletionTokens:    u.OutputTokens,
+		TotalTokens:         prompt + u.OutputTokens,
+		CacheReadTokens:     u.CacheReadInputTokens,
+		CacheCreationTokens: u.CacheCreationInputTokens,
+		CostUSD:             costUSD,
+	}
+}
@@ -1,8 +1,11 @@
 package providers
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -73,7 +76,8 @@ func createSlowMockCLI(t *testing.T, sleepSeconds int) string {
 	return script
 }
 
-// createArgCaptureCLI creates a script that captures CLI args to a file, then outputs JSON.
+// createArgCaptureCLI creates a script that captures CLI args to a file, one
+// per line, then outputs JSON.
 func createArgCaptureCLI(t *testing.T, argsFile string) string {
 	t.Helper()
 	if runtime.GOOS == "windows" {
@@ -83,7 +87,7 @@ func createArgCaptureCLI(t *testing.T, argsFile string) string {
 	dir := t.TempDir()
 	script := filepath.Join(dir, "claude")
 	content := fmt.Sprintf(`#!/bin/sh
-echo "$@" > '%s'
+printf '%%s\n' "$@" > '%s'
 cat <<'EOFMOCK'
 {"type":"result","result":"ok","session_id":"test"}
 EOFMOCK
@@ -393,6 +397,36 @@ func TestChat_SkipsModelFlagForEmptyModel(t *testing.T) {
 	}
 }
 
+func TestChat_DisablesBuiltinTools(t *testing.T) {
+	argsFile := filepath.Join(t.TempDir(), "args.txt")
+	script := createArgCaptureCLI(t, argsFile)
+
+	p := NewClaudeCliProvider(t.TempDir())
+	p.command = script
+
+	_, err := p.Chat(context.Background(), []Message{
+		{Role: "user", Content: "Hi"},
+	}, nil, "", nil)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	argsBytes, _ := os.ReadFile(argsFile)
+	args := strings.Split(strings.TrimSuffix(string(argsBytes), "\n"), "\n")
+	flags := map[string]string{}
+	for i := 0; i+1 < len(args); i++ {
+		if strings.HasPrefix(args[i], "--") {
+			flags[args[i]] = args[i+1]
+		}
+	}
+	if v, ok := flags["--tools"]; !ok || v != "" {
+		t.Errorf("CLI args should disable built-in tools with --tools \"\", got: %q", args)
+	}
+	if v := flags["--allowedTools"]; v != "mcp__summer__*" {
+		t.Errorf("--allowedTools = %q, want mcp__summer__*", v)
+	}
+}
+
 func TestChat_EmptyWorkspaceDoesNotSetDir(t *testing.T) {
 	mockJSON := `{"type":"result","result":"ok","session_id":"s"}`
 	script := createMockCLI(t, mockJSON, "", 0)
@@ -1039,3 +1073,229 @@ func TestFindMatchingBrace(t *testing.T) {
 		}
 	}
 }
+
+// --- stream-json / MCP bridge tests ---
+
+// createHelperCLI creates a script that re-executes the test binary as a fake
+// claude CLI speaking stream-json and calling tools over MCP; see
+// TestClaudeCliHelperProcess. Each invocation's args are appended to argsFile.
+func createHelperCLI(t *testing.T, argsFile string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("mock CLI scripts not supported on Windows")
+	}
+	t.Setenv("SUMMER_CLAUDE_CLI_HELPER", argsFile)
+
+	script := filepath.Join(t.TempDir(), "claude")
+	content := fmt.Sprintf("#!/bin/sh\nexec '%s' -test.run=TestClaudeCliHelperProcess -- \"$@\"\n", os.Args[0])
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return script
+}
+
+func TestClaudeCliHelperProcess(t *testing.T) {
+	argsFile := os.Getenv("SUMMER_CLAUDE_CLI_HELPER")
+	if argsFile == "" {
+		return
+	}
+	defer os.Exit(0)
+
+	args := os.Args
+	for i, a := range args {
+		if a == "--" {
+			args = args[i+1:]
+			break
+		}
+	}
+	f, _ := os.OpenFile(argsFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	fmt.Fprintln(f, strings.Join(args, " "))
+	f.Close()
+
+	var mcpURL string
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == "--mcp-config" {
+			var cfg struct {
+				MCPServers map[string]struct {
+					URL string `json:"url"`
+				} `json:"mcpServers"`
+			}
+			json.Unmarshal([]byte(args[i+1]), &cfg)
+			mcpURL = cfg.MCPServers["summer"].URL
+		}
+	}
+
+	var input struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	}
+	json.NewDecoder(os.Stdin).Decode(&input)
+
+	emit := func(v interface{}) {
+		data, _ := json.Marshal(v)
+		fmt.Println(string(data))
+	}
+	emit(map[string]interface{}{"type": "system", "subtype": "init", "session_id": "sess_helper"})
+
+	result := "echo: " + input.Message.Content
+	if mcpURL != "" {
+		rpc := func(method string, params interface{}) map[string]interface{} {
+			body, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+			resp, err := http.Post(mcpURL, "application/json", bytes.NewReader(body))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			defer resp.Body.Close()
+			var out map[string]interface{}
+			json.NewDecoder(resp.Body).Decode(&out)
+			return out
+		}
+		rpc("initialize", map[string]interface{}{"protocolVersion": "2025-06-18"})
+		tools := rpc("tools/list", nil)["result"].(map[string]interface{})["tools"].([]interface{})
+		name := tools[0].(map[string]interface{})["name"].(string)
+
+		emit(map[string]interface{}{"type": "assistant", "message": map[string]interface{}{
+			"content": []interface{}{
+				map[string]interface{}{"type": "text", "text": "Let me look."},
+				map[string]interface{}{"type": "tool_use", "id": "toolu_1", "name": "mcp__summer__" + name, "input": map[string]interface{}{"path": "a.txt"}},
+			},
+		}})
+		call := rpc("tools/call", map[string]interface{}{"name": name, "arguments": map[string]interface{}{"path": "a.txt"}})
+		content := call["result"].(map[string]interface{})["content"].([]interface{})
+		result = "File says: " + content[0].(map[string]interface{})["text"].(string)
+	}
+
+	emit(map[string]interface{}{
+		"type": "result", "subtype": "success", "is_error": false, "result": result,
+		"session_id": "sess_helper", "total_cost_usd": 0.0123,
+		"usage": map[string]interface{}{"input_tokens": 10, "output_tokens": 5, "cache_read_input_tokens": 200},
+	})
+}
+
+func TestChat_MCPToolRoundTrip(t *testing.T) {
+	argsFile := filepath.Join(t.TempDir(), "args.txt")
+	p := NewClaudeCliProvider(t.TempDir())
+	p.command = createHelperCLI(t, argsFile)
+
+	tools := []ToolDefinition{{
+		Type: "function",
+		Function: ToolFunctionDefinition{
+			Name:       "read_file",
+			Parameters: map[string]interface{}{"type": "object"},
+		},
+	}}
+	messages := []Message{{Role: "user", Content: "What is in a.txt?"}}
+
+	resp, err := p.Chat(context.Background(), messages, tools, "", nil)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if resp.FinishReason != "tool_calls" || len(resp.ToolCalls) != 1 {
+		t.Fatalf("resp = %+v, want one tool call", resp)
+	}
+	tc := resp.ToolCalls[0]
+	if tc.Name != "read_file" || tc.Arguments["path"] != "a.txt" {
+		t.Errorf("tool call = %+v", tc)
+	}
+	if resp.Content != "Let me look." {
+		t.Errorf("Content = %q, want %q", resp.Content, "Let me look.")
+	}
+
+	messages = append(messages,
+		Message{Role: "assistant", Content: resp.Content, ToolCalls: resp.ToolCalls},
+		Message{Role: "tool", Content: "hello world", ToolCallID: tc.ID},
+	)
+	resp, err = p.Chat(context.Background(), messages, tools, "", nil)
+	if err != nil {
+		t.Fatalf("second Chat() error = %v", err)
+	}
+	if resp.Content != "File says: hello world" {
+		t.Errorf("Content = %q, want %q", resp.Content, "File says: hello world")
+	}
+	if resp.Usage == nil || resp.Usage.CostUSD != 0.0123 || resp.Usage.CacheReadTokens != 200 {
+		t.Errorf("Usage = %+v, want cost and cache read tokens", resp.Usage)
+	}
+
+	args, _ := os.ReadFile(argsFile)
+	if n := strings.Count(string(args), "\n"); n != 1 {
+		t.Errorf("CLI started %d times, want 1 for the whole tool turn", n)
+	}
+	if !strings.Contains(string(args), "--input-format stream-json") {
+		t.Errorf("CLI args missing stream-json input, got: %s", args)
+	}
+	if strings.Contains(string(args), "Available Tools") {
+		t.Error("tools should go through MCP, not the system prompt")
+	}
+}
+
+func TestChat_ResumesSession(t *testing.T) {
+	argsFile := filepath.Join(t.TempDir(), "args.txt")
+	p := NewClaudeCliProvider(t.TempDir())
+	p.command = createHelperCLI(t, argsFile)
+	opts := map[string]interface{}{"session_key": "telegram:42"}
+
+	messages := []Message{{Role: "user", Content: "first"}}
+	resp, err := p.Chat(context.Background(), messages, nil, "", opts)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	messages = append(messages,
+		Message{Role: "assistant", Content: resp.Content},
+		Message{Role: "user", Content: "second"},
+	)
+	resp, err = p.Chat(context.Background(), messages, nil, "", opts)
+	if err != nil {
+		t.Fatalf("second Chat() error = %v", err)
+	}
+	if resp.Content != "echo: second" {
+		t.Errorf("Content = %q, want only the new message to be sent", resp.Content)
+	}
+
+	lines := strings.Split(strings.TrimSpace(readFile(t, argsFile)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("CLI runs = %d, want 2", len(lines))
+	}
+	if strings.Contains(lines[0], "--resume") {
+		t.Error("first turn should not resume a session")
+	}
+	if !strings.Contains(lines[1], "--resume sess_helper") {
+		t.Errorf("second turn should resume the CLI session, got: %s", lines[1])
+	}
+}
+
+func TestClaudeCliProvider_ResetSession(t *testing.T) {
+	argsFile := filepath.Join(t.TempDir(), "args.txt")
+	p := NewClaudeCliProvider(t.TempDir())
+	p.command = createHelperCLI(t, argsFile)
+	opts := map[string]interface{}{"session_key": "telegram:42"}
+
+	messages := []Message{{Role: "user", Content: "first"}}
+	if _, err := p.Chat(context.Background(), messages, nil, "", opts); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	var resetter SessionResetter = p
+	resetter.ResetSession("telegram:42")
+	if id := p.sessionFor("telegram:42"); id != "" {
+		t.Errorf("session = %q after reset, want none", id)
+	}
+
+	if _, err := p.Chat(context.Background(), []Message{{Role: "user", Content: "again"}}, nil, "", opts); err != nil {
+		t.Fatalf("second Chat() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(readFile(t, argsFile)), "\n")
+	if len(lines) != 2 || strings.Contains(lines[1], "--resume") {
+		t.Errorf("turn after reset should start a new CLI session, got: %q", lines)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
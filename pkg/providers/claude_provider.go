@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
@@ -49,7 +50,15 @@ func (p *ClaudeProvider) Chat(ctx context.Context, messages []Message, tools []T
 		return nil, fmt.Errorf("claude API call: %w", err)
 	}
 
-	return parseClaudeResponse(resp), nil
+	result := parseClaudeResponse(resp)
+	if rf := responseFormatOption(options); rf != nil {
+		result = rf.unwrapToolCall(result)
+	}
+	return result, nil
+}
+
+func (p *ClaudeProvider) supportsResponseFormat() bool {
+	return true
 }
 
 func (p *ClaudeProvider) GetDefaultModel() string {
@@ -76,7 +85,9 @@ func buildClaudeParams(messages []Message, tools []ToolDefinition, model string,
 			}
 		case "assistant":
 			if len(msg.ToolCalls) > 0 {
-				var blocks []anthropic.ContentBlockParamUnion
+				// With extended thinking the API requires the thinking blocks of
+				// a tool-calling turn to come back first and unchanged.
+				blocks := claudeThinkingBlocks(msg.ReasoningBlocks)
 				if msg.Content != "" {
 					blocks = append(blocks, anthropic.NewTextBlock(msg.Content))
 				}
@@ -108,13 +119,50 @@ func buildClaudeParams(messages []Message, tools []ToolDefinition, model string,
 	}
 
 	if len(system) > 0 {
+		// The first system block carries the stable part of the prompt
+		// (identity, bootstrap files, skills, memory); later blocks hold
+		// per-turn context and stay outside the cached prefix.
+		system[0].CacheControl = anthropic.NewCacheControlEphemeralParam()
 		params.System = system
 	}
 
-	if temp, ok := options["temperature"].(float64); ok {
+	// Mark the end of the conversation so the next tool iteration can reuse
+	// everything sent so far.
+	if n := len(anthropicMessages); n > 0 {
+		if blocks := anthropicMessages[n-1].Content; len(blocks) > 0 {
+			if cc := blocks[len(blocks)-1].GetCacheControl(); cc != nil {
+				*cc = anthropic.NewCacheControlEphemeralParam()
+			}
+		}
+	}
+
+	// Structured output forces a call to a tool carrying the schema. Forced
+	// tool use cannot be combined with extended thinking.
+	rf := responseFormatOption(options)
+
+	budget, _ := options["thinking_budget"].(int)
+	if budget > 0 && rf == nil {
+		// The API rejects budgets below 1024 and requires max_tokens to
+		// leave room for the answer after thinking.
+		if budget < 1024 {
+			budget = 1024
+		}
+		if params.MaxTokens <= int64(budget) {
+			params.MaxTokens += int64(budget)
+		}
+		params.Thinking = anthropic.ThinkingConfigParamOfEnabled(int64(budget))
+	}
+
+	// Temperature cannot be changed while thinking is enabled.
+	if temp, ok := options["temperature"].(float64); ok && params.Thinking.OfEnabled == nil {
 		params.Temperature = anthropic.Float(temp)
 	}
 
+	if rf != nil {
+		tools = append(tools[:len(tools):len(tools)], rf.toolDefinition())
+		params.ToolChoice = anthropic.ToolChoiceParamOfTool(rf.Name)
+	}
+
 	if len(tools) > 0 {
 		params.Tools = translateToolsForClaude(tools)
 	}
@@ -122,6 +170,21 @@ func buildClaudeParams(messages []Message, tools []ToolDefinition, model string,
 	return params, nil
 }
 
+// claudeThinkingBlocks converts stored reasoning back into Anthropic thinking
+// blocks, skipping reasoning that came from other providers.
+func claudeThinkingBlocks(reasoning []ReasoningBlock) []anthropic.ContentBlockParamUnion {
+	var blocks []anthropic.ContentBlockParamUnion
+	for _, rb := range reasoning {
+		switch rb.Type {
+		case "thinking":
+			blocks = append(blocks, anthropic.NewThinkingBlock(rb.Signature, rb.Text))
+		case "redacted_thinking":
+			blocks = append(blocks, anthropic.NewRedactedThinkingBlock(rb.Data))
+		}
+	}
+	return blocks
+}
+
 func translateToolsForClaude(tools []ToolDefinition) []anthropic.ToolUnionParam {
 	result := make([]anthropic.ToolUnionParam, 0, len(tools))
 	for _, t := range tools {
@@ -145,18 +208,38 @@ func translateToolsForClaude(tools []ToolDefinition) []anthropic.ToolUnionParam
 		}
 		result = append(result, anthropic.ToolUnionParam{OfTool: &tool})
 	}
+	// Tools are rendered before the system prompt, so a breakpoint on the
+	// last tool caches the whole tool list.
+	if len(result) > 0 {
+		result[len(result)-1].OfTool.CacheControl = anthropic.NewCacheControlEphemeralParam()
+	}
 	return result
 }
 
 func parseClaudeResponse(resp *anthropic.Message) *LLMResponse {
 	var content string
 	var toolCalls []ToolCall
+	var reasoning []string
+	var reasoningBlocks []ReasoningBlock
 
 	for _, block := range resp.Content {
 		switch block.Type {
 		case "text":
 			tb := block.AsText()
 			content += tb.Text
+		case "thinking":
+			th := block.AsThinking()
+			reasoning = append(reasoning, th.Thinking)
+			reasoningBlocks = append(reasoningBlocks, ReasoningBlock{
+				Type:      "thinking",
+				Text:      th.Thinking,
+				Signature: th.Signature,
+			})
+		case "redacted_thinking":
+			reasoningBlocks = append(reasoningBlocks, ReasoningBlock{
+				Type: "redacted_thinking",
+				Data: block.AsRedactedThinking().Data,
+			})
 		case "tool_use":
 			tu := block.AsToolUse()
 			var args map[string]interface{}
@@ -182,14 +265,26 @@ func parseClaudeResponse(resp *anthropic.Message) *LLMResponse {
 	}
 
 	return &LLMResponse{
-		Content:      content,
-		ToolCalls:    toolCalls,
-		FinishReason: finishReason,
-		Usage: &UsageInfo{
-			PromptTokens:     int(resp.Usage.InputTokens),
-			CompletionTokens: int(resp.Usage.OutputTokens),
-			TotalTokens:      int(resp.Usage.InputTokens + resp.Usage.OutputTokens),
-		},
+		Content:         content,
+		ToolCalls:       toolCalls,
+		FinishReason:    finishReason,
+		Usage:           parseClaudeUsage(resp.Usage),
+		Reasoning:       strings.Join(reasoning, "\n\n"),
+		ReasoningBlocks: reasoningBlocks,
+	}
+}
+
+// parseClaudeUsage converts Anthropic usage into UsageInfo. Anthropic reports
+// cached input separately from input_tokens, so PromptTokens is the sum of all
+// three to match the OpenAI-style meaning of prompt_tokens.
+func parseClaudeUsage(u anthropic.Usage) *UsageInfo {
+	prompt := int(u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens)
+	return &UsageInfo{
+		PromptTokens:        prompt,
+		CompletionTokens:    int(u.OutputTokens),
+		TotalTokens:         prompt + int(u.OutputTokens),
+		CacheReadTokens:     int(u.CacheReadInputTokens),
+		CacheCreationTokens: int(u.CacheCreationInputTokens),
 	}
 }
 
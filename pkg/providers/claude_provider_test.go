@@ -102,6 +102,172 @@ func TestBuildClaudeParams_WithTools(t *testing.T) {
 	}
 }
 
+func TestBuildClaudeParams_CacheBreakpoints(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: "stable prompt"},
+		{Role: "system", Content: "current time"},
+		{Role: "user", Content: "Hi"},
+	}
+	tools := []ToolDefinition{
+		{Type: "function", Function: ToolFunctionDefinition{Name: "a", Parameters: map[string]interface{}{}}},
+		{Type: "function", Function: ToolFunctionDefinition{Name: "b", Parameters: map[string]interface{}{}}},
+	}
+	params, err := buildClaudeParams(messages, tools, "claude-sonnet-4-5-20250929", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("buildClaudeParams() error: %v", err)
+	}
+
+	if params.System[0].CacheControl.Type != "ephemeral" {
+		t.Error("System[0] should carry a cache breakpoint")
+	}
+	if params.System[1].CacheControl.Type != "" {
+		t.Error("System[1] holds per-turn context and should not be cached")
+	}
+	if params.Tools[0].OfTool.CacheControl.Type != "" {
+		t.Error("only the last tool should carry a cache breakpoint")
+	}
+	if params.Tools[1].OfTool.CacheControl.Type != "ephemeral" {
+		t.Error("last tool should carry a cache breakpoint")
+	}
+	last := params.Messages[len(params.Messages)-1].Content
+	if cc := last[len(last)-1].GetCacheControl(); cc == nil || cc.Type != "ephemeral" {
+		t.Error("last message block should carry a cache breakpoint")
+	}
+}
+
+func TestParseClaudeResponse_CacheUsage(t *testing.T) {
+	resp := &anthropic.Message{
+		Usage: anthropic.Usage{
+			InputTokens:              10,
+			CacheCreationInputTokens: 200,
+			CacheReadInputTokens:     1000,
+			OutputTokens:             5,
+		},
+	}
+	usage := parseClaudeResponse(resp).Usage
+	if usage.PromptTokens != 1210 {
+		t.Errorf("PromptTokens = %d, want 1210", usage.PromptTokens)
+	}
+	if usage.CacheReadTokens != 1000 {
+		t.Errorf("CacheReadTokens = %d, want 1000", usage.CacheReadTokens)
+	}
+	if usage.CacheCreationTokens != 200 {
+		t.Errorf("CacheCreationTokens = %d, want 200", usage.CacheCreationTokens)
+	}
+	if usage.CacheMissTokens() != 210 {
+		t.Errorf("CacheMissTokens() = %d, want 210", usage.CacheMissTokens())
+	}
+	if usage.TotalTokens != 1215 {
+		t.Errorf("TotalTokens = %d, want 1215", usage.TotalTokens)
+	}
+}
+
+func TestBuildClaudeParams_Thinking(t *testing.T) {
+	messages := []Message{{Role: "user", Content: "Hi"}}
+	params, err := buildClaudeParams(messages, nil, "claude-sonnet-4-5-20250929", map[string]interface{}{
+		"max_tokens":      1000,
+		"temperature":     0.7,
+		"thinking_budget": 2048,
+	})
+	if err != nil {
+		t.Fatalf("buildClaudeParams() error: %v", err)
+	}
+	if params.Thinking.OfEnabled == nil || params.Thinking.OfEnabled.BudgetTokens != 2048 {
+		t.Fatalf("Thinking = %+v, want enabled with budget 2048", params.Thinking)
+	}
+	if params.MaxTokens <= 2048 {
+		t.Errorf("MaxTokens = %d, want more than the thinking budget", params.MaxTokens)
+	}
+	if params.Temperature.Valid() {
+		t.Error("Temperature should not be set while thinking is enabled")
+	}
+}
+
+func TestBuildClaudeParams_ReplaysThinkingBlocks(t *testing.T) {
+	messages := []Message{
+		{Role: "user", Content: "What's the weather?"},
+		{
+			Role: "assistant",
+			ToolCalls: []ToolCall{
+				{ID: "call_1", Name: "get_weather", Arguments: map[string]interface{}{"city": "SF"}},
+			},
+			ReasoningBlocks: []ReasoningBlock{
+				{Type: "thinking", Text: "need weather", Signature: "sig"},
+				{Type: "reasoning_content", Text: "from another provider"},
+			},
+		},
+		{Role: "tool", Content: "sunny", ToolCallID: "call_1"},
+	}
+	params, err := buildClaudeParams(messages, nil, "claude-sonnet-4-5-20250929", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("buildClaudeParams() error: %v", err)
+	}
+	blocks := params.Messages[1].Content
+	if len(blocks) != 2 {
+		t.Fatalf("len(assistant blocks) = %d, want 2", len(blocks))
+	}
+	if blocks[0].OfThinking == nil || blocks[0].OfThinking.Signature != "sig" {
+		t.Errorf("first block = %+v, want thinking block with signature", blocks[0])
+	}
+	if blocks[1].OfToolUse == nil {
+		t.Error("second block should be the tool use")
+	}
+}
+
+func TestParseClaudeResponse_Thinking(t *testing.T) {
+	var resp anthropic.Message
+	body := `{"content":[
+		{"type":"thinking","thinking":"Let me think.","signature":"sig"},
+		{"type":"redacted_thinking","data":"opaque"},
+		{"type":"text","text":"Answer"}
+	],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	result := parseClaudeResponse(&resp)
+	if result.Content != "Answer" {
+		t.Errorf("Content = %q, want %q", result.Content, "Answer")
+	}
+	if result.Reasoning != "Let me think." {
+		t.Errorf("Reasoning = %q, want %q", result.Reasoning, "Let me think.")
+	}
+	if len(result.ReasoningBlocks) != 2 {
+		t.Fatalf("len(ReasoningBlocks) = %d, want 2", len(result.ReasoningBlocks))
+	}
+	if result.ReasoningBlocks[0].Signature != "sig" || result.ReasoningBlocks[1].Data != "opaque" {
+		t.Errorf("ReasoningBlocks = %+v", result.ReasoningBlocks)
+	}
+}
+
+func TestBuildClaudeParams_ResponseFormat(t *testing.T) {
+	rf := &ResponseFormat{Name: "answer", Schema: map[string]interface{}{"type": "object"}}
+	messages := []Message{{Role: "user", Content: "Hi"}}
+	params, err := buildClaudeParams(messages, nil, "claude-sonnet-4-5-20250929", map[string]interface{}{
+		"response_format": rf,
+		"thinking_budget": 2048,
+	})
+	if err != nil {
+		t.Fatalf("buildClaudeParams() error: %v", err)
+	}
+	if len(params.Tools) != 1 || params.Tools[0].OfTool.Name != "answer" {
+		t.Fatalf("Tools = %+v, want the response format tool", params.Tools)
+	}
+	if params.ToolChoice.OfTool == nil || params.ToolChoice.OfTool.Name != "answer" {
+		t.Error("ToolChoice should force the response format tool")
+	}
+	if params.Thinking.OfEnabled != nil {
+		t.Error("thinking should be disabled while a tool call is forced")
+	}
+
+	resp := rf.unwrapToolCall(&LLMResponse{
+		ToolCalls:    []ToolCall{{ID: "t1", Name: "answer", Arguments: map[string]interface{}{"ok": true}}},
+		FinishReason: "tool_calls",
+	})
+	if resp.Content != `{"ok":true}` || len(resp.ToolCalls) != 0 || resp.FinishReason != "stop" {
+		t.Errorf("unwrapToolCall() = %+v", resp)
+	}
+}
+
 func TestParseClaudeResponse_TextOnly(t *testing.T) {
 	resp := &anthropic.Message{
 		Content: []anthropic.ContentBlockUnion{},
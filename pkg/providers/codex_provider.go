@@ -9,6 +9,7 @@ import (
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
 	"github.com/openai/openai-go/v3/responses"
+	"github.com/openai/openai-go/v3/shared"
 	"github.com/srikesh3005/summer/pkg/auth"
 )
 
@@ -62,18 +63,22 @@ func (p *CodexProvider) Chat(ctx context.Context, messages []Message, tools []To
 	return parseCodexResponse(resp), nil
 }
 
+func (p *CodexProvider) supportsResponseFormat() bool {
+	return true
+}
+
 func (p *CodexProvider) GetDefaultModel() string {
 	return "gpt-4o"
 }
 
 func buildCodexParams(messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) responses.ResponseNewParams {
 	var inputItems responses.ResponseInputParam
-	var instructions string
+	var systemParts []string
 
 	for _, msg := range messages {
 		switch msg.Role {
 		case "system":
-			instructions = msg.Content
+			systemParts = append(systemParts, msg.Content)
 		case "user":
 			if msg.ToolCallID != "" {
 				inputItems = append(inputItems, responses.ResponseInputItemUnionParam{
@@ -100,6 +105,9 @@ func buildCodexParams(messages []Message, tools []ToolDefinition, model string,
 						},
 					})
 				}
+				// Requests are not stored server-side, so reasoning items of a
+				// tool-calling turn have to be replayed from their encrypted form.
+				inputItems = append(inputItems, codexReasoningItems(msg.ReasoningBlocks)...)
 				for _, tc := range msg.ToolCalls {
 					argsJSON, _ := json.Marshal(tc.Arguments)
 					inputItems = append(inputItems, responses.ResponseInputItemUnionParam{
@@ -136,10 +144,16 @@ func buildCodexParams(messages []Message, tools []ToolDefinition, model string,
 		Store: openai.Opt(false),
 	}
 
-	if instructions != "" {
+	if instructions := strings.Join(systemParts, "\n\n"); instructions != "" {
 		params.Instructions = openai.Opt(instructions)
 	}
 
+	// Requests sharing a cache key are routed to the same cache shard, which
+	// keeps the stable instruction prefix warm across turns.
+	if key, ok := options["prompt_cache_key"].(string); ok && key != "" {
+		params.PromptCacheKey = openai.Opt(key)
+	}
+
 	if maxTokens, ok := options["max_tokens"].(int); ok {
 		params.MaxOutputTokens = openai.Opt(int64(maxTokens))
 	}
@@ -148,6 +162,28 @@ func buildCodexParams(messages []Message, tools []ToolDefinition, model string,
 		params.Temperature = openai.Opt(temp)
 	}
 
+	if effort, ok := options["reasoning_effort"].(string); ok && effort != "" {
+		params.Reasoning = shared.ReasoningParam{
+			Effort:  shared.ReasoningEffort(effort),
+			Summary: shared.ReasoningSummaryAuto,
+		}
+		params.Include = []responses.ResponseIncludable{responses.ResponseIncludableReasoningEncryptedContent}
+	}
+
+	if rf := responseFormatOption(options); rf != nil {
+		format := &responses.ResponseFormatTextJSONSchemaConfigParam{
+			Name:   rf.Name,
+			Schema: rf.Schema,
+			Strict: openai.Opt(rf.Strict),
+		}
+		if rf.Description != "" {
+			format.Description = openai.Opt(rf.Description)
+		}
+		params.Text = responses.ResponseTextConfigParam{
+			Format: responses.ResponseFormatTextConfigUnionParam{OfJSONSchema: format},
+		}
+	}
+
 	if len(tools) > 0 {
 		params.Tools = translateToolsForCodex(tools)
 	}
@@ -155,6 +191,29 @@ func buildCodexParams(messages []Message, tools []ToolDefinition, model string,
 	return params
 }
 
+// codexReasoningItems converts stored reasoning back into Responses API
+// reasoning input items, skipping reasoning that came from other providers.
+func codexReasoningItems(reasoning []ReasoningBlock) []responses.ResponseInputItemUnionParam {
+	var items []responses.ResponseInputItemUnionParam
+	for _, rb := range reasoning {
+		if rb.Type != "reasoning" || rb.ID == "" {
+			continue
+		}
+		item := &responses.ResponseReasoningItemParam{
+			ID:      rb.ID,
+			Summary: []responses.ResponseReasoningItemSummaryParam{},
+		}
+		if rb.Text != "" {
+			item.Summary = append(item.Summary, responses.ResponseReasoningItemSummaryParam{Text: rb.Text})
+		}
+		if rb.Data != "" {
+			item.EncryptedContent = openai.Opt(rb.Data)
+		}
+		items = append(items, responses.ResponseInputItemUnionParam{OfReasoning: item})
+	}
+	return items
+}
+
 func translateToolsForCodex(tools []ToolDefinition) []responses.ToolUnionParam {
 	result := make([]responses.ToolUnionParam, 0, len(tools))
 	for _, t := range tools {
@@ -174,9 +233,26 @@ func translateToolsForCodex(tools []ToolDefinition) []responses.ToolUnionParam {
 func parseCodexResponse(resp *responses.Response) *LLMResponse {
 	var content strings.Builder
 	var toolCalls []ToolCall
+	var reasoning []string
+	var reasoningBlocks []ReasoningBlock
 
 	for _, item := range resp.Output {
 		switch item.Type {
+		case "reasoning":
+			var summary []string
+			for _, sp := range item.Summary {
+				summary = append(summary, sp.Text)
+			}
+			text := strings.Join(summary, "\n\n")
+			if text != "" {
+				reasoning = append(reasoning, text)
+			}
+			reasoningBlocks = append(reasoningBlocks, ReasoningBlock{
+				Type: "reasoning",
+				ID:   item.ID,
+				Text: text,
+				Data: item.EncryptedContent,
+			})
 		case "message":
 			for _, c := range item.Content {
 				if c.Type == "output_text" {
@@ -210,14 +286,17 @@ func parseCodexResponse(resp *responses.Response) *LLMResponse {
 			PromptTokens:     int(resp.Usage.InputTokens),
 			CompletionTokens: int(resp.Usage.OutputTokens),
 			TotalTokens:      int(resp.Usage.TotalTokens),
+			CacheReadTokens:  int(resp.Usage.InputTokensDetails.CachedTokens),
 		}
 	}
 
 	return &LLMResponse{
-		Content:      content.String(),
-		ToolCalls:    toolCalls,
-		FinishReason: finishReason,
-		Usage:        usage,
+		Content:         content.String(),
+		ToolCalls:       toolCalls,
+		FinishReason:    finishReason,
+		Usage:           usage,
+		Reasoning:       strings.Join(reasoning, "\n\n"),
+		ReasoningBlocks: reasoningBlocks,
 	}
 }
 
@@ -37,6 +37,23 @@ func TestBuildCodexParams_SystemAsInstructions(t *testing.T) {
 	}
 }
 
+func TestBuildCodexParams_MultipleSystemMessages(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: "stable"},
+		{Role: "system", Content: "per turn"},
+		{Role: "user", Content: "Hi"},
+	}
+	params := buildCodexParams(messages, nil, "gpt-4o", map[string]interface{}{
+		"prompt_cache_key": "telegram:42",
+	})
+	if got := params.Instructions.Or(""); got != "stable\n\nper turn" {
+		t.Errorf("Instructions = %q, want both system messages joined", got)
+	}
+	if got := params.PromptCacheKey.Or(""); got != "telegram:42" {
+		t.Errorf("PromptCacheKey = %q, want %q", got, "telegram:42")
+	}
+}
+
 func TestBuildCodexParams_ToolCallConversation(t *testing.T) {
 	messages := []Message{
 		{Role: "user", Content: "What's the weather?"},
@@ -92,6 +109,20 @@ func TestBuildCodexParams_StoreIsFalse(t *testing.T) {
 	}
 }
 
+func TestBuildCodexParams_ResponseFormat(t *testing.T) {
+	rf := &ResponseFormat{Name: "answer", Schema: map[string]interface{}{"type": "object"}, Strict: true}
+	params := buildCodexParams([]Message{{Role: "user", Content: "Hi"}}, nil, "gpt-4o", map[string]interface{}{
+		"response_format": rf,
+	})
+	format := params.Text.Format.OfJSONSchema
+	if format == nil {
+		t.Fatal("Text.Format should be a json_schema format")
+	}
+	if format.Name != "answer" || !format.Strict.Value {
+		t.Errorf("format = %+v", format)
+	}
+}
+
 func TestParseCodexResponse_TextOutput(t *testing.T) {
 	respJSON := `{
 		"id": "resp_test",
@@ -134,6 +165,70 @@ func TestParseCodexResponse_TextOutput(t *testing.T) {
 	}
 }
 
+func TestParseCodexResponse_Reasoning(t *testing.T) {
+	respJSON := `{
+		"id": "resp_test",
+		"object": "response",
+		"status": "completed",
+		"output": [
+			{
+				"id": "rs_1",
+				"type": "reasoning",
+				"summary": [{"type": "summary_text", "text": "Thinking it over."}],
+				"encrypted_content": "enc"
+			},
+			{
+				"id": "msg_1",
+				"type": "message",
+				"role": "assistant",
+				"status": "completed",
+				"content": [{"type": "output_text", "text": "Done"}]
+			}
+		]
+	}`
+
+	var resp responses.Response
+	if err := json.Unmarshal([]byte(respJSON), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	result := parseCodexResponse(&resp)
+	if result.Reasoning != "Thinking it over." {
+		t.Errorf("Reasoning = %q, want %q", result.Reasoning, "Thinking it over.")
+	}
+	if len(result.ReasoningBlocks) != 1 {
+		t.Fatalf("len(ReasoningBlocks) = %d, want 1", len(result.ReasoningBlocks))
+	}
+	if rb := result.ReasoningBlocks[0]; rb.ID != "rs_1" || rb.Data != "enc" {
+		t.Errorf("ReasoningBlocks[0] = %+v", rb)
+	}
+}
+
+func TestBuildCodexParams_ReplaysReasoning(t *testing.T) {
+	messages := []Message{
+		{Role: "user", Content: "Hi"},
+		{
+			Role: "assistant",
+			ToolCalls: []ToolCall{
+				{ID: "call_1", Name: "get_weather", Arguments: map[string]interface{}{"city": "SF"}},
+			},
+			ReasoningBlocks: []ReasoningBlock{{Type: "reasoning", ID: "rs_1", Data: "enc"}},
+		},
+		{Role: "tool", Content: "sunny", ToolCallID: "call_1"},
+	}
+	params := buildCodexParams(messages, nil, "gpt-5.2", map[string]interface{}{"reasoning_effort": "high"})
+	if params.Reasoning.Effort != "high" {
+		t.Errorf("Reasoning.Effort = %q, want %q", params.Reasoning.Effort, "high")
+	}
+	items := params.Input.OfInputItemList
+	if len(items) != 4 {
+		t.Fatalf("len(Input items) = %d, want 4", len(items))
+	}
+	if items[1].OfReasoning == nil || items[1].OfReasoning.ID != "rs_1" {
+		t.Errorf("item 1 should replay reasoning rs_1, got %+v", items[1])
+	}
+}
+
 func TestParseCodexResponse_FunctionCall(t *testing.T) {
 	respJSON := `{
 		"id": "resp_test",
@@ -50,6 +50,16 @@ func NewHTTPProvider(apiKey, apiBase, proxy string) *HTTPProvider {
 	}
 }
 
+// isOpenAI reports whether requests go to the OpenAI API itself rather than
+// a compatible third-party endpoint.
+func (p *HTTPProvider) isOpenAI() bool {
+	return strings.Contains(p.apiBase, "api.openai.com")
+}
+
+func (p *HTTPProvider) supportsResponseFormat() bool {
+	return p.isOpenAI()
+}
+
 func (p *HTTPProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
 	if p.apiBase == "" {
 		return nil, fmt.Errorf("API base not configured")
@@ -65,7 +75,7 @@ func (p *HTTPProvider) Chat(ctx context.Context, messages []Message, tools []Too
 
 	requestBody := map[string]interface{}{
 		"model":    model,
-		"messages": messages,
+		"messages": toHTTPMessages(mergeLeadingSystemMessages(messages)),
 	}
 
 	if len(tools) > 0 {
@@ -92,6 +102,34 @@ func (p *HTTPProvider) Chat(ctx context.Context, messages []Message, tools []Too
 		}
 	}
 
+	if effort, ok := options["reasoning_effort"].(string); ok && effort != "" {
+		requestBody["reasoning_effort"] = effort
+	}
+
+	// OpenAI caches prompt prefixes automatically; a cache key keeps requests
+	// of one session on the same cache shard. Other compatible APIs may reject
+	// unknown fields, so only send it to OpenAI itself.
+	if key, ok := options["prompt_cache_key"].(string); ok && key != "" && p.isOpenAI() {
+		requestBody["prompt_cache_key"] = key
+	}
+
+	// json_schema support varies across compatible APIs; elsewhere the
+	// ChatStructured fallback validates and repairs the reply instead.
+	if rf := responseFormatOption(options); rf != nil && p.isOpenAI() {
+		jsonSchema := map[string]interface{}{
+			"name":   rf.Name,
+			"schema": rf.Schema,
+			"strict": rf.Strict,
+		}
+		if rf.Description != "" {
+			jsonSchema["description"] = rf.Description
+		}
+		requestBody["response_format"] = map[string]interface{}{
+			"type":        "json_schema",
+			"json_schema": jsonSchema,
+		}
+	}
+
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
@@ -177,7 +215,11 @@ func (p *HTTPProvider) parseResponse(body []byte) (*LLMResponse, error) {
 	var apiResponse struct {
 		Choices []struct {
 			Message struct {
-				Content   string `json:"content"`
+				Content string `json:"content"`
+				// DeepSeek, Moonshot and GLM
+				ReasoningContent string `json:"reasoning_content"`
+				// OpenRouter and Groq
+				Reasoning string `json:"reasoning"`
 				ToolCalls []struct {
 					ID       string `json:"id"`
 					Type     string `json:"type"`
@@ -189,7 +231,7 @@ func (p *HTTPProvider) parseResponse(body []byte) (*LLMResponse, error) {
 			} `json:"message"`
 			FinishReason string `json:"finish_reason"`
 		} `json:"choices"`
-		Usage *UsageInfo `json:"usage"`
+		Usage *httpUsage `json:"usage"`
 	}
 
 	if err := json.Unmarshal(body, &apiResponse); err != nil {
@@ -235,12 +277,102 @@ func (p *HTTPProvider) parseResponse(body []byte) (*LLMResponse, error) {
 		})
 	}
 
-	return &LLMResponse{
+	resp := &LLMResponse{
 		Content:      choice.Message.Content,
 		ToolCalls:    toolCalls,
 		FinishReason: choice.FinishReason,
-		Usage:        apiResponse.Usage,
-	}, nil
+		Usage:        apiResponse.Usage.toUsageInfo(),
+	}
+
+	// Only reasoning_content is echoed back on later requests; the APIs that
+	// return it expect it on tool-calling turns, while others reject the field.
+	if rc := choice.Message.ReasoningContent; rc != "" {
+		resp.Reasoning = rc
+		resp.ReasoningBlocks = []ReasoningBlock{{Type: "reasoning_content", Text: rc}}
+	} else if choice.Message.Reasoning != "" {
+		resp.Reasoning = choice.Message.Reasoning
+	}
+
+	return resp, nil
+}
+
+// httpMessage is the wire form of a Message for OpenAI-compatible APIs.
+type httpMessage struct {
+	Message
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+}
+
+func toHTTPMessages(messages []Message) []httpMessage {
+	out := make([]httpMessage, 0, len(messages))
+	for _, msg := range messages {
+		hm := httpMessage{Message: msg}
+		for _, rb := range msg.ReasoningBlocks {
+			if rb.Type == "reasoning_content" {
+				hm.ReasoningContent += rb.Text
+			}
+		}
+		out = append(out, hm)
+	}
+	return out
+}
+
+// httpUsage is the usage block of an OpenAI-compatible response, including
+// the vendor-specific fields that report prompt cache hits.
+type httpUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	PromptTokensDetails *struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+	// DeepSeek
+	PromptCacheHitTokens int `json:"prompt_cache_hit_tokens"`
+	// Moonshot
+	CachedTokens int `json:"cached_tokens"`
+}
+
+func (u *httpUsage) toUsageInfo() *UsageInfo {
+	if u == nil {
+		return nil
+	}
+	info := &UsageInfo{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+	switch {
+	case u.PromptTokensDetails != nil && u.PromptTokensDetails.CachedTokens > 0:
+		info.CacheReadTokens = u.PromptTokensDetails.CachedTokens
+	case u.PromptCacheHitTokens > 0:
+		info.CacheReadTokens = u.PromptCacheHitTokens
+	case u.CachedTokens > 0:
+		info.CacheReadTokens = u.CachedTokens
+	}
+	return info
+}
+
+// mergeLeadingSystemMessages folds the system messages at the start of the
+// conversation into one, keeping their order. The agent sends the stable
+// prompt and the per-turn context as separate system messages; several
+// OpenAI-compatible servers only accept a single system message, and a
+// single message with the stable part first still forms a cacheable prefix.
+func mergeLeadingSystemMessages(messages []Message) []Message {
+	n := 0
+	for n < len(messages) && messages[n].Role == "system" {
+		n++
+	}
+	if n < 2 {
+		return messages
+	}
+
+	parts := make([]string, 0, n)
+	for _, msg := range messages[:n] {
+		parts = append(parts, msg.Content)
+	}
+
+	merged := make([]Message, 0, len(messages)-n+1)
+	merged = append(merged, Message{Role: "system", Content: strings.Join(parts, "\n\n")})
+	return append(merged, messages[n:]...)
 }
 
 func (p *HTTPProvider) GetDefaultModel() string {
@@ -0,0 +1,133 @@
+package providers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMergeLeadingSystemMessages(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: "stable"},
+		{Role: "system", Content: "per turn"},
+		{Role: "user", Content: "Hi"},
+	}
+	merged := mergeLeadingSystemMessages(messages)
+	if len(merged) != 2 {
+		t.Fatalf("len(merged) = %d, want 2", len(merged))
+	}
+	if merged[0].Role != "system" || merged[0].Content != "stable\n\nper turn" {
+		t.Errorf("merged[0] = %+v, want joined system message", merged[0])
+	}
+	if merged[1].Content != "Hi" {
+		t.Errorf("merged[1].Content = %q, want %q", merged[1].Content, "Hi")
+	}
+
+	single := []Message{{Role: "system", Content: "only"}, {Role: "user", Content: "Hi"}}
+	if got := mergeLeadingSystemMessages(single); len(got) != 2 || got[0].Content != "only" {
+		t.Errorf("single system message should be left unchanged, got %+v", got)
+	}
+}
+
+func TestHTTPProvider_ParseResponse_CachedTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{
+			name: "openai",
+			body: `{"choices":[{"message":{"content":"ok"}}],"usage":{"prompt_tokens":2000,"completion_tokens":5,"total_tokens":2005,"prompt_tokens_details":{"cached_tokens":1536}}}`,
+			want: 1536,
+		},
+		{
+			name: "deepseek",
+			body: `{"choices":[{"message":{"content":"ok"}}],"usage":{"prompt_tokens":2000,"completion_tokens":5,"total_tokens":2005,"prompt_cache_hit_tokens":1800,"prompt_cache_miss_tokens":200}}`,
+			want: 1800,
+		},
+		{
+			name: "no cache",
+			body: `{"choices":[{"message":{"content":"ok"}}],"usage":{"prompt_tokens":2000,"completion_tokens":5,"total_tokens":2005}}`,
+			want: 0,
+		},
+	}
+
+	p := NewHTTPProvider("key", "http://example.invalid", "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := p.parseResponse([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("parseResponse() error: %v", err)
+			}
+			if resp.Usage.PromptTokens != 2000 {
+				t.Errorf("PromptTokens = %d, want 2000", resp.Usage.PromptTokens)
+			}
+			if resp.Usage.CacheReadTokens != tt.want {
+				t.Errorf("CacheReadTokens = %d, want %d", resp.Usage.CacheReadTokens, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPProvider_Chat_PromptCacheKeyOnlyForOpenAI(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	p := NewHTTPProvider("key", server.URL, "")
+	messages := []Message{
+		{Role: "system", Content: "stable"},
+		{Role: "system", Content: "per turn"},
+		{Role: "user", Content: "Hi"},
+	}
+	if _, err := p.Chat(t.Context(), messages, nil, "gpt-4o", map[string]interface{}{"prompt_cache_key": "cli:direct"}); err != nil {
+		t.Fatalf("Chat() error: %v", err)
+	}
+	if _, ok := got["prompt_cache_key"]; ok {
+		t.Error("prompt_cache_key should not be sent to non-OpenAI endpoints")
+	}
+	if msgs, _ := got["messages"].([]interface{}); len(msgs) != 2 {
+		t.Errorf("len(messages) = %d, want 2 after merging system messages", len(msgs))
+	}
+}
+
+func TestHTTPProvider_ReasoningContentRoundTrip(t *testing.T) {
+	var got struct {
+		Messages []map[string]interface{} `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok","reasoning_content":"step by step"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	p := NewHTTPProvider("key", server.URL, "")
+	resp, err := p.Chat(t.Context(), []Message{{Role: "user", Content: "Hi"}}, nil, "deepseek-reasoner", nil)
+	if err != nil {
+		t.Fatalf("Chat() error: %v", err)
+	}
+	if resp.Reasoning != "step by step" {
+		t.Errorf("Reasoning = %q, want %q", resp.Reasoning, "step by step")
+	}
+
+	messages := []Message{
+		{Role: "user", Content: "Hi"},
+		{Role: "assistant", Content: "ok", ReasoningBlocks: resp.ReasoningBlocks},
+		{Role: "user", Content: "Again"},
+	}
+	if _, err := p.Chat(t.Context(), messages, nil, "deepseek-reasoner", nil); err != nil {
+		t.Fatalf("Chat() error: %v", err)
+	}
+	if rc := got.Messages[1]["reasoning_content"]; rc != "step by step" {
+		t.Errorf("assistant reasoning_content = %v, want %q", rc, "step by step")
+	}
+	if _, ok := got.Messages[0]["reasoning_content"]; ok {
+		t.Error("reasoning_content should be omitted on messages without reasoning")
+	}
+}
@@ -0,0 +1,176 @@
+package providers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Fixture is one recorded Chat call as stored on disk by RecordingProvider.
+type Fixture struct {
+	Request  FixtureRequest `json:"request"`
+	Response *LLMResponse   `json:"response"`
+}
+
+// FixtureRequest is the part of a Chat request that identifies it for replay.
+// System messages, tool descriptions and options are left out because they
+// carry the time, temp paths and session keys, which change between runs.
+// Model and Tools are kept for reference only and do not affect the key, so
+// fixtures replay under any model name and survive new tools being added.
+type FixtureRequest struct {
+	Model    string           `json:"model"`
+	Tools    []string         `json:"tools,omitempty"`
+	Messages []FixtureMessage `json:"messages"`
+}
+
+// FixtureMessage is a conversation message in its replay-relevant form.
+type FixtureMessage struct {
+	Role       string            `json:"role"`
+	Content    string            `json:"content,omitempty"`
+	ToolCalls  []FixtureToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string            `json:"tool_call_id,omitempty"`
+}
+
+// FixtureToolCall is a tool call made by the assistant.
+type FixtureToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// newFixtureRequest normalizes a Chat request. scrub, when set, is applied to
+// every message content and tool argument, e.g. to replace a temp directory.
+func newFixtureRequest(messages []Message, tools []ToolDefinition, model string, scrub func(string) string) FixtureRequest {
+	if scrub == nil {
+		scrub = func(s string) string { return s }
+	}
+
+	req := FixtureRequest{Model: model}
+	for _, t := range tools {
+		req.Tools = append(req.Tools, t.Function.Name)
+	}
+	sort.Strings(req.Tools)
+
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			continue
+		}
+		fm := FixtureMessage{
+			Role:       msg.Role,
+			Content:    scrub(msg.Content),
+			ToolCallID: msg.ToolCallID,
+		}
+		for _, tc := range msg.ToolCalls {
+			name, args := tc.Name, ""
+			if tc.Function != nil {
+				name, args = tc.Function.Name, tc.Function.Arguments
+			} else if tc.Arguments != nil {
+				data, _ := json.Marshal(tc.Arguments)
+				args = string(data)
+			}
+			fm.ToolCalls = append(fm.ToolCalls, FixtureToolCall{ID: tc.ID, Name: name, Arguments: scrub(args)})
+		}
+		req.Messages = append(req.Messages, fm)
+	}
+	return req
+}
+
+// Hash returns the fixture key of the request.
+func (r FixtureRequest) Hash() string {
+	data, _ := json.Marshal(r.Messages)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// RecordingProvider wraps a real provider and writes every Chat call to
+// <dir>/<hash>.json so that ReplayProvider can serve it back offline.
+type RecordingProvider struct {
+	inner LLMProvider
+	dir   string
+	mu    sync.Mutex
+	// Scrub is applied to message contents before hashing; see ReplayProvider.
+	Scrub func(string) string
+}
+
+// NewRecordingProvider creates a provider that records inner's responses to dir.
+func NewRecordingProvider(inner LLMProvider, dir string) *RecordingProvider {
+	return &RecordingProvider{inner: inner, dir: dir}
+}
+
+func (p *RecordingProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	resp, err := p.inner.Chat(ctx, messages, tools, model, options)
+	if err != nil {
+		return nil, err
+	}
+
+	req := newFixtureRequest(messages, tools, model, p.Scrub)
+	data, err := json.MarshalIndent(Fixture{Request: req, Response: resp}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fixture: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(p.dir, req.Hash()+".json"), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return resp, nil
+}
+
+func (p *RecordingProvider) GetDefaultModel() string {
+	return p.inner.GetDefaultModel()
+}
+
+// ResetSession passes the reset on to the wrapped provider.
+func (p *RecordingProvider) ResetSession(sessionKey string) {
+	if resetter, ok := p.inner.(SessionResetter); ok {
+		resetter.ResetSession(sessionKey)
+	}
+}
+
+// ReplayProvider serves responses recorded by RecordingProvider, looked up
+// by request hash. A request without a fixture is an error, so tests fail
+// loudly when the agent's behaviour drifts from the recording.
+type ReplayProvider struct {
+	dir string
+	// Scrub must match the function used while recording.
+	Scrub func(string) string
+}
+
+// NewReplayProvider creates a provider that replays fixtures from dir.
+func NewReplayProvider(dir string) *ReplayProvider {
+	return &ReplayProvider{dir: dir}
+}
+
+func (p *ReplayProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	req := newFixtureRequest(messages, tools, model, p.Scrub)
+	path := filepath.Join(p.dir, req.Hash()+".json")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		reqJSON, _ := json.MarshalIndent(req, "", "  ")
+		return nil, fmt.Errorf("no fixture %s for request:\n%s", path, reqJSON)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	if fixture.Response == nil {
+		return nil, fmt.Errorf("fixture %s has no response", path)
+	}
+	return fixture.Response, nil
+}
+
+func (p *ReplayProvider) GetDefaultModel() string {
+	return "replay"
+}
@@ -0,0 +1,80 @@
+package providers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type countingProvider struct {
+	calls int
+}
+
+func (p *countingProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	p.calls++
+	last := messages[len(messages)-1]
+	return &LLMResponse{Content: "echo: " + last.Content, FinishReason: "stop"}, nil
+}
+
+func (p *countingProvider) GetDefaultModel() string { return "counting" }
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	inner := &countingProvider{}
+	rec := NewRecordingProvider(inner, dir)
+	rec.Scrub = func(s string) string { return strings.ReplaceAll(s, "/tmp/run-1", "$TMP") }
+
+	tools := []ToolDefinition{{Type: "function", Function: ToolFunctionDefinition{Name: "read_file"}}}
+	messages := []Message{
+		{Role: "system", Content: "time: 10:00"},
+		{Role: "user", Content: "open /tmp/run-1/a.txt"},
+	}
+	if _, err := rec.Chat(t.Context(), messages, tools, "gpt-4o", nil); err != nil {
+		t.Fatalf("record Chat() error: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("fixtures = %d, want 1", len(files))
+	}
+
+	replay := NewReplayProvider(dir)
+	replay.Scrub = func(s string) string { return strings.ReplaceAll(s, "/tmp/run-2", "$TMP") }
+
+	// A different system prompt, model and temp dir must still hit the fixture.
+	messages = []Message{
+		{Role: "system", Content: "time: 11:30"},
+		{Role: "user", Content: "open /tmp/run-2/a.txt"},
+	}
+	resp, err := replay.Chat(t.Context(), messages, tools, "replay-model", nil)
+	if err != nil {
+		t.Fatalf("replay Chat() error: %v", err)
+	}
+	if resp.Content != "echo: open /tmp/run-1/a.txt" {
+		t.Errorf("Content = %q", resp.Content)
+	}
+	if inner.calls != 1 {
+		t.Errorf("inner calls = %d, want 1", inner.calls)
+	}
+
+	messages[1].Content = "open something else"
+	if _, err := replay.Chat(t.Context(), messages, tools, "replay-model", nil); err == nil {
+		t.Error("replay Chat() should fail for an unrecorded request")
+	}
+}
+
+func TestReplayProvider_ToolCallForms(t *testing.T) {
+	// The agent loop sends tool calls in Function form while responses carry
+	// Name/Arguments; both must normalize to the same fixture key.
+	a := newFixtureRequest([]Message{{
+		Role:      "assistant",
+		ToolCalls: []ToolCall{{ID: "1", Name: "exec", Arguments: map[string]interface{}{"command": "ls"}}},
+	}}, nil, "m", nil)
+	b := newFixtureRequest([]Message{{
+		Role:      "assistant",
+		ToolCalls: []ToolCall{{ID: "1", Type: "function", Function: &FunctionCall{Name: "exec", Arguments: `{"command":"ls"}`}}},
+	}}, nil, "m", nil)
+	if a.Hash() != b.Hash() {
+		t.Error("tool call forms should hash the same")
+	}
+}
@@ -0,0 +1,289 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// maxRepairAttempts is how many times ChatStructured asks the model to fix a
+// reply that does not match the schema.
+const maxRepairAttempts = 2
+
+// ResponseFormat constrains a reply to a JSON object matching Schema. Pass it
+// to Chat as options["response_format"]. Providers that support it natively
+// (OpenAI json_schema, Anthropic tool forcing) enforce it server-side and
+// return the object as Content; ChatStructured works with any provider.
+type ResponseFormat struct {
+	Name        string
+	Description string
+	Schema      map[string]interface{}
+	// Strict enables OpenAI strict mode, which requires every property to be
+	// listed in "required" and additionalProperties to be false.
+	Strict bool
+}
+
+// structuredOutputProvider is implemented by providers that can enforce a
+// ResponseFormat themselves.
+type structuredOutputProvider interface {
+	supportsResponseFormat() bool
+}
+
+// responseFormatOption returns the ResponseFormat passed in options, if any.
+func responseFormatOption(options map[string]interface{}) *ResponseFormat {
+	rf, _ := options["response_format"].(*ResponseFormat)
+	if rf == nil || rf.Name == "" {
+		return nil
+	}
+	return rf
+}
+
+// toolDefinition returns the format as a tool, for providers that implement
+// structured output by forcing a tool call.
+func (rf *ResponseFormat) toolDefinition() ToolDefinition {
+	desc := rf.Description
+	if desc == "" {
+		desc = "Return the final answer as structured data."
+	}
+	return ToolDefinition{
+		Type: "function",
+		Function: ToolFunctionDefinition{
+			Name:        rf.Name,
+			Description: desc,
+			Parameters:  rf.Schema,
+		},
+	}
+}
+
+// unwrapToolCall moves the arguments of the forced tool call into Content so
+// callers see the same shape as with native JSON output.
+func (rf *ResponseFormat) unwrapToolCall(resp *LLMResponse) *LLMResponse {
+	for i, tc := range resp.ToolCalls {
+		if tc.Name != rf.Name {
+			continue
+		}
+		data, err := json.Marshal(tc.Arguments)
+		if err != nil {
+			return resp
+		}
+		resp.Content = string(data)
+		resp.ToolCalls = append(resp.ToolCalls[:i:i], resp.ToolCalls[i+1:]...)
+		if len(resp.ToolCalls) == 0 {
+			resp.FinishReason = "stop"
+		}
+		return resp
+	}
+	return resp
+}
+
+// ChatStructured asks the model for a reply matching format and decodes it
+// into out. When the provider cannot enforce the schema, the schema is added
+// to the prompt and invalid replies are sent back for repair up to
+// maxRepairAttempts times. The last raw response is returned alongside.
+func ChatStructured(ctx context.Context, provider LLMProvider, messages []Message, model string, format *ResponseFormat, options map[string]interface{}, out interface{}) (*LLMResponse, error) {
+	if format == nil || format.Name == "" {
+		return nil, fmt.Errorf("response format name is required")
+	}
+
+	opts := make(map[string]interface{}, len(options)+1)
+	for k, v := range options {
+		opts[k] = v
+	}
+	opts["response_format"] = format
+
+	msgs := append([]Message(nil), messages...)
+	if sp, ok := provider.(structuredOutputProvider); !ok || !sp.supportsResponseFormat() {
+		schemaJSON, _ := json.Marshal(format.Schema)
+		msgs = append(msgs, Message{
+			Role: "system",
+			Content: fmt.Sprintf("Reply with a single JSON object that matches this JSON schema, and nothing else:\n%s",
+				schemaJSON),
+		})
+	}
+
+	var resp *LLMResponse
+	var lastErr error
+	for attempt := 0; attempt <= maxRepairAttempts; attempt++ {
+		var err error
+		resp, err = provider.Chat(ctx, msgs, nil, model, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		raw := extractJSON(resp.Content)
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			lastErr = fmt.Errorf("invalid JSON: %v", err)
+		} else if err := ValidateJSONSchema(value, format.Schema); err != nil {
+			lastErr = err
+		} else {
+			if err := json.Unmarshal([]byte(raw), out); err != nil {
+				return resp, fmt.Errorf("failed to decode structured output: %w", err)
+			}
+			return resp, nil
+		}
+
+		msgs = append(msgs,
+			Message{Role: "assistant", Content: resp.Content},
+			Message{Role: "user", Content: fmt.Sprintf("That reply does not match the required schema (%v). Reply again with only the corrected JSON object.", lastErr)},
+		)
+	}
+
+	return resp, fmt.Errorf("structured output failed after %d attempts: %w", maxRepairAttempts+1, lastErr)
+}
+
+// extractJSON returns the JSON object in content, dropping markdown code
+// fences and any text around the outermost braces.
+func extractJSON(content string) string {
+	s := strings.TrimSpace(content)
+	if strings.HasPrefix(s, "```") {
+		s = strings.TrimPrefix(s, "```json")
+		s = strings.TrimPrefix(s, "```")
+		s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+	}
+	start := strings.Index(s, "{")
+	end := strings.LastIndex(s, "}")
+	if start >= 0 && end > start {
+		return s[start : end+1]
+	}
+	return strings.TrimSpace(s)
+}
+
+// ValidateJSONSchema checks a decoded JSON value against the subset of JSON
+// Schema used for tool parameters and response formats: type, properties,
+// required, additionalProperties (false only), items and enum.
+func ValidateJSONSchema(value interface{}, schema map[string]interface{}) error {
+	return validateSchema(value, schema, "$")
+}
+
+func validateSchema(value interface{}, schema map[string]interface{}, path string) error {
+	if len(schema) == 0 {
+		return nil
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if fmt.Sprint(e) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value %v is not one of %v", path, value, enum)
+		}
+	}
+
+	if t, ok := schema["type"]; ok {
+		var types []string
+		switch tv := t.(type) {
+		case string:
+			types = []string{tv}
+		case []interface{}:
+			for _, x := range tv {
+				if s, ok := x.(string); ok {
+					types = append(types, s)
+				}
+			}
+		case []string:
+			types = tv
+		}
+		if len(types) > 0 && !matchesAnyType(value, types) {
+			return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), jsonTypeName(value))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		props, _ := schema["properties"].(map[string]interface{})
+		for _, name := range stringList(schema["required"]) {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			propSchema, known := props[k].(map[string]interface{})
+			if !known {
+				if ap, ok := schema["additionalProperties"].(bool); ok && !ap {
+					return fmt.Errorf("%s: unexpected property %q", path, k)
+				}
+				continue
+			}
+			if err := validateSchema(v[k], propSchema, path+"."+k); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateSchema(item, items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func matchesAnyType(value interface{}, types []string) bool {
+	for _, t := range types {
+		switch t {
+		case "object", "array", "string", "boolean", "null", "number":
+			if jsonTypeName(value) == t || (t == "number" && jsonTypeName(value) == "integer") {
+				return true
+			}
+		case "integer":
+			if jsonTypeName(value) == "integer" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jsonTypeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func stringList(v interface{}) []string {
+	switch l := v.(type) {
+	case []string:
+		return l
+	case []interface{}:
+		out := make([]string, 0, len(l))
+		for _, x := range l {
+			if s, ok := x.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package providers
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type scriptedProvider struct {
+	replies []string
+	calls   [][]Message
+	native  bool
+}
+
+func (p *scriptedProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	p.calls = append(p.calls, messages)
+	reply := p.replies[0]
+	if len(p.replies) > 1 {
+		p.replies = p.replies[1:]
+	}
+	return &LLMResponse{Content: reply, FinishReason: "stop"}, nil
+}
+
+func (p *scriptedProvider) GetDefaultModel() string { return "test" }
+
+func (p *scriptedProvider) supportsResponseFormat() bool { return p.native }
+
+var reminderFormat = &ResponseFormat{
+	Name: "reminder",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"message":    map[string]interface{}{"type": "string"},
+			"in_minutes": map[string]interface{}{"type": "integer"},
+		},
+		"required":             []interface{}{"message", "in_minutes"},
+		"additionalProperties": false,
+	},
+}
+
+type reminder struct {
+	Message   string `json:"message"`
+	InMinutes int    `json:"in_minutes"`
+}
+
+func TestChatStructured_DecodesFencedJSON(t *testing.T) {
+	p := &scriptedProvider{replies: []string{"Sure!\n```json\n{\"message\": \"stretch\", \"in_minutes\": 30}\n```"}}
+	var got reminder
+	if _, err := ChatStructured(t.Context(), p, []Message{{Role: "user", Content: "remind me"}}, "test", reminderFormat, nil, &got); err != nil {
+		t.Fatalf("ChatStructured() error: %v", err)
+	}
+	if got.Message != "stretch" || got.InMinutes != 30 {
+		t.Errorf("got %+v", got)
+	}
+	last := p.calls[0][len(p.calls[0])-1]
+	if last.Role != "system" || !strings.Contains(last.Content, `"in_minutes"`) {
+		t.Error("schema should be added to the prompt for providers without native support")
+	}
+}
+
+func TestChatStructured_RepairsInvalidReply(t *testing.T) {
+	p := &scriptedProvider{replies: []string{
+		`{"message": "stretch"}`,
+		`{"message": "stretch", "in_minutes": 30}`,
+	}}
+	var got reminder
+	if _, err := ChatStructured(t.Context(), p, []Message{{Role: "user", Content: "remind me"}}, "test", reminderFormat, nil, &got); err != nil {
+		t.Fatalf("ChatStructured() error: %v", err)
+	}
+	if len(p.calls) != 2 {
+		t.Fatalf("calls = %d, want 2", len(p.calls))
+	}
+	repair := p.calls[1][len(p.calls[1])-1]
+	if repair.Role != "user" || !strings.Contains(repair.Content, "in_minutes") {
+		t.Errorf("repair message = %+v, want one naming the missing property", repair)
+	}
+	if got.InMinutes != 30 {
+		t.Errorf("InMinutes = %d, want 30", got.InMinutes)
+	}
+}
+
+func TestChatStructured_GivesUp(t *testing.T) {
+	p := &scriptedProvider{replies: []string{"not json"}, native: true}
+	var got reminder
+	if _, err := ChatStructured(t.Context(), p, nil, "test", reminderFormat, nil, &got); err == nil {
+		t.Fatal("ChatStructured() should fail when the reply never validates")
+	}
+	if len(p.calls) != maxRepairAttempts+1 {
+		t.Errorf("calls = %d, want %d", len(p.calls), maxRepairAttempts+1)
+	}
+	if len(p.calls[0]) != 0 {
+		t.Error("schema prompt should not be added for providers with native support")
+	}
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"level": map[string]interface{}{"type": "string", "enum": []interface{}{"low", "high"}},
+			"tags":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"score": map[string]interface{}{"type": "number"},
+		},
+		"required": []interface{}{"level"},
+	}
+	tests := []struct {
+		name    string
+		value   interface{}
+		wantErr string
+	}{
+		{"valid", map[string]interface{}{"level": "low", "tags": []interface{}{"a"}, "score": 1.5}, ""},
+		{"integer is a number", map[string]interface{}{"level": "high", "score": float64(2)}, ""},
+		{"missing required", map[string]interface{}{}, "missing required"},
+		{"enum", map[string]interface{}{"level": "medium"}, "not one of"},
+		{"item type", map[string]interface{}{"level": "low", "tags": []interface{}{1.0}}, "$.tags[0]"},
+		{"not an object", "low", "expected object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateJSONSchema(tt.value, schema)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
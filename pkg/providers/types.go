@@ -20,12 +20,53 @@ type LLMResponse struct {
 	ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
 	FinishReason string     `json:"finish_reason"`
 	Usage        *UsageInfo `json:"usage,omitempty"`
+	// Reasoning is the human-readable reasoning or reasoning summary, if the
+	// model produced one.
+	Reasoning string `json:"reasoning,omitempty"`
+	// ReasoningBlocks is the provider-native form of the reasoning. Copy it
+	// onto the assistant message of a tool-calling turn so providers that
+	// require it get it back unchanged.
+	ReasoningBlocks []ReasoningBlock `json:"reasoning_blocks,omitempty"`
+}
+
+// ReasoningBlock is one piece of model reasoning in the shape the originating
+// provider expects to receive it back.
+type ReasoningBlock struct {
+	// Type is "thinking" or "redacted_thinking" (Anthropic), "reasoning"
+	// (OpenAI Responses) or "reasoning_content" (OpenAI-compatible APIs such
+	// as DeepSeek, Moonshot and GLM).
+	Type      string `json:"type"`
+	ID        string `json:"id,omitempty"`
+	Text      string `json:"text,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	// Data holds opaque content: redacted thinking or encrypted reasoning.
+	Data string `json:"data,omitempty"`
 }
 
 type UsageInfo struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+	// CacheReadTokens is the part of PromptTokens served from the provider's
+	// prompt cache (a cache hit).
+	CacheReadTokens int `json:"cache_read_tokens,omitempty"`
+	// CacheCreationTokens is the part of PromptTokens written to the prompt
+	// cache on this request (Anthropic only).
+	CacheCreationTokens int `json:"cache_creation_tokens,omitempty"`
+	// CostUSD is the cost of the request as reported by the provider, if any.
+	CostUSD float64 `json:"cost_usd,omitempty"`
+}
+
+// CacheMissTokens returns the number of prompt tokens that were not served
+// from the prompt cache.
+func (u *UsageInfo) CacheMissTokens() int {
+	if u == nil {
+		return 0
+	}
+	if miss := u.PromptTokens - u.CacheReadTokens; miss > 0 {
+		return miss
+	}
+	return 0
 }
 
 type Message struct {
@@ -33,6 +74,9 @@ type Message struct {
 	Content    string     `json:"content"`
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string     `json:"tool_call_id,omitempty"`
+	// ReasoningBlocks carries the reasoning of an assistant tool-calling turn
+	// back to the provider. It is never persisted.
+	ReasoningBlocks []ReasoningBlock `json:"-"`
 }
 
 type LLMProvider interface {
@@ -40,6 +84,13 @@ type LLMProvider interface {
 	GetDefaultModel() string
 }
 
+// SessionResetter is implemented by providers that keep conversation state of
+// their own under options["session_key"]. ResetSession forgets that state so
+// the next call starts a fresh conversation.
+type SessionResetter interface {
+	ResetSession(sessionKey string)
+}
+
 type ToolDefinition struct {
 	Type     string                 `json:"type"`
 	Function ToolFunctionDefinition `json:"function"`
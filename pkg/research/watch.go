@@ -0,0 +1,303 @@
+// Package research stores research alert subscriptions ("watches") and
+// schedules them on the cron service. Each watch remembers the papers it has
+// already reported so that digests only contain new ones.
+package research
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adhocore/gronx"
+
+	"github.com/srikesh3005/summer/pkg/cron"
+)
+
+// JobKind marks cron jobs that run a research watch. The job's message
+// holds the watch ID.
+const JobKind = "research_watch"
+
+// DefaultSchedule runs watches every morning.
+const DefaultSchedule = "0 8 * * *"
+
+// Watch kinds.
+const (
+	KindTopic    = "topic"    // free-text query
+	KindAuthor   = "author"   // author name
+	KindCategory = "category" // arXiv category, e.g. cs.SD
+)
+
+// Sources a watch can poll.
+const (
+	SourceArXiv    = "arxiv"
+	SourceCrossref = "crossref"
+)
+
+// maxSeen bounds the remembered paper keys per watch; the oldest are
+// forgotten first.
+const maxSeen = 2000
+
+// Watch is a subscription to new papers on a topic, by an author or in an
+// arXiv category.
+type Watch struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Query     string    `json:"query"`
+	Sources   []string  `json:"sources"`
+	Schedule  string    `json:"schedule"` // cron expression
+	Channel   string    `json:"channel,omitempty"`
+	ChatID    string    `json:"chat_id,omitempty"`
+	JobID     string    `json:"job_id,omitempty"`
+	Seen      []string  `json:"seen,omitempty"` // paper keys, oldest first
+	CreatedAt time.Time `json:"created_at"`
+	LastRunAt time.Time `json:"last_run_at,omitempty"`
+}
+
+// Label describes the watch for listings and digest headers.
+func (w *Watch) Label() string {
+	switch w.Kind {
+	case KindAuthor:
+		return "author " + w.Query
+	case KindCategory:
+		return "arXiv " + w.Query
+	}
+	return fmt.Sprintf("%q", w.Query)
+}
+
+// Unseen returns the keys not yet reported by the watch, in order.
+func (w *Watch) Unseen(keys []string) []string {
+	seen := make(map[string]bool, len(w.Seen))
+	for _, k := range w.Seen {
+		seen[k] = true
+	}
+	var out []string
+	for _, k := range keys {
+		if !seen[k] {
+			seen[k] = true
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// Validate normalizes the watch and fills in defaults.
+func (w *Watch) Validate() error {
+	w.Query = strings.TrimSpace(w.Query)
+	if w.Query == "" {
+		return fmt.Errorf("watch query is empty")
+	}
+	switch w.Kind {
+	case "":
+		w.Kind = KindTopic
+	case KindTopic, KindAuthor, KindCategory:
+	default:
+		return fmt.Errorf("unknown watch kind %q (use topic, author or category)", w.Kind)
+	}
+	if len(w.Sources) == 0 {
+		w.Sources = []string{SourceArXiv, SourceCrossref}
+		if w.Kind == KindCategory {
+			w.Sources = []string{SourceArXiv}
+		}
+	}
+	for i, s := range w.Sources {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s != SourceArXiv && s != SourceCrossref {
+			return fmt.Errorf("unknown source %q (use arxiv or crossref)", s)
+		}
+		if s == SourceCrossref && w.Kind == KindCategory {
+			return fmt.Errorf("category watches only support arxiv")
+		}
+		w.Sources[i] = s
+	}
+	if w.Schedule == "" {
+		w.Schedule = DefaultSchedule
+	}
+	if !gronx.New().IsValid(w.Schedule) {
+		return fmt.Errorf("invalid cron expression %q", w.Schedule)
+	}
+	return nil
+}
+
+// Store persists watches in research/watches.json under the workspace.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore returns the watch store of a workspace.
+func NewStore(workspace string) *Store {
+	return &Store{path: filepath.Join(workspace, "research", "watches.json")}
+}
+
+func (s *Store) load() ([]*Watch, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watches: %w", err)
+	}
+	var watches []*Watch
+	if err := json.Unmarshal(data, &watches); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	return watches, nil
+}
+
+// save writes the file atomically via a temp file and rename.
+func (s *Store) save(watches []*Watch) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create research directory: %w", err)
+	}
+	data, err := json.MarshalIndent(watches, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal watches: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write watches: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write watches: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) update(fn func(watches []*Watch) ([]*Watch, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	watches, err := s.load()
+	if err != nil {
+		return err
+	}
+	if watches, err = fn(watches); err != nil {
+		return err
+	}
+	return s.save(watches)
+}
+
+// List returns all watches.
+func (s *Store) List() ([]*Watch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Get returns the watch with the given ID.
+func (s *Store) Get(id string) (*Watch, error) {
+	watches, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range watches {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return nil, fmt.Errorf("watch %s not found", id)
+}
+
+// Add validates and stores a new watch, assigning its ID.
+func (s *Store) Add(w *Watch) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	return s.update(func(watches []*Watch) ([]*Watch, error) {
+		for _, have := range watches {
+			if have.Kind == w.Kind && strings.EqualFold(have.Query, w.Query) &&
+				have.Channel == w.Channel && have.ChatID == w.ChatID {
+				return nil, fmt.Errorf("already watching %s (id %s)", w.Label(), have.ID)
+			}
+		}
+		w.ID = newID()
+		if w.CreatedAt.IsZero() {
+			w.CreatedAt = time.Now()
+		}
+		return append(watches, w), nil
+	})
+}
+
+// Update applies fn to the watch with the given ID and saves it.
+func (s *Store) Update(id string, fn func(w *Watch)) error {
+	return s.update(func(watches []*Watch) ([]*Watch, error) {
+		for _, w := range watches {
+			if w.ID == id {
+				fn(w)
+				return watches, nil
+			}
+		}
+		return nil, fmt.Errorf("watch %s not found", id)
+	})
+}
+
+// MarkSeen records that keys were reported and sets the last run time.
+func (s *Store) MarkSeen(id string, keys []string, at time.Time) error {
+	return s.Update(id, func(w *Watch) {
+		w.Seen = append(w.Seen, w.Unseen(keys)...)
+		if len(w.Seen) > maxSeen {
+			w.Seen = w.Seen[len(w.Seen)-maxSeen:]
+		}
+		w.LastRunAt = at
+	})
+}
+
+// Remove deletes the watch with the given ID and returns it.
+func (s *Store) Remove(id string) (*Watch, error) {
+	var removed *Watch
+	err := s.update(func(watches []*Watch) ([]*Watch, error) {
+		for i, w := range watches {
+			if w.ID == id {
+				removed = w
+				return append(watches[:i], watches[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("watch %s not found", id)
+	})
+	return removed, err
+}
+
+// Subscribe stores w and schedules a cron job that runs it.
+func Subscribe(store *Store, cs *cron.CronService, w *Watch) error {
+	if err := store.Add(w); err != nil {
+		return err
+	}
+	job, err := cs.AddJob("research: "+w.Label(), cron.CronSchedule{Kind: "cron", Expr: w.Schedule},
+		w.ID, false, w.Channel, w.ChatID)
+	if err == nil {
+		job.Payload.Kind = JobKind
+		err = cs.UpdateJob(job)
+	}
+	if err != nil {
+		store.Remove(w.ID)
+		return fmt.Errorf("failed to schedule watch: %w", err)
+	}
+	w.JobID = job.ID
+	return store.Update(w.ID, func(stored *Watch) { stored.JobID = job.ID })
+}
+
+// Unsubscribe removes a watch and its cron job.
+func Unsubscribe(store *Store, cs *cron.CronService, id string) (*Watch, error) {
+	w, err := store.Remove(id)
+	if err != nil {
+		return nil, err
+	}
+	if w.JobID != "" {
+		cs.RemoveJob(w.JobID)
+	}
+	return w, nil
+}
+
+func newID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano()&0xffffffff)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,83 @@
+package research
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatch_Validate(t *testing.T) {
+	w := &Watch{Query: "  cs.SD ", Kind: KindCategory}
+	if err := w.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if w.Query != "cs.SD" || len(w.Sources) != 1 || w.Sources[0] != SourceArXiv || w.Schedule != DefaultSchedule {
+		t.Errorf("defaults not applied: %+v", w)
+	}
+
+	w = &Watch{Query: "diffusion", Sources: []string{" ArXiv", "crossref"}}
+	if err := w.Validate(); err != nil || w.Kind != KindTopic || w.Sources[0] != SourceArXiv {
+		t.Errorf("Validate = %v, watch %+v", err, w)
+	}
+
+	for _, bad := range []*Watch{
+		{},
+		{Query: "x", Kind: "journal"},
+		{Query: "x", Sources: []string{"scopus"}},
+		{Query: "cs.SD", Kind: KindCategory, Sources: []string{SourceCrossref}},
+		{Query: "x", Schedule: "every morning"},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("Validate(%+v) should fail", bad)
+		}
+	}
+}
+
+func TestStore_SeenSet(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+	w := &Watch{Query: "diffusion models for audio"}
+	if err := store.Add(w); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := store.Add(&Watch{Query: "Diffusion Models for Audio"}); err == nil {
+		t.Error("duplicate watch should be rejected")
+	}
+
+	if err := store.MarkSeen(w.ID, []string{"arxiv:1", "doi:a", "arxiv:1"}, time.Now()); err != nil {
+		t.Fatalf("MarkSeen: %v", err)
+	}
+	got, err := NewStore(dir).Get(w.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got.Seen) != 2 || got.LastRunAt.IsZero() {
+		t.Errorf("seen = %v, last run %v", got.Seen, got.LastRunAt)
+	}
+	if unseen := got.Unseen([]string{"doi:a", "arxiv:2", "arxiv:2"}); len(unseen) != 1 || unseen[0] != "arxiv:2" {
+		t.Errorf("Unseen = %v", unseen)
+	}
+
+	// The seen set keeps only the newest keys.
+	var many []string
+	for i := 0; i < maxSeen+10; i++ {
+		many = append(many, fmt.Sprintf("arxiv:%d", i+100))
+	}
+	store.MarkSeen(w.ID, many, time.Now())
+	got, _ = store.Get(w.ID)
+	if len(got.Seen) != maxSeen || got.Seen[len(got.Seen)-1] != many[len(many)-1] {
+		t.Errorf("seen set not capped: %d keys", len(got.Seen))
+	}
+
+	if _, err := store.Remove(w.ID); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := store.Get(w.ID); err == nil {
+		t.Error("watch still present after Remove")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "research", "watches.json.tmp")); !os.IsNotExist(err) {
+		t.Error("temp file left behind")
+	}
+}
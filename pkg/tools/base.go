@@ -17,6 +17,13 @@ type ContextualTool interface {
 	SetContext(channel, chatID string)
 }
 
+// SessionResetter is an optional interface for tools that keep state per
+// conversation, such as processes or browser tabs, which must be dropped
+// when the conversation is reset.
+type SessionResetter interface {
+	ResetSession(channel, chatID string)
+}
+
 // AsyncCallback is a function type that async tools use to notify completion.
 // When an async tool finishes its work, it calls this callback with the result.
 //
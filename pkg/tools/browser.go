@@ -0,0 +1,571 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBrowserIdleTimeout = 10 * time.Minute
+	browserNavigateTimeout    = 30 * time.Second
+	defaultBrowserWait        = 10 * time.Second
+	maxBrowserWait            = 60 * time.Second
+	defaultBrowserTextChars   = 20000
+)
+
+// BrowserToolOptions configures the browser tool.
+type BrowserToolOptions struct {
+	// ChromePath is the Chromium executable; see FindChrome.
+	ChromePath string
+	// IdleTimeout closes a session's browser context after this long without
+	// use, and the browser once no contexts remain.
+	IdleTimeout time.Duration
+	// Policy is applied to every request the browser makes.
+	Policy OutboundPolicy
+}
+
+// BrowserTool drives a local Chromium over the Chrome DevTools Protocol for
+// pages that need JavaScript. Each conversation gets its own incognito-like
+// browser context with one tab; the browser process is started on first use
+// and shut down when idle. All of the browser's traffic goes through a proxy
+// that enforces the outbound policy.
+type BrowserTool struct {
+	paths *PathPolicy
+	opts  BrowserToolOptions
+	guard *outboundGuard
+
+	// launch starts the browser; replaced in tests.
+	launch func(ctx context.Context) (*chromeBrowser, error)
+
+	// mu guards the fields below; actions on a tab hold only that
+	// session's lock, so one conversation's slow wait does not block others.
+	mu         sync.Mutex
+	sessionKey string
+	browser    *chromeBrowser
+	sessions   map[string]*browserSession
+}
+
+type browserSession struct {
+	mu        sync.Mutex
+	conn      *cdpConn
+	contextID string
+	targetID  string
+	sessionID string
+	idle      *time.Timer
+}
+
+func NewBrowserTool(workspace string, restrict bool, opts BrowserToolOptions) *BrowserTool {
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = defaultBrowserIdleTimeout
+	}
+	t := &BrowserTool{
+		paths:    NewPathPolicy(workspace, restrict),
+		opts:     opts,
+		guard:    newOutboundGuard(opts.Policy),
+		sessions: make(map[string]*browserSession),
+	}
+	t.launch = func(ctx context.Context) (*chromeBrowser, error) {
+		return launchChrome(ctx, opts.ChromePath, t.guard)
+	}
+	return t
+}
+
+func (t *BrowserTool) Name() string {
+	return "browser"
+}
+
+func (t *BrowserTool) Description() string {
+	return "Control a real web browser for pages that need JavaScript or interaction (web_fetch returns empty pages for those). " +
+		"Actions: open a URL, click or type into elements by CSS selector, wait for a selector to appear, extract the visible text, " +
+		"save a PNG screenshot to the workspace, or close the browser. The tab persists between calls in this conversation."
+}
+
+func (t *BrowserTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"open", "click", "type", "wait", "text", "screenshot", "close"},
+				"description": "What to do",
+			},
+			"url": map[string]interface{}{
+				"type":        "string",
+				"description": "URL to open (action=open)",
+			},
+			"selector": map[string]interface{}{
+				"type":        "string",
+				"description": "CSS selector of the element (click, type, wait; optional for text to limit it to one element)",
+			},
+			"text": map[string]interface{}{
+				"type":        "string",
+				"description": "Text to type (action=type)",
+			},
+			"submit": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Press Enter after typing (action=type)",
+			},
+			"timeout": map[string]interface{}{
+				"type":        "integer",
+				"description": "Seconds to wait for the selector (action=wait, default 10, max 60)",
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Where to save the screenshot, relative to the workspace (default screenshots/browser-<time>.png)",
+			},
+			"full_page": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Capture the whole page instead of the viewport (action=screenshot)",
+			},
+			"maxChars": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum characters of text to return (action=text)",
+				"minimum":     100.0,
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+// SetContext selects the conversation whose browser context is used.
+func (t *BrowserTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sessionKey = channel + ":" + chatID
+}
+
+func (t *BrowserTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	action, _ := args["action"].(string)
+	if action == "" {
+		return ErrorResult("action is required")
+	}
+
+	t.mu.Lock()
+	key := t.sessionKey
+	if key == "" {
+		key = "default"
+	}
+
+	if action == "close" {
+		defer t.mu.Unlock()
+		if _, ok := t.sessions[key]; !ok {
+			return NewToolResult("No browser session is open.")
+		}
+		t.closeSessionLocked(key)
+		return NewToolResult("Browser session closed.")
+	}
+
+	sess, err := t.sessionLocked(ctx, key)
+	if err != nil {
+		t.mu.Unlock()
+		return ErrorResult(err.Error())
+	}
+	sess.idle.Reset(t.opts.IdleTimeout)
+	t.mu.Unlock()
+
+	// Actions on one tab are serialized; other conversations' tabs share the
+	// DevTools connection, which handles concurrent calls.
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	switch action {
+	case "open":
+		return t.open(ctx, sess, args)
+	case "click":
+		return t.click(ctx, sess, args)
+	case "type":
+		return t.typeText(ctx, sess, args)
+	case "wait":
+		return t.wait(ctx, sess, args)
+	case "text":
+		return t.text(ctx, sess, args)
+	case "screenshot":
+		return t.screenshot(ctx, sess, args)
+	default:
+		return ErrorResult(fmt.Sprintf("unknown action: %s", action))
+	}
+}
+
+// Close shuts down the browser and all sessions.
+func (t *BrowserTool) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key := range t.sessions {
+		t.closeSessionLocked(key)
+	}
+	if t.browser != nil {
+		t.browser.close()
+		t.browser = nil
+	}
+	return nil
+}
+
+// ResetSession closes the browser context of a conversation.
+func (t *BrowserTool) ResetSession(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closeSessionLocked(channel + ":" + chatID)
+}
+
+// sessionLocked returns the session for key, starting the browser and
+// creating a browser context and tab as needed.
+func (t *BrowserTool) sessionLocked(ctx context.Context, key string) (*browserSession, error) {
+	if sess, ok := t.sessions[key]; ok && t.browser != nil && t.browser.conn.alive() {
+		return sess, nil
+	}
+	delete(t.sessions, key)
+
+	if t.browser == nil || !t.browser.conn.alive() {
+		if t.browser != nil {
+			t.browser.close()
+			t.sessions = make(map[string]*browserSession) // contexts died with it
+		}
+		b, err := t.launch(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start browser: %v", err)
+		}
+		t.browser = b
+	}
+	conn := t.browser.conn
+
+	var bc struct {
+		BrowserContextID string `json:"browserContextId"`
+	}
+	if err := conn.call(ctx, "", "Target.createBrowserContext", map[string]interface{}{"disposeOnDetach": true}, &bc); err != nil {
+		return nil, err
+	}
+	var target struct {
+		TargetID string `json:"targetId"`
+	}
+	if err := conn.call(ctx, "", "Target.createTarget", map[string]interface{}{
+		"url":              "about:blank",
+		"browserContextId": bc.BrowserContextID,
+	}, &target); err != nil {
+		return nil, err
+	}
+	var attached struct {
+		SessionID string `json:"sessionId"`
+	}
+	if err := conn.call(ctx, "", "Target.attachToTarget", map[string]interface{}{
+		"targetId": target.TargetID,
+		"flatten":  true,
+	}, &attached); err != nil {
+		return nil, err
+	}
+	if err := conn.call(ctx, attached.SessionID, "Page.enable", nil, nil); err != nil {
+		return nil, err
+	}
+
+	sess := &browserSession{
+		conn:      conn,
+		contextID: bc.BrowserContextID,
+		targetID:  target.TargetID,
+		sessionID: attached.SessionID,
+	}
+	sess.idle = time.AfterFunc(t.opts.IdleTimeout, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.sessions[key] == sess {
+			t.closeSessionLocked(key)
+		}
+	})
+	t.sessions[key] = sess
+	return sess, nil
+}
+
+// closeSessionLocked disposes of a session's browser context and stops the
+// browser when it was the last one.
+func (t *BrowserTool) closeSessionLocked(key string) {
+	sess, ok := t.sessions[key]
+	if !ok {
+		return
+	}
+	delete(t.sessions, key)
+	sess.idle.Stop()
+
+	if t.browser == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.browser.conn.call(ctx, "", "Target.disposeBrowserContext", map[string]interface{}{"browserContextId": sess.contextID}, nil)
+	cancel()
+
+	if len(t.sessions) == 0 {
+		t.browser.close()
+		t.browser = nil
+	}
+}
+
+func (t *BrowserTool) open(ctx context.Context, sess *browserSession, args map[string]interface{}) *ToolResult {
+	rawURL, _ := args["url"].(string)
+	if rawURL == "" {
+		return ErrorResult("url is required for action=open")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("invalid URL: %v", err))
+	}
+	// The proxy enforces the policy on this and every later request; this
+	// check only gives a clearer error than the browser's proxy failure.
+	if err := t.guard.checkResolved(ctx, u); err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	conn := sess.conn
+	loaded, unsubscribe := conn.subscribe(sess.sessionID, "Page.loadEventFired")
+	defer unsubscribe()
+
+	var nav struct {
+		ErrorText string `json:"errorText"`
+	}
+	if err := conn.call(ctx, sess.sessionID, "Page.navigate", map[string]interface{}{"url": u.String()}, &nav); err != nil {
+		return ErrorResult(fmt.Sprintf("navigation failed: %v", err))
+	}
+	if nav.ErrorText != "" {
+		return ErrorResult(fmt.Sprintf("navigation failed: %s", nav.ErrorText))
+	}
+
+	note := ""
+	select {
+	case <-loaded:
+	case <-time.After(browserNavigateTimeout):
+		note = " (page still loading)"
+	case <-ctx.Done():
+		return ErrorResult(ctx.Err().Error())
+	}
+
+	var page struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+	}
+	if err := t.eval(ctx, sess, `({title: document.title, url: location.href})`, &page); err != nil {
+		return ErrorResult(err.Error())
+	}
+	return NewToolResult(fmt.Sprintf("Opened %s%s\nTitle: %s\nUse action=text to read the page.", page.URL, note, page.Title))
+}
+
+func (t *BrowserTool) click(ctx context.Context, sess *browserSession, args map[string]interface{}) *ToolResult {
+	selector, _ := args["selector"].(string)
+	if selector == "" {
+		return ErrorResult("selector is required for action=click")
+	}
+	var found bool
+	expr := fmt.Sprintf(`(() => {
+		const el = document.querySelector(%s);
+		if (!el) return false;
+		el.scrollIntoView({block: "center"});
+		el.click();
+		return true;
+	})()`, jsString(selector))
+	if err := t.eval(ctx, sess, expr, &found); err != nil {
+		return ErrorResult(err.Error())
+	}
+	if !found {
+		return ErrorResult(fmt.Sprintf("no element matches %s", selector))
+	}
+	return NewToolResult(fmt.Sprintf("Clicked %s", selector))
+}
+
+func (t *BrowserTool) typeText(ctx context.Context, sess *browserSession, args map[string]interface{}) *ToolResult {
+	selector, _ := args["selector"].(string)
+	text, _ := args["text"].(string)
+	if selector == "" {
+		return ErrorResult("selector is required for action=type")
+	}
+	var found bool
+	expr := fmt.Sprintf(`(() => {
+		const el = document.querySelector(%s);
+		if (!el) return false;
+		el.scrollIntoView({block: "center"});
+		el.focus();
+		return true;
+	})()`, jsString(selector))
+	if err := t.eval(ctx, sess, expr, &found); err != nil {
+		return ErrorResult(err.Error())
+	}
+	if !found {
+		return ErrorResult(fmt.Sprintf("no element matches %s", selector))
+	}
+
+	conn := sess.conn
+	if err := conn.call(ctx, sess.sessionID, "Input.insertText", map[string]interface{}{"text": text}, nil); err != nil {
+		return ErrorResult(fmt.Sprintf("typing failed: %v", err))
+	}
+	if submit, _ := args["submit"].(bool); submit {
+		for _, typ := range []string{"keyDown", "keyUp"} {
+			key := map[string]interface{}{
+				"type":                  typ,
+				"key":                   "Enter",
+				"code":                  "Enter",
+				"windowsVirtualKeyCode": 13,
+			}
+			if typ == "keyDown" {
+				key["text"] = "\r"
+			}
+			if err := conn.call(ctx, sess.sessionID, "Input.dispatchKeyEvent", key, nil); err != nil {
+				return ErrorResult(fmt.Sprintf("pressing Enter failed: %v", err))
+			}
+		}
+		return NewToolResult(fmt.Sprintf("Typed %d characters into %s and pressed Enter", len([]rune(text)), selector))
+	}
+	return NewToolResult(fmt.Sprintf("Typed %d characters into %s", len([]rune(text)), selector))
+}
+
+func (t *BrowserTool) wait(ctx context.Context, sess *browserSession, args map[string]interface{}) *ToolResult {
+	selector, _ := args["selector"].(string)
+	if selector == "" {
+		return ErrorResult("selector is required for action=wait")
+	}
+	timeout := defaultBrowserWait
+	if s, ok := args["timeout"].(float64); ok && s > 0 {
+		timeout = min(time.Duration(s)*time.Second, maxBrowserWait)
+	}
+
+	deadline := time.Now().Add(timeout)
+	expr := fmt.Sprintf(`document.querySelector(%s) !== null`, jsString(selector))
+	for {
+		var found bool
+		if err := t.eval(ctx, sess, expr, &found); err != nil {
+			return ErrorResult(err.Error())
+		}
+		if found {
+			return NewToolResult(fmt.Sprintf("%s is present", selector))
+		}
+		if time.Now().After(deadline) {
+			return ErrorResult(fmt.Sprintf("timed out after %s waiting for %s", timeout, selector))
+		}
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-ctx.Done():
+			return ErrorResult(ctx.Err().Error())
+		}
+	}
+}
+
+func (t *BrowserTool) text(ctx context.Context, sess *browserSession, args map[string]interface{}) *ToolResult {
+	selector, _ := args["selector"].(string)
+	maxChars := defaultBrowserTextChars
+	if mc, ok := args["maxChars"].(float64); ok && int(mc) >= 100 {
+		maxChars = int(mc)
+	}
+
+	target := "document.body"
+	if selector != "" {
+		target = fmt.Sprintf("document.querySelector(%s)", jsString(selector))
+	}
+	var page struct {
+		Found bool   `json:"found"`
+		Title string `json:"title"`
+		URL   string `json:"url"`
+		Text  string `json:"text"`
+	}
+	expr := fmt.Sprintf(`(() => {
+		const el = %s;
+		return {found: !!el, title: document.title, url: location.href, text: el ? el.innerText : ""};
+	})()`, target)
+	if err := t.eval(ctx, sess, expr, &page); err != nil {
+		return ErrorResult(err.Error())
+	}
+	if !page.Found {
+		if selector == "" {
+			return ErrorResult("the page has no body; open a URL first")
+		}
+		return ErrorResult(fmt.Sprintf("no element matches %s", selector))
+	}
+
+	text := collapseBlankLines(strings.TrimSpace(page.Text))
+	runes := []rune(text)
+	header := fmt.Sprintf("URL: %s\nTitle: %s\n", page.URL, page.Title)
+	if len(runes) > maxChars {
+		text = string(runes[:maxChars])
+		header += fmt.Sprintf("Showing %d of %d characters\n", maxChars, len(runes))
+	}
+	return NewToolResult(header + "\n" + text)
+}
+
+func (t *BrowserTool) screenshot(ctx context.Context, sess *browserSession, args map[string]interface{}) *ToolResult {
+	path, _ := args["path"].(string)
+	if path == "" {
+		path = filepath.Join("screenshots", fmt.Sprintf("browser-%s.png", time.Now().Format("20060102-150405")))
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".png") {
+		path += ".png"
+	}
+	resolved, err := t.paths.Resolve(path, PathWrite)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	fullPage, _ := args["full_page"].(bool)
+	var shot struct {
+		Data string `json:"data"`
+	}
+	if err := sess.conn.call(ctx, sess.sessionID, "Page.captureScreenshot", map[string]interface{}{
+		"format":                "png",
+		"captureBeyondViewport": fullPage,
+	}, &shot); err != nil {
+		return ErrorResult(fmt.Sprintf("screenshot failed: %v", err))
+	}
+	data, err := base64.StdEncoding.DecodeString(shot.Data)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to decode screenshot: %v", err))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(resolved), 0755); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to create directory: %v", err))
+	}
+	if err := os.WriteFile(resolved, data, 0644); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to write screenshot: %v", err))
+	}
+	return NewToolResult(fmt.Sprintf("Saved screenshot to %s (%d bytes)", resolved, len(data)))
+}
+
+// eval runs a JavaScript expression in the page and decodes its value.
+func (t *BrowserTool) eval(ctx context.Context, sess *browserSession, expr string, out interface{}) error {
+	var res struct {
+		Result struct {
+			Value json.RawMessage `json:"value"`
+		} `json:"result"`
+		ExceptionDetails *struct {
+			Text      string `json:"text"`
+			Exception struct {
+				Description string `json:"description"`
+			} `json:"exception"`
+		} `json:"exceptionDetails"`
+	}
+	if err := sess.conn.call(ctx, sess.sessionID, "Runtime.evaluate", map[string]interface{}{
+		"expression":    expr,
+		"returnByValue": true,
+		"awaitPromise":  true,
+	}, &res); err != nil {
+		return err
+	}
+	if res.ExceptionDetails != nil {
+		msg := res.ExceptionDetails.Exception.Description
+		if msg == "" {
+			msg = res.ExceptionDetails.Text
+		}
+		return fmt.Errorf("script error: %s", msg)
+	}
+	if len(res.Result.Value) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(res.Result.Value, out); err != nil {
+		return fmt.Errorf("unexpected script result: %v", err)
+	}
+	return nil
+}
+
+// jsString quotes s as a JavaScript string literal.
+func jsString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
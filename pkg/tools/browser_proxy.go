@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"time"
+)
+
+// browserProxy is a loopback HTTP proxy the browser is started with, so that
+// every connection it makes - navigations, redirects, subresources,
+// fetch/XHR and WebSockets - is dialed through the outbound guard. Host names
+// are resolved by the proxy, which also rules out DNS rebinding between a
+// check and the browser's own lookup.
+type browserProxy struct {
+	listener net.Listener
+	server   *http.Server
+	guard    *outboundGuard
+	dial     func(ctx context.Context, network, addr string) (net.Conn, error)
+	forward  *httputil.ReverseProxy
+}
+
+func startBrowserProxy(guard *outboundGuard) (*browserProxy, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start browser proxy: %w", err)
+	}
+	p := &browserProxy{
+		listener: ln,
+		guard:    guard,
+		dial:     guard.dialContext(&net.Dialer{Timeout: 15 * time.Second, KeepAlive: 30 * time.Second}),
+	}
+	p.forward = &httputil.ReverseProxy{
+		// Proxy requests already carry the absolute target URL.
+		Rewrite: func(r *httputil.ProxyRequest) {},
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         p.dial,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     30 * time.Second,
+			TLSHandshakeTimeout: 15 * time.Second,
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, fmt.Sprintf("summer blocked or could not reach %s: %v", r.URL.Host, err), http.StatusForbidden)
+		},
+	}
+	p.server = &http.Server{Handler: p, ReadHeaderTimeout: 30 * time.Second}
+	go p.server.Serve(ln)
+	return p, nil
+}
+
+// addr is the proxy's host:port.
+func (p *browserProxy) addr() string {
+	return p.listener.Addr().String()
+}
+
+func (p *browserProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+	if !r.URL.IsAbs() {
+		http.Error(w, "not a proxy request", http.StatusBadRequest)
+		return
+	}
+	if err := p.guard.checkURL(r.URL); err != nil {
+		http.Error(w, fmt.Sprintf("summer blocked %s: %v", r.URL.Host, err), http.StatusForbidden)
+		return
+	}
+	p.forward.ServeHTTP(w, r)
+}
+
+// tunnel handles CONNECT, which the browser uses for HTTPS and WebSockets.
+func (p *browserProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	upstream, err := p.dial(r.Context(), "tcp", r.Host)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("summer blocked or could not reach %s: %v", r.Host, err), http.StatusForbidden)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "tunneling not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buf, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	if _, err := io.WriteString(client, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		client.Close()
+		upstream.Close()
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		// Bytes the client sent after the CONNECT line may be buffered.
+		if n := buf.Reader.Buffered(); n > 0 {
+			data, _ := buf.Reader.Peek(n)
+			upstream.Write(data)
+		}
+		io.Copy(upstream, client)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(client, upstream)
+		done <- struct{}{}
+	}()
+	<-done
+	client.Close()
+	upstream.Close()
+	<-done
+}
+
+func (p *browserProxy) close() {
+	p.server.Close()
+}
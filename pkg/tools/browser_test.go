@@ -0,0 +1,387 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeCDP is a DevTools endpoint that answers the commands the browser tool
+// sends, so the protocol handling can be tested without a browser.
+type fakeCDP struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	methods  []string
+	typed    []string
+	disposed []string
+}
+
+func newFakeCDP(t *testing.T) *fakeCDP {
+	f := &fakeCDP{}
+	upgrader := websocket.Upgrader{}
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		var writeMu sync.Mutex
+		send := func(msg map[string]interface{}) {
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			ws.WriteJSON(msg)
+		}
+		for {
+			var msg cdpMessage
+			if err := ws.ReadJSON(&msg); err != nil {
+				return
+			}
+			f.handle(msg, send)
+		}
+	}))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeCDP) handle(msg cdpMessage, send func(map[string]interface{})) {
+	f.mu.Lock()
+	f.methods = append(f.methods, msg.Method)
+	f.mu.Unlock()
+
+	var params map[string]interface{}
+	json.Unmarshal(msg.Params, &params)
+	result := map[string]interface{}{}
+
+	switch msg.Method {
+	case "Target.createBrowserContext":
+		result["browserContextId"] = "ctx-1"
+	case "Target.createTarget":
+		result["targetId"] = "target-1"
+	case "Target.attachToTarget":
+		result["sessionId"] = "session-1"
+	case "Target.disposeBrowserContext":
+		f.mu.Lock()
+		f.disposed = append(f.disposed, params["browserContextId"].(string))
+		f.mu.Unlock()
+	case "Page.navigate":
+		defer send(map[string]interface{}{"method": "Page.loadEventFired", "sessionId": msg.SessionID, "params": map[string]interface{}{}})
+		result["frameId"] = "frame-1"
+	case "Input.insertText":
+		f.mu.Lock()
+		f.typed = append(f.typed, params["text"].(string))
+		f.mu.Unlock()
+	case "Page.captureScreenshot":
+		result["data"] = base64.StdEncoding.EncodeToString([]byte("\x89PNG fake"))
+	case "Runtime.evaluate":
+		expr := params["expression"].(string)
+		var value interface{}
+		switch {
+		case strings.Contains(expr, `"#slow"`):
+			value = false
+		case strings.Contains(expr, `"#missing"`):
+			value = false
+			if strings.Contains(expr, "innerText") {
+				value = map[string]interface{}{"found": false}
+			}
+		case strings.Contains(expr, "innerText"):
+			value = map[string]interface{}{"found": true, "title": "Fake", "url": "http://example.com/", "text": "Hello from the page"}
+		case strings.Contains(expr, "document.title"):
+			value = map[string]interface{}{"title": "Fake", "url": "http://example.com/"}
+		case strings.Contains(expr, "throw"):
+			result["exceptionDetails"] = map[string]interface{}{"text": "Uncaught", "exception": map[string]interface{}{"description": "Error: boom"}}
+		default:
+			value = true
+		}
+		result["result"] = map[string]interface{}{"type": "object", "value": value}
+	}
+	send(map[string]interface{}{"id": msg.ID, "sessionId": msg.SessionID, "result": result})
+}
+
+func (f *fakeCDP) called(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, m := range f.methods {
+		if m == method {
+			n++
+		}
+	}
+	return n
+}
+
+func newFakeBrowserTool(t *testing.T, f *fakeCDP, idle time.Duration) (*BrowserTool, *int) {
+	workspace := t.TempDir()
+	tool := NewBrowserTool(workspace, true, BrowserToolOptions{IdleTimeout: idle})
+	launches := 0
+	tool.launch = func(ctx context.Context) (*chromeBrowser, error) {
+		launches++
+		conn, err := dialCDP(ctx, "ws"+strings.TrimPrefix(f.server.URL, "http"))
+		if err != nil {
+			return nil, err
+		}
+		return &chromeBrowser{conn: conn}, nil
+	}
+	t.Cleanup(func() { tool.Close() })
+	return tool, &launches
+}
+
+func TestBrowserTool_FakeCDPActions(t *testing.T) {
+	f := newFakeCDP(t)
+	tool, launches := newFakeBrowserTool(t, f, time.Minute)
+	tool.SetContext("telegram", "42")
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]interface{}{"action": "open", "url": "http://example.com/"})
+	if result.IsError || !strings.Contains(result.ForLLM, "Title: Fake") {
+		t.Fatalf("open: unexpected result: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "type", "selector": "#q", "text": "summer", "submit": true})
+	if result.IsError || !strings.Contains(result.ForLLM, "pressed Enter") {
+		t.Errorf("type: unexpected result: %s", result.ForLLM)
+	}
+	if len(f.typed) != 1 || f.typed[0] != "summer" {
+		t.Errorf("expected typed text to reach the browser, got %v", f.typed)
+	}
+	if n := f.called("Input.dispatchKeyEvent"); n != 2 {
+		t.Errorf("expected Enter keyDown and keyUp, got %d key events", n)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "click", "selector": "#missing"})
+	if !result.IsError {
+		t.Errorf("click: expected error for missing element")
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "text"})
+	if result.IsError || !strings.Contains(result.ForLLM, "Hello from the page") {
+		t.Errorf("text: unexpected result: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "screenshot", "path": "shots/page"})
+	if result.IsError {
+		t.Fatalf("screenshot: %s", result.ForLLM)
+	}
+	data, err := os.ReadFile(filepath.Join(tool.paths.Workspace(), "shots", "page.png"))
+	if err != nil || !bytes.HasPrefix(data, []byte("\x89PNG")) {
+		t.Errorf("expected screenshot file in workspace, got err=%v data=%q", err, data)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "screenshot", "path": "../outside.png"})
+	if !result.IsError {
+		t.Errorf("screenshot: expected path outside the workspace to be refused")
+	}
+
+	if *launches != 1 || f.called("Target.createBrowserContext") != 1 {
+		t.Errorf("expected one browser and one context, got %d launches and %d contexts",
+			*launches, f.called("Target.createBrowserContext"))
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "close"})
+	if result.IsError || len(f.disposed) != 1 || f.disposed[0] != "ctx-1" {
+		t.Errorf("close: expected the browser context to be disposed, got %v (%s)", f.disposed, result.ForLLM)
+	}
+	if tool.browser != nil {
+		t.Errorf("expected browser to stop after the last session closed")
+	}
+}
+
+func TestBrowserTool_SessionPerConversation(t *testing.T) {
+	f := newFakeCDP(t)
+	tool, launches := newFakeBrowserTool(t, f, time.Minute)
+	ctx := context.Background()
+
+	for _, chat := range []string{"1", "2", "1"} {
+		tool.SetContext("discord", chat)
+		if result := tool.Execute(ctx, map[string]interface{}{"action": "text"}); result.IsError {
+			t.Fatalf("text: %s", result.ForLLM)
+		}
+	}
+	if *launches != 1 {
+		t.Errorf("expected the browser to be shared, got %d launches", *launches)
+	}
+	if n := f.called("Target.createBrowserContext"); n != 2 {
+		t.Errorf("expected one context per conversation, got %d", n)
+	}
+}
+
+func TestBrowserTool_IdleTeardown(t *testing.T) {
+	f := newFakeCDP(t)
+	tool, _ := newFakeBrowserTool(t, f, 50*time.Millisecond)
+
+	if result := tool.Execute(context.Background(), map[string]interface{}{"action": "text"}); result.IsError {
+		t.Fatalf("text: %s", result.ForLLM)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		tool.mu.Lock()
+		stopped := tool.browser == nil && len(tool.sessions) == 0
+		tool.mu.Unlock()
+		if stopped {
+			if f.called("Target.disposeBrowserContext") != 1 {
+				t.Errorf("expected the idle context to be disposed")
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("browser was not torn down after the idle timeout")
+}
+
+func TestBrowserTool_OpenChecksPolicy(t *testing.T) {
+	f := newFakeCDP(t)
+	tool, _ := newFakeBrowserTool(t, f, time.Minute)
+
+	for _, u := range []string{"http://169.254.169.254/", "file:///etc/passwd", "http://localhost:8080/"} {
+		result := tool.Execute(context.Background(), map[string]interface{}{"action": "open", "url": u})
+		if !result.IsError {
+			t.Errorf("expected %s to be refused", u)
+		}
+	}
+	if n := f.called("Page.navigate"); n != 0 {
+		t.Errorf("expected no navigation, got %d", n)
+	}
+}
+
+func TestBrowserTool_SessionsDoNotBlockEachOther(t *testing.T) {
+	f := newFakeCDP(t)
+	tool, _ := newFakeBrowserTool(t, f, time.Minute)
+	ctx := context.Background()
+
+	tool.SetContext("discord", "1")
+	if result := tool.Execute(ctx, map[string]interface{}{"action": "text"}); result.IsError {
+		t.Fatalf("text: %s", result.ForLLM)
+	}
+	waiting := make(chan *ToolResult)
+	go func() {
+		waiting <- tool.Execute(ctx, map[string]interface{}{"action": "wait", "selector": "#slow", "timeout": 2.0})
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	tool.SetContext("discord", "2")
+	if result := tool.Execute(ctx, map[string]interface{}{"action": "text"}); result.IsError {
+		t.Fatalf("text: %s", result.ForLLM)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("second conversation waited %s for the first one's wait", elapsed)
+	}
+	if result := <-waiting; !result.IsError {
+		t.Errorf("expected the wait to time out, got: %s", result.ForLLM)
+	}
+}
+
+func TestBrowserTool_ScriptError(t *testing.T) {
+	f := newFakeCDP(t)
+	tool, _ := newFakeBrowserTool(t, f, time.Minute)
+	tool.Execute(context.Background(), map[string]interface{}{"action": "text"})
+
+	tool.mu.Lock()
+	defer tool.mu.Unlock()
+	err := tool.eval(context.Background(), tool.sessions["default"], `throw new Error("boom")`, new(bool))
+	if err == nil || !strings.Contains(err.Error(), "Error: boom") {
+		t.Errorf("expected script error, got: %v", err)
+	}
+}
+
+func TestBrowserProxy_EnforcesPolicy(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer plain.Close()
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer secure.Close()
+
+	get := func(policy OutboundPolicy, target string) (string, error) {
+		proxy, err := startBrowserProxy(newOutboundGuard(policy))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer proxy.close()
+		transport := secure.Client().Transport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(&url.URL{Scheme: "http", Host: proxy.addr()})
+		resp, err := (&http.Client{Transport: transport, Timeout: 5 * time.Second}).Get(target)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Sprintf("%d %s", resp.StatusCode, body), nil
+	}
+
+	for _, target := range []string{plain.URL, secure.URL, strings.Replace(plain.URL, "127.0.0.1", "localhost", 1)} {
+		got, err := get(OutboundPolicy{}, target)
+		if err == nil && !strings.HasPrefix(got, "403 ") {
+			t.Errorf("%s: expected the proxy to refuse a loopback destination, got %q", target, got)
+		}
+	}
+	for _, target := range []string{plain.URL, secure.URL} {
+		got, err := get(OutboundPolicy{AllowHosts: []string{"127.0.0.1"}}, target)
+		if err != nil || got != "200 internal" {
+			t.Errorf("%s: expected an allowlisted destination to pass, got %q (%v)", target, got, err)
+		}
+	}
+}
+
+// TestBrowserTool_Chromium runs the tool against a real browser and a static
+// page whose content is rendered by JavaScript.
+func TestBrowserTool_Chromium(t *testing.T) {
+	chrome := FindChrome("")
+	if chrome == "" {
+		t.Skip("no Chromium-based browser installed")
+	}
+
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata/browser")))
+	defer server.Close()
+
+	tool := NewBrowserTool(t.TempDir(), true, BrowserToolOptions{
+		ChromePath: chrome,
+		Policy:     OutboundPolicy{AllowHosts: []string{"127.0.0.1"}},
+	})
+	defer tool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	steps := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"action": "open", "url": server.URL + "/index.html"}, "Title: Browser fixture"},
+		{map[string]interface{}{"action": "text"}, "Rendered by script"},
+		{map[string]interface{}{"action": "type", "selector": "#name", "text": "Ada"}, "Typed 3 characters"},
+		{map[string]interface{}{"action": "click", "selector": "#greet"}, "Clicked"},
+		{map[string]interface{}{"action": "wait", "selector": "#greeting.done", "timeout": 5.0}, "is present"},
+		{map[string]interface{}{"action": "text", "selector": "#greeting"}, "Hello, Ada!"},
+		{map[string]interface{}{"action": "screenshot", "path": "fixture.png"}, "Saved screenshot"},
+		{map[string]interface{}{"action": "close"}, "closed"},
+	}
+	for _, step := range steps {
+		result := tool.Execute(ctx, step.args)
+		if result.IsError || !strings.Contains(result.ForLLM, step.want) {
+			t.Fatalf("%v: expected %q, got: %s", step.args["action"], step.want, result.ForLLM)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(tool.paths.Workspace(), "fixture.png"))
+	if err != nil || !bytes.HasPrefix(data, []byte("\x89PNG")) {
+		t.Errorf("expected a PNG screenshot, got err=%v", err)
+	}
+}
@@ -0,0 +1,265 @@
+package tools
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/srikesh3005/summer/pkg/calc"
+)
+
+// maxDescribedColumns bounds the summary of a table without a column.
+const maxDescribedColumns = 20
+
+// loadTable reads a CSV, TSV or Excel file as a table. For workbooks the
+// named sheet, or else the first one, is used.
+func loadTable(path, sheetName string) (*calc.Table, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > maxDocumentSize {
+		return nil, fmt.Errorf("file is too large (%d MB, limit %d MB)", info.Size()>>20, maxDocumentSize>>20)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open archive: %v", err)
+		}
+		sheets, _, err := extractXLSX(zr)
+		if err != nil {
+			return nil, err
+		}
+		if sheetName == "" {
+			return calc.NewTable(sheets[0].rows), nil
+		}
+		var names []string
+		for _, s := range sheets {
+			if strings.EqualFold(s.name, sheetName) {
+				return calc.NewTable(s.rows), nil
+			}
+			names = append(names, s.name)
+		}
+		return nil, fmt.Errorf("no sheet named %q; available: %s", sheetName, strings.Join(names, ", "))
+	}
+	if isBinary(data) {
+		return nil, fmt.Errorf("unsupported binary file (supported: CSV, TSV and XLSX)")
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	header, _, _ := strings.Cut(string(data), "\n")
+	if strings.EqualFold(filepath.Ext(path), ".tsv") || strings.Count(header, "\t") > strings.Count(header, ",") {
+		r.Comma = '\t'
+	} else if strings.Count(header, ";") > strings.Count(header, ",") {
+		r.Comma = ';'
+	}
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %v", err)
+	}
+	return calc.NewTable(rows), nil
+}
+
+// numberListArg reads numbers given as a JSON array or a comma- or
+// space-separated string.
+func numberListArg(args map[string]interface{}, key string) ([]float64, error) {
+	var out []float64
+	switch v := args[key].(type) {
+	case []interface{}:
+		for _, item := range v {
+			switch x := item.(type) {
+			case float64:
+				out = append(out, x)
+			case string:
+				f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %q is not a number", key, x)
+				}
+				out = append(out, f)
+			}
+		}
+	case string:
+		for _, field := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ';' || r == ' ' || r == '\n' || r == '\t' }) {
+			f, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %q is not a number", key, field)
+			}
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
+
+// CalculateTool evaluates expressions with units and computes statistics
+// over inline numbers or columns of a CSV or Excel file.
+type CalculateTool struct {
+	paths *PathPolicy
+}
+
+func NewCalculateTool(workspace string, restrict bool, roots ...PathRoot) *CalculateTool {
+	return &CalculateTool{paths: NewPathPolicy(workspace, restrict, roots...)}
+}
+
+func (t *CalculateTool) Name() string {
+	return "calculate"
+}
+
+func (t *CalculateTool) Description() string {
+	return "Compute numbers exactly instead of estimating them. Evaluates expressions with + - * / % ^, parentheses, comparisons and functions " +
+		"(sqrt, abs, round(x, digits), floor, ceil, exp, ln, log(x, base), log10, sin/cos/tan with deg or rad, min, max, sum, mean, median, " +
+		"std, var, count, percentile(values, p), corr(x, y), cov, slope, intercept). Numbers can carry units and be converted, e.g. " +
+		"\"3 ft + 2 in to cm\", \"60 km / 45 min to mph\", \"100 degF to degC\", \"1.5 GiB to MB\". " +
+		"Pass numbers as data to use them as the list `data` (e.g. \"mean(data)\"), or a CSV/TSV/XLSX path to use its numeric columns by name " +
+		"(lower case, other characters replaced by _). Without an expression, returns descriptive statistics of the data or column."
+}
+
+func (t *CalculateTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"expression": map[string]interface{}{
+				"type":        "string",
+				"description": "Expression to evaluate, e.g. \"(4.2 - 3.9) / 3.9 * 100\", \"5 km to mi\" or \"percentile(data, 95)\"",
+			},
+			"data": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "number"},
+				"description": "Numbers available to the expression as `data`",
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "CSV, TSV or XLSX file whose numeric columns become lists of the same name",
+			},
+			"sheet": map[string]interface{}{
+				"type":        "string",
+				"description": "For workbooks, the sheet to read (default: the first)",
+			},
+			"column": map[string]interface{}{
+				"type":        "string",
+				"description": "Column to describe when no expression is given (default: all numeric columns)",
+			},
+		},
+	}
+}
+
+func (t *CalculateTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	expression, _ := args["expression"].(string)
+	expression = strings.TrimSpace(expression)
+	data, err := numberListArg(args, "data")
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	vars := map[string]calc.Value{}
+	var table *calc.Table
+	if path, _ := args["path"].(string); path != "" {
+		resolved, err := t.paths.Resolve(path, PathRead)
+		if err != nil {
+			return ErrorResult(err.Error())
+		}
+		sheet, _ := args["sheet"].(string)
+		if table, err = loadTable(resolved, sheet); err != nil {
+			return ErrorResult(fmt.Sprintf("failed to read table: %v", err))
+		}
+		vars = table.Vars()
+	}
+	if _, ok := args["data"]; ok {
+		vars["data"] = calc.List(data)
+	}
+
+	if expression == "" {
+		return t.describe(args, table, data)
+	}
+	v, err := calc.Eval(expression, vars)
+	if err != nil {
+		msg := fmt.Sprintf("failed to evaluate %q: %v", expression, err)
+		if len(vars) > 0 && strings.Contains(err.Error(), "unknown name") {
+			names := make([]string, 0, len(vars))
+			for name := range vars {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			msg += fmt.Sprintf(" (available lists: %s)", strings.Join(names, ", "))
+		}
+		return ErrorResult(msg)
+	}
+	return NewToolResult(fmt.Sprintf("%s = %s", expression, v))
+}
+
+func (t *CalculateTool) describe(args map[string]interface{}, table *calc.Table, data []float64) *ToolResult {
+	var sb strings.Builder
+	if len(data) > 0 {
+		fmt.Fprintf(&sb, "data:\n%s\n\n", calc.Describe(data))
+	}
+	if table != nil {
+		columns := make([]int, 0, len(table.Columns))
+		if name, _ := args["column"].(string); name != "" {
+			i, err := table.Column(name)
+			if err != nil {
+				return ErrorResult(err.Error())
+			}
+			columns = append(columns, i)
+		} else {
+			for i := range table.Columns {
+				columns = append(columns, i)
+			}
+		}
+		described := 0
+		for _, i := range columns {
+			xs, n := table.Numbers(i)
+			if n == 0 {
+				if len(columns) == 1 {
+					return ErrorResult(fmt.Sprintf("column %q has no numeric values", table.Columns[i]))
+				}
+				continue
+			}
+			if described == maxDescribedColumns {
+				sb.WriteString("(more columns omitted; pass column to describe one)\n")
+				break
+			}
+			described++
+			s := calc.Describe(compactNumbers(xs))
+			fmt.Fprintf(&sb, "%s (`%s`):\n%s\n", table.Columns[i], calc.Ident(table.Columns[i]), s)
+			if missing := len(xs) - n; missing > 0 {
+				fmt.Fprintf(&sb, "non-numeric or empty: %d\n", missing)
+			}
+			sb.WriteString("\n")
+		}
+		if described == 0 {
+			return ErrorResult("the table has no numeric columns")
+		}
+	}
+	if sb.Len() == 0 {
+		return ErrorResult("expression is required (or pass data or path to describe)")
+	}
+	return NewToolResult(strings.TrimSpace(sb.String()))
+}
+
+// compactNumbers drops the NaNs that mark non-numeric cells.
+func compactNumbers(xs []float64) []float64 {
+	out := make([]float64, 0, len(xs))
+	for _, x := range xs {
+		if !math.IsNaN(x) {
+			out = append(out, x)
+		}
+	}
+	return out
+}
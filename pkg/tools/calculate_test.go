@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testMeasurementsCSV = "Subject,Height (cm),Weight\nA,160,55\nB,170,68\nC,,72\nD,180,80\n"
+
+func TestCalculateTool_Expression(t *testing.T) {
+	tool := NewCalculateTool(t.TempDir(), true)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"expression": "60 km / 45 min to mph"})
+	if result.IsError || result.ForLLM != "60 km / 45 min to mph = 49.70969538 mph" {
+		t.Errorf("unexpected result: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{
+		"expression": "percentile(data, 50) + count(data)",
+		"data":       []interface{}{3.0, 1.0, 2.0},
+	})
+	if result.IsError || !strings.HasSuffix(result.ForLLM, "= 5") {
+		t.Errorf("unexpected result: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"expression": "2 km + 1 kg"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "incompatible units") {
+		t.Errorf("expected a unit error, got: %s", result.ForLLM)
+	}
+	if result := tool.Execute(context.Background(), map[string]interface{}{}); !result.IsError {
+		t.Error("expected an error without expression or data")
+	}
+}
+
+func TestCalculateTool_Table(t *testing.T) {
+	workspace := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workspace, "people.csv"), []byte(testMeasurementsCSV), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewCalculateTool(workspace, true)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"path": "people.csv", "expression": "mean(height_cm) * 1 cm to ft"})
+	if result.IsError || !strings.HasSuffix(result.ForLLM, "= 5.577427822 ft") {
+		t.Errorf("unexpected result: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"path": "people.csv", "expression": "mean(height)"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "available lists: height_cm, weight") {
+		t.Errorf("expected the available columns in the error, got: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"path": "people.csv"})
+	for _, want := range []string{"Height (cm) (`height_cm`):\ncount: 3", "non-numeric or empty: 1", "Weight (`weight`):\ncount: 4\nsum: 275"} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("summary missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+	if strings.Contains(result.ForLLM, "Subject") {
+		t.Errorf("text columns should not be described:\n%s", result.ForLLM)
+	}
+
+	if result := tool.Execute(context.Background(), map[string]interface{}{"path": "../outside.csv"}); !result.IsError {
+		t.Error("expected paths outside the workspace to be rejected")
+	}
+}
@@ -0,0 +1,331 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// chromeStartTimeout bounds how long we wait for Chromium to print its
+// DevTools endpoint.
+const chromeStartTimeout = 20 * time.Second
+
+// chromeCandidates are the executable names tried when no path is configured.
+var chromeCandidates = []string{
+	"chromium", "chromium-browser", "google-chrome", "google-chrome-stable",
+	"chrome", "headless_shell", "microsoft-edge",
+	"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+	"/Applications/Chromium.app/Contents/MacOS/Chromium",
+}
+
+// FindChrome returns the configured browser path if it exists, otherwise the
+// first Chromium-based browser found on PATH or in the usual install
+// locations, or "" if there is none.
+func FindChrome(configured string) string {
+	if configured != "" {
+		if p, err := exec.LookPath(configured); err == nil {
+			return p
+		}
+		return ""
+	}
+	for _, name := range chromeCandidates {
+		if p, err := exec.LookPath(name); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// cdpMessage is a Chrome DevTools Protocol command, response or event.
+type cdpMessage struct {
+	ID        int64           `json:"id,omitempty"`
+	Method    string          `json:"method,omitempty"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     *cdpError       `json:"error,omitempty"`
+	SessionID string          `json:"sessionId,omitempty"`
+}
+
+type cdpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *cdpError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+type cdpSubscription struct {
+	sessionID string
+	method    string
+	ch        chan cdpMessage
+}
+
+// cdpConn is a connection to a browser's DevTools endpoint. Page sessions are
+// multiplexed over it using flat sessionId routing.
+type cdpConn struct {
+	ws      *websocket.Conn
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan cdpMessage
+	subs    []*cdpSubscription
+	err     error
+	done    chan struct{}
+}
+
+func dialCDP(ctx context.Context, wsURL string) (*cdpConn, error) {
+	dialer := websocket.Dialer{HandshakeTimeout: 10 * time.Second}
+	ws, _, err := dialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to DevTools at %s: %w", wsURL, err)
+	}
+	ws.SetReadLimit(64 << 20) // screenshots arrive as base64 in one message
+	c := &cdpConn{
+		ws:      ws,
+		pending: make(map[int64]chan cdpMessage),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+func (c *cdpConn) readLoop() {
+	defer close(c.done)
+	for {
+		var msg cdpMessage
+		if err := c.ws.ReadJSON(&msg); err != nil {
+			c.mu.Lock()
+			c.err = fmt.Errorf("DevTools connection closed: %w", err)
+			c.mu.Unlock()
+			return
+		}
+
+		c.mu.Lock()
+		if msg.ID != 0 {
+			if ch, ok := c.pending[msg.ID]; ok {
+				delete(c.pending, msg.ID)
+				ch <- msg
+			}
+		} else {
+			for _, sub := range c.subs {
+				if sub.method == msg.Method && sub.sessionID == msg.SessionID {
+					select {
+					case sub.ch <- msg:
+					default: // a slow subscriber only needs one event
+					}
+				}
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+// call sends a command to the browser (sessionID "") or a page session and
+// decodes its result into result, which may be nil.
+func (c *cdpConn) call(ctx context.Context, sessionID, method string, params, result interface{}) error {
+	if params == nil {
+		params = struct{}{}
+	}
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s params: %w", method, err)
+	}
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan cdpMessage, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	c.writeMu.Lock()
+	err = c.ws.WriteJSON(cdpMessage{ID: id, Method: method, Params: raw, SessionID: sessionID})
+	c.writeMu.Unlock()
+	if err != nil {
+		c.forget(id)
+		return fmt.Errorf("failed to send %s: %w", method, err)
+	}
+
+	select {
+	case msg := <-ch:
+		if msg.Error != nil {
+			return fmt.Errorf("%s failed: %w", method, msg.Error)
+		}
+		if result != nil && len(msg.Result) > 0 {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				return fmt.Errorf("failed to decode %s result: %w", method, err)
+			}
+		}
+		return nil
+	case <-ctx.Done():
+		c.forget(id)
+		return ctx.Err()
+	case <-c.done:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.err
+	}
+}
+
+func (c *cdpConn) forget(id int64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// subscribe returns a channel receiving method events of a session. Subscribe
+// before issuing the command that triggers the event.
+func (c *cdpConn) subscribe(sessionID, method string) (<-chan cdpMessage, func()) {
+	sub := &cdpSubscription{sessionID: sessionID, method: method, ch: make(chan cdpMessage, 1)}
+	c.mu.Lock()
+	c.subs = append(c.subs, sub)
+	c.mu.Unlock()
+	return sub.ch, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, s := range c.subs {
+			if s == sub {
+				c.subs = append(c.subs[:i], c.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (c *cdpConn) alive() bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (c *cdpConn) close() {
+	c.ws.Close()
+	<-c.done
+}
+
+// chromeBrowser is a running browser and its DevTools connection.
+type chromeBrowser struct {
+	conn    *cdpConn
+	cmd     *exec.Cmd
+	dataDir string
+	proxy   *browserProxy
+}
+
+// launchChrome starts a headless Chromium with a throwaway profile, sending
+// all of its traffic through a proxy that enforces guard, and connects to its
+// DevTools endpoint.
+func launchChrome(ctx context.Context, path string, guard *outboundGuard) (*chromeBrowser, error) {
+	proxy, err := startBrowserProxy(guard)
+	if err != nil {
+		return nil, err
+	}
+	dataDir, err := os.MkdirTemp("", "summer-browser-")
+	if err != nil {
+		proxy.close()
+		return nil, fmt.Errorf("failed to create browser profile: %w", err)
+	}
+
+	args := []string{
+		"--headless=new",
+		"--remote-debugging-port=0",
+		"--user-data-dir=" + dataDir,
+		"--no-first-run",
+		"--no-default-browser-check",
+		"--disable-extensions",
+		"--disable-background-networking",
+		"--disable-sync",
+		"--disable-gpu",
+		"--mute-audio",
+		"--hide-scrollbars",
+		"--window-size=1280,900",
+		"--proxy-server=http://" + proxy.addr(),
+		// Loopback is bypassed by default; route it through the proxy too.
+		"--proxy-bypass-list=<-loopback>",
+	}
+	if os.Geteuid() == 0 {
+		args = append(args, "--no-sandbox") // Chromium refuses to run as root otherwise
+	}
+	args = append(args, "about:blank")
+
+	cmd := exec.Command(path, args...)
+	cmd.SysProcAttr = chromeSysProcAttr()
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		os.RemoveAll(dataDir)
+		proxy.close()
+		return nil, fmt.Errorf("failed to capture browser output: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dataDir)
+		proxy.close()
+		return nil, fmt.Errorf("failed to start browser: %w", err)
+	}
+
+	b := &chromeBrowser{cmd: cmd, dataDir: dataDir, proxy: proxy}
+
+	wsURL := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			if u, ok := strings.CutPrefix(scanner.Text(), "DevTools listening on "); ok {
+				wsURL <- strings.TrimSpace(u)
+				break
+			}
+		}
+		io.Copy(io.Discard, stderr) // keep the pipe drained
+	}()
+
+	select {
+	case u := <-wsURL:
+		conn, err := dialCDP(ctx, u)
+		if err != nil {
+			b.close()
+			return nil, err
+		}
+		b.conn = conn
+		return b, nil
+	case <-time.After(chromeStartTimeout):
+		b.close()
+		return nil, fmt.Errorf("browser did not start within %s", chromeStartTimeout)
+	case <-ctx.Done():
+		b.close()
+		return nil, ctx.Err()
+	}
+}
+
+func (b *chromeBrowser) close() {
+	if b.conn != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		b.conn.call(ctx, "", "Browser.close", nil, nil)
+		cancel()
+		b.conn.close()
+	}
+	if b.cmd != nil && b.cmd.Process != nil {
+		b.cmd.Process.Kill()
+		b.cmd.Wait()
+	}
+	if b.dataDir != "" {
+		os.RemoveAll(b.dataDir)
+	}
+	if b.proxy != nil {
+		b.proxy.close()
+	}
+}
@@ -0,0 +1,9 @@
+package tools
+
+import "syscall"
+
+// chromeSysProcAttr makes the kernel kill the browser if summer exits without
+// shutting it down.
+func chromeSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Pdeathsig: syscall.SIGKILL}
+}
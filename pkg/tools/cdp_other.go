@@ -0,0 +1,10 @@
+//go:build !linux
+
+package tools
+
+import "syscall"
+
+// chromeSysProcAttr is a no-op on platforms without a parent-death signal.
+func chromeSysProcAttr() *syscall.SysProcAttr {
+	return nil
+}
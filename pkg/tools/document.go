@@ -0,0 +1,367 @@
+package tools
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxDocumentSize         = 50 << 20
+	defaultDocumentMaxChars = 20000
+	maxDocumentChars        = 100000
+)
+
+// docPart is a page, section or sheet of a document.
+type docPart struct {
+	label string
+	text  string
+	// repeat is prepended to continuations when the part is split across
+	// chunks, e.g. the header row of a sheet.
+	repeat string
+}
+
+type document struct {
+	format string // human-readable type, e.g. "PDF"
+	unit   string // what parts are, e.g. "page"
+	meta   [][2]string
+	parts  []docPart
+}
+
+// loadDocument detects the type of a file from its content and extension and
+// extracts its text.
+func loadDocument(path string) (*document, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > maxDocumentSize {
+		return nil, fmt.Errorf("file is too large (%d MB, limit %d MB)", info.Size()>>20, maxDocumentSize>>20)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+
+	switch {
+	case bytes.HasPrefix(bytes.TrimSpace(data[:min(len(data), 1024)]), []byte("%PDF")):
+		pages, meta, err := extractPDFPages(data)
+		if err != nil {
+			return nil, err
+		}
+		doc := &document{format: "PDF", unit: "page", meta: meta}
+		for i, text := range pages {
+			if len(pages) == 1 && text == "" {
+				text = "(no extractable text)"
+			} else if text == "" {
+				text = "(no extractable text on this page)"
+			}
+			doc.parts = append(doc.parts, docPart{label: fmt.Sprintf("Page %d", i+1), text: text})
+		}
+		return doc, nil
+
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open archive: %v", err)
+		}
+		switch {
+		case hasZipEntry(zr, "word/document.xml"):
+			pages, meta, err := extractDOCX(zr)
+			if err != nil {
+				return nil, err
+			}
+			doc := &document{format: "Word document", unit: "page", meta: meta}
+			for i, text := range pages {
+				doc.parts = append(doc.parts, docPart{label: fmt.Sprintf("Page %d", i+1), text: text})
+			}
+			return doc, nil
+		case hasZipEntry(zr, "xl/workbook.xml"):
+			sheets, meta, err := extractXLSX(zr)
+			if err != nil {
+				return nil, err
+			}
+			doc := &document{format: "Excel workbook", unit: "sheet", meta: meta}
+			for i, s := range sheets {
+				doc.parts = append(doc.parts, sheetPart(fmt.Sprintf("Sheet %d: %s", i+1, s.name), s))
+			}
+			return doc, nil
+		}
+		return nil, fmt.Errorf("unsupported archive format (expected .docx or .xlsx)")
+
+	case bytes.HasPrefix(data, []byte("\xD0\xCF\x11\xE0")):
+		return nil, fmt.Errorf("legacy Office formats (.doc, .xls, .ppt) are not supported; convert the file to .docx, .xlsx or PDF")
+
+	case isBinary(data):
+		return nil, fmt.Errorf("unsupported binary file (supported: PDF, DOCX, XLSX, CSV and text)")
+
+	case ext == ".csv" || ext == ".tsv":
+		r := csv.NewReader(bytes.NewReader(data))
+		r.FieldsPerRecord = -1
+		r.LazyQuotes = true
+		if ext == ".tsv" {
+			r.Comma = '\t'
+		}
+		rows, err := r.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", strings.ToUpper(ext[1:]), err)
+		}
+		s := &sheet{name: filepath.Base(path), rows: rows}
+		return &document{format: strings.ToUpper(ext[1:]), unit: "table", parts: []docPart{sheetPart("Table", s)}}, nil
+	}
+
+	text := strings.TrimSpace(string(data))
+	return &document{format: "text", unit: "file", parts: []docPart{{label: "Text", text: text}}}, nil
+}
+
+func sheetPart(label string, s *sheet) docPart {
+	text := s.csvText()
+	part := docPart{
+		label: fmt.Sprintf("%s (%d rows x %d columns)", label, len(s.rows), s.columns()),
+		text:  text,
+	}
+	if text == "" {
+		part.text = "(empty)"
+	} else if i := strings.IndexByte(text, '\n'); i > 0 {
+		part.repeat = text[:i]
+	}
+	return part
+}
+
+// parsePageSpec parses a selection such as "1-3,7" into sorted, distinct
+// one-based numbers no larger than n.
+func parsePageSpec(spec string, n int) ([]int, error) {
+	seen := make(map[int]bool)
+	var out []int
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		lo, hi := field, field
+		if i := strings.Index(field, "-"); i >= 0 {
+			lo, hi = strings.TrimSpace(field[:i]), strings.TrimSpace(field[i+1:])
+		}
+		from, err1 := strconv.Atoi(lo)
+		to, err2 := strconv.Atoi(hi)
+		if hi == "" {
+			to, err2 = n, nil
+		}
+		if err1 != nil || err2 != nil || from < 1 || to < from {
+			return nil, fmt.Errorf("invalid page selection %q (use e.g. \"3\", \"1-5\" or \"2,4-6\")", field)
+		}
+		for p := from; p <= min(to, n); p++ {
+			if !seen[p] {
+				seen[p] = true
+				out = append(out, p)
+			}
+		}
+	}
+	sort.Ints(out)
+	return out, nil
+}
+
+// chunkParts renders parts as "=== label ===" blocks and packs them into
+// chunks of at most about maxChars.
+func chunkParts(parts []docPart, maxChars int) []string {
+	var chunks []string
+	var cur strings.Builder
+	for _, p := range parts {
+		for _, b := range splitPart(p, maxChars) {
+			if cur.Len() > 0 && cur.Len()+len(b)+2 > maxChars {
+				chunks = append(chunks, cur.String())
+				cur.Reset()
+			}
+			if cur.Len() > 0 {
+				cur.WriteString("\n\n")
+			}
+			cur.WriteString(b)
+		}
+	}
+	if cur.Len() > 0 {
+		chunks = append(chunks, cur.String())
+	}
+	return chunks
+}
+
+// splitPart renders a part as blocks of at most maxChars, splitting at line
+// boundaries (and inside lines longer than half a block).
+func splitPart(p docPart, maxChars int) []string {
+	head := "=== " + p.label + " ===\n"
+	if len(head)+len(p.text) <= maxChars {
+		return []string{head + p.text}
+	}
+	var lines []string
+	for _, line := range strings.Split(p.text, "\n") {
+		for len(line) > maxChars/2 {
+			cut := maxChars / 2
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			lines = append(lines, line[:cut])
+			line = line[cut:]
+		}
+		lines = append(lines, line)
+	}
+
+	var blocks []string
+	var cur strings.Builder
+	cur.WriteString(head)
+	body := 0
+	for _, line := range lines {
+		if body > 0 && cur.Len()+len(line)+1 > maxChars {
+			blocks = append(blocks, strings.TrimSuffix(cur.String(), "\n"))
+			cur.Reset()
+			cur.WriteString("=== " + p.label + " (continued) ===\n")
+			if p.repeat != "" {
+				cur.WriteString(p.repeat + "\n")
+			}
+			body = 0
+		}
+		cur.WriteString(line + "\n")
+		body++
+	}
+	return append(blocks, strings.TrimSuffix(cur.String(), "\n"))
+}
+
+// ReadDocumentTool extracts text from PDF, Word and Excel files, CSV and
+// plain text, split by page or sheet, and returns large results in chunks.
+type ReadDocumentTool struct {
+	paths *PathPolicy
+}
+
+func NewReadDocumentTool(workspace string, restrict bool, roots ...PathRoot) *ReadDocumentTool {
+	return &ReadDocumentTool{paths: NewPathPolicy(workspace, restrict, roots...)}
+}
+
+func (t *ReadDocumentTool) Name() string {
+	return "read_document"
+}
+
+func (t *ReadDocumentTool) Description() string {
+	return "Extract the text of a document: PDF (by page), Word .docx (by page break), Excel .xlsx (each sheet as CSV), CSV/TSV or plain text, " +
+		"with its metadata (title, author, page count). Use this instead of read_file for attachments and other binary documents. " +
+		"Long documents are returned in chunks; request the next one with chunk."
+}
+
+func (t *ReadDocumentTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Path to the document",
+			},
+			"pages": map[string]interface{}{
+				"type":        "string",
+				"description": "Pages (or sheets) to read, e.g. \"3\", \"1-5\" or \"2,4-6\" (default: all)",
+			},
+			"sheet": map[string]interface{}{
+				"type":        "string",
+				"description": "For spreadsheets, the name of the sheet to read",
+			},
+			"chunk": map[string]interface{}{
+				"type":        "integer",
+				"description": "Which chunk of a long result to return, starting at 1 (default 1)",
+			},
+			"max_chars": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum characters per chunk (default 20000, max 100000)",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+func (t *ReadDocumentTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	path, _ := args["path"].(string)
+	if path == "" {
+		return ErrorResult("path is required")
+	}
+	resolved, err := t.paths.Resolve(path, PathRead)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	maxChars := defaultDocumentMaxChars
+	if v, ok := args["max_chars"].(float64); ok && v > 0 {
+		maxChars = max(min(int(v), maxDocumentChars), 1000)
+	}
+	chunk := 1
+	if v, ok := args["chunk"].(float64); ok && v >= 1 {
+		chunk = int(v)
+	}
+
+	doc, err := loadDocument(resolved)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to read document: %v", err))
+	}
+
+	parts := doc.parts
+	if name, _ := args["sheet"].(string); name != "" {
+		parts = nil
+		for _, p := range doc.parts {
+			if label := p.label; strings.Contains(strings.ToLower(label), ": "+strings.ToLower(name)+" (") {
+				parts = append(parts, p)
+			}
+		}
+		if len(parts) == 0 {
+			return ErrorResult(fmt.Sprintf("no sheet named %q; available: %s", name, documentPartLabels(doc)))
+		}
+	}
+	if spec, _ := args["pages"].(string); spec != "" {
+		selected, err := parsePageSpec(spec, len(parts))
+		if err != nil {
+			return ErrorResult(err.Error())
+		}
+		if len(selected) == 0 {
+			return ErrorResult(fmt.Sprintf("pages %q are out of range: the document has %d %s(s)", spec, len(parts), doc.unit))
+		}
+		var picked []docPart
+		for _, n := range selected {
+			picked = append(picked, parts[n-1])
+		}
+		parts = picked
+	}
+
+	chunks := chunkParts(parts, maxChars)
+	if chunk > len(chunks) {
+		return ErrorResult(fmt.Sprintf("chunk %d does not exist: the result has %d chunk(s)", chunk, len(chunks)))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Document: %s\nType: %s, %d %s(s)\n", path, doc.format, len(doc.parts), doc.unit)
+	if chunk == 1 {
+		for _, kv := range doc.meta {
+			fmt.Fprintf(&sb, "%s: %s\n", kv[0], kv[1])
+		}
+	}
+	if len(chunks) > 1 {
+		fmt.Fprintf(&sb, "Chunk %d of %d\n", chunk, len(chunks))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(chunks[chunk-1])
+	if chunk < len(chunks) {
+		fmt.Fprintf(&sb, "\n\n[Chunk %d of %d. Call read_document with chunk=%d for the rest.]", chunk, len(chunks), chunk+1)
+	}
+	return NewToolResult(sb.String())
+}
+
+func documentPartLabels(doc *document) string {
+	labels := make([]string, len(doc.parts))
+	for i, p := range doc.parts {
+		labels[i] = p.label
+	}
+	return strings.Join(labels, ", ")
+}
@@ -0,0 +1,421 @@
+package tools
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxZipEntrySize caps how much of a single Office part is decompressed.
+const maxZipEntrySize = 100 << 20
+
+func readZipEntry(zr *zip.Reader, name string) ([]byte, error) {
+	for _, f := range zr.File {
+		if f.Name == name {
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return io.ReadAll(io.LimitReader(rc, maxZipEntrySize))
+		}
+	}
+	return nil, fmt.Errorf("%s not found", name)
+}
+
+func hasZipEntry(zr *zip.Reader, name string) bool {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// officeCoreProperties reads title, author and dates from docProps/core.xml.
+func officeCoreProperties(zr *zip.Reader) [][2]string {
+	data, err := readZipEntry(zr, "docProps/core.xml")
+	if err != nil {
+		return nil
+	}
+	var core struct {
+		Title    string `xml:"title"`
+		Subject  string `xml:"subject"`
+		Creator  string `xml:"creator"`
+		Keywords string `xml:"keywords"`
+		Created  string `xml:"created"`
+		Modified string `xml:"modified"`
+	}
+	if xml.Unmarshal(data, &core) != nil {
+		return nil
+	}
+	var out [][2]string
+	for _, kv := range [][2]string{
+		{"Title", core.Title},
+		{"Subject", core.Subject},
+		{"Author", core.Creator},
+		{"Keywords", core.Keywords},
+		{"Created", core.Created},
+		{"Modified", core.Modified},
+	} {
+		if v := strings.TrimSpace(kv[1]); v != "" {
+			out = append(out, [2]string{kv[0], v})
+		}
+	}
+	return out
+}
+
+// extractDOCX returns the text of a Word document split at page breaks:
+// explicit ones and, when Word saved them, the rendered ones. Headings are
+// prefixed with "#" and table cells separated by " | ".
+func extractDOCX(zr *zip.Reader) ([]string, [][2]string, error) {
+	data, err := readZipEntry(zr, "word/document.xml")
+	if err != nil {
+		return nil, nil, fmt.Errorf("not a Word document: %v", err)
+	}
+
+	var pages []string
+	var page, para strings.Builder
+	heading := 0
+	cellInRow := 0
+	parasInCell := 0
+	inTable := 0
+	flushPage := func() {
+		pages = append(pages, strings.TrimSpace(collapseBlankLines(page.String())))
+		page.Reset()
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse document.xml: %v", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				para.Reset()
+				heading = 0
+				if inTable > 0 {
+					// Paragraphs within a cell are joined by spaces.
+					if parasInCell > 0 {
+						page.WriteString(" ")
+					}
+					parasInCell++
+				}
+			case "pStyle":
+				if v := xmlAttr(t, "val"); strings.HasPrefix(strings.ToLower(v), "heading") {
+					heading, _ = strconv.Atoi(strings.TrimPrefix(strings.ToLower(v), "heading"))
+					if heading <= 0 {
+						heading = 1
+					}
+				} else if strings.EqualFold(v, "Title") {
+					heading = 1
+				}
+			case "t":
+				var text string
+				if dec.DecodeElement(&text, &t) == nil {
+					para.WriteString(text)
+				}
+			case "tab":
+				para.WriteString("\t")
+			case "br", "cr":
+				if xmlAttr(t, "type") == "page" {
+					page.WriteString(para.String())
+					para.Reset()
+					flushPage()
+				} else {
+					para.WriteString("\n")
+				}
+			case "lastRenderedPageBreak":
+				if page.Len() > 0 {
+					flushPage()
+				}
+			case "tbl":
+				inTable++
+			case "tr":
+				cellInRow = 0
+			case "tc":
+				if cellInRow > 0 {
+					page.WriteString(" | ")
+				}
+				cellInRow++
+				parasInCell = 0
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "p":
+				text := para.String()
+				if heading > 0 && strings.TrimSpace(text) != "" {
+					text = strings.Repeat("#", min(heading, 6)) + " " + text
+				}
+				page.WriteString(text)
+				if inTable == 0 {
+					page.WriteString("\n")
+				}
+				para.Reset()
+			case "tr":
+				page.WriteString("\n")
+			case "tbl":
+				inTable--
+				page.WriteString("\n")
+			}
+		}
+	}
+	flushPage()
+
+	// Drop empty pages from breaks at the very start or end.
+	for len(pages) > 1 && pages[len(pages)-1] == "" {
+		pages = pages[:len(pages)-1]
+	}
+	for len(pages) > 1 && pages[0] == "" {
+		pages = pages[1:]
+	}
+	return pages, officeCoreProperties(zr), nil
+}
+
+func xmlAttr(el xml.StartElement, local string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// sheet is one worksheet of a spreadsheet as rows of cell values.
+type sheet struct {
+	name string
+	rows [][]string
+}
+
+// csvText renders the sheet as CSV, dropping trailing empty cells and rows.
+func (s *sheet) csvText() string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	for _, row := range s.rows {
+		for len(row) > 0 && row[len(row)-1] == "" {
+			row = row[:len(row)-1]
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+func (s *sheet) columns() int {
+	cols := 0
+	for _, row := range s.rows {
+		n := len(row)
+		for n > 0 && row[n-1] == "" {
+			n--
+		}
+		cols = max(cols, n)
+	}
+	return cols
+}
+
+// extractXLSX reads the cell values of every worksheet. Formulas come back
+// as their cached results; number formats (dates included) are not applied.
+func extractXLSX(zr *zip.Reader) ([]*sheet, [][2]string, error) {
+	data, err := readZipEntry(zr, "xl/workbook.xml")
+	if err != nil {
+		return nil, nil, fmt.Errorf("not an Excel workbook: %v", err)
+	}
+	var wb struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(data, &wb); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse workbook.xml: %v", err)
+	}
+
+	targets := map[string]string{}
+	if data, err := readZipEntry(zr, "xl/_rels/workbook.xml.rels"); err == nil {
+		var rels struct {
+			Rels []struct {
+				ID     string `xml:"Id,attr"`
+				Target string `xml:"Target,attr"`
+			} `xml:"Relationship"`
+		}
+		if xml.Unmarshal(data, &rels) == nil {
+			for _, r := range rels.Rels {
+				target := strings.TrimPrefix(r.Target, "/")
+				if !strings.HasPrefix(target, "xl/") {
+					target = path.Join("xl", target)
+				}
+				targets[r.ID] = target
+			}
+		}
+	}
+
+	var shared []string
+	if data, err := readZipEntry(zr, "xl/sharedStrings.xml"); err == nil {
+		shared = parseSharedStrings(data)
+	}
+
+	var sheets []*sheet
+	for i, s := range wb.Sheets {
+		target, ok := targets[s.RID]
+		if !ok {
+			target = fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
+		}
+		data, err := readZipEntry(zr, target)
+		if err != nil {
+			continue
+		}
+		rows, err := parseWorksheet(data, shared)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse sheet %q: %v", s.Name, err)
+		}
+		sheets = append(sheets, &sheet{name: s.Name, rows: rows})
+	}
+	if len(sheets) == 0 {
+		return nil, nil, fmt.Errorf("workbook has no readable sheets")
+	}
+	return sheets, officeCoreProperties(zr), nil
+}
+
+func parseSharedStrings(data []byte) []string {
+	var out []string
+	var cur strings.Builder
+	inSI := false
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return out
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				inSI = true
+				cur.Reset()
+			case "t":
+				var text string
+				if inSI && dec.DecodeElement(&text, &t) == nil {
+					cur.WriteString(text)
+				}
+			case "rPh":
+				// Phonetic hints repeat the text; skip them.
+				dec.Skip()
+			}
+		case xml.EndElement:
+			if t.Name.Local == "si" {
+				out = append(out, cur.String())
+				inSI = false
+			}
+		}
+	}
+}
+
+// parseWorksheet returns the cell values of a worksheet, placed by their
+// references so that gaps stay aligned.
+func parseWorksheet(data []byte, shared []string) ([][]string, error) {
+	var ws struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline struct {
+					Text string `xml:",innerxml"`
+				} `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(data, &ws); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for i, r := range ws.Rows {
+		rowNum := r.R
+		if rowNum <= 0 {
+			rowNum = i + 1
+		}
+		if rowNum > len(rows)+100000 {
+			break // absurd gap; stop rather than allocate
+		}
+		for len(rows) < rowNum {
+			rows = append(rows, nil)
+		}
+		var row []string
+		for j, c := range r.Cells {
+			col := j
+			if c.Ref != "" {
+				if n, ok := cellColumn(c.Ref); ok {
+					col = n
+				}
+			}
+			if col > 16384 {
+				continue
+			}
+			var v string
+			switch c.Type {
+			case "s":
+				if idx, err := strconv.Atoi(strings.TrimSpace(c.Value)); err == nil && idx >= 0 && idx < len(shared) {
+					v = shared[idx]
+				}
+			case "inlineStr":
+				v = xmlInnerText(c.Inline.Text)
+			case "b":
+				v = map[string]string{"1": "TRUE", "0": "FALSE"}[c.Value]
+			default:
+				v = c.Value
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+			row[col] = v
+		}
+		rows[rowNum-1] = row
+	}
+	for len(rows) > 0 && len(rows[len(rows)-1]) == 0 {
+		rows = rows[:len(rows)-1]
+	}
+	return rows, nil
+}
+
+// cellColumn returns the zero-based column of a reference like "AB12".
+func cellColumn(ref string) (int, bool) {
+	col := 0
+	i := 0
+	for i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z' {
+		col = col*26 + int(ref[i]-'A'+1)
+		i++
+	}
+	if i == 0 {
+		return 0, false
+	}
+	return col - 1, true
+}
+
+// xmlInnerText concatenates the character data of an XML fragment.
+func xmlInnerText(fragment string) string {
+	var sb strings.Builder
+	dec := xml.NewDecoder(strings.NewReader("<x>" + fragment + "</x>"))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return sb.String()
+		}
+		if cd, ok := tok.(xml.CharData); ok {
+			sb.Write(cd)
+		}
+	}
+}
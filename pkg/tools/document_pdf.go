@@ -0,0 +1,343 @@
+package tools
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	pdfObjRe       = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	pdfStreamKwRe  = regexp.MustCompile(`stream\r?\n`)
+	pdfRefRe       = regexp.MustCompile(`(\d+)\s+\d+\s+R\b`)
+	pdfTypePageRe  = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfTypePagesRe = regexp.MustCompile(`/Type\s*/Pages\b`)
+)
+
+// maxPDFPages bounds the page tree walk of malformed or hostile files.
+const maxPDFPages = 10000
+
+type pdfObject struct {
+	dict   string // the object text before "stream", or all of it
+	stream []byte // decoded stream data, nil if none or undecodable
+}
+
+// pdfFile is a PDF split into its numbered objects, enough to walk the page
+// tree and read the document information dictionary.
+type pdfFile struct {
+	data    []byte
+	objects map[int]*pdfObject
+}
+
+// parsePDF indexes the objects of a PDF, including those packed into object
+// streams. Later definitions win, as with incremental updates.
+func parsePDF(data []byte) (*pdfFile, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("%PDF")) {
+		return nil, fmt.Errorf("not a PDF file")
+	}
+	f := &pdfFile{data: data, objects: make(map[int]*pdfObject)}
+	var objStreams []*pdfObject
+
+	for pos := 0; pos < len(data); {
+		loc := pdfObjRe.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		num, _ := strconv.Atoi(string(data[pos+loc[2] : pos+loc[3]]))
+		bodyStart := pos + loc[1]
+		rest := data[bodyStart:]
+
+		endObj := bytes.Index(rest, []byte("endobj"))
+		if endObj < 0 {
+			endObj = len(rest)
+		}
+		obj := &pdfObject{}
+		next := bodyStart + endObj
+		if s := pdfStreamKwRe.FindIndex(rest); s != nil && s[0] < endObj && bytes.Contains(rest[:s[0]], []byte(">>")) {
+			obj.dict = string(rest[:s[0]])
+			start := bodyStart + s[1]
+			end := -1
+			if length, ok := pdfInt(obj.dict, "Length"); ok && start+length <= len(data) &&
+				bytes.HasPrefix(bytes.TrimLeft(data[start+length:], "\r\n "), []byte("endstream")) {
+				end = length
+			} else if end = bytes.Index(data[start:], []byte("endstream")); end < 0 {
+				break
+			}
+			obj.stream = decodePDFStream(obj.dict, data[start:start+end])
+			next = start + end
+		} else {
+			obj.dict = string(rest[:endObj])
+		}
+		f.objects[num] = obj
+		if strings.Contains(obj.dict, "/ObjStm") {
+			objStreams = append(objStreams, obj)
+		}
+		pos = next
+	}
+
+	for _, stm := range objStreams {
+		f.unpackObjectStream(stm)
+	}
+	if len(f.objects) == 0 {
+		return nil, fmt.Errorf("no PDF objects found")
+	}
+	return f, nil
+}
+
+// decodePDFStream returns the decoded data of a stream, or nil if it uses a
+// filter other than FlateDecode.
+func decodePDFStream(dict string, raw []byte) []byte {
+	if strings.Contains(dict, "/FlateDecode") {
+		r, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil
+		}
+		defer r.Close()
+		out, err := io.ReadAll(io.LimitReader(r, maxPDFStreamSize))
+		if err != nil && len(out) == 0 {
+			return nil
+		}
+		return out
+	}
+	if strings.Contains(dict, "/Filter") {
+		return nil
+	}
+	return raw
+}
+
+// unpackObjectStream adds the objects stored in an /ObjStm stream.
+func (f *pdfFile) unpackObjectStream(obj *pdfObject) {
+	n, ok1 := pdfInt(obj.dict, "N")
+	first, ok2 := pdfInt(obj.dict, "First")
+	if !ok1 || !ok2 || obj.stream == nil || first > len(obj.stream) {
+		return
+	}
+	header := strings.Fields(string(obj.stream[:first]))
+	type entry struct{ num, off int }
+	var entries []entry
+	for i := 0; i+1 < len(header) && len(entries) < n; i += 2 {
+		num, err1 := strconv.Atoi(header[i])
+		off, err2 := strconv.Atoi(header[i+1])
+		if err1 != nil || err2 != nil {
+			return
+		}
+		entries = append(entries, entry{num, off})
+	}
+	for i, e := range entries {
+		start := first + e.off
+		end := len(obj.stream)
+		if i+1 < len(entries) {
+			end = first + entries[i+1].off
+		}
+		if start < 0 || start > end || end > len(obj.stream) {
+			continue
+		}
+		if _, exists := f.objects[e.num]; !exists {
+			f.objects[e.num] = &pdfObject{dict: string(obj.stream[start:end])}
+		}
+	}
+}
+
+// pdfIntRes match direct integer entries; a trailing "0 R" marks an
+// indirect value, which pdfInt does not follow.
+var pdfIntRes = map[string]*regexp.Regexp{
+	"Length": regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`),
+	"N":      regexp.MustCompile(`/N\s+(\d+)(\s+\d+\s+R)?`),
+	"First":  regexp.MustCompile(`/First\s+(\d+)(\s+\d+\s+R)?`),
+}
+
+func pdfInt(dict, key string) (int, bool) {
+	m := pdfIntRes[key].FindStringSubmatch(dict)
+	if m == nil || m[2] != "" {
+		return 0, false
+	}
+	v, err := strconv.Atoi(m[1])
+	return v, err == nil
+}
+
+// refs returns the object numbers referenced by key in dict, following one
+// indirection to an array object.
+func (f *pdfFile) refs(dict, key string) []int {
+	i := strings.Index(dict, "/"+key)
+	if i < 0 {
+		return nil
+	}
+	rest := strings.TrimLeft(dict[i+len(key)+1:], " \t\r\n")
+	if strings.HasPrefix(rest, "[") {
+		if end := strings.Index(rest, "]"); end >= 0 {
+			return parsePDFRefs(rest[:end])
+		}
+		return nil
+	}
+	m := pdfRefRe.FindStringSubmatchIndex(rest)
+	if m == nil || m[0] != 0 {
+		return nil
+	}
+	num, _ := strconv.Atoi(rest[m[2]:m[3]])
+	if obj := f.objects[num]; obj != nil && obj.stream == nil {
+		if body := strings.TrimSpace(obj.dict); strings.HasPrefix(body, "[") {
+			return parsePDFRefs(body)
+		}
+	}
+	return []int{num}
+}
+
+func parsePDFRefs(s string) []int {
+	var out []int
+	for _, m := range pdfRefRe.FindAllStringSubmatch(s, -1) {
+		if n, err := strconv.Atoi(m[1]); err == nil {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// trailerRef finds a reference such as /Root or /Info in the last trailer or
+// cross-reference stream dictionary.
+func (f *pdfFile) trailerRef(key string) (int, bool) {
+	re := regexp.MustCompile(`/` + key + `\s+(\d+)\s+\d+\s+R`)
+	all := re.FindAllSubmatch(f.data, -1)
+	if len(all) == 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(string(all[len(all)-1][1]))
+	return n, err == nil
+}
+
+// pages returns the page objects in document order.
+func (f *pdfFile) pages() []*pdfObject {
+	root, ok := f.trailerRef("Root")
+	var pagesRoot []int
+	if ok && f.objects[root] != nil {
+		pagesRoot = f.refs(f.objects[root].dict, "Pages")
+	}
+	if len(pagesRoot) == 0 {
+		// No usable catalog: start from every root-looking /Pages node.
+		for _, num := range sortedPDFObjectNumbers(f.objects) {
+			if d := f.objects[num].dict; pdfTypePagesRe.MatchString(d) && !strings.Contains(d, "/Parent") {
+				pagesRoot = append(pagesRoot, num)
+			}
+		}
+	}
+
+	var out []*pdfObject
+	seen := make(map[int]bool)
+	var walk func(num int)
+	walk = func(num int) {
+		obj := f.objects[num]
+		if obj == nil || seen[num] || len(out) >= maxPDFPages {
+			return
+		}
+		seen[num] = true
+		switch {
+		case pdfTypePagesRe.MatchString(obj.dict):
+			for _, kid := range f.refs(obj.dict, "Kids") {
+				walk(kid)
+			}
+		case pdfTypePageRe.MatchString(obj.dict):
+			out = append(out, obj)
+		}
+	}
+	for _, num := range pagesRoot {
+		walk(num)
+	}
+	return out
+}
+
+func sortedPDFObjectNumbers(objects map[int]*pdfObject) []int {
+	nums := make([]int, 0, len(objects))
+	for n := range objects {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+	return nums
+}
+
+// info returns the document information entries that are present.
+func (f *pdfFile) info() [][2]string {
+	num, ok := f.trailerRef("Info")
+	if !ok || f.objects[num] == nil {
+		return nil
+	}
+	dict := f.objects[num].dict
+	var out [][2]string
+	for _, key := range []string{"Title", "Author", "Subject", "Keywords", "Creator", "Producer", "CreationDate"} {
+		if v := pdfStringValue(dict, key); v != "" {
+			out = append(out, [2]string{key, v})
+		}
+	}
+	return out
+}
+
+// pdfStringValue reads a literal or hex string value from a dictionary.
+func pdfStringValue(dict, key string) string {
+	re := regexp.MustCompile(`/` + key + `\s*([(<])`)
+	loc := re.FindStringSubmatchIndex(dict)
+	if loc == nil {
+		return ""
+	}
+	start := loc[2]
+	var v string
+	if dict[start] == '(' {
+		v, _ = readPDFLiteral([]byte(dict), start)
+	} else {
+		end := strings.IndexByte(dict[start:], '>')
+		if end < 0 {
+			return ""
+		}
+		v = decodePDFHex(dict[start+1:start+end], nil)
+	}
+	v = strings.TrimSpace(v)
+	if key == "CreationDate" && strings.HasPrefix(v, "D:") && len(v) >= 10 {
+		v = v[2:6] + "-" + v[6:8] + "-" + v[8:10]
+	}
+	return v
+}
+
+// extractPDFPages returns the text of each page and the document
+// information. Pages without extractable text are returned empty. If the
+// page tree cannot be walked, the whole text comes back as one page.
+func extractPDFPages(data []byte) ([]string, [][2]string, error) {
+	f, err := parsePDF(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	cmap := map[string]string{}
+	for _, num := range sortedPDFObjectNumbers(f.objects) {
+		if s := f.objects[num].stream; s != nil && bytes.Contains(s, []byte("begincmap")) {
+			parsePDFCMap(s, cmap)
+		}
+	}
+
+	pages := f.pages()
+	var texts []string
+	found := false
+	for _, page := range pages {
+		var sb strings.Builder
+		for _, ref := range f.refs(page.dict, "Contents") {
+			if obj := f.objects[ref]; obj != nil && obj.stream != nil {
+				sb.WriteString(pdfContentText(obj.stream, cmap))
+				sb.WriteString("\n")
+			}
+		}
+		text := strings.TrimSpace(collapseBlankLines(sb.String()))
+		if text != "" {
+			found = true
+		}
+		texts = append(texts, text)
+	}
+
+	if !found {
+		text, err := extractPDFText(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		texts = []string{text}
+	}
+	return texts, f.info(), nil
+}
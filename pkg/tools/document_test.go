@@ -0,0 +1,253 @@
+package tools
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildTestPDF writes a PDF with one page per entry of pages, listed in a
+// nested page tree, and an information dictionary.
+func buildTestPDF(t *testing.T, path string, pages []string) {
+	t.Helper()
+	var objs []string
+	objs = append(objs, "<< /Type /Catalog /Pages 2 0 R >>")
+	// Object 2 is the root /Pages node holding an intermediate node (3), so
+	// that the walk has to recurse.
+	var kids []string
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5+2*i))
+	}
+	objs = append(objs, fmt.Sprintf("<< /Type /Pages /Kids [3 0 R] /Count %d >>", len(pages)))
+	objs = append(objs, fmt.Sprintf("<< /Type /Pages /Parent 2 0 R /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	objs = append(objs, "<< /Title (Quarterly Report) /Author (Jane Doe) /CreationDate (D:20240315120000Z) >>")
+	for i, text := range pages {
+		objs = append(objs, fmt.Sprintf("<< /Type /Page /Parent 3 0 R /Contents %d 0 R >>", 6+2*i))
+		content := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		objs = append(objs, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	for i, obj := range objs {
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	buf.WriteString("trailer\n<< /Root 1 0 R /Info 4 0 R >>\n%%EOF\n")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// buildTestZip writes a zip archive with the given entries.
+func buildTestZip(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readDocument(t *testing.T, tool *ReadDocumentTool, args map[string]interface{}) string {
+	t.Helper()
+	result := tool.Execute(context.Background(), args)
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	return result.ForLLM
+}
+
+func TestReadDocument_PDF(t *testing.T) {
+	dir := t.TempDir()
+	buildTestPDF(t, filepath.Join(dir, "report.pdf"), []string{"Revenue grew", "Costs fell", "Outlook stable"})
+	tool := NewReadDocumentTool(dir, true)
+
+	out := readDocument(t, tool, map[string]interface{}{"path": "report.pdf"})
+	for _, want := range []string{
+		"Type: PDF, 3 page(s)",
+		"Title: Quarterly Report",
+		"Author: Jane Doe",
+		"CreationDate: 2024-03-15",
+		"=== Page 1 ===\nRevenue grew",
+		"=== Page 3 ===\nOutlook stable",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	out = readDocument(t, tool, map[string]interface{}{"path": "report.pdf", "pages": "2-"})
+	if strings.Contains(out, "Revenue grew") || !strings.Contains(out, "Costs fell") || !strings.Contains(out, "Outlook stable") {
+		t.Errorf("pages 2- selected the wrong pages:\n%s", out)
+	}
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"path": "report.pdf", "pages": "9"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "out of range") {
+		t.Errorf("expected out of range error, got: %s", result.ForLLM)
+	}
+}
+
+func TestReadDocument_DOCX(t *testing.T) {
+	dir := t.TempDir()
+	body := `<?xml version="1.0"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Introduction</w:t></w:r></w:p>
+<w:p><w:r><w:t xml:space="preserve">First page </w:t></w:r><w:r><w:t>text.</w:t></w:r></w:p>
+<w:p><w:r><w:br w:type="page"/></w:r></w:p>
+<w:tbl><w:tr><w:tc><w:p><w:r><w:t>Name</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Score</w:t></w:r></w:p></w:tc></w:tr>
+<w:tr><w:tc><w:p><w:r><w:t>Ada</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>42</w:t></w:r></w:p></w:tc></w:tr></w:tbl>
+</w:body></w:document>`
+	core := `<?xml version="1.0"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title>Design Notes</dc:title><dc:creator>Ada</dc:creator></cp:coreProperties>`
+	buildTestZip(t, filepath.Join(dir, "notes.docx"), map[string]string{
+		"word/document.xml": body,
+		"docProps/core.xml": core,
+	})
+
+	out := readDocument(t, NewReadDocumentTool(dir, true), map[string]interface{}{"path": "notes.docx"})
+	for _, want := range []string{
+		"Type: Word document, 2 page(s)",
+		"Title: Design Notes",
+		"=== Page 1 ===\n# Introduction\nFirst page text.",
+		"=== Page 2 ===\nName | Score\nAda | 42",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestReadDocument_XLSX(t *testing.T) {
+	dir := t.TempDir()
+	buildTestZip(t, filepath.Join(dir, "data.xlsx"), map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Sales" sheetId="1" r:id="rId1"/><sheet name="Notes" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>Region</t></si><si><t>Total</t></si><si><t>North</t></si><si><r><t>So</t></r><r><t>uth</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>120</v></c></row>
+<row r="4"><c r="A4" t="s"><v>3</v></c><c r="C4" t="inlineStr"><is><t>late</t></is></c></row>
+</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData><row r="1"><c r="A1" t="b"><v>1</v></c></row></sheetData></worksheet>`,
+	})
+	tool := NewReadDocumentTool(dir, true)
+
+	out := readDocument(t, tool, map[string]interface{}{"path": "data.xlsx"})
+	for _, want := range []string{
+		"Type: Excel workbook, 2 sheet(s)",
+		"=== Sheet 1: Sales (4 rows x 3 columns) ===\nRegion,Total\nNorth,120\n\nSouth,,late",
+		"=== Sheet 2: Notes (1 rows x 1 columns) ===\nTRUE",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	out = readDocument(t, tool, map[string]interface{}{"path": "data.xlsx", "sheet": "notes"})
+	if strings.Contains(out, "Sales") || !strings.Contains(out, "TRUE") {
+		t.Errorf("sheet selection returned:\n%s", out)
+	}
+	result := tool.Execute(context.Background(), map[string]interface{}{"path": "data.xlsx", "sheet": "Missing"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "Sheet 1: Sales") {
+		t.Errorf("expected error listing sheets, got: %s", result.ForLLM)
+	}
+}
+
+func TestReadDocument_CSVChunks(t *testing.T) {
+	dir := t.TempDir()
+	var sb strings.Builder
+	sb.WriteString("id,name,comment\n")
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&sb, "%d,item %d,\"a comment, with a comma\"\n", i, i)
+	}
+	os.WriteFile(filepath.Join(dir, "items.csv"), []byte(sb.String()), 0644)
+	tool := NewReadDocumentTool(dir, true)
+
+	first := readDocument(t, tool, map[string]interface{}{"path": "items.csv", "max_chars": 2000.0})
+	if !strings.Contains(first, "Chunk 1 of ") || !strings.Contains(first, "chunk=2") {
+		t.Fatalf("expected chunked output, got:\n%s", first)
+	}
+	if !strings.Contains(first, "(201 rows x 3 columns)") {
+		t.Errorf("expected table dimensions in:\n%s", first)
+	}
+	second := readDocument(t, tool, map[string]interface{}{"path": "items.csv", "max_chars": 2000.0, "chunk": 2.0})
+	if !strings.Contains(second, "(continued) ===\nid,name,comment\n") {
+		t.Errorf("expected header row repeated in continuation:\n%s", second)
+	}
+	if strings.Contains(second, "item 0,") {
+		t.Errorf("second chunk repeats rows from the first:\n%s", second)
+	}
+	if len(second) > 2500 {
+		t.Errorf("chunk is %d chars, want about 2000", len(second))
+	}
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"path": "items.csv", "max_chars": 2000.0, "chunk": 99.0})
+	if !result.IsError {
+		t.Errorf("expected error for a chunk past the end")
+	}
+}
+
+func TestReadDocument_Rejects(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "old.doc"), []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1rest"), 0644)
+	os.WriteFile(filepath.Join(dir, "blob.bin"), []byte("\x00\x01\x02binary"), 0644)
+	tool := NewReadDocumentTool(dir, true)
+
+	for path, want := range map[string]string{
+		"old.doc":        "legacy Office formats",
+		"blob.bin":       "unsupported binary file",
+		"../outside.pdf": "outside",
+		"missing.pdf":    "no such file",
+	} {
+		result := tool.Execute(context.Background(), map[string]interface{}{"path": path})
+		if !result.IsError || !strings.Contains(result.ForLLM, want) {
+			t.Errorf("%s: expected error containing %q, got: %s", path, want, result.ForLLM)
+		}
+	}
+}
+
+func TestParsePageSpec(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []int
+		err  bool
+	}{
+		{"3", []int{3}, false},
+		{"2,1", []int{1, 2}, false},
+		{"4-6, 1", []int{1, 4, 5}, false},
+		{"4-", []int{4, 5}, false},
+		{"1-2,2-3", []int{1, 2, 3}, false},
+		{"7", nil, false},
+		{"0", nil, true},
+		{"3-1", nil, true},
+		{"x", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parsePageSpec(tt.spec, 5)
+		if (err != nil) != tt.err {
+			t.Errorf("parsePageSpec(%q) error = %v, want error %v", tt.spec, err, tt.err)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("parsePageSpec(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
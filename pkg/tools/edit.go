@@ -10,16 +10,12 @@ import (
 // EditFileTool edits a file by replacing old_text with new_text.
 // The old_text must exist exactly in the file.
 type EditFileTool struct {
-	allowedDir string
-	restrict   bool
+	paths *PathPolicy
 }
 
 // NewEditFileTool creates a new EditFileTool with optional directory restriction.
-func NewEditFileTool(allowedDir string, restrict bool) *EditFileTool {
-	return &EditFileTool{
-		allowedDir: allowedDir,
-		restrict:   restrict,
-	}
+func NewEditFileTool(allowedDir string, restrict bool, roots ...PathRoot) *EditFileTool {
+	return &EditFileTool{paths: NewPathPolicy(allowedDir, restrict, roots...)}
 }
 
 func (t *EditFileTool) Name() string {
@@ -67,7 +63,7 @@ func (t *EditFileTool) Execute(ctx context.Context, args map[string]interface{})
 		return ErrorResult("new_text is required")
 	}
 
-	resolvedPath, err := validatePath(path, t.allowedDir, t.restrict)
+	resolvedPath, err := t.paths.Resolve(path, PathWrite)
 	if err != nil {
 		return ErrorResult(err.Error())
 	}
@@ -102,12 +98,11 @@ func (t *EditFileTool) Execute(ctx context.Context, args map[string]interface{})
 }
 
 type AppendFileTool struct {
-	workspace string
-	restrict  bool
+	paths *PathPolicy
 }
 
-func NewAppendFileTool(workspace string, restrict bool) *AppendFileTool {
-	return &AppendFileTool{workspace: workspace, restrict: restrict}
+func NewAppendFileTool(workspace string, restrict bool, roots ...PathRoot) *AppendFileTool {
+	return &AppendFileTool{paths: NewPathPolicy(workspace, restrict, roots...)}
 }
 
 func (t *AppendFileTool) Name() string {
@@ -146,7 +141,7 @@ func (t *AppendFileTool) Execute(ctx context.Context, args map[string]interface{
 		return ErrorResult("content is required")
 	}
 
-	resolvedPath, err := validatePath(path, t.workspace, t.restrict)
+	resolvedPath, err := t.paths.Resolve(path, PathWrite)
 	if err != nil {
 		return ErrorResult(err.Error())
 	}
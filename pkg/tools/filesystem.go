@@ -1,48 +1,21 @@
 package tools
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
-// validatePath ensures the given path is within the workspace if restrict is true.
-func validatePath(path, workspace string, restrict bool) (string, error) {
-	if workspace == "" {
-		return path, nil
-	}
-
-	absWorkspace, err := filepath.Abs(workspace)
-	if err != nil {
-		return "", fmt.Errorf("failed to resolve workspace path: %w", err)
-	}
-
-	var absPath string
-	if filepath.IsAbs(path) {
-		absPath = filepath.Clean(path)
-	} else {
-		absPath, err = filepath.Abs(filepath.Join(absWorkspace, path))
-		if err != nil {
-			return "", fmt.Errorf("failed to resolve file path: %w", err)
-		}
-	}
-
-	if restrict && !strings.HasPrefix(absPath, absWorkspace) {
-		return "", fmt.Errorf("access denied: path is outside the workspace")
-	}
-
-	return absPath, nil
-}
-
 type ReadFileTool struct {
-	workspace string
-	restrict  bool
+	paths *PathPolicy
 }
 
-func NewReadFileTool(workspace string, restrict bool) *ReadFileTool {
-	return &ReadFileTool{workspace: workspace, restrict: restrict}
+func NewReadFileTool(workspace string, restrict bool, roots ...PathRoot) *ReadFileTool {
+	return &ReadFileTool{paths: NewPathPolicy(workspace, restrict, roots...)}
 }
 
 func (t *ReadFileTool) Name() string {
@@ -50,7 +23,7 @@ func (t *ReadFileTool) Name() string {
 }
 
 func (t *ReadFileTool) Description() string {
-	return "Read the contents of a file"
+	return "Read the contents of a file. For large files, pass start_line and end_line to read only part of it; the lines are returned numbered."
 }
 
 func (t *ReadFileTool) Parameters() map[string]interface{} {
@@ -61,6 +34,14 @@ func (t *ReadFileTool) Parameters() map[string]interface{} {
 				"type":        "string",
 				"description": "Path to the file to read",
 			},
+			"start_line": map[string]interface{}{
+				"type":        "integer",
+				"description": "First line to read, starting at 1 (optional)",
+			},
+			"end_line": map[string]interface{}{
+				"type":        "integer",
+				"description": "Last line to read, inclusive (optional, default: start_line + 499 or the end of the file)",
+			},
 		},
 		"required": []string{"path"},
 	}
@@ -72,11 +53,17 @@ func (t *ReadFileTool) Execute(ctx context.Context, args map[string]interface{})
 		return ErrorResult("path is required")
 	}
 
-	resolvedPath, err := validatePath(path, t.workspace, t.restrict)
+	resolvedPath, err := t.paths.Resolve(path, PathRead)
 	if err != nil {
 		return ErrorResult(err.Error())
 	}
 
+	startLine, hasStart := args["start_line"].(float64)
+	endLine, hasEnd := args["end_line"].(float64)
+	if hasStart || hasEnd {
+		return readLineRange(resolvedPath, int(startLine), int(endLine))
+	}
+
 	content, err := os.ReadFile(resolvedPath)
 	if err != nil {
 		return ErrorResult(fmt.Sprintf("failed to read file: %v", err))
@@ -85,13 +72,62 @@ func (t *ReadFileTool) Execute(ctx context.Context, args map[string]interface{})
 	return NewToolResult(string(content))
 }
 
+// defaultReadLines is the number of lines read_file returns when only
+// start_line is given.
+const defaultReadLines = 500
+
+// readLineRange returns lines start..end (1-based, inclusive) of a file,
+// prefixed with their numbers. The file is streamed, so only the requested
+// lines are held in memory.
+func readLineRange(path string, start, end int) *ToolResult {
+	if start < 1 {
+		start = 1
+	}
+	if end <= 0 {
+		end = start + defaultReadLines - 1
+	}
+	if end < start {
+		return ErrorResult(fmt.Sprintf("end_line %d is before start_line %d", end, start))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to read file: %v", err))
+	}
+	defer f.Close()
+
+	var sb strings.Builder
+	reader := bufio.NewReader(f)
+	total := 0
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			total++
+			if total >= start && total <= end {
+				fmt.Fprintf(&sb, "%6d\t%s\n", total, strings.TrimRight(line, "\r\n"))
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("failed to read file: %v", err))
+		}
+	}
+
+	if start > total {
+		return ErrorResult(fmt.Sprintf("start_line %d is past the end of the file (%d lines)", start, total))
+	}
+	end = min(end, total)
+	return NewToolResult(fmt.Sprintf("Lines %d-%d of %d:\n%s", start, end, total, sb.String()))
+}
+
 type WriteFileTool struct {
-	workspace string
-	restrict  bool
+	paths *PathPolicy
 }
 
-func NewWriteFileTool(workspace string, restrict bool) *WriteFileTool {
-	return &WriteFileTool{workspace: workspace, restrict: restrict}
+func NewWriteFileTool(workspace string, restrict bool, roots ...PathRoot) *WriteFileTool {
+	return &WriteFileTool{paths: NewPathPolicy(workspace, restrict, roots...)}
 }
 
 func (t *WriteFileTool) Name() string {
@@ -130,7 +166,7 @@ func (t *WriteFileTool) Execute(ctx context.Context, args map[string]interface{}
 		return ErrorResult("content is required")
 	}
 
-	resolvedPath, err := validatePath(path, t.workspace, t.restrict)
+	resolvedPath, err := t.paths.Resolve(path, PathWrite)
 	if err != nil {
 		return ErrorResult(err.Error())
 	}
@@ -148,12 +184,11 @@ func (t *WriteFileTool) Execute(ctx context.Context, args map[string]interface{}
 }
 
 type ListDirTool struct {
-	workspace string
-	restrict  bool
+	paths *PathPolicy
 }
 
-func NewListDirTool(workspace string, restrict bool) *ListDirTool {
-	return &ListDirTool{workspace: workspace, restrict: restrict}
+func NewListDirTool(workspace string, restrict bool, roots ...PathRoot) *ListDirTool {
+	return &ListDirTool{paths: NewPathPolicy(workspace, restrict, roots...)}
 }
 
 func (t *ListDirTool) Name() string {
@@ -183,7 +218,7 @@ func (t *ListDirTool) Execute(ctx context.Context, args map[string]interface{})
 		path = "."
 	}
 
-	resolvedPath, err := validatePath(path, t.workspace, t.restrict)
+	resolvedPath, err := t.paths.Resolve(path, PathRead)
 	if err != nil {
 		return ErrorResult(err.Error())
 	}
@@ -247,3 +247,36 @@ func TestFilesystemTool_ListDir_DefaultPath(t *testing.T) {
 		t.Errorf("Expected success with default path '.', got IsError=true: %s", result.ForLLM)
 	}
 }
+
+// TestFilesystemTool_ReadFile_LineRange verifies reading part of a file
+func TestFilesystemTool_ReadFile_LineRange(t *testing.T) {
+	tmpDir := t.TempDir()
+	var sb strings.Builder
+	for i := 1; i <= 1000; i++ {
+		sb.WriteString("line " + strings.Repeat("x", i%3) + "\n")
+	}
+	os.WriteFile(filepath.Join(tmpDir, "big.txt"), []byte(sb.String()), 0644)
+	tool := NewReadFileTool(tmpDir, true)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"path": "big.txt", "start_line": float64(10), "end_line": float64(12),
+	})
+	if result.IsError {
+		t.Fatalf("Expected success, got: %s", result.ForLLM)
+	}
+	want := "Lines 10-12 of 1000:\n    10\tline x\n    11\tline xx\n    12\tline \n"
+	if result.ForLLM != want {
+		t.Errorf("Unexpected output:\n%q\nwant:\n%q", result.ForLLM, want)
+	}
+
+	// Only start_line reads a default window; the end is clamped.
+	result = tool.Execute(context.Background(), map[string]interface{}{"path": "big.txt", "start_line": float64(900)})
+	if !strings.HasPrefix(result.ForLLM, "Lines 900-1000 of 1000:") {
+		t.Errorf("Expected clamped range, got: %s", result.ForLLM[:40])
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"path": "big.txt", "start_line": float64(2000)})
+	if !result.IsError || !strings.Contains(result.ForLLM, "past the end") {
+		t.Errorf("Expected error for start past the end, got: %s", result.ForLLM)
+	}
+}
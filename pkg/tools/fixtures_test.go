@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// readFixture returns the recorded response testdata/<dir>/<name>.
+func readFixture(t *testing.T, dir, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", dir, name))
+	if err != nil {
+		t.Fatalf("missing fixture %s/%s: %v", dir, name, err)
+	}
+	return data
+}
+
+// fixtureServer serves recorded API responses from testdata/<dir> and keeps
+// the requests it received. route picks the fixture for a request; an empty
+// name is a 404.
+type fixtureServer struct {
+	*httptest.Server
+	t     *testing.T
+	dir   string
+	route func(r *http.Request) string
+
+	mu       sync.Mutex
+	received []*http.Request
+}
+
+func newFixtureServer(t *testing.T, dir string, route func(r *http.Request) string) *fixtureServer {
+	t.Helper()
+	s := &fixtureServer{t: t, dir: dir, route: route}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// pathRoutes routes requests by URL path alone.
+func pathRoutes(routes map[string]string) func(r *http.Request) string {
+	return func(r *http.Request) string { return routes[r.URL.Path] }
+}
+
+func (s *fixtureServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.received = append(s.received, r)
+	s.mu.Unlock()
+
+	name := s.route(r)
+	if name == "" {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := os.ReadFile(filepath.Join("testdata", s.dir, name))
+	if err != nil {
+		s.t.Errorf("missing fixture %s/%s: %v", s.dir, name, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if ct := mime.TypeByExtension(filepath.Ext(name)); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.Write(data)
+}
+
+// requests returns the requests received so far.
+func (s *fixtureServer) requests() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*http.Request(nil), s.received...)
+}
+
+// queries returns the query parameters of each request received so far.
+func (s *fixtureServer) queries() []url.Values {
+	var out []url.Values
+	for _, r := range s.requests() {
+		out = append(out, r.URL.Query())
+	}
+	return out
+}
+
+// paths returns the URL path of each request received so far.
+func (s *fixtureServer) paths() []string {
+	var out []string
+	for _, r := range s.requests() {
+		out = append(out, r.URL.Path)
+	}
+	return out
+}
+
+// reset forgets the requests received so far.
+func (s *fixtureServer) reset() {
+	s.mu.Lock()
+	s.received = nil
+	s.mu.Unlock()
+}
@@ -0,0 +1,281 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// errStopWalk ends walkFiles early without an error, e.g. at a result limit.
+var errStopWalk = errors.New("stop walk")
+
+// globToRegexp translates a glob into a regular expression matching
+// slash-separated relative paths. "*" and "?" do not cross directories,
+// "**/" matches zero or more directories and a trailing "**" everything.
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				if i+2 < len(glob) && glob[i+2] == '/' {
+					sb.WriteString("(?:.*/)?")
+					i += 2
+				} else {
+					sb.WriteString(".*")
+					i++
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			j := i + 1
+			if j < len(glob) && (glob[j] == '!' || glob[j] == '^') {
+				j++
+			}
+			if j < len(glob) && glob[j] == ']' {
+				j++
+			}
+			for j < len(glob) && glob[j] != ']' {
+				j++
+			}
+			if j >= len(glob) {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : j]
+			if class[0] == '!' {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i = j
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// pathGlob matches relative paths against a glob. Like in .gitignore, a glob
+// without a slash matches the file name at any depth.
+type pathGlob struct {
+	re *regexp.Regexp
+}
+
+func compilePathGlob(glob string) (*pathGlob, error) {
+	glob = strings.TrimPrefix(filepath.ToSlash(glob), "./")
+	if !strings.Contains(glob, "/") {
+		glob = "**/" + glob
+	}
+	re, err := globToRegexp(glob)
+	if err != nil {
+		return nil, err
+	}
+	return &pathGlob{re: re}, nil
+}
+
+func (g *pathGlob) match(rel string) bool {
+	return g.re.MatchString(rel)
+}
+
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreSet holds the rules of one .gitignore; base is its directory
+// relative to the walk root ("" for the root itself).
+type ignoreSet struct {
+	base  string
+	rules []ignoreRule
+}
+
+// parseGitignore compiles the patterns of a .gitignore file. Invalid
+// patterns are skipped.
+func parseGitignore(data string) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if !strings.HasSuffix(line, `\ `) {
+			line = strings.TrimRight(line, " \t")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			line = strings.TrimPrefix(line, "/")
+		} else {
+			line = "**/" + line
+		}
+		re, err := globToRegexp(line)
+		if err != nil {
+			continue
+		}
+		rule.re = re
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// ignoreMatcher applies the .gitignore files from the walk root down to the
+// current directory; deeper files and later rules take precedence.
+type ignoreMatcher struct {
+	sets []ignoreSet
+}
+
+func (m ignoreMatcher) with(dir, rel string) ignoreMatcher {
+	data, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return m
+	}
+	rules := parseGitignore(string(data))
+	if len(rules) == 0 {
+		return m
+	}
+	sets := make([]ignoreSet, len(m.sets), len(m.sets)+1)
+	copy(sets, m.sets)
+	return ignoreMatcher{sets: append(sets, ignoreSet{base: rel, rules: rules})}
+}
+
+func (m ignoreMatcher) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, set := range m.sets {
+		p := rel
+		if set.base != "" {
+			if !strings.HasPrefix(rel, set.base+"/") {
+				continue
+			}
+			p = rel[len(set.base)+1:]
+		}
+		for _, rule := range set.rules {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.re.MatchString(p) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// fileWalker visits the regular files below a directory, skipping .git,
+// symbolic links and, unless noIgnore is set, paths excluded by .gitignore
+// files between root and the file.
+type fileWalker struct {
+	root     string
+	noIgnore bool
+}
+
+// walk calls fn with the path of every file below start (which must be root
+// or inside it) and its slash-separated path relative to root. fn may return
+// errStopWalk to end the walk.
+func (w fileWalker) walk(ctx context.Context, start string, fn func(path, rel string) error) error {
+	rel, err := filepath.Rel(w.root, start)
+	if err != nil {
+		return err
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		rel = ""
+	}
+
+	// Collect the .gitignore files above start.
+	var m ignoreMatcher
+	if !w.noIgnore {
+		m = m.with(w.root, "")
+		if rel != "" {
+			parts := strings.Split(rel, "/")
+			for i := 1; i < len(parts); i++ {
+				dirRel := path.Join(parts[:i]...)
+				if m.ignored(dirRel, true) {
+					return nil
+				}
+				m = m.with(filepath.Join(w.root, filepath.FromSlash(dirRel)), dirRel)
+			}
+		}
+	}
+
+	info, err := os.Lstat(start)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		if info.Mode().IsRegular() {
+			err = fn(start, rel)
+		}
+		if err == errStopWalk {
+			return nil
+		}
+		return err
+	}
+	if rel != "" && !w.noIgnore && m.ignored(rel, true) {
+		return nil
+	}
+	err = w.walkDir(ctx, start, rel, m, fn)
+	if err == errStopWalk {
+		return nil
+	}
+	return err
+}
+
+func (w fileWalker) walkDir(ctx context.Context, dir, rel string, m ignoreMatcher, fn func(path, rel string) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !w.noIgnore && rel != "" {
+		m = m.with(dir, rel)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil // unreadable directories are skipped
+	}
+	for _, e := range entries {
+		name := e.Name()
+		childRel := name
+		if rel != "" {
+			childRel = rel + "/" + name
+		}
+		if e.Type()&os.ModeSymlink != 0 || (e.IsDir() && name == ".git") {
+			continue
+		}
+		if !w.noIgnore && m.ignored(childRel, e.IsDir()) {
+			continue
+		}
+		child := filepath.Join(dir, name)
+		if e.IsDir() {
+			if err := w.walkDir(ctx, child, childRel, m, fn); err != nil {
+				return err
+			}
+		} else if e.Type().IsRegular() {
+			if err := fn(child, childRel); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,471 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/srikesh3005/summer/pkg/library"
+)
+
+const defaultLibrarySearchLimit = 20
+
+// stringListArg reads a list argument given either as an array or as a
+// comma-separated string.
+func stringListArg(args map[string]interface{}, key string) []string {
+	var out []string
+	switch v := args[key].(type) {
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				out = append(out, strings.TrimSpace(s))
+			}
+		}
+	case string:
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// formatLibraryEntry renders an entry as a short multi-line summary.
+func formatLibraryEntry(lib *library.Library, e *library.Entry) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[%s] %s\n", e.Key, e.Title)
+	if len(e.Authors) > 0 {
+		names := e.AuthorNames()
+		if len(names) > 5 {
+			names = append(names[:5], "et al.")
+		}
+		sb.WriteString("   " + strings.Join(names, ", "))
+		if e.Year > 0 {
+			fmt.Fprintf(&sb, " (%d)", e.Year)
+		}
+		sb.WriteString("\n")
+	}
+	if e.Venue != "" {
+		sb.WriteString("   " + e.Venue + "\n")
+	}
+	var ids []string
+	if e.DOI != "" {
+		ids = append(ids, "DOI: "+e.DOI)
+	}
+	if e.ArXivID != "" {
+		ids = append(ids, "arXiv: "+e.ArXivID)
+	}
+	if len(ids) > 0 {
+		sb.WriteString("   " + strings.Join(ids, " | ") + "\n")
+	}
+	if len(e.Tags) > 0 {
+		sb.WriteString("   Tags: " + strings.Join(e.Tags, ", ") + "\n")
+	}
+	if e.PDF != "" {
+		sb.WriteString("   PDF: " + filepath.Join(lib.Dir(), e.PDF) + "\n")
+	}
+	for _, n := range e.Notes {
+		fmt.Fprintf(&sb, "   Note (%s): %s\n", n.Time.Format("2006-01-02"), n.Text)
+	}
+	return sb.String()
+}
+
+// LibraryAddTool adds papers to the workspace library, resolving metadata
+// from arXiv and Crossref and optionally downloading the PDF.
+type LibraryAddTool struct {
+	lib      *library.Library
+	resolver *library.Resolver
+}
+
+func NewLibraryAddTool(lib *library.Library, resolver *library.Resolver) *LibraryAddTool {
+	return &LibraryAddTool{lib: lib, resolver: resolver}
+}
+
+func (t *LibraryAddTool) Name() string {
+	return "library_add"
+}
+
+func (t *LibraryAddTool) Description() string {
+	return "Add a paper to the persistent paper library (library/ in the workspace). Give a DOI or arXiv ID (or URL) to fetch " +
+		"normalized metadata from arXiv and Crossref, or enter title/authors/year manually. Adding a paper that is already " +
+		"in the library merges the new details into it. Can download the PDF into library/pdfs/."
+}
+
+func (t *LibraryAddTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "DOI or arXiv ID, or a doi.org/arxiv.org URL",
+			},
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "Title, for papers without a DOI or arXiv ID",
+			},
+			"authors": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Author names, for manual entries",
+			},
+			"year": map[string]interface{}{
+				"type":        "integer",
+				"description": "Publication year, for manual entries",
+			},
+			"venue": map[string]interface{}{
+				"type":        "string",
+				"description": "Journal or conference, for manual entries",
+			},
+			"url": map[string]interface{}{
+				"type":        "string",
+				"description": "Landing page URL, for manual entries",
+			},
+			"tags": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Tags to attach",
+			},
+			"note": map[string]interface{}{
+				"type":        "string",
+				"description": "A note to attach",
+			},
+			"download_pdf": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Download the PDF (open-access or arXiv) into the library",
+			},
+			"pdf_url": map[string]interface{}{
+				"type":        "string",
+				"description": "Download the PDF from this URL instead",
+			},
+		},
+	}
+}
+
+func (t *LibraryAddTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	id, _ := args["id"].(string)
+	title, _ := args["title"].(string)
+	if strings.TrimSpace(id) == "" && strings.TrimSpace(title) == "" {
+		return ErrorResult("id or title is required")
+	}
+
+	var entry *library.Entry
+	var warning string
+	if strings.TrimSpace(id) != "" {
+		resolved, err := t.resolver.Resolve(ctx, id)
+		if err != nil {
+			if title == "" {
+				return ErrorResult(fmt.Sprintf("failed to resolve %s: %v", id, err))
+			}
+			warning = fmt.Sprintf("\nMetadata lookup failed (%v); added the details given.", err)
+		}
+		entry = resolved
+	}
+	if entry == nil {
+		entry = &library.Entry{Title: title, DOI: id, ArXivID: id}
+		for _, name := range stringListArg(args, "authors") {
+			entry.Authors = append(entry.Authors, library.SplitName(name))
+		}
+		if y, ok := args["year"].(float64); ok {
+			entry.Year = int(y)
+		}
+		entry.Venue, _ = args["venue"].(string)
+		entry.URL, _ = args["url"].(string)
+	}
+	entry.Tags = stringListArg(args, "tags")
+	if note, _ := args["note"].(string); strings.TrimSpace(note) != "" {
+		entry.Notes = []library.Note{{Time: time.Now(), Text: strings.TrimSpace(note)}}
+	}
+
+	pdfURL, _ := args["pdf_url"].(string)
+	if download, _ := args["download_pdf"].(bool); download && pdfURL == "" {
+		pdfURL = t.resolver.PDFURL(entry)
+	}
+
+	stored, added, err := t.lib.Add(entry)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to add to library: %v", err))
+	}
+
+	status := "Added"
+	if !added {
+		status = "Already in library, merged"
+	}
+	if download, _ := args["download_pdf"].(bool); (download || pdfURL != "") && stored.PDF == "" {
+		if pdfURL == "" {
+			warning += "\nNo PDF link is known for this paper; pass pdf_url to download one."
+		} else if rel, err := t.resolver.DownloadPDF(ctx, t.lib, stored.Key, pdfURL); err != nil {
+			warning += fmt.Sprintf("\nPDF not downloaded: %v", err)
+		} else if stored, err = t.lib.Modify(stored.Key, func(e *library.Entry) error {
+			e.PDF = rel
+			return nil
+		}); err != nil {
+			return ErrorResult(fmt.Sprintf("failed to update library: %v", err))
+		}
+	}
+	return NewToolResult(fmt.Sprintf("%s:\n%s%s", status, formatLibraryEntry(t.lib, stored), warning))
+}
+
+// LibraryTagTool adds and removes tags on library entries.
+type LibraryTagTool struct {
+	lib *library.Library
+}
+
+func NewLibraryTagTool(lib *library.Library) *LibraryTagTool {
+	return &LibraryTagTool{lib: lib}
+}
+
+func (t *LibraryTagTool) Name() string {
+	return "library_tag"
+}
+
+func (t *LibraryTagTool) Description() string {
+	return "Add or remove tags on a paper in the library. Identify the paper by citation key, DOI or arXiv ID."
+}
+
+func (t *LibraryTagTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"key": map[string]interface{}{
+				"type":        "string",
+				"description": "Citation key, DOI or arXiv ID of the paper",
+			},
+			"add": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Tags to add",
+			},
+			"remove": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Tags to remove",
+			},
+		},
+		"required": []string{"key"},
+	}
+}
+
+func (t *LibraryTagTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	key, _ := args["key"].(string)
+	if key == "" {
+		return ErrorResult("key is required")
+	}
+	add, remove := stringListArg(args, "add"), stringListArg(args, "remove")
+	if len(add) == 0 && len(remove) == 0 {
+		return ErrorResult("add or remove is required")
+	}
+	e, err := t.lib.Tag(key, add, remove)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to tag entry: %v", err))
+	}
+	tags := "none"
+	if len(e.Tags) > 0 {
+		tags = strings.Join(e.Tags, ", ")
+	}
+	return NewToolResult(fmt.Sprintf("Tags of %s: %s", e.Key, tags))
+}
+
+// LibraryNoteTool attaches notes to library entries.
+type LibraryNoteTool struct {
+	lib *library.Library
+}
+
+func NewLibraryNoteTool(lib *library.Library) *LibraryNoteTool {
+	return &LibraryNoteTool{lib: lib}
+}
+
+func (t *LibraryNoteTool) Name() string {
+	return "library_note"
+}
+
+func (t *LibraryNoteTool) Description() string {
+	return "Attach a dated note (summary, critique, how it relates to the project) to a paper in the library. " +
+		"Notes are included in library_search."
+}
+
+func (t *LibraryNoteTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"key": map[string]interface{}{
+				"type":        "string",
+				"description": "Citation key, DOI or arXiv ID of the paper",
+			},
+			"text": map[string]interface{}{
+				"type":        "string",
+				"description": "The note",
+			},
+		},
+		"required": []string{"key", "text"},
+	}
+}
+
+func (t *LibraryNoteTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	key, _ := args["key"].(string)
+	text, _ := args["text"].(string)
+	if key == "" {
+		return ErrorResult("key is required")
+	}
+	e, err := t.lib.AddNote(key, text)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to add note: %v", err))
+	}
+	return NewToolResult(fmt.Sprintf("Note added to %s (%d notes)", e.Key, len(e.Notes)))
+}
+
+// LibrarySearchTool searches the library.
+type LibrarySearchTool struct {
+	lib *library.Library
+}
+
+func NewLibrarySearchTool(lib *library.Library) *LibrarySearchTool {
+	return &LibrarySearchTool{lib: lib}
+}
+
+func (t *LibrarySearchTool) Name() string {
+	return "library_search"
+}
+
+func (t *LibrarySearchTool) Description() string {
+	return "Search the paper library by words in the title, authors, venue, abstract and notes, and/or by tag. " +
+		"With no query or tag, lists the library."
+}
+
+func (t *LibrarySearchTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Words that must all appear",
+			},
+			"tags": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Only papers carrying all of these tags",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum results (default 20)",
+			},
+		},
+	}
+}
+
+func (t *LibrarySearchTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	query, _ := args["query"].(string)
+	limit := defaultLibrarySearchLimit
+	if v, ok := args["limit"].(float64); ok && v >= 1 {
+		limit = int(v)
+	}
+	entries, err := t.lib.Search(query, stringListArg(args, "tags"))
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to search library: %v", err))
+	}
+	if len(entries) == 0 {
+		return NewToolResult("No matching papers in the library")
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d paper(s) in the library match:\n\n", len(entries))
+	for i, e := range entries {
+		if i == limit {
+			fmt.Fprintf(&sb, "... %d more; narrow the search or raise limit\n", len(entries)-limit)
+			break
+		}
+		sb.WriteString(formatLibraryEntry(t.lib, e) + "\n")
+	}
+	return NewToolResult(strings.TrimSuffix(sb.String(), "\n"))
+}
+
+// LibraryExportTool exports library entries as BibTeX, CSL-JSON or RIS.
+type LibraryExportTool struct {
+	lib   *library.Library
+	paths *PathPolicy
+}
+
+func NewLibraryExportTool(lib *library.Library, workspace string, restrict bool, roots ...PathRoot) *LibraryExportTool {
+	return &LibraryExportTool{lib: lib, paths: NewPathPolicy(workspace, restrict, roots...)}
+}
+
+func (t *LibraryExportTool) Name() string {
+	return "library_export"
+}
+
+func (t *LibraryExportTool) Description() string {
+	return "Export papers from the library as BibTeX, CSL-JSON or RIS, either all of them, selected keys or a tag. " +
+		"Writes to path if given, otherwise returns the text."
+}
+
+func (t *LibraryExportTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"format": map[string]interface{}{
+				"type":        "string",
+				"enum":        library.Formats,
+				"description": "Export format",
+			},
+			"keys": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Citation keys, DOIs or arXiv IDs to export (default: all)",
+			},
+			"tags": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Only export papers carrying all of these tags",
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File to write, e.g. references.bib",
+			},
+		},
+		"required": []string{"format"},
+	}
+}
+
+func (t *LibraryExportTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	format, _ := args["format"].(string)
+	entries, err := t.lib.Search("", stringListArg(args, "tags"))
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to read library: %v", err))
+	}
+	if keys := stringListArg(args, "keys"); len(keys) > 0 {
+		var selected []*library.Entry
+		for _, key := range keys {
+			e, err := t.lib.Find(key)
+			if err != nil {
+				return ErrorResult(err.Error())
+			}
+			selected = append(selected, e)
+		}
+		entries = selected
+	}
+	if len(entries) == 0 {
+		return ErrorResult("no papers to export")
+	}
+	out, err := library.Export(entries, format)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	path, _ := args["path"].(string)
+	if path == "" {
+		return NewToolResult(out)
+	}
+	resolved, err := t.paths.Resolve(path, PathWrite)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	if err := os.MkdirAll(filepath.Dir(resolved), 0755); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to create directory: %v", err))
+	}
+	if err := os.WriteFile(resolved, []byte(out), 0644); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to write file: %v", err))
+	}
+	return NewToolResult(fmt.Sprintf("Exported %d paper(s) as %s to %s", len(entries), format, path))
+}
@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/srikesh3005/summer/pkg/library"
+)
+
+func TestLibraryTools(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	workspace := t.TempDir()
+	lib := library.New(filepath.Join(workspace, "library"))
+	resolver := &library.Resolver{Client: srv.Client(), ArXivAPI: srv.URL, CrossrefAPI: srv.URL + "/"}
+	ctx := context.Background()
+
+	add := NewLibraryAddTool(lib, resolver)
+	result := add.Execute(ctx, map[string]interface{}{
+		"title":   "Deep learning",
+		"authors": []interface{}{"Yann LeCun", "Yoshua Bengio"},
+		"year":    2015.0,
+		"venue":   "Nature",
+		"tags":    "survey, Reading List",
+		"note":    "Overview of the field.",
+	})
+	if result.IsError || !strings.Contains(result.ForLLM, "[lecun2015deep] Deep learning") {
+		t.Fatalf("library_add: %s", result.ForLLM)
+	}
+
+	// An unresolvable ID is an error unless details are given.
+	if result := add.Execute(ctx, map[string]interface{}{"id": "10.1234/missing"}); !result.IsError {
+		t.Errorf("expected an error for an unknown DOI: %s", result.ForLLM)
+	}
+	result = add.Execute(ctx, map[string]interface{}{"id": "10.1234/missing", "title": "Deep Learning", "year": 2015.0})
+	if result.IsError || !strings.Contains(result.ForLLM, "merged") || !strings.Contains(result.ForLLM, "Metadata lookup failed") {
+		t.Errorf("library_add duplicate: %s", result.ForLLM)
+	}
+
+	result = NewLibraryTagTool(lib).Execute(ctx, map[string]interface{}{
+		"key": "lecun2015deep", "add": []interface{}{"classic"}, "remove": []interface{}{"survey"},
+	})
+	if result.IsError || result.ForLLM != "Tags of lecun2015deep: classic, reading-list" {
+		t.Errorf("library_tag: %s", result.ForLLM)
+	}
+	result = NewLibraryNoteTool(lib).Execute(ctx, map[string]interface{}{"key": "10.1234/MISSING", "text": "Cite in chapter 2."})
+	if result.IsError {
+		t.Errorf("library_note by DOI: %s", result.ForLLM)
+	}
+
+	result = NewLibrarySearchTool(lib).Execute(ctx, map[string]interface{}{"query": "chapter", "tags": []interface{}{"classic"}})
+	if result.IsError || !strings.Contains(result.ForLLM, "1 paper(s)") || !strings.Contains(result.ForLLM, "Cite in chapter 2.") {
+		t.Errorf("library_search: %s", result.ForLLM)
+	}
+	result = NewLibrarySearchTool(lib).Execute(ctx, map[string]interface{}{"query": "transformers"})
+	if result.IsError || !strings.Contains(result.ForLLM, "No matching papers") {
+		t.Errorf("library_search without matches: %s", result.ForLLM)
+	}
+
+	export := NewLibraryExportTool(lib, workspace, true)
+	result = export.Execute(ctx, map[string]interface{}{"format": "bibtex", "path": "refs/references.bib"})
+	if result.IsError {
+		t.Fatalf("library_export: %s", result.ForLLM)
+	}
+	data, err := os.ReadFile(filepath.Join(workspace, "refs", "references.bib"))
+	if err != nil || !strings.Contains(string(data), "@article{lecun2015deep,") {
+		t.Errorf("exported file: %v\n%s", err, data)
+	}
+	if result := export.Execute(ctx, map[string]interface{}{"format": "ris", "path": "../outside.ris"}); !result.IsError {
+		t.Error("expected export outside the workspace to be rejected")
+	}
+	if result := export.Execute(ctx, map[string]interface{}{"format": "ris", "keys": []interface{}{"nope2020"}}); !result.IsError {
+		t.Error("expected an error for an unknown key")
+	}
+}
@@ -0,0 +1,625 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/srikesh3005/summer/pkg/logger"
+	"github.com/srikesh3005/summer/pkg/providers"
+	"github.com/srikesh3005/summer/pkg/utils"
+)
+
+const (
+	reviewMaxQueries   = 5
+	reviewPerQuery     = 8    // results asked of each source per query
+	reviewPDFLimit     = 6    // PDFs read per review
+	reviewPDFChars     = 6000 // characters kept from each PDF
+	reviewDefaultCount = 12
+	reviewMaxCount     = 30
+)
+
+// reviewSource is a candidate source for a literature review.
+type reviewSource struct {
+	watchPaper
+	Web      bool   // found by web search rather than a paper index
+	FullText string // start of the PDF, when it was read
+	hits     int    // number of planned queries that found it
+	order    int    // position of the first hit
+}
+
+// reviewTheme is a cluster of sources, by index into the selected list.
+type reviewTheme struct {
+	Name    string
+	Sources []int
+}
+
+// LiteratureReviewToolOptions wires the tools the review pipeline uses.
+type LiteratureReviewToolOptions struct {
+	Provider providers.LLMProvider
+	Model    string
+	ArXiv    *ArXivTool
+	Crossref *CrossrefTool
+	// WebSearch is optional; without it only paper indexes are searched.
+	WebSearch *WebSearchTool
+	Markdown  *MarkdownFileTool
+	// Message sends progress updates; nil disables them.
+	Message *MessageTool
+	// HTTPClient downloads PDFs; nil means a client with the default
+	// OutboundPolicy.
+	HTTPClient *http.Client
+}
+
+// LiteratureReviewTool runs a multi-step literature review: it plans
+// search queries, searches arXiv, Crossref and the web, reads abstracts and
+// PDFs, clusters the findings into themes and writes a Markdown report with
+// numbered citations and a bibliography.
+type LiteratureReviewTool struct {
+	opts    LiteratureReviewToolOptions
+	client  *http.Client
+	channel string
+	chatID  string
+	mu      sync.RWMutex
+}
+
+func NewLiteratureReviewTool(opts LiteratureReviewToolOptions) *LiteratureReviewTool {
+	return &LiteratureReviewTool{opts: opts, client: clientOrDefault(opts.HTTPClient)}
+}
+
+func (t *LiteratureReviewTool) Name() string {
+	return "literature_review"
+}
+
+func (t *LiteratureReviewTool) Description() string {
+	return "Write a literature review on a topic: plans searches, queries arXiv, Crossref and the web, reads abstracts and available PDFs, " +
+		"groups the findings into themes and saves a Markdown report with numbered citations and a bibliography, sending it to the chat. " +
+		"Takes a few minutes; progress updates are sent while it runs. Use this instead of answering literature questions from memory."
+}
+
+func (t *LiteratureReviewTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"topic": map[string]interface{}{
+				"type":        "string",
+				"description": "Topic or research question to review",
+			},
+			"focus": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional aspects to emphasize (e.g. 'evaluation methods, work since 2020')",
+			},
+			"max_sources": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("Maximum number of sources to cite (default: %d, max: %d)", reviewDefaultCount, reviewMaxCount),
+				"minimum":     3.0,
+				"maximum":     float64(reviewMaxCount),
+			},
+			"web": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Include web search results alongside papers (default: true)",
+			},
+			"read_pdfs": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Read open-access PDFs in addition to abstracts (default: true)",
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Report path (default: reports/literature-review-<topic>.md)",
+			},
+			"send": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Send the report file to the current chat (default: true)",
+			},
+		},
+		"required": []string{"topic"},
+	}
+}
+
+// SetContext sets the conversation that receives progress and the report.
+func (t *LiteratureReviewTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	t.channel = channel
+	t.chatID = chatID
+	t.mu.Unlock()
+	if t.opts.Markdown != nil {
+		t.opts.Markdown.SetContext(channel, chatID)
+	}
+}
+
+func (t *LiteratureReviewTool) progress(format string, args ...interface{}) {
+	if t.opts.Message == nil {
+		return
+	}
+	t.mu.RLock()
+	channel, chatID := t.channel, t.chatID
+	t.mu.RUnlock()
+	if err := t.opts.Message.SendProgress(channel, chatID, fmt.Sprintf(format, args...)); err != nil {
+		logger.DebugCF("tool", "Literature review progress not sent", map[string]interface{}{"error": err.Error()})
+	}
+}
+
+func (t *LiteratureReviewTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	topic, _ := args["topic"].(string)
+	topic = strings.TrimSpace(topic)
+	if topic == "" {
+		return ErrorResult("topic is required")
+	}
+	focus, _ := args["focus"].(string)
+	maxSources := reviewDefaultCount
+	if n, ok := args["max_sources"].(float64); ok && n > 0 {
+		maxSources = int(n)
+		if maxSources < 3 {
+			maxSources = 3
+		}
+		if maxSources > reviewMaxCount {
+			maxSources = reviewMaxCount
+		}
+	}
+	useWeb, readPDFs, send := true, true, true
+	if v, ok := args["web"].(bool); ok {
+		useWeb = v
+	}
+	if v, ok := args["read_pdfs"].(bool); ok {
+		readPDFs = v
+	}
+	if v, ok := args["send"].(bool); ok {
+		send = v
+	}
+	t.mu.RLock()
+	if t.channel == "" || t.chatID == "" {
+		send = false
+	}
+	t.mu.RUnlock()
+
+	t.progress("🔎 Starting a literature review on %q: planning searches…", topic)
+	queries := t.planQueries(ctx, topic, focus)
+
+	t.progress("📡 Searching %s for: %s", t.sourceNames(useWeb), strings.Join(queries, "; "))
+	candidates, errs := t.search(ctx, queries, useWeb)
+	if len(candidates) == 0 {
+		if len(errs) > 0 {
+			return ErrorResult(fmt.Sprintf("literature search failed: %s", strings.Join(errs, "; ")))
+		}
+		return ErrorResult(fmt.Sprintf("no sources found for %q", topic))
+	}
+	sources := selectReviewSources(candidates, maxSources)
+
+	if readPDFs {
+		if n := t.readPDFs(ctx, sources); n > 0 {
+			t.progress("📄 Read %d of %d papers in full; the rest from abstracts", n, len(sources))
+		}
+	}
+
+	t.progress("🧩 Grouping %d sources into themes…", len(sources))
+	themes := t.cluster(ctx, topic, sources)
+
+	t.progress("✍️ Writing the report…")
+	body := t.write(ctx, topic, focus, themes, sources)
+	report := strings.TrimSpace(body) + "\n\n" + formatReviewBibliography(sources)
+
+	path, _ := args["path"].(string)
+	if path == "" {
+		path = filepath.Join("reports", "literature-review-"+reviewSlug(topic)+".md")
+	}
+	saved := t.opts.Markdown.Execute(ctx, map[string]interface{}{
+		"path":    path,
+		"content": report,
+		"send":    send,
+		"caption": fmt.Sprintf("Literature review: %s (%d sources)", topic, len(sources)),
+	})
+	if saved.IsError {
+		return ErrorResult(fmt.Sprintf("failed to save report: %s", saved.ForLLM))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s\nSources: %d (searched: %s)\nThemes: ", saved.ForLLM, len(sources), strings.Join(queries, "; "))
+	names := make([]string, len(themes))
+	for i, th := range themes {
+		names[i] = th.Name
+	}
+	sb.WriteString(strings.Join(names, "; "))
+	if len(errs) > 0 {
+		fmt.Fprintf(&sb, "\nSome searches failed: %s", strings.Join(errs, "; "))
+	}
+	sb.WriteString("\n\nReport:\n")
+	sb.WriteString(utils.Truncate(report, 8000))
+	return SilentResult(sb.String())
+}
+
+func (t *LiteratureReviewTool) sourceNames(useWeb bool) string {
+	if useWeb && t.opts.WebSearch != nil {
+		return "arXiv, Crossref and the web"
+	}
+	return "arXiv and Crossref"
+}
+
+// chat sends a single prompt to the LLM and returns the reply text.
+func (t *LiteratureReviewTool) chat(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if t.opts.Provider == nil {
+		return "", fmt.Errorf("no LLM provider configured")
+	}
+	resp, err := t.opts.Provider.Chat(ctx, []providers.Message{{Role: "user", Content: prompt}}, nil, t.opts.Model, map[string]interface{}{
+		"max_tokens":  maxTokens,
+		"temperature": 0.3,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+var listMarkerRe = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s*`)
+
+// planQueries asks the LLM for search queries covering the topic. The topic
+// itself is always searched.
+func (t *LiteratureReviewTool) planQueries(ctx context.Context, topic, focus string) []string {
+	queries := []string{topic}
+	prompt := fmt.Sprintf("Plan the searches for a literature review on: %s\n", topic)
+	if focus != "" {
+		prompt += fmt.Sprintf("Focus on: %s\n", focus)
+	}
+	prompt += fmt.Sprintf("Reply with 2 to %d short keyword queries for academic search engines, one per line, "+
+		"each covering a different aspect (methods, applications, evaluation, related terms). No numbering or commentary.", reviewMaxQueries-1)
+	reply, err := t.chat(ctx, prompt, 256)
+	if err != nil {
+		logger.WarnCF("tool", "Literature review planning failed, searching the topic only",
+			map[string]interface{}{"error": err.Error()})
+		return queries
+	}
+	seen := map[string]bool{strings.ToLower(topic): true}
+	for _, line := range strings.Split(reply, "\n") {
+		q := strings.Trim(listMarkerRe.ReplaceAllString(line, ""), " \t\"'`")
+		if q == "" || len(q) > 120 || seen[strings.ToLower(q)] {
+			continue
+		}
+		seen[strings.ToLower(q)] = true
+		queries = append(queries, q)
+		if len(queries) == reviewMaxQueries {
+			break
+		}
+	}
+	return queries
+}
+
+// search runs every query against every source and merges the results,
+// counting how many queries found each source.
+func (t *LiteratureReviewTool) search(ctx context.Context, queries []string, useWeb bool) ([]*reviewSource, []string) {
+	byKey := make(map[string]*reviewSource)
+	var all []*reviewSource
+	var errs []string
+	add := func(papers []watchPaper, web bool) {
+		for _, p := range papers {
+			if s, ok := byKey[p.Key]; ok {
+				s.hits++
+				continue
+			}
+			s := &reviewSource{watchPaper: p, Web: web, hits: 1, order: len(all)}
+			byKey[p.Key] = s
+			all = append(all, s)
+		}
+	}
+	for _, q := range queries {
+		if t.opts.ArXiv != nil {
+			papers, err := t.opts.ArXiv.fetch(ctx, arxivTermsQuery(q), "relevance", reviewPerQuery)
+			if err != nil {
+				errs = append(errs, err.Error())
+			}
+			add(papers, false)
+		}
+		if t.opts.Crossref != nil {
+			papers, err := t.opts.Crossref.works(ctx, url.Values{"query.bibliographic": {q}}, reviewPerQuery)
+			if err != nil {
+				errs = append(errs, err.Error())
+			}
+			add(papers, false)
+		}
+		if useWeb && t.opts.WebSearch != nil {
+			text, err := t.opts.WebSearch.provider.Search(ctx, q, reviewPerQuery)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("web search failed: %v", err))
+			}
+			add(parseSearchResults(text), true)
+		}
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err().Error())
+			break
+		}
+	}
+	return all, errs
+}
+
+var searchResultRe = regexp.MustCompile(`^\d+\.\s+(.+)$`)
+
+// parseSearchResults reads the "N. Title / URL / snippet" listing produced
+// by the web search providers.
+func parseSearchResults(text string) []watchPaper {
+	var papers []watchPaper
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		m := searchResultRe.FindStringSubmatch(strings.TrimSpace(lines[i]))
+		if m == nil || i+1 >= len(lines) {
+			continue
+		}
+		link := strings.TrimSpace(lines[i+1])
+		u, err := url.Parse(link)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		p := watchPaper{
+			Key:   "url:" + strings.TrimSuffix(link, "/"),
+			Title: strings.TrimSpace(m[1]),
+			Venue: strings.TrimPrefix(u.Host, "www."),
+			Link:  link,
+		}
+		if i+2 < len(lines) && !searchResultRe.MatchString(strings.TrimSpace(lines[i+2])) {
+			p.Abstract = strings.TrimSpace(lines[i+2])
+		}
+		papers = append(papers, p)
+	}
+	return papers
+}
+
+// selectReviewSources keeps the most relevant candidates: those found by
+// more queries first, papers before web pages, then in search order. Web
+// pages are limited to a third of the selection.
+func selectReviewSources(candidates []*reviewSource, max int) []*reviewSource {
+	sorted := append([]*reviewSource(nil), candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.hits != b.hits {
+			return a.hits > b.hits
+		}
+		if a.Web != b.Web {
+			return !a.Web
+		}
+		if (a.Abstract == "") != (b.Abstract == "") {
+			return a.Abstract != ""
+		}
+		return a.order < b.order
+	})
+	webLimit := max / 3
+	var out []*reviewSource
+	for _, s := range sorted {
+		if len(out) == max {
+			break
+		}
+		if s.Web {
+			if webLimit == 0 {
+				continue
+			}
+			webLimit--
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// readPDFs downloads and extracts the start of each source's PDF, up to
+// reviewPDFLimit, and returns how many were read.
+func (t *LiteratureReviewTool) readPDFs(ctx context.Context, sources []*reviewSource) int {
+	read := 0
+	for _, s := range sources {
+		if read == reviewPDFLimit || ctx.Err() != nil {
+			break
+		}
+		if s.PDF == "" {
+			continue
+		}
+		text, err := t.fetchPDFText(ctx, s.PDF)
+		if err != nil {
+			logger.DebugCF("tool", "Skipping PDF", map[string]interface{}{"url": s.PDF, "error": err.Error()})
+			continue
+		}
+		s.FullText = utils.Truncate(text, reviewPDFChars)
+		read++
+	}
+	return read
+}
+
+func (t *LiteratureReviewTool) fetchPDFText(ctx context.Context, pdfURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pdfURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+	resp, err := withTimeout(t.client, 60*time.Second).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("PDF request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("PDF request failed: %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read PDF: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF")) {
+		return "", fmt.Errorf("not a PDF")
+	}
+	pages, _, err := extractPDFPages(data)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(strings.Join(pages, "\n")), " "), nil
+}
+
+var themeLineRe = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])?\s*\**(.+?)\**\s*:\s*((?:\[?\d+\]?[\s,;]*)+)$`)
+
+// cluster asks the LLM to group the sources into themes. Sources left out
+// of every theme are collected under "Other work"; without an answer all
+// sources form a single theme.
+func (t *LiteratureReviewTool) cluster(ctx context.Context, topic string, sources []*reviewSource) []reviewTheme {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "These sources were found for a literature review on: %s\n\n", topic)
+	for i, s := range sources {
+		fmt.Fprintf(&sb, "[%d] %s\n%s\n\n", i+1, s.Title, utils.Truncate(s.Abstract, 400))
+	}
+	sb.WriteString("Group the sources into 2 to 5 themes by the approach or question they address. " +
+		"Reply with one line per theme, formatted \"<theme name>: <source numbers separated by commas>\", and nothing else. " +
+		"Put every source in exactly one theme.")
+	reply, err := t.chat(ctx, sb.String(), 512)
+	if err != nil {
+		logger.WarnCF("tool", "Literature review clustering failed", map[string]interface{}{"error": err.Error()})
+	}
+
+	var themes []reviewTheme
+	used := make(map[int]bool)
+	for _, line := range strings.Split(reply, "\n") {
+		m := themeLineRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		th := reviewTheme{Name: strings.TrimSpace(m[1])}
+		for _, f := range strings.FieldsFunc(m[2], func(r rune) bool { return r < '0' || r > '9' }) {
+			n, _ := strconv.Atoi(f)
+			if n >= 1 && n <= len(sources) && !used[n-1] {
+				used[n-1] = true
+				th.Sources = append(th.Sources, n-1)
+			}
+		}
+		if len(th.Sources) > 0 {
+			themes = append(themes, th)
+		}
+	}
+	var rest []int
+	for i := range sources {
+		if !used[i] {
+			rest = append(rest, i)
+		}
+	}
+	if len(themes) == 0 {
+		return []reviewTheme{{Name: "Overview", Sources: rest}}
+	}
+	if len(rest) > 0 {
+		themes = append(themes, reviewTheme{Name: "Other work", Sources: rest})
+	}
+	return themes
+}
+
+// write asks the LLM for the review body. If that fails, the body is an
+// annotated list of the sources by theme.
+func (t *LiteratureReviewTool) write(ctx context.Context, topic, focus string, themes []reviewTheme, sources []*reviewSource) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Write a literature review in Markdown on: %s\n", topic)
+	if focus != "" {
+		fmt.Fprintf(&sb, "Focus on: %s\n", focus)
+	}
+	sb.WriteString("\nUse only the numbered sources below. Cite them inline as [n] (e.g. [2] or [3][5]) for every claim they support, " +
+		"and never cite a number that is not listed. Do not invent findings or sources.\n" +
+		"Structure: a '# Literature review: <topic>' title, an '## Overview' paragraph, one '##' section per theme below " +
+		"comparing the sources' approaches and results, and '## Gaps and open questions'. Do not add a reference list; it is appended automatically.\n\n")
+	for _, th := range themes {
+		fmt.Fprintf(&sb, "Theme: %s\n", th.Name)
+		for _, i := range th.Sources {
+			s := sources[i]
+			fmt.Fprintf(&sb, "[%d] %s (%s)\n", i+1, s.Title, reviewCitationMeta(s))
+			if s.Abstract != "" {
+				fmt.Fprintf(&sb, "Abstract: %s\n", utils.Truncate(s.Abstract, 1500))
+			}
+			if s.FullText != "" {
+				fmt.Fprintf(&sb, "Full text excerpt: %s\n", s.FullText)
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	body, err := t.chat(ctx, sb.String(), 4096)
+	if err == nil && strings.TrimSpace(body) != "" {
+		return stripInvalidCitations(body, len(sources))
+	}
+	if err != nil {
+		logger.WarnCF("tool", "Literature review writing failed, saving an annotated list",
+			map[string]interface{}{"error": err.Error()})
+	}
+	return formatReviewFallback(topic, themes, sources)
+}
+
+var citationRe = regexp.MustCompile(`\[(\d+)\]`)
+
+// stripInvalidCitations removes citations of sources that do not exist.
+func stripInvalidCitations(body string, n int) string {
+	return citationRe.ReplaceAllStringFunc(body, func(c string) string {
+		if k, _ := strconv.Atoi(c[1 : len(c)-1]); k >= 1 && k <= n {
+			return c
+		}
+		return ""
+	})
+}
+
+func formatReviewFallback(topic string, themes []reviewTheme, sources []*reviewSource) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Literature review: %s\n\n", topic)
+	sb.WriteString("_The summary could not be generated; the sources found are listed by theme._\n")
+	for _, th := range themes {
+		fmt.Fprintf(&sb, "\n## %s\n\n", th.Name)
+		for _, i := range th.Sources {
+			s := sources[i]
+			fmt.Fprintf(&sb, "- **%s** [%d]", s.Title, i+1)
+			if s.Abstract != "" {
+				fmt.Fprintf(&sb, ": %s", utils.Truncate(s.Abstract, 300))
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// reviewCitationMeta renders "authors, venue, year" for a source.
+func reviewCitationMeta(s *reviewSource) string {
+	var parts []string
+	if len(s.Authors) > 0 && s.Authors[0] != "" {
+		authors := strings.Join(s.Authors, ", ")
+		if len(s.Authors) > 3 {
+			authors = s.Authors[0] + " et al."
+		}
+		parts = append(parts, authors)
+	}
+	if s.Venue != "" {
+		parts = append(parts, s.Venue)
+	}
+	if len(s.Date) >= 4 {
+		parts = append(parts, s.Date[:4])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatReviewBibliography(sources []*reviewSource) string {
+	var sb strings.Builder
+	sb.WriteString("## References\n\n")
+	for i, s := range sources {
+		fmt.Fprintf(&sb, "[%d] ", i+1)
+		if meta := reviewCitationMeta(s); meta != "" {
+			fmt.Fprintf(&sb, "%s. ", meta)
+		}
+		fmt.Fprintf(&sb, "*%s*.", s.Title)
+		if s.Link != "" {
+			fmt.Fprintf(&sb, " <%s>", s.Link)
+		}
+		sb.WriteString("\n\n")
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+var slugRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// reviewSlug turns a topic into a short file name component.
+func reviewSlug(topic string) string {
+	slug := strings.Trim(slugRe.ReplaceAllString(strings.ToLower(topic), "-"), "-")
+	if len(slug) > 50 {
+		slug = strings.TrimRight(slug[:50], "-")
+	}
+	if slug == "" {
+		slug = strconv.FormatInt(time.Now().Unix(), 10)
+	}
+	return slug
+}
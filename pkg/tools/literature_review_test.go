@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/srikesh3005/summer/pkg/bus"
+	"github.com/srikesh3005/summer/pkg/providers"
+)
+
+// reviewProvider answers the planning, clustering and writing prompts of a
+// literature review.
+type reviewProvider struct {
+	mu      sync.Mutex
+	prompts []string
+	fail    bool
+}
+
+func (p *reviewProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (*providers.LLMResponse, error) {
+	prompt := messages[0].Content
+	p.mu.Lock()
+	p.prompts = append(p.prompts, prompt)
+	p.mu.Unlock()
+	if p.fail {
+		return nil, errors.New("provider unavailable")
+	}
+	switch {
+	case strings.HasPrefix(prompt, "Plan the searches"):
+		return &providers.LLMResponse{Content: "1. latent diffusion music\n- \"speech enhancement diffusion\"\n"}, nil
+	case strings.Contains(prompt, "Group the sources"):
+		return &providers.LLMResponse{Content: "Themes:\n**Generation**: 1, 2\nEnhancement: [3]\n"}, nil
+	default:
+		return &providers.LLMResponse{Content: "# Literature review: audio diffusion\n\n## Overview\nDiffusion is fast [1] and enhances speech [3][9].\n"}, nil
+	}
+}
+
+func (p *reviewProvider) GetDefaultModel() string { return "test-model" }
+
+type stubSearchProvider struct{}
+
+func (stubSearchProvider) Search(ctx context.Context, query string, count int) (string, error) {
+	return "Results for: " + query + "\n1. Diffusion explained\n   https://www.example.com/diffusion/\n   A blog post on diffusion.\n2. No snippet\n   https://example.org/x", nil
+}
+
+func newReviewTool(t *testing.T, provider providers.LLMProvider) (*LiteratureReviewTool, *bus.MessageBus, string) {
+	t.Helper()
+	workspace := t.TempDir()
+	pdfPath := filepath.Join(workspace, "paper.pdf")
+	buildTestPDF(t, pdfPath, []string{"We distill the sampler into one step."})
+	pdf, err := os.ReadFile(pdfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixtures := make(map[string][]byte)
+	for path, name := range watchRoutes {
+		fixtures[path] = readFixture(t, "research_watch", name)
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/pdf/2410.01234v1":
+			w.Write(pdf)
+			return
+		case strings.HasPrefix(r.URL.Path, "/pdf/"):
+			http.NotFound(w, r)
+			return
+		}
+		data, ok := fixtures[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(strings.ReplaceAll(string(data), "http://arxiv.org/pdf/", server.URL+"/pdf/")))
+	}))
+	t.Cleanup(server.Close)
+
+	arxiv := NewArXivTool(testOutboundClient())
+	arxiv.baseURL = server.URL + "/arxiv"
+	crossref := NewCrossrefTool(testOutboundClient())
+	crossref.baseURL = server.URL + "/works"
+	msgBus := bus.NewMessageBus()
+	message := NewMessageTool()
+	message.SetSendCallback(func(channel, chatID, content string) error {
+		msgBus.PublishOutbound(bus.OutboundMessage{Channel: channel, ChatID: chatID, Content: content})
+		return nil
+	})
+	tool := NewLiteratureReviewTool(LiteratureReviewToolOptions{
+		Provider:   provider,
+		Model:      "test-model",
+		ArXiv:      arxiv,
+		Crossref:   crossref,
+		WebSearch:  &WebSearchTool{provider: stubSearchProvider{}, maxResults: 5},
+		Markdown:   NewMarkdownFileTool(workspace, true, msgBus),
+		Message:    message,
+		HTTPClient: testOutboundClient(),
+	})
+	tool.SetContext("telegram", "42")
+	return tool, msgBus, workspace
+}
+
+func TestLiteratureReview(t *testing.T) {
+	provider := &reviewProvider{}
+	tool, msgBus, workspace := newReviewTool(t, provider)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"topic":       "Audio diffusion",
+		"max_sources": 4.0,
+	})
+	if result.IsError {
+		t.Fatalf("Execute failed: %s", result.ForLLM)
+	}
+
+	data, err := os.ReadFile(filepath.Join(workspace, "reports", "literature-review-audio-diffusion.md"))
+	if err != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	report := string(data)
+	for _, want := range []string{
+		"Diffusion is fast [1] and enhances speech [3].",
+		"## References",
+		"[1] Ada Lovelace, Alan Turing, arXiv, 2024. *Fast Audio Diffusion with Consistency Distillation*. <https://arxiv.org/abs/2410.01234>",
+		"[3] Claude Shannon, IEEE/ACM Transactions on Audio, Speech, and Language Processing, 2024. *Diffusion Models for Speech Enhancement*.",
+		"[4] example.com. *Diffusion explained*. <https://www.example.com/diffusion/>",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "[9]") || strings.Contains(report, "[5]") {
+		t.Errorf("report cites sources that do not exist:\n%s", report)
+	}
+
+	// Three queries: the topic and the two planned ones.
+	if len(provider.prompts) != 3 {
+		t.Fatalf("expected plan, cluster and write prompts, got %d", len(provider.prompts))
+	}
+	write := provider.prompts[2]
+	for _, want := range []string{"Theme: Generation\n[1] ", "Theme: Enhancement\n[3] ", "Theme: Other work\n[4] ",
+		"Full text excerpt: We distill the sampler into one step."} {
+		if !strings.Contains(write, want) {
+			t.Errorf("write prompt missing %q:\n%s", want, write)
+		}
+	}
+	if !strings.Contains(result.ForLLM, "Themes: Generation; Enhancement; Other work") {
+		t.Errorf("unexpected result: %s", result.ForLLM)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var progress []string
+	for {
+		msg, ok := msgBus.SubscribeOutbound(ctx)
+		if !ok {
+			t.Fatal("report file was not sent")
+		}
+		if msg.FilePath != "" {
+			if !strings.HasSuffix(msg.FilePath, "literature-review-audio-diffusion.md") || msg.ChatID != "42" {
+				t.Errorf("unexpected file message: %+v", msg)
+			}
+			break
+		}
+		progress = append(progress, msg.Content)
+	}
+	if len(progress) != 5 || !strings.Contains(progress[2], "Read 1 of 4 papers in full") {
+		t.Errorf("unexpected progress messages: %q", progress)
+	}
+	if tool.opts.Message.HasSentInRound() {
+		t.Error("progress updates should not count as the round's reply")
+	}
+}
+
+func TestLiteratureReview_Fallback(t *testing.T) {
+	tool, _, workspace := newReviewTool(t, &reviewProvider{fail: true})
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"topic":     "audio diffusion",
+		"web":       false,
+		"read_pdfs": false,
+		"send":      false,
+		"path":      "notes/review",
+	})
+	if result.IsError {
+		t.Fatalf("Execute failed: %s", result.ForLLM)
+	}
+	data, err := os.ReadFile(filepath.Join(workspace, "notes", "review.md"))
+	if err != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	report := string(data)
+	for _, want := range []string{"could not be generated", "## Overview", "- **Music Generation by Latent Diffusion** [2]", "## References"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "example.com") {
+		t.Error("web results included with web disabled")
+	}
+
+	if r := tool.Execute(context.Background(), map[string]interface{}{}); !r.IsError {
+		t.Error("expected an error without a topic")
+	}
+}
+
+func TestParseSearchResults(t *testing.T) {
+	papers := parseSearchResults("Results for: q (via DuckDuckGo)\n1. First\n   https://a.example/x\n   Snippet one\n2. Second\n   not a url\n3. Third\n   https://b.example/\n")
+	if len(papers) != 2 {
+		t.Fatalf("expected 2 results, got %+v", papers)
+	}
+	if papers[0].Title != "First" || papers[0].Abstract != "Snippet one" || papers[0].Venue != "a.example" {
+		t.Errorf("unexpected first result: %+v", papers[0])
+	}
+	if papers[1].Key != "url:https://b.example" || papers[1].Abstract != "" {
+		t.Errorf("unexpected second result: %+v", papers[1])
+	}
+}
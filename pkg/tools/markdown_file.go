@@ -13,18 +13,16 @@ import (
 
 // MarkdownFileTool creates a markdown file and can send it to the active chat.
 type MarkdownFileTool struct {
-	workspace string
-	restrict  bool
-	msgBus    *bus.MessageBus
-	channel   string
-	chatID    string
+	paths   *PathPolicy
+	msgBus  *bus.MessageBus
+	channel string
+	chatID  string
 }
 
-func NewMarkdownFileTool(workspace string, restrict bool, msgBus *bus.MessageBus) *MarkdownFileTool {
+func NewMarkdownFileTool(workspace string, restrict bool, msgBus *bus.MessageBus, roots ...PathRoot) *MarkdownFileTool {
 	return &MarkdownFileTool{
-		workspace: workspace,
-		restrict:  restrict,
-		msgBus:    msgBus,
+		paths:  NewPathPolicy(workspace, restrict, roots...),
+		msgBus: msgBus,
 	}
 }
 
@@ -80,7 +78,7 @@ func (t *MarkdownFileTool) Execute(ctx context.Context, args map[string]interfac
 		path += ".md"
 	}
 
-	resolvedPath, err := validatePath(path, t.workspace, t.restrict)
+	resolvedPath, err := t.paths.Resolve(path, PathWrite)
 	if err != nil {
 		return ErrorResult(err.Error())
 	}
@@ -7,8 +7,12 @@ import (
 
 type SendCallback func(channel, chatID, content string) error
 
+// ContentFilter may rewrite a message before it is sent.
+type ContentFilter func(ctx context.Context, content string) string
+
 type MessageTool struct {
 	sendCallback   SendCallback
+	filter         ContentFilter
 	defaultChannel string
 	defaultChatID  string
 	sentInRound    bool // Tracks whether a message was sent in the current processing round
@@ -62,6 +66,25 @@ func (t *MessageTool) SetSendCallback(callback SendCallback) {
 	t.sendCallback = callback
 }
 
+// SetContentFilter sets a filter applied to messages sent by Execute, such
+// as the citation check the agent runs on its final answers.
+func (t *MessageTool) SetContentFilter(filter ContentFilter) {
+	t.filter = filter
+}
+
+// SendProgress sends a status update from a long-running tool. Unlike
+// Execute it does not count as the round's reply, so the agent's final
+// response is still delivered.
+func (t *MessageTool) SendProgress(channel, chatID, content string) error {
+	if channel == "" || chatID == "" {
+		return fmt.Errorf("no target channel/chat specified")
+	}
+	if t.sendCallback == nil {
+		return fmt.Errorf("message sending not configured")
+	}
+	return t.sendCallback(channel, chatID, content)
+}
+
 func (t *MessageTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
 	content, ok := args["content"].(string)
 	if !ok {
@@ -86,6 +109,10 @@ func (t *MessageTool) Execute(ctx context.Context, args map[string]interface{})
 		return &ToolResult{ForLLM: "Message sending not configured", IsError: true}
 	}
 
+	if t.filter != nil {
+		content = t.filter(ctx, content)
+	}
+
 	if err := t.sendCallback(channel, chatID, content); err != nil {
 		return &ToolResult{
 			ForLLM:  fmt.Sprintf("sending message: %v", err),
@@ -58,6 +58,34 @@ func TestMessageTool_Execute_Success(t *testing.T) {
 	}
 }
 
+func TestMessageTool_Execute_ContentFilter(t *testing.T) {
+	tool := NewMessageTool()
+	tool.SetContext("test-channel", "test-chat-id")
+
+	var sentContent string
+	tool.SetSendCallback(func(channel, chatID, content string) error {
+		sentContent = content
+		return nil
+	})
+	tool.SetContentFilter(func(ctx context.Context, content string) string {
+		return content + " [checked]"
+	})
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"content": "See doi:10.9999/nope"})
+	if result.IsError {
+		t.Fatalf("Execute failed: %s", result.ForLLM)
+	}
+	if sentContent != "See doi:10.9999/nope [checked]" {
+		t.Errorf("Expected filtered content, got '%s'", sentContent)
+	}
+
+	// Progress updates are not replies and are sent as is.
+	tool.SendProgress("test-channel", "test-chat-id", "Searching...")
+	if sentContent != "Searching..." {
+		t.Errorf("Expected unfiltered progress, got '%s'", sentContent)
+	}
+}
+
 func TestMessageTool_Execute_WithCustomChannel(t *testing.T) {
 	tool := NewMessageTool()
 	tool.SetContext("default-channel", "default-chat-id")
@@ -0,0 +1,256 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const openAlexAPI = "https://api.openalex.org"
+
+// openAlex is the OpenAlex API.
+type openAlex struct {
+	client  *http.Client
+	baseURL string
+	mailto  string
+}
+
+type oaWork struct {
+	ID          string `json:"id"`
+	DOI         string `json:"doi"`
+	Title       string `json:"display_name"`
+	Year        int    `json:"publication_year"`
+	Authorships []struct {
+		Author struct {
+			DisplayName string `json:"display_name"`
+		} `json:"author"`
+	} `json:"authorships"`
+	PrimaryLocation *oaLocation  `json:"primary_location"`
+	Locations       []oaLocation `json:"locations"`
+	CitedByCount    int          `json:"cited_by_count"`
+	ReferencedWorks []string     `json:"referenced_works"`
+	// AbstractInvertedIndex maps each word of the abstract to its positions.
+	AbstractInvertedIndex map[string][]int `json:"abstract_inverted_index"`
+}
+
+type oaLocation struct {
+	LandingPageURL string `json:"landing_page_url"`
+	Source         *struct {
+		DisplayName string `json:"display_name"`
+	} `json:"source"`
+}
+
+var arxivLandingRe = regexp.MustCompile(`arxiv\.org/abs/([^?#]+)`)
+
+func (w *oaWork) toPaper(source string) *scholarPaper {
+	out := &scholarPaper{
+		Title:       w.Title,
+		Year:        w.Year,
+		DOI:         w.DOI,
+		Citations:   w.CitedByCount,
+		References:  len(w.ReferencedWorks),
+		IDs:         map[string]string{source: shortOpenAlexID(w.ID)},
+		CitationsBy: map[string]int{source: w.CitedByCount},
+		Sources:     []string{source},
+		Abstract:    invertedIndexText(w.AbstractInvertedIndex),
+	}
+	for _, a := range w.Authorships {
+		out.Authors = append(out.Authors, a.Author.DisplayName)
+	}
+	for _, ref := range w.ReferencedWorks {
+		out.refIDs = append(out.refIDs, shortOpenAlexID(ref))
+	}
+	if loc := w.PrimaryLocation; loc != nil {
+		out.URL = loc.LandingPageURL
+		if loc.Source != nil {
+			out.Venue = loc.Source.DisplayName
+		}
+	}
+	for _, loc := range w.Locations {
+		if m := arxivLandingRe.FindStringSubmatch(loc.LandingPageURL); m != nil {
+			out.ArXivID = m[1]
+			break
+		}
+	}
+	if out.URL == "" {
+		out.URL = w.ID
+	}
+	out.normalizeIdentifiers()
+	return out
+}
+
+// shortOpenAlexID strips the https://openalex.org/ prefix from an ID.
+func shortOpenAlexID(id string) string {
+	return strings.TrimPrefix(id, "https://openalex.org/")
+}
+
+// invertedIndexText rebuilds an abstract from OpenAlex's inverted index.
+func invertedIndexText(index map[string][]int) string {
+	if len(index) == 0 {
+		return ""
+	}
+	type wordAt struct {
+		pos  int
+		word string
+	}
+	var words []wordAt
+	for word, positions := range index {
+		for _, pos := range positions {
+			words = append(words, wordAt{pos, word})
+		}
+	}
+	sort.Slice(words, func(i, j int) bool { return words[i].pos < words[j].pos })
+	parts := make([]string, len(words))
+	for i, w := range words {
+		parts[i] = w.word
+	}
+	return strings.Join(parts, " ")
+}
+
+func (s *openAlex) name() string {
+	return "OpenAlex"
+}
+
+func (s *openAlex) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	if s.mailto != "" {
+		params.Set("mailto", s.mailto)
+	}
+	rawURL := s.baseURL + path
+	if len(params) > 0 {
+		rawURL += "?" + params.Encode()
+	}
+	return scholarGet(ctx, s.client, rawURL, nil, out)
+}
+
+// listWorks runs a /works query and converts the results.
+func (s *openAlex) listWorks(ctx context.Context, params url.Values) ([]*scholarPaper, error) {
+	var resp struct {
+		Results []oaWork `json:"results"`
+	}
+	if err := s.get(ctx, "/works", params, &resp); err != nil {
+		return nil, err
+	}
+	var out []*scholarPaper
+	for i := range resp.Results {
+		out = append(out, resp.Results[i].toPaper(s.name()))
+	}
+	return out, nil
+}
+
+func (s *openAlex) paper(ctx context.Context, id paperID) (*scholarPaper, error) {
+	var path string
+	switch id.kind {
+	case "doi":
+		path = "/works/doi:" + escapeIDPath(id.value)
+	case "arxiv":
+		path = "/works/doi:" + arxivDOIPrefix + escapeIDPath(id.value)
+	case "openalex":
+		path = "/works/" + id.value
+	default:
+		return nil, fmt.Errorf("OpenAlex cannot look up %s IDs; use a DOI or arXiv ID", id.kind)
+	}
+	var w oaWork
+	if err := s.get(ctx, path, nil, &w); err != nil {
+		return nil, err
+	}
+	return w.toPaper(s.name()), nil
+}
+
+// workID returns the OpenAlex ID of p, looking it up by DOI or arXiv ID if
+// p came from another source.
+func (s *openAlex) workID(ctx context.Context, p *scholarPaper) (*scholarPaper, string, error) {
+	if id := p.IDs[s.name()]; id != "" {
+		return p, id, nil
+	}
+	var id paperID
+	switch {
+	case p.DOI != "":
+		id = paperID{"doi", p.DOI}
+	case p.ArXivID != "":
+		id = paperID{"arxiv", p.ArXivID}
+	default:
+		return nil, "", fmt.Errorf("no identifier OpenAlex understands for %q", p.Title)
+	}
+	found, err := s.paper(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	return found, found.IDs[s.name()], nil
+}
+
+func (s *openAlex) search(ctx context.Context, query string, limit int) ([]*scholarPaper, error) {
+	return s.listWorks(ctx, url.Values{"search": {query}, "per-page": {fmt.Sprint(limit)}})
+}
+
+func (s *openAlex) citations(ctx context.Context, p *scholarPaper, limit int) ([]*scholarPaper, error) {
+	_, id, err := s.workID(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return s.listWorks(ctx, url.Values{
+		"filter":   {"cites:" + id},
+		"sort":     {"cited_by_count:desc"},
+		"per-page": {fmt.Sprint(limit)},
+	})
+}
+
+func (s *openAlex) references(ctx context.Context, p *scholarPaper, limit int) ([]*scholarPaper, error) {
+	work, _, err := s.workID(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	refs := work.refIDs
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	if len(refs) > limit {
+		refs = refs[:limit]
+	}
+	return s.listWorks(ctx, url.Values{
+		"filter":   {"openalex:" + strings.Join(refs, "|")},
+		"per-page": {fmt.Sprint(len(refs))},
+	})
+}
+
+func (s *openAlex) authors(ctx context.Context, query string, limit int) ([]*scholarAuthor, error) {
+	var resp struct {
+		Results []struct {
+			ID           string `json:"id"`
+			DisplayName  string `json:"display_name"`
+			WorksCount   int    `json:"works_count"`
+			CitedByCount int    `json:"cited_by_count"`
+			SummaryStats struct {
+				HIndex int `json:"h_index"`
+			} `json:"summary_stats"`
+			LastKnownInstitutions []struct {
+				DisplayName string `json:"display_name"`
+			} `json:"last_known_institutions"`
+		} `json:"results"`
+	}
+	if err := s.get(ctx, "/authors", url.Values{"search": {query}, "per-page": {fmt.Sprint(limit)}}, &resp); err != nil {
+		return nil, err
+	}
+	var out []*scholarAuthor
+	for _, a := range resp.Results {
+		author := &scholarAuthor{
+			ID:        shortOpenAlexID(a.ID),
+			Name:      a.DisplayName,
+			Papers:    a.WorksCount,
+			Citations: a.CitedByCount,
+			HIndex:    a.SummaryStats.HIndex,
+			URL:       a.ID,
+		}
+		for _, inst := range a.LastKnownInstitutions {
+			author.Affiliations = append(author.Affiliations, inst.DisplayName)
+		}
+		out = append(out, author)
+	}
+	return out, nil
+}
@@ -0,0 +1,321 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultMaxResponseBytes caps response bodies read through an outbound
+// client when the policy does not set a limit.
+const DefaultMaxResponseBytes = 10 << 20
+
+// maxRedirects is how many redirects an outbound client follows.
+const maxRedirects = 5
+
+// blockedNetworks lists ranges that IsPrivate/IsLoopback and friends do not
+// cover but that are still not the public internet.
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",       // "this" network
+	"100.64.0.0/10",   // carrier-grade NAT
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // TEST-NET-1
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // TEST-NET-2
+	"203.0.113.0/24",  // TEST-NET-3
+	"240.0.0.0/4",     // reserved, includes broadcast
+	"64:ff9b::/96",    // NAT64, can map onto private IPv4
+	"2001:db8::/32",   // documentation
+)
+
+// OutboundPolicy controls which hosts tools that fetch model-chosen URLs may
+// reach. By default loopback, private, link-local (including cloud metadata
+// endpoints) and other non-public addresses are refused, so a prompt-injected
+// page cannot steer the agent at the local network.
+type OutboundPolicy struct {
+	// AllowHosts exempts hosts from the private-network check. Entries are
+	// hostnames ("intranet.example"), wildcard domains ("*.corp.example"),
+	// IP addresses or CIDR ranges ("10.0.0.0/8").
+	AllowHosts []string
+	// MaxResponseBytes caps response bodies; reading past it fails.
+	// Zero means DefaultMaxResponseBytes.
+	MaxResponseBytes int64
+}
+
+// outboundGuard checks destinations against an OutboundPolicy.
+type outboundGuard struct {
+	hosts    map[string]bool
+	suffixes []string
+	nets     []*net.IPNet
+}
+
+func newOutboundGuard(policy OutboundPolicy) *outboundGuard {
+	g := &outboundGuard{hosts: make(map[string]bool)}
+	for _, entry := range policy.AllowHosts {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+		case strings.HasPrefix(entry, "*."):
+			g.suffixes = append(g.suffixes, entry[1:])
+		case strings.Contains(entry, "/"):
+			if _, n, err := net.ParseCIDR(entry); err == nil {
+				g.nets = append(g.nets, n)
+			}
+		default:
+			if ip := net.ParseIP(entry); ip != nil {
+				bits := 8 * len(ip.To16())
+				if ip.To4() != nil {
+					ip, bits = ip.To4(), 32
+				}
+				g.nets = append(g.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			} else {
+				g.hosts[entry] = true
+			}
+		}
+	}
+	return g
+}
+
+// hostAllowed reports whether host is explicitly allowlisted by name.
+func (g *outboundGuard) hostAllowed(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if g.hosts[host] {
+		return true
+	}
+	for _, suffix := range g.suffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIP returns an error if ip is a non-public address that is not
+// allowlisted.
+func (g *outboundGuard) checkIP(ip net.IP) error {
+	for _, n := range g.nets {
+		if n.Contains(ip) {
+			return nil
+		}
+	}
+	if isNonPublicIP(ip) {
+		return fmt.Errorf("destination %s is a private or reserved address (add it to tools.network.allow_hosts to permit)", ip)
+	}
+	return nil
+}
+
+// checkURL validates the scheme and, for IP literals, the address of u. Host
+// names are resolved and checked when the connection is dialed.
+func (g *outboundGuard) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("only http/https URLs are allowed")
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("missing domain in URL")
+	}
+	if g.hostAllowed(host) {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return g.checkIP(ip)
+	}
+	if h := strings.ToLower(strings.TrimSuffix(host, ".")); h == "localhost" || strings.HasSuffix(h, ".localhost") {
+		return fmt.Errorf("destination %s is a loopback host (add it to tools.network.allow_hosts to permit)", host)
+	}
+	return nil
+}
+
+// checkResolved is checkURL followed by a lookup of the host name, for
+// callers whose connections are dialed elsewhere and that want a clear error
+// up front. Lookup failures are left for the connection to report.
+func (g *outboundGuard) checkResolved(ctx context.Context, u *url.URL) error {
+	if err := g.checkURL(u); err != nil {
+		return err
+	}
+	host := u.Hostname()
+	if g.hostAllowed(host) || net.ParseIP(host) != nil {
+		return nil
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil
+	}
+	for _, ip := range ips {
+		if err := g.checkIP(ip.IP); err != nil {
+			return fmt.Errorf("%s: %w", host, err)
+		}
+	}
+	return nil
+}
+
+// dialContext resolves the host itself and connects to a checked address, so
+// a DNS answer cannot change between the check and the connection.
+func (g *outboundGuard) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if g.hostAllowed(host) {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var lastErr error
+		for _, ip := range ips {
+			if err := g.checkIP(ip.IP); err != nil {
+				// Refuse hosts that resolve to any blocked address
+				// rather than picking a public one among them.
+				return nil, err
+			}
+		}
+		for _, ip := range ips {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.IP.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		if lastErr == nil {
+			lastErr = fmt.Errorf("no addresses found for %s", host)
+		}
+		return nil, lastErr
+	}
+}
+
+// NewOutboundClient returns an HTTP client that enforces policy on every
+// connection and redirect and limits response body size. Environment proxies
+// are ignored, since the proxy would connect on the client's behalf.
+func NewOutboundClient(policy OutboundPolicy, timeout time.Duration) *http.Client {
+	guard := newOutboundGuard(policy)
+	maxBytes := policy.MaxResponseBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxResponseBytes
+	}
+
+	dialer := &net.Dialer{Timeout: 15 * time.Second, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		Proxy:               nil,
+		DialContext:         guard.dialContext(dialer),
+		MaxIdleConns:        10,
+		IdleConnTimeout:     30 * time.Second,
+		TLSHandshakeTimeout: 15 * time.Second,
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &limitedTransport{guard: guard, next: transport, maxBytes: maxBytes},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return guard.checkURL(req.URL)
+		},
+	}
+}
+
+// withTimeout returns a copy of client (or the default client if nil) with a
+// different overall timeout, sharing its transport and policy.
+func withTimeout(client *http.Client, timeout time.Duration) *http.Client {
+	c := *clientOrDefault(client)
+	c.Timeout = timeout
+	return &c
+}
+
+// defaultOutboundClient is used by tools constructed without a client.
+var defaultOutboundClient = NewOutboundClient(OutboundPolicy{}, 60*time.Second)
+
+// clientOrDefault is how tools that fetch model-chosen URLs pick their
+// client. Constructors take a client built with NewOutboundClient from the
+// configured policy; nil falls back to the default OutboundPolicy.
+func clientOrDefault(client *http.Client) *http.Client {
+	if client == nil {
+		return defaultOutboundClient
+	}
+	return client
+}
+
+// limitedTransport checks each request URL before sending it and caps the
+// response body.
+type limitedTransport struct {
+	guard    *outboundGuard
+	next     http.RoundTripper
+	maxBytes int64
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.guard.checkURL(req.URL); err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ContentLength > t.maxBytes {
+		resp.Body.Close()
+		return nil, fmt.Errorf("response too large: %d bytes (limit %d)", resp.ContentLength, t.maxBytes)
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.maxBytes, limit: t.maxBytes}
+	return resp, nil
+}
+
+// limitedBody fails reads once more than limit bytes have been returned.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	limit     int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, fmt.Errorf("response body exceeds %d bytes", b.limit)
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), fmt.Errorf("response body exceeds %d bytes", b.limit)
+	}
+	return n, err
+}
+
+// isNonPublicIP reports whether ip is loopback, private, link-local,
+// multicast, unspecified or in another reserved range.
+func isNonPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, n := range blockedNetworks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
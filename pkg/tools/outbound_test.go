@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsNonPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"255.255.255.255", true},
+		{"::1", true},
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"64:ff9b::a00:1", true},
+		{"8.8.8.8", false},
+		{"1.1.1.1", false},
+		{"2606:4700:4700::1111", false},
+	}
+	for _, tt := range tests {
+		if got := isNonPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isNonPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestOutboundClient_BlocksPrivateByDefault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	}))
+	defer server.Close()
+
+	client := NewOutboundClient(OutboundPolicy{}, 5*time.Second)
+	for _, u := range []string{
+		server.URL,
+		strings.Replace(server.URL, "127.0.0.1", "localhost", 1),
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::1]/",
+	} {
+		resp, err := client.Get(u)
+		if err == nil {
+			resp.Body.Close()
+			t.Errorf("expected %s to be blocked", u)
+			continue
+		}
+		if !strings.Contains(err.Error(), "allow_hosts") {
+			t.Errorf("expected policy error for %s, got: %v", u, err)
+		}
+	}
+}
+
+func TestOutboundClient_BlocksResolvedPrivateAddress(t *testing.T) {
+	guard := newOutboundGuard(OutboundPolicy{})
+	dial := guard.dialContext(&net.Dialer{Timeout: time.Second})
+
+	// Names that resolve to loopback are caught at dial time, not by the
+	// URL check.
+	_, err := dial(context.Background(), "tcp", "localhost:80")
+	if err == nil || !strings.Contains(err.Error(), "private or reserved") {
+		t.Errorf("expected resolved loopback address to be blocked, got: %v", err)
+	}
+}
+
+func TestOutboundClient_AllowHosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	for _, allow := range []string{"127.0.0.1", "127.0.0.0/8"} {
+		client := NewOutboundClient(OutboundPolicy{AllowHosts: []string{allow}}, 5*time.Second)
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Errorf("allow %q: expected success, got: %v", allow, err)
+			continue
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "ok" {
+			t.Errorf("allow %q: unexpected body %q", allow, body)
+		}
+	}
+
+	guard := newOutboundGuard(OutboundPolicy{AllowHosts: []string{"*.corp.example", "Intranet"}})
+	for _, host := range []string{"wiki.corp.example", "intranet", "INTRANET."} {
+		if !guard.hostAllowed(host) {
+			t.Errorf("expected %s to be allowed", host)
+		}
+	}
+	if guard.hostAllowed("corp.example.evil.com") {
+		t.Errorf("expected suffix match to be anchored")
+	}
+}
+
+func TestOutboundClient_RechecksRedirects(t *testing.T) {
+	// The allowlisted host redirects to the metadata endpoint, which must
+	// still be refused.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := NewOutboundClient(OutboundPolicy{AllowHosts: []string{"127.0.0.1"}}, 5*time.Second)
+	resp, err := client.Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected redirect to a private address to be blocked")
+	}
+	if !strings.Contains(err.Error(), "169.254.169.254") {
+		t.Errorf("expected error to name the blocked address, got: %v", err)
+	}
+}
+
+func TestOutboundClient_MaxResponseBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunked" {
+			w.(http.Flusher).Flush() // no Content-Length
+		}
+		w.Write([]byte(strings.Repeat("x", 2048)))
+	}))
+	defer server.Close()
+
+	client := NewOutboundClient(OutboundPolicy{AllowHosts: []string{"127.0.0.1"}, MaxResponseBytes: 1024}, 5*time.Second)
+
+	if resp, err := client.Get(server.URL); err == nil {
+		resp.Body.Close()
+		t.Errorf("expected Content-Length over the limit to be refused")
+	}
+
+	resp, err := client.Get(server.URL + "/chunked")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err == nil || !strings.Contains(err.Error(), "exceeds 1024 bytes") {
+		t.Errorf("expected size limit error, got: %v", err)
+	}
+	if len(body) > 1024 {
+		t.Errorf("read %d bytes past the limit", len(body))
+	}
+}
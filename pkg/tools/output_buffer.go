@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// outputBuffer keeps the last limit bytes written to it. Readers follow it by
+// absolute offset, so they can tell how much output was dropped in between.
+type outputBuffer struct {
+	mu     sync.Mutex
+	data   []byte
+	start  int64 // offset of data[0] in the whole stream
+	limit  int
+	closed bool
+	wake   chan struct{}
+}
+
+func newOutputBuffer(limit int) *outputBuffer {
+	return &outputBuffer{limit: limit, wake: make(chan struct{})}
+}
+
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if over := len(b.data) - b.limit; over > 0 {
+		n := copy(b.data, b.data[over:])
+		b.data = b.data[:n]
+		b.start += int64(over)
+	}
+	b.signalLocked()
+	return len(p), nil
+}
+
+// Close marks the end of the stream, e.g. when the process exited.
+func (b *outputBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.signalLocked()
+	return nil
+}
+
+func (b *outputBuffer) signalLocked() {
+	close(b.wake)
+	b.wake = make(chan struct{})
+}
+
+// end returns the offset just past the last byte written.
+func (b *outputBuffer) end() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.start + int64(len(b.data))
+}
+
+// readFrom returns the retained output from offset off on, the offset to
+// continue from, and how many bytes after off were already dropped.
+func (b *outputBuffer) readFrom(off int64) (data []byte, next, dropped int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if off < b.start {
+		dropped = b.start - off
+		off = b.start
+	}
+	data = append([]byte(nil), b.data[off-b.start:]...)
+	return data, b.start + int64(len(b.data)), dropped
+}
+
+// wait blocks until output past off is available, the stream is closed, the
+// timeout elapses or ctx is done. It reports whether the stream is closed.
+func (b *outputBuffer) wait(ctx context.Context, off int64, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		b.mu.Lock()
+		closed := b.closed
+		ready := closed || b.start+int64(len(b.data)) > off
+		wake := b.wake
+		b.mu.Unlock()
+		if ready {
+			return closed
+		}
+		select {
+		case <-wake:
+		case <-timer.C:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
@@ -0,0 +1,681 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxPatchFuzz is how many outer context lines of a hunk may be ignored
+	// when the hunk does not match otherwise.
+	maxPatchFuzz        = 2
+	maxPatchPreviewSize = 20000
+)
+
+// ApplyPatchTool applies unified diffs or lists of search/replace edits to
+// one or more files. Nothing is written unless every hunk and edit applies.
+type ApplyPatchTool struct {
+	paths *PathPolicy
+}
+
+func NewApplyPatchTool(workspace string, restrict bool, roots ...PathRoot) *ApplyPatchTool {
+	return &ApplyPatchTool{paths: NewPathPolicy(workspace, restrict, roots...)}
+}
+
+func (t *ApplyPatchTool) Name() string {
+	return "apply_patch"
+}
+
+func (t *ApplyPatchTool) Description() string {
+	return "Change one or more files at once, either with a unified diff (`patch`, as produced by `diff -u` or `git diff`; " +
+		"line numbers may be approximate and whitespace differences in context are tolerated) or with a list of " +
+		"search/replace `edits`. All changes are applied atomically: if any hunk or edit fails, no file is modified. " +
+		"Returns the resulting diff and the status of each hunk. Use dry_run to check a patch first."
+}
+
+func (t *ApplyPatchTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"patch": map[string]interface{}{
+				"type":        "string",
+				"description": "Unified diff. Use --- /dev/null to create a file and +++ /dev/null to delete one.",
+			},
+			"edits": map[string]interface{}{
+				"type":        "array",
+				"description": "Search/replace edits, applied in order",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "File to edit",
+						},
+						"old_text": map[string]interface{}{
+							"type":        "string",
+							"description": "Text to replace; matched exactly, or line by line ignoring indentation if there is no exact match. Empty to create a new file.",
+						},
+						"new_text": map[string]interface{}{
+							"type":        "string",
+							"description": "Replacement text",
+						},
+						"replace_all": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Replace every occurrence instead of requiring a unique match",
+						},
+					},
+					"required": []string{"path", "old_text", "new_text"},
+				},
+			},
+			"dry_run": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Only check that the changes apply and show the diff",
+			},
+		},
+	}
+}
+
+// patchFile is the pending change to one file.
+type patchFile struct {
+	path     string // as given, for messages
+	resolved string
+	original string
+	content  string
+	mode     os.FileMode // permissions of the original file
+	exists   bool
+	delete   bool
+	status   []string
+}
+
+func (t *ApplyPatchTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	patchText, _ := args["patch"].(string)
+	edits, _ := args["edits"].([]interface{})
+	if strings.TrimSpace(patchText) == "" && len(edits) == 0 {
+		return ErrorResult("patch or edits is required")
+	}
+	dryRun, _ := args["dry_run"].(bool)
+
+	files := make(map[string]*patchFile)
+	var order []string
+	load := func(path string) (*patchFile, error) {
+		resolved, err := t.paths.Resolve(path, PathWrite)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		if f, ok := files[resolved]; ok {
+			return f, nil
+		}
+		f := &patchFile{path: path, resolved: resolved, mode: 0644}
+		data, err := os.ReadFile(resolved)
+		switch {
+		case err == nil:
+			f.exists = true
+			f.original = string(data)
+			f.content = f.original
+			if info, err := os.Stat(resolved); err == nil {
+				f.mode = info.Mode().Perm()
+			}
+		case !os.IsNotExist(err):
+			return nil, fmt.Errorf("%s: failed to read file: %v", path, err)
+		}
+		files[resolved] = f
+		order = append(order, resolved)
+		return f, nil
+	}
+
+	failed := false
+	if strings.TrimSpace(patchText) != "" {
+		filePatches, err := parseUnifiedDiff(patchText)
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("failed to parse patch: %v", err))
+		}
+		for _, fp := range filePatches {
+			f, err := load(fp.path())
+			if err != nil {
+				return ErrorResult(err.Error())
+			}
+			if !applyFilePatch(f, fp) {
+				failed = true
+			}
+		}
+	}
+	for i, raw := range edits {
+		edit, ok := raw.(map[string]interface{})
+		if !ok {
+			return ErrorResult(fmt.Sprintf("edit %d: expected an object", i+1))
+		}
+		path, _ := edit["path"].(string)
+		oldText, okOld := edit["old_text"].(string)
+		newText, okNew := edit["new_text"].(string)
+		if path == "" || !okOld || !okNew {
+			return ErrorResult(fmt.Sprintf("edit %d: path, old_text and new_text are required", i+1))
+		}
+		replaceAll, _ := edit["replace_all"].(bool)
+		f, err := load(path)
+		if err != nil {
+			return ErrorResult(err.Error())
+		}
+		status, ok := applyEdit(f, oldText, newText, replaceAll)
+		f.status = append(f.status, fmt.Sprintf("edit %d: %s", i+1, status))
+		if !ok {
+			failed = true
+		}
+	}
+
+	var report strings.Builder
+	var diff strings.Builder
+	changed := 0
+	for _, resolved := range order {
+		f := files[resolved]
+		fmt.Fprintf(&report, "%s:\n", f.path)
+		for _, s := range f.status {
+			fmt.Fprintf(&report, "  %s\n", s)
+		}
+		name := filepath.ToSlash(f.path)
+		oldName, newName := "a/"+name, "b/"+name
+		if filepath.IsAbs(f.path) {
+			oldName, newName = name, name
+		}
+		if !f.exists {
+			oldName = "/dev/null"
+		}
+		if f.delete {
+			newName = "/dev/null"
+		}
+		newContent := f.content
+		if f.delete {
+			newContent = ""
+		}
+		if d := unifiedDiff(oldName, newName, f.original, newContent); d != "" || f.delete || !f.exists {
+			changed++
+			diff.WriteString(d)
+		}
+	}
+
+	if failed {
+		return ErrorResult("Patch not applied; no files were changed.\n\n" + report.String())
+	}
+
+	preview := diff.String()
+	if len(preview) > maxPatchPreviewSize {
+		preview = preview[:maxPatchPreviewSize] + fmt.Sprintf("\n... (diff truncated, %d more chars)", len(preview)-maxPatchPreviewSize)
+	}
+	summary := fmt.Sprintf("Applied changes to %d file(s).", changed)
+	if dryRun {
+		summary = fmt.Sprintf("Dry run: the changes apply cleanly to %d file(s); nothing was written.", changed)
+	} else if err := writePatchedFiles(files, order); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to write changes: %v", err))
+	}
+	if preview == "" {
+		preview = "(no changes)"
+	}
+	return SilentResult(summary + "\n\n" + report.String() + "\n```diff\n" + preview + "```")
+}
+
+// writePatchedFiles writes every changed file through a temporary file and a
+// rename, restoring the originals if a later file cannot be written.
+func writePatchedFiles(files map[string]*patchFile, order []string) error {
+	type staged struct {
+		f   *patchFile
+		tmp string
+	}
+	var pending []staged
+	cleanup := func() {
+		for _, s := range pending {
+			os.Remove(s.tmp)
+		}
+	}
+
+	for _, resolved := range order {
+		f := files[resolved]
+		if f.delete || (f.exists && f.content == f.original) {
+			continue
+		}
+		dir := filepath.Dir(resolved)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			cleanup()
+			return fmt.Errorf("%s: %v", f.path, err)
+		}
+		tmp, err := os.CreateTemp(dir, "."+filepath.Base(resolved)+".patch-*")
+		if err != nil {
+			cleanup()
+			return fmt.Errorf("%s: %v", f.path, err)
+		}
+		_, err = tmp.WriteString(f.content)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Chmod(tmp.Name(), f.mode)
+		}
+		pending = append(pending, staged{f, tmp.Name()})
+		if err != nil {
+			cleanup()
+			return fmt.Errorf("%s: %v", f.path, err)
+		}
+	}
+
+	var done []*patchFile
+	for i, s := range pending {
+		if err := os.Rename(s.tmp, s.f.resolved); err != nil {
+			for _, r := range pending[i:] {
+				os.Remove(r.tmp)
+			}
+			return restorePatchedFiles(done, fmt.Errorf("%s: %v", s.f.path, err))
+		}
+		done = append(done, s.f)
+	}
+	for _, resolved := range order {
+		if f := files[resolved]; f.delete {
+			if err := os.Remove(resolved); err != nil {
+				return restorePatchedFiles(done, fmt.Errorf("%s: %v", f.path, err))
+			}
+			done = append(done, f)
+		}
+	}
+	return nil
+}
+
+// restorePatchedFiles puts files already written back as they were after
+// cause stopped the commit, and returns cause together with any file that
+// could not be restored.
+func restorePatchedFiles(files []*patchFile, cause error) error {
+	var failed []string
+	for _, f := range files {
+		var err error
+		if f.exists {
+			err = os.WriteFile(f.resolved, []byte(f.original), f.mode)
+			if err == nil {
+				err = os.Chmod(f.resolved, f.mode)
+			}
+		} else {
+			err = os.Remove(f.resolved)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", f.path, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%v; failed to restore %s", cause, strings.Join(failed, "; "))
+	}
+	return cause
+}
+
+// applyEdit replaces oldText with newText in f. Without an exact match it
+// retries line by line, ignoring leading and trailing whitespace.
+func applyEdit(f *patchFile, oldText, newText string, replaceAll bool) (string, bool) {
+	if f.delete {
+		return "FAILED: file is deleted by this patch", false
+	}
+	if oldText == "" {
+		if f.exists || f.content != "" {
+			return "FAILED: old_text is empty but the file already exists", false
+		}
+		f.content = newText
+		return "created file", true
+	}
+	if !f.exists && f.content == "" {
+		return "FAILED: file not found", false
+	}
+
+	if count := strings.Count(f.content, oldText); count > 0 {
+		if count > 1 && !replaceAll {
+			return fmt.Sprintf("FAILED: old_text appears %d times; add context or set replace_all", count), false
+		}
+		line := strings.Count(f.content[:strings.Index(f.content, oldText)], "\n") + 1
+		f.content = strings.ReplaceAll(f.content, oldText, newText)
+		if count > 1 {
+			return fmt.Sprintf("replaced %d occurrences", count), true
+		}
+		return fmt.Sprintf("applied at line %d", line), true
+	}
+
+	lines := splitLines(f.content)
+	want := splitLines(oldText)
+	var matches []int
+	for pos := 0; pos+len(want) <= len(lines); pos++ {
+		if linesMatch(lines[pos:pos+len(want)], want, matchTrimmed) {
+			matches = append(matches, pos)
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		return "FAILED: old_text not found, even ignoring whitespace", false
+	case len(matches) > 1 && !replaceAll:
+		return fmt.Sprintf("FAILED: old_text matches %d places when ignoring whitespace; add context", len(matches)), false
+	}
+	replacement := splitLines(newText)
+	for i := len(matches) - 1; i >= 0; i-- {
+		pos := matches[i]
+		lines = append(lines[:pos], append(append([]string(nil), replacement...), lines[pos+len(want):]...)...)
+	}
+	f.content = joinLines(lines, strings.HasSuffix(f.content, "\n"))
+	return fmt.Sprintf("applied at line %d (ignoring whitespace)", matches[0]+1), true
+}
+
+// filePatch is the part of a unified diff that applies to one file.
+type filePatch struct {
+	oldPath string
+	newPath string
+	hunks   []patchHunk
+}
+
+func (fp filePatch) path() string {
+	if fp.newPath == "/dev/null" {
+		return fp.oldPath
+	}
+	return fp.newPath
+}
+
+type patchHunk struct {
+	oldStart int // 1-based, 0 if unknown
+	lines    []hunkLine
+	// noNewline is set when the new side ends without a newline.
+	noNewline bool
+}
+
+type hunkLine struct {
+	kind  byte // ' ', '-' or '+'
+	text  string
+	blank bool // an empty line in the patch, read as empty context
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+// parseUnifiedDiff splits a unified diff into per-file hunks. It accepts the
+// output of diff -u and git diff, and hunk headers without line numbers.
+func parseUnifiedDiff(text string) ([]filePatch, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var patches []filePatch
+	var cur *filePatch
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			patches = append(patches, filePatch{
+				oldPath: diffPath(line[4:]),
+				newPath: diffPath(lines[i+1][4:]),
+			})
+			cur = &patches[len(patches)-1]
+			i++
+		case strings.HasPrefix(line, "@@"):
+			if cur == nil {
+				return nil, fmt.Errorf("hunk before file header (--- / +++ lines)")
+			}
+			h := patchHunk{}
+			if m := hunkHeaderRe.FindStringSubmatch(line); m != nil {
+				h.oldStart, _ = strconv.Atoi(m[1])
+			}
+			// Line counts in the header are ignored: hand-written patches
+			// often get them wrong. The hunk ends at the next header.
+			for i+1 < len(lines) && !isPatchBoundary(lines, i+1) {
+				i++
+				next := lines[i]
+				if next == "" {
+					h.lines = append(h.lines, hunkLine{kind: ' ', blank: true})
+					continue
+				}
+				switch next[0] {
+				case ' ', '-', '+':
+					h.lines = append(h.lines, hunkLine{kind: next[0], text: next[1:]})
+				case '\\':
+					// "\ No newline at end of file" after a line of the new side.
+					h.noNewline = len(h.lines) > 0 && h.lines[len(h.lines)-1].kind != '-'
+				default:
+					return nil, fmt.Errorf("%s: unexpected line in hunk: %q", cur.path(), next)
+				}
+			}
+			// Blank lines before the next header are separators, not context.
+			for len(h.lines) > 0 && h.lines[len(h.lines)-1].blank {
+				h.lines = h.lines[:len(h.lines)-1]
+			}
+			cur.hunks = append(cur.hunks, h)
+		}
+		// Anything else, such as "diff --git" or "index" lines, is ignored.
+	}
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("no file headers (--- / +++ lines) found")
+	}
+	for _, p := range patches {
+		if p.oldPath == "/dev/null" && p.newPath == "/dev/null" {
+			return nil, fmt.Errorf("file header with /dev/null on both sides")
+		}
+	}
+	return patches, nil
+}
+
+// isPatchBoundary reports whether lines[i] starts a new hunk or file.
+func isPatchBoundary(lines []string, i int) bool {
+	line := lines[i]
+	return strings.HasPrefix(line, "@@") || strings.HasPrefix(line, "diff ") ||
+		(strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "))
+}
+
+// diffPath extracts the file name from a ---/+++ line, dropping timestamps
+// and git's a/ and b/ prefixes.
+func diffPath(s string) string {
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSpace(s)
+	if s == "/dev/null" {
+		return s
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		s = s[2:]
+	}
+	return s
+}
+
+// applyFilePatch applies the hunks of fp to f and records a status line per
+// hunk. It reports whether all hunks applied.
+func applyFilePatch(f *patchFile, fp filePatch) bool {
+	switch {
+	case fp.oldPath == "/dev/null":
+		if f.exists || f.content != "" {
+			f.status = append(f.status, "FAILED: patch creates the file but it already exists")
+			return false
+		}
+	case !f.exists && f.content == "":
+		f.status = append(f.status, "FAILED: file not found")
+		return false
+	}
+
+	lines := splitLines(f.content)
+	trailingNewline := f.content == "" || strings.HasSuffix(f.content, "\n")
+	ok := true
+	delta, minPos := 0, 0
+	for n, h := range fp.hunks {
+		res := applyHunk(lines, h, delta, minPos)
+		f.status = append(f.status, fmt.Sprintf("hunk %d: %s", n+1, res.status))
+		if !res.ok {
+			ok = false
+			continue
+		}
+		lines, delta, minPos = res.lines, res.delta, res.next
+		if res.atEOF {
+			trailingNewline = !h.noNewline
+		}
+	}
+	if !ok {
+		return false
+	}
+
+	if fp.newPath == "/dev/null" {
+		if len(lines) > 0 {
+			f.status = append(f.status, "FAILED: file is not empty after removing the patch's lines")
+			return false
+		}
+		f.delete = true
+		f.status = append(f.status, "deleted file")
+		return true
+	}
+	f.content = joinLines(lines, trailingNewline)
+	if fp.oldPath == "/dev/null" {
+		f.status = append(f.status, "created file")
+	}
+	return true
+}
+
+type matchMode int
+
+const (
+	matchExact matchMode = iota
+	matchTrailingSpace
+	matchTrimmed
+)
+
+var matchModeNotes = map[matchMode]string{
+	matchTrailingSpace: "ignoring trailing whitespace",
+	matchTrimmed:       "ignoring whitespace",
+}
+
+type hunkResult struct {
+	lines  []string
+	delta  int // line count change so far, to adjust later hunks' positions
+	next   int // first line later hunks may touch
+	status string
+	ok     bool
+	atEOF  bool // the hunk ends at the end of the file
+}
+
+// applyHunk finds the hunk's old lines near their expected position, trying
+// looser whitespace matching and then dropping up to maxPatchFuzz outer
+// context lines. Context lines keep the file's text.
+func applyHunk(lines []string, h patchHunk, delta, minPos int) hunkResult {
+	expected := minPos
+	if h.oldStart > 0 {
+		expected = h.oldStart - 1 + delta
+	}
+
+	lead, trail := 0, 0
+	for lead < len(h.lines) && h.lines[lead].kind == ' ' {
+		lead++
+	}
+	for trail < len(h.lines)-lead && h.lines[len(h.lines)-1-trail].kind == ' ' {
+		trail++
+	}
+
+	for fuzz := 0; fuzz <= maxPatchFuzz; fuzz++ {
+		if fuzz > 0 && fuzz > lead && fuzz > trail {
+			break
+		}
+		body := h.lines[min(fuzz, lead) : len(h.lines)-min(fuzz, trail)]
+		var old []string
+		for _, l := range body {
+			if l.kind != '+' {
+				old = append(old, l.text)
+			}
+		}
+		for mode := matchExact; mode <= matchTrimmed; mode++ {
+			pos, found := findLines(lines, old, expected+min(fuzz, lead), minPos, mode)
+			if !found {
+				continue
+			}
+
+			var replacement []string
+			cursor := pos
+			for _, l := range body {
+				switch l.kind {
+				case ' ':
+					replacement = append(replacement, lines[cursor])
+					cursor++
+				case '-':
+					cursor++
+				case '+':
+					replacement = append(replacement, l.text)
+				}
+			}
+			result := make([]string, 0, len(lines)-len(old)+len(replacement))
+			result = append(result, lines[:pos]...)
+			result = append(result, replacement...)
+			result = append(result, lines[pos+len(old):]...)
+
+			var notes []string
+			if offset := pos - (expected + min(fuzz, lead)); h.oldStart > 0 && offset != 0 {
+				notes = append(notes, fmt.Sprintf("offset %+d lines", offset))
+			}
+			if note, ok := matchModeNotes[mode]; ok {
+				notes = append(notes, note)
+			}
+			if fuzz > 0 {
+				notes = append(notes, fmt.Sprintf("fuzz %d", fuzz))
+			}
+			status := fmt.Sprintf("applied at line %d", pos+1)
+			if len(notes) > 0 {
+				status += " (" + strings.Join(notes, ", ") + ")"
+			}
+			return hunkResult{
+				lines:  result,
+				delta:  delta + len(replacement) - len(old),
+				next:   pos + len(replacement),
+				status: status,
+				ok:     true,
+				atEOF:  pos+len(old) == len(lines),
+			}
+		}
+	}
+
+	for _, l := range h.lines {
+		if l.kind != '+' && strings.TrimSpace(l.text) != "" {
+			return hunkResult{status: fmt.Sprintf("FAILED: could not find the hunk's lines (first: %q)", l.text)}
+		}
+	}
+	return hunkResult{status: "FAILED: could not place the hunk"}
+}
+
+// findLines returns the position at or after minPos where want occurs in
+// lines, preferring the one closest to expected.
+func findLines(lines, want []string, expected, minPos int, mode matchMode) (int, bool) {
+	last := len(lines) - len(want)
+	if last < minPos {
+		return 0, false
+	}
+	if expected < minPos {
+		expected = minPos
+	}
+	if expected > last {
+		expected = last
+	}
+	for dist := 0; expected-dist >= minPos || expected+dist <= last; dist++ {
+		for _, pos := range []int{expected - dist, expected + dist} {
+			if pos >= minPos && pos <= last && linesMatch(lines[pos:pos+len(want)], want, mode) {
+				return pos, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func linesMatch(have, want []string, mode matchMode) bool {
+	for i := range want {
+		a, b := have[i], want[i]
+		switch mode {
+		case matchTrailingSpace:
+			a, b = strings.TrimRight(a, " \t\r"), strings.TrimRight(b, " \t\r")
+		case matchTrimmed:
+			a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+		}
+		if a != b {
+			return false
+		}
+	}
+	return true
+}
+
+func joinLines(lines []string, trailingNewline bool) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	s := strings.Join(lines, "\n")
+	if trailingNewline {
+		s += "\n"
+	}
+	return s
+}
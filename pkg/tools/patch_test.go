@@ -0,0 +1,313 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readTestFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+	return string(data)
+}
+
+const patchTestMain = `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("hello")
+	fmt.Println("world")
+}
+`
+
+func TestApplyPatch_UnifiedDiffMultipleFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"main.go":   patchTestMain,
+		"README.md": "# Demo\n\nOld text.\n",
+		"old.txt":   "remove me\n",
+	})
+	tool := NewApplyPatchTool(dir, true)
+
+	patch := `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -5,4 +5,4 @@ import "fmt"
+ func main() {
+-	fmt.Println("hello")
++	fmt.Println("hi")
+ 	fmt.Println("world")
+ }
+--- a/README.md
++++ b/README.md
+@@ -1,3 +1,3 @@
+ # Demo
+
+-Old text.
++New text.
+--- /dev/null
++++ b/docs/new.md
+@@ -0,0 +1,2 @@
++# New
++file
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-remove me
+`
+	result := tool.Execute(context.Background(), map[string]interface{}{"patch": patch})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if got := readTestFile(t, dir, "main.go"); !strings.Contains(got, `fmt.Println("hi")`) || strings.Contains(got, `"hello"`) {
+		t.Errorf("main.go not patched:\n%s", got)
+	}
+	if got := readTestFile(t, dir, "README.md"); got != "# Demo\n\nNew text.\n" {
+		t.Errorf("README.md not patched: %q", got)
+	}
+	if got := readTestFile(t, dir, "docs/new.md"); got != "# New\nfile\n" {
+		t.Errorf("docs/new.md not created: %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.txt")); !os.IsNotExist(err) {
+		t.Errorf("old.txt should be deleted")
+	}
+	for _, want := range []string{"Applied changes to 4 file(s)", "hunk 1: applied at line 5", "created file", "deleted file", `+	fmt.Println("hi")`} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("expected %q in result:\n%s", want, result.ForLLM)
+		}
+	}
+}
+
+func TestApplyPatch_FuzzyContext(t *testing.T) {
+	dir := t.TempDir()
+	// The file has drifted: two extra lines at the top and different
+	// indentation than the patch assumes.
+	writeTestFiles(t, dir, map[string]string{"main.go": "// Header\n// added later\n" + patchTestMain})
+	tool := NewApplyPatchTool(dir, true)
+
+	patch := `--- a/main.go
++++ b/main.go
+@@ -6,3 +6,3 @@
+ func main() {
+-    fmt.Println("hello")
++	fmt.Println("hi")
+     fmt.Println("world")
+`
+	result := tool.Execute(context.Background(), map[string]interface{}{"patch": patch})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "offset +1 lines") || !strings.Contains(result.ForLLM, "ignoring whitespace") {
+		t.Errorf("expected offset and whitespace notes in status:\n%s", result.ForLLM)
+	}
+	got := readTestFile(t, dir, "main.go")
+	if !strings.Contains(got, "\tfmt.Println(\"hi\")\n\tfmt.Println(\"world\")") {
+		t.Errorf("expected patched line and the file's own context lines:\n%s", got)
+	}
+}
+
+func TestApplyPatch_HunkWithoutLineNumbers(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"a.txt": "one\ntwo\nthree\nfour\n"})
+	tool := NewApplyPatchTool(dir, true)
+
+	patch := "--- a/a.txt\n+++ b/a.txt\n@@ @@\n two\n-three\n+THREE\n\n@@ @@\n-four\n+FOUR\n"
+	result := tool.Execute(context.Background(), map[string]interface{}{"patch": patch})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if got := readTestFile(t, dir, "a.txt"); got != "one\ntwo\nTHREE\nFOUR\n" {
+		t.Errorf("unexpected content: %q", got)
+	}
+}
+
+func TestApplyPatch_AtomicOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"a.txt": "alpha\n", "b.txt": "beta\n"})
+	tool := NewApplyPatchTool(dir, true)
+
+	patch := "--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-alpha\n+ALPHA\n--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-gamma\n+GAMMA\n"
+	result := tool.Execute(context.Background(), map[string]interface{}{"patch": patch})
+	if !result.IsError {
+		t.Fatalf("expected failure, got: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "hunk 1: applied") || !strings.Contains(result.ForLLM, `FAILED: could not find the hunk's lines (first: "gamma")`) {
+		t.Errorf("expected per-hunk status, got:\n%s", result.ForLLM)
+	}
+	if got := readTestFile(t, dir, "a.txt"); got != "alpha\n" {
+		t.Errorf("a.txt must not change when another hunk fails, got %q", got)
+	}
+}
+
+func TestRestorePatchedFiles(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "run.sh")
+	files := []*patchFile{
+		// Deleted by the patch before the commit failed.
+		{path: "run.sh", resolved: script, original: "#!/bin/sh\necho hi\n", mode: 0750, exists: true},
+		{path: "missing/new.txt", resolved: filepath.Join(dir, "missing", "new.txt")},
+	}
+
+	err := restorePatchedFiles(files, fmt.Errorf("b.txt: disk full"))
+	if err == nil || !strings.Contains(err.Error(), "b.txt: disk full") || !strings.Contains(err.Error(), "failed to restore missing/new.txt") {
+		t.Errorf("expected the cause and the failed restore, got %v", err)
+	}
+	if got := readTestFile(t, dir, "run.sh"); got != "#!/bin/sh\necho hi\n" {
+		t.Errorf("run.sh not restored, got %q", got)
+	}
+	if info, err := os.Stat(script); err != nil || info.Mode().Perm() != 0750 {
+		t.Errorf("run.sh mode not restored: %v", err)
+	}
+}
+
+func TestApplyPatch_Edits(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"config.yaml": "name: demo\nport: 80\nhost: localhost\n",
+		"app.py":      "def f():\n    x = 1\n    return x\n",
+	})
+	tool := NewApplyPatchTool(dir, true)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"edits": []interface{}{
+			map[string]interface{}{"path": "config.yaml", "old_text": "port: 80", "new_text": "port: 8080"},
+			map[string]interface{}{"path": "config.yaml", "old_text": "host: localhost\n", "new_text": "host: 0.0.0.0\ndebug: true\n"},
+			// Indentation differs from the file.
+			map[string]interface{}{"path": "app.py", "old_text": "x = 1\nreturn x", "new_text": "    x = 2\n    return x"},
+			map[string]interface{}{"path": "new/notes.txt", "old_text": "", "new_text": "created\n"},
+		},
+	})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if got := readTestFile(t, dir, "config.yaml"); got != "name: demo\nport: 8080\nhost: 0.0.0.0\ndebug: true\n" {
+		t.Errorf("unexpected config.yaml: %q", got)
+	}
+	if got := readTestFile(t, dir, "app.py"); got != "def f():\n    x = 2\n    return x\n" {
+		t.Errorf("unexpected app.py: %q", got)
+	}
+	if got := readTestFile(t, dir, "new/notes.txt"); got != "created\n" {
+		t.Errorf("unexpected new/notes.txt: %q", got)
+	}
+	if !strings.Contains(result.ForLLM, "edit 3: applied at line 2 (ignoring whitespace)") {
+		t.Errorf("expected whitespace-insensitive match status:\n%s", result.ForLLM)
+	}
+}
+
+func TestApplyPatch_EditAmbiguous(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"a.txt": "x\nx\n"})
+	tool := NewApplyPatchTool(dir, true)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"edits": []interface{}{map[string]interface{}{"path": "a.txt", "old_text": "x", "new_text": "y"}},
+	})
+	if !result.IsError || !strings.Contains(result.ForLLM, "appears 2 times") {
+		t.Errorf("expected ambiguity error, got: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{
+		"edits": []interface{}{map[string]interface{}{"path": "a.txt", "old_text": "x", "new_text": "y", "replace_all": true}},
+	})
+	if result.IsError || readTestFile(t, dir, "a.txt") != "y\ny\n" {
+		t.Errorf("expected replace_all to replace both, got: %s", result.ForLLM)
+	}
+}
+
+func TestApplyPatch_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"a.txt": "old\n"})
+	tool := NewApplyPatchTool(dir, true)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"patch":   "--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-old\n+new\n",
+		"dry_run": true,
+	})
+	if result.IsError || !strings.Contains(result.ForLLM, "Dry run") || !strings.Contains(result.ForLLM, "-old\n+new") {
+		t.Errorf("unexpected dry run result: %s", result.ForLLM)
+	}
+	if got := readTestFile(t, dir, "a.txt"); got != "old\n" {
+		t.Errorf("dry run must not write, got %q", got)
+	}
+}
+
+func TestApplyPatch_OutsideWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	tool := NewApplyPatchTool(dir, true)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"patch": "--- /dev/null\n+++ b/../escape.txt\n@@ -0,0 +1 @@\n+x\n",
+	})
+	if !result.IsError {
+		t.Errorf("expected path outside the workspace to be refused")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape.txt")); err == nil {
+		t.Errorf("file was written outside the workspace")
+	}
+}
+
+func TestApplyPatch_NoNewlineAtEOF(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"a.txt": "one\ntwo"})
+	tool := NewApplyPatchTool(dir, true)
+
+	patch := "--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n one\n-two\n\\ No newline at end of file\n+TWO\n"
+	if result := tool.Execute(context.Background(), map[string]interface{}{"patch": patch}); result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if got := readTestFile(t, dir, "a.txt"); got != "one\nTWO\n" {
+		t.Errorf("expected the newline to be added, got %q", got)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	newText := "a\nB\nc\nd\ne\nf\ng\nh\ni\nJ\nk\nl\n"
+	got := unifiedDiff("a/x", "b/x", oldText, newText)
+	want := `--- a/x
++++ b/x
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -7,5 +7,6 @@
+ g
+ h
+ i
+-j
++J
+ k
++l
+`
+	if got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+	if d := unifiedDiff("a", "b", "same\n", "same\n"); d != "" {
+		t.Errorf("expected no diff for equal texts, got %q", d)
+	}
+	if d := unifiedDiff("/dev/null", "b/x", "", "new\n"); !strings.Contains(d, "@@ -0,0 +1 @@\n+new") {
+		t.Errorf("unexpected diff for a new file: %q", d)
+	}
+}
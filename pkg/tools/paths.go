@@ -0,0 +1,188 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxSymlinkDepth bounds symlink resolution, like the kernel's ELOOP limit.
+const maxSymlinkDepth = 40
+
+// PathAccess is the kind of access a tool needs to a path.
+type PathAccess int
+
+const (
+	PathRead PathAccess = iota
+	PathWrite
+)
+
+// PathRoot is a directory tools may use besides the workspace when they are
+// restricted to it.
+type PathRoot struct {
+	Path     string
+	ReadOnly bool
+}
+
+// PathPolicy resolves the paths tools are given. Relative paths are taken
+// relative to the workspace. When restricted, the fully resolved path (all
+// symlinks followed, including dangling ones) must lie inside the workspace
+// or one of the extra roots, compared component by component; read-only
+// roots refuse writes.
+//
+// A nil *PathPolicy, or one without a workspace, passes paths through.
+type PathPolicy struct {
+	workspace string
+	restrict  bool
+	roots     []PathRoot
+}
+
+func NewPathPolicy(workspace string, restrict bool, roots ...PathRoot) *PathPolicy {
+	return &PathPolicy{workspace: workspace, restrict: restrict, roots: roots}
+}
+
+// Workspace returns the absolute workspace path with symlinks resolved.
+func (p *PathPolicy) Workspace() string {
+	if p == nil || p.workspace == "" {
+		return ""
+	}
+	ws, err := resolveAbsPath(p.workspace)
+	if err != nil {
+		return filepath.Clean(p.workspace)
+	}
+	return ws
+}
+
+// Resolve returns the absolute, symlink-free path for path, or an error if
+// the policy does not allow the requested access to it.
+func (p *PathPolicy) Resolve(path string, access PathAccess) (string, error) {
+	if p == nil || p.workspace == "" {
+		return path, nil
+	}
+	absWorkspace, err := filepath.Abs(p.workspace)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve workspace path: %w", err)
+	}
+	absPath := path
+	if !filepath.IsAbs(absPath) {
+		absPath = filepath.Join(absWorkspace, path)
+	}
+
+	resolved, err := resolveAbsPath(absPath)
+	if err != nil {
+		if p.restrict {
+			return "", fmt.Errorf("failed to resolve file path: %w", err)
+		}
+		return filepath.Clean(absPath), nil
+	}
+	if !p.restrict {
+		return resolved, nil
+	}
+
+	if ws, err := resolveAbsPath(absWorkspace); err == nil && pathWithin(resolved, ws) {
+		return resolved, nil
+	}
+	readOnly := false
+	for _, root := range p.roots {
+		rootPath, err := resolveAbsPath(root.Path)
+		if err != nil || !pathWithin(resolved, rootPath) {
+			continue
+		}
+		if !root.ReadOnly || access == PathRead {
+			return resolved, nil
+		}
+		readOnly = true
+	}
+	if readOnly {
+		return "", fmt.Errorf("access denied: %s is in a read-only directory", path)
+	}
+	return "", fmt.Errorf("access denied: path is outside the workspace")
+}
+
+// allowedDirs returns the resolved workspace and extra roots.
+func (p *PathPolicy) allowedDirs() []string {
+	if p == nil || p.workspace == "" {
+		return nil
+	}
+	dirs := []string{p.Workspace()}
+	for _, root := range p.roots {
+		if dir, err := resolveAbsPath(root.Path); err == nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// pathWithin reports whether path is dir or inside it. Both must be clean
+// absolute paths; comparing components means /ws-evil is not inside /ws.
+func pathWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// resolveAbsPath makes path absolute and follows every symlink in it,
+// including dangling ones, so the result names the file that opening or
+// creating path (with its parent directories) would reach.
+func resolveAbsPath(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	volume := filepath.VolumeName(path)
+	root := volume + string(filepath.Separator)
+	resolved := root
+	rest := splitPathComponents(path[len(volume):])
+	links := 0
+	for len(rest) > 0 {
+		name := rest[0]
+		rest = rest[1:]
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, name)
+		info, err := os.Lstat(next)
+		if os.IsNotExist(err) {
+			// Keep walking: a later ".." can lead back to existing
+			// directories and their symlinks once the missing part is
+			// created.
+			resolved = next
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		links++
+		if links > maxSymlinkDepth {
+			return "", fmt.Errorf("too many levels of symbolic links: %s", path)
+		}
+		target, err := os.Readlink(next)
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(target) {
+			targetVolume := filepath.VolumeName(target)
+			resolved = targetVolume + string(filepath.Separator)
+			target = target[len(targetVolume):]
+		}
+		rest = append(splitPathComponents(target), rest...)
+	}
+	return resolved, nil
+}
+
+func splitPathComponents(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool { return os.IsPathSeparator(uint8(r)) })
+}
@@ -0,0 +1,284 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pathTestLayout creates a workspace next to a sibling whose name shares its
+// prefix, an outside directory with a secret, and a read-only and a
+// read-write root, all below a fresh temp dir.
+func pathTestLayout(t *testing.T) (base, ws string) {
+	t.Helper()
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws = filepath.Join(base, "ws")
+	writeTestFiles(t, base, map[string]string{
+		"ws/inside.txt":       "inside\n",
+		"ws/sub/nested.txt":   "nested\n",
+		"ws-evil/secret.txt":  "evil\n",
+		"outside/secret.txt":  "secret\n",
+		"ro/docs.txt":         "docs\n",
+		"ro-evil/secret.txt":  "evil\n",
+		"rw/data.txt":         "data\n",
+		"rw/inner/keep.txt":   "keep\n",
+		"outside/sub/deep.md": "deep\n",
+	})
+	return base, ws
+}
+
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+}
+
+func TestPathPolicy_EscapeAttempts(t *testing.T) {
+	base, ws := pathTestLayout(t)
+	outside := filepath.Join(base, "outside")
+
+	symlink(t, outside, filepath.Join(ws, "link-dir"))
+	symlink(t, filepath.Join(outside, "secret.txt"), filepath.Join(ws, "link-file"))
+	symlink(t, "../outside/secret.txt", filepath.Join(ws, "link-relative"))
+	symlink(t, "../../outside", filepath.Join(ws, "sub", "link-up"))
+	symlink(t, filepath.Join(outside, "new.txt"), filepath.Join(ws, "dangling"))
+	symlink(t, "link-dir", filepath.Join(ws, "chain1"))
+	symlink(t, "chain1", filepath.Join(ws, "chain2"))
+	symlink(t, filepath.Join(ws, "sub", "nested.txt"), filepath.Join(ws, "link-inside"))
+	symlink(t, "/", filepath.Join(ws, "root"))
+	symlink(t, "loop-b", filepath.Join(ws, "loop-a"))
+	symlink(t, "loop-a", filepath.Join(ws, "loop-b"))
+
+	policy := NewPathPolicy(ws, true)
+	denied := []string{
+		"../ws-evil/secret.txt",
+		filepath.Join(base, "ws-evil", "secret.txt"),
+		"..",
+		"../outside/secret.txt",
+		"sub/../../outside/secret.txt",
+		filepath.Join(outside, "secret.txt"),
+		"/etc/passwd",
+		"link-dir/secret.txt",
+		"link-dir",
+		"link-file",
+		"link-relative",
+		"sub/link-up/secret.txt",
+		"dangling",
+		"chain2/secret.txt",
+		"root/etc/passwd",
+		"missing/../link-dir/secret.txt",
+		"loop-a",
+	}
+	for _, path := range denied {
+		for _, access := range []PathAccess{PathRead, PathWrite} {
+			if resolved, err := policy.Resolve(path, access); err == nil {
+				t.Errorf("Resolve(%q, %d) = %q, expected access to be denied", path, access, resolved)
+			}
+		}
+	}
+
+	allowed := map[string]string{
+		"inside.txt":             filepath.Join(ws, "inside.txt"),
+		".":                      ws,
+		"":                       ws,
+		"sub/../inside.txt":      filepath.Join(ws, "inside.txt"),
+		filepath.Join(ws, "sub"): filepath.Join(ws, "sub"),
+		"new/dir/file.txt":       filepath.Join(ws, "new", "dir", "file.txt"),
+		"link-inside":            filepath.Join(ws, "sub", "nested.txt"),
+	}
+	for path, want := range allowed {
+		got, err := policy.Resolve(path, PathWrite)
+		if err != nil {
+			t.Errorf("Resolve(%q) failed: %v", path, err)
+		} else if got != want {
+			t.Errorf("Resolve(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestPathPolicy_SymlinkedWorkspace(t *testing.T) {
+	base, ws := pathTestLayout(t)
+	alias := filepath.Join(base, "alias")
+	symlink(t, ws, alias)
+
+	policy := NewPathPolicy(alias, true)
+	if got, err := policy.Resolve("inside.txt", PathRead); err != nil || got != filepath.Join(ws, "inside.txt") {
+		t.Errorf("expected paths in a symlinked workspace to resolve, got %q, %v", got, err)
+	}
+	if got, err := policy.Resolve(filepath.Join(alias, "sub", "nested.txt"), PathRead); err != nil || got != filepath.Join(ws, "sub", "nested.txt") {
+		t.Errorf("expected absolute path through the workspace symlink to resolve, got %q, %v", got, err)
+	}
+	if _, err := policy.Resolve("../outside/secret.txt", PathRead); err == nil {
+		t.Errorf("expected escape from a symlinked workspace to be denied")
+	}
+	if policy.Workspace() != ws {
+		t.Errorf("Workspace() = %q, want %q", policy.Workspace(), ws)
+	}
+}
+
+func TestPathPolicy_ExtraRoots(t *testing.T) {
+	base, ws := pathTestLayout(t)
+	ro, rw := filepath.Join(base, "ro"), filepath.Join(base, "rw")
+	symlink(t, filepath.Join(base, "outside"), filepath.Join(rw, "escape"))
+	symlink(t, filepath.Join(ro, "docs.txt"), filepath.Join(ws, "docs-link"))
+
+	policy := NewPathPolicy(ws, true, PathRoot{Path: ro, ReadOnly: true}, PathRoot{Path: rw})
+
+	if _, err := policy.Resolve(filepath.Join(ro, "docs.txt"), PathRead); err != nil {
+		t.Errorf("expected read access to the read-only root: %v", err)
+	}
+	if _, err := policy.Resolve(filepath.Join(ro, "docs.txt"), PathWrite); err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Errorf("expected write to the read-only root to be denied, got %v", err)
+	}
+	if _, err := policy.Resolve("docs-link", PathWrite); err == nil {
+		t.Errorf("expected write through a workspace symlink into the read-only root to be denied")
+	}
+	if _, err := policy.Resolve("docs-link", PathRead); err != nil {
+		t.Errorf("expected read through a workspace symlink into the read-only root: %v", err)
+	}
+	if _, err := policy.Resolve(filepath.Join(rw, "inner", "new.txt"), PathWrite); err != nil {
+		t.Errorf("expected write access to the read-write root: %v", err)
+	}
+	for _, path := range []string{
+		filepath.Join(base, "ro-evil", "secret.txt"),
+		filepath.Join(rw, "..", "outside", "secret.txt"),
+		filepath.Join(rw, "escape", "secret.txt"),
+		base,
+	} {
+		if _, err := policy.Resolve(path, PathRead); err == nil {
+			t.Errorf("expected %q to be denied", path)
+		}
+	}
+
+	// A root that is itself a symlink is compared by its target.
+	rootLink := filepath.Join(base, "rw-link")
+	symlink(t, rw, rootLink)
+	linked := NewPathPolicy(ws, true, PathRoot{Path: rootLink})
+	if _, err := linked.Resolve(filepath.Join(rw, "data.txt"), PathWrite); err != nil {
+		t.Errorf("expected access through a symlinked root: %v", err)
+	}
+}
+
+func TestPathPolicy_Unrestricted(t *testing.T) {
+	base, ws := pathTestLayout(t)
+	policy := NewPathPolicy(ws, false)
+	if got, err := policy.Resolve("../outside/secret.txt", PathWrite); err != nil || got != filepath.Join(base, "outside", "secret.txt") {
+		t.Errorf("expected unrestricted access, got %q, %v", got, err)
+	}
+
+	var nilPolicy *PathPolicy
+	if got, err := nilPolicy.Resolve("any/path", PathWrite); err != nil || got != "any/path" {
+		t.Errorf("expected nil policy to pass paths through, got %q, %v", got, err)
+	}
+	if got, err := NewPathPolicy("", true).Resolve("/etc/passwd", PathRead); err != nil || got != "/etc/passwd" {
+		t.Errorf("expected policy without workspace to pass paths through, got %q, %v", got, err)
+	}
+}
+
+func TestPathWithin(t *testing.T) {
+	tests := []struct {
+		path, dir string
+		want      bool
+	}{
+		{"/home/u/ws", "/home/u/ws", true},
+		{"/home/u/ws/a/b", "/home/u/ws", true},
+		{"/home/u/ws-evil", "/home/u/ws", false},
+		{"/home/u/wsx/a", "/home/u/ws", false},
+		{"/home/u", "/home/u/ws", false},
+		{"/home/u/..ws", "/home/u", true},
+		{"/", "/home/u/ws", false},
+		{"/anything", "/", true},
+	}
+	for _, tt := range tests {
+		if got := pathWithin(tt.path, tt.dir); got != tt.want {
+			t.Errorf("pathWithin(%q, %q) = %v, want %v", tt.path, tt.dir, got, tt.want)
+		}
+	}
+}
+
+// TestFilesystemTools_SymlinkEscape verifies the tools refuse to follow
+// symlinks out of the workspace and write nothing outside.
+func TestFilesystemTools_SymlinkEscape(t *testing.T) {
+	base, ws := pathTestLayout(t)
+	outside := filepath.Join(base, "outside")
+	symlink(t, outside, filepath.Join(ws, "link-dir"))
+	symlink(t, filepath.Join(outside, "secret.txt"), filepath.Join(ws, "link-file"))
+	symlink(t, filepath.Join(outside, "planted.txt"), filepath.Join(ws, "dangling"))
+	ctx := context.Background()
+
+	calls := []struct {
+		tool Tool
+		args map[string]interface{}
+	}{
+		{NewReadFileTool(ws, true), map[string]interface{}{"path": "link-file"}},
+		{NewReadFileTool(ws, true), map[string]interface{}{"path": "link-dir/secret.txt", "start_line": float64(1)}},
+		{NewListDirTool(ws, true), map[string]interface{}{"path": "link-dir"}},
+		{NewWriteFileTool(ws, true), map[string]interface{}{"path": "dangling", "content": "x"}},
+		{NewWriteFileTool(ws, true), map[string]interface{}{"path": "link-dir/planted.txt", "content": "x"}},
+		{NewWriteFileTool(ws, true), map[string]interface{}{"path": "../ws-evil/planted.txt", "content": "x"}},
+		{NewEditFileTool(ws, true), map[string]interface{}{"path": "link-file", "old_text": "secret", "new_text": "owned"}},
+		{NewAppendFileTool(ws, true), map[string]interface{}{"path": "link-file", "content": "owned"}},
+		{NewApplyPatchTool(ws, true), map[string]interface{}{"patch": "--- /dev/null\n+++ b/dangling\n@@ -0,0 +1 @@\n+x\n"}},
+		{NewGrepTool(ws, true), map[string]interface{}{"pattern": "secret", "path": "link-dir"}},
+		{NewGlobTool(ws, true), map[string]interface{}{"pattern": "*", "path": "link-dir"}},
+		{NewMarkdownFileTool(ws, true, nil), map[string]interface{}{"path": "link-dir/planted", "content": "x", "send": false}},
+	}
+	for _, call := range calls {
+		result := call.tool.Execute(ctx, call.args)
+		if !result.IsError {
+			t.Errorf("%s(%v): expected access to be denied, got: %s", call.tool.Name(), call.args, result.ForLLM)
+		}
+	}
+
+	if got := readTestFile(t, outside, "secret.txt"); got != "secret\n" {
+		t.Errorf("file outside the workspace was modified: %q", got)
+	}
+	for _, name := range []string{"outside/planted.txt", "outside/planted.md", "ws-evil/planted.txt"} {
+		if _, err := os.Stat(filepath.Join(base, name)); err == nil {
+			t.Errorf("%s was written outside the workspace", name)
+		}
+	}
+
+	// Grep does not descend into symlinked directories either.
+	result := NewGrepTool(ws, true).Execute(ctx, map[string]interface{}{"pattern": "secret"})
+	if strings.Contains(result.ForLLM, "link-dir") {
+		t.Errorf("grep followed a symlink out of the workspace: %s", result.ForLLM)
+	}
+}
+
+func TestExecTool_PathPolicy(t *testing.T) {
+	base, ws := pathTestLayout(t)
+	ro := filepath.Join(base, "ro")
+	symlink(t, filepath.Join(base, "outside"), filepath.Join(ws, "link-dir"))
+	tool := NewExecTool(ws, true, PathRoot{Path: ro, ReadOnly: true})
+	ctx := context.Background()
+
+	blocked := []map[string]interface{}{
+		{"command": "cat " + filepath.Join(base, "outside", "secret.txt")},
+		{"command": "cat " + filepath.Join(base, "ws-evil", "secret.txt")},
+		{"command": "cat " + filepath.Join(ws, "link-dir", "secret.txt")},
+		{"command": "cat ../outside/secret.txt"},
+		{"command": "ls", "working_dir": filepath.Join(base, "outside")},
+		{"command": "ls", "working_dir": "link-dir"},
+	}
+	for _, args := range blocked {
+		if result := tool.Execute(ctx, args); !result.IsError {
+			t.Errorf("expected %v to be blocked, got: %s", args, result.ForLLM)
+		}
+	}
+
+	result := tool.Execute(ctx, map[string]interface{}{"command": "cat " + filepath.Join(ro, "docs.txt") + " " + filepath.Join(ws, "inside.txt")})
+	if result.IsError || !strings.Contains(result.ForLLM, "docs") || !strings.Contains(result.ForLLM, "inside") {
+		t.Errorf("expected paths in the workspace and extra roots to be allowed, got: %s", result.ForLLM)
+	}
+	result = tool.Execute(ctx, map[string]interface{}{"command": "cat nested.txt", "working_dir": "sub"})
+	if result.IsError || !strings.Contains(result.ForLLM, "nested") {
+		t.Errorf("expected relative working_dir inside the workspace, got: %s", result.ForLLM)
+	}
+}
@@ -0,0 +1,360 @@
+package tools
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+var (
+	pdfStreamRe  = regexp.MustCompile(`(?s)<<(.*?)>>\s*stream\r?\n`)
+	pdfBfcharRe  = regexp.MustCompile(`<([0-9A-Fa-f]+)>\s*<([0-9A-Fa-f]+)>`)
+	pdfBfrangeRe = regexp.MustCompile(`<([0-9A-Fa-f]+)>\s*<([0-9A-Fa-f]+)>\s*<([0-9A-Fa-f]+)>`)
+)
+
+// maxPDFStreamSize caps the decompressed size of a single PDF stream.
+const maxPDFStreamSize = 20 << 20
+
+// extractPDFText pulls the text out of a PDF's content streams. It handles
+// uncompressed and Flate-compressed streams, literal and hex strings and
+// ToUnicode maps; it is not a full PDF parser (no object streams, encryption
+// or font encodings), which covers most machine-generated papers and
+// reports.
+func extractPDFText(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("%PDF")) {
+		return "", fmt.Errorf("not a PDF file")
+	}
+
+	var contents [][]byte
+	cmap := map[string]string{}
+
+	for _, loc := range pdfStreamRe.FindAllSubmatchIndex(data, -1) {
+		dict := string(data[loc[2]:loc[3]])
+		start := loc[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		raw := data[start : start+end]
+
+		if strings.Contains(dict, "/Image") || strings.Contains(dict, "/XRef") {
+			continue
+		}
+		stream := raw
+		if strings.Contains(dict, "/FlateDecode") {
+			r, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				continue
+			}
+			stream, err = io.ReadAll(io.LimitReader(r, maxPDFStreamSize))
+			r.Close()
+			if err != nil && len(stream) == 0 {
+				continue
+			}
+		} else if strings.Contains(dict, "/Filter") {
+			continue // other filters (DCT, LZW, ...) are not text
+		}
+
+		if bytes.Contains(stream, []byte("begincmap")) {
+			parsePDFCMap(stream, cmap)
+			continue
+		}
+		if bytes.Contains(stream, []byte("BT")) {
+			contents = append(contents, stream)
+		}
+	}
+
+	var sb strings.Builder
+	for _, c := range contents {
+		sb.WriteString(pdfContentText(c, cmap))
+		sb.WriteString("\n\n")
+	}
+
+	text := strings.TrimSpace(collapseBlankLines(sb.String()))
+	if text == "" {
+		return "", fmt.Errorf("no extractable text (the PDF may be scanned or use unsupported encodings)")
+	}
+	return text, nil
+}
+
+// parsePDFCMap adds the bfchar and bfrange entries of a ToUnicode CMap to cmap,
+// keyed by the upper-case hex source code.
+func parsePDFCMap(stream []byte, cmap map[string]string) {
+	s := string(stream)
+	for _, section := range pdfSections(s, "beginbfrange", "endbfrange") {
+		for _, m := range pdfBfrangeRe.FindAllStringSubmatch(section, -1) {
+			lo, err1 := strconv.ParseUint(m[1], 16, 32)
+			hi, err2 := strconv.ParseUint(m[2], 16, 32)
+			dst, err3 := strconv.ParseUint(m[3], 16, 32)
+			if err1 != nil || err2 != nil || err3 != nil || hi < lo || hi-lo > 0xFFFF {
+				continue
+			}
+			width := len(m[1])
+			for c := lo; c <= hi; c++ {
+				cmap[fmt.Sprintf("%0*X", width, c)] = string(rune(dst + (c - lo)))
+			}
+		}
+	}
+	for _, section := range pdfSections(s, "beginbfchar", "endbfchar") {
+		for _, m := range pdfBfcharRe.FindAllStringSubmatch(section, -1) {
+			cmap[strings.ToUpper(m[1])] = decodeUTF16Hex(m[2])
+		}
+	}
+}
+
+func pdfSections(s, begin, end string) []string {
+	var out []string
+	for {
+		i := strings.Index(s, begin)
+		if i < 0 {
+			return out
+		}
+		s = s[i+len(begin):]
+		j := strings.Index(s, end)
+		if j < 0 {
+			return append(out, s)
+		}
+		out = append(out, s[:j])
+		s = s[j+len(end):]
+	}
+}
+
+func decodeUTF16Hex(h string) string {
+	b := make([]byte, len(h)/2)
+	for i := range b {
+		v, _ := strconv.ParseUint(h[2*i:2*i+2], 16, 8)
+		b[i] = byte(v)
+	}
+	return decodeUTF16BE(b)
+}
+
+func decodeUTF16BE(b []byte) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(u))
+}
+
+// pdfContentText interprets the text operators of one content stream.
+func pdfContentText(stream []byte, cmap map[string]string) string {
+	var sb strings.Builder
+	var operands []string // decoded string operands since the last operator
+	var lastNum float64
+	var arrayParts []string
+	inArray := false
+
+	flushLine := func() {
+		if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteString("\n")
+		}
+	}
+
+	for i := 0; i < len(stream); {
+		c := stream[i]
+		switch {
+		case c == '%':
+			for i < len(stream) && stream[i] != '\n' && stream[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			s, next := readPDFLiteral(stream, i)
+			i = next
+			if inArray {
+				arrayParts = append(arrayParts, s)
+			} else {
+				operands = append(operands, s)
+			}
+		case c == '<' && i+1 < len(stream) && stream[i+1] != '<':
+			end := bytes.IndexByte(stream[i:], '>')
+			if end < 0 {
+				return sb.String()
+			}
+			s := decodePDFHex(string(stream[i+1:i+end]), cmap)
+			i += end + 1
+			if inArray {
+				arrayParts = append(arrayParts, s)
+			} else {
+				operands = append(operands, s)
+			}
+		case c == '[':
+			inArray, arrayParts = true, nil
+			i++
+		case c == ']':
+			inArray = false
+			operands = append(operands, strings.Join(arrayParts, ""))
+			i++
+		case c == '-' || c == '.' || c == '+' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(stream) && (stream[j] == '.' || (stream[j] >= '0' && stream[j] <= '9')) {
+				j++
+			}
+			lastNum, _ = strconv.ParseFloat(string(stream[i:j]), 64)
+			// Large negative kerning inside TJ arrays separates words.
+			if inArray && lastNum < -200 {
+				arrayParts = append(arrayParts, " ")
+			}
+			i = j
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '\'' || c == '"' || c == '*':
+			j := i + 1
+			for j < len(stream) && ((stream[j] >= 'a' && stream[j] <= 'z') || (stream[j] >= 'A' && stream[j] <= 'Z') || stream[j] == '*') {
+				j++
+			}
+			op := string(stream[i:j])
+			i = j
+			if inArray {
+				continue
+			}
+			switch op {
+			case "Tj", "TJ":
+				for _, s := range operands {
+					sb.WriteString(s)
+				}
+			case "'", "\"":
+				flushLine()
+				for _, s := range operands {
+					sb.WriteString(s)
+				}
+			case "T*", "ET":
+				flushLine()
+			case "Td", "TD":
+				if lastNum != 0 {
+					flushLine()
+				}
+			case "Tm":
+				flushLine()
+			}
+			operands = nil
+		default:
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// readPDFLiteral reads a (...) string starting at stream[i] and returns its
+// decoded value and the index after the closing parenthesis.
+func readPDFLiteral(stream []byte, i int) (string, int) {
+	var b []byte
+	depth := 0
+	for i < len(stream) {
+		c := stream[i]
+		switch {
+		case c == '\\' && i+1 < len(stream):
+			i++
+			switch e := stream[i]; e {
+			case 'n':
+				b = append(b, '\n')
+			case 'r':
+				b = append(b, '\r')
+			case 't':
+				b = append(b, '\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					j := i
+					for j < len(stream) && j < i+3 && stream[j] >= '0' && stream[j] <= '7' {
+						j++
+					}
+					v, _ := strconv.ParseUint(string(stream[i:j]), 8, 8)
+					b = append(b, byte(v))
+					i = j - 1
+				} else {
+					b = append(b, e)
+				}
+			}
+		case c == '(':
+			if depth > 0 {
+				b = append(b, c)
+			}
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return decodePDFBytes(b), i + 1
+			}
+			b = append(b, c)
+		default:
+			b = append(b, c)
+		}
+		i++
+	}
+	return decodePDFBytes(b), i
+}
+
+func decodePDFHex(h string, cmap map[string]string) string {
+	h = strings.ToUpper(strings.Join(strings.Fields(h), ""))
+	if len(h)%2 == 1 {
+		h += "0"
+	}
+	if len(cmap) > 0 {
+		// Try 2-byte codes first (CID fonts), then single bytes.
+		for _, width := range []int{4, 2} {
+			if len(h)%width != 0 {
+				continue
+			}
+			var sb strings.Builder
+			ok := true
+			for i := 0; i < len(h); i += width {
+				r, found := cmap[h[i:i+width]]
+				if !found {
+					ok = false
+					break
+				}
+				sb.WriteString(r)
+			}
+			if ok {
+				return sb.String()
+			}
+		}
+	}
+	b := make([]byte, len(h)/2)
+	for i := range b {
+		v, _ := strconv.ParseUint(h[2*i:2*i+2], 16, 8)
+		b[i] = byte(v)
+	}
+	return decodePDFBytes(b)
+}
+
+// decodePDFBytes decodes a PDF text string: UTF-16BE with a byte order mark,
+// otherwise treated as Latin-1 with control characters dropped.
+func decodePDFBytes(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		return decodeUTF16BE(b[2:])
+	}
+	var sb strings.Builder
+	for _, c := range b {
+		if c == '\n' || c == '\t' || c >= 0x20 {
+			sb.WriteRune(rune(c))
+		}
+	}
+	return sb.String()
+}
+
+// collapseBlankLines trims trailing spaces and reduces runs of blank lines to
+// a single blank line.
+func collapseBlankLines(s string) string {
+	lines := strings.Split(s, "\n")
+	out := make([]string, 0, len(lines))
+	blank := 0
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			blank++
+			if blank > 1 {
+				continue
+			}
+		} else {
+			blank = 0
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,305 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/srikesh3005/summer/pkg/bus"
+	"github.com/srikesh3005/summer/pkg/chart"
+)
+
+// PlotTool renders line, bar, scatter and histogram charts to PNG or SVG in
+// the workspace and can send the image to the active chat.
+type PlotTool struct {
+	paths   *PathPolicy
+	msgBus  *bus.MessageBus
+	channel string
+	chatID  string
+}
+
+func NewPlotTool(workspace string, restrict bool, msgBus *bus.MessageBus, roots ...PathRoot) *PlotTool {
+	return &PlotTool{
+		paths:  NewPathPolicy(workspace, restrict, roots...),
+		msgBus: msgBus,
+	}
+}
+
+func (t *PlotTool) Name() string {
+	return "plot"
+}
+
+func (t *PlotTool) Description() string {
+	return "Draw a line, bar, scatter or histogram chart as a PNG (or SVG) image and send it to the current chat. " +
+		"Give the data inline as series of x/y numbers (with labels for bar categories), or plot columns of a CSV/TSV/XLSX file " +
+		"by passing path, an x column and one or more y columns. Use this whenever a picture explains numbers better than a table."
+}
+
+func (t *PlotTool) Parameters() map[string]interface{} {
+	numbers := map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "number"}}
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"type": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"line", "bar", "scatter", "histogram"},
+				"description": "Chart type",
+			},
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "Chart title",
+			},
+			"x_label": map[string]interface{}{
+				"type":        "string",
+				"description": "X axis label (default: the x column name)",
+			},
+			"y_label": map[string]interface{}{
+				"type":        "string",
+				"description": "Y axis label (default: the y column name when there is one)",
+			},
+			"series": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"name": map[string]interface{}{"type": "string", "description": "Legend name"},
+						"x":    numbers,
+						"y":    numbers,
+					},
+					"required": []string{"y"},
+				},
+				"description": "Inline data. y holds the values (for histograms, the values to bin); x is optional for line and scatter charts",
+			},
+			"labels": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Category labels for the x axis of bar charts (or line charts without x values)",
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "CSV, TSV or XLSX file to plot instead of inline series",
+			},
+			"sheet": map[string]interface{}{
+				"type":        "string",
+				"description": "For workbooks, the sheet to read (default: the first)",
+			},
+			"x": map[string]interface{}{
+				"type":        "string",
+				"description": "Column for the x axis; text columns become category labels",
+			},
+			"y": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Columns to plot, one series each",
+			},
+			"bins": map[string]interface{}{
+				"type":        "integer",
+				"description": "Number of histogram bins (default: chosen from the data)",
+			},
+			"width": map[string]interface{}{
+				"type":        "integer",
+				"description": "Image width in pixels (default: 800)",
+			},
+			"height": map[string]interface{}{
+				"type":        "integer",
+				"description": "Image height in pixels (default: 500)",
+			},
+			"output": map[string]interface{}{
+				"type":        "string",
+				"description": "Where to save the image; a .svg extension writes SVG (default: charts/<title>-<timestamp>.png)",
+			},
+			"send": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Whether to send the image to the current chat (default: true)",
+			},
+			"caption": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional caption when sending the image",
+			},
+		},
+		"required": []string{"type"},
+	}
+}
+
+func (t *PlotTool) SetContext(channel, chatID string) {
+	t.channel = channel
+	t.chatID = chatID
+}
+
+func (t *PlotTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	kind, _ := args["type"].(string)
+	c := &chart.Chart{Kind: chart.Kind(strings.ToLower(strings.TrimSpace(kind)))}
+	c.Title, _ = args["title"].(string)
+	c.XLabel, _ = args["x_label"].(string)
+	c.YLabel, _ = args["y_label"].(string)
+	c.Categories = stringListArg(args, "labels")
+	if v, ok := args["bins"].(float64); ok {
+		c.Bins = int(v)
+	}
+	if v, ok := args["width"].(float64); ok {
+		c.Width = int(v)
+	}
+	if v, ok := args["height"].(float64); ok {
+		c.Height = int(v)
+	}
+
+	if path, _ := args["path"].(string); path != "" {
+		if err := t.loadSeries(c, path, args); err != nil {
+			return ErrorResult(err.Error())
+		}
+	} else {
+		series, err := seriesArg(args)
+		if err != nil {
+			return ErrorResult(err.Error())
+		}
+		c.Series = series
+	}
+	if c.Kind == chart.Histogram && c.YLabel == "" {
+		c.YLabel = "Count"
+	}
+
+	output, _ := args["output"].(string)
+	if output == "" {
+		name := c.Title
+		if name == "" {
+			name = string(c.Kind)
+		}
+		output = filepath.Join("charts", fmt.Sprintf("%s-%d.png", reviewSlug(name), time.Now().Unix()))
+	}
+	ext := strings.ToLower(filepath.Ext(output))
+	if ext != ".png" && ext != ".svg" {
+		output += ".png"
+		ext = ".png"
+	}
+
+	var buf bytes.Buffer
+	render := c.PNG
+	if ext == ".svg" {
+		render = c.SVG
+	}
+	if err := render(&buf); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to draw chart: %v", err))
+	}
+
+	resolvedPath, err := t.paths.Resolve(output, PathWrite)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	if err := os.MkdirAll(filepath.Dir(resolvedPath), 0755); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to create directory: %v", err))
+	}
+	if err := os.WriteFile(resolvedPath, buf.Bytes(), 0644); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to write chart: %v", err))
+	}
+
+	send := true
+	if v, ok := args["send"].(bool); ok {
+		send = v
+	}
+	if !send {
+		return NewToolResult(fmt.Sprintf("Chart saved: %s", resolvedPath))
+	}
+	if t.msgBus == nil {
+		return ErrorResult("message bus is not configured for sending files")
+	}
+	if t.channel == "" || t.chatID == "" {
+		return ErrorResult("no active channel/chat context to send file")
+	}
+	caption, _ := args["caption"].(string)
+	if caption == "" {
+		caption = c.Title
+	}
+	t.msgBus.PublishOutbound(bus.OutboundMessage{
+		Channel:  t.channel,
+		ChatID:   t.chatID,
+		Content:  caption,
+		FilePath: resolvedPath,
+		FileName: filepath.Base(resolvedPath),
+	})
+	return SilentResult(fmt.Sprintf("Chart saved and sent to the chat: %s", resolvedPath))
+}
+
+// loadSeries fills c from the columns of a table file.
+func (t *PlotTool) loadSeries(c *chart.Chart, path string, args map[string]interface{}) error {
+	resolved, err := t.paths.Resolve(path, PathRead)
+	if err != nil {
+		return err
+	}
+	sheet, _ := args["sheet"].(string)
+	table, err := loadTable(resolved, sheet)
+	if err != nil {
+		return fmt.Errorf("failed to read table: %v", err)
+	}
+
+	columns := stringListArg(args, "y")
+	if len(columns) == 0 {
+		return fmt.Errorf("y is required with path; columns are %s", strings.Join(table.Columns, ", "))
+	}
+	var xs []float64
+	if name, _ := args["x"].(string); name != "" {
+		i, err := table.Column(name)
+		if err != nil {
+			return err
+		}
+		if c.XLabel == "" {
+			c.XLabel = table.Columns[i]
+		}
+		nums, n := table.Numbers(i)
+		if n == len(table.Rows) && c.Kind != chart.Bar {
+			xs = nums
+		} else {
+			c.Categories = nil
+			for _, row := range table.Rows {
+				c.Categories = append(c.Categories, strings.TrimSpace(row[i]))
+			}
+		}
+	}
+	for _, name := range columns {
+		i, err := table.Column(name)
+		if err != nil {
+			return err
+		}
+		ys, n := table.Numbers(i)
+		if n == 0 {
+			return fmt.Errorf("column %q has no numeric values", table.Columns[i])
+		}
+		c.Series = append(c.Series, chart.Series{Name: table.Columns[i], X: xs, Y: ys})
+	}
+	if len(columns) == 1 && c.YLabel == "" && c.Kind != chart.Histogram {
+		c.YLabel = c.Series[0].Name
+	}
+	if c.Kind == chart.Histogram && c.XLabel == "" && len(columns) == 1 {
+		c.XLabel = c.Series[0].Name
+	}
+	return nil
+}
+
+// seriesArg reads the inline series argument.
+func seriesArg(args map[string]interface{}) ([]chart.Series, error) {
+	items, _ := args["series"].([]interface{})
+	if len(items) == 0 {
+		return nil, fmt.Errorf("series or path is required")
+	}
+	var out []chart.Series
+	for i, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("series %d must be an object with x and y", i+1)
+		}
+		name, _ := m["name"].(string)
+		x, err := numberListArg(m, "x")
+		if err != nil {
+			return nil, err
+		}
+		y, err := numberListArg(m, "y")
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, chart.Series{Name: name, X: x, Y: y})
+	}
+	return out, nil
+}
@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"context"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/srikesh3005/summer/pkg/bus"
+)
+
+func TestPlotTool_SendPNG(t *testing.T) {
+	workspace := t.TempDir()
+	mb := bus.NewMessageBus()
+	tool := NewPlotTool(workspace, true, mb)
+	tool.SetContext("telegram", "12345")
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"type":   "bar",
+		"title":  "Papers by venue",
+		"labels": []interface{}{"NeurIPS", "ICLR", "ACL"},
+		"series": []interface{}{
+			map[string]interface{}{"name": "2024", "y": []interface{}{12.0, 7.0, 3.0}},
+		},
+		"output": "charts/venues.png",
+	})
+	if result.IsError {
+		t.Fatalf("Execute failed: %s", result.ForLLM)
+	}
+
+	outPath := filepath.Join(workspace, "charts", "venues.png")
+	f, err := os.Open(outPath)
+	if err != nil {
+		t.Fatalf("chart not written: %v", err)
+	}
+	defer f.Close()
+	if _, err := png.Decode(f); err != nil {
+		t.Fatalf("chart is not a PNG: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	out, ok := mb.SubscribeOutbound(ctx)
+	if !ok {
+		t.Fatal("expected outbound message")
+	}
+	if out.FilePath != outPath || out.FileName != "venues.png" || out.Content != "Papers by venue" {
+		t.Errorf("unexpected outbound message: %+v", out)
+	}
+}
+
+func TestPlotTool_Table(t *testing.T) {
+	workspace := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workspace, "people.csv"), []byte(testMeasurementsCSV), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewPlotTool(workspace, true, nil)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"type":   "line",
+		"path":   "people.csv",
+		"x":      "Subject",
+		"y":      "height_cm, weight",
+		"output": "out/people.svg",
+		"send":   false,
+	})
+	if result.IsError {
+		t.Fatalf("Execute failed: %s", result.ForLLM)
+	}
+	data, err := os.ReadFile(filepath.Join(workspace, "out", "people.svg"))
+	if err != nil {
+		t.Fatalf("chart not written: %v", err)
+	}
+	for _, want := range []string{"<svg ", ">Subject</text>", ">Height (cm)</text>", ">Weight</text>", ">D</text>"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("SVG missing %q", want)
+		}
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"type": "line", "path": "people.csv", "y": "age"},
+		{"type": "line", "path": "people.csv", "y": "Subject"},
+		{"type": "pie", "series": []interface{}{map[string]interface{}{"y": []interface{}{1.0}}}},
+		{"type": "line"},
+		{"type": "line", "series": []interface{}{map[string]interface{}{"y": []interface{}{1.0}}}},
+	} {
+		if result := tool.Execute(context.Background(), args); !result.IsError {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+// startTerminal starts cmd on a new pseudo-terminal and returns its master
+// side. The child becomes a session leader with the terminal as controlling
+// tty, so job control and Ctrl-C work as in an interactive shell. Echo and
+// output post-processing are turned off so what is read back is exactly what
+// the programs wrote.
+func startTerminal(cmd *exec.Cmd) (io.ReadWriteCloser, error) {
+	// The master is opened non-blocking so os.File uses the poller and Close
+	// interrupts a pending Read.
+	fd, err := syscall.Open("/dev/ptmx", syscall.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pty: %v", err)
+	}
+	master := os.NewFile(uintptr(fd), "/dev/ptmx")
+
+	var unlock int32
+	if err := ioctl(uintptr(fd), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); err != nil {
+		master.Close()
+		return nil, fmt.Errorf("failed to unlock pty: %v", err)
+	}
+	var n uint32
+	if err := ioctl(uintptr(fd), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); err != nil {
+		master.Close()
+		return nil, fmt.Errorf("failed to get pty number: %v", err)
+	}
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, fmt.Errorf("failed to open pty slave: %v", err)
+	}
+	defer slave.Close()
+
+	var termios syscall.Termios
+	if err := ioctl(slave.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&termios))); err == nil {
+		termios.Lflag &^= syscall.ECHO | syscall.ECHONL
+		// Keep queued input on Ctrl-C; callers may have written more lines.
+		termios.Lflag |= syscall.NOFLSH
+		termios.Oflag &^= syscall.OPOST
+		ioctl(slave.Fd(), syscall.TCSETS, uintptr(unsafe.Pointer(&termios)))
+	}
+	ws := struct{ rows, cols, x, y uint16 }{rows: 50, cols: 200}
+	ioctl(slave.Fd(), syscall.TIOCSWINSZ, uintptr(unsafe.Pointer(&ws)))
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 0
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		return nil, err
+	}
+	return master, nil
+}
+
+// killProcessGroup sends sig to the session started by startTerminal, which
+// includes background jobs of a shell.
+func killProcessGroup(cmd *exec.Cmd, sig syscall.Signal) {
+	if cmd.Process == nil {
+		return
+	}
+	if err := syscall.Kill(-cmd.Process.Pid, sig); err != nil {
+		cmd.Process.Signal(sig)
+	}
+}
+
+func ioctl(fd, req, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
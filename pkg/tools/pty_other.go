@@ -0,0 +1,60 @@
+//go:build !linux
+
+package tools
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// startTerminal starts cmd with pipes for stdin and combined stdout/stderr.
+// Programs see no terminal, so prompts and job control may differ from
+// Linux, where a real pseudo-terminal is used.
+func startTerminal(cmd *exec.Cmd) (io.ReadWriteCloser, error) {
+	inR, inW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		inR.Close()
+		inW.Close()
+		return nil, err
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = inR, outW, outW
+	err = cmd.Start()
+	inR.Close()
+	outW.Close()
+	if err != nil {
+		inW.Close()
+		outR.Close()
+		return nil, err
+	}
+	return &pipeTerminal{r: outR, w: inW}, nil
+}
+
+type pipeTerminal struct {
+	r *os.File
+	w *os.File
+}
+
+func (p *pipeTerminal) Read(b []byte) (int, error)  { return p.r.Read(b) }
+func (p *pipeTerminal) Write(b []byte) (int, error) { return p.w.Write(b) }
+
+func (p *pipeTerminal) Close() error {
+	p.w.Close()
+	return p.r.Close()
+}
+
+func killProcessGroup(cmd *exec.Cmd, sig syscall.Signal) {
+	if cmd.Process == nil {
+		return
+	}
+	if sig == syscall.SIGKILL {
+		cmd.Process.Kill()
+		return
+	}
+	cmd.Process.Signal(sig)
+}
@@ -0,0 +1,597 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/srikesh3005/summer/pkg/utils"
+)
+
+const (
+	defaultEUtilsURL    = "https://eutils.ncbi.nlm.nih.gov/entrez/eutils/"
+	defaultEuropePMCURL = "https://www.ebi.ac.uk/europepmc/webservices/rest/search"
+)
+
+// biomedArticle is a PubMed or Europe PMC record.
+type biomedArticle struct {
+	PMID     string
+	PMCID    string
+	DOI      string
+	Title    string
+	Authors  []string
+	Journal  string
+	Date     string
+	Abstract string
+	PubTypes []string
+	MeSH     []string
+}
+
+// link returns the article's PubMed or Europe PMC page.
+func (a biomedArticle) link() string {
+	switch {
+	case a.PMID != "":
+		return "https://pubmed.ncbi.nlm.nih.gov/" + a.PMID + "/"
+	case a.PMCID != "":
+		return "https://europepmc.org/article/PMC/" + a.PMCID
+	case a.DOI != "":
+		return "https://doi.org/" + a.DOI
+	}
+	return ""
+}
+
+// markupTagRe matches HTML tags that Europe PMC escapes inside text
+// elements, without catching comparisons such as "p < 0.05".
+var markupTagRe = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9]*(\s[^<>]*)?/?>`)
+
+// xmlText flattens an element's inner XML (which may contain <i>, <sup> and
+// similar markup, raw or escaped) into plain text.
+func xmlText(inner string) string {
+	text := markupTagRe.ReplaceAllString(html.UnescapeString(stripTags(inner)), "")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+type pubmedArticleSet struct {
+	Articles []struct {
+		PMID    string `xml:"MedlineCitation>PMID"`
+		Article struct {
+			Title   xmlInner `xml:"ArticleTitle"`
+			Journal struct {
+				Title   string `xml:"Title"`
+				PubDate struct {
+					Year        string `xml:"Year"`
+					Month       string `xml:"Month"`
+					Day         string `xml:"Day"`
+					MedlineDate string `xml:"MedlineDate"`
+				} `xml:"JournalIssue>PubDate"`
+			} `xml:"Journal"`
+			Abstract []struct {
+				Label string `xml:"Label,attr"`
+				Text  string `xml:",innerxml"`
+			} `xml:"Abstract>AbstractText"`
+			Authors []struct {
+				LastName       string `xml:"LastName"`
+				ForeName       string `xml:"ForeName"`
+				CollectiveName string `xml:"CollectiveName"`
+			} `xml:"AuthorList>Author"`
+			PubTypes []string `xml:"PublicationTypeList>PublicationType"`
+		} `xml:"MedlineCitation>Article"`
+		MeSH       []string `xml:"MedlineCitation>MeshHeadingList>MeshHeading>DescriptorName"`
+		ArticleIDs []struct {
+			Type  string `xml:"IdType,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"PubmedData>ArticleIdList>ArticleId"`
+	} `xml:"PubmedArticle"`
+}
+
+type xmlInner struct {
+	Inner string `xml:",innerxml"`
+}
+
+// parsePubMedXML parses an efetch PubmedArticleSet.
+func parsePubMedXML(data []byte) ([]biomedArticle, error) {
+	var set pubmedArticleSet
+	if err := xml.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	var articles []biomedArticle
+	for _, p := range set.Articles {
+		a := biomedArticle{
+			PMID:     strings.TrimSpace(p.PMID),
+			Title:    xmlText(p.Article.Title.Inner),
+			Journal:  strings.TrimSpace(p.Article.Journal.Title),
+			PubTypes: p.Article.PubTypes,
+			MeSH:     p.MeSH,
+		}
+		date := p.Article.Journal.PubDate
+		if date.MedlineDate != "" {
+			a.Date = date.MedlineDate
+		} else {
+			a.Date = strings.Join(strings.Fields(date.Year+" "+date.Month+" "+date.Day), " ")
+		}
+		var sections []string
+		for _, s := range p.Article.Abstract {
+			text := xmlText(s.Text)
+			if s.Label != "" {
+				text = s.Label + ": " + text
+			}
+			sections = append(sections, text)
+		}
+		a.Abstract = strings.Join(sections, " ")
+		for _, au := range p.Article.Authors {
+			if au.CollectiveName != "" {
+				a.Authors = append(a.Authors, au.CollectiveName)
+			} else {
+				a.Authors = append(a.Authors, strings.TrimSpace(au.ForeName+" "+au.LastName))
+			}
+		}
+		for _, id := range p.ArticleIDs {
+			switch id.Type {
+			case "doi":
+				a.DOI = strings.TrimSpace(id.Value)
+			case "pmc":
+				a.PMCID = strings.TrimSpace(id.Value)
+			}
+		}
+		articles = append(articles, a)
+	}
+	return articles, nil
+}
+
+type europePMCResponse struct {
+	HitCount int `xml:"hitCount"`
+	Results  []struct {
+		PMID         string   `xml:"pmid"`
+		PMCID        string   `xml:"pmcid"`
+		DOI          string   `xml:"doi"`
+		Title        xmlInner `xml:"title"`
+		AuthorString string   `xml:"authorString"`
+		Authors      []string `xml:"authorList>author>fullName"`
+		Journal      string   `xml:"journalInfo>journal>title"`
+		PubYear      string   `xml:"pubYear"`
+		FirstPubDate string   `xml:"firstPublicationDate"`
+		Abstract     xmlInner `xml:"abstractText"`
+		PubTypes     []string `xml:"pubTypeList>pubType"`
+		MeSH         []string `xml:"meshHeadingList>meshHeading>descriptorName"`
+	} `xml:"resultList>result"`
+}
+
+// parseEuropePMCXML parses a Europe PMC search response (resultType=core).
+// It also returns the total hit count.
+func parseEuropePMCXML(data []byte) ([]biomedArticle, int, error) {
+	var resp europePMCResponse
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, 0, err
+	}
+	var articles []biomedArticle
+	for _, r := range resp.Results {
+		a := biomedArticle{
+			PMID:     r.PMID,
+			PMCID:    r.PMCID,
+			DOI:      r.DOI,
+			Title:    strings.TrimSuffix(xmlText(r.Title.Inner), "."),
+			Authors:  r.Authors,
+			Journal:  r.Journal,
+			Date:     r.FirstPubDate,
+			Abstract: xmlText(r.Abstract.Inner),
+			PubTypes: r.PubTypes,
+			MeSH:     r.MeSH,
+		}
+		if a.Date == "" {
+			a.Date = r.PubYear
+		}
+		if len(a.Authors) == 0 && r.AuthorString != "" {
+			for _, name := range strings.Split(strings.TrimSuffix(r.AuthorString, "."), ",") {
+				a.Authors = append(a.Authors, strings.TrimSpace(name))
+			}
+		}
+		articles = append(articles, a)
+	}
+	return articles, resp.HitCount, nil
+}
+
+// parseDateBound validates a YYYY, YYYY-MM or YYYY-MM-DD date (slashes are
+// accepted too) and expands it to the first or, if end is set, last day of
+// the period.
+func parseDateBound(s string, end bool) (time.Time, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "/", "-")
+	for _, layout := range []string{"2006-01-02", "2006-01", "2006"} {
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			continue
+		}
+		if end {
+			switch layout {
+			case "2006-01":
+				t = t.AddDate(0, 1, -1)
+			case "2006":
+				t = t.AddDate(1, 0, -1)
+			}
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q (use YYYY, YYYY-MM or YYYY-MM-DD)", s)
+}
+
+// biomedQuery holds the search criteria shared by both sources.
+type biomedQuery struct {
+	text     string
+	mesh     []string
+	pubTypes []string
+	from, to time.Time
+}
+
+func (q biomedQuery) hasDates() bool {
+	return !q.from.IsZero() || !q.to.IsZero()
+}
+
+// dateRange fills in an open end of the date range.
+func (q biomedQuery) dateRange() (time.Time, time.Time) {
+	from, to := q.from, q.to
+	if from.IsZero() {
+		from = time.Date(1800, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	return from, to
+}
+
+// pubmedTerm builds an E-utilities search term with field tags.
+func (q biomedQuery) pubmedTerm() string {
+	var parts []string
+	if q.text != "" {
+		parts = append(parts, "("+q.text+")")
+	}
+	for _, m := range q.mesh {
+		parts = append(parts, fmt.Sprintf("%q[MeSH Terms]", m))
+	}
+	if len(q.pubTypes) > 0 {
+		var types []string
+		for _, pt := range q.pubTypes {
+			types = append(types, fmt.Sprintf("%q[Publication Type]", pt))
+		}
+		parts = append(parts, "("+strings.Join(types, " OR ")+")")
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// europePMCQuery builds a Europe PMC search query.
+func (q biomedQuery) europePMCQuery() string {
+	var parts []string
+	if q.text != "" {
+		parts = append(parts, "("+q.text+")")
+	}
+	for _, m := range q.mesh {
+		parts = append(parts, fmt.Sprintf("MESH:%q", m))
+	}
+	if len(q.pubTypes) > 0 {
+		var types []string
+		for _, pt := range q.pubTypes {
+			types = append(types, fmt.Sprintf("PUB_TYPE:%q", pt))
+		}
+		parts = append(parts, "("+strings.Join(types, " OR ")+")")
+	}
+	if q.hasDates() {
+		from, to := q.dateRange()
+		parts = append(parts, fmt.Sprintf("FIRST_PDATE:[%s TO %s]", from.Format("2006-01-02"), to.Format("2006-01-02")))
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// PubMedTool searches biomedical literature in PubMed (NCBI E-utilities)
+// or Europe PMC.
+type PubMedTool struct {
+	client       *http.Client
+	apiKey       string
+	eutilsURL    string
+	europePMCURL string
+}
+
+// NewPubMedTool creates the pubmed_search tool. apiKey is an optional NCBI
+// API key, which raises the E-utilities rate limit from 3 to 10 requests per second.
+func NewPubMedTool(client *http.Client, apiKey string) *PubMedTool {
+	return &PubMedTool{
+		client:       clientOrDefault(client),
+		apiKey:       apiKey,
+		eutilsURL:    defaultEUtilsURL,
+		europePMCURL: defaultEuropePMCURL,
+	}
+}
+
+func (t *PubMedTool) Name() string {
+	return "pubmed_search"
+}
+
+func (t *PubMedTool) Description() string {
+	return "Search biomedical and life-science literature in PubMed or Europe PMC. Supports MeSH terms, publication-type filters " +
+		"(e.g. Review, Meta-Analysis, Randomized Controlled Trial) and publication date ranges. " +
+		"Pass ids to retrieve the full abstracts of specific PubMed IDs."
+}
+
+func (t *PubMedTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Search query; PubMed or Europe PMC field syntax is allowed (e.g., 'CRISPR off-target effects')",
+			},
+			"mesh_terms": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "MeSH headings the articles must be indexed with (e.g., ['Neoplasms', 'Immunotherapy'])",
+			},
+			"publication_types": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Only articles of any of these types (e.g., ['Review', 'Clinical Trial'])",
+			},
+			"date_from": map[string]interface{}{
+				"type":        "string",
+				"description": "Earliest publication date: YYYY, YYYY-MM or YYYY-MM-DD",
+			},
+			"date_to": map[string]interface{}{
+				"type":        "string",
+				"description": "Latest publication date: YYYY, YYYY-MM or YYYY-MM-DD",
+			},
+			"ids": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "PubMed IDs to retrieve with full abstracts, instead of searching",
+			},
+			"source": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"pubmed", "europepmc"},
+				"description": "Database to search (default: pubmed). Europe PMC also covers preprints and full-text articles.",
+			},
+			"max_results": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum number of results to return (default: 5, max: 20)",
+				"minimum":     1.0,
+				"maximum":     20.0,
+			},
+		},
+	}
+}
+
+func (t *PubMedTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	q := biomedQuery{
+		mesh:     stringListArg(args, "mesh_terms"),
+		pubTypes: stringListArg(args, "publication_types"),
+	}
+	q.text, _ = args["query"].(string)
+	q.text = strings.TrimSpace(q.text)
+	ids := stringListArg(args, "ids")
+	if q.text == "" && len(q.mesh) == 0 && len(ids) == 0 {
+		return ErrorResult("query, mesh_terms or ids is required")
+	}
+	var err error
+	if s, _ := args["date_from"].(string); s != "" {
+		if q.from, err = parseDateBound(s, false); err != nil {
+			return ErrorResult(err.Error())
+		}
+	}
+	if s, _ := args["date_to"].(string); s != "" {
+		if q.to, err = parseDateBound(s, true); err != nil {
+			return ErrorResult(err.Error())
+		}
+	}
+
+	maxResults := 5
+	if mr, ok := args["max_results"].(float64); ok && mr > 0 {
+		maxResults = int(mr)
+		if maxResults > 20 {
+			maxResults = 20
+		}
+	}
+
+	source, _ := args["source"].(string)
+	var articles []biomedArticle
+	var total int
+	var sourceName, label string
+	switch source {
+	case "", "pubmed":
+		sourceName = "PubMed"
+		if len(ids) > 0 {
+			label = strings.Join(ids, ", ")
+			articles, err = t.pubmedFetch(ctx, ids)
+			total = len(articles)
+		} else {
+			label = q.pubmedTerm()
+			articles, total, err = t.pubmedSearch(ctx, q, maxResults)
+		}
+	case "europepmc":
+		sourceName = "Europe PMC"
+		query := q.europePMCQuery()
+		if len(ids) > 0 {
+			var parts []string
+			for _, id := range ids {
+				parts = append(parts, "EXT_ID:"+id)
+			}
+			query = "(" + strings.Join(parts, " OR ") + ") AND SRC:MED"
+		}
+		label = query
+		articles, total, err = t.europePMCSearch(ctx, query, maxResults)
+	default:
+		return ErrorResult(fmt.Sprintf("unknown source %q (use pubmed or europepmc)", source))
+	}
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	if len(articles) == 0 {
+		return &ToolResult{
+			ForLLM:  fmt.Sprintf("No results found on %s for: %s", sourceName, label),
+			ForUser: fmt.Sprintf("No results found on %s for: %s", sourceName, label),
+		}
+	}
+
+	output := formatBiomedArticles(sourceName, label, articles, total, len(ids) > 0)
+	return &ToolResult{
+		ForLLM:  output,
+		ForUser: output,
+	}
+}
+
+func (t *PubMedTool) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("User-Agent", "Summer AI Assistant (mailto:research@example.com)")
+
+	resp, err := withTimeout(t.client, 15*time.Second).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %d - %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// eutilsParams returns the parameters NCBI asks every E-utilities call to send.
+func (t *PubMedTool) eutilsParams() url.Values {
+	params := url.Values{"db": {"pubmed"}, "tool": {"summer"}}
+	if t.apiKey != "" {
+		params.Set("api_key", t.apiKey)
+	}
+	return params
+}
+
+func (t *PubMedTool) pubmedSearch(ctx context.Context, q biomedQuery, maxResults int) ([]biomedArticle, int, error) {
+	params := t.eutilsParams()
+	params.Set("term", q.pubmedTerm())
+	params.Set("retmax", fmt.Sprint(maxResults))
+	params.Set("retmode", "json")
+	params.Set("sort", "relevance")
+	if q.hasDates() {
+		from, to := q.dateRange()
+		params.Set("datetype", "pdat")
+		params.Set("mindate", from.Format("2006/01/02"))
+		params.Set("maxdate", to.Format("2006/01/02"))
+	}
+	body, err := t.get(ctx, t.eutilsURL+"esearch.fcgi?"+params.Encode())
+	if err != nil {
+		return nil, 0, err
+	}
+	var search struct {
+		Result struct {
+			Count  string   `json:"count"`
+			IDList []string `json:"idlist"`
+		} `json:"esearchresult"`
+	}
+	if err := json.Unmarshal(body, &search); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse search results: %v", err)
+	}
+	if len(search.Result.IDList) == 0 {
+		return nil, 0, nil
+	}
+	var total int
+	fmt.Sscan(search.Result.Count, &total)
+	articles, err := t.pubmedFetch(ctx, search.Result.IDList)
+	return articles, total, err
+}
+
+func (t *PubMedTool) pubmedFetch(ctx context.Context, ids []string) ([]biomedArticle, error) {
+	params := t.eutilsParams()
+	params.Set("id", strings.Join(ids, ","))
+	params.Set("retmode", "xml")
+	body, err := t.get(ctx, t.eutilsURL+"efetch.fcgi?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	articles, err := parsePubMedXML(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse results: %v", err)
+	}
+	return articles, nil
+}
+
+func (t *PubMedTool) europePMCSearch(ctx context.Context, query string, maxResults int) ([]biomedArticle, int, error) {
+	params := url.Values{
+		"query":      {query},
+		"format":     {"xml"},
+		"resultType": {"core"},
+		"pageSize":   {fmt.Sprint(maxResults)},
+	}
+	body, err := t.get(ctx, t.europePMCURL+"?"+params.Encode())
+	if err != nil {
+		return nil, 0, err
+	}
+	articles, total, err := parseEuropePMCXML(body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse results: %v", err)
+	}
+	return articles, total, nil
+}
+
+// formatBiomedArticles renders results in the same layout as arxiv_search.
+// Abstracts are shortened unless specific articles were requested.
+func formatBiomedArticles(source, label string, articles []biomedArticle, total int, full bool) string {
+	var lines []string
+	header := fmt.Sprintf("%s Results for: %s", source, label)
+	if total > len(articles) {
+		header += fmt.Sprintf(" (showing %d of %d)", len(articles), total)
+	}
+	lines = append(lines, header+"\n")
+
+	for i, a := range articles {
+		authors := a.Authors
+		if len(authors) > 10 && !full {
+			authors = append(authors[:10:10], "et al.")
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, a.Title))
+		lines = append(lines, fmt.Sprintf("   Authors: %s", strings.Join(authors, ", ")))
+		if a.Journal != "" {
+			lines = append(lines, fmt.Sprintf("   Journal: %s", a.Journal))
+		}
+		lines = append(lines, fmt.Sprintf("   Published: %s", a.Date))
+		var ids []string
+		if a.PMID != "" {
+			ids = append(ids, "PMID: "+a.PMID)
+		}
+		if a.PMCID != "" {
+			ids = append(ids, "PMCID: "+a.PMCID)
+		}
+		if a.DOI != "" {
+			ids = append(ids, "DOI: "+a.DOI)
+		}
+		if len(ids) > 0 {
+			lines = append(lines, "   "+strings.Join(ids, " | "))
+		}
+		if link := a.link(); link != "" {
+			lines = append(lines, fmt.Sprintf("   Link: %s", link))
+		}
+		if len(a.PubTypes) > 0 {
+			lines = append(lines, fmt.Sprintf("   Type: %s", strings.Join(a.PubTypes, ", ")))
+		}
+		if full && len(a.MeSH) > 0 {
+			lines = append(lines, fmt.Sprintf("   MeSH: %s", strings.Join(a.MeSH, "; ")))
+		}
+		if a.Abstract != "" {
+			abstract := a.Abstract
+			if !full {
+				abstract = utils.Truncate(abstract, 300)
+			}
+			lines = append(lines, fmt.Sprintf("   Abstract: %s", abstract))
+		}
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
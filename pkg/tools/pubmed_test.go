@@ -0,0 +1,198 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParsePubMedXML(t *testing.T) {
+	articles, err := parsePubMedXML(readFixture(t, "pubmed", "efetch.xml"))
+	if err != nil {
+		t.Fatalf("parsePubMedXML: %v", err)
+	}
+	if len(articles) != 2 {
+		t.Fatalf("got %d articles, want 2", len(articles))
+	}
+
+	a := articles[0]
+	if a.PMID != "32015507" || a.PMCID != "PMC7095418" || a.DOI != "10.1038/s41586-020-2012-7" {
+		t.Errorf("unexpected identifiers: %+v", a)
+	}
+	if a.Title != "A pneumonia outbreak associated with a new coronavirus of probable bat origin." || a.Journal != "Nature" || a.Date != "2020 Mar" {
+		t.Errorf("unexpected title/journal/date: %q / %q / %q", a.Title, a.Journal, a.Date)
+	}
+	if got := strings.Join(a.Authors, ", "); got != "Peng Zhou, Xing-Lou Yang, COVID-19 Research Group" {
+		t.Errorf("authors = %q", got)
+	}
+	wantAbstract := "BACKGROUND: Since the outbreak of severe acute respiratory syndrome (SARS) 18 years ago, a large number of SARS-related coronaviruses have been discovered. " +
+		"RESULTS: The sequence is 96% identical at the whole-genome level to a bat coronavirus & related strains."
+	if a.Abstract != wantAbstract {
+		t.Errorf("abstract = %q", a.Abstract)
+	}
+	if strings.Join(a.MeSH, ";") != "Animals;Chiroptera" || a.PubTypes[0] != "Journal Article" {
+		t.Errorf("MeSH %v, types %v", a.MeSH, a.PubTypes)
+	}
+
+	b := articles[1]
+	if b.Title != "Effects of 13C labelling: a review" || b.Date != "1999 Nov-Dec" || b.Abstract != "" {
+		t.Errorf("unexpected second article: %+v", b)
+	}
+}
+
+func TestParseEuropePMCXML(t *testing.T) {
+	articles, total, err := parseEuropePMCXML(readFixture(t, "pubmed", "europepmc.xml"))
+	if err != nil {
+		t.Fatalf("parseEuropePMCXML: %v", err)
+	}
+	if total != 27 || len(articles) != 2 {
+		t.Fatalf("got %d articles of %d", len(articles), total)
+	}
+	a := articles[0]
+	if a.Title != "CRISPR-Cas9 genome editing of human cells" || a.Date != "2020-10-14" || a.PMCID != "PMC7560299" {
+		t.Errorf("unexpected article: %+v", a)
+	}
+	if a.Abstract != "Genome editing with CRISPR has transformed biology." {
+		t.Errorf("abstract = %q", a.Abstract)
+	}
+	if strings.Join(a.Authors, ", ") != "Doudna JA, Charpentier E" || a.MeSH[0] != "CRISPR-Cas Systems" {
+		t.Errorf("authors %v, MeSH %v", a.Authors, a.MeSH)
+	}
+	// Without an author list, authorString is split.
+	if b := articles[1]; strings.Join(b.Authors, "|") != "Smith A|Jones B" || b.Date != "2021" || b.link() != "https://doi.org/10.1101/2021.01.01.425000" {
+		t.Errorf("unexpected preprint: %+v", b)
+	}
+}
+
+func TestParseDateBound(t *testing.T) {
+	cases := []struct {
+		in   string
+		end  bool
+		want string
+	}{
+		{"2020", false, "2020-01-01"},
+		{"2020", true, "2020-12-31"},
+		{"2024-02", true, "2024-02-29"},
+		{"2019/11/05", false, "2019-11-05"},
+	}
+	for _, tc := range cases {
+		got, err := parseDateBound(tc.in, tc.end)
+		if err != nil || got.Format("2006-01-02") != tc.want {
+			t.Errorf("parseDateBound(%q, %v) = %v, %v; want %s", tc.in, tc.end, got, err, tc.want)
+		}
+	}
+	if _, err := parseDateBound("last year", false); err == nil {
+		t.Error("expected an error for an invalid date")
+	}
+}
+
+func newPubMedServer(t *testing.T) (*PubMedTool, *fixtureServer) {
+	t.Helper()
+	server := newFixtureServer(t, "pubmed", pathRoutes(map[string]string{
+		"/eutils/esearch.fcgi": "esearch.json",
+		"/eutils/efetch.fcgi":  "efetch.xml",
+		"/europepmc":           "europepmc.xml",
+	}))
+	tool := NewPubMedTool(testOutboundClient(), "test-key")
+	tool.eutilsURL = server.URL + "/eutils/"
+	tool.europePMCURL = server.URL + "/europepmc"
+	return tool, server
+}
+
+func TestPubMedTool_Search(t *testing.T) {
+	tool, server := newPubMedServer(t)
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"query":             "bat coronavirus",
+		"mesh_terms":        []interface{}{"Chiroptera"},
+		"publication_types": []interface{}{"Journal Article", "Review"},
+		"date_from":         "2019",
+		"date_to":           "2020-06",
+		"max_results":       2.0,
+	})
+	if result.IsError {
+		t.Fatalf("Execute: %s", result.ForLLM)
+	}
+
+	search := server.queries()[0]
+	wantTerm := `(bat coronavirus) AND "Chiroptera"[MeSH Terms] AND ("Journal Article"[Publication Type] OR "Review"[Publication Type])`
+	if search.Get("term") != wantTerm {
+		t.Errorf("term = %s", search.Get("term"))
+	}
+	if search.Get("mindate") != "2019/01/01" || search.Get("maxdate") != "2020/06/30" || search.Get("datetype") != "pdat" {
+		t.Errorf("date range = %s - %s (%s)", search.Get("mindate"), search.Get("maxdate"), search.Get("datetype"))
+	}
+	if search.Get("retmax") != "2" || search.Get("api_key") != "test-key" {
+		t.Errorf("retmax %s, api_key %s", search.Get("retmax"), search.Get("api_key"))
+	}
+	if fetch := server.queries()[1]; fetch.Get("id") != "32015507,10000001" {
+		t.Errorf("efetch ids = %s", fetch.Get("id"))
+	}
+
+	for _, want := range []string{
+		"PubMed Results for: " + wantTerm + " (showing 2 of 1342)\n",
+		"1. A pneumonia outbreak associated with a new coronavirus of probable bat origin.\n   Authors: Peng Zhou, Xing-Lou Yang, COVID-19 Research Group\n   Journal: Nature\n   Published: 2020 Mar\n",
+		"   PMID: 32015507 | PMCID: PMC7095418 | DOI: 10.1038/s41586-020-2012-7\n   Link: https://pubmed.ncbi.nlm.nih.gov/32015507/\n",
+		"   Type: Review\n",
+	} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("output missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+	if strings.Contains(result.ForLLM, "MeSH:") {
+		t.Error("search results should not list MeSH headings")
+	}
+}
+
+func TestPubMedTool_FetchByID(t *testing.T) {
+	tool, server := newPubMedServer(t)
+	result := tool.Execute(context.Background(), map[string]interface{}{"ids": "32015507, 10000001"})
+	if result.IsError {
+		t.Fatalf("Execute: %s", result.ForLLM)
+	}
+	if queries := server.queries(); len(queries) != 1 || queries[0].Get("id") != "32015507,10000001" {
+		t.Errorf("expected a single efetch, got %v", queries)
+	}
+	// Full abstracts and MeSH headings are shown for requested articles.
+	if !strings.Contains(result.ForLLM, "related strains.") || !strings.Contains(result.ForLLM, "MeSH: Animals; Chiroptera") {
+		t.Errorf("full record missing:\n%s", result.ForLLM)
+	}
+}
+
+func TestPubMedTool_EuropePMC(t *testing.T) {
+	tool, server := newPubMedServer(t)
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"source":            "europepmc",
+		"query":             "CRISPR",
+		"mesh_terms":        []interface{}{"CRISPR-Cas Systems"},
+		"publication_types": []interface{}{"Review"},
+		"date_from":         "2020-01",
+	})
+	if result.IsError {
+		t.Fatalf("Execute: %s", result.ForLLM)
+	}
+	q := server.queries()[0]
+	if !strings.HasPrefix(q.Get("query"), `(CRISPR) AND MESH:"CRISPR-Cas Systems" AND (PUB_TYPE:"Review") AND FIRST_PDATE:[2020-01-01 TO `) {
+		t.Errorf("query = %s", q.Get("query"))
+	}
+	if q.Get("format") != "xml" || q.Get("resultType") != "core" || q.Get("pageSize") != "5" {
+		t.Errorf("unexpected parameters: %v", q)
+	}
+	if !strings.Contains(result.ForLLM, "Europe PMC Results for:") || !strings.Contains(result.ForLLM, "2. A preprint without an author list") {
+		t.Errorf("unexpected output:\n%s", result.ForLLM)
+	}
+}
+
+func TestPubMedTool_Errors(t *testing.T) {
+	tool, _ := newPubMedServer(t)
+	cases := []map[string]interface{}{
+		{},
+		{"publication_types": []interface{}{"Review"}},
+		{"query": "x", "date_from": "yesterday"},
+		{"query": "x", "source": "scopus"},
+	}
+	for _, args := range cases {
+		if result := tool.Execute(context.Background(), args); !result.IsError {
+			t.Errorf("Execute(%v) should fail, got %s", args, result.ForLLM)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/srikesh3005/summer/pkg/calc"
+)
+
+const (
+	defaultQueryRows = 50
+	maxQueryRows     = 1000
+)
+
+// specListArg reads a list of column specs given as a JSON array or a
+// comma-separated string; commas inside parentheses or quotes do not split.
+func specListArg(args map[string]interface{}, key string) []string {
+	s, ok := args[key].(string)
+	if !ok {
+		return stringListArg(args, key)
+	}
+	var out []string
+	depth, quote, start := 0, byte(0), 0
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) {
+			switch c := s[i]; {
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+				continue
+			case c == '"' || c == '\'' || c == '`':
+				quote = c
+				continue
+			case c == '(' || c == '[':
+				depth++
+				continue
+			case c == ')' || c == ']':
+				depth--
+				continue
+			case c != ',' || depth > 0:
+				continue
+			}
+		}
+		if part := strings.TrimSpace(s[start:i]); part != "" {
+			out = append(out, part)
+		}
+		start = i + 1
+	}
+	return out
+}
+
+// formatTableMarkdown renders a table as Markdown, escaping pipes in cells.
+func formatTableMarkdown(t *calc.Table) string {
+	cell := func(s string) string {
+		return strings.ReplaceAll(strings.ReplaceAll(strings.TrimSpace(s), "|", `\|`), "\n", " ")
+	}
+	var sb strings.Builder
+	sb.WriteString("|")
+	for _, c := range t.Columns {
+		sb.WriteString(" " + cell(c) + " |")
+	}
+	sb.WriteString("\n|")
+	for range t.Columns {
+		sb.WriteString(" --- |")
+	}
+	for _, row := range t.Rows {
+		sb.WriteString("\n|")
+		for _, v := range row {
+			sb.WriteString(" " + cell(v) + " |")
+		}
+	}
+	return sb.String()
+}
+
+// QueryCSVTool filters, groups and aggregates the rows of a CSV, TSV or
+// Excel file.
+type QueryCSVTool struct {
+	paths *PathPolicy
+}
+
+func NewQueryCSVTool(workspace string, restrict bool, roots ...PathRoot) *QueryCSVTool {
+	return &QueryCSVTool{paths: NewPathPolicy(workspace, restrict, roots...)}
+}
+
+func (t *QueryCSVTool) Name() string {
+	return "query_csv"
+}
+
+func (t *QueryCSVTool) Description() string {
+	return "Query a CSV, TSV or XLSX table: filter rows (where), group them (group_by), aggregate (count, distinct, sum, mean, median, min, max, std, var), " +
+		"pick or compute columns (select), sort and limit. Columns are referred to by name, or in expressions by their lower-case name with other " +
+		"characters replaced by _ (or in backticks). Example: where \"year >= 2020 and contains(venue, 'neurips')\", group_by [\"year\"], " +
+		"aggregate [\"count\", \"mean(citations) as avg_citations\"], sort \"-year\". Use this instead of reading large tables and counting by hand."
+}
+
+func (t *QueryCSVTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Path to the CSV, TSV or XLSX file",
+			},
+			"sheet": map[string]interface{}{
+				"type":        "string",
+				"description": "For workbooks, the sheet to query (default: the first)",
+			},
+			"where": map[string]interface{}{
+				"type":        "string",
+				"description": "Row filter, e.g. \"price > 10 and country == 'DE'\"; supports and/or/not, contains, startswith, lower",
+			},
+			"group_by": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Columns to group by",
+			},
+			"aggregate": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Aggregates per group, e.g. [\"count\", \"sum(qty)\", \"mean(price * qty) as avg_revenue\"] (default with group_by: count)",
+			},
+			"select": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Columns or expressions to return when not aggregating, e.g. [\"title\", \"price * 1.19 as gross\"] (default: all)",
+			},
+			"sort": map[string]interface{}{
+				"type":        "string",
+				"description": "Result column to sort by; prefix with - for descending",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum rows to return (default 50, max 1000)",
+			},
+			"output": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional path to save the full result as CSV",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+func (t *QueryCSVTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	path, _ := args["path"].(string)
+	if path == "" {
+		return ErrorResult("path is required")
+	}
+	resolved, err := t.paths.Resolve(path, PathRead)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	sheet, _ := args["sheet"].(string)
+	table, err := loadTable(resolved, sheet)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to read table: %v", err))
+	}
+	limit := defaultQueryRows
+	if v, ok := args["limit"].(float64); ok && v >= 1 {
+		limit = min(int(v), maxQueryRows)
+	}
+
+	q := calc.Query{
+		GroupBy:   stringListArg(args, "group_by"),
+		Aggregate: specListArg(args, "aggregate"),
+		Select:    specListArg(args, "select"),
+	}
+	q.Where, _ = args["where"].(string)
+	q.Sort, _ = args["sort"].(string)
+	result, err := table.Query(q)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to query table: %v", err))
+	}
+
+	var sb strings.Builder
+	if output, _ := args["output"].(string); output != "" {
+		if err := t.save(output, result); err != nil {
+			return ErrorResult(err.Error())
+		}
+		fmt.Fprintf(&sb, "Saved %d row(s) to %s\n", len(result.Rows), output)
+	}
+	total := len(result.Rows)
+	if total > limit {
+		result.Rows = result.Rows[:limit]
+	}
+	fmt.Fprintf(&sb, "%d row(s) from %s (%d data rows)", total, path, len(table.Rows))
+	if total > limit {
+		fmt.Fprintf(&sb, ", showing the first %d", limit)
+	}
+	sb.WriteString("\n\n")
+	if len(result.Rows) == 0 {
+		sb.WriteString("No rows matched.")
+	} else {
+		sb.WriteString(formatTableMarkdown(result))
+	}
+	return NewToolResult(sb.String())
+}
+
+func (t *QueryCSVTool) save(path string, table *calc.Table) error {
+	resolved, err := t.paths.Resolve(path, PathWrite)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(table.Columns)
+	w.WriteAll(table.Rows)
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(resolved), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(resolved, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQueryCSVTool(t *testing.T) {
+	workspace := t.TempDir()
+	data := "title;year;venue;citations\nAttention;2017;NeurIPS;120000\nBERT;2019;NAACL;95000\nGPT-3;2020;NeurIPS;30000\nLoRA|v2;2021;ICLR;8000\n"
+	if err := os.WriteFile(filepath.Join(workspace, "papers.csv"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewQueryCSVTool(workspace, true)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"path":      "papers.csv",
+		"group_by":  []interface{}{"venue"},
+		"aggregate": "count, mean(citations) as avg",
+		"sort":      "-count",
+		"output":    "out/venues.csv",
+	})
+	if result.IsError {
+		t.Fatalf("Execute failed: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "| NeurIPS | 2 | 75000 |") || !strings.Contains(result.ForLLM, "3 row(s) from papers.csv (4 data rows)") {
+		t.Errorf("unexpected result:\n%s", result.ForLLM)
+	}
+
+	saved, err := os.ReadFile(filepath.Join(workspace, "out", "venues.csv"))
+	if err != nil {
+		t.Fatalf("result not saved: %v", err)
+	}
+	if !strings.HasPrefix(string(saved), "venue,count,") {
+		t.Errorf("unexpected saved CSV:\n%s", saved)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{
+		"path":   "papers.csv",
+		"where":  "year > 2018",
+		"select": []interface{}{"title", "citations"},
+		"limit":  2.0,
+	})
+	if result.IsError {
+		t.Fatalf("Execute failed: %s", result.ForLLM)
+	}
+	for _, want := range []string{"showing the first 2", "| BERT | 95000 |", "| title | citations |"} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("result missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+	if strings.Contains(result.ForLLM, "LoRA") {
+		t.Errorf("limit not applied:\n%s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"path": "papers.csv", "where": "title == 'LoRA|v2'"})
+	if !strings.Contains(result.ForLLM, `LoRA\|v2`) {
+		t.Errorf("pipes in cells not escaped:\n%s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"path": "papers.csv", "group_by": "country"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "columns are title, year, venue, citations") {
+		t.Errorf("expected an unknown column error, got: %s", result.ForLLM)
+	}
+}
+
+func TestSpecListArg(t *testing.T) {
+	got := specListArg(map[string]interface{}{"s": "count, round(x, 2) as r, contains(a, 'b,c')"}, "s")
+	want := []string{"count", "round(x, 2) as r", "contains(a, 'b,c')"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("specListArg = %q, want %q", got, want)
+	}
+}
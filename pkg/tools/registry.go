@@ -3,6 +3,8 @@ package tools
 import (
 	"context"
 	"fmt"
+	"io"
+	"sort"
 	"sync"
 	"time"
 
@@ -101,12 +103,29 @@ func (r *ToolRegistry) ExecuteWithContext(ctx context.Context, name string, args
 	return result
 }
 
+// sortedTools returns the registered tools ordered by name.
+// A stable order keeps the tool list byte-identical between requests,
+// which provider-side prompt caching depends on. Callers must hold r.mu.
+func (r *ToolRegistry) sortedTools() []Tool {
+	names := make([]string, 0, len(r.tools))
+	for name := range r.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sorted := make([]Tool, 0, len(names))
+	for _, name := range names {
+		sorted = append(sorted, r.tools[name])
+	}
+	return sorted
+}
+
 func (r *ToolRegistry) GetDefinitions() []map[string]interface{} {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	definitions := make([]map[string]interface{}, 0, len(r.tools))
-	for _, tool := range r.tools {
+	for _, tool := range r.sortedTools() {
 		definitions = append(definitions, ToolToSchema(tool))
 	}
 	return definitions
@@ -119,7 +138,7 @@ func (r *ToolRegistry) ToProviderDefs() []providers.ToolDefinition {
 	defer r.mu.RUnlock()
 
 	definitions := make([]providers.ToolDefinition, 0, len(r.tools))
-	for _, tool := range r.tools {
+	for _, tool := range r.sortedTools() {
 		schema := ToolToSchema(tool)
 
 		// Safely extract nested values with type checks
@@ -144,6 +163,29 @@ func (r *ToolRegistry) ToProviderDefs() []providers.ToolDefinition {
 	return definitions
 }
 
+// ResetSession drops the per-conversation state of all tools that keep any.
+func (r *ToolRegistry) ResetSession(channel, chatID string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, tool := range r.tools {
+		if resetter, ok := tool.(SessionResetter); ok {
+			resetter.ResetSession(channel, chatID)
+		}
+	}
+}
+
+// Close shuts down tools that own processes, such as the browser and shell
+// sessions.
+func (r *ToolRegistry) Close() {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, tool := range r.tools {
+		if closer, ok := tool.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
 // List returns a list of all registered tool names.
 func (r *ToolRegistry) List() []string {
 	r.mu.RLock()
@@ -170,7 +212,7 @@ func (r *ToolRegistry) GetSummaries() []string {
 	defer r.mu.RUnlock()
 
 	summaries := make([]string, 0, len(r.tools))
-	for _, tool := range r.tools {
+	for _, tool := range r.sortedTools() {
 		summaries = append(summaries, fmt.Sprintf("- `%s` - %s", tool.Name(), tool.Description()))
 	}
 	return summaries
@@ -12,10 +12,14 @@ import (
 )
 
 // ArXivTool searches for research papers on arXiv
-type ArXivTool struct{}
+type ArXivTool struct {
+	client  *http.Client
+	baseURL string
+}
 
-func NewArXivTool() *ArXivTool {
-	return &ArXivTool{}
+// NewArXivTool creates the arxiv_search tool.
+func NewArXivTool(client *http.Client) *ArXivTool {
+	return &ArXivTool{client: clientOrDefault(client), baseURL: "http://export.arxiv.org/api/query"}
 }
 
 func (t *ArXivTool) Name() string {
@@ -59,16 +63,15 @@ func (t *ArXivTool) Execute(ctx context.Context, args map[string]interface{}) *T
 		}
 	}
 
-	searchURL := fmt.Sprintf("http://export.arxiv.org/api/query?search_query=all:%s&start=0&max_results=%d",
-		url.QueryEscape(query), maxResults)
+	searchURL := fmt.Sprintf("%s?search_query=all:%s&start=0&max_results=%d",
+		t.baseURL, url.QueryEscape(query), maxResults)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
 	if err != nil {
 		return ErrorResult(fmt.Sprintf("failed to create request: %v", err))
 	}
 
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := withTimeout(t.client, 15*time.Second).Do(req)
 	if err != nil {
 		return ErrorResult(fmt.Sprintf("request failed: %v", err))
 	}
@@ -210,10 +213,14 @@ func (t *ArXivTool) parseArXivXML(xml string) ([]arxivResult, error) {
 }
 
 // CrossrefTool searches for paper metadata via Crossref API
-type CrossrefTool struct{}
+type CrossrefTool struct {
+	client  *http.Client
+	baseURL string
+}
 
-func NewCrossrefTool() *CrossrefTool {
-	return &CrossrefTool{}
+// NewCrossrefTool creates the crossref_search tool.
+func NewCrossrefTool(client *http.Client) *CrossrefTool {
+	return &CrossrefTool{client: clientOrDefault(client), baseURL: "https://api.crossref.org/works"}
 }
 
 func (t *CrossrefTool) Name() string {
@@ -261,11 +268,11 @@ func (t *CrossrefTool) Execute(ctx context.Context, args map[string]interface{})
 	var searchURL string
 	if strings.HasPrefix(query, "10.") {
 		// DOI lookup
-		searchURL = fmt.Sprintf("https://api.crossref.org/works/%s", url.PathEscape(query))
+		searchURL = fmt.Sprintf("%s/%s", t.baseURL, url.PathEscape(query))
 	} else {
 		// General search
-		searchURL = fmt.Sprintf("https://api.crossref.org/works?query=%s&rows=%d",
-			url.QueryEscape(query), rows)
+		searchURL = fmt.Sprintf("%s?query=%s&rows=%d",
+			t.baseURL, url.QueryEscape(query), rows)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
@@ -275,8 +282,7 @@ func (t *CrossrefTool) Execute(ctx context.Context, args map[string]interface{})
 
 	req.Header.Set("User-Agent", "Summer AI Assistant (mailto:research@example.com)")
 
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := withTimeout(t.client, 15*time.Second).Do(req)
 	if err != nil {
 		return ErrorResult(fmt.Sprintf("request failed: %v", err))
 	}
@@ -420,10 +426,13 @@ func (t *CrossrefTool) Execute(ctx context.Context, args map[string]interface{})
 }
 
 // DuckDuckGoInstantAnswerTool uses DDG Instant Answer API
-type DuckDuckGoInstantAnswerTool struct{}
+type DuckDuckGoInstantAnswerTool struct {
+	client *http.Client
+}
 
-func NewDuckDuckGoInstantAnswerTool() *DuckDuckGoInstantAnswerTool {
-	return &DuckDuckGoInstantAnswerTool{}
+// NewDuckDuckGoInstantAnswerTool creates the ddg_instant_answer tool.
+func NewDuckDuckGoInstantAnswerTool(client *http.Client) *DuckDuckGoInstantAnswerTool {
+	return &DuckDuckGoInstantAnswerTool{client: clientOrDefault(client)}
 }
 
 func (t *DuckDuckGoInstantAnswerTool) Name() string {
@@ -461,8 +470,7 @@ func (t *DuckDuckGoInstantAnswerTool) Execute(ctx context.Context, args map[stri
 		return ErrorResult(fmt.Sprintf("failed to create request: %v", err))
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := withTimeout(t.client, 10*time.Second).Do(req)
 	if err != nil {
 		return ErrorResult(fmt.Sprintf("request failed: %v", err))
 	}
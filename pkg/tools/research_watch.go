@@ -0,0 +1,562 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/srikesh3005/summer/pkg/bus"
+	"github.com/srikesh3005/summer/pkg/cron"
+	"github.com/srikesh3005/summer/pkg/library"
+	"github.com/srikesh3005/summer/pkg/logger"
+	"github.com/srikesh3005/summer/pkg/providers"
+	"github.com/srikesh3005/summer/pkg/research"
+	"github.com/srikesh3005/summer/pkg/utils"
+)
+
+const (
+	// watchFetchLimit is how many of the newest papers each source is asked
+	// for per run; watchDigestLimit caps the papers listed in one digest.
+	watchFetchLimit  = 25
+	watchDigestLimit = 10
+)
+
+// watchPaper is a paper found by a research watch or a literature review.
+type watchPaper struct {
+	Key      string // "arxiv:<id>", "doi:<doi>" or "url:<url>", used to dedupe
+	Title    string
+	Authors  []string
+	Date     string
+	Venue    string
+	Link     string
+	PDF      string
+	Abstract string
+	Summary  string
+}
+
+var watchStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "of": true, "for": true, "in": true,
+	"on": true, "and": true, "to": true, "with": true, "via": true,
+}
+
+// arxivWatchQuery translates a watch into arXiv search_query syntax.
+func arxivWatchQuery(w *research.Watch) string {
+	switch w.Kind {
+	case research.KindAuthor:
+		return fmt.Sprintf("au:%q", w.Query)
+	case research.KindCategory:
+		return "cat:" + w.Query
+	}
+	return arxivTermsQuery(w.Query)
+}
+
+// arxivTermsQuery requires every significant word of a free-text query.
+func arxivTermsQuery(q string) string {
+	if strings.Contains(q, ":") {
+		return q // already uses field syntax
+	}
+	var terms []string
+	for _, word := range strings.Fields(strings.ToLower(q)) {
+		if !watchStopWords[word] {
+			terms = append(terms, "all:"+word)
+		}
+	}
+	return strings.Join(terms, " AND ")
+}
+
+// recent returns the newest arXiv submissions matching searchQuery, which
+// uses arXiv's field syntax (e.g. "cat:cs.SD" or `au:"Geoffrey Hinton"`).
+func (t *ArXivTool) recent(ctx context.Context, searchQuery string, maxResults int) ([]watchPaper, error) {
+	return t.fetch(ctx, searchQuery, "submittedDate", maxResults)
+}
+
+// fetch returns arXiv papers matching searchQuery ordered by sortBy
+// ("relevance" or "submittedDate").
+func (t *ArXivTool) fetch(ctx context.Context, searchQuery, sortBy string, maxResults int) ([]watchPaper, error) {
+	params := url.Values{
+		"search_query": {searchQuery},
+		"sortBy":       {sortBy},
+		"sortOrder":    {"descending"},
+		"max_results":  {strconv.Itoa(maxResults)},
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", t.baseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	resp, err := withTimeout(t.client, 30*time.Second).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("arXiv request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read arXiv response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("arXiv API error: %d", resp.StatusCode)
+	}
+	results, err := t.parseArXivXML(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse arXiv results: %v", err)
+	}
+	var papers []watchPaper
+	for _, r := range results {
+		id := library.NormalizeArXivID(r.Link)
+		if id == "" {
+			continue
+		}
+		papers = append(papers, watchPaper{
+			Key:      "arxiv:" + id,
+			Title:    strings.Join(strings.Fields(r.Title), " "),
+			Authors:  strings.Split(r.Authors, ", "),
+			Date:     strings.SplitN(r.Published, "T", 2)[0],
+			Venue:    "arXiv",
+			Link:     "https://arxiv.org/abs/" + id,
+			PDF:      r.PDF,
+			Abstract: r.Abstract,
+		})
+	}
+	return papers, nil
+}
+
+// recent returns the works most recently registered with Crossref that
+// match query, or are by query when author is set.
+func (t *CrossrefTool) recent(ctx context.Context, query string, author bool, rows int) ([]watchPaper, error) {
+	params := url.Values{
+		"sort":  {"created"},
+		"order": {"desc"},
+	}
+	if author {
+		params.Set("query.author", query)
+	} else {
+		params.Set("query.bibliographic", query)
+	}
+	return t.works(ctx, params, rows)
+}
+
+// works runs a Crossref works query with the given filter and sort params.
+func (t *CrossrefTool) works(ctx context.Context, params url.Values, rows int) ([]watchPaper, error) {
+	params.Set("rows", strconv.Itoa(rows))
+	params.Set("select", "DOI,title,author,created,issued,container-title,URL,abstract")
+	req, err := http.NewRequestWithContext(ctx, "GET", t.baseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("User-Agent", "Summer AI Assistant (mailto:research@example.com)")
+	resp, err := withTimeout(t.client, 30*time.Second).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Crossref request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Crossref response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Crossref API error: %d", resp.StatusCode)
+	}
+	var apiResp struct {
+		Message struct {
+			Items []struct {
+				DOI    string   `json:"DOI"`
+				Title  []string `json:"title"`
+				Author []struct {
+					Given  string `json:"given"`
+					Family string `json:"family"`
+				} `json:"author"`
+				Created struct {
+					DateTime string `json:"date-time"`
+				} `json:"created"`
+				Issued struct {
+					DateParts [][]int `json:"date-parts"`
+				} `json:"issued"`
+				ContainerTitle []string `json:"container-title"`
+				URL            string   `json:"URL"`
+				Abstract       string   `json:"abstract"`
+			} `json:"items"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse Crossref results: %v", err)
+	}
+	var papers []watchPaper
+	for _, item := range apiResp.Message.Items {
+		if len(item.Title) == 0 || item.DOI == "" {
+			continue
+		}
+		p := watchPaper{
+			Key:      "doi:" + strings.ToLower(item.DOI),
+			Title:    strings.Join(strings.Fields(item.Title[0]), " "),
+			Date:     strings.SplitN(item.Created.DateTime, "T", 2)[0],
+			Link:     item.URL,
+			Abstract: xmlText(item.Abstract),
+		}
+		// Show the publication date; the registration date is only the
+		// fallback, as back catalogues are often registered years later.
+		if parts := item.Issued.DateParts; len(parts) > 0 && len(parts[0]) > 0 && parts[0][0] > 0 {
+			p.Date = fmt.Sprintf("%04d", parts[0][0])
+			for _, n := range parts[0][1:] {
+				p.Date += fmt.Sprintf("-%02d", n)
+			}
+		}
+		if len(item.ContainerTitle) > 0 {
+			p.Venue = item.ContainerTitle[0]
+		}
+		for _, a := range item.Author {
+			p.Authors = append(p.Authors, strings.TrimSpace(a.Given+" "+a.Family))
+		}
+		papers = append(papers, p)
+	}
+	return papers, nil
+}
+
+// ResearchWatcher runs research watches: it fetches the newest papers,
+// drops those already reported, asks the LLM for short summaries and sends
+// the digest to the watch's channel.
+type ResearchWatcher struct {
+	store    *research.Store
+	arxiv    *ArXivTool
+	crossref *CrossrefTool
+	provider providers.LLMProvider
+	model    string
+	msgBus   *bus.MessageBus
+}
+
+// NewResearchWatcher creates a watcher. provider may be nil, in which case
+// digests quote the start of each abstract instead of a summary.
+func NewResearchWatcher(store *research.Store, arxiv *ArXivTool, crossref *CrossrefTool, provider providers.LLMProvider, model string, msgBus *bus.MessageBus) *ResearchWatcher {
+	return &ResearchWatcher{
+		store:    store,
+		arxiv:    arxiv,
+		crossref: crossref,
+		provider: provider,
+		model:    model,
+		msgBus:   msgBus,
+	}
+}
+
+// RunJob runs the watch named by a research cron job.
+func (rw *ResearchWatcher) RunJob(ctx context.Context, job *cron.CronJob) (string, error) {
+	digest, err := rw.Run(ctx, job.Payload.Message)
+	if err != nil {
+		logger.WarnCF("research", "Research watch failed",
+			map[string]interface{}{"watch": job.Payload.Message, "error": err.Error()})
+		return "", err
+	}
+	if digest == "" {
+		return "no new papers", nil
+	}
+	return "ok", nil
+}
+
+// Run checks a watch for new papers and, if there are any, sends and
+// returns the digest. It returns "" when nothing is new.
+func (rw *ResearchWatcher) Run(ctx context.Context, id string) (string, error) {
+	w, err := rw.store.Get(id)
+	if err != nil {
+		return "", err
+	}
+
+	var found []watchPaper
+	var errs []string
+	for _, source := range w.Sources {
+		var papers []watchPaper
+		switch source {
+		case research.SourceArXiv:
+			papers, err = rw.arxiv.recent(ctx, arxivWatchQuery(w), watchFetchLimit)
+		case research.SourceCrossref:
+			papers, err = rw.crossref.recent(ctx, w.Query, w.Kind == research.KindAuthor, watchFetchLimit)
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		found = append(found, papers...)
+	}
+	if len(errs) == len(w.Sources) {
+		return "", fmt.Errorf("all sources failed: %s", strings.Join(errs, "; "))
+	}
+
+	keys := make([]string, len(found))
+	for i, p := range found {
+		keys[i] = p.Key
+	}
+	unseen := make(map[string]bool)
+	for _, k := range w.Unseen(keys) {
+		unseen[k] = true
+	}
+	var fresh []watchPaper
+	for _, p := range found {
+		if unseen[p.Key] {
+			fresh = append(fresh, p)
+			delete(unseen, p.Key) // the same paper may come from both sources
+		}
+	}
+
+	if len(fresh) == 0 {
+		return "", rw.store.MarkSeen(w.ID, nil, time.Now())
+	}
+
+	shown := fresh
+	if len(shown) > watchDigestLimit {
+		shown = shown[:watchDigestLimit]
+	}
+	rw.summarize(ctx, w, shown)
+	digest := formatWatchDigest(w, shown, len(fresh))
+
+	channel, chatID := w.Channel, w.ChatID
+	if channel == "" || chatID == "" {
+		channel, chatID = "cli", "direct"
+	}
+	if rw.msgBus != nil {
+		rw.msgBus.PublishOutbound(bus.OutboundMessage{
+			Channel: channel,
+			ChatID:  chatID,
+			Content: digest,
+		})
+	}
+	// Everything found is marked seen, including papers beyond the digest
+	// limit, so a burst of papers is not repeated in the next digest.
+	if err := rw.store.MarkSeen(w.ID, keys, time.Now()); err != nil {
+		return digest, err
+	}
+	return digest, nil
+}
+
+// digestSummaryFormat is the structured reply summarize asks the LLM for.
+var digestSummaryFormat = &providers.ResponseFormat{
+	Name:        "paper_summaries",
+	Description: "One summary per numbered paper.",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"summaries": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"number":  map[string]interface{}{"type": "integer"},
+						"summary": map[string]interface{}{"type": "string"},
+					},
+					"required":             []interface{}{"number", "summary"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		"required":             []interface{}{"summaries"},
+		"additionalProperties": false,
+	},
+	Strict: true,
+}
+
+// summarize fills in each paper's Summary, using the LLM when available and
+// falling back to the start of the abstract.
+func (rw *ResearchWatcher) summarize(ctx context.Context, w *research.Watch, papers []watchPaper) {
+	if rw.provider != nil {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Summarize each of these new papers in one or two plain sentences for a researcher following %s. "+
+			"Say what the paper does and what it finds, and give each summary with the paper's number.\n\n",
+			w.Label())
+		for i, p := range papers {
+			fmt.Fprintf(&sb, "%d. Title: %s\nAuthors: %s\nAbstract: %s\n\n", i+1, p.Title,
+				strings.Join(p.Authors, ", "), utils.Truncate(p.Abstract, 1500))
+		}
+		var reply struct {
+			Summaries []struct {
+				Number  int    `json:"number"`
+				Summary string `json:"summary"`
+			} `json:"summaries"`
+		}
+		_, err := providers.ChatStructured(ctx, rw.provider, []providers.Message{{Role: "user", Content: sb.String()}}, rw.model,
+			digestSummaryFormat, map[string]interface{}{
+				"max_tokens":  1024,
+				"temperature": 0.3,
+			}, &reply)
+		if err != nil {
+			logger.WarnCF("research", "Digest summaries failed, using abstracts",
+				map[string]interface{}{"watch": w.ID, "error": err.Error()})
+		} else {
+			for _, s := range reply.Summaries {
+				if s.Number >= 1 && s.Number <= len(papers) {
+					papers[s.Number-1].Summary = strings.TrimSpace(s.Summary)
+				}
+			}
+		}
+	}
+	for i := range papers {
+		if papers[i].Summary == "" {
+			papers[i].Summary = utils.Truncate(papers[i].Abstract, 200)
+		}
+	}
+}
+
+func formatWatchDigest(w *research.Watch, papers []watchPaper, total int) string {
+	var lines []string
+	lines = append(lines, fmt.Sprintf("📚 New papers for %s (%d)\n", w.Label(), total))
+	for i, p := range papers {
+		authors := p.Authors
+		if len(authors) > 4 {
+			authors = append(authors[:3:3], "et al.")
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, p.Title))
+		meta := strings.Join(authors, ", ")
+		if p.Venue != "" {
+			meta += " — " + p.Venue
+		}
+		if p.Date != "" {
+			meta += " (" + p.Date + ")"
+		}
+		lines = append(lines, "   "+meta)
+		if p.Summary != "" {
+			lines = append(lines, "   "+p.Summary)
+		}
+		if p.Link != "" {
+			lines = append(lines, "   "+p.Link)
+		}
+		lines = append(lines, "")
+	}
+	if total > len(papers) {
+		lines = append(lines, fmt.Sprintf("…and %d more not shown.", total-len(papers)))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// ResearchWatchTool lets the agent subscribe the current conversation to
+// research alerts.
+type ResearchWatchTool struct {
+	store       *research.Store
+	cronService *cron.CronService
+	channel     string
+	chatID      string
+	mu          sync.RWMutex
+}
+
+func NewResearchWatchTool(store *research.Store, cronService *cron.CronService) *ResearchWatchTool {
+	return &ResearchWatchTool{store: store, cronService: cronService}
+}
+
+func (t *ResearchWatchTool) Name() string {
+	return "research_watch"
+}
+
+func (t *ResearchWatchTool) Description() string {
+	return "Subscribe this conversation to research alerts: a scheduled digest of new papers on a topic, by an author or in an arXiv category " +
+		"(from arXiv and Crossref), with short summaries. Each paper is reported once. Actions: add, list, remove."
+}
+
+func (t *ResearchWatchTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"add", "list", "remove"},
+				"description": "Action to perform",
+			},
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "For add: the topic (e.g. 'diffusion models for audio'), author name or arXiv category (e.g. 'cs.SD')",
+			},
+			"kind": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{research.KindTopic, research.KindAuthor, research.KindCategory},
+				"description": "For add: what query is (default: topic)",
+			},
+			"sources": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string", "enum": []string{research.SourceArXiv, research.SourceCrossref}},
+				"description": "For add: sources to poll (default: arxiv and crossref; categories use arxiv only)",
+			},
+			"cron_expr": map[string]interface{}{
+				"type":        "string",
+				"description": "For add: when to send the digest (default: '0 8 * * *', daily at 8am)",
+			},
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "For remove: the watch ID",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+// SetContext sets the conversation that new watches report to.
+func (t *ResearchWatchTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.channel = channel
+	t.chatID = chatID
+}
+
+func (t *ResearchWatchTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	action, _ := args["action"].(string)
+	switch action {
+	case "add":
+		return t.add(args)
+	case "list":
+		return t.list()
+	case "remove":
+		id, _ := args["id"].(string)
+		if id == "" {
+			return ErrorResult("id is required for remove")
+		}
+		w, err := research.Unsubscribe(t.store, t.cronService, id)
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("failed to remove watch: %v", err))
+		}
+		return SilentResult(fmt.Sprintf("Stopped watching %s (id: %s)", w.Label(), w.ID))
+	default:
+		return ErrorResult(fmt.Sprintf("unknown action: %s", action))
+	}
+}
+
+func (t *ResearchWatchTool) add(args map[string]interface{}) *ToolResult {
+	t.mu.RLock()
+	channel, chatID := t.channel, t.chatID
+	t.mu.RUnlock()
+	if channel == "" || chatID == "" {
+		return ErrorResult("no session context (channel/chat_id not set). Use this tool in an active conversation.")
+	}
+
+	w := &research.Watch{
+		Sources: stringListArg(args, "sources"),
+		Channel: channel,
+		ChatID:  chatID,
+	}
+	w.Query, _ = args["query"].(string)
+	w.Kind, _ = args["kind"].(string)
+	w.Schedule, _ = args["cron_expr"].(string)
+	if err := research.Subscribe(t.store, t.cronService, w); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to add watch: %v", err))
+	}
+	return SilentResult(fmt.Sprintf("Watching %s on %s (id: %s, schedule: %s)",
+		w.Label(), strings.Join(w.Sources, ", "), w.ID, w.Schedule))
+}
+
+func (t *ResearchWatchTool) list() *ToolResult {
+	watches, err := t.store.List()
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to list watches: %v", err))
+	}
+	if len(watches) == 0 {
+		return SilentResult("No research watches")
+	}
+	var sb strings.Builder
+	sb.WriteString("Research watches:\n")
+	for _, w := range watches {
+		fmt.Fprintf(&sb, "- %s (id: %s, %s, sources: %s, %d papers seen", w.Label(), w.ID, w.Schedule,
+			strings.Join(w.Sources, ", "), len(w.Seen))
+		if !w.LastRunAt.IsZero() {
+			fmt.Fprintf(&sb, ", last run %s", w.LastRunAt.Format("2006-01-02 15:04"))
+		}
+		sb.WriteString(")\n")
+	}
+	return SilentResult(sb.String())
+}
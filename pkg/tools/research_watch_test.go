@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/srikesh3005/summer/pkg/bus"
+	"github.com/srikesh3005/summer/pkg/cron"
+	"github.com/srikesh3005/summer/pkg/providers"
+	"github.com/srikesh3005/summer/pkg/research"
+)
+
+// digestProvider answers summary prompts with structured summaries, or fails.
+type digestProvider struct {
+	mu      sync.Mutex
+	prompts []string
+	fail    bool
+}
+
+func (p *digestProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (*providers.LLMResponse, error) {
+	p.mu.Lock()
+	p.prompts = append(p.prompts, messages[0].Content)
+	p.mu.Unlock()
+	if p.fail {
+		return nil, errors.New("provider unavailable")
+	}
+	return &providers.LLMResponse{Content: "```json\n" + `{"summaries": [{"number": 1, "summary": "Distills audio diffusion into one step."},` +
+		` {"number": 3, "summary": " Score-based speech enhancement."}, {"number": 7, "summary": "Out of range."}]}` + "\n```"}, nil
+}
+
+func (p *digestProvider) GetDefaultModel() string { return "test-model" }
+
+// watchRoutes maps the arXiv and Crossref endpoints onto their fixtures.
+var watchRoutes = map[string]string{"/arxiv": "arxiv.xml", "/works": "crossref.json"}
+
+func newWatchServer(t *testing.T) (*ArXivTool, *CrossrefTool, *fixtureServer) {
+	t.Helper()
+	server := newFixtureServer(t, "research_watch", pathRoutes(watchRoutes))
+	arxiv := NewArXivTool(testOutboundClient())
+	arxiv.baseURL = server.URL + "/arxiv"
+	crossref := NewCrossrefTool(testOutboundClient())
+	crossref.baseURL = server.URL + "/works"
+	return arxiv, crossref, server
+}
+
+func TestArXivWatchQuery(t *testing.T) {
+	cases := []struct {
+		watch research.Watch
+		want  string
+	}{
+		{research.Watch{Kind: research.KindTopic, Query: "Diffusion models for audio"}, "all:diffusion AND all:models AND all:audio"},
+		{research.Watch{Kind: research.KindTopic, Query: "ti:transformer AND cat:cs.CL"}, "ti:transformer AND cat:cs.CL"},
+		{research.Watch{Kind: research.KindAuthor, Query: "Geoffrey Hinton"}, `au:"Geoffrey Hinton"`},
+		{research.Watch{Kind: research.KindCategory, Query: "cs.SD"}, "cat:cs.SD"},
+	}
+	for _, tc := range cases {
+		if got := arxivWatchQuery(&tc.watch); got != tc.want {
+			t.Errorf("arxivWatchQuery(%+v) = %q, want %q", tc.watch, got, tc.want)
+		}
+	}
+}
+
+func TestResearchWatcher_Run(t *testing.T) {
+	arxiv, crossref, server := newWatchServer(t)
+	store := research.NewStore(t.TempDir())
+	w := &research.Watch{Query: "diffusion models for audio", Channel: "telegram", ChatID: "42"}
+	if err := store.Add(w); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	provider := &digestProvider{}
+	msgBus := bus.NewMessageBus()
+	watcher := NewResearchWatcher(store, arxiv, crossref, provider, "test-model", msgBus)
+
+	digest, err := watcher.Run(context.Background(), w.ID)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	queries := server.queries()
+	arxivQuery, crossrefQuery := queries[0], queries[1]
+	if arxivQuery.Get("search_query") != "all:diffusion AND all:models AND all:audio" || arxivQuery.Get("sortBy") != "submittedDate" {
+		t.Errorf("unexpected arXiv query: %v", arxivQuery)
+	}
+	if crossrefQuery.Get("query.bibliographic") != "diffusion models for audio" || crossrefQuery.Get("sort") != "created" {
+		t.Errorf("unexpected Crossref query: %v", crossrefQuery)
+	}
+
+	for _, want := range []string{
+		`📚 New papers for "diffusion models for audio" (3)`,
+		"1. Fast Audio Diffusion with Consistency Distillation\n   Ada Lovelace, Alan Turing — arXiv (2024-10-02)\n   Distills audio diffusion into one step.\n   https://arxiv.org/abs/2410.01234\n",
+		// Paper 2 has no LLM line and falls back to its abstract.
+		"2. Music Generation by Latent Diffusion\n   Grace Hopper — arXiv (2024-10-01)\n   A latent diffusion model for music.\n",
+		"3. Diffusion Models for Speech Enhancement\n   Claude Shannon — IEEE/ACM Transactions on Audio, Speech, and Language Processing (2024-09)\n   Score-based speech enhancement.\n",
+	} {
+		if !strings.Contains(digest, want) {
+			t.Errorf("digest missing %q:\n%s", want, digest)
+		}
+	}
+	if len(provider.prompts) != 1 || !strings.Contains(provider.prompts[0], "2. Title: Music Generation by Latent Diffusion") {
+		t.Errorf("unexpected prompts: %v", provider.prompts)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, ok := msgBus.SubscribeOutbound(ctx)
+	if !ok || msg.Channel != "telegram" || msg.ChatID != "42" || msg.Content != digest {
+		t.Errorf("digest not delivered: %+v", msg)
+	}
+
+	stored, _ := store.Get(w.ID)
+	if len(stored.Seen) != 3 || stored.LastRunAt.IsZero() {
+		t.Errorf("seen set not updated: %v", stored.Seen)
+	}
+
+	// Nothing is new on the second run: no digest and no LLM call.
+	digest, err = watcher.Run(context.Background(), w.ID)
+	if err != nil || digest != "" {
+		t.Errorf("second Run = %q, %v", digest, err)
+	}
+	if len(provider.prompts) != 1 {
+		t.Errorf("LLM called without new papers")
+	}
+}
+
+func TestResearchWatcher_FallbackAndErrors(t *testing.T) {
+	arxiv, crossref, _ := newWatchServer(t)
+	store := research.NewStore(t.TempDir())
+	w := &research.Watch{Query: "cs.SD", Kind: research.KindCategory}
+	if err := store.Add(w); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	watcher := NewResearchWatcher(store, arxiv, crossref, &digestProvider{fail: true}, "test-model", bus.NewMessageBus())
+	digest, err := watcher.Run(context.Background(), w.ID)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !strings.Contains(digest, "📚 New papers for arXiv cs.SD (2)") || !strings.Contains(digest, "We distill an audio diffusion model") {
+		t.Errorf("abstract fallback missing:\n%s", digest)
+	}
+
+	// A source that fails entirely is an error.
+	arxiv.baseURL += "/missing"
+	broken := &research.Watch{Query: "cs.LG", Kind: research.KindCategory}
+	store.Add(broken)
+	if _, err := watcher.RunJob(context.Background(), &cron.CronJob{Payload: cron.CronPayload{Kind: research.JobKind, Message: broken.ID}}); err == nil {
+		t.Error("expected an error when every source fails")
+	}
+}
+
+func TestResearchWatchTool(t *testing.T) {
+	workspace := t.TempDir()
+	store := research.NewStore(workspace)
+	cs := cron.NewCronService(filepath.Join(workspace, "cron", "jobs.json"), nil)
+	tool := NewResearchWatchTool(store, cs)
+	ctx := context.Background()
+
+	if result := tool.Execute(ctx, map[string]interface{}{"action": "add", "query": "x"}); !result.IsError {
+		t.Error("add without a conversation should fail")
+	}
+
+	tool.SetContext("slack", "C123")
+	result := tool.Execute(ctx, map[string]interface{}{"action": "add", "query": "Yoshua Bengio", "kind": "author", "sources": []interface{}{"arxiv"}})
+	if result.IsError {
+		t.Fatalf("add: %s", result.ForLLM)
+	}
+	watches, _ := store.List()
+	if len(watches) != 1 || watches[0].Channel != "slack" || watches[0].ChatID != "C123" || watches[0].JobID == "" {
+		t.Fatalf("unexpected watches: %+v", watches)
+	}
+	jobs := cs.ListJobs(true)
+	if len(jobs) != 1 || jobs[0].Payload.Kind != research.JobKind || jobs[0].Payload.Message != watches[0].ID || jobs[0].Schedule.Expr != research.DefaultSchedule {
+		t.Errorf("unexpected cron jobs: %+v", jobs)
+	}
+
+	if result := tool.Execute(ctx, map[string]interface{}{"action": "add", "query": "yoshua bengio", "kind": "author"}); !result.IsError {
+		t.Error("duplicate watch should be rejected")
+	}
+	if result := tool.Execute(ctx, map[string]interface{}{"action": "add", "query": "x", "cron_expr": "every day"}); !result.IsError {
+		t.Error("invalid cron expression should be rejected")
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "list"})
+	if !strings.Contains(result.ForLLM, "author Yoshua Bengio (id: "+watches[0].ID) {
+		t.Errorf("list: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "remove", "id": watches[0].ID})
+	if result.IsError {
+		t.Fatalf("remove: %s", result.ForLLM)
+	}
+	if watches, _ := store.List(); len(watches) != 0 || len(cs.ListJobs(true)) != 0 {
+		t.Errorf("watch or job left after remove")
+	}
+}
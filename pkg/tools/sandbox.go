@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// Sandbox modes accepted by NewSandbox.
+const (
+	SandboxOff      = "off"
+	SandboxAuto     = "auto"
+	SandboxBwrap    = "bwrap"
+	SandboxLandlock = "landlock"
+)
+
+// SandboxOptions configures command isolation for the exec tool.
+type SandboxOptions struct {
+	// Mode is one of SandboxOff, SandboxAuto (bubblewrap if installed,
+	// otherwise Landlock), SandboxBwrap or SandboxLandlock.
+	Mode string
+	// Workspace is the only directory commands may write to, besides a
+	// private temporary directory and WritablePaths.
+	Workspace string
+	// WritablePaths are further directories commands may write to;
+	// directories that do not exist are skipped.
+	WritablePaths []string
+	// AllowNetwork keeps network access; by default it is cut off.
+	AllowNetwork bool
+	// MemoryMB limits the address space of each process (0 = unlimited).
+	MemoryMB int
+	// CPUSeconds limits CPU time of each process (0 = unlimited).
+	CPUSeconds int
+	// MaxProcesses limits the number of processes of the user
+	// (0 = unlimited). It does not apply to root.
+	MaxProcesses int
+}
+
+// Sandbox runs shell commands in an isolated environment: the filesystem is
+// read-only except for the workspace, the network is unavailable unless
+// allowed, and resource limits apply.
+type Sandbox interface {
+	// Name identifies the mechanism, e.g. "bwrap" or "landlock".
+	Name() string
+	// Command returns a command that runs `sh -c command` in dir inside the
+	// sandbox. cleanup must be called once the command has finished.
+	Command(ctx context.Context, command, dir string) (cmd *exec.Cmd, cleanup func(), err error)
+}
+
+// NewSandbox returns the sandbox selected by opts.Mode, or nil for
+// SandboxOff. It fails if the requested mechanism is unavailable, so callers
+// can fall back to the command guard.
+func NewSandbox(opts SandboxOptions) (Sandbox, error) {
+	switch opts.Mode {
+	case "", SandboxOff:
+		return nil, nil
+	case SandboxAuto, SandboxBwrap, SandboxLandlock:
+	default:
+		return nil, fmt.Errorf("unknown sandbox mode %q (want off, auto, bwrap or landlock)", opts.Mode)
+	}
+	if opts.Workspace == "" {
+		return nil, fmt.Errorf("sandbox requires a workspace")
+	}
+	return newPlatformSandbox(opts)
+}
+
+// sandboxLimits are the resource limits applied by the sandbox trampoline.
+type sandboxLimits struct {
+	MemoryMB     int `json:"memory_mb,omitempty"`
+	CPUSeconds   int `json:"cpu_seconds,omitempty"`
+	MaxProcesses int `json:"max_processes,omitempty"`
+}
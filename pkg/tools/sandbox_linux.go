@@ -0,0 +1,407 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// sandboxChildEnv carries the sandboxChildSpec to the re-executed binary.
+// When it is set, init turns the process into a trampoline that applies the
+// limits and Landlock rules to itself and then execs the shell, since Go
+// cannot run code between fork and exec.
+const sandboxChildEnv = "SUMMER_SANDBOX_CHILD"
+
+// Landlock syscalls and constants (<linux/landlock.h>); the syscall numbers
+// are the same on every architecture.
+const (
+	sysLandlockCreateRuleset = 444
+	sysLandlockAddRule       = 445
+	sysLandlockRestrictSelf  = 446
+
+	landlockCreateRulesetVersion = 1 << 0
+	landlockRulePathBeneath      = 1
+	prSetNoNewPrivs              = 38
+	rlimitNproc                  = 6
+	oPath                        = 0x200000
+
+	llFSExecute     = 1 << 0
+	llFSWriteFile   = 1 << 1
+	llFSReadFile    = 1 << 2
+	llFSReadDir     = 1 << 3
+	llFSRefer       = 1 << 13 // ABI 2
+	llFSTruncate    = 1 << 14 // ABI 3
+	llFSIoctlDev    = 1 << 15 // ABI 5
+	llFSAllV1       = 1<<13 - 1
+	llNetBindTCP    = 1 << 0 // ABI 4
+	llNetConnectTCP = 1 << 1 // ABI 4
+)
+
+// sandboxDevices stay writable so commands can redirect to /dev/null etc.
+var sandboxDevices = []string{"/dev/null", "/dev/zero", "/dev/full", "/dev/random", "/dev/urandom", "/dev/tty"}
+
+type sandboxChildSpec struct {
+	Limits   sandboxLimits `json:"limits"`
+	Landlock *landlockSpec `json:"landlock,omitempty"`
+}
+
+type landlockSpec struct {
+	Writable    []string `json:"writable"`
+	DenyNetwork bool     `json:"deny_network,omitempty"`
+}
+
+func init() {
+	spec, ok := os.LookupEnv(sandboxChildEnv)
+	if !ok {
+		return
+	}
+	os.Unsetenv(sandboxChildEnv)
+	err := runSandboxChild(spec, os.Args[1:])
+	fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+	os.Exit(126)
+}
+
+// runSandboxChild applies spec to the current process and replaces it with
+// `sh -c command`. It only returns on error.
+func runSandboxChild(rawSpec string, args []string) error {
+	var spec sandboxChildSpec
+	if err := json.Unmarshal([]byte(rawSpec), &spec); err != nil {
+		return fmt.Errorf("invalid sandbox spec: %v", err)
+	}
+	if len(args) != 2 || args[0] != "-c" {
+		return fmt.Errorf("usage: -c command")
+	}
+
+	if err := applyLimits(spec.Limits); err != nil {
+		return err
+	}
+	if spec.Landlock != nil {
+		if err := applyLandlock(spec.Landlock); err != nil {
+			return err
+		}
+	}
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(sh, []string{"sh", "-c", args[1]}, os.Environ())
+}
+
+func applyLimits(l sandboxLimits) error {
+	set := func(resource int, value uint64, name string) error {
+		if err := syscall.Setrlimit(resource, &syscall.Rlimit{Cur: value, Max: value}); err != nil {
+			return fmt.Errorf("failed to set %s limit: %v", name, err)
+		}
+		return nil
+	}
+	if l.MemoryMB > 0 {
+		if err := set(syscall.RLIMIT_AS, uint64(l.MemoryMB)<<20, "memory"); err != nil {
+			return err
+		}
+	}
+	if l.CPUSeconds > 0 {
+		if err := set(syscall.RLIMIT_CPU, uint64(l.CPUSeconds), "CPU"); err != nil {
+			return err
+		}
+	}
+	if l.MaxProcesses > 0 {
+		if err := set(rlimitNproc, uint64(l.MaxProcesses), "process"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// landlockABI returns the kernel's Landlock ABI version, or 0 if Landlock is
+// unavailable.
+func landlockABI() int {
+	v, _, errno := syscall.Syscall(sysLandlockCreateRuleset, 0, 0, landlockCreateRulesetVersion)
+	if errno != 0 {
+		return 0
+	}
+	return int(v)
+}
+
+func landlockFSAccess(abi int) uint64 {
+	access := uint64(llFSAllV1)
+	if abi >= 2 {
+		access |= llFSRefer
+	}
+	if abi >= 3 {
+		access |= llFSTruncate
+	}
+	if abi >= 5 {
+		access |= llFSIoctlDev
+	}
+	return access
+}
+
+// applyLandlock restricts the process to reading and executing everywhere
+// and writing only below spec.Writable.
+func applyLandlock(spec *landlockSpec) error {
+	abi := landlockABI()
+	if abi < 1 {
+		return fmt.Errorf("landlock is not supported by this kernel")
+	}
+	fsAll := landlockFSAccess(abi)
+
+	// struct landlock_ruleset_attr { __u64 handled_access_fs; __u64 handled_access_net; }
+	attr := [2]uint64{fsAll, 0}
+	attrSize := unsafe.Sizeof(attr[0])
+	if abi >= 4 {
+		attrSize = unsafe.Sizeof(attr)
+		if spec.DenyNetwork {
+			attr[1] = llNetBindTCP | llNetConnectTCP
+		}
+	}
+	fd, _, errno := syscall.Syscall(sysLandlockCreateRuleset, uintptr(unsafe.Pointer(&attr)), attrSize, 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to create landlock ruleset: %v", errno)
+	}
+	defer syscall.Close(int(fd))
+
+	if err := landlockAllow(int(fd), "/", llFSExecute|llFSReadFile|llFSReadDir); err != nil {
+		return err
+	}
+	for _, dir := range spec.Writable {
+		if err := landlockAllow(int(fd), dir, fsAll); err != nil {
+			return err
+		}
+	}
+	devAccess := uint64(llFSReadFile | llFSWriteFile)
+	if abi >= 3 {
+		devAccess |= llFSTruncate
+	}
+	if abi >= 5 {
+		devAccess |= llFSIoctlDev
+	}
+	for _, dev := range sandboxDevices {
+		if _, err := os.Stat(dev); err == nil {
+			if err := landlockAllow(int(fd), dev, devAccess); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, _, errno := syscall.Syscall6(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0, 0, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to set no_new_privs: %v", errno)
+	}
+	if _, _, errno := syscall.Syscall(sysLandlockRestrictSelf, fd, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to enforce landlock ruleset: %v", errno)
+	}
+	return nil
+}
+
+// landlockAllow adds a path_beneath rule granting access below path.
+func landlockAllow(rulesetFD int, path string, access uint64) error {
+	pathFD, err := syscall.Open(path, oPath|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s for landlock: %v", path, err)
+	}
+	defer syscall.Close(pathFD)
+
+	// struct landlock_path_beneath_attr { __u64 allowed_access; __s32 parent_fd; } __attribute__((packed))
+	var rule [12]byte
+	*(*uint64)(unsafe.Pointer(&rule[0])) = access
+	*(*int32)(unsafe.Pointer(&rule[8])) = int32(pathFD)
+	if _, _, errno := syscall.Syscall6(sysLandlockAddRule, uintptr(rulesetFD), landlockRulePathBeneath,
+		uintptr(unsafe.Pointer(&rule[0])), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to add landlock rule for %s: %v", path, errno)
+	}
+	return nil
+}
+
+func newPlatformSandbox(opts SandboxOptions) (Sandbox, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate executable for sandbox: %v", err)
+	}
+	workspace, err := filepath.Abs(opts.Workspace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve workspace path: %v", err)
+	}
+	if err := os.MkdirAll(workspace, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %v", err)
+	}
+	var writable []string
+	for _, p := range opts.WritablePaths {
+		if resolved, err := filepath.EvalSymlinks(p); err == nil {
+			if info, err := os.Stat(resolved); err == nil && info.IsDir() {
+				writable = append(writable, resolved)
+			}
+		}
+	}
+	base := sandboxBase{
+		self:         self,
+		workspace:    workspace,
+		writable:     writable,
+		allowNetwork: opts.AllowNetwork,
+		limits: sandboxLimits{
+			MemoryMB:     opts.MemoryMB,
+			CPUSeconds:   opts.CPUSeconds,
+			MaxProcesses: opts.MaxProcesses,
+		},
+	}
+
+	if opts.Mode == SandboxBwrap || opts.Mode == SandboxAuto {
+		if bwrap, err := exec.LookPath("bwrap"); err == nil {
+			return &bwrapSandbox{sandboxBase: base, bwrap: bwrap}, nil
+		} else if opts.Mode == SandboxBwrap {
+			return nil, fmt.Errorf("bubblewrap (bwrap) is not installed")
+		}
+	}
+
+	abi := landlockABI()
+	if abi < 1 {
+		return nil, fmt.Errorf("no sandbox available: bwrap is not installed and the kernel does not support landlock")
+	}
+	if !opts.AllowNetwork {
+		if err := probeNetNamespace(); err != nil {
+			return nil, fmt.Errorf("landlock sandbox cannot cut off the network: %v (enable unprivileged user namespaces or set allow_network)", err)
+		}
+	}
+	return &landlockSandbox{sandboxBase: base, abi: abi}, nil
+}
+
+// netNamespaceAttr runs a child in new user and network namespaces, mapping
+// the caller's IDs so the child keeps its own user.
+func netNamespaceAttr() *syscall.SysProcAttr {
+	uid, gid := os.Getuid(), os.Getgid()
+	return &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}},
+	}
+}
+
+// probeNetNamespace starts a trivial process the way landlock commands
+// without network access are started. It fails where unprivileged user
+// namespaces are disabled, as with kernel.unprivileged_userns_clone=0 and
+// in some container runtimes.
+func probeNetNamespace() error {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(sh, "-c", "exit 0")
+	cmd.SysProcAttr = netNamespaceAttr()
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to create a user namespace: %v", err)
+	}
+	return nil
+}
+
+type sandboxBase struct {
+	self         string
+	workspace    string
+	writable     []string
+	allowNetwork bool
+	limits       sandboxLimits
+}
+
+// trampoline returns the command re-executing this binary with spec.
+func (b *sandboxBase) trampoline(ctx context.Context, name string, args []string, spec sandboxChildSpec) (*exec.Cmd, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), sandboxChildEnv+"="+string(data))
+	return cmd, nil
+}
+
+// bwrapSandbox isolates commands with bubblewrap: a read-only view of the
+// root filesystem, the workspace bound read-write, private /tmp, /proc and
+// /dev, and new PID, IPC and (unless allowed) network namespaces.
+type bwrapSandbox struct {
+	sandboxBase
+	bwrap string
+}
+
+func (s *bwrapSandbox) Name() string { return SandboxBwrap }
+
+func (s *bwrapSandbox) args(command, dir string) []string {
+	args := []string{
+		"--die-with-parent",
+		"--new-session",
+		"--unshare-user-try",
+		"--unshare-ipc",
+		"--unshare-pid",
+		"--unshare-uts",
+		"--unshare-cgroup-try",
+	}
+	if !s.allowNetwork {
+		args = append(args, "--unshare-net")
+	}
+	args = append(args,
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+		// The executable and workspace may live under /tmp.
+		"--ro-bind", s.self, s.self,
+		"--bind", s.workspace, s.workspace,
+	)
+	for _, p := range s.writable {
+		args = append(args, "--bind", p, p)
+	}
+	args = append(args,
+		"--chdir", dir,
+		"--", s.self, "-c", command,
+	)
+	return args
+}
+
+func (s *bwrapSandbox) Command(ctx context.Context, command, dir string) (*exec.Cmd, func(), error) {
+	cmd, err := s.trampoline(ctx, s.bwrap, s.args(command, dir), sandboxChildSpec{Limits: s.limits})
+	if err != nil {
+		return nil, nil, err
+	}
+	return cmd, func() {}, nil
+}
+
+// landlockSandbox confines commands with Landlock: everything is readable and
+// executable, only the workspace and a per-command temporary directory are
+// writable. Without network access commands run in a new, empty network
+// namespace, which also cuts off UDP and abstract Unix sockets; on ABI 4+
+// Landlock's TCP rules are added as a second layer.
+type landlockSandbox struct {
+	sandboxBase
+	abi int
+}
+
+func (s *landlockSandbox) Name() string { return SandboxLandlock }
+
+func (s *landlockSandbox) Command(ctx context.Context, command, dir string) (*exec.Cmd, func(), error) {
+	tmp, err := os.MkdirTemp("", "summer-sandbox-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create sandbox temp dir: %v", err)
+	}
+	cleanup := func() { os.RemoveAll(tmp) }
+
+	spec := sandboxChildSpec{
+		Limits: s.limits,
+		Landlock: &landlockSpec{
+			Writable:    append([]string{s.workspace, tmp}, s.writable...),
+			DenyNetwork: !s.allowNetwork && s.abi >= 4,
+		},
+	}
+	cmd, err := s.trampoline(ctx, s.self, []string{"-c", command}, spec)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	cmd.Dir = dir
+	cmd.Env = append(cmd.Env, "TMPDIR="+tmp)
+
+	if !s.allowNetwork {
+		cmd.SysProcAttr = netNamespaceAttr()
+	}
+	return cmd, cleanup, nil
+}
@@ -0,0 +1,210 @@
+package tools
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLandlockExec(t *testing.T, opts SandboxOptions) (*ExecTool, string) {
+	t.Helper()
+	if landlockABI() < 1 {
+		t.Skip("landlock is not supported by this kernel")
+	}
+	if !opts.AllowNetwork {
+		if err := probeNetNamespace(); err != nil {
+			t.Skipf("no-network mode is unavailable: %v", err)
+		}
+	}
+	workspace := t.TempDir()
+	opts.Mode = SandboxLandlock
+	opts.Workspace = workspace
+	sandbox, err := NewSandbox(opts)
+	if err != nil {
+		t.Fatalf("NewSandbox: %v", err)
+	}
+	if sandbox.Name() != SandboxLandlock {
+		t.Fatalf("expected landlock sandbox, got %s", sandbox.Name())
+	}
+	tool := NewExecTool(workspace, true)
+	tool.SetSandbox(sandbox)
+	return tool, workspace
+}
+
+func TestSandbox_LandlockFilesystem(t *testing.T) {
+	tool, workspace := newTestLandlockExec(t, SandboxOptions{})
+	outside := t.TempDir()
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]interface{}{"command": "echo hi > note.txt && cat note.txt"})
+	if result.IsError || !strings.Contains(result.ForLLM, "hi") {
+		t.Fatalf("expected write inside workspace to succeed, got: %s", result.ForLLM)
+	}
+	if _, err := os.Stat(filepath.Join(workspace, "note.txt")); err != nil {
+		t.Errorf("expected note.txt in workspace: %v", err)
+	}
+
+	// Reading outside the workspace is allowed; the guard's path scan would
+	// have refused this command.
+	result = tool.Execute(ctx, map[string]interface{}{"command": "cd /; head -c 4 etc/hostname >/dev/null && echo readable"})
+	if result.IsError || !strings.Contains(result.ForLLM, "readable") {
+		t.Errorf("expected read-only access outside the workspace, got: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"command": "cd " + outside + "; echo x > escaped.txt"})
+	if !result.IsError {
+		t.Errorf("expected write outside the workspace to fail, got: %s", result.ForLLM)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "escaped.txt")); err == nil {
+		t.Errorf("sandboxed command wrote outside the workspace")
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"command": `echo tmp > "$TMPDIR/scratch" && cat "$TMPDIR/scratch"`})
+	if result.IsError || !strings.Contains(result.ForLLM, "tmp") {
+		t.Errorf("expected private TMPDIR to be writable, got: %s", result.ForLLM)
+	}
+}
+
+func TestSandbox_LandlockLimits(t *testing.T) {
+	tool, _ := newTestLandlockExec(t, SandboxOptions{MemoryMB: 512, CPUSeconds: 7})
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"command": "ulimit -t; ulimit -v"})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	lines := strings.Fields(result.ForLLM)
+	if len(lines) < 2 || lines[0] != "7" || lines[1] != "524288" {
+		t.Errorf("expected CPU limit 7s and 524288 KiB address space, got: %q", result.ForLLM)
+	}
+}
+
+func TestSandbox_LandlockNetwork(t *testing.T) {
+	curl, err := exec.LookPath("curl")
+	if err != nil {
+		t.Skip("curl not installed")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("HTTP/1.0 200 OK\r\nContent-Length: 2\r\n\r\nok"))
+			conn.Close()
+		}
+	}()
+	command := curl + " -s -m 5 http://" + ln.Addr().String() + "/"
+
+	denied, _ := newTestLandlockExec(t, SandboxOptions{})
+	if result := denied.Execute(context.Background(), map[string]interface{}{"command": command}); !result.IsError {
+		t.Errorf("expected network access to be denied, got: %s", result.ForLLM)
+	}
+
+	allowed, _ := newTestLandlockExec(t, SandboxOptions{AllowNetwork: true})
+	if result := allowed.Execute(context.Background(), map[string]interface{}{"command": command}); result.IsError || !strings.Contains(result.ForLLM, "ok") {
+		t.Errorf("expected network access with allow_network, got: %s", result.ForLLM)
+	}
+}
+
+func TestSandbox_LandlockNetworkUDP(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	command := "echo started; " + bash + " -c 'echo leak > /dev/udp/127.0.0.1/" + strings.Split(conn.LocalAddr().String(), ":")[1] + "'"
+	received := func() bool {
+		conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+		buf := make([]byte, 16)
+		n, _, err := conn.ReadFrom(buf)
+		return err == nil && strings.HasPrefix(string(buf[:n]), "leak")
+	}
+
+	// Landlock only covers TCP; the network namespace must stop UDP too.
+	denied, _ := newTestLandlockExec(t, SandboxOptions{})
+	result := denied.Execute(context.Background(), map[string]interface{}{"command": command})
+	if !strings.Contains(result.ForLLM, "started") || !strings.Contains(result.ForLLM, "/dev/udp") {
+		t.Errorf("expected the command to run and fail to send, got: %s", result.ForLLM)
+	}
+	if received() {
+		t.Error("expected UDP to be blocked without allow_network")
+	}
+
+	allowed, _ := newTestLandlockExec(t, SandboxOptions{AllowNetwork: true})
+	if result := allowed.Execute(context.Background(), map[string]interface{}{"command": command}); result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if !received() {
+		t.Error("expected UDP to work with allow_network")
+	}
+}
+
+func TestSandbox_BwrapArgs(t *testing.T) {
+	s := &bwrapSandbox{
+		sandboxBase: sandboxBase{self: "/usr/bin/summer", workspace: "/home/u/ws", writable: []string{"/data/shared"}},
+		bwrap:       "/usr/bin/bwrap",
+	}
+	args := strings.Join(s.args("ls", "/home/u/ws/src"), " ")
+	for _, want := range []string{
+		"--unshare-net",
+		"--ro-bind / /",
+		"--tmpfs /tmp --ro-bind /usr/bin/summer /usr/bin/summer --bind /home/u/ws /home/u/ws --bind /data/shared /data/shared",
+		"--chdir /home/u/ws/src",
+		"-- /usr/bin/summer -c ls",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("expected bwrap args to contain %q, got: %s", want, args)
+		}
+	}
+
+	s.allowNetwork = true
+	if args := strings.Join(s.args("ls", "/"), " "); strings.Contains(args, "--unshare-net") {
+		t.Errorf("expected network to be shared when allowed, got: %s", args)
+	}
+}
+
+func TestSandbox_Bwrap(t *testing.T) {
+	if _, err := exec.LookPath("bwrap"); err != nil {
+		t.Skip("bwrap not installed")
+	}
+	workspace := t.TempDir()
+	sandbox, err := NewSandbox(SandboxOptions{Mode: SandboxBwrap, Workspace: workspace})
+	if err != nil {
+		t.Fatalf("NewSandbox: %v", err)
+	}
+	tool := NewExecTool(workspace, true)
+	tool.SetSandbox(sandbox)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"command": "echo ok > f && cat f && touch /usr/summer-test"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "ok") {
+		t.Errorf("expected workspace write to succeed and root write to fail, got: %s", result.ForLLM)
+	}
+}
+
+func TestNewSandbox_Modes(t *testing.T) {
+	if s, err := NewSandbox(SandboxOptions{Mode: SandboxOff, Workspace: t.TempDir()}); s != nil || err != nil {
+		t.Errorf("expected no sandbox for mode off, got %v, %v", s, err)
+	}
+	if _, err := NewSandbox(SandboxOptions{Mode: "docker", Workspace: t.TempDir()}); err == nil {
+		t.Errorf("expected error for unknown mode")
+	}
+	if _, err := NewSandbox(SandboxOptions{Mode: SandboxAuto}); err == nil {
+		t.Errorf("expected error without a workspace")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package tools
+
+import "fmt"
+
+// newPlatformSandbox is a stub for non-Linux platforms.
+func newPlatformSandbox(opts SandboxOptions) (Sandbox, error) {
+	return nil, fmt.Errorf("exec sandboxing is only supported on Linux")
+}
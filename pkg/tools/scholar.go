@@ -0,0 +1,745 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultScholarLimit = 10
+	maxScholarLimit     = 50
+	// maxGraphPapers bounds the number of papers a citation expansion
+	// collects across all levels.
+	maxGraphPapers = 200
+)
+
+// errPaperNotFound is returned by sources when an identifier is unknown.
+var errPaperNotFound = errors.New("not found")
+
+// scholarPaper is a paper as reported by one or more bibliographic sources.
+type scholarPaper struct {
+	Title      string
+	Authors    []string
+	Year       int
+	Venue      string
+	DOI        string
+	ArXivID    string
+	URL        string
+	Abstract   string
+	Citations  int
+	References int
+	// IDs holds the paper's identifier in each source that returned it,
+	// keyed by source name.
+	IDs map[string]string
+	// CitationsBy records the citation count each source reported.
+	CitationsBy map[string]int
+	Sources     []string
+
+	refIDs []string      // source-specific IDs of cited works, when known
+	via    *scholarPaper // the paper this one was reached from in a graph
+}
+
+// scholarAuthor is an author profile.
+type scholarAuthor struct {
+	ID           string
+	Name         string
+	Affiliations []string
+	Papers       int
+	Citations    int
+	HIndex       int
+	URL          string
+}
+
+// scholarSource is a bibliographic database such as Semantic Scholar or
+// OpenAlex.
+type scholarSource interface {
+	name() string
+	paper(ctx context.Context, id paperID) (*scholarPaper, error)
+	search(ctx context.Context, query string, limit int) ([]*scholarPaper, error)
+	// citations returns papers citing p; references returns papers p cites.
+	citations(ctx context.Context, p *scholarPaper, limit int) ([]*scholarPaper, error)
+	references(ctx context.Context, p *scholarPaper, limit int) ([]*scholarPaper, error)
+	authors(ctx context.Context, query string, limit int) ([]*scholarAuthor, error)
+}
+
+// paperID is a parsed paper identifier.
+type paperID struct {
+	kind  string // "doi", "arxiv", "s2" or "openalex"
+	value string
+}
+
+var (
+	arxivIDRe    = regexp.MustCompile(`^(\d{4}\.\d{4,5}|[a-z][a-z.-]*/\d{7})(v\d+)?$`)
+	s2IDRe       = regexp.MustCompile(`^[0-9a-f]{40}$`)
+	openAlexIDRe = regexp.MustCompile(`^[Ww]\d+$`)
+	// arXiv registers DOIs of the form 10.48550/arXiv.<id> for its papers.
+	arxivDOIPrefix = "10.48550/arxiv."
+)
+
+// parsePaperID recognizes DOIs, arXiv IDs, Semantic Scholar and OpenAlex
+// IDs, with or without their URL or "doi:"/"arXiv:" prefixes.
+func parsePaperID(s string) (paperID, error) {
+	id := strings.TrimSpace(s)
+	lower := strings.ToLower(id)
+	for _, prefix := range []string{"https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "doi:"} {
+		if strings.HasPrefix(lower, prefix) {
+			id, lower = id[len(prefix):], lower[len(prefix):]
+			break
+		}
+	}
+	if strings.HasPrefix(lower, "10.") && strings.Contains(lower, "/") {
+		if strings.HasPrefix(lower, arxivDOIPrefix) {
+			return paperID{"arxiv", id[len(arxivDOIPrefix):]}, nil
+		}
+		return paperID{"doi", lower}, nil
+	}
+
+	for _, prefix := range []string{"https://arxiv.org/abs/", "http://arxiv.org/abs/", "https://arxiv.org/pdf/", "arxiv:"} {
+		if strings.HasPrefix(lower, prefix) {
+			lower = strings.TrimSuffix(lower[len(prefix):], ".pdf")
+			break
+		}
+	}
+	if m := arxivIDRe.FindStringSubmatch(lower); m != nil {
+		return paperID{"arxiv", m[1]}, nil
+	}
+
+	lower = strings.TrimPrefix(lower, "https://www.semanticscholar.org/paper/")
+	if i := strings.LastIndex(lower, "/"); i >= 0 && s2IDRe.MatchString(lower[i+1:]) {
+		lower = lower[i+1:]
+	}
+	if s2IDRe.MatchString(lower) {
+		return paperID{"s2", lower}, nil
+	}
+
+	id = strings.TrimPrefix(strings.TrimPrefix(id, "https://openalex.org/"), "openalex:")
+	if openAlexIDRe.MatchString(id) {
+		return paperID{"openalex", strings.ToUpper(id)}, nil
+	}
+	return paperID{}, fmt.Errorf("unrecognized paper identifier %q (expected a DOI, arXiv ID, Semantic Scholar ID or OpenAlex ID)", s)
+}
+
+// normalizeIdentifiers fills ArXivID from an arXiv DOI and lower-cases the
+// DOI so that records from different sources compare equal.
+func (p *scholarPaper) normalizeIdentifiers() {
+	p.DOI = strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(p.DOI, "https://doi.org/"), "http://doi.org/"))
+	if strings.HasPrefix(p.DOI, arxivDOIPrefix) {
+		if p.ArXivID == "" {
+			p.ArXivID = p.DOI[len(arxivDOIPrefix):]
+		}
+		p.DOI = ""
+	}
+	if m := arxivIDRe.FindStringSubmatch(strings.ToLower(p.ArXivID)); m != nil {
+		p.ArXivID = m[1]
+	}
+}
+
+var nonAlnumRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// paperKeys returns the keys under which two records are considered the
+// same paper: DOI, arXiv ID, and normalized title plus year.
+func paperKeys(p *scholarPaper) []string {
+	var keys []string
+	if p.DOI != "" {
+		keys = append(keys, "doi:"+p.DOI)
+	}
+	if p.ArXivID != "" {
+		keys = append(keys, "arxiv:"+p.ArXivID)
+	}
+	if title := nonAlnumRe.ReplaceAllString(strings.ToLower(p.Title), ""); len(title) >= 16 {
+		keys = append(keys, fmt.Sprintf("title:%s:%d", title, p.Year))
+	}
+	for source, id := range p.IDs {
+		keys = append(keys, source+":"+id)
+	}
+	return keys
+}
+
+// mergePaper fills in what dst is missing from src and records src's
+// sources, identifiers and citation counts.
+func mergePaper(dst, src *scholarPaper) {
+	fill := func(d *string, s string) {
+		if *d == "" {
+			*d = s
+		}
+	}
+	fill(&dst.Title, src.Title)
+	fill(&dst.Venue, src.Venue)
+	fill(&dst.DOI, src.DOI)
+	fill(&dst.ArXivID, src.ArXivID)
+	fill(&dst.URL, src.URL)
+	fill(&dst.Abstract, src.Abstract)
+	if len(dst.Authors) == 0 {
+		dst.Authors = src.Authors
+	}
+	if dst.Year == 0 {
+		dst.Year = src.Year
+	}
+	dst.Citations = max(dst.Citations, src.Citations)
+	dst.References = max(dst.References, src.References)
+	if dst.IDs == nil {
+		dst.IDs = make(map[string]string)
+	}
+	for k, v := range src.IDs {
+		if _, ok := dst.IDs[k]; !ok {
+			dst.IDs[k] = v
+		}
+	}
+	if dst.CitationsBy == nil {
+		dst.CitationsBy = make(map[string]int)
+	}
+	for k, v := range src.CitationsBy {
+		dst.CitationsBy[k] = v
+	}
+	for _, s := range src.Sources {
+		found := false
+		for _, d := range dst.Sources {
+			found = found || d == s
+		}
+		if !found {
+			dst.Sources = append(dst.Sources, s)
+		}
+	}
+}
+
+// paperSet collects papers, merging records that share a DOI, arXiv ID or
+// title and year. Papers keep the order in which they were first added.
+type paperSet struct {
+	papers []*scholarPaper
+	index  map[string]*scholarPaper
+}
+
+func newPaperSet() *paperSet {
+	return &paperSet{index: make(map[string]*scholarPaper)}
+}
+
+// add merges p into the set and reports whether it was new.
+func (s *paperSet) add(p *scholarPaper) bool {
+	keys := paperKeys(p)
+	for _, k := range keys {
+		if existing := s.index[k]; existing != nil {
+			mergePaper(existing, p)
+			for _, k := range paperKeys(existing) {
+				s.index[k] = existing
+			}
+			return false
+		}
+	}
+	s.papers = append(s.papers, p)
+	for _, k := range keys {
+		s.index[k] = p
+	}
+	return true
+}
+
+// expandCitations walks the citation graph from seed: direction "citations"
+// follows papers citing it, "references" papers it cites. Each level holds
+// the papers first reached at that depth; papers seen before are skipped.
+func expandCitations(ctx context.Context, src scholarSource, seed *scholarPaper, direction string, depth, limit int) ([][]*scholarPaper, error) {
+	seen := newPaperSet()
+	seen.add(seed)
+	frontier := []*scholarPaper{seed}
+	var levels [][]*scholarPaper
+	total := 0
+
+	for d := 1; d <= depth && len(frontier) > 0 && total < maxGraphPapers; d++ {
+		var next []*scholarPaper
+		for _, p := range frontier {
+			if ctx.Err() != nil {
+				return levels, ctx.Err()
+			}
+			var found []*scholarPaper
+			var err error
+			if direction == "citations" {
+				found, err = src.citations(ctx, p, limit)
+			} else {
+				found, err = src.references(ctx, p, limit)
+			}
+			if err != nil {
+				if d == 1 {
+					return nil, err
+				}
+				// Deeper levels are best effort.
+				continue
+			}
+			for _, f := range found {
+				if total >= maxGraphPapers {
+					break
+				}
+				if !seen.add(f) {
+					continue
+				}
+				if d > 1 {
+					f.via = p
+				}
+				next = append(next, f)
+				total++
+			}
+		}
+		levels = append(levels, next)
+		frontier = next
+	}
+	return levels, nil
+}
+
+// scholarGet fetches a JSON document into out.
+func scholarGet(ctx context.Context, client *http.Client, rawURL string, header map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("User-Agent", "Summer AI Assistant")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := withTimeout(client, 20*time.Second).Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return errPaperNotFound
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("rate limited (HTTP 429); wait a moment and retry")
+	case resp.StatusCode != http.StatusOK:
+		msg := strings.TrimSpace(string(body))
+		if len(msg) > 200 {
+			msg = msg[:200] + "..."
+		}
+		return fmt.Errorf("API error: %d - %s", resp.StatusCode, msg)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse response: %v", err)
+	}
+	return nil
+}
+
+// escapeIDPath escapes an identifier for use in a URL path, keeping the
+// slashes of DOIs, which the APIs expect literally.
+func escapeIDPath(id string) string {
+	return strings.ReplaceAll(url.PathEscape(id), "%2F", "/")
+}
+
+// ScholarTool queries one bibliographic source for papers, citation graphs
+// and author profiles.
+type ScholarTool struct {
+	toolName    string
+	description string
+	source      scholarSource
+	maxDepth    int
+}
+
+// NewSemanticScholarTool creates the semantic_scholar tool. apiKey is
+// optional but raises rate limits. maxDepth caps citation expansion
+// (default 2).
+func NewSemanticScholarTool(client *http.Client, apiKey string, maxDepth int) *ScholarTool {
+	return &ScholarTool{
+		toolName: "semantic_scholar",
+		description: "Query Semantic Scholar: search papers, look one up by DOI, arXiv ID or Semantic Scholar ID (citation and reference counts, venue, abstract), " +
+			"follow its citations (papers citing it) or references (papers it cites) several levels deep, and find author profiles (h-index, affiliations).",
+		source:   &semanticScholar{client: clientOrDefault(client), baseURL: semanticScholarAPI, apiKey: apiKey},
+		maxDepth: maxDepthOrDefault(maxDepth),
+	}
+}
+
+// NewOpenAlexTool creates the openalex tool. mailto, if set, is sent to use
+// OpenAlex's polite pool. maxDepth caps citation expansion (default 2).
+func NewOpenAlexTool(client *http.Client, mailto string, maxDepth int) *ScholarTool {
+	return &ScholarTool{
+		toolName: "openalex",
+		description: "Query OpenAlex: search works, look one up by DOI, arXiv ID or OpenAlex ID (citation counts, venue, abstract), " +
+			"follow its citations (works citing it) or references (works it cites) several levels deep, and find author profiles (h-index, institutions).",
+		source:   &openAlex{client: clientOrDefault(client), baseURL: openAlexAPI, mailto: mailto},
+		maxDepth: maxDepthOrDefault(maxDepth),
+	}
+}
+
+func maxDepthOrDefault(depth int) int {
+	if depth <= 0 {
+		return 2
+	}
+	return depth
+}
+
+func (t *ScholarTool) Name() string {
+	return t.toolName
+}
+
+func (t *ScholarTool) Description() string {
+	return t.description
+}
+
+func (t *ScholarTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"search", "paper", "citations", "references", "author"},
+				"description": "search: find papers by keywords; paper: details of one paper; citations: papers citing it; references: papers it cites; author: find author profiles",
+			},
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Keywords (search) or author name (author)",
+			},
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "Paper identifier for paper/citations/references: DOI (10.1038/...), arXiv ID (1706.03762) or the source's own ID",
+			},
+			"depth": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("Levels of citations/references to follow (default 1, max %d)", t.maxDepth),
+				"minimum":     1.0,
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Results to return, or per paper when following citations (default 10, max 50)",
+				"minimum":     1.0,
+				"maximum":     float64(maxScholarLimit),
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *ScholarTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	action, _ := args["action"].(string)
+	query, _ := args["query"].(string)
+	limit := defaultScholarLimit
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = min(int(l), maxScholarLimit)
+	}
+	src := t.source.name()
+
+	switch action {
+	case "search":
+		if query == "" {
+			return ErrorResult("query is required for search")
+		}
+		papers, err := t.source.search(ctx, query, limit)
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("%s search failed: %v", src, err))
+		}
+		if len(papers) == 0 {
+			return NewToolResult(fmt.Sprintf("No results found on %s for: %s", src, query))
+		}
+		return NewToolResult(fmt.Sprintf("%s Results for: %s\n\n%s", src, query, formatPaperList(papers)))
+
+	case "author":
+		if query == "" {
+			return ErrorResult("query is required for author")
+		}
+		authors, err := t.source.authors(ctx, query, limit)
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("%s author search failed: %v", src, err))
+		}
+		if len(authors) == 0 {
+			return NewToolResult(fmt.Sprintf("No authors found on %s for: %s", src, query))
+		}
+		return NewToolResult(fmt.Sprintf("%s Authors matching: %s\n\n%s", src, query, formatAuthors(authors)))
+
+	case "paper", "citations", "references":
+		raw, _ := args["id"].(string)
+		if raw == "" {
+			return ErrorResult(fmt.Sprintf("id is required for %s", action))
+		}
+		id, err := parsePaperID(raw)
+		if err != nil {
+			return ErrorResult(err.Error())
+		}
+		paper, err := t.source.paper(ctx, id)
+		if errors.Is(err, errPaperNotFound) {
+			return ErrorResult(fmt.Sprintf("%s has no paper with ID %s", src, raw))
+		}
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("%s lookup failed: %v", src, err))
+		}
+		if action == "paper" {
+			return NewToolResult(formatPaperDetails(paper))
+		}
+
+		depth := 1
+		if d, ok := args["depth"].(float64); ok && d >= 1 {
+			depth = int(d)
+		}
+		if depth > t.maxDepth {
+			return ErrorResult(fmt.Sprintf("depth %d exceeds the configured maximum of %d", depth, t.maxDepth))
+		}
+		levels, err := expandCitations(ctx, t.source, paper, action, depth, limit)
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("%s %s lookup failed: %v", src, action, err))
+		}
+		return NewToolResult(formatCitationGraph(paper, action, levels))
+
+	default:
+		return ErrorResult("action must be one of: search, paper, citations, references, author")
+	}
+}
+
+// PaperLookupTool looks a paper up in every source at once and merges the
+// records, or runs a search across sources with duplicates removed.
+type PaperLookupTool struct {
+	sources []scholarSource
+}
+
+// NewPaperLookupTool creates the paper_lookup tool over the sources of the
+// given scholar tools.
+func NewPaperLookupTool(tools ...*ScholarTool) *PaperLookupTool {
+	t := &PaperLookupTool{}
+	for _, st := range tools {
+		t.sources = append(t.sources, st.source)
+	}
+	return t
+}
+
+func (t *PaperLookupTool) Name() string {
+	return "paper_lookup"
+}
+
+func (t *PaperLookupTool) Description() string {
+	names := make([]string, len(t.sources))
+	for i, s := range t.sources {
+		names[i] = s.name()
+	}
+	return "Look up a paper by DOI or arXiv ID, or search by keywords, across " + strings.Join(names, " and ") +
+		" at once. Records for the same paper are merged, showing which sources know it and the citation count each reports."
+}
+
+func (t *PaperLookupTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "DOI or arXiv ID of the paper",
+			},
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Keywords to search for instead of an ID",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Search results per source (default 10, max 50)",
+				"minimum":     1.0,
+				"maximum":     float64(maxScholarLimit),
+			},
+		},
+	}
+}
+
+func (t *PaperLookupTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	raw, _ := args["id"].(string)
+	query, _ := args["query"].(string)
+	limit := defaultScholarLimit
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = min(int(l), maxScholarLimit)
+	}
+	if raw == "" && query == "" {
+		return ErrorResult("id or query is required")
+	}
+
+	var id paperID
+	if raw != "" {
+		var err error
+		if id, err = parsePaperID(raw); err != nil {
+			return ErrorResult(err.Error())
+		}
+		if id.kind != "doi" && id.kind != "arxiv" {
+			return ErrorResult("paper_lookup needs a DOI or arXiv ID; use semantic_scholar or openalex for source-specific IDs")
+		}
+	}
+
+	set := newPaperSet()
+	var problems []string
+	for _, src := range t.sources {
+		var papers []*scholarPaper
+		var err error
+		if raw != "" {
+			var p *scholarPaper
+			if p, err = src.paper(ctx, id); err == nil {
+				papers = []*scholarPaper{p}
+			}
+		} else {
+			papers, err = src.search(ctx, query, limit)
+		}
+		if errors.Is(err, errPaperNotFound) {
+			problems = append(problems, fmt.Sprintf("%s: not found", src.name()))
+			continue
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", src.name(), err))
+			continue
+		}
+		for _, p := range papers {
+			set.add(p)
+		}
+	}
+
+	if len(set.papers) == 0 {
+		if raw != "" {
+			return ErrorResult(fmt.Sprintf("no source has a paper with ID %s (%s)", raw, strings.Join(problems, "; ")))
+		}
+		if len(problems) == len(t.sources) {
+			return ErrorResult(fmt.Sprintf("all sources failed: %s", strings.Join(problems, "; ")))
+		}
+		return NewToolResult(fmt.Sprintf("No results found for: %s", query))
+	}
+
+	var sb strings.Builder
+	if raw != "" {
+		sb.WriteString(formatPaperDetails(set.papers[0]))
+	} else {
+		fmt.Fprintf(&sb, "Results for: %s (%d unique papers)\n\n%s", query, len(set.papers), formatPaperList(set.papers))
+	}
+	if len(problems) > 0 {
+		sb.WriteString("\nUnavailable: " + strings.Join(problems, "; "))
+	}
+	return NewToolResult(strings.TrimRight(sb.String(), "\n"))
+}
+
+func formatPaperList(papers []*scholarPaper) string {
+	var lines []string
+	for i, p := range papers {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, p.Title))
+		lines = append(lines, paperSummaryLines(p, "   ")...)
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// paperSummaryLines renders the fields shared by lists and details.
+func paperSummaryLines(p *scholarPaper, indent string) []string {
+	var lines []string
+	if len(p.Authors) > 0 {
+		authors := p.Authors
+		if len(authors) > 6 {
+			authors = append(authors[:6:6], fmt.Sprintf("and %d more", len(p.Authors)-6))
+		}
+		lines = append(lines, indent+"Authors: "+strings.Join(authors, ", "))
+	}
+	var pub []string
+	if p.Year > 0 {
+		pub = append(pub, fmt.Sprintf("Year: %d", p.Year))
+	}
+	if p.Venue != "" {
+		pub = append(pub, "Venue: "+p.Venue)
+	}
+	if len(pub) > 0 {
+		lines = append(lines, indent+strings.Join(pub, " | "))
+	}
+	var ids []string
+	if p.DOI != "" {
+		ids = append(ids, "DOI: "+p.DOI)
+	}
+	if p.ArXivID != "" {
+		ids = append(ids, "arXiv: "+p.ArXivID)
+	}
+	if len(ids) > 0 {
+		lines = append(lines, indent+strings.Join(ids, " | "))
+	}
+	counts := fmt.Sprintf("Citations: %d", p.Citations)
+	if len(p.CitationsBy) > 1 {
+		var by []string
+		for _, s := range p.Sources {
+			if n, ok := p.CitationsBy[s]; ok {
+				by = append(by, fmt.Sprintf("%s %d", s, n))
+			}
+		}
+		counts += " (" + strings.Join(by, ", ") + ")"
+	}
+	if p.References > 0 {
+		counts += fmt.Sprintf(" | References: %d", p.References)
+	}
+	lines = append(lines, indent+counts)
+	if len(p.Sources) > 1 {
+		lines = append(lines, indent+"Sources: "+strings.Join(p.Sources, ", "))
+	}
+	if p.URL != "" {
+		lines = append(lines, indent+"URL: "+p.URL)
+	}
+	return lines
+}
+
+func formatPaperDetails(p *scholarPaper) string {
+	lines := []string{p.Title}
+	lines = append(lines, paperSummaryLines(p, "")...)
+	for _, s := range p.Sources {
+		if id := p.IDs[s]; id != "" {
+			lines = append(lines, fmt.Sprintf("%s ID: %s", s, id))
+		}
+	}
+	if p.Abstract != "" {
+		abstract := p.Abstract
+		if len(abstract) > 1500 {
+			abstract = abstract[:1497] + "..."
+		}
+		lines = append(lines, "", "Abstract: "+abstract)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatCitationGraph(seed *scholarPaper, direction string, levels [][]*scholarPaper) string {
+	var sb strings.Builder
+	relation := "Papers citing"
+	if direction == "references" {
+		relation = "Papers cited by"
+	}
+	fmt.Fprintf(&sb, "%s: %s", relation, seed.Title)
+	if seed.Year > 0 {
+		fmt.Fprintf(&sb, " (%d)", seed.Year)
+	}
+	fmt.Fprintf(&sb, "\nCitations: %d | References: %d\n", seed.Citations, seed.References)
+
+	total := 0
+	for i, level := range levels {
+		total += len(level)
+		fmt.Fprintf(&sb, "\nDepth %d (%d papers):\n", i+1, len(level))
+		for j, p := range level {
+			fmt.Fprintf(&sb, "%d. %s\n", j+1, p.Title)
+			for _, line := range paperSummaryLines(p, "   ") {
+				sb.WriteString(line + "\n")
+			}
+			if p.via != nil {
+				fmt.Fprintf(&sb, "   Via: %s\n", p.via.Title)
+			}
+		}
+	}
+	if total == 0 {
+		sb.WriteString("\nNo papers found.")
+	} else if total >= maxGraphPapers {
+		fmt.Fprintf(&sb, "\nStopped after %d papers; use a smaller depth or limit.", maxGraphPapers)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func formatAuthors(authors []*scholarAuthor) string {
+	var lines []string
+	for i, a := range authors {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, a.Name))
+		if len(a.Affiliations) > 0 {
+			lines = append(lines, "   Affiliations: "+strings.Join(a.Affiliations, "; "))
+		}
+		lines = append(lines, fmt.Sprintf("   Papers: %d | Citations: %d | h-index: %d", a.Papers, a.Citations, a.HIndex))
+		lines = append(lines, "   ID: "+a.ID)
+		if a.URL != "" {
+			lines = append(lines, "   URL: "+a.URL)
+		}
+		lines = append(lines, "")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
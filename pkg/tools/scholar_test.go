@@ -0,0 +1,253 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// scholarRoutes routes requests by path, or by path and the filter
+// parameter as "path|filter".
+func scholarRoutes(routes map[string]string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		if name, ok := routes[r.URL.Path+"|"+r.URL.Query().Get("filter")]; ok {
+			return name
+		}
+		return routes[r.URL.Path]
+	}
+}
+
+var s2Routes = map[string]string{
+	"/paper/ARXIV:1706.03762":                                   "s2_paper_attention.json",
+	"/paper/204e3073870fae3d05bcbc2f6a8e263d9b72e776/citations": "s2_citations_attention.json",
+	"/paper/df2b0e26d0599ce3e70df8a9da02e51594e0e992/citations": "s2_citations_bert.json",
+	"/paper/268d347e8a55b5eb82fb5e7d2f800e33c75ab18a/citations": "s2_citations_empty.json",
+	"/paper/search":  "s2_search_transformers.json",
+	"/author/search": "s2_authors_hinton.json",
+}
+
+var openAlexRoutes = map[string]string{
+	"/works/doi:10.48550/arxiv.1706.03762":    "oa_work_attention.json",
+	"/works|openalex:W1522301498|W2130942839": "oa_references_attention.json",
+	"/works|cites:W2963403868":                "oa_citations_attention.json",
+	"/works":                                  "oa_search_transformers.json",
+	"/authors":                                "oa_authors_hinton.json",
+}
+
+func newTestSemanticScholarTool(t *testing.T, routes map[string]string) (*ScholarTool, *fixtureServer) {
+	server := newFixtureServer(t, "scholar", scholarRoutes(routes))
+	tool := NewSemanticScholarTool(testOutboundClient(), "test-key", 2)
+	tool.source.(*semanticScholar).baseURL = server.URL
+	return tool, server
+}
+
+func newTestOpenAlexTool(t *testing.T, routes map[string]string) (*ScholarTool, *fixtureServer) {
+	server := newFixtureServer(t, "scholar", scholarRoutes(routes))
+	tool := NewOpenAlexTool(testOutboundClient(), "me@example.com", 2)
+	tool.source.(*openAlex).baseURL = server.URL
+	return tool, server
+}
+
+func executeScholar(t *testing.T, tool Tool, args map[string]interface{}) string {
+	t.Helper()
+	result := tool.Execute(context.Background(), args)
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	return result.ForLLM
+}
+
+func assertContainsAll(t *testing.T, out string, wants ...string) {
+	t.Helper()
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestParsePaperID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want paperID
+	}{
+		{"10.1038/nature14539", paperID{"doi", "10.1038/nature14539"}},
+		{"https://doi.org/10.18653/v1/N19-1423", paperID{"doi", "10.18653/v1/n19-1423"}},
+		{"doi:10.1162/neco.1997.9.8.1735", paperID{"doi", "10.1162/neco.1997.9.8.1735"}},
+		{"10.48550/arXiv.1706.03762", paperID{"arxiv", "1706.03762"}},
+		{"1706.03762v5", paperID{"arxiv", "1706.03762"}},
+		{"arXiv:1810.04805", paperID{"arxiv", "1810.04805"}},
+		{"https://arxiv.org/abs/2010.11929v2", paperID{"arxiv", "2010.11929"}},
+		{"https://arxiv.org/pdf/1409.0473.pdf", paperID{"arxiv", "1409.0473"}},
+		{"hep-th/9901001", paperID{"arxiv", "hep-th/9901001"}},
+		{"204e3073870fae3d05bcbc2f6a8e263d9b72e776", paperID{"s2", "204e3073870fae3d05bcbc2f6a8e263d9b72e776"}},
+		{"https://www.semanticscholar.org/paper/Attention-is-All-you-Need-Vaswani/204e3073870fae3d05bcbc2f6a8e263d9b72e776", paperID{"s2", "204e3073870fae3d05bcbc2f6a8e263d9b72e776"}},
+		{"https://openalex.org/W2963403868", paperID{"openalex", "W2963403868"}},
+		{"w2963403868", paperID{"openalex", "W2963403868"}},
+	}
+	for _, tt := range tests {
+		got, err := parsePaperID(tt.in)
+		if err != nil {
+			t.Errorf("parsePaperID(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parsePaperID(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+	if _, err := parsePaperID("attention is all you need"); err == nil {
+		t.Error("expected an error for free text")
+	}
+}
+
+func TestSemanticScholar_PaperLookup(t *testing.T) {
+	tool, fixtures := newTestSemanticScholarTool(t, s2Routes)
+
+	out := executeScholar(t, tool, map[string]interface{}{"action": "paper", "id": "https://arxiv.org/abs/1706.03762v7"})
+	assertContainsAll(t, out,
+		"Attention is All you Need",
+		"Authors: Ashish Vaswani, Noam M. Shazeer, Niki Parmar, Jakob Uszkoreit, Llion Jones, Aidan N. Gomez, and 2 more",
+		"Year: 2017 | Venue: Neural Information Processing Systems",
+		"arXiv: 1706.03762",
+		"Citations: 118542 | References: 41",
+		"Semantic Scholar ID: 204e3073870fae3d05bcbc2f6a8e263d9b72e776",
+		"Abstract: The dominant sequence transduction models",
+	)
+	if got := fixtures.requests()[0].Header.Get("x-api-key"); got != "test-key" {
+		t.Errorf("expected API key header, got %q", got)
+	}
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"action": "paper", "id": "10.1000/missing"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "has no paper with ID 10.1000/missing") {
+		t.Errorf("expected not found error, got: %s", result.ForLLM)
+	}
+}
+
+func TestSemanticScholar_CitationDepth(t *testing.T) {
+	tool, fixtures := newTestSemanticScholarTool(t, s2Routes)
+
+	out := executeScholar(t, tool, map[string]interface{}{"action": "citations", "id": "1706.03762", "depth": 2.0})
+	assertContainsAll(t, out,
+		"Papers citing: Attention is All you Need (2017)",
+		"Depth 1 (2 papers):",
+		"1. BERT: Pre-training of Deep Bidirectional Transformers",
+		"DOI: 10.18653/v1/n19-1423 | arXiv: 1810.04805",
+		"2. An Image is Worth 16x16 Words",
+		"Depth 2 (1 papers):",
+		"1. RoBERTa: A Robustly Optimized BERT Pretraining Approach",
+		"Via: BERT: Pre-training",
+	)
+	// The ViT paper cites both the seed and BERT but is listed once; the
+	// citation without a paper ID is dropped.
+	if n := strings.Count(out, "An Image is Worth 16x16 Words"); n != 1 {
+		t.Errorf("expected the shared citing paper once, got %d times:\n%s", n, out)
+	}
+	if strings.Contains(out, "unresolved citation") {
+		t.Errorf("expected papers without an ID to be skipped:\n%s", out)
+	}
+	// RoBERTa was never expanded: the walk stops at depth 2.
+	for _, path := range fixtures.paths() {
+		if strings.Contains(path, "077f8329") {
+			t.Errorf("unexpected request beyond the requested depth: %s", path)
+		}
+	}
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"action": "citations", "id": "1706.03762", "depth": 3.0})
+	if !result.IsError || !strings.Contains(result.ForLLM, "configured maximum of 2") {
+		t.Errorf("expected depth limit error, got: %s", result.ForLLM)
+	}
+}
+
+func TestSemanticScholar_SearchAndAuthors(t *testing.T) {
+	tool, fixtures := newTestSemanticScholarTool(t, s2Routes)
+
+	out := executeScholar(t, tool, map[string]interface{}{"action": "search", "query": "transformers", "limit": 2.0})
+	assertContainsAll(t, out, "Semantic Scholar Results for: transformers", "1. Attention is All you Need", "2. BERT")
+	if q := fixtures.requests()[0].URL.Query(); q.Get("query") != "transformers" || q.Get("limit") != "2" {
+		t.Errorf("unexpected search parameters: %v", q)
+	}
+
+	out = executeScholar(t, tool, map[string]interface{}{"action": "author", "query": "Geoffrey Hinton"})
+	assertContainsAll(t, out,
+		"1. Geoffrey E. Hinton",
+		"Affiliations: University of Toronto",
+		"Papers: 416 | Citations: 612345 | h-index: 152",
+		"ID: 1695689",
+	)
+}
+
+func TestOpenAlex_ReferencesAndCitations(t *testing.T) {
+	tool, fixtures := newTestOpenAlexTool(t, openAlexRoutes)
+
+	out := executeScholar(t, tool, map[string]interface{}{"action": "references", "id": "10.48550/arXiv.1706.03762"})
+	assertContainsAll(t, out,
+		"Papers cited by: Attention Is All You Need (2017)",
+		"Citations: 97120 | References: 2",
+		"Depth 1 (2 papers):",
+		"1. Long Short-Term Memory",
+		"Authors: Sepp Hochreiter, Jürgen Schmidhuber",
+		"Venue: Neural Computation",
+		"DOI: 10.1162/neco.1997.9.8.1735",
+		"2. Neural Machine Translation by Jointly Learning to Align and Translate",
+		"arXiv: 1409.0473",
+	)
+	for _, r := range fixtures.requests() {
+		if r.URL.Query().Get("mailto") != "me@example.com" {
+			t.Errorf("expected mailto on %s", r.URL)
+		}
+	}
+
+	out = executeScholar(t, tool, map[string]interface{}{"action": "citations", "id": "1706.03762"})
+	assertContainsAll(t, out, "Papers citing: Attention Is All You Need", "BERT: Pre-training", "arXiv: 1810.04805")
+
+	out = executeScholar(t, tool, map[string]interface{}{"action": "paper", "id": "1706.03762"})
+	assertContainsAll(t, out, "Abstract: The dominant sequence transduction models", "OpenAlex ID: W2963403868")
+
+	out = executeScholar(t, tool, map[string]interface{}{"action": "author", "query": "Hinton"})
+	assertContainsAll(t, out, "Geoffrey E. Hinton", "Papers: 389 | Citations: 598211 | h-index: 148", "ID: A5108093963")
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"action": "paper", "id": "204e3073870fae3d05bcbc2f6a8e263d9b72e776"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "cannot look up s2 IDs") {
+		t.Errorf("expected unsupported ID error, got: %s", result.ForLLM)
+	}
+}
+
+func TestPaperLookup_MergesSources(t *testing.T) {
+	s2, _ := newTestSemanticScholarTool(t, s2Routes)
+	oa, _ := newTestOpenAlexTool(t, openAlexRoutes)
+	tool := NewPaperLookupTool(s2, oa)
+
+	out := executeScholar(t, tool, map[string]interface{}{"id": "arXiv:1706.03762"})
+	assertContainsAll(t, out,
+		"Attention is All you Need",
+		"Citations: 118542 (Semantic Scholar 118542, OpenAlex 97120)",
+		"Sources: Semantic Scholar, OpenAlex",
+		"Semantic Scholar ID: 204e3073870fae3d05bcbc2f6a8e263d9b72e776",
+		"OpenAlex ID: W2963403868",
+	)
+
+	out = executeScholar(t, tool, map[string]interface{}{"query": "transformers"})
+	assertContainsAll(t, out,
+		"Results for: transformers (3 unique papers)",
+		"Citations: 84321 (Semantic Scholar 84321, OpenAlex 70233)",
+		"3. Transformers: State-of-the-Art Natural Language Processing",
+	)
+	if n := strings.Count(out, "BERT: Pre-training"); n != 1 {
+		t.Errorf("expected BERT once after deduplication, got %d:\n%s", n, out)
+	}
+}
+
+func TestPaperLookup_PartialFailure(t *testing.T) {
+	s2, _ := newTestSemanticScholarTool(t, s2Routes)
+	oa, _ := newTestOpenAlexTool(t, map[string]string{})
+	tool := NewPaperLookupTool(s2, oa)
+
+	out := executeScholar(t, tool, map[string]interface{}{"id": "1706.03762"})
+	assertContainsAll(t, out, "Attention is All you Need", "Unavailable: OpenAlex: not found")
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"id": "W2963403868"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "needs a DOI or arXiv ID") {
+		t.Errorf("expected error for a source-specific ID, got: %s", result.ForLLM)
+	}
+}
@@ -0,0 +1,476 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultGrepResults = 100
+	maxGrepResults     = 1000
+	maxGrepContext     = 10
+	maxGrepFileSize    = 10 << 20
+	maxGrepLineChars   = 500
+	defaultGlobResults = 200
+	maxGlobResults     = 2000
+)
+
+// grepFileTypes maps the type filter of grep to file name globs.
+var grepFileTypes = map[string][]string{
+	"c":          {"*.c", "*.h"},
+	"cpp":        {"*.cc", "*.cpp", "*.cxx", "*.hh", "*.hpp", "*.hxx", "*.h"},
+	"css":        {"*.css", "*.scss", "*.sass", "*.less"},
+	"csv":        {"*.csv", "*.tsv"},
+	"go":         {"*.go"},
+	"html":       {"*.html", "*.htm"},
+	"java":       {"*.java"},
+	"js":         {"*.js", "*.jsx", "*.mjs", "*.cjs"},
+	"json":       {"*.json", "*.jsonl"},
+	"md":         {"*.md", "*.markdown"},
+	"py":         {"*.py", "*.pyi", "*.ipynb"},
+	"rust":       {"*.rs"},
+	"sh":         {"*.sh", "*.bash", "*.zsh"},
+	"sql":        {"*.sql"},
+	"tex":        {"*.tex", "*.bib", "*.sty", "*.cls"},
+	"toml":       {"*.toml"},
+	"ts":         {"*.ts", "*.tsx", "*.mts", "*.cts"},
+	"txt":        {"*.txt"},
+	"yaml":       {"*.yaml", "*.yml"},
+	"dockerfile": {"Dockerfile", "*.dockerfile"},
+}
+
+// searchScope is the resolved starting point of a grep or glob search.
+type searchScope struct {
+	start  string // file or directory to search
+	walker fileWalker
+	// relative reports whether results are shown relative to the workspace.
+	relative bool
+}
+
+func resolveSearchScope(path string, paths *PathPolicy, noIgnore bool) (*searchScope, error) {
+	if path == "" {
+		path = "."
+	}
+	start, err := paths.Resolve(path, PathRead)
+	if err != nil {
+		return nil, err
+	}
+	if !filepath.IsAbs(start) {
+		if start, err = filepath.Abs(start); err != nil {
+			return nil, err
+		}
+	}
+	info, err := os.Stat(start)
+	if err != nil {
+		return nil, fmt.Errorf("path not found: %s", path)
+	}
+
+	scope := &searchScope{start: start, walker: fileWalker{root: start, noIgnore: noIgnore}}
+	if !info.IsDir() {
+		scope.walker.root = filepath.Dir(start)
+	}
+	// Inside the workspace, .gitignore files from the workspace root apply
+	// and results are relative to it.
+	if workspace := paths.Workspace(); workspace != "" && pathWithin(start, workspace) {
+		scope.walker.root = workspace
+		scope.relative = true
+	}
+	return scope, nil
+}
+
+func (s *searchScope) display(path, rel string) string {
+	if s.relative {
+		return rel
+	}
+	return path
+}
+
+// fileFilter selects files by glob and type.
+type fileFilter struct {
+	globs []*pathGlob
+	types []*pathGlob
+}
+
+func newFileFilter(glob, fileType string) (*fileFilter, error) {
+	f := &fileFilter{}
+	for _, g := range strings.Split(glob, ",") {
+		if g = strings.TrimSpace(g); g == "" {
+			continue
+		}
+		pg, err := compilePathGlob(g)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %v", g, err)
+		}
+		f.globs = append(f.globs, pg)
+	}
+	if fileType != "" {
+		globs, ok := grepFileTypes[strings.ToLower(fileType)]
+		if !ok {
+			names := make([]string, 0, len(grepFileTypes))
+			for name := range grepFileTypes {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown file type %q (known: %s)", fileType, strings.Join(names, ", "))
+		}
+		for _, g := range globs {
+			pg, _ := compilePathGlob(g)
+			f.types = append(f.types, pg)
+		}
+	}
+	return f, nil
+}
+
+func (f *fileFilter) match(rel string) bool {
+	return matchAnyGlob(f.globs, rel) && matchAnyGlob(f.types, rel)
+}
+
+// matchAnyGlob reports whether rel matches one of globs; an empty list
+// matches everything.
+func matchAnyGlob(globs []*pathGlob, rel string) bool {
+	if len(globs) == 0 {
+		return true
+	}
+	for _, g := range globs {
+		if g.match(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// GrepTool searches file contents in the workspace with a regular
+// expression, like ripgrep: .gitignore'd and binary files are skipped.
+type GrepTool struct {
+	paths *PathPolicy
+}
+
+func NewGrepTool(workspace string, restrict bool, roots ...PathRoot) *GrepTool {
+	return &GrepTool{paths: NewPathPolicy(workspace, restrict, roots...)}
+}
+
+func (t *GrepTool) Name() string {
+	return "grep"
+}
+
+func (t *GrepTool) Description() string {
+	return "Search file contents in the workspace with a regular expression (RE2 syntax). " +
+		"Skips files ignored by .gitignore and binary files. Returns matching lines as path:line:text, with optional context lines."
+}
+
+func (t *GrepTool) Parameters() map[string]interface{} {
+	types := make([]string, 0, len(grepFileTypes))
+	for name := range grepFileTypes {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"pattern": map[string]interface{}{
+				"type":        "string",
+				"description": "Regular expression to search for",
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File or directory to search (default: the workspace)",
+			},
+			"glob": map[string]interface{}{
+				"type":        "string",
+				"description": "Only search files matching this glob, e.g. \"*.go\" or \"src/**/*.ts\"; comma-separate several",
+			},
+			"type": map[string]interface{}{
+				"type":        "string",
+				"enum":        types,
+				"description": "Only search files of this type",
+			},
+			"ignore_case": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Case-insensitive search",
+			},
+			"context": map[string]interface{}{
+				"type":        "integer",
+				"description": "Lines of context to show before and after each match (max 10)",
+			},
+			"files_only": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Only list the files that contain a match",
+			},
+			"max_results": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum matching lines (or files with files_only) to return (default 100, max 1000)",
+			},
+			"no_ignore": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Also search files ignored by .gitignore",
+			},
+		},
+		"required": []string{"pattern"},
+	}
+}
+
+func (t *GrepTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	pattern, _ := args["pattern"].(string)
+	if pattern == "" {
+		return ErrorResult("pattern is required")
+	}
+	if ignoreCase, _ := args["ignore_case"].(bool); ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("invalid pattern: %v", err))
+	}
+
+	path, _ := args["path"].(string)
+	glob, _ := args["glob"].(string)
+	fileType, _ := args["type"].(string)
+	noIgnore, _ := args["no_ignore"].(bool)
+	filesOnly, _ := args["files_only"].(bool)
+	contextLines := 0
+	if v, ok := args["context"].(float64); ok && v > 0 {
+		contextLines = min(int(v), maxGrepContext)
+	}
+	maxResults := defaultGrepResults
+	if v, ok := args["max_results"].(float64); ok && v > 0 {
+		maxResults = min(int(v), maxGrepResults)
+	}
+
+	scope, err := resolveSearchScope(path, t.paths, noIgnore)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	filter, err := newFileFilter(glob, fileType)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	var out strings.Builder
+	matches, files, truncated := 0, 0, false
+	err = scope.walker.walk(ctx, scope.start, func(path, rel string) error {
+		if !filter.match(rel) {
+			return nil
+		}
+		info, err := os.Stat(path)
+		if err != nil || info.Size() > maxGrepFileSize {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || isBinary(data) {
+			return nil
+		}
+		lines := splitLines(string(data))
+		var hits []int
+		for i, line := range lines {
+			if re.MatchString(line) {
+				hits = append(hits, i)
+			}
+		}
+		if len(hits) == 0 {
+			return nil
+		}
+
+		name := scope.display(path, rel)
+		files++
+		if filesOnly {
+			out.WriteString(name + "\n")
+			matches++
+			if matches >= maxResults {
+				truncated = true
+				return errStopWalk
+			}
+			return nil
+		}
+		if len(hits) > maxResults-matches {
+			hits = hits[:maxResults-matches]
+			truncated = true
+		}
+		matches += len(hits)
+		writeGrepMatches(&out, name, lines, hits, contextLines)
+		if truncated {
+			return errStopWalk
+		}
+		return nil
+	})
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("search failed: %v", err))
+	}
+
+	if matches == 0 {
+		return NewToolResult("No matches found.")
+	}
+	summary := fmt.Sprintf("Found %d matching lines in %d files.", matches, files)
+	if filesOnly {
+		summary = fmt.Sprintf("Found %d files with matches.", files)
+	}
+	if truncated {
+		summary += fmt.Sprintf(" Results stopped at the limit of %d; narrow the search or raise max_results.", maxResults)
+	}
+	return NewToolResult(strings.TrimRight(out.String(), "\n") + "\n\n" + summary)
+}
+
+// writeGrepMatches prints the matching lines of one file with context,
+// using ":" after the line number of matches and "-" for context, and "--"
+// between non-adjacent groups.
+func writeGrepMatches(out *strings.Builder, name string, lines []string, hits []int, contextLines int) {
+	isHit := make(map[int]bool, len(hits))
+	for _, h := range hits {
+		isHit[h] = true
+	}
+	last := -1
+	for _, h := range hits {
+		from, to := max(h-contextLines, last+1), min(h+contextLines, len(lines)-1)
+		if last >= 0 && from > last+1 && contextLines > 0 {
+			out.WriteString("--\n")
+		}
+		for i := from; i <= to; i++ {
+			sep := "-"
+			if isHit[i] {
+				sep = ":"
+			}
+			line := lines[i]
+			if len(line) > maxGrepLineChars {
+				line = line[:maxGrepLineChars] + " ..."
+			}
+			fmt.Fprintf(out, "%s%s%d%s%s\n", name, sep, i+1, sep, line)
+		}
+		last = max(last, to)
+	}
+}
+
+// isBinary guesses whether data is binary by looking for NUL bytes near the
+// start, as git and ripgrep do.
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// GlobTool lists workspace files whose paths match a glob.
+type GlobTool struct {
+	paths *PathPolicy
+}
+
+func NewGlobTool(workspace string, restrict bool, roots ...PathRoot) *GlobTool {
+	return &GlobTool{paths: NewPathPolicy(workspace, restrict, roots...)}
+}
+
+func (t *GlobTool) Name() string {
+	return "glob"
+}
+
+func (t *GlobTool) Description() string {
+	return "Find files in the workspace by path pattern, e.g. \"**/*.go\", \"docs/*.md\" or \"*_test.py\" (a pattern without a slash matches file names at any depth). " +
+		"Skips files ignored by .gitignore. Returns matching paths, newest first."
+}
+
+func (t *GlobTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"pattern": map[string]interface{}{
+				"type":        "string",
+				"description": "Glob pattern; ** matches any number of directories",
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Directory to search in; the pattern is matched against paths relative to it (default: the workspace)",
+			},
+			"max_results": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum number of paths to return (default 200, max 2000)",
+			},
+			"no_ignore": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Also list files ignored by .gitignore",
+			},
+		},
+		"required": []string{"pattern"},
+	}
+}
+
+func (t *GlobTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	pattern, _ := args["pattern"].(string)
+	if pattern == "" {
+		return ErrorResult("pattern is required")
+	}
+	glob, err := compilePathGlob(pattern)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("invalid pattern: %v", err))
+	}
+	path, _ := args["path"].(string)
+	noIgnore, _ := args["no_ignore"].(bool)
+	maxResults := defaultGlobResults
+	if v, ok := args["max_results"].(float64); ok && v > 0 {
+		maxResults = min(int(v), maxGlobResults)
+	}
+
+	scope, err := resolveSearchScope(path, t.paths, noIgnore)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	info, err := os.Stat(scope.start)
+	if err != nil || !info.IsDir() {
+		return ErrorResult(fmt.Sprintf("not a directory: %s", path))
+	}
+	// The pattern is relative to the search directory, results to the
+	// workspace.
+	startRel, _ := filepath.Rel(scope.walker.root, scope.start)
+	startRel = filepath.ToSlash(startRel)
+
+	type found struct {
+		name    string
+		modTime int64
+	}
+	var results []found
+	total := 0
+	err = scope.walker.walk(ctx, scope.start, func(path, rel string) error {
+		patternRel := rel
+		if startRel != "." {
+			patternRel = strings.TrimPrefix(rel, startRel+"/")
+		}
+		if !glob.match(patternRel) {
+			return nil
+		}
+		total++
+		var mod int64
+		if info, err := os.Stat(path); err == nil {
+			mod = info.ModTime().UnixNano()
+		}
+		results = append(results, found{scope.display(path, rel), mod})
+		return nil
+	})
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("search failed: %v", err))
+	}
+	if total == 0 {
+		return NewToolResult("No files found.")
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].modTime != results[j].modTime {
+			return results[i].modTime > results[j].modTime
+		}
+		return results[i].name < results[j].name
+	})
+	var out strings.Builder
+	for i, r := range results {
+		if i == maxResults {
+			break
+		}
+		out.WriteString(r.name + "\n")
+	}
+	if total > maxResults {
+		fmt.Fprintf(&out, "\n(showing %d of %d files; narrow the pattern or raise max_results)", maxResults, total)
+	} else {
+		fmt.Fprintf(&out, "\n%d files", total)
+	}
+	return NewToolResult(out.String())
+}
@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func newSearchTestWorkspace(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		".gitignore":          "build/\n*.log\n!keep.log\n",
+		"main.go":             "package main\n\n// TODO: handle errors\nfunc main() {\n\trun()\n}\n",
+		"util/helper.go":      "package util\n\nfunc Helper() {} // todo later\n",
+		"util/helper_test.go": "package util\n",
+		"web/app.ts":          "// TODO: types\nexport const x = 1\n",
+		"web/.gitignore":      "generated.ts\n",
+		"web/generated.ts":    "// TODO: generated\n",
+		"build/out.go":        "// TODO: built\n",
+		"debug.log":           "TODO: log\n",
+		"keep.log":            "TODO: kept\n",
+		"docs/notes.md":       "nothing here\n",
+		".git/HEAD":           "TODO: git internals\n",
+		"assets/blob.bin":     "TODO\x00binary",
+	})
+	return dir
+}
+
+func TestGrepTool_RespectsGitignore(t *testing.T) {
+	dir := newSearchTestWorkspace(t)
+	tool := NewGrepTool(dir, true)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"pattern": "TODO"})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	for _, want := range []string{"main.go:3:// TODO: handle errors", "web/app.ts:1:// TODO: types", "keep.log:1:TODO: kept", "Found 3 matching lines in 3 files."} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("expected %q in:\n%s", want, result.ForLLM)
+		}
+	}
+	for _, unwanted := range []string{"build/", "debug.log", "generated.ts", ".git", "blob.bin"} {
+		if strings.Contains(result.ForLLM, unwanted) {
+			t.Errorf("did not expect %q in:\n%s", unwanted, result.ForLLM)
+		}
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"pattern": "TODO", "no_ignore": true, "files_only": true})
+	for _, want := range []string{"build/out.go", "debug.log", "web/generated.ts"} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("expected %q with no_ignore in:\n%s", want, result.ForLLM)
+		}
+	}
+}
+
+func TestGrepTool_FiltersAndContext(t *testing.T) {
+	dir := newSearchTestWorkspace(t)
+	tool := NewGrepTool(dir, true)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"pattern": "todo", "ignore_case": true, "type": "go"})
+	if !strings.Contains(result.ForLLM, "main.go:3:") || !strings.Contains(result.ForLLM, "util/helper.go:3:") || strings.Contains(result.ForLLM, "app.ts") {
+		t.Errorf("unexpected type-filtered result:\n%s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"pattern": "TODO", "glob": "*.ts", "path": "web"})
+	if !strings.Contains(result.ForLLM, "web/app.ts:1:") || strings.Contains(result.ForLLM, "main.go") {
+		t.Errorf("unexpected glob-filtered result:\n%s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"pattern": "run", "path": "main.go", "context": float64(1)})
+	want := "main.go-4-func main() {\nmain.go:5:\trun()\nmain.go-6-}"
+	if !strings.Contains(result.ForLLM, want) {
+		t.Errorf("expected context lines %q in:\n%s", want, result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"pattern": "TODO", "max_results": float64(1)})
+	if !strings.Contains(result.ForLLM, "Found 1 matching lines") || !strings.Contains(result.ForLLM, "limit of 1") {
+		t.Errorf("expected truncated result:\n%s", result.ForLLM)
+	}
+
+	if result := tool.Execute(context.Background(), map[string]interface{}{"pattern": "("}); !result.IsError {
+		t.Errorf("expected error for invalid regex")
+	}
+	if result := tool.Execute(context.Background(), map[string]interface{}{"pattern": "x", "type": "cobol"}); !result.IsError {
+		t.Errorf("expected error for unknown type")
+	}
+}
+
+func TestGrepTool_OutsideWorkspace(t *testing.T) {
+	dir := newSearchTestWorkspace(t)
+	tool := NewGrepTool(dir+"/util", true)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"pattern": "TODO", "path": ".."})
+	if !result.IsError || !strings.Contains(result.ForLLM, "outside the workspace") {
+		t.Errorf("expected access denied, got: %s", result.ForLLM)
+	}
+}
+
+func TestGlobTool(t *testing.T) {
+	dir := newSearchTestWorkspace(t)
+	tool := NewGlobTool(dir, true)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"pattern": "*.go"})
+	for _, want := range []string{"main.go", "util/helper.go", "util/helper_test.go", "3 files"} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("expected %q in:\n%s", want, result.ForLLM)
+		}
+	}
+	if strings.Contains(result.ForLLM, "build/out.go") {
+		t.Errorf("ignored file listed:\n%s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"pattern": "*_test.go", "path": "util"})
+	if strings.TrimSpace(result.ForLLM) != "util/helper_test.go\n\n1 files" {
+		t.Errorf("unexpected result for path-relative pattern:\n%s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"pattern": "**", "max_results": float64(2)})
+	if !strings.Contains(result.ForLLM, "showing 2 of") {
+		t.Errorf("expected truncation note:\n%s", result.ForLLM)
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob  string
+		path  string
+		match bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "pkg/main.go", false},
+		{"**/*.go", "pkg/a/main.go", true},
+		{"**/*.go", "main.go", true},
+		{"src/**", "src/a/b.txt", true},
+		{"src/**/test", "src/test", true},
+		{"a?c", "abc", true},
+		{"a?c", "a/c", false},
+		{"[!a]b", "cb", true},
+		{"[!a]b", "ab", false},
+		{"file[0-9].txt", "file7.txt", true},
+		{`\*.md`, "*.md", true},
+		{`\*.md`, "a.md", false},
+	}
+	for _, tt := range tests {
+		re, err := globToRegexp(tt.glob)
+		if err != nil {
+			t.Fatalf("globToRegexp(%q): %v", tt.glob, err)
+		}
+		if got := re.MatchString(tt.path); got != tt.match {
+			t.Errorf("glob %q on %q = %v, want %v", tt.glob, tt.path, got, tt.match)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	semanticScholarAPI = "https://api.semanticscholar.org/graph/v1"
+	s2PaperFields      = "paperId,externalIds,title,year,venue,authors,citationCount,referenceCount,url,abstract"
+	s2AuthorFields     = "authorId,name,affiliations,paperCount,citationCount,hIndex,url"
+)
+
+// semanticScholar is the Semantic Scholar Academic Graph API.
+type semanticScholar struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+type s2Paper struct {
+	PaperID string `json:"paperId"`
+	// ExternalIDs mixes strings with numbers such as CorpusId.
+	ExternalIDs map[string]interface{} `json:"externalIds"`
+	Title       string                 `json:"title"`
+	Year        int                    `json:"year"`
+	Venue       string                 `json:"venue"`
+	Authors     []struct {
+		Name string `json:"name"`
+	} `json:"authors"`
+	CitationCount  int    `json:"citationCount"`
+	ReferenceCount int    `json:"referenceCount"`
+	URL            string `json:"url"`
+	Abstract       string `json:"abstract"`
+}
+
+func (p *s2Paper) toPaper(source string) *scholarPaper {
+	out := &scholarPaper{
+		Title:       p.Title,
+		Year:        p.Year,
+		Venue:       p.Venue,
+		URL:         p.URL,
+		Abstract:    p.Abstract,
+		Citations:   p.CitationCount,
+		References:  p.ReferenceCount,
+		IDs:         map[string]string{source: p.PaperID},
+		CitationsBy: map[string]int{source: p.CitationCount},
+		Sources:     []string{source},
+	}
+	out.DOI, _ = p.ExternalIDs["DOI"].(string)
+	out.ArXivID, _ = p.ExternalIDs["ArXiv"].(string)
+	for _, a := range p.Authors {
+		out.Authors = append(out.Authors, a.Name)
+	}
+	out.normalizeIdentifiers()
+	return out
+}
+
+func (s *semanticScholar) name() string {
+	return "Semantic Scholar"
+}
+
+func (s *semanticScholar) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	var header map[string]string
+	if s.apiKey != "" {
+		header = map[string]string{"x-api-key": s.apiKey}
+	}
+	return scholarGet(ctx, s.client, s.baseURL+path+"?"+params.Encode(), header, out)
+}
+
+// pathID returns the identifier Semantic Scholar accepts for id.
+func (s *semanticScholar) pathID(id paperID) (string, error) {
+	switch id.kind {
+	case "doi":
+		return "DOI:" + id.value, nil
+	case "arxiv":
+		return "ARXIV:" + id.value, nil
+	case "s2":
+		return id.value, nil
+	}
+	return "", fmt.Errorf("Semantic Scholar cannot look up %s IDs; use a DOI or arXiv ID", id.kind)
+}
+
+// paperRef returns the identifier to use for a paper that may have come
+// from another source.
+func (s *semanticScholar) paperRef(p *scholarPaper) (string, error) {
+	switch {
+	case p.IDs[s.name()] != "":
+		return p.IDs[s.name()], nil
+	case p.DOI != "":
+		return "DOI:" + p.DOI, nil
+	case p.ArXivID != "":
+		return "ARXIV:" + p.ArXivID, nil
+	}
+	return "", fmt.Errorf("no identifier Semantic Scholar understands for %q", p.Title)
+}
+
+func (s *semanticScholar) paper(ctx context.Context, id paperID) (*scholarPaper, error) {
+	ref, err := s.pathID(id)
+	if err != nil {
+		return nil, err
+	}
+	var p s2Paper
+	if err := s.get(ctx, "/paper/"+escapeIDPath(ref), url.Values{"fields": {s2PaperFields}}, &p); err != nil {
+		return nil, err
+	}
+	return p.toPaper(s.name()), nil
+}
+
+func (s *semanticScholar) search(ctx context.Context, query string, limit int) ([]*scholarPaper, error) {
+	var resp struct {
+		Data []s2Paper `json:"data"`
+	}
+	params := url.Values{"query": {query}, "limit": {fmt.Sprint(limit)}, "fields": {s2PaperFields}}
+	if err := s.get(ctx, "/paper/search", params, &resp); err != nil {
+		return nil, err
+	}
+	var out []*scholarPaper
+	for i := range resp.Data {
+		out = append(out, resp.Data[i].toPaper(s.name()))
+	}
+	return out, nil
+}
+
+func (s *semanticScholar) citations(ctx context.Context, p *scholarPaper, limit int) ([]*scholarPaper, error) {
+	return s.edges(ctx, p, "citations", "citingPaper", limit)
+}
+
+func (s *semanticScholar) references(ctx context.Context, p *scholarPaper, limit int) ([]*scholarPaper, error) {
+	return s.edges(ctx, p, "references", "citedPaper", limit)
+}
+
+// edges lists one direction of a paper's citation edges; each item holds
+// the paper at the other end under key.
+func (s *semanticScholar) edges(ctx context.Context, p *scholarPaper, endpoint, key string, limit int) ([]*scholarPaper, error) {
+	ref, err := s.paperRef(p)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Data []map[string]*s2Paper `json:"data"`
+	}
+	params := url.Values{"limit": {fmt.Sprint(limit)}, "fields": {s2PaperFields}}
+	if err := s.get(ctx, "/paper/"+escapeIDPath(ref)+"/"+endpoint, params, &resp); err != nil {
+		return nil, err
+	}
+	var out []*scholarPaper
+	for _, item := range resp.Data {
+		// Papers Semantic Scholar only knows by title come back without an ID.
+		if other := item[key]; other != nil && other.PaperID != "" {
+			out = append(out, other.toPaper(s.name()))
+		}
+	}
+	return out, nil
+}
+
+func (s *semanticScholar) authors(ctx context.Context, query string, limit int) ([]*scholarAuthor, error) {
+	var resp struct {
+		Data []struct {
+			AuthorID      string   `json:"authorId"`
+			Name          string   `json:"name"`
+			Affiliations  []string `json:"affiliations"`
+			PaperCount    int      `json:"paperCount"`
+			CitationCount int      `json:"citationCount"`
+			HIndex        int      `json:"hIndex"`
+			URL           string   `json:"url"`
+		} `json:"data"`
+	}
+	params := url.Values{"query": {query}, "limit": {fmt.Sprint(limit)}, "fields": {s2AuthorFields}}
+	if err := s.get(ctx, "/author/search", params, &resp); err != nil {
+		return nil, err
+	}
+	var out []*scholarAuthor
+	for _, a := range resp.Data {
+		out = append(out, &scholarAuthor{
+			ID:           a.AuthorID,
+			Name:         strings.TrimSpace(a.Name),
+			Affiliations: a.Affiliations,
+			Papers:       a.PaperCount,
+			Citations:    a.CitationCount,
+			HIndex:       a.HIndex,
+			URL:          a.URL,
+		})
+	}
+	return out, nil
+}
@@ -19,9 +19,23 @@ type ExecTool struct {
 	denyPatterns        []*regexp.Regexp
 	allowPatterns       []*regexp.Regexp
 	restrictToWorkspace bool
+	paths               *PathPolicy
+	sandbox             Sandbox
+	maxOutputChars      int
+	envPassthrough      []string
+	envRedact           []string
 }
 
-func NewExecTool(workingDir string, restrict bool) *ExecTool {
+const defaultExecMaxOutputChars = 10000
+
+// alwaysPassedEnv are kept even when an env passthrough list is set;
+// commands rarely work without them.
+var alwaysPassedEnv = []string{"PATH", "HOME"}
+
+// NewExecTool creates the exec tool running commands in workingDir, which is
+// also the workspace the command guard confines paths to when restrict is
+// set, together with roots.
+func NewExecTool(workingDir string, restrict bool, roots ...PathRoot) *ExecTool {
 	denyPatterns := []*regexp.Regexp{
 		regexp.MustCompile(`\brm\s+-[rf]{1,2}\b`),
 		regexp.MustCompile(`\bdel\s+/[fq]\b`),
@@ -39,6 +53,8 @@ func NewExecTool(workingDir string, restrict bool) *ExecTool {
 		denyPatterns:        denyPatterns,
 		allowPatterns:       nil,
 		restrictToWorkspace: restrict,
+		paths:               NewPathPolicy(workingDir, restrict, roots...),
+		maxOutputChars:      defaultExecMaxOutputChars,
 	}
 }
 
@@ -76,6 +92,13 @@ func (t *ExecTool) Execute(ctx context.Context, args map[string]interface{}) *To
 	cwd := t.workingDir
 	if wd, ok := args["working_dir"].(string); ok && wd != "" {
 		cwd = wd
+		if t.restrictToWorkspace {
+			resolved, err := t.paths.Resolve(wd, PathRead)
+			if err != nil {
+				return ErrorResult(fmt.Sprintf("invalid working_dir: %v", err))
+			}
+			cwd = resolved
+		}
 	}
 
 	if cwd == "" {
@@ -85,7 +108,7 @@ func (t *ExecTool) Execute(ctx context.Context, args map[string]interface{}) *To
 		}
 	}
 
-	if guardError := t.guardCommand(command, cwd); guardError != "" {
+	if guardError := t.guardCommand(command); guardError != "" {
 		return ErrorResult(guardError)
 	}
 
@@ -93,7 +116,15 @@ func (t *ExecTool) Execute(ctx context.Context, args map[string]interface{}) *To
 	defer cancel()
 
 	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
+	if t.sandbox != nil {
+		sandboxed, cleanup, err := t.sandbox.Command(cmdCtx, command, cwd)
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("failed to prepare sandbox: %v", err))
+		}
+		defer cleanup()
+		cmd = sandboxed
+		cmd.Env = append(t.environ(), sandboxOnlyEnv(cmd.Env)...)
+	} else if runtime.GOOS == "windows" {
 		cmd = exec.CommandContext(cmdCtx, "powershell", "-NoProfile", "-NonInteractive", "-Command", command)
 	} else {
 		cmd = exec.CommandContext(cmdCtx, "sh", "-c", command)
@@ -101,6 +132,9 @@ func (t *ExecTool) Execute(ctx context.Context, args map[string]interface{}) *To
 	if cwd != "" {
 		cmd.Dir = cwd
 	}
+	if cmd.Env == nil {
+		cmd.Env = t.environ()
+	}
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -128,7 +162,8 @@ func (t *ExecTool) Execute(ctx context.Context, args map[string]interface{}) *To
 		output = "(no output)"
 	}
 
-	maxLen := 10000
+	output = t.redactOutput(output)
+	maxLen := t.maxOutputChars
 	if len(output) > maxLen {
 		output = output[:maxLen] + fmt.Sprintf("\n... (truncated, %d more chars)", len(output)-maxLen)
 	}
@@ -148,7 +183,7 @@ func (t *ExecTool) Execute(ctx context.Context, args map[string]interface{}) *To
 	}
 }
 
-func (t *ExecTool) guardCommand(command, cwd string) string {
+func (t *ExecTool) guardCommand(command string) string {
 	cmd := strings.TrimSpace(command)
 	lower := strings.ToLower(cmd)
 
@@ -171,32 +206,25 @@ func (t *ExecTool) guardCommand(command, cwd string) string {
 		}
 	}
 
-	if t.restrictToWorkspace {
+	// A sandbox enforces the workspace boundary itself; the path scan below
+	// is only a best-effort fallback without one.
+	if t.restrictToWorkspace && t.sandbox == nil {
 		if strings.Contains(cmd, "..\\") || strings.Contains(cmd, "../") {
 			return "Command blocked by safety guard (path traversal detected)"
 		}
 
-		cwdPath, err := filepath.Abs(cwd)
-		if err != nil {
-			return ""
-		}
-
+		// Absolute paths must resolve, symlinks included, into the
+		// workspace or an extra root. Read-only roots cannot be enforced
+		// here: the guard cannot tell which paths a command writes.
 		pathPattern := regexp.MustCompile(`[A-Za-z]:\\[^\\\"']+|/[^\s\"']+`)
 		matches := pathPattern.FindAllString(cmd, -1)
 
 		for _, raw := range matches {
-			p, err := filepath.Abs(raw)
-			if err != nil {
-				continue
-			}
-
-			rel, err := filepath.Rel(cwdPath, p)
-			if err != nil {
+			if !filepath.IsAbs(raw) {
 				continue
 			}
-
-			if strings.HasPrefix(rel, "..") {
-				return "Command blocked by safety guard (path outside working dir)"
+			if _, err := t.paths.Resolve(raw, PathRead); err != nil {
+				return "Command blocked by safety guard (path outside the workspace)"
 			}
 		}
 	}
@@ -208,8 +236,100 @@ func (t *ExecTool) SetTimeout(timeout time.Duration) {
 	t.timeout = timeout
 }
 
+// SetSandbox runs commands inside s; nil runs them directly, protected only
+// by the command guard.
+func (t *ExecTool) SetSandbox(s Sandbox) {
+	t.sandbox = s
+}
+
+// SetWorkingDir changes the default directory commands run in.
+func (t *ExecTool) SetWorkingDir(dir string) {
+	t.workingDir = dir
+}
+
+// SetMaxOutput limits the characters of output returned to the model.
+func (t *ExecTool) SetMaxOutput(chars int) {
+	if chars > 0 {
+		t.maxOutputChars = chars
+	}
+}
+
+// SetEnvPolicy limits the environment passed to commands. When passthrough
+// is non-empty only matching variables (plus PATH and HOME) are passed;
+// variables matching redact are never passed and their values are masked in
+// command output. Both take shell-style globs such as "*_API_KEY", matched
+// case-insensitively.
+func (t *ExecTool) SetEnvPolicy(passthrough, redact []string) error {
+	for _, p := range append(append([]string{}, passthrough...), redact...) {
+		if _, err := filepath.Match(strings.ToUpper(p), ""); err != nil {
+			return fmt.Errorf("invalid environment pattern %q: %w", p, err)
+		}
+	}
+	t.envPassthrough = passthrough
+	t.envRedact = redact
+	return nil
+}
+
+// environ returns the environment for commands after applying the env policy.
+func (t *ExecTool) environ() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if matchEnvName(t.envRedact, name) {
+			continue
+		}
+		if len(t.envPassthrough) > 0 && !matchEnvName(t.envPassthrough, name) && !matchEnvName(alwaysPassedEnv, name) {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+// redactOutput masks the values of redacted variables of this process, in
+// case a command read them from elsewhere, e.g. /proc or a config file.
+func (t *ExecTool) redactOutput(output string) string {
+	if len(t.envRedact) == 0 {
+		return output
+	}
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		// Short values would mask unrelated text.
+		if len(value) < 8 || !matchEnvName(t.envRedact, name) {
+			continue
+		}
+		output = strings.ReplaceAll(output, value, "[REDACTED:"+name+"]")
+	}
+	return output
+}
+
+func matchEnvName(patterns []string, name string) bool {
+	name = strings.ToUpper(name)
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(strings.ToUpper(p), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *ExecTool) SetRestrictToWorkspace(restrict bool) {
 	t.restrictToWorkspace = restrict
+	t.paths = NewPathPolicy(t.paths.workspace, restrict, t.paths.roots...)
+}
+
+// SetDenyPatterns adds patterns to the built-in deny list.
+func (t *ExecTool) SetDenyPatterns(patterns []string) error {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("invalid deny pattern %q: %w", p, err)
+		}
+		compiled = append(compiled, re)
+	}
+	t.denyPatterns = append(t.denyPatterns, compiled...)
+	return nil
 }
 
 func (t *ExecTool) SetAllowPatterns(patterns []string) error {
@@ -0,0 +1,747 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultShellRunTimeout = 30 * time.Second
+	maxShellRunTimeout     = 10 * time.Minute
+	shellStartTimeout      = 10 * time.Second
+	shellInterruptGrace    = 2 * time.Second
+	defaultShellSendWait   = time.Second
+	maxShellReadWait       = 60 * time.Second
+	shellBufferBytes       = 256 << 10
+	processBufferBytes     = 64 << 10
+	maxBackgroundProcesses = 8
+)
+
+// shellNames are programs that read commands from their input. Lines sent to
+// a background process started as one of them pass the command guard, like
+// commands given to run and start.
+var shellNames = map[string]bool{
+	"sh": true, "bash": true, "dash": true, "zsh": true, "ksh": true, "mksh": true,
+	"ash": true, "fish": true, "csh": true, "tcsh": true, "busybox": true,
+}
+
+// lineBreakRe splits terminal input into lines; Enter may arrive as CR.
+var lineBreakRe = regexp.MustCompile(`\r\n|\r|\n`)
+
+// shellEnv keeps programs from paging or colouring output meant for the model.
+var shellEnv = []string{"TERM=dumb", "PAGER=cat", "GIT_PAGER=cat", "PS1=", "PS2="}
+
+// ShellSessionTool gives each conversation a persistent shell on a
+// pseudo-terminal, so the working directory, environment variables and
+// activated virtualenvs carry over between commands, plus background
+// processes whose output can be followed and which can be sent input.
+// Commands, and lines sent to background shells, pass the exec tool's guard;
+// commands run in its sandbox, if any.
+type ShellSessionTool struct {
+	exec *ExecTool
+
+	mu         sync.Mutex
+	sessionKey string
+	sessions   map[string]*shellSession
+}
+
+type shellSession struct {
+	// mu serializes actions; the shell runs one command at a time.
+	mu     sync.Mutex
+	shell  *terminalProcess
+	marker *regexp.Regexp
+	nonce  string
+	cwd    string
+	nextID int
+	procs  map[string]*backgroundProcess
+	closed bool
+}
+
+type backgroundProcess struct {
+	*terminalProcess
+	id      string
+	command string
+	offset  int64
+	// shell is set for interactive shells; partial holds input sent to one
+	// since the last line break, so a command split across sends is still
+	// checked as a whole.
+	shell   bool
+	partial string
+}
+
+// terminalProcess is a process attached to a terminal whose output is
+// collected into a bounded buffer.
+type terminalProcess struct {
+	cmd      *exec.Cmd
+	term     io.ReadWriteCloser
+	out      *outputBuffer
+	started  time.Time
+	done     chan struct{}
+	exitCode int
+}
+
+func NewShellSessionTool(execTool *ExecTool) *ShellSessionTool {
+	return &ShellSessionTool{
+		exec:     execTool,
+		sessions: make(map[string]*shellSession),
+	}
+}
+
+func (t *ShellSessionTool) Name() string {
+	return "shell"
+}
+
+func (t *ShellSessionTool) Description() string {
+	return "Persistent shell for this conversation: unlike exec, `cd`, exported variables and activated virtualenvs carry over between run calls. " +
+		"Use start for long-running or interactive programs (servers, watchers, REPLs), then read their output, send them input, or kill them. " +
+		"Use reset to discard the shell and all its processes."
+}
+
+func (t *ShellSessionTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"run", "start", "send", "read", "kill", "list", "reset"},
+				"description": "run a command in the shell and wait for it; start a background process; send input to a process; read its new output; kill it; list processes; reset the session",
+			},
+			"command": map[string]interface{}{
+				"type":        "string",
+				"description": "Shell command (run, start). Commands started with run get no input; use start for programs that read from the terminal.",
+			},
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "Background process id returned by start (send, read, kill)",
+			},
+			"input": map[string]interface{}{
+				"type":        "string",
+				"description": "Text to write to the process (send)",
+			},
+			"enter": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Press Enter after the input (send, default true)",
+			},
+			"timeout": map[string]interface{}{
+				"type":        "integer",
+				"description": "Seconds to wait for run to finish before interrupting it (default 30, max 600)",
+			},
+			"wait": map[string]interface{}{
+				"type":        "number",
+				"description": "Seconds to wait for new output (send default 1, read default 0, max 60)",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+// SetContext selects the conversation whose shell is used.
+func (t *ShellSessionTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sessionKey = channel + ":" + chatID
+}
+
+func (t *ShellSessionTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	action, _ := args["action"].(string)
+	if action == "" {
+		return ErrorResult("action is required")
+	}
+
+	t.mu.Lock()
+	key := t.sessionKey
+	if key == "" {
+		key = "default"
+	}
+	if action == "reset" {
+		sess := t.sessions[key]
+		delete(t.sessions, key)
+		t.mu.Unlock()
+		if sess == nil {
+			return NewToolResult("No shell session is open.")
+		}
+		sess.close()
+		return NewToolResult("Shell session reset; all its processes were stopped.")
+	}
+	sess, ok := t.sessions[key]
+	if !ok {
+		sess = &shellSession{cwd: t.exec.workingDir, procs: make(map[string]*backgroundProcess), nextID: 1}
+		t.sessions[key] = sess
+	}
+	t.mu.Unlock()
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.closed {
+		return ErrorResult("shell session was reset, try again")
+	}
+
+	switch action {
+	case "run":
+		return t.run(ctx, sess, args)
+	case "start":
+		return t.start(ctx, sess, args)
+	case "send":
+		return t.send(ctx, sess, args)
+	case "read":
+		return t.read(ctx, sess, args)
+	case "kill":
+		return t.kill(sess, args)
+	case "list":
+		return t.list(sess)
+	default:
+		return ErrorResult(fmt.Sprintf("unknown action: %s", action))
+	}
+}
+
+// ResetSession stops the shell and background processes of a conversation.
+func (t *ShellSessionTool) ResetSession(channel, chatID string) {
+	t.mu.Lock()
+	key := channel + ":" + chatID
+	sess := t.sessions[key]
+	delete(t.sessions, key)
+	t.mu.Unlock()
+	if sess != nil {
+		sess.close()
+	}
+}
+
+// Close stops all shells and background processes.
+func (t *ShellSessionTool) Close() error {
+	t.mu.Lock()
+	sessions := t.sessions
+	t.sessions = make(map[string]*shellSession)
+	t.mu.Unlock()
+	for _, sess := range sessions {
+		sess.close()
+	}
+	return nil
+}
+
+func (t *ShellSessionTool) run(ctx context.Context, sess *shellSession, args map[string]interface{}) *ToolResult {
+	command, _ := args["command"].(string)
+	if strings.TrimSpace(command) == "" {
+		return ErrorResult("command is required")
+	}
+	if guardError := t.exec.guardCommand(command); guardError != "" {
+		return ErrorResult(guardError)
+	}
+	timeout := defaultShellRunTimeout
+	if v, ok := args["timeout"].(float64); ok && v > 0 {
+		timeout = time.Duration(v) * time.Second
+		if timeout > maxShellRunTimeout {
+			timeout = maxShellRunTimeout
+		}
+	}
+
+	if err := t.ensureShell(ctx, sess); err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	prevCwd := sess.cwd
+	res, err := sess.exec(ctx, command, timeout)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	output := strings.TrimRight(res.output, "\n")
+	if output == "" {
+		output = "(no output)"
+	}
+	output = truncateTail(t.exec.redactOutput(output), t.exec.maxOutputChars)
+	if res.dropped > 0 {
+		output = fmt.Sprintf("... (%d earlier bytes dropped)\n", res.dropped) + output
+	}
+
+	switch {
+	case res.shellLost:
+		output += "\nThe shell exited or stopped responding and was discarded; the next command starts a fresh shell."
+		return ErrorResult(output)
+	case res.timedOut:
+		output += fmt.Sprintf("\nCommand timed out after %v and was interrupted. Use start for long-running commands.", timeout)
+		return ErrorResult(output)
+	}
+	if sess.cwd != prevCwd {
+		output += "\n(cwd: " + sess.cwd + ")"
+	}
+	if res.exitCode != 0 {
+		output += fmt.Sprintf("\nExit code: %d", res.exitCode)
+		return ErrorResult(output)
+	}
+	return NewToolResult(output)
+}
+
+func (t *ShellSessionTool) start(ctx context.Context, sess *shellSession, args map[string]interface{}) *ToolResult {
+	command, _ := args["command"].(string)
+	if strings.TrimSpace(command) == "" {
+		return ErrorResult("command is required")
+	}
+	if guardError := t.exec.guardCommand(command); guardError != "" {
+		return ErrorResult(guardError)
+	}
+	if !sess.pruneProcesses() {
+		return ErrorResult(fmt.Sprintf("too many background processes (max %d); kill one first", maxBackgroundProcesses))
+	}
+
+	// Background processes inherit the shell's directory and environment.
+	env := t.exec.environ()
+	if sess.shell != nil {
+		if shellVars, err := sess.environment(ctx); err == nil {
+			env = shellVars
+		}
+	}
+	env = append(env, shellEnv...)
+
+	var cmd *exec.Cmd
+	cleanup := func() {}
+	if t.exec.sandbox != nil {
+		sandboxed, done, err := t.exec.sandbox.Command(context.Background(), command, sess.cwd)
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("failed to prepare sandbox: %v", err))
+		}
+		cmd, cleanup = sandboxed, done
+		cmd.Env = append(env, sandboxOnlyEnv(cmd.Env)...)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+		cmd.Dir = sess.cwd
+		cmd.Env = env
+	}
+
+	proc, err := startTerminalProcess(cmd, processBufferBytes, cleanup)
+	if err != nil {
+		cleanup()
+		return ErrorResult(fmt.Sprintf("failed to start process: %v", err))
+	}
+	id := "p" + strconv.Itoa(sess.nextID)
+	sess.nextID++
+	sess.procs[id] = &backgroundProcess{terminalProcess: proc, id: id, command: command, shell: isShellCommand(command)}
+
+	// Give the process a moment so immediate failures are reported here.
+	proc.out.wait(ctx, 0, 500*time.Millisecond)
+	return t.readProcess(ctx, sess.procs[id], 0, fmt.Sprintf("Started %s (pid %d).", id, cmd.Process.Pid))
+}
+
+func (t *ShellSessionTool) send(ctx context.Context, sess *shellSession, args map[string]interface{}) *ToolResult {
+	proc, errResult := sess.process(args)
+	if errResult != nil {
+		return errResult
+	}
+	input, _ := args["input"].(string)
+	if enter, ok := args["enter"].(bool); !ok || enter {
+		input += "\n"
+	}
+	if input == "" {
+		return ErrorResult("input is required")
+	}
+	if proc.exited() {
+		return t.readProcess(ctx, proc, 0, fmt.Sprintf("%s has exited; input not sent.", proc.id))
+	}
+	var partial string
+	if proc.shell {
+		lines := lineBreakRe.Split(proc.partial+input, -1)
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if guardError := t.exec.guardCommand(line); guardError != "" {
+				return ErrorResult(guardError)
+			}
+		}
+		partial = lines[len(lines)-1]
+	}
+	if _, err := io.WriteString(proc.term, input); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to write to %s: %v", proc.id, err))
+	}
+	proc.partial = partial
+	return t.readProcess(ctx, proc, waitArg(args, defaultShellSendWait), fmt.Sprintf("Sent %d bytes to %s.", len(input), proc.id))
+}
+
+// isShellCommand reports whether command starts an interactive shell, looking
+// past exec, env and variable assignments. It cannot see shells spawned by
+// other programs; a sandbox remains the real boundary.
+func isShellCommand(command string) bool {
+	for _, word := range strings.Fields(command) {
+		switch {
+		case word == "exec" || word == "env" || word == "command" || strings.Contains(word, "="):
+			continue
+		case strings.HasPrefix(word, "-") && word != "-":
+			continue
+		}
+		return shellNames[strings.TrimPrefix(filepath.Base(word), "-")]
+	}
+	return false
+}
+
+func (t *ShellSessionTool) read(ctx context.Context, sess *shellSession, args map[string]interface{}) *ToolResult {
+	proc, errResult := sess.process(args)
+	if errResult != nil {
+		return errResult
+	}
+	return t.readProcess(ctx, proc, waitArg(args, 0), "")
+}
+
+func (t *ShellSessionTool) kill(sess *shellSession, args map[string]interface{}) *ToolResult {
+	proc, errResult := sess.process(args)
+	if errResult != nil {
+		return errResult
+	}
+	proc.stop()
+	delete(sess.procs, proc.id)
+	return t.readProcess(context.Background(), proc, 0, fmt.Sprintf("Killed %s.", proc.id))
+}
+
+func (t *ShellSessionTool) list(sess *shellSession) *ToolResult {
+	var sb strings.Builder
+	if sess.shell != nil {
+		fmt.Fprintf(&sb, "Shell running in %s\n", sess.cwd)
+	} else {
+		fmt.Fprintf(&sb, "Shell not started (starts in %s)\n", sess.cwd)
+	}
+	if len(sess.procs) == 0 {
+		sb.WriteString("No background processes.")
+		return NewToolResult(sb.String())
+	}
+	ids := make([]string, 0, len(sess.procs))
+	for id := range sess.procs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return processNumber(ids[i]) < processNumber(ids[j]) })
+	sb.WriteString("Background processes:")
+	for _, id := range ids {
+		proc := sess.procs[id]
+		fmt.Fprintf(&sb, "\n- %s [%s] %s", id, proc.status(), proc.command)
+	}
+	return NewToolResult(sb.String())
+}
+
+// readProcess returns the output of proc since the last read, waiting up to
+// wait for some to arrive.
+func (t *ShellSessionTool) readProcess(ctx context.Context, proc *backgroundProcess, wait time.Duration, header string) *ToolResult {
+	if wait > 0 && !proc.exited() {
+		proc.out.wait(ctx, proc.offset, wait)
+	}
+	data, next, dropped := proc.out.readFrom(proc.offset)
+	proc.offset = next
+
+	var sb strings.Builder
+	if header != "" {
+		sb.WriteString(header + "\n")
+	}
+	fmt.Fprintf(&sb, "%s [%s] %s\n", proc.id, proc.status(), proc.command)
+	if dropped > 0 {
+		fmt.Fprintf(&sb, "... (%d bytes of output dropped since the last read)\n", dropped)
+	}
+	if len(data) == 0 {
+		sb.WriteString("(no new output)")
+	} else {
+		sb.WriteString(truncateTail(t.exec.redactOutput(string(data)), t.exec.maxOutputChars))
+	}
+	return NewToolResult(sb.String())
+}
+
+// ensureShell starts the session's shell if it is not running.
+func (t *ShellSessionTool) ensureShell(ctx context.Context, sess *shellSession) error {
+	if sess.shell != nil && !sess.shell.exited() {
+		return nil
+	}
+	if sess.shell != nil {
+		sess.shell.stop()
+		sess.shell = nil
+	}
+
+	shell := "sh -i"
+	if path, err := exec.LookPath("bash"); err == nil {
+		shell = path + " --norc --noprofile --noediting -i"
+	}
+
+	var cmd *exec.Cmd
+	cleanup := func() {}
+	if t.exec.sandbox != nil {
+		sandboxed, done, err := t.exec.sandbox.Command(context.Background(), "exec "+shell, sess.cwd)
+		if err != nil {
+			return fmt.Errorf("failed to prepare sandbox: %v", err)
+		}
+		cmd, cleanup = sandboxed, done
+		cmd.Env = append(t.exec.environ(), sandboxOnlyEnv(cmd.Env)...)
+	} else {
+		fields := strings.Fields(shell)
+		cmd = exec.Command(fields[0], fields[1:]...)
+		cmd.Dir = sess.cwd
+		cmd.Env = t.exec.environ()
+	}
+	cmd.Env = append(cmd.Env, shellEnv...)
+
+	proc, err := startTerminalProcess(cmd, shellBufferBytes, cleanup)
+	if err != nil {
+		cleanup()
+		return fmt.Errorf("failed to start shell: %v", err)
+	}
+
+	nonce := make([]byte, 8)
+	rand.Read(nonce)
+	sess.shell = proc
+	sess.nonce = hex.EncodeToString(nonce)
+	sess.marker = regexp.MustCompile(`\n?__SUMMER_` + sess.nonce + ` (\d+) ([^\n]*)\n`)
+
+	// Interactive shells print prompts; clear them and wait until the shell
+	// answers before handing it commands.
+	res, err := sess.exec(ctx, "PS1=''; PS2=''; PROMPT_COMMAND=''; unset HISTFILE", shellStartTimeout)
+	if err == nil && (res.timedOut || res.shellLost) {
+		err = fmt.Errorf("shell did not start: %s", strings.TrimSpace(res.output))
+	}
+	if err != nil {
+		sess.shell.stop()
+		sess.shell = nil
+		return err
+	}
+	return nil
+}
+
+type shellResult struct {
+	output    string
+	dropped   int64
+	exitCode  int
+	timedOut  bool
+	shellLost bool
+}
+
+// exec runs command in the shell and collects its output up to the marker
+// printed after it. Commands read from /dev/null so they cannot consume the
+// marker line; on timeout the command is interrupted with Ctrl-C, and if the
+// shell does not recover it is discarded.
+func (s *shellSession) exec(ctx context.Context, command string, timeout time.Duration) (shellResult, error) {
+	start := s.shell.out.end()
+	script := "{\n" + command + "\n} </dev/null\n" +
+		"printf '\\n__SUMMER_%s %d %s\\n' " + s.nonce + " \"$?\" \"$PWD\"\n"
+	if _, err := io.WriteString(s.shell.term, script); err != nil {
+		s.discardShell()
+		return shellResult{}, fmt.Errorf("failed to write to shell: %v", err)
+	}
+
+	var res shellResult
+	deadline := time.Now().Add(timeout)
+	interrupted, closed := false, false
+	for {
+		data, next, dropped := s.shell.out.readFrom(start)
+		if loc := s.marker.FindSubmatchIndex(data); loc != nil {
+			res.output = string(data[:loc[0]])
+			res.dropped = dropped
+			res.exitCode, _ = strconv.Atoi(string(data[loc[2]:loc[3]]))
+			res.timedOut = interrupted
+			s.cwd = string(data[loc[4]:loc[5]])
+			return res, nil
+		}
+		if closed {
+			res.output, res.dropped, res.shellLost = string(data), dropped, true
+			s.discardShell()
+			return res, nil
+		}
+
+		closed = s.shell.out.wait(ctx, next, time.Until(deadline))
+		if !closed && (ctx.Err() != nil || time.Now().After(deadline)) {
+			if interrupted || ctx.Err() != nil {
+				res.output, res.dropped, res.shellLost = string(data), dropped, true
+				s.discardShell()
+				return res, nil
+			}
+			interrupted = true
+			io.WriteString(s.shell.term, "\x03")
+			deadline = time.Now().Add(shellInterruptGrace)
+		}
+	}
+}
+
+// environment returns the exported variables of the shell.
+func (s *shellSession) environment(ctx context.Context) ([]string, error) {
+	res, err := s.exec(ctx, "env", shellStartTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if res.exitCode != 0 || res.timedOut || res.shellLost || res.dropped > 0 {
+		return nil, fmt.Errorf("failed to read shell environment")
+	}
+	// Values may span lines; a line only starts a new variable if it looks
+	// like NAME=value.
+	nameRe := regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+	var env []string
+	for _, line := range strings.Split(res.output, "\n") {
+		if nameRe.MatchString(line) || len(env) == 0 {
+			env = append(env, line)
+		} else {
+			env[len(env)-1] += "\n" + line
+		}
+	}
+	return env, nil
+}
+
+func (s *shellSession) process(args map[string]interface{}) (*backgroundProcess, *ToolResult) {
+	id, _ := args["id"].(string)
+	if id == "" {
+		return nil, ErrorResult("id is required")
+	}
+	proc, ok := s.procs[id]
+	if !ok {
+		return nil, ErrorResult(fmt.Sprintf("no background process %q (use action=list)", id))
+	}
+	return proc, nil
+}
+
+// pruneProcesses makes room for a new background process by forgetting the
+// oldest exited one. It reports false if all slots hold running processes.
+func (s *shellSession) pruneProcesses() bool {
+	if len(s.procs) < maxBackgroundProcesses {
+		return true
+	}
+	oldest := ""
+	for id, proc := range s.procs {
+		if proc.exited() && (oldest == "" || processNumber(id) < processNumber(oldest)) {
+			oldest = id
+		}
+	}
+	if oldest == "" {
+		return false
+	}
+	delete(s.procs, oldest)
+	return true
+}
+
+func (s *shellSession) discardShell() {
+	if s.shell != nil {
+		s.shell.stop()
+		s.shell = nil
+	}
+}
+
+func (s *shellSession) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.discardShell()
+	for id, proc := range s.procs {
+		proc.stop()
+		delete(s.procs, id)
+	}
+}
+
+// startTerminalProcess starts cmd on a terminal and collects its output into
+// a buffer of bufferBytes. cleanup runs once the process has exited.
+func startTerminalProcess(cmd *exec.Cmd, bufferBytes int, cleanup func()) (*terminalProcess, error) {
+	term, err := startTerminal(cmd)
+	if err != nil {
+		return nil, err
+	}
+	p := &terminalProcess{
+		cmd:     cmd,
+		term:    term,
+		out:     newOutputBuffer(bufferBytes),
+		started: time.Now(),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		io.Copy(p.out, term)
+		p.out.Close()
+	}()
+	go func() {
+		err := cmd.Wait()
+		p.exitCode = 0
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			p.exitCode = exitErr.ExitCode()
+		} else if err != nil {
+			p.exitCode = -1
+		}
+		cleanup()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+func (p *terminalProcess) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *terminalProcess) status() string {
+	if !p.exited() {
+		return fmt.Sprintf("running %s, pid %d", time.Since(p.started).Round(time.Second), p.cmd.Process.Pid)
+	}
+	if p.exitCode < 0 {
+		return "killed"
+	}
+	return fmt.Sprintf("exited %d", p.exitCode)
+}
+
+// stop hangs up the terminal and terminates the process group, killing it
+// if it does not exit promptly.
+func (p *terminalProcess) stop() {
+	if !p.exited() {
+		killProcessGroup(p.cmd, syscall.SIGHUP)
+		killProcessGroup(p.cmd, syscall.SIGTERM)
+		select {
+		case <-p.done:
+		case <-time.After(shellInterruptGrace):
+			killProcessGroup(p.cmd, syscall.SIGKILL)
+			<-p.done
+		}
+	}
+	p.term.Close()
+}
+
+// sandboxOnlyEnv returns the variables a sandbox added to the environment,
+// such as its private TMPDIR, so they can be kept when the environment is
+// replaced.
+func sandboxOnlyEnv(env []string) []string {
+	inherited := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		inherited[kv] = true
+	}
+	var added []string
+	for _, kv := range env {
+		if !inherited[kv] {
+			added = append(added, kv)
+		}
+	}
+	return added
+}
+
+func waitArg(args map[string]interface{}, def time.Duration) time.Duration {
+	v, ok := args["wait"].(float64)
+	if !ok || v < 0 {
+		return def
+	}
+	wait := time.Duration(v * float64(time.Second))
+	if wait > maxShellReadWait {
+		wait = maxShellReadWait
+	}
+	return wait
+}
+
+func processNumber(id string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(id, "p"))
+	return n
+}
+
+// truncateTail keeps the last max characters of s; for command output the
+// end is usually what matters.
+func truncateTail(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return fmt.Sprintf("... (truncated, %d earlier chars)\n", len(s)-max) + s[len(s)-max:]
+}
@@ -0,0 +1,232 @@
+package tools
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestShellSessionTool(t *testing.T) *ShellSessionTool {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell sessions need a POSIX shell")
+	}
+	tool := NewShellSessionTool(NewExecTool(t.TempDir(), false))
+	t.Cleanup(func() { tool.Close() })
+	return tool
+}
+
+func TestShellSessionTool_StatePersists(t *testing.T) {
+	tool := newTestShellSessionTool(t)
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]interface{}{"action": "run", "command": "mkdir -p sub && cd sub && export GREETING=hello"})
+	if result.IsError || !strings.Contains(result.ForLLM, "(cwd: ") {
+		t.Fatalf("expected cd to report the new directory, got: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "run", "command": "echo $GREETING from $(basename $PWD)"})
+	if result.IsError || strings.TrimSpace(result.ForLLM) != "hello from sub" {
+		t.Errorf("expected directory and variable to persist, got: %q", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "run", "command": "echo oops >&2; exit_code() { return 3; }; exit_code"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "oops") || !strings.Contains(result.ForLLM, "Exit code: 3") {
+		t.Errorf("expected stderr and exit code, got: %s", result.ForLLM)
+	}
+}
+
+func TestShellSessionTool_SessionPerConversation(t *testing.T) {
+	tool := newTestShellSessionTool(t)
+	ctx := context.Background()
+
+	tool.SetContext("telegram", "1")
+	tool.Execute(ctx, map[string]interface{}{"action": "run", "command": "export WHO=first"})
+	tool.SetContext("telegram", "2")
+	result := tool.Execute(ctx, map[string]interface{}{"action": "run", "command": "echo \"[$WHO]\""})
+	if strings.TrimSpace(result.ForLLM) != "[]" {
+		t.Errorf("expected conversations to have separate shells, got: %q", result.ForLLM)
+	}
+}
+
+func TestShellSessionTool_TimeoutInterrupts(t *testing.T) {
+	tool := newTestShellSessionTool(t)
+	ctx := context.Background()
+
+	tool.Execute(ctx, map[string]interface{}{"action": "run", "command": "export KEPT=yes"})
+	start := time.Now()
+	result := tool.Execute(ctx, map[string]interface{}{"action": "run", "command": "echo before; sleep 30", "timeout": 1.0})
+	if !result.IsError || !strings.Contains(result.ForLLM, "timed out") || !strings.Contains(result.ForLLM, "before") {
+		t.Errorf("expected timeout with partial output, got: %s", result.ForLLM)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected the command to be interrupted, took %v", elapsed)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "run", "command": "echo $KEPT"})
+	if strings.TrimSpace(result.ForLLM) != "yes" {
+		t.Errorf("expected the shell to survive the interrupt, got: %q", result.ForLLM)
+	}
+}
+
+func TestShellSessionTool_ShellExitStartsFresh(t *testing.T) {
+	tool := newTestShellSessionTool(t)
+	ctx := context.Background()
+
+	if result := tool.Execute(ctx, map[string]interface{}{"action": "run", "command": "exit"}); !result.IsError {
+		t.Errorf("expected exiting the shell to be reported, got: %s", result.ForLLM)
+	}
+	result := tool.Execute(ctx, map[string]interface{}{"action": "run", "command": "echo again"})
+	if result.IsError || strings.TrimSpace(result.ForLLM) != "again" {
+		t.Errorf("expected a fresh shell, got: %s", result.ForLLM)
+	}
+}
+
+func TestShellSessionTool_BackgroundProcess(t *testing.T) {
+	tool := newTestShellSessionTool(t)
+	ctx := context.Background()
+
+	tool.Execute(ctx, map[string]interface{}{"action": "run", "command": "export PREFIX=got"})
+	result := tool.Execute(ctx, map[string]interface{}{"action": "start", "command": `echo ready; while read line; do echo "$PREFIX $line"; done`})
+	if result.IsError || !strings.Contains(result.ForLLM, "Started p1") {
+		t.Fatalf("start: unexpected result: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "send", "id": "p1", "input": "ping", "wait": 5.0})
+	if result.IsError || !strings.Contains(result.ForLLM, "got ping") {
+		t.Errorf("send: expected the process to answer with the shell's environment, got: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "read", "id": "p1"})
+	if !strings.Contains(result.ForLLM, "(no new output)") {
+		t.Errorf("read: expected no new output, got: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "list"})
+	if !strings.Contains(result.ForLLM, "p1 [running") {
+		t.Errorf("list: expected p1 to be running, got: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "kill", "id": "p1"})
+	if result.IsError || !strings.Contains(result.ForLLM, "Killed p1") {
+		t.Errorf("kill: unexpected result: %s", result.ForLLM)
+	}
+	if result := tool.Execute(ctx, map[string]interface{}{"action": "read", "id": "p1"}); !result.IsError {
+		t.Errorf("read: expected killed process to be gone, got: %s", result.ForLLM)
+	}
+}
+
+func TestShellSessionTool_ProcessExit(t *testing.T) {
+	tool := newTestShellSessionTool(t)
+	ctx := context.Background()
+
+	tool.Execute(ctx, map[string]interface{}{"action": "start", "command": "echo done; exit 4"})
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		result := tool.Execute(ctx, map[string]interface{}{"action": "read", "id": "p1", "wait": 1.0})
+		if strings.Contains(result.ForLLM, "[exited 4]") {
+			return
+		}
+	}
+	t.Error("expected the process to be reported as exited with code 4")
+}
+
+func TestShellSessionTool_ResetStopsProcesses(t *testing.T) {
+	tool := newTestShellSessionTool(t)
+	ctx := context.Background()
+
+	tool.SetContext("cli", "direct")
+	tool.Execute(ctx, map[string]interface{}{"action": "run", "command": "export X=1"})
+	tool.Execute(ctx, map[string]interface{}{"action": "start", "command": "sleep 60"})
+
+	tool.mu.Lock()
+	sess := tool.sessions["cli:direct"]
+	tool.mu.Unlock()
+	proc := sess.procs["p1"]
+
+	tool.ResetSession("cli", "direct")
+	select {
+	case <-proc.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected reset to stop the background process")
+	}
+
+	result := tool.Execute(ctx, map[string]interface{}{"action": "list"})
+	if !strings.Contains(result.ForLLM, "Shell not started") || !strings.Contains(result.ForLLM, "No background processes") {
+		t.Errorf("expected a fresh session after reset, got: %s", result.ForLLM)
+	}
+}
+
+func TestShellSessionTool_Guard(t *testing.T) {
+	tool := newTestShellSessionTool(t)
+	for _, action := range []string{"run", "start"} {
+		result := tool.Execute(context.Background(), map[string]interface{}{"action": action, "command": "rm -rf /"})
+		if !result.IsError || !strings.Contains(result.ForLLM, "blocked") {
+			t.Errorf("%s: expected dangerous command to be blocked, got: %s", action, result.ForLLM)
+		}
+	}
+}
+
+func TestShellSessionTool_GuardsInputToShells(t *testing.T) {
+	tool := newTestShellSessionTool(t)
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]interface{}{"action": "start", "command": "exec sh"})
+	if result.IsError {
+		t.Fatalf("start: %s", result.ForLLM)
+	}
+	result = tool.Execute(ctx, map[string]interface{}{"action": "send", "id": "p1", "input": "rm -rf /"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "blocked") {
+		t.Errorf("send: expected dangerous input to a shell to be blocked, got: %s", result.ForLLM)
+	}
+	// A command split across sends is checked once the pieces are joined.
+	result = tool.Execute(ctx, map[string]interface{}{"action": "send", "id": "p1", "input": "shut", "enter": false})
+	if result.IsError {
+		t.Fatalf("send: %s", result.ForLLM)
+	}
+	result = tool.Execute(ctx, map[string]interface{}{"action": "send", "id": "p1", "input": "down -h now"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "blocked") {
+		t.Errorf("send: expected split dangerous input to be blocked, got: %s", result.ForLLM)
+	}
+	result = tool.Execute(ctx, map[string]interface{}{"action": "send", "id": "p1", "input": "\x15echo fine", "wait": 5.0})
+	if result.IsError || !strings.Contains(result.ForLLM, "fine") {
+		t.Errorf("send: expected a harmless command to run, got: %s", result.ForLLM)
+	}
+}
+
+func TestIsShellCommand(t *testing.T) {
+	for command, want := range map[string]bool{
+		"bash":                  true,
+		"exec /bin/zsh -i":      true,
+		"env -i FOO=1 bash":     true,
+		"python3 -i":            false,
+		"sleep 60":              false,
+		"npm run dev":           false,
+		"BASH_ENV=x /bin/sh -l": true,
+	} {
+		if got := isShellCommand(command); got != want {
+			t.Errorf("isShellCommand(%q) = %v, want %v", command, got, want)
+		}
+	}
+}
+
+func TestOutputBuffer_Bounded(t *testing.T) {
+	b := newOutputBuffer(8)
+	b.Write([]byte("0123456789"))
+	b.Write([]byte("ab"))
+
+	data, next, dropped := b.readFrom(0)
+	if string(data) != "456789ab" || next != 12 || dropped != 4 {
+		t.Errorf("expected last 8 bytes with 4 dropped, got %q next=%d dropped=%d", data, next, dropped)
+	}
+	if data, _, dropped := b.readFrom(10); string(data) != "ab" || dropped != 0 {
+		t.Errorf("expected output from offset 10, got %q dropped=%d", data, dropped)
+	}
+
+	go b.Close()
+	if !b.wait(context.Background(), 12, 5*time.Second) {
+		t.Errorf("expected wait to report the closed stream")
+	}
+}
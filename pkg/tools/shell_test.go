@@ -208,3 +208,65 @@ func TestShellTool_RestrictToWorkspace(t *testing.T) {
 		t.Errorf("Expected 'blocked' message for path traversal, got ForLLM: %s, ForUser: %s", result.ForLLM, result.ForUser)
 	}
 }
+
+// TestShellTool_EnvPolicy verifies passthrough and redaction of variables
+func TestShellTool_EnvPolicy(t *testing.T) {
+	t.Setenv("SUMMER_TEST_VISIBLE", "visible")
+	t.Setenv("SUMMER_TEST_API_KEY", "sk-test-0123456789")
+	t.Setenv("SUMMER_TEST_OTHER", "other")
+
+	tool := NewExecTool("", false)
+	if err := tool.SetEnvPolicy([]string{"summer_test_*"}, []string{"*_API_KEY"}); err != nil {
+		t.Fatalf("SetEnvPolicy: %v", err)
+	}
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"command": `echo "[$SUMMER_TEST_VISIBLE] [$SUMMER_TEST_API_KEY] [$SUMMER_TEST_OTHER]"; test -n "$PATH" && echo path-ok`,
+	})
+	if !strings.Contains(result.ForLLM, "[visible] [] [other]") || !strings.Contains(result.ForLLM, "path-ok") {
+		t.Errorf("Expected matching variables and PATH only, without the redacted key, got: %s", result.ForLLM)
+	}
+
+	tool.SetEnvPolicy([]string{"SUMMER_TEST_VISIBLE"}, []string{"*_API_KEY"})
+	result = tool.Execute(context.Background(), map[string]interface{}{"command": `echo "[$SUMMER_TEST_OTHER]"`})
+	if !strings.Contains(result.ForLLM, "[]") {
+		t.Errorf("Expected variables outside the passthrough list to be dropped, got: %s", result.ForLLM)
+	}
+
+	// A command that finds the secret elsewhere still gets it masked.
+	result = tool.Execute(context.Background(), map[string]interface{}{"command": "echo key=sk-test-0123456789"})
+	if strings.Contains(result.ForLLM, "sk-test-0123456789") || !strings.Contains(result.ForLLM, "[REDACTED:SUMMER_TEST_API_KEY]") {
+		t.Errorf("Expected the secret value to be masked, got: %s", result.ForLLM)
+	}
+
+	if err := tool.SetEnvPolicy(nil, []string{"[bad"}); err == nil {
+		t.Errorf("Expected error for invalid pattern")
+	}
+}
+
+// TestShellTool_ConfiguredLimits verifies max output and extra deny patterns
+func TestShellTool_ConfiguredLimits(t *testing.T) {
+	tool := NewExecTool("", false)
+	tool.SetMaxOutput(100)
+	if err := tool.SetDenyPatterns([]string{`\bcurl\b`}); err != nil {
+		t.Fatalf("SetDenyPatterns: %v", err)
+	}
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"command": "printf '%0300d' 0"})
+	if !strings.Contains(result.ForLLM, "truncated, 200 more chars") {
+		t.Errorf("Expected output truncated to 100 chars, got: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"command": "curl http://example.com"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "blocked") {
+		t.Errorf("Expected configured deny pattern to block the command, got: %s", result.ForLLM)
+	}
+	result = tool.Execute(context.Background(), map[string]interface{}{"command": "rm -rf /tmp/nothing"})
+	if !result.IsError {
+		t.Errorf("Expected built-in deny patterns to stay active")
+	}
+
+	if err := tool.SetDenyPatterns([]string{"("}); err == nil {
+		t.Errorf("Expected error for invalid pattern")
+	}
+}
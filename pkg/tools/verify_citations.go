@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/srikesh3005/summer/pkg/library"
+	"github.com/srikesh3005/summer/pkg/logger"
+)
+
+// maxCheckedCitations bounds the lookups made for one text.
+const maxCheckedCitations = 20
+
+// VerifyCitationsTool checks that the DOIs, arXiv IDs and paper titles in a
+// text resolve to real records in Crossref or arXiv.
+type VerifyCitationsTool struct {
+	resolver *library.Resolver
+}
+
+func NewVerifyCitationsTool(resolver *library.Resolver) *VerifyCitationsTool {
+	return &VerifyCitationsTool{resolver: resolver}
+}
+
+func (t *VerifyCitationsTool) Name() string {
+	return "verify_citations"
+}
+
+func (t *VerifyCitationsTool) Description() string {
+	return "Check the references in a draft answer: extracts DOIs, arXiv IDs and quoted paper titles and looks them up in Crossref and arXiv. " +
+		"Reports which references are verified, not found, or point to a different paper than claimed, and returns the text with bad references flagged or removed. " +
+		"Use it before presenting papers you cited from memory."
+}
+
+func (t *VerifyCitationsTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"text": map[string]interface{}{
+				"type":        "string",
+				"description": "Draft text containing the references to check",
+			},
+			"mode": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"report", library.CheckFlag, library.CheckRemove},
+				"description": "report: list results only; flag: also return the text with bad references marked [unverified]; remove: also drop bad reference-list entries (default: flag)",
+			},
+		},
+		"required": []string{"text"},
+	}
+}
+
+func (t *VerifyCitationsTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	text, _ := args["text"].(string)
+	if strings.TrimSpace(text) == "" {
+		return ErrorResult("text is required")
+	}
+	mode, _ := args["mode"].(string)
+	switch mode {
+	case "":
+		mode = library.CheckFlag
+	case "report", library.CheckFlag, library.CheckRemove:
+	default:
+		return ErrorResult(fmt.Sprintf("unknown mode: %s", mode))
+	}
+
+	cites := library.ExtractCitations(text)
+	if len(cites) == 0 {
+		return NewToolResult("No DOIs, arXiv IDs or quoted paper titles found.")
+	}
+	skipped := 0
+	if len(cites) > maxCheckedCitations {
+		skipped = len(cites) - maxCheckedCitations
+		cites = cites[:maxCheckedCitations]
+	}
+	verdicts := t.resolver.VerifyAll(ctx, cites)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Checked %d reference(s):\n", len(verdicts))
+	for _, v := range verdicts {
+		sb.WriteString(formatVerdict(v))
+		sb.WriteString("\n")
+	}
+	if skipped > 0 {
+		fmt.Fprintf(&sb, "(%d more not checked)\n", skipped)
+	}
+	if mode != "report" {
+		corrected, bad := library.ApplyVerdicts(text, verdicts, mode)
+		if bad > 0 {
+			fmt.Fprintf(&sb, "\nCorrected text:\n%s\n", corrected)
+		}
+	}
+	return NewToolResult(sb.String())
+}
+
+func formatVerdict(v library.Verdict) string {
+	ref := v.Text
+	if v.Kind == library.CitationTitle {
+		ref = fmt.Sprintf("%q", v.Title)
+	}
+	switch v.Status {
+	case library.StatusVerified:
+		if v.Entry != nil && v.Kind != library.CitationTitle {
+			return fmt.Sprintf("✓ %s: %s", ref, strings.Join(strings.Fields(v.Entry.Title), " "))
+		}
+		if v.Entry != nil && v.Entry.DOI != "" {
+			return fmt.Sprintf("✓ %s: doi:%s", ref, v.Entry.DOI)
+		}
+		if v.Entry != nil && v.Entry.ArXivID != "" {
+			return fmt.Sprintf("✓ %s: arXiv:%s", ref, v.Entry.ArXivID)
+		}
+		return fmt.Sprintf("✓ %s: registered", ref)
+	case library.StatusMismatch:
+		return fmt.Sprintf("✗ %s: cited as %q but is %q", ref, v.Title, strings.Join(strings.Fields(v.Entry.Title), " "))
+	case library.StatusNotFound:
+		return fmt.Sprintf("✗ %s: not found in Crossref or arXiv", ref)
+	default:
+		return fmt.Sprintf("? %s: could not be checked (%s)", ref, v.Error)
+	}
+}
+
+// CheckResponse flags or removes hallucinated references in an agent
+// response according to mode (library.CheckFlag or library.CheckRemove).
+// Lookups that fail or time out leave the text unchanged.
+func (t *VerifyCitationsTool) CheckResponse(ctx context.Context, text, mode string) string {
+	cites := library.ExtractCitations(text)
+	if len(cites) == 0 {
+		return text
+	}
+	if len(cites) > maxCheckedCitations {
+		cites = cites[:maxCheckedCitations]
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	verdicts := t.resolver.VerifyAll(ctx, cites)
+	checked, bad := library.ApplyVerdicts(text, verdicts, mode)
+	if bad > 0 {
+		logger.InfoCF("tool", "Flagged unverified references in response",
+			map[string]interface{}{"count": bad, "mode": mode})
+	}
+	return checked
+}
@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/srikesh3005/summer/pkg/library"
+)
+
+func newCitationResolver(t *testing.T) *library.Resolver {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/crossref/10.1038/nature14539" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"message": {"type": "journal-article", "title": ["Deep learning"], "DOI": "10.1038/nature14539"}}`))
+	}))
+	t.Cleanup(server.Close)
+	return &library.Resolver{
+		Client:       testOutboundClient(),
+		ArXivAPI:     server.URL + "/arxiv",
+		CrossrefAPI:  server.URL + "/crossref/",
+		DOIHandleAPI: server.URL + "/handles/",
+	}
+}
+
+func TestVerifyCitationsTool(t *testing.T) {
+	tool := NewVerifyCitationsTool(newCitationResolver(t))
+	text := "See LeCun et al. (2015), doi:10.1038/nature14539.\n- Made-up paper (2022), https://doi.org/10.9999/nope"
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"text": text, "mode": "report"})
+	if result.IsError {
+		t.Fatalf("Execute failed: %s", result.ForLLM)
+	}
+	for _, want := range []string{"✓ 10.1038/nature14539: Deep learning", "✗ 10.9999/nope: not found"} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("report missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+	if strings.Contains(result.ForLLM, "Corrected text") {
+		t.Error("report mode should not return corrected text")
+	}
+
+	checked := tool.CheckResponse(context.Background(), text, library.CheckRemove)
+	if strings.Contains(checked, "Made-up") || !strings.Contains(checked, "doi:10.1038/nature14539.") {
+		t.Errorf("unexpected checked response:\n%s", checked)
+	}
+	if plain := "No references here."; tool.CheckResponse(context.Background(), plain, library.CheckFlag) != plain {
+		t.Error("text without references changed")
+	}
+	if r := tool.Execute(context.Background(), map[string]interface{}{"text": text, "mode": "delete"}); !r.IsError {
+		t.Error("expected an error for an unknown mode")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 const (
@@ -23,6 +24,7 @@ type SearchProvider interface {
 
 type BraveSearchProvider struct {
 	apiKey string
+	client *http.Client
 }
 
 func (p *BraveSearchProvider) Search(ctx context.Context, query string, count int) (string, error) {
@@ -37,8 +39,7 @@ func (p *BraveSearchProvider) Search(ctx context.Context, query string, count in
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("X-Subscription-Token", p.apiKey)
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := withTimeout(p.client, 10*time.Second).Do(req)
 	if err != nil {
 		return "", fmt.Errorf("request failed: %w", err)
 	}
@@ -87,6 +88,7 @@ func (p *BraveSearchProvider) Search(ctx context.Context, query string, count in
 
 type TavilySearchProvider struct {
 	apiKey string
+	client *http.Client
 }
 
 func (p *TavilySearchProvider) Search(ctx context.Context, query string, count int) (string, error) {
@@ -109,8 +111,7 @@ func (p *TavilySearchProvider) Search(ctx context.Context, query string, count i
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := withTimeout(p.client, 10*time.Second).Do(req)
 	if err != nil {
 		return "", fmt.Errorf("request failed: %w", err)
 	}
@@ -157,7 +158,9 @@ func (p *TavilySearchProvider) Search(ctx context.Context, query string, count i
 	return strings.Join(lines, "\n"), nil
 }
 
-type DuckDuckGoSearchProvider struct{}
+type DuckDuckGoSearchProvider struct {
+	client *http.Client
+}
 
 func (p *DuckDuckGoSearchProvider) Search(ctx context.Context, query string, count int) (string, error) {
 	searchURL := fmt.Sprintf("https://html.duckduckgo.com/html/?q=%s", url.QueryEscape(query))
@@ -169,8 +172,7 @@ func (p *DuckDuckGoSearchProvider) Search(ctx context.Context, query string, cou
 
 	req.Header.Set("User-Agent", userAgent)
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := withTimeout(p.client, 10*time.Second).Do(req)
 	if err != nil {
 		return "", fmt.Errorf("request failed: %w", err)
 	}
@@ -263,25 +265,29 @@ type WebSearchToolOptions struct {
 	BraveEnabled         bool
 	DuckDuckGoMaxResults int
 	DuckDuckGoEnabled    bool
+	// HTTPClient is used for search requests; nil means a client with the
+	// default OutboundPolicy.
+	HTTPClient *http.Client
 }
 
 func NewWebSearchTool(opts WebSearchToolOptions) *WebSearchTool {
 	var provider SearchProvider
 	maxResults := 5
+	client := clientOrDefault(opts.HTTPClient)
 
 	// Priority: Tavily > Brave > DuckDuckGo
 	if opts.TavilyEnabled && opts.TavilyAPIKey != "" {
-		provider = &TavilySearchProvider{apiKey: opts.TavilyAPIKey}
+		provider = &TavilySearchProvider{apiKey: opts.TavilyAPIKey, client: client}
 		if opts.TavilyMaxResults > 0 {
 			maxResults = opts.TavilyMaxResults
 		}
 	} else if opts.BraveEnabled && opts.BraveAPIKey != "" {
-		provider = &BraveSearchProvider{apiKey: opts.BraveAPIKey}
+		provider = &BraveSearchProvider{apiKey: opts.BraveAPIKey, client: client}
 		if opts.BraveMaxResults > 0 {
 			maxResults = opts.BraveMaxResults
 		}
 	} else if opts.DuckDuckGoEnabled {
-		provider = &DuckDuckGoSearchProvider{}
+		provider = &DuckDuckGoSearchProvider{client: client}
 		if opts.DuckDuckGoMaxResults > 0 {
 			maxResults = opts.DuckDuckGoMaxResults
 		}
@@ -348,14 +354,17 @@ func (t *WebSearchTool) Execute(ctx context.Context, args map[string]interface{}
 
 type WebFetchTool struct {
 	maxChars int
+	client   *http.Client
 }
 
-func NewWebFetchTool(maxChars int) *WebFetchTool {
+// NewWebFetchTool creates a web_fetch tool.
+func NewWebFetchTool(maxChars int, client *http.Client) *WebFetchTool {
 	if maxChars <= 0 {
 		maxChars = 50000
 	}
 	return &WebFetchTool{
 		maxChars: maxChars,
+		client:   clientOrDefault(client),
 	}
 }
 
@@ -364,7 +373,7 @@ func (t *WebFetchTool) Name() string {
 }
 
 func (t *WebFetchTool) Description() string {
-	return "Fetch a URL and extract readable content (HTML to text). Use this to get weather info, news, articles, or any web content."
+	return "Fetch a URL and return its readable content. HTML pages are reduced to the main article as Markdown (headings, lists, tables, links as numbered references); PDFs, JSON and plain text are supported. Long content is paginated: use offset to continue where the previous call stopped."
 }
 
 func (t *WebFetchTool) Parameters() map[string]interface{} {
@@ -377,9 +386,14 @@ func (t *WebFetchTool) Parameters() map[string]interface{} {
 			},
 			"maxChars": map[string]interface{}{
 				"type":        "integer",
-				"description": "Maximum characters to extract",
+				"description": "Maximum characters to return",
 				"minimum":     100.0,
 			},
+			"offset": map[string]interface{}{
+				"type":        "integer",
+				"description": "Character offset to start from, for reading past the end of a previous call",
+				"minimum":     0.0,
+			},
 		},
 		"required": []string{"url"},
 	}
@@ -411,6 +425,11 @@ func (t *WebFetchTool) Execute(ctx context.Context, args map[string]interface{})
 		}
 	}
 
+	offset := 0
+	if o, ok := args["offset"].(float64); ok && o > 0 {
+		offset = int(o)
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
 	if err != nil {
 		return ErrorResult(fmt.Sprintf("failed to create request: %v", err))
@@ -418,23 +437,7 @@ func (t *WebFetchTool) Execute(ctx context.Context, args map[string]interface{})
 
 	req.Header.Set("User-Agent", userAgent)
 
-	client := &http.Client{
-		Timeout: 60 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        10,
-			IdleConnTimeout:     30 * time.Second,
-			DisableCompression:  false,
-			TLSHandshakeTimeout: 15 * time.Second,
-		},
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= 5 {
-				return fmt.Errorf("stopped after 5 redirects")
-			}
-			return nil
-		},
-	}
-
-	resp, err := client.Do(req)
+	resp, err := t.client.Do(req)
 	if err != nil {
 		return ErrorResult(fmt.Sprintf("request failed: %v", err))
 	}
@@ -445,11 +448,12 @@ func (t *WebFetchTool) Execute(ctx context.Context, args map[string]interface{})
 		return ErrorResult(fmt.Sprintf("failed to read response: %v", err))
 	}
 
-	contentType := resp.Header.Get("Content-Type")
+	contentType := strings.ToLower(resp.Header.Get("Content-Type"))
 
-	var text, extractor string
+	var text, title, extractor string
 
-	if strings.Contains(contentType, "application/json") {
+	switch {
+	case strings.Contains(contentType, "application/json") || strings.HasSuffix(strings.SplitN(contentType, ";", 2)[0], "+json"):
 		var jsonData interface{}
 		if err := json.Unmarshal(body, &jsonData); err == nil {
 			formatted, _ := json.MarshalIndent(jsonData, "", "  ")
@@ -459,58 +463,57 @@ func (t *WebFetchTool) Execute(ctx context.Context, args map[string]interface{})
 			text = string(body)
 			extractor = "raw"
 		}
-	} else if strings.Contains(contentType, "text/html") || len(body) > 0 &&
-		(strings.HasPrefix(string(body), "<!DOCTYPE") || strings.HasPrefix(strings.ToLower(string(body)), "<html")) {
-		text = t.extractText(string(body))
-		extractor = "text"
-	} else {
+	case strings.Contains(contentType, "application/pdf") || bytes.HasPrefix(body, []byte("%PDF")):
+		text, err = extractPDFText(body)
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("failed to extract PDF text from %s: %v", urlStr, err))
+		}
+		extractor = "pdf"
+	case strings.Contains(contentType, "text/html") || strings.Contains(contentType, "application/xhtml") || looksLikeHTML(body):
+		page, err := extractReadableMarkdown(string(body), resp.Request.URL)
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("failed to extract content from %s: %v", urlStr, err))
+		}
+		text, title = page.Markdown, page.Title
+		extractor = "readability"
+	case strings.HasPrefix(contentType, "text/") || utf8.Valid(body):
 		text = string(body)
-		extractor = "raw"
+		extractor = "text"
+	default:
+		return ErrorResult(fmt.Sprintf("unsupported content type %q (%d bytes of binary data)", contentType, len(body)))
 	}
 
-	truncated := len(text) > maxChars
-	if truncated {
-		text = text[:maxChars]
+	runes := []rune(text)
+	total := len(runes)
+	if offset > 0 && offset >= total {
+		return ErrorResult(fmt.Sprintf("offset %d is past the end of the content (%d characters)", offset, total))
 	}
+	end := min(offset+maxChars, total)
 
-	result := map[string]interface{}{
-		"url":       urlStr,
-		"status":    resp.StatusCode,
-		"extractor": extractor,
-		"truncated": truncated,
-		"length":    len(text),
-		"text":      text,
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "URL: %s\n", resp.Request.URL)
+	if title != "" {
+		fmt.Fprintf(&sb, "Title: %s\n", title)
 	}
-
-	resultJSON, _ := json.MarshalIndent(result, "", "  ")
-
-	return &ToolResult{
-		ForLLM:  fmt.Sprintf("Fetched %d bytes from %s (extractor: %s, truncated: %v)", len(text), urlStr, extractor, truncated),
-		ForUser: string(resultJSON),
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(&sb, "Status: %d\n", resp.StatusCode)
 	}
-}
-
-func (t *WebFetchTool) extractText(htmlContent string) string {
-	re := regexp.MustCompile(`<script[\s\S]*?</script>`)
-	result := re.ReplaceAllLiteralString(htmlContent, "")
-	re = regexp.MustCompile(`<style[\s\S]*?</style>`)
-	result = re.ReplaceAllLiteralString(result, "")
-	re = regexp.MustCompile(`<[^>]+>`)
-	result = re.ReplaceAllLiteralString(result, "")
-
-	result = strings.TrimSpace(result)
-
-	re = regexp.MustCompile(`\s+`)
-	result = re.ReplaceAllLiteralString(result, " ")
-
-	lines := strings.Split(result, "\n")
-	var cleanLines []string
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" {
-			cleanLines = append(cleanLines, line)
+	fmt.Fprintf(&sb, "Extractor: %s\n", extractor)
+	if end < total || offset > 0 {
+		fmt.Fprintf(&sb, "Showing characters %d-%d of %d", offset, end, total)
+		if end < total {
+			fmt.Fprintf(&sb, " (call web_fetch again with offset=%d for more)", end)
 		}
+		sb.WriteString("\n")
 	}
+	sb.WriteString("\n")
+	sb.WriteString(string(runes[offset:end]))
+
+	return NewToolResult(sb.String())
+}
 
-	return strings.Join(cleanLines, "\n")
+// looksLikeHTML sniffs bodies served without a useful Content-Type.
+func looksLikeHTML(body []byte) bool {
+	head := strings.ToLower(strings.TrimSpace(string(body[:min(len(body), 512)])))
+	return strings.HasPrefix(head, "<!doctype html") || strings.HasPrefix(head, "<html")
 }
@@ -0,0 +1,558 @@
+package tools
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	unlikelyCandidateRe = regexp.MustCompile(`(?i)\b(comment|sidebar|footer|navbar|nav|menu|breadcrumb|share|social|advert|ads?|banner|cookie|popup|modal|related|subscribe|newsletter|promo|sponsor)\b`)
+	likelyCandidateRe   = regexp.MustCompile(`(?i)\b(article|content|main|body|post|entry|story|text)\b`)
+	blankLinesRe        = regexp.MustCompile(`\n{3,}`)
+	listItemRe          = regexp.MustCompile(`^(- |\d+\. )`)
+)
+
+// minMainContentChars is how much text an <article> or <main> element needs
+// before it is trusted as the page's main content.
+const minMainContentChars = 200
+
+// extractedPage is the readable part of an HTML page.
+type extractedPage struct {
+	Title    string
+	Markdown string
+}
+
+// extractReadableMarkdown finds the main content of an HTML page and renders
+// it as Markdown. Boilerplate (scripts, navigation, sidebars, footers) is
+// dropped, links become numbered references listed at the end and tables are
+// kept as Markdown tables. base resolves relative links and may be nil.
+func extractReadableMarkdown(htmlContent string, base *url.URL) (*extractedPage, error) {
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	page := &extractedPage{Title: pageTitle(doc)}
+	removeBoilerplate(doc)
+
+	root := findMainContent(doc)
+	if root == nil {
+		return page, nil
+	}
+
+	r := &markdownRenderer{base: base, refIndex: make(map[string]int)}
+	md := r.blocks(root)
+	md = normalizeMarkdown(md)
+
+	if len(r.refs) > 0 {
+		var sb strings.Builder
+		sb.WriteString(md)
+		sb.WriteString("\n\n")
+		for i, ref := range r.refs {
+			fmt.Fprintf(&sb, "[%d]: %s\n", i+1, ref)
+		}
+		md = strings.TrimRight(sb.String(), "\n")
+	}
+	page.Markdown = md
+	return page, nil
+}
+
+func pageTitle(doc *html.Node) string {
+	var title, ogTitle string
+	walk(doc, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		switch n.DataAtom {
+		case atom.Title:
+			if title == "" {
+				title = collapseSpace(textContent(n))
+			}
+		case atom.Meta:
+			if attr(n, "property") == "og:title" && ogTitle == "" {
+				ogTitle = strings.TrimSpace(attr(n, "content"))
+			}
+		case atom.Body:
+			return false
+		}
+		return true
+	})
+	if title != "" {
+		return title
+	}
+	return ogTitle
+}
+
+// removeBoilerplate detaches elements that never hold main content.
+func removeBoilerplate(doc *html.Node) {
+	var remove []*html.Node
+	walk(doc, func(n *html.Node) bool {
+		switch n.Type {
+		case html.CommentNode:
+			remove = append(remove, n)
+			return false
+		case html.ElementNode:
+		default:
+			return true
+		}
+
+		switch n.DataAtom {
+		case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Svg, atom.Iframe,
+			atom.Form, atom.Button, atom.Input, atom.Select, atom.Textarea,
+			atom.Nav, atom.Footer, atom.Aside, atom.Header:
+			// A header inside the article usually holds its title.
+			if n.DataAtom == atom.Header && hasAncestor(n, atom.Article) {
+				return true
+			}
+			remove = append(remove, n)
+			return false
+		case atom.Html, atom.Body, atom.Article, atom.Main, atom.Table, atom.Tbody, atom.Thead, atom.Tr, atom.Td, atom.Th:
+			return true
+		}
+
+		if attr(n, "hidden") != "" || attr(n, "aria-hidden") == "true" {
+			remove = append(remove, n)
+			return false
+		}
+		if role := attr(n, "role"); role == "navigation" || role == "banner" || role == "contentinfo" || role == "complementary" {
+			remove = append(remove, n)
+			return false
+		}
+		classID := attr(n, "class") + " " + attr(n, "id")
+		if unlikelyCandidateRe.MatchString(classID) && !likelyCandidateRe.MatchString(classID) {
+			remove = append(remove, n)
+			return false
+		}
+		return true
+	})
+	for _, n := range remove {
+		if n.Parent != nil {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+// findMainContent picks the element holding the page's main text: a
+// sufficiently long <article>, <main> or role=main element, otherwise the
+// element whose paragraphs score highest, otherwise <body>.
+func findMainContent(doc *html.Node) *html.Node {
+	var body *html.Node
+	var semantic []*html.Node
+	walk(doc, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		switch {
+		case n.DataAtom == atom.Body:
+			body = n
+		case n.DataAtom == atom.Article, n.DataAtom == atom.Main, attr(n, "role") == "main":
+			semantic = append(semantic, n)
+		}
+		return true
+	})
+
+	// Prefer the longest semantic container; several <article> elements
+	// (a listing page) fall back to scoring.
+	var best *html.Node
+	bestLen := 0
+	articles := 0
+	for _, n := range semantic {
+		if n.DataAtom == atom.Article {
+			articles++
+		}
+		if l := len(collapseSpace(textContent(n))); l > bestLen {
+			best, bestLen = n, l
+		}
+	}
+	if best != nil && bestLen >= minMainContentChars && articles <= 1 {
+		return best
+	}
+
+	if n := highestScoringNode(doc); n != nil {
+		return n
+	}
+	if best != nil {
+		return best
+	}
+	return body
+}
+
+// highestScoringNode scores paragraph-like elements by text length and comma
+// count, credits their parent and grandparent, and discounts link-heavy
+// candidates.
+func highestScoringNode(doc *html.Node) *html.Node {
+	scores := make(map[*html.Node]float64)
+	var order []*html.Node
+	add := func(n *html.Node, s float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			order = append(order, n)
+		}
+		scores[n] += s
+	}
+
+	walk(doc, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		switch n.DataAtom {
+		case atom.P, atom.Pre, atom.Td, atom.Blockquote:
+		default:
+			return true
+		}
+		text := collapseSpace(textContent(n))
+		if len(text) < 25 {
+			return true
+		}
+		score := 1 + float64(strings.Count(text, ","))
+		score += float64(min(len(text)/100, 3))
+		add(n.Parent, score)
+		if n.Parent != nil {
+			add(n.Parent.Parent, score/2)
+		}
+		return true
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, n := range order {
+		s := scores[n] * (1 - linkDensity(n))
+		if s > bestScore {
+			best, bestScore = n, s
+		}
+	}
+	if bestScore < 3 {
+		return nil
+	}
+	return best
+}
+
+func linkDensity(n *html.Node) float64 {
+	total := len(collapseSpace(textContent(n)))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.ElementNode && c.DataAtom == atom.A {
+			linked += len(collapseSpace(textContent(c)))
+			return false
+		}
+		return true
+	})
+	return float64(linked) / float64(total)
+}
+
+// markdownRenderer converts an HTML subtree to Markdown.
+type markdownRenderer struct {
+	base     *url.URL
+	refs     []string
+	refIndex map[string]int
+}
+
+// blocks renders n's children as block-level Markdown.
+func (r *markdownRenderer) blocks(n *html.Node) string {
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(r.node(c))
+	}
+	return sb.String()
+}
+
+// inline renders n's children and collapses them onto one line.
+func (r *markdownRenderer) inline(n *html.Node) string {
+	return collapseSpace(r.blocks(n))
+}
+
+func (r *markdownRenderer) node(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return whitespaceRe.ReplaceAllString(n.Data, " ")
+	case html.ElementNode:
+	default:
+		return r.blocks(n)
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := r.inline(n)
+		if text == "" {
+			return ""
+		}
+		level := int(n.Data[1] - '0')
+		return "\n\n" + strings.Repeat("#", level) + " " + text + "\n\n"
+	case atom.P:
+		return "\n\n" + strings.TrimSpace(r.inline(n)) + "\n\n"
+	case atom.Br:
+		return "\n"
+	case atom.Hr:
+		return "\n\n---\n\n"
+	case atom.Pre:
+		code := strings.Trim(textContent(n), "\n")
+		return "\n\n```" + codeLanguage(n) + "\n" + code + "\n```\n\n"
+	case atom.Code, atom.Kbd, atom.Samp:
+		code := textContent(n)
+		if strings.TrimSpace(code) == "" {
+			return code
+		}
+		return "`" + strings.ReplaceAll(code, "`", "'") + "`"
+	case atom.Strong, atom.B:
+		return wrapInline(r.blocks(n), "**")
+	case atom.Em, atom.I:
+		return wrapInline(r.blocks(n), "_")
+	case atom.Del, atom.S:
+		return wrapInline(r.blocks(n), "~~")
+	case atom.A:
+		return r.link(n)
+	case atom.Img:
+		return r.image(n)
+	case atom.Ul, atom.Ol:
+		return "\n\n" + r.list(n, 0) + "\n\n"
+	case atom.Blockquote:
+		inner := strings.TrimSpace(collapseBlankLines(r.blocks(n)))
+		if inner == "" {
+			return ""
+		}
+		return "\n\n" + prefixLines(inner, "> ") + "\n\n"
+	case atom.Table:
+		return "\n\n" + r.table(n) + "\n\n"
+	case atom.Dt:
+		return "\n\n**" + r.inline(n) + "**\n"
+	case atom.Dd:
+		return ": " + r.inline(n) + "\n"
+	case atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Figure,
+		atom.Figcaption, atom.Dl, atom.Details, atom.Summary, atom.Address:
+		return "\n\n" + r.blocks(n) + "\n\n"
+	}
+	return r.blocks(n)
+}
+
+func (r *markdownRenderer) link(n *html.Node) string {
+	text := r.inline(n)
+	href := r.resolve(attr(n, "href"))
+	if href == "" || text == "" {
+		return text
+	}
+	idx, ok := r.refIndex[href]
+	if !ok {
+		r.refs = append(r.refs, href)
+		idx = len(r.refs)
+		r.refIndex[href] = idx
+	}
+	return fmt.Sprintf("[%s][%d]", text, idx)
+}
+
+func (r *markdownRenderer) image(n *html.Node) string {
+	alt := collapseSpace(attr(n, "alt"))
+	src := r.resolve(attr(n, "src"))
+	if alt == "" || src == "" || strings.HasPrefix(src, "data:") {
+		return ""
+	}
+	return fmt.Sprintf("![%s](%s)", alt, src)
+}
+
+// resolve makes href absolute and drops fragment-only and script links.
+func (r *markdownRenderer) resolve(href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return ""
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if r.base != nil {
+		u = r.base.ResolveReference(u)
+	}
+	return u.String()
+}
+
+// list renders a <ul> or <ol>, indenting nested lists by depth.
+func (r *markdownRenderer) list(n *html.Node, depth int) string {
+	ordered := n.DataAtom == atom.Ol
+	indent := strings.Repeat("  ", depth)
+	var lines []string
+	num := 1
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+		var text strings.Builder
+		var nested []string
+		for c := li.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && (c.DataAtom == atom.Ul || c.DataAtom == atom.Ol) {
+				nested = append(nested, r.list(c, depth+1))
+				continue
+			}
+			text.WriteString(r.node(c))
+		}
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", num)
+			num++
+		}
+		lines = append(lines, indent+marker+collapseSpace(text.String()))
+		lines = append(lines, nested...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// table renders a table as a Markdown pipe table whose first row is the
+// header.
+func (r *markdownRenderer) table(n *html.Node) string {
+	var rows [][]string
+	walk(n, func(c *html.Node) bool {
+		if c != n && c.Type == html.ElementNode && c.DataAtom == atom.Table {
+			return false // nested tables are flattened into their cell
+		}
+		if c.Type != html.ElementNode || c.DataAtom != atom.Tr {
+			return true
+		}
+		var row []string
+		for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+				row = append(row, strings.ReplaceAll(r.inline(cell), "|", `\|`))
+			}
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+		return false
+	})
+	if len(rows) == 0 {
+		return ""
+	}
+
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+	var sb strings.Builder
+	writeRow := func(row []string) {
+		sb.WriteString("|")
+		for i := 0; i < cols; i++ {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			sb.WriteString(" " + cell + " |")
+		}
+		sb.WriteString("\n")
+	}
+	writeRow(rows[0])
+	sb.WriteString("|" + strings.Repeat(" --- |", cols) + "\n")
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func codeLanguage(pre *html.Node) string {
+	for _, n := range []*html.Node{pre, pre.FirstChild} {
+		if n == nil || n.Type != html.ElementNode {
+			continue
+		}
+		for _, class := range strings.Fields(attr(n, "class")) {
+			for _, prefix := range []string{"language-", "lang-"} {
+				if strings.HasPrefix(class, prefix) {
+					return strings.TrimPrefix(class, prefix)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// wrapInline surrounds s with marker, keeping surrounding spaces outside so
+// the Markdown stays valid.
+func wrapInline(s, marker string) string {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" {
+		return s
+	}
+	lead := s[:strings.Index(s, trimmed)]
+	trail := s[len(lead)+len(trimmed):]
+	return lead + marker + collapseSpace(trimmed) + marker + trail
+}
+
+// normalizeMarkdown removes the stray indentation and blank lines left
+// between blocks, leaving fenced code and nested list items alone.
+func normalizeMarkdown(md string) string {
+	lines := strings.Split(md, "\n")
+	inFence := false
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			lines[i] = trimmed
+			continue
+		}
+		if inFence || listItemRe.MatchString(trimmed) {
+			continue
+		}
+		lines[i] = trimmed
+	}
+	md = collapseBlankLines(strings.Join(lines, "\n"))
+	return strings.TrimSpace(blankLinesRe.ReplaceAllString(md, "\n\n"))
+}
+
+func prefixLines(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(prefix+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+var whitespaceRe = regexp.MustCompile(`\s+`)
+
+func collapseSpace(s string) string {
+	return strings.TrimSpace(whitespaceRe.ReplaceAllString(s, " "))
+}
+
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.TextNode {
+			sb.WriteString(c.Data)
+		}
+		return true
+	})
+	return sb.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAncestor(n *html.Node, a atom.Atom) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.DataAtom == a {
+			return true
+		}
+	}
+	return false
+}
+
+// walk visits n and its descendants depth-first; fn returns false to skip a
+// node's children.
+func walk(n *html.Node, fn func(*html.Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
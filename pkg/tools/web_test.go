@@ -1,14 +1,23 @@
 package tools
 
 import (
+	"bytes"
+	"compress/zlib"
 	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
+// testOutboundClient allows the loopback addresses httptest servers listen on.
+func testOutboundClient() *http.Client {
+	return NewOutboundClient(OutboundPolicy{AllowHosts: []string{"127.0.0.0/8", "::1"}}, 10*time.Second)
+}
+
 // TestWebTool_WebFetch_Success verifies successful URL fetching
 func TestWebTool_WebFetch_Success(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -18,7 +27,7 @@ func TestWebTool_WebFetch_Success(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewWebFetchTool(50000)
+	tool := NewWebFetchTool(50000, testOutboundClient())
 	ctx := context.Background()
 	args := map[string]interface{}{
 		"url": server.URL,
@@ -31,14 +40,14 @@ func TestWebTool_WebFetch_Success(t *testing.T) {
 		t.Errorf("Expected success, got IsError=true: %s", result.ForLLM)
 	}
 
-	// ForUser should contain the fetched content
-	if !strings.Contains(result.ForUser, "Test Page") {
-		t.Errorf("Expected ForUser to contain 'Test Page', got: %s", result.ForUser)
+	// ForLLM should contain the fetched content as Markdown
+	if !strings.Contains(result.ForLLM, "# Test Page") || !strings.Contains(result.ForLLM, "Content here") {
+		t.Errorf("Expected ForLLM to contain the page content, got: %s", result.ForLLM)
 	}
 
-	// ForLLM should contain summary
-	if !strings.Contains(result.ForLLM, "bytes") && !strings.Contains(result.ForLLM, "extractor") {
-		t.Errorf("Expected ForLLM to contain summary, got: %s", result.ForLLM)
+	// The content is for the model, not the user
+	if result.ForUser != "" {
+		t.Errorf("Expected empty ForUser, got: %s", result.ForUser)
 	}
 }
 
@@ -54,7 +63,7 @@ func TestWebTool_WebFetch_JSON(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewWebFetchTool(50000)
+	tool := NewWebFetchTool(50000, testOutboundClient())
 	ctx := context.Background()
 	args := map[string]interface{}{
 		"url": server.URL,
@@ -67,15 +76,15 @@ func TestWebTool_WebFetch_JSON(t *testing.T) {
 		t.Errorf("Expected success, got IsError=true: %s", result.ForLLM)
 	}
 
-	// ForUser should contain formatted JSON
-	if !strings.Contains(result.ForUser, "key") && !strings.Contains(result.ForUser, "value") {
-		t.Errorf("Expected ForUser to contain JSON data, got: %s", result.ForUser)
+	// ForLLM should contain formatted JSON
+	if !strings.Contains(result.ForLLM, `"key": "value"`) {
+		t.Errorf("Expected ForLLM to contain JSON data, got: %s", result.ForLLM)
 	}
 }
 
 // TestWebTool_WebFetch_InvalidURL verifies error handling for invalid URL
 func TestWebTool_WebFetch_InvalidURL(t *testing.T) {
-	tool := NewWebFetchTool(50000)
+	tool := NewWebFetchTool(50000, nil)
 	ctx := context.Background()
 	args := map[string]interface{}{
 		"url": "not-a-valid-url",
@@ -96,7 +105,7 @@ func TestWebTool_WebFetch_InvalidURL(t *testing.T) {
 
 // TestWebTool_WebFetch_UnsupportedScheme verifies error handling for non-http URLs
 func TestWebTool_WebFetch_UnsupportedScheme(t *testing.T) {
-	tool := NewWebFetchTool(50000)
+	tool := NewWebFetchTool(50000, nil)
 	ctx := context.Background()
 	args := map[string]interface{}{
 		"url": "ftp://example.com/file.txt",
@@ -117,7 +126,7 @@ func TestWebTool_WebFetch_UnsupportedScheme(t *testing.T) {
 
 // TestWebTool_WebFetch_MissingURL verifies error handling for missing URL
 func TestWebTool_WebFetch_MissingURL(t *testing.T) {
-	tool := NewWebFetchTool(50000)
+	tool := NewWebFetchTool(50000, nil)
 	ctx := context.Background()
 	args := map[string]interface{}{}
 
@@ -145,7 +154,7 @@ func TestWebTool_WebFetch_Truncation(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewWebFetchTool(1000) // Limit to 1000 chars
+	tool := NewWebFetchTool(1000, testOutboundClient()) // Limit to 1000 chars
 	ctx := context.Background()
 	args := map[string]interface{}{
 		"url": server.URL,
@@ -158,18 +167,15 @@ func TestWebTool_WebFetch_Truncation(t *testing.T) {
 		t.Errorf("Expected success, got IsError=true: %s", result.ForLLM)
 	}
 
-	// ForUser should contain truncated content (not the full 20000 chars)
-	resultMap := make(map[string]interface{})
-	json.Unmarshal([]byte(result.ForUser), &resultMap)
-	if text, ok := resultMap["text"].(string); ok {
-		if len(text) > 1100 { // Allow some margin
-			t.Errorf("Expected content to be truncated to ~1000 chars, got: %d", len(text))
-		}
+	// ForLLM should contain truncated content (not the full 20000 chars)
+	_, body, _ := strings.Cut(result.ForLLM, "\n\n")
+	if n := len(body); n != 1000 {
+		t.Errorf("Expected content to be truncated to 1000 chars, got: %d", n)
 	}
 
-	// Should be marked as truncated
-	if truncated, ok := resultMap["truncated"].(bool); !ok || !truncated {
-		t.Errorf("Expected 'truncated' to be true in result")
+	// Should tell the model how to continue
+	if !strings.Contains(result.ForLLM, "of 20000") || !strings.Contains(result.ForLLM, "offset=1000") {
+		t.Errorf("Expected pagination hint in result, got: %s", result.ForLLM[:200])
 	}
 }
 
@@ -206,7 +212,7 @@ func TestWebTool_WebFetch_HTMLExtraction(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewWebFetchTool(50000)
+	tool := NewWebFetchTool(50000, testOutboundClient())
 	ctx := context.Background()
 	args := map[string]interface{}{
 		"url": server.URL,
@@ -219,20 +225,20 @@ func TestWebTool_WebFetch_HTMLExtraction(t *testing.T) {
 		t.Errorf("Expected success, got IsError=true: %s", result.ForLLM)
 	}
 
-	// ForUser should contain extracted text (without script/style tags)
-	if !strings.Contains(result.ForUser, "Title") && !strings.Contains(result.ForUser, "Content") {
-		t.Errorf("Expected ForUser to contain extracted text, got: %s", result.ForUser)
+	// ForLLM should contain extracted text (without script/style tags)
+	if !strings.Contains(result.ForLLM, "Title") || !strings.Contains(result.ForLLM, "Content") {
+		t.Errorf("Expected ForLLM to contain extracted text, got: %s", result.ForLLM)
 	}
 
-	// Should NOT contain script or style tags
-	if strings.Contains(result.ForUser, "<script>") || strings.Contains(result.ForUser, "<style>") {
-		t.Errorf("Expected script/style tags to be removed, got: %s", result.ForUser)
+	// Should NOT contain script or style content
+	if strings.Contains(result.ForLLM, "alert") || strings.Contains(result.ForLLM, "color:red") {
+		t.Errorf("Expected script/style content to be removed, got: %s", result.ForLLM)
 	}
 }
 
 // TestWebTool_WebFetch_MissingDomain verifies error handling for URL without domain
 func TestWebTool_WebFetch_MissingDomain(t *testing.T) {
-	tool := NewWebFetchTool(50000)
+	tool := NewWebFetchTool(50000, nil)
 	ctx := context.Background()
 	args := map[string]interface{}{
 		"url": "https://",
@@ -250,3 +256,150 @@ func TestWebTool_WebFetch_MissingDomain(t *testing.T) {
 		t.Errorf("Expected domain error message, got ForLLM: %s", result.ForLLM)
 	}
 }
+
+// TestWebTool_WebFetch_Offset verifies pagination through long content
+func TestWebTool_WebFetch_Offset(t *testing.T) {
+	content := strings.Repeat("a", 500) + strings.Repeat("b", 500) + strings.Repeat("c", 200)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	tool := NewWebFetchTool(500, testOutboundClient())
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]interface{}{"url": server.URL, "offset": 500.0})
+	if result.IsError {
+		t.Fatalf("Expected success, got: %s", result.ForLLM)
+	}
+	if strings.Count(result.ForLLM, "b") < 500 || strings.Contains(result.ForLLM, "aaa") || strings.Contains(result.ForLLM, "ccc") {
+		t.Errorf("Expected only the second page, got: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "Showing characters 500-1000 of 1200") || !strings.Contains(result.ForLLM, "offset=1000") {
+		t.Errorf("Expected pagination header, got: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"url": server.URL, "offset": 1000.0})
+	if strings.Contains(result.ForLLM, "offset=") {
+		t.Errorf("Expected no continuation hint on the last page, got: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"url": server.URL, "offset": 5000.0})
+	if !result.IsError {
+		t.Errorf("Expected error for offset past the end")
+	}
+}
+
+// TestWebTool_WebFetch_Readability verifies main-content detection and Markdown rendering
+func TestWebTool_WebFetch_Readability(t *testing.T) {
+	page := `<!DOCTYPE html><html><head><title>Release notes</title></head><body>
+<nav><a href="/">Home</a> <a href="/blog">Blog</a></nav>
+<div class="sidebar"><p>Subscribe to our newsletter for weekly updates, offers, and more news.</p></div>
+<article>
+<h1>Version 2.0</h1>
+<p>This release brings <strong>faster</strong> builds, a new <a href="/docs/cache">cache</a>, and many fixes for long-standing issues.</p>
+<h2>Changes</h2>
+<ul><li>Parallel builds<ul><li>Enabled by default</li></ul></li><li>Smaller binaries</li></ul>
+<table><tr><th>Target</th><th>Time</th></tr><tr><td>linux</td><td>12s</td></tr></table>
+<pre><code>make all</code></pre>
+</article>
+<footer>Copyright 2024</footer>
+</body></html>`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	tool := NewWebFetchTool(50000, testOutboundClient())
+	result := tool.Execute(context.Background(), map[string]interface{}{"url": server.URL})
+	if result.IsError {
+		t.Fatalf("Expected success, got: %s", result.ForLLM)
+	}
+
+	for _, want := range []string{
+		"Title: Release notes",
+		"# Version 2.0",
+		"## Changes",
+		"**faster**",
+		"[cache][1]",
+		"- Parallel builds\n  - Enabled by default\n- Smaller binaries",
+		"| Target | Time |\n| --- | --- |\n| linux | 12s |",
+		"```\nmake all\n```",
+		"[1]: " + server.URL + "/docs/cache",
+	} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("Expected ForLLM to contain %q, got:\n%s", want, result.ForLLM)
+		}
+	}
+	for _, unwanted := range []string{"Home", "newsletter", "Copyright"} {
+		if strings.Contains(result.ForLLM, unwanted) {
+			t.Errorf("Expected boilerplate %q to be removed, got:\n%s", unwanted, result.ForLLM)
+		}
+	}
+}
+
+// TestWebTool_WebFetch_PDF verifies text extraction from PDF responses
+func TestWebTool_WebFetch_PDF(t *testing.T) {
+	var stream bytes.Buffer
+	zw := zlib.NewWriter(&stream)
+	zw.Write([]byte("BT /F1 12 Tf 72 720 Td (Hello PDF) Tj 0 -14 Td [(World) -300 (wide)] TJ ET"))
+	zw.Close()
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n1 0 obj\n<< /Length ")
+	pdf.WriteString(strconv.Itoa(stream.Len()))
+	pdf.WriteString(" /Filter /FlateDecode >>\nstream\n")
+	pdf.Write(stream.Bytes())
+	pdf.WriteString("\nendstream\nendobj\n%%EOF\n")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write(pdf.Bytes())
+	}))
+	defer server.Close()
+
+	tool := NewWebFetchTool(50000, testOutboundClient())
+	result := tool.Execute(context.Background(), map[string]interface{}{"url": server.URL})
+	if result.IsError {
+		t.Fatalf("Expected success, got: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "Extractor: pdf") || !strings.Contains(result.ForLLM, "Hello PDF\nWorld wide") {
+		t.Errorf("Expected PDF text in ForLLM, got: %s", result.ForLLM)
+	}
+}
+
+// TestWebTool_WebFetch_Binary verifies that binary content is rejected
+func TestWebTool_WebFetch_Binary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte{0x89, 'P', 'N', 'G', 0xff, 0xfe, 0x00})
+	}))
+	defer server.Close()
+
+	tool := NewWebFetchTool(50000, testOutboundClient())
+	result := tool.Execute(context.Background(), map[string]interface{}{"url": server.URL})
+	if !result.IsError {
+		t.Errorf("Expected error for binary content, got: %s", result.ForLLM)
+	}
+}
+
+// TestWebTool_WebFetch_BlocksPrivateNetwork verifies the default policy refuses loopback URLs
+func TestWebTool_WebFetch_BlocksPrivateNetwork(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal admin panel"))
+	}))
+	defer server.Close()
+
+	tool := NewWebFetchTool(50000, nil)
+	result := tool.Execute(context.Background(), map[string]interface{}{"url": server.URL})
+	if !result.IsError {
+		t.Fatalf("expected web_fetch of a loopback address to fail, got: %s", result.ForLLM)
+	}
+	if strings.Contains(result.ForLLM, "admin panel") {
+		t.Errorf("blocked content leaked into result: %s", result.ForLLM)
+	}
+}
@@ -0,0 +1,710 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/srikesh3005/summer/pkg/calc"
+	"github.com/srikesh3005/summer/pkg/utils"
+)
+
+const (
+	// defaultWikipediaURL is the MediaWiki API; {lang} is replaced by the
+	// language code.
+	defaultWikipediaURL   = "https://{lang}.wikipedia.org/w/api.php"
+	defaultWikidataURL    = "https://www.wikidata.org/w/api.php"
+	defaultWikidataSPARQL = "https://query.wikidata.org/sparql"
+
+	wikiUserAgent      = "Summer AI Assistant (mailto:research@example.com)"
+	maxWikiSectionText = 8000
+	maxSPARQLRows      = 100
+)
+
+var (
+	wikiLanguageRe = regexp.MustCompile(`^[a-z][a-z-]{1,15}$`)
+	wikiHeadingRe  = regexp.MustCompile(`(?m)^(={2,6})\s*(.*?)\s*={2,6}\s*$`)
+	wikiEntityRe   = regexp.MustCompile(`^[QqPp][1-9]\d*$`)
+)
+
+// wikiSection is one heading of an article's plain-text extract. Number
+// follows the table of contents ("2", "2.1"); the lead section has none.
+type wikiSection struct {
+	Number string
+	Level  int
+	Title  string
+	Text   string
+}
+
+// splitWikiSections splits a plain-text extract requested with
+// exsectionformat=wiki ("== Heading ==" lines) into its sections.
+func splitWikiSections(extract string) []wikiSection {
+	sections := []wikiSection{{}}
+	var counters [5]int
+	last := 0
+	for _, m := range wikiHeadingRe.FindAllStringSubmatchIndex(extract, -1) {
+		sections[len(sections)-1].Text = strings.TrimSpace(extract[last:m[0]])
+		level := m[3] - m[2]
+		counters[level-2]++
+		for i := level - 1; i < len(counters); i++ {
+			counters[i] = 0
+		}
+		var number []string
+		for i := 0; i <= level-2; i++ {
+			number = append(number, fmt.Sprint(max(counters[i], 1)))
+		}
+		sections = append(sections, wikiSection{
+			Number: strings.Join(number, "."),
+			Level:  level,
+			Title:  extract[m[4]:m[5]],
+		})
+		last = m[1]
+	}
+	sections[len(sections)-1].Text = strings.TrimSpace(extract[last:])
+	return sections
+}
+
+// findWikiSection finds a section by number or title (case-insensitive)
+// and returns it with its subsections.
+func findWikiSection(sections []wikiSection, name string) []wikiSection {
+	name = strings.TrimSpace(name)
+	for i, s := range sections {
+		if i == 0 || (s.Number != name && !strings.EqualFold(s.Title, name)) {
+			continue
+		}
+		end := i + 1
+		for end < len(sections) && sections[end].Level > s.Level {
+			end++
+		}
+		return sections[i:end]
+	}
+	return nil
+}
+
+// wikiAnchor is the URL fragment MediaWiki gives a heading.
+func wikiAnchor(title string) string {
+	return "#" + url.PathEscape(strings.ReplaceAll(title, " ", "_"))
+}
+
+// sparqlResults is the SPARQL 1.1 JSON results format.
+type sparqlResults struct {
+	Head struct {
+		Vars []string `json:"vars"`
+	} `json:"head"`
+	Results struct {
+		Bindings []map[string]sparqlValue `json:"bindings"`
+	} `json:"results"`
+}
+
+type sparqlValue struct {
+	Type     string `json:"type"`
+	Value    string `json:"value"`
+	Datatype string `json:"datatype"`
+}
+
+// String shortens Wikidata entity URIs to their IDs and midnight
+// timestamps to dates.
+func (v sparqlValue) String() string {
+	if v.Type == "uri" {
+		if id := wikidataID(v.Value); id != "" {
+			return id
+		}
+	}
+	if strings.HasSuffix(v.Datatype, "#dateTime") {
+		return strings.TrimSuffix(v.Value, "T00:00:00Z")
+	}
+	return v.Value
+}
+
+// wikidataID returns the ID of a Wikidata entity URI, or "".
+func wikidataID(uri string) string {
+	for _, prefix := range []string{"http://www.wikidata.org/entity/", "https://www.wikidata.org/wiki/"} {
+		if id, ok := strings.CutPrefix(uri, prefix); ok && wikiEntityRe.MatchString(id) {
+			return id
+		}
+	}
+	return ""
+}
+
+// WikipediaTool searches and reads Wikipedia articles and looks up
+// structured facts on Wikidata.
+type WikipediaTool struct {
+	client       *http.Client
+	language     string
+	wikipediaURL string
+	wikidataURL  string
+	sparqlURL    string
+}
+
+// NewWikipediaTool creates the wikipedia tool. language is the default
+// Wikipedia edition, "en" if empty.
+func NewWikipediaTool(client *http.Client, language string) *WikipediaTool {
+	if language == "" {
+		language = "en"
+	}
+	return &WikipediaTool{
+		client:       clientOrDefault(client),
+		language:     language,
+		wikipediaURL: defaultWikipediaURL,
+		wikidataURL:  defaultWikidataURL,
+		sparqlURL:    defaultWikidataSPARQL,
+	}
+}
+
+func (t *WikipediaTool) Name() string {
+	return "wikipedia"
+}
+
+func (t *WikipediaTool) Description() string {
+	return "Look up encyclopedic knowledge with citable URLs. Actions: search (find Wikipedia articles with their lead summaries), " +
+		"page (read an article: without section, the lead and table of contents; with section, that section's full text), " +
+		"entity (structured Wikidata facts about a Q-ID or name, optionally limited to properties such as P569), " +
+		"sparql (run a query against the Wikidata Query Service). Prefer this over ddg_instant_answer for facts about people, places, " +
+		"organisations, concepts and events."
+}
+
+func (t *WikipediaTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"search", "page", "entity", "sparql"},
+				"description": "What to do (default: search)",
+			},
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "search: search terms; entity: name to find when no id is known; sparql: the SPARQL query (wd:, wdt:, p:, ps: and wikibase: prefixes are predefined)",
+			},
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "page: article title (redirects are followed)",
+			},
+			"section": map[string]interface{}{
+				"type":        "string",
+				"description": "page: section title or number from the table of contents, e.g. \"History\" or \"2.1\"",
+			},
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "entity: Wikidata item ID such as Q42",
+			},
+			"properties": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "entity: only these property IDs, e.g. [\"P569\", \"P19\"] (default: all except external identifiers)",
+			},
+			"language": map[string]interface{}{
+				"type":        "string",
+				"description": fmt.Sprintf("Wikipedia edition and label language code, e.g. en, de, ja (default: %s)", t.language),
+			},
+			"max_results": map[string]interface{}{
+				"type":        "integer",
+				"description": "search: maximum number of articles (default: 5, max: 10)",
+				"minimum":     1.0,
+				"maximum":     10.0,
+			},
+		},
+	}
+}
+
+func (t *WikipediaTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	lang, _ := args["language"].(string)
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" {
+		lang = t.language
+	}
+	if !wikiLanguageRe.MatchString(lang) {
+		return ErrorResult(fmt.Sprintf("invalid language code %q", lang))
+	}
+	query, _ := args["query"].(string)
+	query = strings.TrimSpace(query)
+
+	var output string
+	var err error
+	action, _ := args["action"].(string)
+	switch action {
+	case "", "search":
+		if query == "" {
+			return ErrorResult("query is required")
+		}
+		maxResults := 5
+		if mr, ok := args["max_results"].(float64); ok && mr > 0 {
+			maxResults = min(int(mr), 10)
+		}
+		output, err = t.search(ctx, lang, query, maxResults)
+	case "page":
+		title, _ := args["title"].(string)
+		if title = strings.TrimSpace(title); title == "" {
+			title = query
+		}
+		if title == "" {
+			return ErrorResult("title is required")
+		}
+		section, _ := args["section"].(string)
+		output, err = t.page(ctx, lang, title, section)
+	case "entity":
+		id, _ := args["id"].(string)
+		id = strings.ToUpper(strings.TrimSpace(id))
+		if id == "" && query == "" {
+			return ErrorResult("id or query is required")
+		}
+		if id != "" && (!wikiEntityRe.MatchString(id) || id[0] != 'Q') {
+			return ErrorResult(fmt.Sprintf("invalid Wikidata item ID %q (expected e.g. Q42)", id))
+		}
+		var props []string
+		for _, p := range stringListArg(args, "properties") {
+			p = strings.ToUpper(p)
+			if !wikiEntityRe.MatchString(p) || p[0] != 'P' {
+				return ErrorResult(fmt.Sprintf("invalid Wikidata property ID %q (expected e.g. P569)", p))
+			}
+			props = append(props, p)
+		}
+		output, err = t.entity(ctx, lang, id, query, props)
+	case "sparql":
+		if query == "" {
+			return ErrorResult("query is required")
+		}
+		output, err = t.sparql(ctx, query)
+	default:
+		return ErrorResult(fmt.Sprintf("unknown action %q (use search, page, entity or sparql)", action))
+	}
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	return &ToolResult{
+		ForLLM:  output,
+		ForUser: output,
+	}
+}
+
+func (t *WikipediaTool) get(ctx context.Context, rawURL, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	// Wikimedia rejects requests without a descriptive User-Agent.
+	req.Header.Set("User-Agent", wikiUserAgent)
+	req.Header.Set("Accept", accept)
+
+	resp, err := withTimeout(t.client, 30*time.Second).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %d - %s", resp.StatusCode, utils.Truncate(strings.TrimSpace(string(body)), 500))
+	}
+	return body, nil
+}
+
+// mediaWiki calls a MediaWiki action API and decodes the JSON response,
+// surfacing API-level errors.
+func (t *WikipediaTool) mediaWiki(ctx context.Context, apiURL string, params url.Values, out interface{}) error {
+	params.Set("format", "json")
+	params.Set("formatversion", "2")
+	body, err := t.get(ctx, apiURL+"?"+params.Encode(), "application/json")
+	if err != nil {
+		return err
+	}
+	var apiErr struct {
+		Error *struct {
+			Code string `json:"code"`
+			Info string `json:"info"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != nil {
+		return fmt.Errorf("API error: %s - %s", apiErr.Error.Code, apiErr.Error.Info)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse response: %v", err)
+	}
+	return nil
+}
+
+func (t *WikipediaTool) apiURL(lang string) string {
+	return strings.ReplaceAll(t.wikipediaURL, "{lang}", lang)
+}
+
+type wikiPage struct {
+	Title     string `json:"title"`
+	Index     int    `json:"index"`
+	Missing   bool   `json:"missing"`
+	Invalid   bool   `json:"invalid"`
+	Extract   string `json:"extract"`
+	FullURL   string `json:"fullurl"`
+	PageProps struct {
+		WikibaseItem   string  `json:"wikibase_item"`
+		Disambiguation *string `json:"disambiguation"`
+	} `json:"pageprops"`
+}
+
+func (t *WikipediaTool) search(ctx context.Context, lang, query string, maxResults int) (string, error) {
+	params := url.Values{
+		"action":      {"query"},
+		"generator":   {"search"},
+		"gsrsearch":   {query},
+		"gsrlimit":    {fmt.Sprint(maxResults)},
+		"prop":        {"extracts|info|pageprops"},
+		"exintro":     {"1"},
+		"explaintext": {"1"},
+		"exlimit":     {"max"},
+		"inprop":      {"url"},
+		"ppprop":      {"wikibase_item|disambiguation"},
+		"redirects":   {"1"},
+	}
+	var resp struct {
+		Query struct {
+			SearchInfo struct {
+				TotalHits  int    `json:"totalhits"`
+				Suggestion string `json:"suggestion"`
+			} `json:"searchinfo"`
+			Pages []wikiPage `json:"pages"`
+		} `json:"query"`
+	}
+	if err := t.mediaWiki(ctx, t.apiURL(lang), params, &resp); err != nil {
+		return "", err
+	}
+	pages := resp.Query.Pages
+	if len(pages) == 0 {
+		msg := fmt.Sprintf("No Wikipedia (%s) articles found for: %s", lang, query)
+		if s := resp.Query.SearchInfo.Suggestion; s != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", s)
+		}
+		return msg, nil
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].Index < pages[j].Index })
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("Wikipedia (%s) results for: %s\n", lang, query))
+	for i, p := range pages {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, p.Title))
+		lines = append(lines, fmt.Sprintf("   URL: %s", p.FullURL))
+		if p.PageProps.WikibaseItem != "" {
+			lines = append(lines, fmt.Sprintf("   Wikidata: %s", p.PageProps.WikibaseItem))
+		}
+		if p.PageProps.Disambiguation != nil {
+			lines = append(lines, "   (disambiguation page)")
+		}
+		if summary := strings.Join(strings.Fields(p.Extract), " "); summary != "" {
+			lines = append(lines, fmt.Sprintf("   Summary: %s", utils.Truncate(summary, 500)))
+		}
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (t *WikipediaTool) page(ctx context.Context, lang, title, section string) (string, error) {
+	params := url.Values{
+		"action":          {"query"},
+		"titles":          {title},
+		"prop":            {"extracts|info|pageprops"},
+		"explaintext":     {"1"},
+		"exsectionformat": {"wiki"},
+		"inprop":          {"url"},
+		"ppprop":          {"wikibase_item|disambiguation"},
+		"redirects":       {"1"},
+	}
+	var resp struct {
+		Query struct {
+			Redirects []struct {
+				From string `json:"from"`
+				To   string `json:"to"`
+			} `json:"redirects"`
+			Pages []wikiPage `json:"pages"`
+		} `json:"query"`
+	}
+	if err := t.mediaWiki(ctx, t.apiURL(lang), params, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Query.Pages) == 0 || resp.Query.Pages[0].Missing || resp.Query.Pages[0].Invalid {
+		return "", fmt.Errorf("no Wikipedia (%s) article titled %q; use action search to find the exact title", lang, title)
+	}
+	p := resp.Query.Pages[0]
+	sections := splitWikiSections(p.Extract)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Wikipedia (%s): %s\n", lang, p.Title)
+	if len(resp.Query.Redirects) > 0 {
+		fmt.Fprintf(&sb, "(redirected from %s)\n", resp.Query.Redirects[0].From)
+	}
+	if p.PageProps.Disambiguation != nil {
+		sb.WriteString("(disambiguation page: pick one of the linked articles below)\n")
+	}
+
+	if section != "" {
+		found := findWikiSection(sections, section)
+		if found == nil {
+			return "", fmt.Errorf("no section %q in %q; sections are: %s", section, p.Title, wikiSectionTitles(sections))
+		}
+		fmt.Fprintf(&sb, "Section: %s %s\n", found[0].Number, found[0].Title)
+		fmt.Fprintf(&sb, "URL: %s%s\n", p.FullURL, wikiAnchor(found[0].Title))
+		if p.PageProps.WikibaseItem != "" {
+			fmt.Fprintf(&sb, "Wikidata: %s\n", p.PageProps.WikibaseItem)
+		}
+		var text strings.Builder
+		for i, s := range found {
+			if i > 0 {
+				fmt.Fprintf(&text, "\n\n%s %s %s\n", strings.Repeat("#", s.Level), s.Number, s.Title)
+			}
+			text.WriteString(s.Text)
+		}
+		body := strings.TrimSpace(text.String())
+		if body == "" {
+			body = "(this section has no text; it may hold only a list, table or references)"
+		}
+		if utf8.RuneCountInString(body) > maxWikiSectionText {
+			body = utils.Truncate(body, maxWikiSectionText) + "\n\n(section truncated; request its subsections for the rest)"
+		}
+		sb.WriteString("\n" + body)
+		return sb.String(), nil
+	}
+
+	fmt.Fprintf(&sb, "URL: %s\n", p.FullURL)
+	if p.PageProps.WikibaseItem != "" {
+		fmt.Fprintf(&sb, "Wikidata: %s\n", p.PageProps.WikibaseItem)
+	}
+	lead := sections[0].Text
+	if utf8.RuneCountInString(lead) > maxWikiSectionText {
+		lead = utils.Truncate(lead, maxWikiSectionText)
+	}
+	sb.WriteString("\n" + lead + "\n")
+	if len(sections) > 1 {
+		sb.WriteString("\nSections (pass section to read one):\n")
+		for _, s := range sections[1:] {
+			fmt.Fprintf(&sb, "%s%s %s", strings.Repeat("  ", s.Level-2), s.Number, s.Title)
+			if n := utf8.RuneCountInString(s.Text); n > 0 {
+				fmt.Fprintf(&sb, " (%d chars)", n)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+func wikiSectionTitles(sections []wikiSection) string {
+	var titles []string
+	for _, s := range sections[1:] {
+		titles = append(titles, s.Number+" "+s.Title)
+	}
+	if len(titles) == 0 {
+		return "(none)"
+	}
+	return strings.Join(titles, ", ")
+}
+
+type wikidataEntity struct {
+	ID           string                              `json:"id"`
+	Missing      *string                             `json:"missing"`
+	Labels       map[string]struct{ Value string }   `json:"labels"`
+	Descriptions map[string]struct{ Value string }   `json:"descriptions"`
+	Aliases      map[string][]struct{ Value string } `json:"aliases"`
+	Sitelinks    map[string]struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+	} `json:"sitelinks"`
+}
+
+// wikidataText returns the value in lang, falling back to English and then to
+// any language.
+func wikidataText(values map[string]struct{ Value string }, lang string) string {
+	for _, l := range []string{lang, "en", "mul"} {
+		if v, ok := values[l]; ok {
+			return v.Value
+		}
+	}
+	for _, v := range values {
+		return v.Value
+	}
+	return ""
+}
+
+func (t *WikipediaTool) entity(ctx context.Context, lang, id, query string, props []string) (string, error) {
+	var others []string
+	if id == "" {
+		var search struct {
+			Search []struct {
+				ID          string `json:"id"`
+				Label       string `json:"label"`
+				Description string `json:"description"`
+			} `json:"search"`
+		}
+		params := url.Values{
+			"action":   {"wbsearchentities"},
+			"search":   {query},
+			"language": {lang},
+			"uselang":  {lang},
+			"type":     {"item"},
+			"limit":    {"5"},
+		}
+		if err := t.mediaWiki(ctx, t.wikidataURL, params, &search); err != nil {
+			return "", err
+		}
+		if len(search.Search) == 0 {
+			return fmt.Sprintf("No Wikidata items found for: %s", query), nil
+		}
+		id = search.Search[0].ID
+		for _, s := range search.Search[1:] {
+			others = append(others, fmt.Sprintf("%s (%s)", s.ID, strings.TrimSpace(s.Label+", "+s.Description)))
+		}
+	}
+
+	var entities struct {
+		Entities map[string]wikidataEntity `json:"entities"`
+	}
+	params := url.Values{
+		"action":     {"wbgetentities"},
+		"ids":        {id},
+		"props":      {"labels|descriptions|aliases|sitelinks/urls"},
+		"languages":  {lang + "|en|mul"},
+		"sitefilter": {lang + "wiki|enwiki"},
+	}
+	if err := t.mediaWiki(ctx, t.wikidataURL, params, &entities); err != nil {
+		return "", err
+	}
+	e, ok := entities.Entities[id]
+	if !ok || e.Missing != nil {
+		return "", fmt.Errorf("no Wikidata item %s", id)
+	}
+
+	values := "?prop wikibase:propertyType ?type .\n  FILTER(?type != wikibase:ExternalId)"
+	if len(props) > 0 {
+		values = "VALUES ?prop { wd:" + strings.Join(props, " wd:") + " }"
+	}
+	sparql := fmt.Sprintf(`SELECT ?prop ?propLabel ?value ?valueLabel WHERE {
+  wd:%s ?claim ?value .
+  ?prop wikibase:directClaim ?claim .
+  %s
+  SERVICE wikibase:label { bd:serviceParam wikibase:language "%s,en,mul" . }
+}
+LIMIT 500`, id, values, lang)
+	results, err := t.runSPARQL(ctx, sparql)
+	if err != nil {
+		return "", err
+	}
+
+	// Group values by property, keeping the order properties first appear.
+	type fact struct {
+		label  string
+		values []string
+	}
+	facts := map[string]*fact{}
+	var order []string
+	for _, b := range results.Results.Bindings {
+		pid := b["prop"].String()
+		f, ok := facts[pid]
+		if !ok {
+			f = &fact{label: b["propLabel"].Value}
+			facts[pid] = f
+			order = append(order, pid)
+		}
+		value := b["value"].String()
+		if label := b["valueLabel"].Value; label != "" && label != value && wikiEntityRe.MatchString(value) {
+			value = fmt.Sprintf("%s (%s)", label, value)
+		}
+		f.values = append(f.values, value)
+	}
+	sort.SliceStable(order, func(i, j int) bool { return propertyNumber(order[i]) < propertyNumber(order[j]) })
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Wikidata %s: %s", id, wikidataText(e.Labels, lang))
+	if d := wikidataText(e.Descriptions, lang); d != "" {
+		fmt.Fprintf(&sb, " - %s", d)
+	}
+	fmt.Fprintf(&sb, "\nURL: https://www.wikidata.org/wiki/%s\n", id)
+	for _, site := range []string{lang + "wiki", "enwiki"} {
+		if link, ok := e.Sitelinks[site]; ok && link.URL != "" {
+			fmt.Fprintf(&sb, "Wikipedia: %s\n", link.URL)
+			break
+		}
+	}
+	if aliases := e.Aliases[lang]; len(aliases) > 0 {
+		var names []string
+		for _, a := range aliases {
+			names = append(names, a.Value)
+		}
+		fmt.Fprintf(&sb, "Also known as: %s\n", strings.Join(names, "; "))
+	}
+	if len(order) == 0 {
+		sb.WriteString("\nNo matching statements.\n")
+	} else {
+		sb.WriteString("\nFacts:\n")
+		for _, pid := range order {
+			f := facts[pid]
+			vals := f.values
+			if len(vals) > 10 {
+				vals = append(vals[:10:10], fmt.Sprintf("and %d more", len(f.values)-10))
+			}
+			fmt.Fprintf(&sb, "- %s (%s): %s\n", f.label, pid, strings.Join(vals, "; "))
+		}
+	}
+	if len(others) > 0 {
+		fmt.Fprintf(&sb, "\nOther matches for %q: %s\n", query, strings.Join(others, ", "))
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// propertyNumber orders property IDs numerically, so P31 comes before P106.
+func propertyNumber(pid string) int {
+	var n int
+	fmt.Sscan(strings.TrimPrefix(pid, "P"), &n)
+	return n
+}
+
+func (t *WikipediaTool) runSPARQL(ctx context.Context, query string) (*sparqlResults, error) {
+	body, err := t.get(ctx, t.sparqlURL+"?"+url.Values{"query": {query}, "format": {"json"}}.Encode(), "application/sparql-results+json")
+	if err != nil {
+		return nil, err
+	}
+	var results sparqlResults
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse SPARQL results: %v", err)
+	}
+	return &results, nil
+}
+
+func (t *WikipediaTool) sparql(ctx context.Context, query string) (string, error) {
+	results, err := t.runSPARQL(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	rows := [][]string{results.Head.Vars}
+	for _, b := range results.Results.Bindings {
+		if len(rows) > maxSPARQLRows {
+			break
+		}
+		row := make([]string, len(results.Head.Vars))
+		for i, v := range results.Head.Vars {
+			if value, ok := b[v]; ok {
+				row[i] = value.String()
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	var sb strings.Builder
+	total := len(results.Results.Bindings)
+	fmt.Fprintf(&sb, "Wikidata query: %d result(s)", total)
+	if total > maxSPARQLRows {
+		fmt.Fprintf(&sb, ", showing the first %d; add LIMIT or filters to narrow it", maxSPARQLRows)
+	}
+	sb.WriteString("\n\n")
+	if total > 0 {
+		sb.WriteString(formatTableMarkdown(calc.NewTable(rows)) + "\n\n")
+	}
+	fmt.Fprintf(&sb, "Source: https://query.wikidata.org/#%s\n", url.PathEscape(query))
+	sb.WriteString("Items link as https://www.wikidata.org/wiki/<ID>.")
+	return sb.String(), nil
+}
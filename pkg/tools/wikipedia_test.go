@@ -0,0 +1,187 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// wikipediaRoute picks the fixture for a Wikipedia, Wikidata or SPARQL
+// request.
+func wikipediaRoute(r *http.Request) string {
+	q := r.URL.Query()
+	switch {
+	case r.URL.Path == "/sparql" && strings.Contains(q.Get("query"), "wikibase:directClaim"):
+		return "entity_sparql.json"
+	case r.URL.Path == "/sparql":
+		return "sparql.json"
+	case r.URL.Path == "/wikidata" && q.Get("action") == "wbsearchentities":
+		return "wbsearchentities.json"
+	case r.URL.Path == "/wikidata" && q.Get("action") == "wbgetentities":
+		return "wbgetentities.json"
+	case strings.HasSuffix(r.URL.Path, "/w/api.php") && q.Get("generator") == "search":
+		return "search.json"
+	case strings.HasSuffix(r.URL.Path, "/w/api.php") && q.Get("titles") == "Lady Lovelace":
+		return "page.json"
+	case strings.HasSuffix(r.URL.Path, "/w/api.php"):
+		return "missing.json"
+	}
+	return ""
+}
+
+func newWikipediaServer(t *testing.T) (*WikipediaTool, *fixtureServer) {
+	t.Helper()
+	server := newFixtureServer(t, "wikipedia", wikipediaRoute)
+	tool := NewWikipediaTool(testOutboundClient(), "")
+	tool.wikipediaURL = server.URL + "/{lang}/w/api.php"
+	tool.wikidataURL = server.URL + "/wikidata"
+	tool.sparqlURL = server.URL + "/sparql"
+	return tool, server
+}
+
+func TestWikipediaTool_Search(t *testing.T) {
+	tool, server := newWikipediaServer(t)
+	result := tool.Execute(context.Background(), map[string]interface{}{"query": "Ada Lovelace", "language": "DE", "max_results": 3.0})
+	if result.IsError {
+		t.Fatalf("Execute: %s", result.ForLLM)
+	}
+	r := server.requests()[0]
+	if r.URL.Path != "/de/w/api.php" || r.URL.Query().Get("gsrsearch") != "Ada Lovelace" || r.URL.Query().Get("gsrlimit") != "3" {
+		t.Errorf("unexpected request: %s", r.URL)
+	}
+	if !strings.HasPrefix(r.Header.Get("User-Agent"), "Summer") {
+		t.Errorf("User-Agent = %q", r.Header.Get("User-Agent"))
+	}
+	for _, want := range []string{
+		"Wikipedia (de) results for: Ada Lovelace\n",
+		"1. Ada Lovelace\n   URL: https://en.wikipedia.org/wiki/Ada_Lovelace\n   Wikidata: Q7259\n   Summary: Augusta Ada King, Countess of Lovelace (née Byron; 10 December 1815 – 27 November 1852) was an English mathematician and writer, chiefly",
+		"2. Ada (programming language)\n",
+		"3. Ada\n   URL: https://en.wikipedia.org/wiki/Ada\n   Wikidata: Q223960\n   (disambiguation page)\n",
+	} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("output missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+
+	if result := tool.Execute(context.Background(), map[string]interface{}{"query": "x", "language": "en/../"}); !result.IsError {
+		t.Error("expected an invalid language to be rejected")
+	}
+}
+
+func TestWikipediaTool_Page(t *testing.T) {
+	tool, _ := newWikipediaServer(t)
+	result := tool.Execute(context.Background(), map[string]interface{}{"action": "page", "title": "Lady Lovelace"})
+	if result.IsError {
+		t.Fatalf("Execute: %s", result.ForLLM)
+	}
+	want := "Wikipedia (en): Ada Lovelace\n(redirected from Lady Lovelace)\nURL: https://en.wikipedia.org/wiki/Ada_Lovelace\nWikidata: Q7259\n\n" +
+		"Augusta Ada King, Countess of Lovelace was an English mathematician and writer.\n\n" +
+		"Sections (pass section to read one):\n1 Biography\n  1.1 Childhood (67 chars)\n  1.2 Adult years (32 chars)\n2 Work (73 chars)\n3 References"
+	if result.ForLLM != want {
+		t.Errorf("got:\n%s\nwant:\n%s", result.ForLLM, want)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"action": "page", "title": "Lady Lovelace", "section": "biography"})
+	for _, want := range []string{
+		"Section: 1 Biography\nURL: https://en.wikipedia.org/wiki/Ada_Lovelace#Biography\n",
+		"### 1.1 Childhood\nLovelace was born 10 December 1815",
+		"### 1.2 Adult years\nIn 1833 she met Charles Babbage.",
+	} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("section output missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+	if strings.Contains(result.ForLLM, "Analytical Engine") {
+		t.Errorf("section output includes the next section:\n%s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"action": "page", "title": "Lady Lovelace", "section": "Legacy"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "sections are: 1 Biography, 1.1 Childhood") {
+		t.Errorf("expected the available sections in the error, got: %s", result.ForLLM)
+	}
+	result = tool.Execute(context.Background(), map[string]interface{}{"action": "page", "title": "Lady Lovelase"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "use action search") {
+		t.Errorf("expected a missing article error, got: %s", result.ForLLM)
+	}
+}
+
+func TestWikipediaTool_Entity(t *testing.T) {
+	tool, server := newWikipediaServer(t)
+	result := tool.Execute(context.Background(), map[string]interface{}{"action": "entity", "query": "Ada Lovelace"})
+	if result.IsError {
+		t.Fatalf("Execute: %s", result.ForLLM)
+	}
+	want := "Wikidata Q7259: Ada Lovelace - English mathematician, considered the first computer programmer (1815–1852)\n" +
+		"URL: https://www.wikidata.org/wiki/Q7259\nWikipedia: https://en.wikipedia.org/wiki/Ada_Lovelace\n" +
+		"Also known as: Augusta Ada King; Countess of Lovelace\n\nFacts:\n" +
+		"- instance of (P31): human (Q5)\n" +
+		"- occupation (P106): mathematician (Q170790); university teacher (Q1622272)\n" +
+		"- date of birth (P569): 1815-12-10\n\n" +
+		"Other matches for \"Ada Lovelace\": Q55630449 (Ada Lovelace, crater on the Moon)"
+	if result.ForLLM != want {
+		t.Errorf("got:\n%s\nwant:\n%s", result.ForLLM, want)
+	}
+
+	server.reset()
+	result = tool.Execute(context.Background(), map[string]interface{}{"action": "entity", "id": "q7259", "properties": []interface{}{"P569", "p106"}})
+	if result.IsError {
+		t.Fatalf("Execute: %s", result.ForLLM)
+	}
+	requests := server.requests()
+	if len(requests) != 2 {
+		t.Fatalf("expected no entity search for an ID, got %d requests", len(requests))
+	}
+	sparql := requests[1].URL.Query().Get("query")
+	if !strings.Contains(sparql, "wd:Q7259 ?claim ?value") || !strings.Contains(sparql, "VALUES ?prop { wd:P569 wd:P106 }") {
+		t.Errorf("unexpected SPARQL:\n%s", sparql)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"action": "entity"},
+		{"action": "entity", "id": "P31"},
+		{"action": "entity", "id": "Q1", "properties": "birthday"},
+	} {
+		if result := tool.Execute(context.Background(), args); !result.IsError {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestWikipediaTool_SPARQL(t *testing.T) {
+	tool, server := newWikipediaServer(t)
+	query := "SELECT ?item ?itemLabel ?born WHERE { ?item wdt:P106 wd:Q170790 . }"
+	result := tool.Execute(context.Background(), map[string]interface{}{"action": "sparql", "query": query})
+	if result.IsError {
+		t.Fatalf("Execute: %s", result.ForLLM)
+	}
+	r := server.requests()[0]
+	if r.URL.Query().Get("query") != query || r.Header.Get("Accept") != "application/sparql-results+json" {
+		t.Errorf("unexpected request: %s (Accept %s)", r.URL, r.Header.Get("Accept"))
+	}
+	for _, want := range []string{
+		"Wikidata query: 2 result(s)\n\n| item | itemLabel | born |\n| --- | --- | --- |\n| Q7259 | Ada Lovelace | 1815-12-10 |\n| Q46633 | Charles Babbage |  |",
+		"Source: https://query.wikidata.org/#" + url.PathEscape(query),
+	} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("output missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+}
+
+func TestSplitWikiSections(t *testing.T) {
+	sections := splitWikiSections("Lead.\n\n== A ==\na\n\n=== A1 ===\n\n==== A1x ====\nx\n\n== B ==\nb")
+	var got []string
+	for _, s := range sections {
+		got = append(got, s.Number+"|"+s.Title+"|"+s.Text)
+	}
+	want := []string{"||Lead.", "1|A|a", "1.1|A1|", "1.1.1|A1x|x", "2|B|b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sections = %q, want %q", got, want)
+	}
+	if found := findWikiSection(sections, "1.1"); len(found) != 2 || found[1].Title != "A1x" {
+		t.Errorf("findWikiSection(1.1) = %+v", found)
+	}
+}
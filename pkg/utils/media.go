@@ -32,6 +32,20 @@ func IsAudioFile(filename, contentType string) bool {
 	return false
 }
 
+// IsDocumentFile checks if a file is a document the agent can extract text
+// from (PDF, Word, Excel, CSV) based on its filename extension.
+func IsDocumentFile(filename string) bool {
+	documentExtensions := []string{".pdf", ".docx", ".xlsx", ".xlsm", ".csv", ".tsv"}
+
+	for _, ext := range documentExtensions {
+		if strings.HasSuffix(strings.ToLower(filename), ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // SanitizeFilename removes potentially dangerous characters from a filename
 // and returns a safe version for local filesystem storage.
 func SanitizeFilename(filename string) string {